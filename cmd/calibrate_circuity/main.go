@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/gobuffalo/pop"
+	"github.com/namsral/flag"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/route"
+)
+
+// Fits the offline planner's circuity factors to road distances planned by HERE, and prints
+// them as a table for pkg/route/offline_planner.go. Samples are planned between randomly
+// picked zip codes, or with -from_cache taken from the zip code distances that the webserver
+// has cached. They are written to -output, which is committed as
+// pkg/route/testdata/circuity_samples.csv, and -input refits the factors from such a file
+// without planning anything.
+func main() {
+	config := flag.String("config-dir", "config", "The location of server config files")
+	env := flag.String("env", "development", "The environment to run in, which configures the database.")
+	input := flag.String("input", "", "A samples file to fit the factors to, instead of planning new samples")
+	output := flag.String("output", "circuity_samples.csv", "Where to write the samples")
+	fromCache := flag.Bool("from_cache", false, "Take the samples from the route distance cache rather than planning them")
+	seed := flag.Int64("seed", 1, "Seed for picking the zip codes to plan between")
+	perArea := flag.Int("per_area", 50, "How many routes to plan from each national area")
+	hereGeoEndpoint := flag.String("here_maps_geocode_endpoint", "", "URL for the HERE maps geocoder endpoint")
+	hereRouteEndpoint := flag.String("here_maps_routing_endpoint", "", "URL for the HERE maps routing endpoint")
+	hereAppID := flag.String("here_maps_app_id", "", "HERE maps App ID for this application")
+	hereAppCode := flag.String("here_maps_app_code", "", "HERE maps App API code")
+	flag.Parse()
+
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		log.Fatalf("Failed to initialize Zap logging due to %v", err)
+	}
+
+	var samples []route.CircuitySample
+	switch {
+	case *input != "":
+		samples, err = readSamples(*input)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case *fromCache:
+		err = pop.AddLookupPaths(*config)
+		if err != nil {
+			log.Fatal(err)
+		}
+		db, err := pop.Connect(*env)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var distances []models.RouteDistance
+		err = db.Where("kind = ? AND provider <> ?", "zip5", "offline").All(&distances)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, distance := range distances {
+			samples = append(samples, route.CircuitySample{
+				SourceZip5:      distance.SourceKey,
+				DestinationZip5: distance.DestinationKey,
+				Miles:           distance.Miles,
+			})
+		}
+	default:
+		planner := route.NewHEREPlanner(logger, *hereGeoEndpoint, *hereRouteEndpoint, *hereAppID, *hereAppCode)
+		for _, pair := range route.CircuitySamplePairs(*seed, *perArea) {
+			miles, err := planner.Zip5TransitDistance(pair[0], pair[1])
+			if err != nil {
+				logger.Warn("Could not plan sample route", zap.String("source_zip5", pair[0]), zap.String("destination_zip5", pair[1]), zap.Error(err))
+				continue
+			}
+			samples = append(samples, route.CircuitySample{SourceZip5: pair[0], DestinationZip5: pair[1], Miles: miles})
+		}
+	}
+
+	if *input == "" {
+		if err := writeSamples(*output, samples); err != nil {
+			log.Fatal(err)
+		}
+		logger.Info("Wrote samples", zap.String("output", *output), zap.Int("samples", len(samples)))
+	}
+
+	factors, counts, err := route.FitCircuityFactors(samples)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("var circuityFactors = [10]float64{")
+	for area, factor := range factors {
+		fmt.Printf("\t%.2f, // %d: %d samples\n", factor, area, counts[area])
+	}
+	fmt.Println("}")
+}
+
+func readSamples(path string) ([]route.CircuitySample, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	// Skip the header
+	if _, err := reader.Read(); err != nil {
+		return nil, err
+	}
+	var samples []route.CircuitySample
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return samples, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		miles, err := strconv.Atoi(record[2])
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, route.CircuitySample{SourceZip5: record[0], DestinationZip5: record[1], Miles: miles})
+	}
+}
+
+func writeSamples(path string, samples []route.CircuitySample) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"source_zip5", "destination_zip5", "miles"})
+	for _, sample := range samples {
+		writer.Write([]string{sample.SourceZip5, sample.DestinationZip5, strconv.Itoa(sample.Miles)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/namsral/flag"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/rateengine"
+	"github.com/transcom/mymove/pkg/route"
+)
+
+// Rates a shipment and records the computation, with every tariff row it read and the
+// mileage the planner returned, as a scenario file. Saved in pkg/rateengine/testdata/scenarios,
+// the scenario is replayed by the rate engine tests whenever the rate math or tariff
+// tables change.
+func main() {
+	config := flag.String("config-dir", "config", "The location of server config files")
+	env := flag.String("env", "development", "The environment to run in, which configures the database.")
+	shipmentID := flag.String("shipment", "", "The shipment to capture")
+	name := flag.String("name", "", "A name for the scenario, which defaults to the shipment ID")
+	output := flag.String("output", "", "Where to write the scenario, which defaults to <shipment>.json")
+	hereGeoEndpoint := flag.String("here_maps_geocode_endpoint", "", "URL for the HERE maps geocoder endpoint")
+	hereRouteEndpoint := flag.String("here_maps_routing_endpoint", "", "URL for the HERE maps routing endpoint")
+	hereAppID := flag.String("here_maps_app_id", "", "HERE maps App ID for this application")
+	hereAppCode := flag.String("here_maps_app_code", "", "HERE maps App API code")
+	flag.Parse()
+
+	if *shipmentID == "" {
+		log.Fatal("Usage: capture_rate_scenario -shipment <29cb984e-c70d-46f0-926d-cd89e07a6ec3>")
+	}
+	id, err := uuid.FromString(*shipmentID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *name == "" {
+		*name = id.String()
+	}
+	if *output == "" {
+		*output = id.String() + ".json"
+	}
+
+	err = pop.AddLookupPaths(*config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := pop.Connect(*env)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		log.Fatalf("Failed to initialize Zap logging due to %v", err)
+	}
+	planner := route.NewHEREPlanner(logger, *hereGeoEndpoint, *hereRouteEndpoint, *hereAppID, *hereAppCode)
+
+	var shipment models.Shipment
+	err = db.Eager(
+		"PickupAddress",
+		"SecondaryPickupAddress",
+		"PartialSITDeliveryAddress",
+		"Move.Orders.NewDutyStation.Address",
+		"ShipmentOffers.TransportationServiceProviderPerformance",
+	).Find(&shipment, id)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	engine := rateengine.NewRateEngine(db, logger, planner)
+	scenario, err := engine.CaptureShipmentScenario(shipment, *name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	data, err := scenario.MarshalIndentedJSON()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Captured shipment %s, with a GCC of %s, to %s\n", id, scenario.Expected.GCC.ToDollarString(), *output)
+}
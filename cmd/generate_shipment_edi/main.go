@@ -5,6 +5,7 @@ import (
 	"github.com/namsral/flag"
 	"github.com/transcom/mymove/pkg/edi/gex"
 	"log"
+	"time"
 
 	"github.com/gobuffalo/pop"
 	"github.com/gofrs/uuid"
@@ -41,6 +42,8 @@ func main() {
 
 	err = db.Eager(
 		"PickupAddress",
+		"SecondaryPickupAddress",
+		"PartialSITDeliveryAddress",
 		"Move.Orders.NewDutyStation.Address",
 		"ServiceMember",
 		"ShipmentOffers.TransportationServiceProviderPerformance",
@@ -64,6 +67,12 @@ func main() {
 
 	engine := rateengine.NewRateEngine(db, logger, planner)
 	for _, shipment := range shipments {
+		// Invoicing fixes the tariff version, so a later re-run gives the invoiced numbers
+		if *sendToGex {
+			if err := shipment.MarkRated(db, time.Now()); err != nil {
+				log.Fatal(err)
+			}
+		}
 		costByShipment, err := engine.HandleRunOnShipment(shipment)
 		if err != nil {
 			log.Fatal(err)
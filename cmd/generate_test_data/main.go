@@ -8,6 +8,7 @@ import (
 	"github.com/transcom/mymove/pkg/models"
 	"go.uber.org/zap"
 
+	"github.com/transcom/mymove/pkg/scanner"
 	"github.com/transcom/mymove/pkg/storage"
 	"github.com/transcom/mymove/pkg/testdatagen"
 	tdgs "github.com/transcom/mymove/pkg/testdatagen/scenario"
@@ -41,7 +42,7 @@ func main() {
 	zap.L().Info("Using filesystem storage backend")
 	fsParams := storage.DefaultFilesystemParams(logger)
 	storer := storage.NewFilesystem(fsParams)
-	loader := uploader.NewUploader(db, logger, storer)
+	loader := uploader.NewUploader(db, logger, storer, scanner.NewNoopScanner())
 
 	if *scenario == 1 {
 		tdgs.RunAwardQueueScenario1(db)
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/namsral/flag"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/internal/pkg/tariff400ngimport"
+)
+
+// Imports the published 400NG rate files into the tariff tables. Each file in the directory
+// is staged and validated, and compared with the current tables. With -promote, valid rates
+// are published as a new version, superseding the current rows with the same keys for the
+// effective dates they cover; without it, nothing is changed.
+func main() {
+	config := flag.String("config-dir", "config", "The location of server config files")
+	env := flag.String("env", "development", "The environment to run in, which configures the database.")
+	dir := flag.String("dir", "", "The directory of rate files, such as linehaul_rates.csv, to import")
+	promote := flag.Bool("promote", false, "Publish the rates if they are valid")
+	diffLimit := flag.Int("diff-limit", 20, "The most changed rows to list for each table")
+	flag.Parse()
+
+	if *dir == "" {
+		log.Fatal("Usage: import_tariff400ng -dir <2019_rates> [-promote]")
+	}
+
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		log.Fatalf("Failed to initialize Zap logging due to %v", err)
+	}
+
+	err = pop.AddLookupPaths(*config)
+	if err != nil {
+		logger.Fatal("Error initializing db connection", zap.Error(err))
+	}
+	db, err := pop.Connect(*env)
+	if err != nil {
+		logger.Fatal("Error initializing db connection", zap.Error(err))
+	}
+
+	importer := tariff400ngimport.NewImporter(db, logger)
+	report, err := importer.Import(*dir, *promote, time.Now())
+	if err != nil {
+		logger.Fatal("Error importing tariff", zap.Error(err))
+	}
+
+	for _, staged := range report.Staged {
+		fmt.Printf("Staged %d rows from %s\n", staged.Rows, staged.Path)
+	}
+	fmt.Println()
+	for _, diff := range report.Diffs {
+		fmt.Print(diff.String(*diffLimit))
+	}
+	fmt.Println()
+
+	if len(report.Problems) > 0 {
+		fmt.Printf("Found %d problems; nothing was published:\n", len(report.Problems))
+		for _, problem := range report.Problems {
+			fmt.Printf("  %s\n", problem)
+		}
+		os.Exit(1)
+	}
+	if report.Promoted {
+		fmt.Println("Published the new rates.")
+	} else {
+		fmt.Println("The rates are valid. Run again with -promote to publish them.")
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/namsral/flag"
+
+	"github.com/transcom/mymove/pkg/auth"
+	"github.com/transcom/mymove/pkg/auth/authentication"
+	"github.com/transcom/mymove/pkg/models"
+)
+
+// Registers an API client that calls the public API on behalf of a TSP user, who must have
+// logged in at least once. The client authenticates with the secret printed, or with the
+// DoD-signed client certificate given with -certificate. The secret can't be shown again.
+func main() {
+	config := flag.String("config-dir", "config", "The location of server config files")
+	env := flag.String("env", "development", "The environment to run in, which configures the database.")
+	name := flag.String("name", "", "The name of the system the client is for")
+	email := flag.String("tsp-user-email", "", "The email of the TSP user the client acts on behalf of")
+	scopes := flag.String("scopes", "", "Comma separated scopes to limit the client to. Defaults to every scope.")
+	certificate := flag.String("certificate", "", "Path to a PEM client certificate to authenticate with instead of a secret")
+	rateLimit := flag.Int("rate-limit", 60, "How many requests the client can make a minute")
+	flag.Parse()
+
+	if *name == "" || *email == "" {
+		log.Fatal("Usage: make_api_client -name <name> -tsp-user-email <user@example.com> [-scopes shipments.view,...] [-certificate <cert.pem>]")
+	}
+
+	clientScopes := auth.APIClientScopes
+	if *scopes != "" {
+		clientScopes = nil
+		for _, scope := range strings.Split(*scopes, ",") {
+			clientScopes = append(clientScopes, auth.Permission(strings.TrimSpace(scope)))
+		}
+	}
+
+	err := pop.AddLookupPaths(*config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := pop.Connect(*env)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tspUser, err := models.FetchTspUserByEmail(db, *email)
+	if err != nil {
+		log.Fatalf("Failed to fetch TSP user %s: %v", *email, err)
+	}
+	if tspUser.UserID == nil {
+		log.Fatalf("TSP user %s must log in before an API client can act on their behalf", *email)
+	}
+
+	client := models.APIClient{
+		Name:               *name,
+		TspUserID:          tspUser.ID,
+		ClientID:           uuid.Must(uuid.NewV4()).String(),
+		RateLimitPerMinute: *rateLimit,
+	}
+	var secret string
+	if *certificate != "" {
+		certPEM, err := ioutil.ReadFile(*certificate)
+		if err != nil {
+			log.Fatal(err)
+		}
+		block, _ := pem.Decode(certPEM)
+		if block == nil {
+			log.Fatalf("No PEM certificate in %s", *certificate)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fingerprint := authentication.CertificateFingerprint(cert)
+		client.CertificateFingerprint = &fingerprint
+	} else {
+		secret = authentication.NewAPIClientSecret()
+		secretHash := auth.SessionKey(secret)
+		client.SecretHash = &secretHash
+	}
+
+	err = db.Transaction(func(tx *pop.Connection) error {
+		verrs, err := tx.ValidateAndCreate(&client)
+		if err != nil {
+			return err
+		}
+		if verrs.HasAny() {
+			return fmt.Errorf("validation errors %v", verrs)
+		}
+		return models.SetAPIClientScopes(tx, client.ID, clientScopes...)
+	})
+	if err != nil {
+		log.Fatalf("Failed to create API client: %v", err)
+	}
+
+	fmt.Printf("client_id: %s\n", client.ClientID)
+	if secret != "" {
+		fmt.Printf("client_secret: %s\n", secret)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	awssession "github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
 	"github.com/gobuffalo/pop"
 	"github.com/gofrs/uuid"
 	"github.com/namsral/flag"
@@ -13,6 +14,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/transcom/mymove/pkg/paperwork"
+	"github.com/transcom/mymove/pkg/scanner"
 	"github.com/transcom/mymove/pkg/storage"
 	"github.com/transcom/mymove/pkg/uploader"
 )
@@ -24,6 +26,10 @@ func main() {
 	s3Bucket := flag.String("aws_s3_bucket_name", "", "S3 bucket used for file storage")
 	s3Region := flag.String("aws_s3_region", "", "AWS region used for S3 file storage")
 	s3KeyNamespace := flag.String("aws_s3_key_namespace", "", "Key prefix for all objects written to S3")
+	encryption := flag.String("storage_encryption", "", "Where master keys come from, either local or kms. Objects are not encrypted if empty.")
+	keyFile := flag.String("storage_encryption_key_file", "", "JSON file of master keys, used when storage_encryption is local")
+	kmsKeyID := flag.String("storage_encryption_kms_key_id", "", "KMS key that wraps data keys, used when storage_encryption is kms")
+	allowPlaintext := flag.Bool("storage_encryption_allow_plaintext", false, "Read objects stored before encryption was enabled")
 	moveID := flag.String("move", "", "The move ID to generate advance paperwork for")
 	build := flag.String("build", "build", "the directory to serve static files from.")
 	flag.Parse()
@@ -70,7 +76,34 @@ func main() {
 		fsParams := storage.DefaultFilesystemParams(logger)
 		storer = storage.NewFilesystem(fsParams)
 	}
-	uploader := uploader.NewUploader(db, logger, storer)
+
+	// Encrypted objects have to be decrypted to be read
+	if *encryption != "" {
+		var keys storage.KeyProvider
+		switch *encryption {
+		case "local":
+			keys, err = storage.NewLocalKeyProviderFromFile(*keyFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+		case "kms":
+			if *kmsKeyID == "" {
+				log.Fatalln(errors.New("Must provide storage_encryption_kms_key_id parameter, exiting"))
+			}
+			session := awssession.Must(awssession.NewSession(&aws.Config{
+				Region: s3Region,
+			}))
+			keys = storage.NewKMSKeyProvider(kms.New(session), *kmsKeyID)
+		default:
+			log.Fatalf("Unknown storage_encryption %s", *encryption)
+		}
+
+		// Objects are read and written directly, so signed URLs aren't needed
+		encryptedStorer := storage.NewEncryptedStorer(storer, keys, storage.NewURLSigner(nil, ""), logger)
+		encryptedStorer.SetAllowPlaintext(*allowPlaintext)
+		storer = encryptedStorer
+	}
+	uploader := uploader.NewUploader(db, logger, storer, scanner.NewNoopScanner())
 	generator, err := paperwork.NewGenerator(db, logger, uploader)
 	if err != nil {
 		log.Fatal(err)
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/namsral/flag"
+
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/paperwork"
+)
+
+// Renders a form definition over its template with a border around every field, to help
+// line up field coordinates. Without a shipment, each field is labelled with its name;
+// with one, the form is filled with the shipment's GBL data.
+func main() {
+	config := flag.String("config-dir", "config", "The location of server config files")
+	env := flag.String("env", "development", "The environment to run in, which configures the database.")
+	definition := flag.String("definition", paperwork.Form1203DefinitionPath, "The form definition to render")
+	shipmentID := flag.String("shipment", "", "A shipment to fill the form with its GBL data")
+	output := flag.String("output", "render-form.pdf", "Where to write the rendered form")
+	flag.Parse()
+
+	layout, err := paperwork.ReadFormLayoutFile(*definition)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	template, err := os.Open(layout.TemplateImagePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer template.Close()
+
+	form, err := paperwork.NewFormFromLayout(template, layout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	form.UseBorders()
+
+	if *shipmentID == "" {
+		err = form.DrawFieldNames()
+	} else {
+		err = drawGBL(&form, layout, *config, *env, *shipmentID)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	if err := form.Output(f); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Rendered %s to %s\n", *definition, *output)
+}
+
+// drawGBL fills the form with a shipment's GBL data
+func drawGBL(form *paperwork.FormFiller, layout paperwork.FormLayout, config string, env string, shipmentID string) error {
+	id, err := uuid.FromString(shipmentID)
+	if err != nil {
+		return err
+	}
+
+	err = pop.AddLookupPaths(config)
+	if err != nil {
+		return err
+	}
+	db, err := pop.Connect(env)
+	if err != nil {
+		return err
+	}
+
+	gbl, err := models.FetchGovBillOfLadingExtractor(db, id)
+	if err != nil {
+		return err
+	}
+	if err := layout.Validate(gbl); err != nil {
+		return err
+	}
+	return form.DrawData(gbl)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/namsral/flag"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/auth"
+	"github.com/transcom/mymove/pkg/auth/authentication"
+	"github.com/transcom/mymove/pkg/models"
+)
+
+// Revokes logged in sessions, logging users out. With -email, every session of the user is
+// revoked, such as when their account is compromised or their access is removed; with -key, a
+// single session is. With -api-client, an API client and its access tokens are revoked. With
+// -delete-expired, sessions, login attempts, API access tokens and cached route distances that
+// have already expired are cleaned up.
+func main() {
+	config := flag.String("config-dir", "config", "The location of server config files")
+	env := flag.String("env", "development", "The environment to run in, which configures the database.")
+	email := flag.String("email", "", "The login.gov email of the user to log out everywhere")
+	key := flag.String("key", "", "The key of a single session to revoke")
+	apiClientID := flag.String("api-client", "", "The client ID of an API client to revoke")
+	deleteExpired := flag.Bool("delete-expired", false, "Delete sessions, login attempts, API access tokens and cached route distances that have expired")
+	flag.Parse()
+
+	if *email == "" && *key == "" && *apiClientID == "" && !*deleteExpired {
+		log.Fatal("Usage: revoke_sessions [-email <user@example.com>] [-key <session key>] [-api-client <client ID>] [-delete-expired]")
+	}
+
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		log.Fatalf("Failed to initialize Zap logging due to %v", err)
+	}
+
+	err = pop.AddLookupPaths(*config)
+	if err != nil {
+		logger.Fatal("Error initializing db connection", zap.Error(err))
+	}
+	db, err := pop.Connect(*env)
+	if err != nil {
+		logger.Fatal("Error initializing db connection", zap.Error(err))
+	}
+
+	store := auth.NewPostgresSessionStore(db)
+
+	if *email != "" {
+		var user models.User
+		err = db.Where("login_gov_email = $1", *email).First(&user)
+		if err != nil {
+			logger.Fatal("Error fetching user", zap.String("email", *email), zap.Error(err))
+		}
+		count, err := store.DeleteForUser(user.ID)
+		if err != nil {
+			logger.Fatal("Error revoking sessions", zap.String("email", *email), zap.Error(err))
+		}
+		logger.Info("Revoked sessions for user", zap.String("email", *email), zap.Int("sessions", count))
+	}
+
+	if *key != "" {
+		err = store.Delete(*key)
+		if err != nil {
+			logger.Fatal("Error revoking session", zap.Error(err))
+		}
+		logger.Info("Revoked session", zap.String("key", *key))
+	}
+
+	if *apiClientID != "" {
+		err = models.RevokeAPIClient(db, *apiClientID, time.Now())
+		if err != nil {
+			logger.Fatal("Error revoking API client", zap.String("client_id", *apiClientID), zap.Error(err))
+		}
+		logger.Info("Revoked API client", zap.String("client_id", *apiClientID))
+	}
+
+	if *deleteExpired {
+		count, err := store.DeleteExpired(time.Now())
+		if err != nil {
+			logger.Fatal("Error deleting expired sessions", zap.Error(err))
+		}
+		logger.Info("Deleted expired sessions", zap.Int("sessions", count))
+
+		count, err = authentication.NewPostgresLoginAttemptStore(db).DeleteExpired(time.Now())
+		if err != nil {
+			logger.Fatal("Error deleting expired login attempts", zap.Error(err))
+		}
+		logger.Info("Deleted expired login attempts", zap.Int("login_attempts", count))
+
+		count, err = models.DeleteExpiredAPIAccessTokens(db, time.Now())
+		if err != nil {
+			logger.Fatal("Error deleting expired API access tokens", zap.Error(err))
+		}
+		logger.Info("Deleted expired API access tokens", zap.Int("api_access_tokens", count))
+
+		count, err = models.DeleteExpiredRouteDistances(db, time.Now())
+		if err != nil {
+			logger.Fatal("Error deleting expired route distances", zap.Error(err))
+		}
+		logger.Info("Deleted expired route distances", zap.Int("route_distances", count))
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"log"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awssession "github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/gobuffalo/pop"
+	"github.com/namsral/flag"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/storage"
+)
+
+const uploadsPerPage = 100
+
+// Rewraps the data key of every stored upload under the current master key, and encrypts
+// any uploads that were stored before encryption was enabled. Run it after making a new
+// master key current, and retire the old key once it succeeds.
+func main() {
+	config := flag.String("config-dir", "config", "The location of server config files")
+	env := flag.String("env", "development", "The environment to run in, which configures the database.")
+	storageBackend := flag.String("storage_backend", "filesystem", "Storage backend to use, either filesystem or s3.")
+	s3Bucket := flag.String("aws_s3_bucket_name", "", "S3 bucket used for file storage")
+	s3Region := flag.String("aws_s3_region", "", "AWS region used for S3 file storage")
+	s3KeyNamespace := flag.String("aws_s3_key_namespace", "", "Key prefix for all objects written to S3")
+	encryption := flag.String("storage_encryption", "local", "Where master keys come from, either local or kms.")
+	keyFile := flag.String("storage_encryption_key_file", "", "JSON file of master keys, used when storage_encryption is local")
+	kmsKeyID := flag.String("storage_encryption_kms_key_id", "", "KMS key to wrap data keys with, used when storage_encryption is kms")
+	flag.Parse()
+
+	// DB connection
+	err := pop.AddLookupPaths(*config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := pop.Connect(*env)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		log.Fatalf("Failed to initialize Zap logging due to %v", err)
+	}
+
+	var storer storage.FileStorer
+	if *storageBackend == "s3" {
+		zap.L().Info("Using s3 storage backend")
+		if len(*s3Bucket) == 0 {
+			log.Fatalln(errors.New("must provide aws_s3_bucket_name parameter, exiting"))
+		}
+		if *s3Region == "" {
+			log.Fatalln(errors.New("Must provide aws_s3_region parameter, exiting"))
+		}
+		if *s3KeyNamespace == "" {
+			log.Fatalln(errors.New("Must provide aws_s3_key_namespace parameter, exiting"))
+		}
+		aws := awssession.Must(awssession.NewSession(&aws.Config{
+			Region: s3Region,
+		}))
+
+		storer = storage.NewS3(*s3Bucket, *s3KeyNamespace, logger, aws)
+	} else {
+		zap.L().Info("Using filesystem storage backend")
+		fsParams := storage.DefaultFilesystemParams(logger)
+		storer = storage.NewFilesystem(fsParams)
+	}
+
+	var keys storage.KeyProvider
+	switch *encryption {
+	case "local":
+		keys, err = storage.NewLocalKeyProviderFromFile(*keyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "kms":
+		if *kmsKeyID == "" {
+			log.Fatalln(errors.New("Must provide storage_encryption_kms_key_id parameter, exiting"))
+		}
+		session := awssession.Must(awssession.NewSession(&aws.Config{
+			Region: s3Region,
+		}))
+		keys = storage.NewKMSKeyProvider(kms.New(session), *kmsKeyID)
+	default:
+		log.Fatalf("Unknown storage_encryption %s", *encryption)
+	}
+
+	// Signed URLs aren't needed to rewrap objects
+	encryptedStorer := storage.NewEncryptedStorer(storer, keys, storage.NewURLSigner(nil, ""), logger)
+
+	// Identical uploads share objects, so each key is only rewrapped once
+	seen := map[string]bool{}
+	rewrapped, failed := 0, 0
+	for page := 1; ; page++ {
+		var uploads models.Uploads
+		err := db.Q().Order("created_at asc").Paginate(page, uploadsPerPage).All(&uploads)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, upload := range uploads {
+			// Quarantined uploads never had their content stored
+			if upload.IsQuarantined() {
+				continue
+			}
+
+			objectKeys := []string{upload.StorageKey}
+			if upload.HasOriginal() {
+				objectKeys = append(objectKeys, *upload.OriginalStorageKey)
+			}
+			for _, key := range objectKeys {
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				changed, err := encryptedStorer.RewrapObject(key)
+				if err != nil {
+					failed++
+					logger.Error("Failed to rewrap object", zap.String("upload_id", upload.ID.String()), zap.String("key", key), zap.Error(err))
+				} else if changed {
+					rewrapped++
+				}
+			}
+		}
+
+		if len(uploads) < uploadsPerPage {
+			break
+		}
+	}
+
+	logger.Info("Finished rotating storage keys",
+		zap.String("current_key_id", keys.CurrentKeyID()),
+		zap.Int("objects", len(seen)),
+		zap.Int("rewrapped", rewrapped),
+		zap.Int("failed", failed))
+	if failed > 0 {
+		log.Fatalf("Failed to rewrap %d objects", failed)
+	}
+}
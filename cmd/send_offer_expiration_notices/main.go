@@ -0,0 +1,83 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awssession "github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ses"
+	"github.com/gobuffalo/pop"
+	"github.com/namsral/flag"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/notifications"
+)
+
+// Warns TSP users about shipment offers they have not responded to and which are about to expire.
+// Meant to be run periodically, e.g. hourly, alongside the award queue.
+func main() {
+	config := flag.String("config-dir", "config", "The location of server config files")
+	env := flag.String("env", "development", "The environment to run in, configures the database, presenetly.")
+	notice := flag.Duration("notice", 12*time.Hour, "How long before an offer expires to warn the TSP")
+	emailBackend := flag.String("email-backend", "local", "Email backend to use, either SES or local")
+	sesRegion := flag.String("aws-ses-region", "", "AWS region used for SES")
+	flag.Parse()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("Failed to initialize Zap logging due to %v", err)
+	}
+
+	err = pop.AddLookupPaths(*config)
+	if err != nil {
+		log.Panic(err)
+	}
+	db, err := pop.Connect(*env)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	var notificationSender notifications.NotificationSender
+	if *emailBackend == "ses" {
+		sesSession, err := awssession.NewSession(&aws.Config{
+			Region: aws.String(*sesRegion),
+		})
+		if err != nil {
+			logger.Fatal("Failed to create a new AWS client config provider", zap.Error(err))
+		}
+		notificationSender = notifications.NewNotificationSender(ses.New(sesSession), logger)
+	} else {
+		notificationSender = notifications.NewStubNotificationSender(logger)
+	}
+
+	now := time.Now()
+	offers, err := models.FetchShipmentOffersNearingExpiration(db, now, *notice)
+	if err != nil {
+		logger.Fatal("Failed to fetch shipment offers nearing expiration", zap.Error(err))
+	}
+
+	for _, offer := range offers {
+		err = notificationSender.SendNotification(
+			notifications.NewShipmentOfferExpiring(db, logger, offer.ID),
+		)
+		if err != nil {
+			logger.Error("Failed to notify TSP of expiring shipment offer",
+				zap.String("shipment_offer_id", offer.ID.String()),
+				zap.Error(err))
+			continue
+		}
+
+		offer.MarkExpirationNotified(now)
+		verrs, err := db.ValidateAndUpdate(&offer)
+		if err != nil || verrs.HasAny() {
+			logger.Error("Failed to record expiration notice on shipment offer",
+				zap.String("shipment_offer_id", offer.ID.String()),
+				zap.Error(err),
+				zap.String("validation_errors", verrs.String()))
+		}
+	}
+
+	logger.Info("Sent shipment offer expiration notices", zap.Int("offer_count", len(offers)))
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"log"
 
+	"github.com/aws/aws-sdk-go/aws"
+	awssession "github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ses"
 	"github.com/gobuffalo/pop"
 	"github.com/namsral/flag"
 	"go.uber.org/zap"
 
 	"github.com/transcom/mymove/pkg/awardqueue"
 	"github.com/transcom/mymove/pkg/logging/hnyzap"
+	"github.com/transcom/mymove/pkg/notifications"
 )
 
 var logger *zap.Logger
@@ -18,6 +22,8 @@ func main() {
 	config := flag.String("config-dir", "config", "The location of server config files")
 	env := flag.String("env", "development", "The environment to run in, configures the database, presenetly.")
 	debugLogging := flag.Bool("debug_logging", false, "log messages at the debug level.")
+	emailBackend := flag.String("email-backend", "local", "Email backend to use, either SES or local")
+	sesRegion := flag.String("aws-ses-region", "", "AWS region used for SES")
 	flag.Parse()
 
 	// Set up logger for the system
@@ -44,7 +50,20 @@ func main() {
 		log.Panic(err)
 	}
 
-	awardQueue := awardqueue.NewAwardQueue(dbConnection, &honeyZapLogger)
+	var notificationSender notifications.NotificationSender
+	if *emailBackend == "ses" {
+		sesSession, err := awssession.NewSession(&aws.Config{
+			Region: aws.String(*sesRegion),
+		})
+		if err != nil {
+			logger.Fatal("Failed to create a new AWS client config provider", zap.Error(err))
+		}
+		notificationSender = notifications.NewNotificationSender(ses.New(sesSession), logger)
+	} else {
+		notificationSender = notifications.NewStubNotificationSender(logger)
+	}
+
+	awardQueue := awardqueue.NewAwardQueue(dbConnection, &honeyZapLogger, notificationSender)
 	err = awardQueue.Run(context.Background())
 	if err != nil {
 		log.Panic(err)
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awssession "github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/gobuffalo/pop"
+	"github.com/namsral/flag"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/scanner"
+	"github.com/transcom/mymove/pkg/storage"
+	"github.com/transcom/mymove/pkg/uploader"
+)
+
+const uploadsPerPage = 100
+
+// Checks that every upload's stored objects exist and still match their checksums, and
+// reports the ones that don't. Exits with a non-zero status if any problems were found.
+func main() {
+	config := flag.String("config-dir", "config", "The location of server config files")
+	env := flag.String("env", "development", "The environment to run in, which configures the database.")
+	storageBackend := flag.String("storage_backend", "filesystem", "Storage backend to use, either filesystem or s3.")
+	s3Bucket := flag.String("aws_s3_bucket_name", "", "S3 bucket used for file storage")
+	s3Region := flag.String("aws_s3_region", "", "AWS region used for S3 file storage")
+	s3KeyNamespace := flag.String("aws_s3_key_namespace", "", "Key prefix for all objects written to S3")
+	encryption := flag.String("storage_encryption", "", "Where master keys come from, either local or kms. Objects are not encrypted if empty.")
+	keyFile := flag.String("storage_encryption_key_file", "", "JSON file of master keys, used when storage_encryption is local")
+	kmsKeyID := flag.String("storage_encryption_kms_key_id", "", "KMS key that wraps data keys, used when storage_encryption is kms")
+	allowPlaintext := flag.Bool("storage_encryption_allow_plaintext", false, "Read objects stored before encryption was enabled")
+	flag.Parse()
+
+	// DB connection
+	err := pop.AddLookupPaths(*config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := pop.Connect(*env)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		log.Fatalf("Failed to initialize Zap logging due to %v", err)
+	}
+
+	var storer storage.FileStorer
+	if *storageBackend == "s3" {
+		zap.L().Info("Using s3 storage backend")
+		if len(*s3Bucket) == 0 {
+			log.Fatalln(errors.New("must provide aws_s3_bucket_name parameter, exiting"))
+		}
+		if *s3Region == "" {
+			log.Fatalln(errors.New("Must provide aws_s3_region parameter, exiting"))
+		}
+		if *s3KeyNamespace == "" {
+			log.Fatalln(errors.New("Must provide aws_s3_key_namespace parameter, exiting"))
+		}
+		aws := awssession.Must(awssession.NewSession(&aws.Config{
+			Region: s3Region,
+		}))
+
+		storer = storage.NewS3(*s3Bucket, *s3KeyNamespace, logger, aws)
+	} else {
+		zap.L().Info("Using filesystem storage backend")
+		fsParams := storage.DefaultFilesystemParams(logger)
+		storer = storage.NewFilesystem(fsParams)
+	}
+
+	// Encrypted objects have to be decrypted to be read
+	if *encryption != "" {
+		var keys storage.KeyProvider
+		switch *encryption {
+		case "local":
+			keys, err = storage.NewLocalKeyProviderFromFile(*keyFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+		case "kms":
+			if *kmsKeyID == "" {
+				log.Fatalln(errors.New("Must provide storage_encryption_kms_key_id parameter, exiting"))
+			}
+			session := awssession.Must(awssession.NewSession(&aws.Config{
+				Region: s3Region,
+			}))
+			keys = storage.NewKMSKeyProvider(kms.New(session), *kmsKeyID)
+		default:
+			log.Fatalf("Unknown storage_encryption %s", *encryption)
+		}
+
+		// Objects are read and written directly, so signed URLs aren't needed
+		encryptedStorer := storage.NewEncryptedStorer(storer, keys, storage.NewURLSigner(nil, ""), logger)
+		encryptedStorer.SetAllowPlaintext(*allowPlaintext)
+		storer = encryptedStorer
+	}
+	up := uploader.NewUploader(db, logger, storer, scanner.NewNoopScanner())
+
+	checked, problems := 0, 0
+	for page := 1; ; page++ {
+		var uploads models.Uploads
+		err := db.Q().Order("created_at asc").Paginate(page, uploadsPerPage).All(&uploads)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, upload := range uploads {
+			// Quarantined uploads never had their content stored
+			if upload.IsQuarantined() {
+				continue
+			}
+			checked++
+
+			if problem := verify(up.Download(&upload)); problem != "" {
+				problems++
+				fmt.Printf("%s\tupload %s\t%s\n", problem, upload.ID, upload.StorageKey)
+			}
+			if upload.HasOriginal() {
+				if problem := verify(up.DownloadOriginal(&upload)); problem != "" {
+					problems++
+					fmt.Printf("%s\toriginal of upload %s\t%s\n", problem, upload.ID, *upload.OriginalStorageKey)
+				}
+			}
+		}
+
+		if len(uploads) < uploadsPerPage {
+			break
+		}
+	}
+
+	fmt.Printf("checked %d uploads, found %d problems\n", checked, problems)
+	if problems > 0 {
+		os.Exit(1)
+	}
+}
+
+// verify reads a downloaded object to its end and describes what's wrong with it, if anything
+func verify(data io.ReadCloser, err error) string {
+	if err != nil {
+		return "MISSING"
+	}
+	defer data.Close()
+
+	_, err = io.Copy(ioutil.Discard, data)
+	if errors.Cause(err) == storage.ErrChecksumMismatch {
+		return "CORRUPTED"
+	} else if err != nil {
+		return "UNREADABLE"
+	}
+	return ""
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"html/template"
 	"io/ioutil"
 	"log"
@@ -11,9 +13,11 @@ import (
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	awssession "github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
 	"github.com/aws/aws-sdk-go/service/ses"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gobuffalo/pop"
@@ -22,8 +26,10 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"github.com/transcom/mymove/pkg/addressverifier"
 	"github.com/transcom/mymove/pkg/auth"
 	"github.com/transcom/mymove/pkg/auth/authentication"
+	"github.com/transcom/mymove/pkg/dpsauth"
 	"github.com/transcom/mymove/pkg/handlers"
 	"github.com/transcom/mymove/pkg/handlers/dpsapi"
 	"github.com/transcom/mymove/pkg/handlers/internalapi"
@@ -32,9 +38,12 @@ import (
 	"github.com/transcom/mymove/pkg/iws"
 	"github.com/transcom/mymove/pkg/logging"
 	"github.com/transcom/mymove/pkg/notifications"
+	"github.com/transcom/mymove/pkg/paperwork"
 	"github.com/transcom/mymove/pkg/route"
+	"github.com/transcom/mymove/pkg/scanner"
 	"github.com/transcom/mymove/pkg/server"
 	"github.com/transcom/mymove/pkg/storage"
+	"github.com/transcom/mymove/pkg/uploader"
 	"go.uber.org/zap"
 	"goji.io"
 	"goji.io/pat"
@@ -112,8 +121,9 @@ func initFlags(flag *pflag.FlagSet) {
 	flag.String("dps-swagger", "swagger/dps.yaml", "The location of the DPS API swagger definition")
 
 	flag.Bool("debug-logging", false, "log messages at the debug level.")
-	flag.String("client-auth-secret-key", "", "Client auth secret JWT key.")
 	flag.Bool("no-session-timeout", false, "whether user sessions should timeout.")
+	flag.Duration("session-idle-timeout", auth.DefaultSessionTimeouts.Idle, "How long user sessions last without being used")
+	flag.Duration("session-absolute-timeout", auth.DefaultSessionTimeouts.Absolute, "How long user sessions last after logging in")
 
 	flag.String("dod-ca-package", "", "Path to PKCS#7 package containing certificates of all DoD root and intermediate CAs")
 	flag.String("move-mil-dod-ca-cert", "", "The DoD CA certificate used to sign the move.mil TLS certificate.")
@@ -134,10 +144,13 @@ func initFlags(flag *pflag.FlagSet) {
 	flag.String("login-gov-tsp-client-id", "", "Client ID registered with login gov.")
 	flag.String("login-gov-hostname", "", "Hostname for communicating with login gov.")
 
-	/* For bing Maps use the following
-	bingMapsEndpoint := flag.String("bing_maps_endpoint", "", "URL for the Bing Maps Truck endpoint to use")
-	bingMapsKey := flag.String("bing_maps_key", "", "Authentication key to use for the Bing Maps endpoint")
-	*/
+	flag.String("route-planner", "here", "Route planner to use for transit distances: here, offline, or fallback to try HERE, then Bing, then offline.")
+	flag.Duration("route-cache-ttl", route.DefaultRouteCacheTTL, "How long to cache distances planned by mapping services. Offline estimates are never cached. Zero disables the cache.")
+	flag.Duration("route-cache-stats-interval", time.Hour, "How often to log the route distance cache's hits and misses. Zero disables the log.")
+
+	// Bing Maps Config, used by the fallback route planner
+	flag.String("bing-maps-endpoint", "", "URL for the Bing Maps Truck endpoint to use")
+	flag.String("bing-maps-key", "", "Authentication key to use for the Bing Maps endpoint")
 
 	// HERE Maps Config
 	flag.String("here-maps-geocode-endpoint", "", "URL for the HERE maps geocoder endpoint")
@@ -145,6 +158,8 @@ func initFlags(flag *pflag.FlagSet) {
 	flag.String("here-maps-app-id", "", "HERE maps App ID for this application")
 	flag.String("here-maps-app-code", "", "HERE maps App API code")
 
+	flag.Bool("address-verification", true, "Standardize and verify addresses before saving them, geocoding them with HERE maps when it is configured.")
+
 	flag.String("storage-backend", "filesystem", "Storage backend to use, either filesystem or s3.")
 	flag.String("email-backend", "local", "Email backend to use, either SES or local")
 	flag.String("aws-s3-bucket-name", "", "S3 bucket used for file storage")
@@ -152,6 +167,22 @@ func initFlags(flag *pflag.FlagSet) {
 	flag.String("aws-s3-key-namespace", "", "Key prefix for all objects written to S3")
 	flag.String("aws-ses-region", "", "AWS region used for SES")
 
+	// Encryption of stored objects
+	flag.String("storage-encryption", "", "Encrypt stored objects with master keys from either local or kms. Objects are not encrypted if empty.")
+	flag.String("storage-encryption-key-file", "", "JSON file of master keys, used when storage-encryption is local")
+	flag.String("storage-encryption-kms-key-id", "", "KMS key that wraps data keys, used when storage-encryption is kms")
+	flag.String("storage-url-secret", "", "Secret used to sign download URLs for encrypted objects")
+	flag.Bool("storage-encryption-allow-plaintext", false, "Serve objects stored before encryption was enabled. Turn off once rotate_storage_keys has encrypted them.")
+
+	// Malware scanning of uploads
+	flag.String("clamav-network", "tcp", "Network used to reach clamd, either tcp or unix")
+	flag.String("clamav-address", "", "Address of clamd (host:port or socket path). Required outside development and test.")
+	flag.String("image-converter-path", "", "Path to ImageMagick's convert, used to accept HEIC and TIFF uploads. They are rejected if empty.")
+
+	// Paperwork jobs
+	flag.Int("paperwork-workers", 1, "The number of workers generating queued paperwork. Queued paperwork is not generated if 0.")
+	flag.Duration("paperwork-job-poll-interval", paperwork.DefaultJobPollInterval, "How often idle paperwork workers check for new jobs")
+
 	// New Relic Config
 	flag.String("new-relic-application-id", "", "App ID for New Relic Browser")
 	flag.String("new-relic-license-key", "", "License key for New Relic Browser")
@@ -164,6 +195,12 @@ func initFlags(flag *pflag.FlagSet) {
 
 	// IWS
 	flag.String("iws-rbs-host", "", "Hostname for the IWS RBS")
+
+	// DPS authentication cookies
+	flag.String("dps-auth-cookie-secret-key", "", "Secret key DPS authentication cookies are signed with")
+	flag.String("dps-auth-cookie-secret-key-id", "1", "ID of the key DPS authentication cookies are signed with, changed whenever the key is rotated")
+	flag.String("dps-auth-cookie-verification-keys", "", "JSON object of the IDs and secrets of previous keys, whose DPS authentication cookies are still accepted while rotating keys")
+	flag.Int("dps-cookie-expires-in-minutes", 240, "How long DPS authentication cookies can be used for")
 }
 
 func initDODCertificates(v *viper.Viper, logger *zap.Logger) ([]server.TLSCert, *x509.CertPool, error) {
@@ -193,13 +230,81 @@ func initDODCertificates(v *viper.Viper, logger *zap.Logger) ([]server.TLSCert,
 	return moveMilCerts, dodCACertPool, nil
 }
 
-func initRoutePlanner(v *viper.Viper, logger *zap.Logger) route.Planner {
-	return route.NewHEREPlanner(
+func initRoutePlanner(v *viper.Viper, db *pop.Connection, logger *zap.Logger) route.Planner {
+	herePlanner := route.NewHEREPlanner(
 		logger,
 		v.GetString("here-maps-geocode-endpoint"),
 		v.GetString("here-maps-routing-endpoint"),
 		v.GetString("here-maps-app-id"),
 		v.GetString("here-maps-app-code"))
+
+	var planner route.Planner
+	switch v.GetString("route-planner") {
+	case "offline":
+		planner = route.NewOfflinePlanner(logger)
+	case "fallback":
+		planners := []route.Planner{herePlanner}
+		if bingMapsKey := v.GetString("bing-maps-key"); bingMapsKey != "" {
+			bingMapsEndpoint := v.GetString("bing-maps-endpoint")
+			planners = append(planners, route.NewBingPlanner(logger, &bingMapsEndpoint, &bingMapsKey))
+		}
+		planners = append(planners, route.NewOfflinePlanner(logger))
+		planner = route.NewFallbackPlanner(logger, planners...)
+	default:
+		planner = herePlanner
+	}
+
+	if ttl := v.GetDuration("route-cache-ttl"); ttl > 0 {
+		cachingPlanner := route.NewCachingPlanner(db, logger, planner, ttl)
+		if interval := v.GetDuration("route-cache-stats-interval"); interval > 0 {
+			go cachingPlanner.LogStats(context.Background(), interval)
+		}
+		return cachingPlanner
+	}
+	return planner
+}
+
+func initStorageKeyProvider(v *viper.Viper) (storage.KeyProvider, error) {
+	switch v.GetString("storage-encryption") {
+	case "local":
+		return storage.NewLocalKeyProviderFromFile(v.GetString("storage-encryption-key-file"))
+	case "kms":
+		kmsKeyID := v.GetString("storage-encryption-kms-key-id")
+		if len(kmsKeyID) == 0 {
+			return nil, errors.New("must provide storage-encryption-kms-key-id parameter")
+		}
+		session, err := awssession.NewSession(&aws.Config{
+			Region: aws.String(v.GetString("aws-s3-region")),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "could not create AWS session")
+		}
+		return storage.NewKMSKeyProvider(kms.New(session), kmsKeyID), nil
+	}
+	return nil, errors.Errorf("unknown storage-encryption %s", v.GetString("storage-encryption"))
+}
+
+func initDPSAuthenticator(v *viper.Viper, db *pop.Connection) (*dpsauth.Authenticator, error) {
+	params := dpsauth.Params{
+		SigningKey: dpsauth.SigningKey{
+			ID:     v.GetString("dps-auth-cookie-secret-key-id"),
+			Secret: []byte(v.GetString("dps-auth-cookie-secret-key")),
+		},
+		Issuer:           v.GetString("http-my-server-name"),
+		Audience:         v.GetString("http-dps-server-name"),
+		CookieExpiration: time.Duration(v.GetInt("dps-cookie-expires-in-minutes")) * time.Minute,
+	}
+	if verificationKeys := v.GetString("dps-auth-cookie-verification-keys"); len(verificationKeys) > 0 {
+		secrets := map[string]string{}
+		err := json.Unmarshal([]byte(verificationKeys), &secrets)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse dps-auth-cookie-verification-keys")
+		}
+		for id, secret := range secrets {
+			params.VerificationKeys = append(params.VerificationKeys, dpsauth.SigningKey{ID: id, Secret: []byte(secret)})
+		}
+	}
+	return dpsauth.NewAuthenticator(params, dpsauth.NewPostgresReplayCache(db))
 }
 
 func initHoneycomb(v *viper.Viper, logger *zap.Logger) bool {
@@ -253,25 +358,25 @@ func main() {
 	// Honeycomb
 	useHoneycomb := initHoneycomb(v, logger)
 
-	clientAuthSecretKey := v.GetString("client-auth-secret-key")
-
 	loginGovCallbackProtocol := v.GetString("login-gov-callback-protocol")
 	loginGovCallbackPort := v.GetInt("login-gov-callback-port")
 	loginGovSecretKey := v.GetString("login-gov-secret-key")
 	loginGovHostname := v.GetString("login-gov-hostname")
 
-	// Assert that our secret keys can be parsed into actual private keys
+	// Assert that our secret key can be parsed into an actual private key
 	// TODO: Store the parsed key in handlers/AppContext instead of parsing every time
 	if _, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(loginGovSecretKey)); err != nil {
 		logger.Fatal("Login.gov private key", zap.Error(err))
 	}
-	if _, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(clientAuthSecretKey)); err != nil {
-		logger.Fatal("Client auth private key", zap.Error(err))
-	}
 	if len(loginGovHostname) == 0 {
 		log.Fatal("Must provide the Login.gov hostname parameter, exiting")
 	}
 
+	// Form layouts are loaded from definition files, so check them before serving anything
+	if err := paperwork.ValidateBundledFormLayouts(); err != nil {
+		logger.Fatal("Invalid form layout", zap.Error(err))
+	}
+
 	//DB connection
 	err = pop.AddLookupPaths(v.GetString("config-dir"))
 	if err != nil {
@@ -302,16 +407,22 @@ func main() {
 	}
 
 	// Session management and authentication middleware
-	noSessionTimeout := v.GetBool("no-session-timeout")
-	sessionCookieMiddleware := auth.SessionCookieMiddleware(logger, clientAuthSecretKey, noSessionTimeout)
+	sessionTimeouts := auth.SessionTimeouts{
+		Idle:     v.GetDuration("session-idle-timeout"),
+		Absolute: v.GetDuration("session-absolute-timeout"),
+	}
+	if v.GetBool("no-session-timeout") {
+		sessionTimeouts = auth.SessionTimeouts{}
+	}
+	sessionManager := auth.NewSessionManager(logger, auth.NewPostgresSessionStore(dbConnection), sessionTimeouts)
+	sessionManager.SetSecureCookies(loginGovCallbackProtocol == "https://")
+	sessionCookieMiddleware := sessionManager.SessionCookieMiddleware
 	appDetectionMiddleware := auth.DetectorMiddleware(logger, myHostname, officeHostname, tspHostname)
 	userAuthMiddleware := authentication.UserAuthMiddleware(logger)
+	permissionsMiddleware := authentication.PermissionsMiddleware(logger, dbConnection)
 
 	handlerContext := handlers.NewHandlerContext(dbConnection, logger)
-	handlerContext.SetCookieSecret(clientAuthSecretKey)
-	if noSessionTimeout {
-		handlerContext.SetNoSessionTimeout()
-	}
+	handlerContext.SetSessionManager(sessionManager)
 
 	if v.GetString("email-backend") == "ses" {
 		// Setup Amazon SES (email) service
@@ -335,10 +446,19 @@ func main() {
 	clientHandler := http.FileServer(http.Dir(build))
 
 	// Get route planner for handlers to calculate transit distances
-	// routePlanner := route.NewBingPlanner(logger, bingMapsEndpoint, bingMapsKey)
-	routePlanner := initRoutePlanner(v, logger)
+	routePlanner := initRoutePlanner(v, dbConnection, logger)
 	handlerContext.SetPlanner(routePlanner)
 
+	if v.GetBool("address-verification") {
+		var geocoder route.Geocoder
+		if geocodeEndpoint := v.GetString("here-maps-geocode-endpoint"); geocodeEndpoint != "" {
+			geocoder = route.NewHEREGeocoder(logger, geocodeEndpoint, v.GetString("here-maps-app-id"), v.GetString("here-maps-app-code"))
+		} else {
+			zap.L().Warn("No here-maps-geocode-endpoint provided, addresses will only be checked against their ZIP code")
+		}
+		handlerContext.SetAddressVerifier(addressverifier.NewVerifier(logger, geocoder))
+	}
+
 	storageBackend := v.GetString("storage-backend")
 
 	var storer storage.FileStorer
@@ -365,7 +485,58 @@ func main() {
 		fsParams := storage.DefaultFilesystemParams(logger)
 		storer = storage.NewFilesystem(fsParams)
 	}
-	handlerContext.SetFileStorer(storer)
+
+	// Encrypted objects can't be read from storage directly, so the server serves them
+	// from signed URLs instead
+	var downloadSigner *storage.URLSigner
+	if len(v.GetString("storage-encryption")) > 0 {
+		keys, err := initStorageKeyProvider(v)
+		if err != nil {
+			logger.Fatal("Could not set up storage encryption", zap.Error(err))
+		}
+		storageURLSecret := v.GetString("storage-url-secret")
+		if len(storageURLSecret) == 0 {
+			log.Fatalln(errors.New("Must provide storage-url-secret parameter when encrypting storage, exiting"))
+		}
+		zap.L().Info("Encrypting stored objects", zap.String("current_key_id", keys.CurrentKeyID()))
+		downloadSigner = storage.NewURLSigner([]byte(storageURLSecret), "/storage")
+		encryptedStorer := storage.NewEncryptedStorer(storer, keys, downloadSigner, logger)
+		if v.GetBool("storage-encryption-allow-plaintext") {
+			zap.L().Warn("Serving stored objects that are not encrypted")
+			encryptedStorer.SetAllowPlaintext(true)
+		}
+		storer = encryptedStorer
+	}
+
+	// Content addressed objects are checked against their key whenever they are read
+	handlerContext.SetFileStorer(storage.NewVerifyingStorer(storer))
+
+	if clamavAddress := v.GetString("clamav-address"); clamavAddress != "" {
+		zap.L().Info("Scanning uploads with ClamAV", zap.String("address", clamavAddress))
+		handlerContext.SetFileScanner(scanner.NewClamAV(scanner.ClamAVParams{
+			Network: v.GetString("clamav-network"),
+			Address: clamavAddress,
+			Logger:  logger,
+		}))
+	} else if env == "development" || env == "test" {
+		zap.L().Warn("No clamav-address provided, uploads will not be scanned for malware")
+		handlerContext.SetFileScanner(scanner.NewNoopScanner())
+	} else {
+		log.Fatalf("clamav-address is required in the %s environment", env)
+	}
+
+	if imageConverterPath := v.GetString("image-converter-path"); imageConverterPath != "" {
+		handlerContext.SetImageConverter(uploader.NewCommandImageConverter(imageConverterPath))
+	} else {
+		zap.L().Info("No image-converter-path provided, HEIC and TIFF uploads will be rejected")
+	}
+
+	// Paperwork that takes too long to generate in a request is queued for these workers
+	for i := 0; i < v.GetInt("paperwork-workers"); i++ {
+		jobUploader := uploader.NewUploader(dbConnection, logger, handlerContext.FileStorer(), handlerContext.FileScanner())
+		worker := paperwork.NewJobWorker(dbConnection, logger, jobUploader, v.GetDuration("paperwork-job-poll-interval"))
+		go worker.Run(context.Background())
+	}
 
 	rbs, err := initRealTimeBrokerService(v, logger)
 	if err != nil {
@@ -373,6 +544,12 @@ func main() {
 	}
 	handlerContext.SetIWSRealTimeBrokerService(*rbs)
 
+	dpsAuthenticator, err := initDPSAuthenticator(v, dbConnection)
+	if err != nil {
+		logger.Fatal("Configuring DPS authentication cookies", zap.Error(err))
+	}
+	handlerContext.SetDPSAuthenticator(dpsAuthenticator)
+
 	// Base routes
 	site := goji.NewMux()
 	// Add middleware: they are evaluated in the reverse order in which they
@@ -421,10 +598,14 @@ func main() {
 	apiMux.Handle(pat.Get("/swagger.yaml"), fileHandler(v.GetString("swagger")))
 	apiMux.Handle(pat.Get("/docs"), fileHandler(path.Join(build, "swagger-ui", "api.html")))
 
+	apiMux.Handle(pat.Post("/oauth/token"), authentication.NewAPIClientTokenHandler(logger, dbConnection))
+
 	externalAPIMux := goji.SubMux()
 	apiMux.Handle(pat.New("/*"), externalAPIMux)
 	externalAPIMux.Use(noCacheMiddleware)
+	externalAPIMux.Use(authentication.APIClientAuthMiddleware(logger, dbConnection, authentication.NewAPIClientRateLimiter()))
 	externalAPIMux.Use(userAuthMiddleware)
+	externalAPIMux.Use(permissionsMiddleware)
 	externalAPIMux.Handle(pat.New("/*"), publicapi.NewPublicAPIHandler(handlerContext))
 
 	internalMux := goji.SubMux()
@@ -436,26 +617,33 @@ func main() {
 	internalAPIMux := goji.SubMux()
 	internalMux.Handle(pat.New("/*"), internalAPIMux)
 	internalAPIMux.Use(userAuthMiddleware)
+	internalAPIMux.Use(permissionsMiddleware)
 	internalAPIMux.Use(noCacheMiddleware)
 	internalAPIMux.Handle(pat.New("/*"), internalapi.NewInternalAPIHandler(handlerContext))
 
 	authContext := authentication.NewAuthContext(logger, loginGovProvider, loginGovCallbackProtocol, loginGovCallbackPort)
+	loginAttempts := authentication.NewPostgresLoginAttemptStore(dbConnection)
 	authMux := goji.SubMux()
 	root.Handle(pat.New("/auth/*"), authMux)
-	authMux.Handle(pat.Get("/login-gov"), authentication.RedirectHandler{Context: authContext})
-	authMux.Handle(pat.Get("/login-gov/callback"), authentication.NewCallbackHandler(authContext, dbConnection, clientAuthSecretKey, noSessionTimeout))
-	authMux.Handle(pat.Get("/logout"), authentication.NewLogoutHandler(authContext, clientAuthSecretKey, noSessionTimeout))
+	authMux.Handle(pat.Get("/login-gov"), authentication.NewRedirectHandler(authContext, loginAttempts))
+	authMux.Handle(pat.Get("/login-gov/callback"), authentication.NewCallbackHandler(authContext, dbConnection, sessionManager, loginAttempts))
+	authMux.Handle(pat.Get("/logout"), authentication.NewLogoutHandler(authContext, sessionManager))
+	authMux.Handle(pat.Post("/logout-everywhere"), authentication.NewLogoutEverywhereHandler(authContext, sessionManager))
+	authMux.Handle(pat.Get("/sessions"), authentication.NewSessionListHandler(authContext, sessionManager))
+	authMux.Handle(pat.Delete("/sessions/:key"), authentication.NewRevokeSessionHandler(authContext, sessionManager))
 
 	if env == "development" || env == "test" {
 		zap.L().Info("Enabling devlocal auth")
 		localAuthMux := goji.SubMux()
 		root.Handle(pat.New("/devlocal-auth/*"), localAuthMux)
 		localAuthMux.Handle(pat.Get("/login"), authentication.NewUserListHandler(authContext, dbConnection))
-		localAuthMux.Handle(pat.Post("/login"), authentication.NewAssignUserHandler(authContext, dbConnection, clientAuthSecretKey, noSessionTimeout))
-		localAuthMux.Handle(pat.Post("/new"), authentication.NewCreateUserHandler(authContext, dbConnection, clientAuthSecretKey, noSessionTimeout))
+		localAuthMux.Handle(pat.Post("/login"), authentication.NewAssignUserHandler(authContext, dbConnection, sessionManager))
+		localAuthMux.Handle(pat.Post("/new"), authentication.NewCreateUserHandler(authContext, dbConnection, sessionManager))
 	}
 
-	if storageBackend == "filesystem" {
+	if downloadSigner != nil {
+		root.Handle(pat.Get("/storage/*"), storage.NewDownloadHandler(handlerContext.FileStorer(), downloadSigner, logger))
+	} else if storageBackend == "filesystem" {
 		// Add a file handler to provide access to files uploaded in development
 		fs := storage.NewFilesystemHandler("tmp")
 		root.Handle(pat.Get("/storage/*"), fs)
@@ -0,0 +1,122 @@
+package tariff400ngimport
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gobuffalo/pop"
+	"github.com/pkg/errors"
+)
+
+// DiffRow is a row that an import adds, removes or changes
+type DiffRow struct {
+	Change string `db:"change"`
+	// Key names the row by its natural key
+	Key string `db:"row_key"`
+	// Changes describes how a changed row's values change
+	Changes string `db:"changes"`
+}
+
+// TableDiff compares a table's staged rows with its current rows. For effective-dated tables,
+// only current rows in the effective dates the staged rows cover, with keys the staged rows
+// have, are compared.
+type TableDiff struct {
+	Table     string
+	Added     int
+	Removed   int
+	Changed   int
+	Unchanged int
+	Rows      []DiffRow
+}
+
+// String summarizes the diff, listing at most limit rows
+func (d TableDiff) String(limit int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d added, %d removed, %d changed, %d unchanged\n", d.Table, d.Added, d.Removed, d.Changed, d.Unchanged)
+	for index, row := range d.Rows {
+		if index == limit {
+			fmt.Fprintf(&b, "  ... and %d more\n", len(d.Rows)-limit)
+			break
+		}
+		switch row.Change {
+		case "added":
+			fmt.Fprintf(&b, "  + %s\n", row.Key)
+		case "removed":
+			fmt.Fprintf(&b, "  - %s\n", row.Key)
+		default:
+			fmt.Fprintf(&b, "  ~ %s: %s\n", row.Key, row.Changes)
+		}
+	}
+	return b.String()
+}
+
+// diffTable compares a table's staged rows with its current rows, matching them by natural key
+func diffTable(tx *pop.Connection, spec tableSpec) (TableDiff, error) {
+	diff := TableDiff{Table: spec.table}
+
+	current := spec.table
+	if spec.versioned {
+		current = fmt.Sprintf(`(SELECT * FROM %[1]s
+			WHERE superseded_at IS NULL
+			AND daterange(effective_date_lower, effective_date_upper) && (%[2]s)
+			%[3]s)`, spec.table, stagedPeriod(spec), stagedKeys(spec, spec.table))
+	}
+
+	var matches, keys, stagedKey, currentKey []string
+	for _, column := range spec.naturalKey() {
+		matches = append(matches, fmt.Sprintf("s.%[1]s IS NOT DISTINCT FROM c.%[1]s", column))
+		keys = append(keys, fmt.Sprintf("'%[1]s=' || COALESCE(r.%[1]s::text, 'NULL')", column))
+		stagedKey = append(stagedKey, "s."+column)
+		currentKey = append(currentKey, "c."+column)
+	}
+	match := strings.Join(matches, " AND ")
+	key := fmt.Sprintf("concat_ws(' ', %s)", strings.Join(keys, ", "))
+
+	var differs []string
+	var changes []string
+	for _, column := range spec.values() {
+		differs = append(differs, fmt.Sprintf("s.%[1]s IS DISTINCT FROM c.%[1]s", column))
+		changes = append(changes, fmt.Sprintf(
+			"CASE WHEN s.%[1]s IS DISTINCT FROM c.%[1]s THEN '%[1]s ' || COALESCE(c.%[1]s::text, 'NULL') || ' -> ' || COALESCE(s.%[1]s::text, 'NULL') END",
+			column))
+	}
+	changed := "FALSE"
+	changeList := "''"
+	if len(differs) > 0 {
+		changed = strings.Join(differs, " OR ")
+		changeList = fmt.Sprintf("concat_ws(', ', %s)", strings.Join(changes, ", "))
+	}
+
+	sql := fmt.Sprintf(`SELECT change, %[1]s AS row_key, changes FROM (
+			SELECT 'added' AS change, %[7]s, '' AS changes FROM %[2]s s
+				WHERE NOT EXISTS (SELECT 1 FROM %[3]s c WHERE %[4]s)
+			UNION ALL
+			SELECT 'removed' AS change, %[8]s, '' AS changes FROM %[3]s c
+				WHERE NOT EXISTS (SELECT 1 FROM %[2]s s WHERE %[4]s)
+			UNION ALL
+			SELECT 'changed' AS change, %[7]s, %[6]s AS changes FROM %[2]s s JOIN %[3]s c ON %[4]s
+				WHERE %[5]s
+		) r
+		ORDER BY row_key, change`, key, spec.stagingTable(), current, match, changed, changeList,
+		strings.Join(stagedKey, ", "), strings.Join(currentKey, ", "))
+	if err := tx.RawQuery(sql).All(&diff.Rows); err != nil {
+		return diff, errors.Wrapf(err, "could not compare the staged rows of %s", spec.table)
+	}
+
+	var staged int
+	if err := tx.RawQuery(fmt.Sprintf("SELECT COUNT(*) FROM %s", spec.stagingTable())).First(&staged); err != nil {
+		return diff, errors.Wrapf(err, "could not count the staged rows of %s", spec.table)
+	}
+	for _, row := range diff.Rows {
+		switch row.Change {
+		case "added":
+			diff.Added++
+		case "removed":
+			diff.Removed++
+		case "changed":
+			diff.Changed++
+		}
+	}
+	diff.Unchanged = staged - diff.Added - diff.Changed
+	return diff, nil
+}
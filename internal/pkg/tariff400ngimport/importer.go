@@ -0,0 +1,312 @@
+package tariff400ngimport
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/tealeg/xlsx"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/models"
+)
+
+// errDryRun rolls back an import that wasn't asked to be promoted
+var errDryRun = errors.New("dry run")
+
+// Importer loads the published 400NG rate files into the tariff tables
+type Importer struct {
+	db     *pop.Connection
+	logger *zap.Logger
+}
+
+// NewImporter returns a new Importer
+func NewImporter(db *pop.Connection, logger *zap.Logger) Importer {
+	return Importer{db: db, logger: logger}
+}
+
+// StagedFile is a rate file that was staged for import
+type StagedFile struct {
+	Table string
+	Path  string
+	Rows  int
+}
+
+// Report describes an import
+type Report struct {
+	Staged []StagedFile
+	// Problems are the validation failures that stop the import from being promoted
+	Problems []string
+	Diffs    []TableDiff
+	// Promoted is true if the staged rows were published
+	Promoted bool
+}
+
+// stagedFile pairs a staged file with the table it is imported into
+type stagedFile struct {
+	spec tableSpec
+	name string
+}
+
+// Import stages every rate file in dir, validates the staged rows and compares them with the
+// current tariff tables. If the rows are valid and promote is set, they are published as of
+// at, superseding the current rows with the same keys for the effective dates they cover, all
+// in one transaction. Files are named after the table they update, such as linehaul_rates.csv, and their first row
+// names the table's columns.
+func (i Importer) Import(dir string, promote bool, at time.Time) (Report, error) {
+	var report Report
+
+	err := i.db.Transaction(func(tx *pop.Connection) error {
+		var staged []stagedFile
+		for _, spec := range tableSpecs {
+			path, err := findFile(dir, spec.file)
+			if err != nil {
+				return err
+			}
+			if path == "" {
+				continue
+			}
+			rows, err := readRows(path)
+			if err != nil {
+				return err
+			}
+			count, err := i.stage(tx, spec, filepath.Base(path), rows)
+			if err != nil {
+				return err
+			}
+			staged = append(staged, stagedFile{spec: spec, name: filepath.Base(path)})
+			report.Staged = append(report.Staged, StagedFile{Table: spec.table, Path: path, Rows: count})
+			i.logger.Info("Staged tariff file", zap.String("path", path), zap.Int("rows", count))
+		}
+		if len(staged) == 0 {
+			return errors.Errorf("found no tariff files in %s", dir)
+		}
+
+		problems, err := validate(tx, staged)
+		if err != nil {
+			return err
+		}
+		report.Problems = problems
+
+		for _, file := range staged {
+			diff, err := diffTable(tx, file.spec)
+			if err != nil {
+				return err
+			}
+			report.Diffs = append(report.Diffs, diff)
+		}
+
+		if !promote || len(report.Problems) > 0 {
+			return errDryRun
+		}
+		for _, file := range staged {
+			if err := promoteTable(tx, file.spec, at); err != nil {
+				return err
+			}
+		}
+		report.Promoted = true
+		return nil
+	})
+	if err == errDryRun {
+		return report, nil
+	}
+	return report, err
+}
+
+// findFile returns the .csv or .xlsx file for a table in dir, or an empty path if there is none
+func findFile(dir string, name string) (string, error) {
+	var found string
+	for _, ext := range []string{".csv", ".xlsx"} {
+		path := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return "", errors.Wrapf(err, "could not read %s", path)
+		}
+		if found != "" {
+			return "", errors.Errorf("found both %s and %s; import one of them", found, path)
+		}
+		found = path
+	}
+	return found, nil
+}
+
+// readRows reads a CSV file, or the first sheet of a spreadsheet, as rows of cells
+func readRows(path string) ([][]string, error) {
+	if filepath.Ext(path) == ".xlsx" {
+		file, err := xlsx.OpenFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not open %s", path)
+		}
+		if len(file.Sheets) == 0 {
+			return nil, errors.Errorf("%s has no sheets", path)
+		}
+		var rows [][]string
+		for _, row := range file.Sheets[0].Rows {
+			var cells []string
+			for _, cell := range row.Cells {
+				cells = append(cells, cell.String())
+			}
+			rows = append(rows, cells)
+		}
+		return rows, nil
+	}
+
+	// #nosec the path is given by the operator running the import
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open %s", path)
+	}
+	defer f.Close()
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read %s", path)
+	}
+	return rows, nil
+}
+
+// stage copies a file's rows into a temporary table shaped like the tariff table, and returns
+// the number of rows staged. Blank cells take the column's default.
+func (i Importer) stage(tx *pop.Connection, spec tableSpec, name string, rows [][]string) (int, error) {
+	if len(rows) == 0 {
+		return 0, errors.Errorf("%s is empty", name)
+	}
+
+	header := map[string]int{}
+	for index, cell := range rows[0] {
+		column := strings.ToLower(strings.TrimSpace(cell))
+		if column == "" {
+			continue
+		}
+		if _, ok := header[column]; ok {
+			return 0, errors.Errorf("%s names column %s twice", name, column)
+		}
+		header[column] = index
+	}
+	for _, column := range spec.columns {
+		if _, ok := header[column]; !ok {
+			return 0, errors.Errorf("%s is missing column %s", name, column)
+		}
+	}
+	if len(header) != len(spec.columns) {
+		return 0, errors.Errorf("%s has columns other than %s", name, strings.Join(spec.columns, ", "))
+	}
+
+	staging := spec.stagingTable()
+	for _, sql := range []string{
+		fmt.Sprintf("CREATE TEMP TABLE %s (LIKE %s INCLUDING DEFAULTS) ON COMMIT DROP", staging, spec.table),
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN source_line integer", staging),
+	} {
+		if err := tx.RawQuery(sql).Exec(); err != nil {
+			return 0, errors.Wrapf(err, "could not create staging table for %s", name)
+		}
+	}
+
+	columns := append([]string{"id", "created_at", "updated_at", "source_line"}, spec.columns...)
+	count := 0
+	for index, row := range rows[1:] {
+		line := index + 2
+		if isBlank(row) {
+			continue
+		}
+
+		values := []string{"$1", "now()", "now()", "$2"}
+		args := []interface{}{uuid.Must(uuid.NewV4()), line}
+		for _, column := range spec.columns {
+			cell := ""
+			if position := header[column]; position < len(row) {
+				cell = strings.TrimSpace(row[position])
+			}
+			if cell == "" {
+				values = append(values, "DEFAULT")
+				continue
+			}
+			args = append(args, cell)
+			values = append(values, fmt.Sprintf("$%d", len(args)))
+		}
+
+		sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", staging, strings.Join(columns, ", "), strings.Join(values, ", "))
+		if err := tx.RawQuery(sql, args...).Exec(); err != nil {
+			return 0, errors.Wrapf(err, "could not stage line %d of %s", line, name)
+		}
+		count++
+	}
+	if count == 0 {
+		return 0, errors.Errorf("%s has no rows", name)
+	}
+	return count, nil
+}
+
+func isBlank(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// promoteTable publishes a table's staged rows. Effective-dated tables supersede the current
+// rows for the effective dates the staged rows cover, with the keys the staged rows have;
+// other tables are replaced.
+func promoteTable(tx *pop.Connection, spec tableSpec, at time.Time) error {
+	staging := spec.stagingTable()
+	columns := strings.Join(append([]string{"id", "created_at", "updated_at"}, spec.columns...), ", ")
+
+	if !spec.versioned {
+		if err := tx.RawQuery(fmt.Sprintf("DELETE FROM %s", spec.table)).Exec(); err != nil {
+			return errors.Wrapf(err, "could not replace %s", spec.table)
+		}
+		sql := fmt.Sprintf("INSERT INTO %[1]s (%[2]s) SELECT %[2]s FROM %[3]s", spec.table, columns, staging)
+		if err := tx.RawQuery(sql).Exec(); err != nil {
+			return errors.Wrapf(err, "could not replace %s", spec.table)
+		}
+		return nil
+	}
+
+	var period effectivePeriod
+	sql := fmt.Sprintf("SELECT MIN(effective_date_lower) AS lower, MAX(effective_date_upper) AS upper FROM %s", staging)
+	if err := tx.RawQuery(sql).First(&period); err != nil {
+		return errors.Wrapf(err, "could not find the effective dates of the staged rows of %s", spec.table)
+	}
+	if err := models.SupersedeTariff400ngRows(tx, spec.table, staging, spec.key, period.Lower, period.Upper, at); err != nil {
+		return err
+	}
+	sql = fmt.Sprintf("INSERT INTO %[1]s (published_at, %[2]s) SELECT $1, %[2]s FROM %[3]s", spec.table, columns, staging)
+	if err := tx.RawQuery(sql, at).Exec(); err != nil {
+		return errors.Wrapf(err, "could not publish %s", spec.table)
+	}
+	return nil
+}
+
+// effectivePeriod is the range of effective dates a table's staged rows cover
+type effectivePeriod struct {
+	Lower time.Time `db:"lower"`
+	Upper time.Time `db:"upper"`
+}
+
+// stagedKeys is a SQL condition that a current row of a table has a key that its staged rows
+// have, or nothing if the table has no key columns
+func stagedKeys(spec tableSpec, alias string) string {
+	if len(spec.key) == 0 {
+		return ""
+	}
+	var matches []string
+	for _, column := range spec.key {
+		matches = append(matches, fmt.Sprintf("s.%[1]s IS NOT DISTINCT FROM %[2]s.%[1]s", column, alias))
+	}
+	return fmt.Sprintf("AND EXISTS (SELECT 1 FROM %s s WHERE %s)", spec.stagingTable(), strings.Join(matches, " AND "))
+}
+
+// stagedPeriod is a SQL expression for the range of effective dates a table's staged rows cover
+func stagedPeriod(spec tableSpec) string {
+	return fmt.Sprintf("SELECT daterange(MIN(effective_date_lower), MAX(effective_date_upper)) FROM %s", spec.stagingTable())
+}
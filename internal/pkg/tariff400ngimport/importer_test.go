@@ -0,0 +1,162 @@
+package tariff400ngimport
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/unit"
+)
+
+type ImporterSuite struct {
+	suite.Suite
+	db     *pop.Connection
+	logger *zap.Logger
+}
+
+func (suite *ImporterSuite) SetupTest() {
+	suite.db.TruncateAll()
+}
+
+func TestImporterSuite(t *testing.T) {
+	configLocation := "../../../config"
+	pop.AddLookupPaths(configLocation)
+	db, err := pop.Connect("test")
+	if err != nil {
+		log.Panic(err)
+	}
+
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		log.Panic(err)
+	}
+
+	hs := &ImporterSuite{
+		db:     db,
+		logger: logger,
+	}
+
+	suite.Run(t, hs)
+}
+
+func (suite *ImporterSuite) TestDryRun() {
+	importer := NewImporter(suite.db, suite.logger)
+	at := time.Date(2019, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	report, err := importer.Import("testdata/valid", false, at)
+	suite.NoError(err)
+	suite.Empty(report.Problems)
+	suite.False(report.Promoted)
+	suite.Len(report.Staged, 5)
+	for _, diff := range report.Diffs {
+		if diff.Table == "tariff400ng_full_pack_rates" {
+			suite.Equal(4, diff.Added)
+			suite.Equal(0, diff.Unchanged)
+		}
+	}
+
+	// Nothing is published without promoting
+	count, err := suite.db.Count(&models.Tariff400ngFullPackRate{})
+	suite.NoError(err)
+	suite.Equal(0, count)
+}
+
+func (suite *ImporterSuite) TestPromote() {
+	importer := NewImporter(suite.db, suite.logger)
+	published := time.Date(2019, time.May, 1, 0, 0, 0, 0, time.UTC)
+	date := time.Date(2019, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	report, err := importer.Import("testdata/valid", true, published)
+	suite.NoError(err)
+	suite.True(report.Promoted)
+
+	rate, err := models.FetchTariff400ngFullPackRate(suite.db, unit.Pound(1500), 1, date, time.Time{})
+	suite.NoError(err)
+	suite.Equal(unit.Cents(6000), rate.RateCents)
+
+	// Blank cells take the column's default
+	itemRate, err := models.FetchTariff400ngItemRate(suite.db, "4A", 1, unit.Pound(1500), date, time.Time{})
+	suite.NoError(err)
+	suite.Nil(itemRate.Schedule)
+	suite.Equal(unit.Cents(7076), itemRate.RateCents)
+
+	// Peak rate adjustments are imported with the rates they adjust
+	adjustment, err := models.FetchTariff400ngPeakRateAdjustment(suite.db, "185A", date, time.Time{})
+	suite.NoError(err)
+	suite.Equal(1.1, adjustment.Multiplier)
+
+	// Importing the same rates again changes nothing
+	report, err = importer.Import("testdata/valid", false, published)
+	suite.NoError(err)
+	for _, diff := range report.Diffs {
+		suite.Equal(0, diff.Added+diff.Removed+diff.Changed, diff.Table)
+	}
+
+	// Promoting them again supersedes the earlier version
+	republished := published.AddDate(0, 0, 7)
+	_, err = importer.Import("testdata/valid", true, republished)
+	suite.NoError(err)
+	superseded, err := suite.db.Where("superseded_at = ?", republished).Count(&models.Tariff400ngFullPackRate{})
+	suite.NoError(err)
+	suite.Equal(4, superseded)
+
+	rate, err = models.FetchTariff400ngFullPackRate(suite.db, unit.Pound(1500), 1, date, published)
+	suite.NoError(err)
+	suite.Equal(published, rate.PublishedAt.UTC())
+	rate, err = models.FetchTariff400ngFullPackRate(suite.db, unit.Pound(1500), 1, date, time.Time{})
+	suite.NoError(err)
+	suite.Equal(republished, rate.PublishedAt.UTC())
+}
+
+func (suite *ImporterSuite) TestPromotePartialFile() {
+	importer := NewImporter(suite.db, suite.logger)
+	published := time.Date(2019, time.May, 1, 0, 0, 0, 0, time.UTC)
+	date := time.Date(2019, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := importer.Import("testdata/valid", true, published)
+	suite.NoError(err)
+
+	// A file with only some of a table's keys compares and supersedes only those keys
+	republished := published.AddDate(0, 0, 7)
+	report, err := importer.Import("testdata/partial", true, republished)
+	suite.NoError(err)
+	suite.True(report.Promoted)
+	suite.Len(report.Diffs, 1)
+	suite.Equal(0, report.Diffs[0].Removed)
+	suite.Equal(1, report.Diffs[0].Changed)
+
+	itemRate, err := models.FetchTariff400ngItemRate(suite.db, "4A", 1, unit.Pound(1500), date, time.Time{})
+	suite.NoError(err)
+	suite.Equal(unit.Cents(7250), itemRate.RateCents)
+	itemRate, err = models.FetchTariff400ngItemRate(suite.db, "17B", 1, unit.Pound(1500), date, time.Time{})
+	suite.NoError(err)
+	suite.Equal(unit.Cents(13943), itemRate.RateCents)
+	suite.Equal(published, itemRate.PublishedAt.UTC())
+}
+
+func (suite *ImporterSuite) TestValidation() {
+	importer := NewImporter(suite.db, suite.logger)
+
+	report, err := importer.Import("testdata/invalid", true, time.Now())
+	suite.NoError(err)
+	suite.False(report.Promoted)
+	suite.Contains(report.Problems, "full_pack_rates.csv line 5: weight_lbs_lower must be less than weight_lbs_upper")
+	suite.Contains(report.Problems, "full_pack_rates.csv lines 2 and 3 overlap")
+	suite.Contains(report.Problems, "full_pack_rates.csv lines 3 and 4 leave a gap between their weight_lbs_upper and weight_lbs_lower")
+	suite.Contains(report.Problems, "zip3 999 is in service area 111, which has no rates")
+
+	count, err := suite.db.Count(&models.Tariff400ngZip3{})
+	suite.NoError(err)
+	suite.Equal(0, count)
+}
+
+func (suite *ImporterSuite) TestMissingColumn() {
+	zip3s := tableSpecs[0]
+	_, err := NewImporter(suite.db, suite.logger).stage(suite.db, zip3s, "zip3s.csv", [][]string{{"zip3", "state"}})
+	suite.EqualError(err, "zip3s.csv is missing column basepoint_city")
+}
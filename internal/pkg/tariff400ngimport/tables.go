@@ -0,0 +1,131 @@
+package tariff400ngimport
+
+// band is a pair of columns holding the lower (inclusive) and upper (exclusive) bounds of a
+// weight, mileage or CWT-miles band
+type band struct {
+	lower string
+	upper string
+}
+
+// tableSpec describes how a published 400NG file maps onto a tariff table
+type tableSpec struct {
+	// table is the tariff table the file is promoted into
+	table string
+	// file is the name of the file, without its .csv or .xlsx extension
+	file string
+	// columns are the columns the file's header row must name
+	columns []string
+	// key columns, with the bands and effective dates, identify a row within a version
+	key []string
+	// bands must neither overlap nor leave gaps for rows with the same key
+	bands []band
+	// versioned tables are effective-dated; rows are superseded rather than replaced
+	versioned bool
+}
+
+var effectiveDates = band{lower: "effective_date_lower", upper: "effective_date_upper"}
+
+// tableSpecs are the tables an import can update, in the order they are staged
+var tableSpecs = []tableSpec{
+	{
+		table:   "tariff400ng_zip3s",
+		file:    "zip3s",
+		columns: []string{"zip3", "basepoint_city", "state", "service_area", "rate_area", "region"},
+		key:     []string{"zip3"},
+	},
+	{
+		table:   "tariff400ng_zip5_rate_areas",
+		file:    "zip5_rate_areas",
+		columns: []string{"zip5", "rate_area"},
+		key:     []string{"zip5"},
+	},
+	{
+		table: "tariff400ng_service_areas",
+		file:  "service_areas",
+		columns: []string{"service_area", "name", "services_schedule", "linehaul_factor", "service_charge_cents",
+			"sit_185a_rate_cents", "sit_185b_rate_cents", "sit_pd_schedule", "effective_date_lower", "effective_date_upper"},
+		key:       []string{"service_area"},
+		versioned: true,
+	},
+	{
+		table: "tariff400ng_linehaul_rates",
+		file:  "linehaul_rates",
+		columns: []string{"type", "distance_miles_lower", "distance_miles_upper", "weight_lbs_lower", "weight_lbs_upper",
+			"rate_cents", "effective_date_lower", "effective_date_upper"},
+		key: []string{"type"},
+		bands: []band{
+			{lower: "distance_miles_lower", upper: "distance_miles_upper"},
+			{lower: "weight_lbs_lower", upper: "weight_lbs_upper"},
+		},
+		versioned: true,
+	},
+	{
+		table:     "tariff400ng_shorthaul_rates",
+		file:      "shorthaul_rates",
+		columns:   []string{"cwt_miles_lower", "cwt_miles_upper", "rate_cents", "effective_date_lower", "effective_date_upper"},
+		bands:     []band{{lower: "cwt_miles_lower", upper: "cwt_miles_upper"}},
+		versioned: true,
+	},
+	{
+		table:     "tariff400ng_full_pack_rates",
+		file:      "full_pack_rates",
+		columns:   []string{"schedule", "weight_lbs_lower", "weight_lbs_upper", "rate_cents", "effective_date_lower", "effective_date_upper"},
+		key:       []string{"schedule"},
+		bands:     []band{{lower: "weight_lbs_lower", upper: "weight_lbs_upper"}},
+		versioned: true,
+	},
+	{
+		table:     "tariff400ng_full_unpack_rates",
+		file:      "full_unpack_rates",
+		columns:   []string{"schedule", "rate_millicents", "effective_date_lower", "effective_date_upper"},
+		key:       []string{"schedule"},
+		versioned: true,
+	},
+	{
+		table:     "tariff400ng_item_rates",
+		file:      "item_rates",
+		columns:   []string{"code", "schedule", "weight_lbs_lower", "weight_lbs_upper", "rate_cents", "effective_date_lower", "effective_date_upper"},
+		key:       []string{"code", "schedule"},
+		bands:     []band{{lower: "weight_lbs_lower", upper: "weight_lbs_upper"}},
+		versioned: true,
+	},
+	{
+		table:     "tariff400ng_peak_rate_adjustments",
+		file:      "peak_rate_adjustments",
+		columns:   []string{"code", "multiplier", "effective_date_lower", "effective_date_upper"},
+		key:       []string{"code"},
+		versioned: true,
+	},
+}
+
+// stagingTable is the temporary table a file is staged in
+func (s tableSpec) stagingTable() string {
+	return "staged_" + s.table
+}
+
+// naturalKey are the columns that identify a row within a version
+func (s tableSpec) naturalKey() []string {
+	columns := append([]string{}, s.key...)
+	for _, b := range s.bands {
+		columns = append(columns, b.lower, b.upper)
+	}
+	if s.versioned {
+		columns = append(columns, effectiveDates.lower, effectiveDates.upper)
+	}
+	return columns
+}
+
+// values are the columns that aren't part of the natural key
+func (s tableSpec) values() []string {
+	natural := map[string]bool{}
+	for _, column := range s.naturalKey() {
+		natural[column] = true
+	}
+	var values []string
+	for _, column := range s.columns {
+		if !natural[column] {
+			values = append(values, column)
+		}
+	}
+	return values
+}
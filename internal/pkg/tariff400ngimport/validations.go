@@ -0,0 +1,204 @@
+package tariff400ngimport
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gobuffalo/pop"
+	"github.com/pkg/errors"
+)
+
+// linePair names two lines of a staged file
+type linePair struct {
+	Line      int `db:"line"`
+	OtherLine int `db:"other_line"`
+}
+
+// zip3ServiceArea is the service area a zip3 is in
+type zip3ServiceArea struct {
+	Zip3        string `db:"zip3"`
+	ServiceArea string `db:"service_area"`
+}
+
+// validate checks the staged files, and returns a description of each problem found
+func validate(tx *pop.Connection, staged []stagedFile) ([]string, error) {
+	var problems []string
+	for _, file := range staged {
+		for _, check := range []func(*pop.Connection, stagedFile) ([]string, error){
+			checkRanges,
+			checkOverlaps,
+			checkGaps,
+			checkPartlySuperseded,
+		} {
+			found, err := check(tx, file)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not validate %s", file.name)
+			}
+			problems = append(problems, found...)
+		}
+	}
+
+	found, err := checkZip3ServiceAreas(tx, staged)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not validate zip3 service areas")
+	}
+	return append(problems, found...), nil
+}
+
+// bandsAndDates are the ranges of a staged row that must be non-empty
+func (s tableSpec) bandsAndDates() []band {
+	if s.versioned {
+		return append(append([]band{}, s.bands...), effectiveDates)
+	}
+	return s.bands
+}
+
+// checkRanges finds rows whose lower bound isn't below their upper bound
+func checkRanges(tx *pop.Connection, file stagedFile) ([]string, error) {
+	var problems []string
+	for _, b := range file.spec.bandsAndDates() {
+		var pairs []linePair
+		sql := fmt.Sprintf("SELECT source_line AS line, source_line AS other_line FROM %s WHERE %s >= %s ORDER BY line",
+			file.spec.stagingTable(), b.lower, b.upper)
+		if err := tx.RawQuery(sql).All(&pairs); err != nil {
+			return nil, err
+		}
+		for _, pair := range pairs {
+			problems = append(problems, fmt.Sprintf("%s line %d: %s must be less than %s", file.name, pair.Line, b.lower, b.upper))
+		}
+	}
+	return problems, nil
+}
+
+// checkOverlaps finds rows with the same key whose effective dates and bands overlap
+func checkOverlaps(tx *pop.Connection, file stagedFile) ([]string, error) {
+	conditions := []string{"a.source_line < b.source_line"}
+	for _, column := range file.spec.key {
+		conditions = append(conditions, fmt.Sprintf("a.%[1]s IS NOT DISTINCT FROM b.%[1]s", column))
+	}
+	for _, b := range file.spec.bandsAndDates() {
+		rangeType := "int4range"
+		if b == effectiveDates {
+			rangeType = "daterange"
+		}
+		conditions = append(conditions, fmt.Sprintf("%[1]s(a.%[2]s, a.%[3]s) && %[1]s(b.%[2]s, b.%[3]s)", rangeType, b.lower, b.upper))
+	}
+
+	var pairs []linePair
+	sql := fmt.Sprintf(`SELECT a.source_line AS line, b.source_line AS other_line
+		FROM %[1]s a JOIN %[1]s b ON %[2]s
+		ORDER BY line, other_line`, file.spec.stagingTable(), strings.Join(conditions, " AND "))
+	if err := tx.RawQuery(sql).All(&pairs); err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, pair := range pairs {
+		problems = append(problems, fmt.Sprintf("%s lines %d and %d overlap", file.name, pair.Line, pair.OtherLine))
+	}
+	return problems, nil
+}
+
+// checkGaps finds bands that don't start where the band below them ends, among rows that
+// share a key, effective dates and other bands
+func checkGaps(tx *pop.Connection, file stagedFile) ([]string, error) {
+	var problems []string
+	for _, b := range file.spec.bands {
+		partition := append([]string{}, file.spec.key...)
+		for _, other := range file.spec.bandsAndDates() {
+			if other != b {
+				partition = append(partition, other.lower, other.upper)
+			}
+		}
+		window := "ORDER BY " + b.lower
+		if len(partition) > 0 {
+			window = "PARTITION BY " + strings.Join(partition, ", ") + " " + window
+		}
+
+		var pairs []linePair
+		sql := fmt.Sprintf(`SELECT previous_line AS line, source_line AS other_line
+			FROM (
+				SELECT source_line, %[1]s,
+					LAG(%[2]s) OVER w AS previous_upper,
+					LAG(source_line) OVER w AS previous_line
+				FROM %[3]s
+				WINDOW w AS (%[4]s)
+			) bands
+			WHERE previous_upper < %[1]s
+			ORDER BY line`, b.lower, b.upper, file.spec.stagingTable(), window)
+		if err := tx.RawQuery(sql).All(&pairs); err != nil {
+			return nil, err
+		}
+		for _, pair := range pairs {
+			problems = append(problems, fmt.Sprintf("%s lines %d and %d leave a gap between their %s and %s",
+				file.name, pair.Line, pair.OtherLine, b.upper, b.lower))
+		}
+	}
+	return problems, nil
+}
+
+// checkPartlySuperseded finds current rows that the staged effective dates cover only part of.
+// Promoting would supersede them entirely, leaving the rest of their effective dates unrated.
+func checkPartlySuperseded(tx *pop.Connection, file stagedFile) ([]string, error) {
+	if !file.spec.versioned {
+		return nil, nil
+	}
+
+	var count int
+	sql := fmt.Sprintf(`SELECT COUNT(*)
+		FROM %[1]s, (%[2]s) AS staged(period)
+		WHERE superseded_at IS NULL
+		AND daterange(effective_date_lower, effective_date_upper) && period
+		AND NOT daterange(effective_date_lower, effective_date_upper) <@ period
+		%[3]s`, file.spec.table, stagedPeriod(file.spec), stagedKeys(file.spec, file.spec.table))
+	if err := tx.RawQuery(sql).First(&count); err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	return []string{fmt.Sprintf("%s would supersede %d current %s rows whose effective dates it only partly covers",
+		file.name, count, file.spec.table)}, nil
+}
+
+// checkZip3ServiceAreas finds zip3s in a service area that has no rates. Staged zip3s are
+// checked against the current service areas, and those that are staged, since promoting
+// service areas leaves the ones that aren't staged current.
+func checkZip3ServiceAreas(tx *pop.Connection, staged []stagedFile) ([]string, error) {
+	zip3s := "tariff400ng_zip3s"
+	serviceAreas := "(SELECT * FROM tariff400ng_service_areas WHERE superseded_at IS NULL)"
+	zip3sStaged, serviceAreasStaged := false, false
+	for _, file := range staged {
+		switch file.spec.table {
+		case "tariff400ng_zip3s":
+			zip3s = file.spec.stagingTable()
+			zip3sStaged = true
+		case "tariff400ng_service_areas":
+			serviceAreas = fmt.Sprintf(`(SELECT service_area FROM %s
+				UNION SELECT service_area FROM tariff400ng_service_areas WHERE superseded_at IS NULL)`, file.spec.stagingTable())
+			serviceAreasStaged = true
+		}
+	}
+	if !zip3sStaged && !serviceAreasStaged {
+		return nil, nil
+	}
+
+	var missing []zip3ServiceArea
+	sql := fmt.Sprintf(`SELECT z.zip3, z.service_area
+		FROM %s z
+		WHERE NOT EXISTS (SELECT 1 FROM %s sa WHERE sa.service_area = z.service_area)
+		ORDER BY z.zip3`, zip3s, serviceAreas)
+	if err := tx.RawQuery(sql).All(&missing); err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, m := range missing {
+		problem := fmt.Sprintf("zip3 %s is in service area %s, which has no rates", m.Zip3, m.ServiceArea)
+		if !zip3sStaged {
+			problem = fmt.Sprintf("current zip3 %s is in service area %s, which has no rates", m.Zip3, m.ServiceArea)
+		}
+		problems = append(problems, problem)
+	}
+	return problems, nil
+}
@@ -0,0 +1,13 @@
+ALTER TABLE uploads ADD COLUMN sha256_checksum VARCHAR(255);
+
+CREATE TABLE stored_objects (
+    storage_key VARCHAR(255) PRIMARY KEY,
+    reference_count INTEGER NOT NULL
+);
+
+-- Every existing upload owns its objects. Quarantined uploads never stored any.
+INSERT INTO stored_objects (storage_key, reference_count)
+    SELECT storage_key, 1 FROM uploads WHERE status = 'CLEAN';
+
+INSERT INTO stored_objects (storage_key, reference_count)
+    SELECT original_storage_key, 1 FROM uploads WHERE status = 'CLEAN' AND original_storage_key IS NOT NULL;
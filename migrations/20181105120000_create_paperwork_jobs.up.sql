@@ -0,0 +1,21 @@
+CREATE TABLE paperwork_jobs (
+    id uuid PRIMARY KEY,
+    job_type VARCHAR(255) NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    personally_procured_move_id uuid NOT NULL REFERENCES personally_procured_moves,
+    requested_by_user_id uuid NOT NULL REFERENCES users,
+    doc_types VARCHAR(255),
+    incentive_cents INTEGER,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    run_after TIMESTAMP NOT NULL,
+    last_error TEXT,
+    upload_id uuid REFERENCES uploads,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+-- Workers look for the next job that is ready to run
+CREATE INDEX paperwork_jobs_status_run_after_idx ON paperwork_jobs (status, run_after);
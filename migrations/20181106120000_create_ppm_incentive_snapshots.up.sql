@@ -0,0 +1,48 @@
+CREATE TABLE ppm_incentive_snapshots (
+    id uuid PRIMARY KEY,
+    personally_procured_move_id uuid NOT NULL REFERENCES personally_procured_moves,
+    requested_by_user_id uuid NOT NULL REFERENCES users,
+    source VARCHAR(255) NOT NULL,
+    weight INTEGER NOT NULL,
+    prorate_factor FLOAT NOT NULL,
+    origin_zip VARCHAR(255) NOT NULL,
+    destination_zip VARCHAR(255) NOT NULL,
+    origin_zip3 VARCHAR(3) NOT NULL,
+    destination_zip3 VARCHAR(3) NOT NULL,
+    origin_service_area VARCHAR(255) NOT NULL,
+    destination_service_area VARCHAR(255) NOT NULL,
+    planned_move_date DATE NOT NULL,
+    days_in_sit INTEGER NOT NULL,
+    discount_code_of_service VARCHAR(255) NOT NULL,
+    linehaul_discount FLOAT NOT NULL,
+    sit_discount FLOAT NOT NULL,
+    mileage INTEGER NOT NULL,
+    base_linehaul INTEGER NOT NULL,
+    origin_linehaul_factor INTEGER NOT NULL,
+    destination_linehaul_factor INTEGER NOT NULL,
+    shorthaul_charge INTEGER NOT NULL,
+    linehaul_charge_total INTEGER NOT NULL,
+    origin_service_fee INTEGER NOT NULL,
+    destination_service_fee INTEGER NOT NULL,
+    pack_fee INTEGER NOT NULL,
+    unpack_fee INTEGER NOT NULL,
+    sit_fee INTEGER NOT NULL,
+    sit_max INTEGER NOT NULL,
+    gcc INTEGER NOT NULL,
+    incentive_rate FLOAT NOT NULL,
+    incentive INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX ppm_incentive_snapshots_ppm_id_idx ON ppm_incentive_snapshots (personally_procured_move_id, created_at);
+
+-- Snapshots record what a member was quoted, so they can never be changed
+CREATE OR REPLACE FUNCTION reject_ppm_incentive_snapshot_update()
+RETURNS trigger language plpgsql AS $$
+BEGIN
+    RAISE EXCEPTION 'ppm_incentive_snapshots are immutable';
+END $$;
+
+CREATE TRIGGER ppm_incentive_snapshots_immutable
+    BEFORE UPDATE ON ppm_incentive_snapshots
+    FOR EACH ROW EXECUTE PROCEDURE reject_ppm_incentive_snapshot_update();
@@ -0,0 +1,32 @@
+CREATE TABLE storage_in_transits (
+    id uuid PRIMARY KEY,
+    shipment_id uuid NOT NULL REFERENCES shipments,
+    location VARCHAR(255) NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    warehouse_id VARCHAR(255) NOT NULL,
+    warehouse_name VARCHAR(255) NOT NULL,
+    warehouse_address_id uuid NOT NULL REFERENCES addresses,
+    in_date DATE NOT NULL,
+    out_date DATE,
+    authorized_days INTEGER NOT NULL,
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX storage_in_transits_shipment_id_idx ON storage_in_transits (shipment_id);
+
+CREATE TABLE storage_in_transit_extensions (
+    id uuid PRIMARY KEY,
+    storage_in_transit_id uuid NOT NULL REFERENCES storage_in_transits,
+    requested_days INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    requested_by_user_id uuid NOT NULL REFERENCES users,
+    reviewed_by_office_user_id uuid REFERENCES office_users,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX storage_in_transit_extensions_storage_in_transit_id_idx ON storage_in_transit_extensions (storage_in_transit_id);
@@ -0,0 +1,38 @@
+-- Tariff rows are never updated in place. A row that changes during a rate cycle is superseded
+-- and its replacement published, so a shipment can be rated again against the rows that were
+-- current when it was first rated.
+ALTER TABLE tariff400ng_linehaul_rates
+    ADD COLUMN published_at TIMESTAMP NOT NULL DEFAULT now(),
+    ADD COLUMN superseded_at TIMESTAMP;
+ALTER TABLE tariff400ng_shorthaul_rates
+    ADD COLUMN published_at TIMESTAMP NOT NULL DEFAULT now(),
+    ADD COLUMN superseded_at TIMESTAMP;
+ALTER TABLE tariff400ng_service_areas
+    ADD COLUMN published_at TIMESTAMP NOT NULL DEFAULT now(),
+    ADD COLUMN superseded_at TIMESTAMP;
+ALTER TABLE tariff400ng_full_pack_rates
+    ADD COLUMN published_at TIMESTAMP NOT NULL DEFAULT now(),
+    ADD COLUMN superseded_at TIMESTAMP;
+ALTER TABLE tariff400ng_full_unpack_rates
+    ADD COLUMN published_at TIMESTAMP NOT NULL DEFAULT now(),
+    ADD COLUMN superseded_at TIMESTAMP;
+ALTER TABLE tariff400ng_item_rates
+    ADD COLUMN published_at TIMESTAMP NOT NULL DEFAULT now(),
+    ADD COLUMN superseded_at TIMESTAMP;
+
+CREATE TABLE tariff400ng_peak_rate_adjustments (
+    id uuid PRIMARY KEY,
+    code VARCHAR(255) NOT NULL,
+    multiplier FLOAT NOT NULL,
+    effective_date_lower DATE NOT NULL,
+    effective_date_upper DATE NOT NULL,
+    published_at TIMESTAMP NOT NULL DEFAULT now(),
+    superseded_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX tariff400ng_peak_rate_adjustments_code_idx ON tariff400ng_peak_rate_adjustments (code);
+
+-- The tariff version a shipment is invoiced against is fixed the first time it is rated
+ALTER TABLE shipments ADD COLUMN rated_at TIMESTAMP;
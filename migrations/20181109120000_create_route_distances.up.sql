@@ -0,0 +1,13 @@
+CREATE TABLE route_distances (
+    kind VARCHAR(255) NOT NULL,
+    source_key VARCHAR(1024) NOT NULL,
+    destination_key VARCHAR(1024) NOT NULL,
+    miles INTEGER NOT NULL,
+    provider VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (kind, source_key, destination_key)
+);
+
+CREATE INDEX route_distances_expires_at_idx ON route_distances (expires_at);
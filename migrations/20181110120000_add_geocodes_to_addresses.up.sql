@@ -0,0 +1,5 @@
+ALTER TABLE addresses
+    ADD COLUMN latitude DOUBLE PRECISION,
+    ADD COLUMN longitude DOUBLE PRECISION,
+    ADD COLUMN geocode_match_level VARCHAR(255),
+    ADD COLUMN geocode_relevance DOUBLE PRECISION;
@@ -0,0 +1,63 @@
+-- Roles grant users the permissions checked by the API handlers
+CREATE TABLE roles (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE role_permissions (
+    role_id UUID NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    permission VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (role_id, permission)
+);
+
+CREATE TABLE users_roles (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role_id UUID NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX users_roles_role_id_idx ON users_roles (role_id);
+
+INSERT INTO roles (id, name, description, created_at, updated_at) VALUES
+    ('0895e296-ca27-41b5-8b5d-12f7d653897f', 'ppm_office_approver', 'Approves moves, PPMs and reimbursements', now(), now()),
+    ('b360c496-7f7d-4ad0-8bd1-ac2fae5cd4c5', 'hhg_office_approver', 'Approves, completes and invoices HHG shipments', now(), now()),
+    ('8644ee4e-255e-41ca-8916-982d3bfd1e82', 'tsp_dispatcher', 'Accepts and moves the shipments awarded to a TSP', now(), now()),
+    ('1e85b416-cc56-4890-a9cf-26f73ebe682a', 'tsp_admin', 'Dispatches shipments and manages the service agents of a TSP', now(), now()),
+    ('9d24e580-ae09-4c52-8c28-a39aaeddcc33', 'auditor', 'Views moves and shipments without changing them', now(), now());
+
+INSERT INTO role_permissions (role_id, permission, created_at) VALUES
+    ('0895e296-ca27-41b5-8b5d-12f7d653897f', 'move_queues.view', now()),
+    ('0895e296-ca27-41b5-8b5d-12f7d653897f', 'moves.view', now()),
+    ('0895e296-ca27-41b5-8b5d-12f7d653897f', 'shipments.view', now()),
+    ('0895e296-ca27-41b5-8b5d-12f7d653897f', 'ppms.approve', now()),
+    ('b360c496-7f7d-4ad0-8bd1-ac2fae5cd4c5', 'move_queues.view', now()),
+    ('b360c496-7f7d-4ad0-8bd1-ac2fae5cd4c5', 'moves.view', now()),
+    ('b360c496-7f7d-4ad0-8bd1-ac2fae5cd4c5', 'shipments.view', now()),
+    ('b360c496-7f7d-4ad0-8bd1-ac2fae5cd4c5', 'shipments.edit', now()),
+    ('b360c496-7f7d-4ad0-8bd1-ac2fae5cd4c5', 'hhgs.approve', now()),
+    ('8644ee4e-255e-41ca-8916-982d3bfd1e82', 'shipments.view', now()),
+    ('8644ee4e-255e-41ca-8916-982d3bfd1e82', 'shipments.edit', now()),
+    ('8644ee4e-255e-41ca-8916-982d3bfd1e82', 'shipments.dispatch', now()),
+    ('1e85b416-cc56-4890-a9cf-26f73ebe682a', 'shipments.view', now()),
+    ('1e85b416-cc56-4890-a9cf-26f73ebe682a', 'shipments.edit', now()),
+    ('1e85b416-cc56-4890-a9cf-26f73ebe682a', 'shipments.dispatch', now()),
+    ('1e85b416-cc56-4890-a9cf-26f73ebe682a', 'service_agents.manage', now()),
+    ('9d24e580-ae09-4c52-8c28-a39aaeddcc33', 'move_queues.view', now()),
+    ('9d24e580-ae09-4c52-8c28-a39aaeddcc33', 'moves.view', now()),
+    ('9d24e580-ae09-4c52-8c28-a39aaeddcc33', 'shipments.view', now());
+
+-- Office and TSP users keep everything they could do before roles
+INSERT INTO users_roles (user_id, role_id, created_at)
+    SELECT office_users.user_id, roles.id, now()
+    FROM office_users, roles
+    WHERE office_users.user_id IS NOT NULL AND roles.name IN ('ppm_office_approver', 'hhg_office_approver');
+
+INSERT INTO users_roles (user_id, role_id, created_at)
+    SELECT tsp_users.user_id, roles.id, now()
+    FROM tsp_users, roles
+    WHERE tsp_users.user_id IS NOT NULL AND roles.name = 'tsp_admin';
@@ -0,0 +1,17 @@
+-- Logged in sessions are kept on the server so that they can be revoked. The session cookie
+-- holds a random ID; only its SHA-256 hash is stored here, as the key.
+CREATE TABLE user_sessions (
+    key VARCHAR(64) PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    data JSONB NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    remote_addr VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    last_seen_at TIMESTAMP NOT NULL,
+    idle_expires_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX user_sessions_user_id_idx ON user_sessions (user_id);
+CREATE INDEX user_sessions_idle_expires_at_idx ON user_sessions (idle_expires_at);
+CREATE INDEX user_sessions_expires_at_idx ON user_sessions (expires_at);
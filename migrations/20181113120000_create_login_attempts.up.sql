@@ -0,0 +1,11 @@
+-- The state and nonce of each login to login.gov, which its callback is checked against. The
+-- login attempt cookie holds a random ID; only its SHA-256 hash is stored here, as the key.
+CREATE TABLE login_attempts (
+    key VARCHAR(64) PRIMARY KEY,
+    state VARCHAR(255) NOT NULL,
+    nonce VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX login_attempts_expires_at_idx ON login_attempts (expires_at);
@@ -0,0 +1,9 @@
+-- The IDs of DPS authentication cookies that have been used, so that each can only be used
+-- once. Rows are only needed until the cookie expires.
+CREATE TABLE dps_cookie_uses (
+    token_id VARCHAR(255) PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX dps_cookie_uses_expires_at_idx ON dps_cookie_uses (expires_at);
@@ -0,0 +1,36 @@
+-- API clients are TSP systems that call the public API without logging in to login.gov. Each
+-- acts on behalf of a TSP user, and authenticates with a client secret or a client certificate.
+-- Like session IDs, only the SHA-256 hashes of secrets and access tokens are stored.
+CREATE TABLE api_clients (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    tsp_user_id UUID NOT NULL REFERENCES tsp_users (id),
+    client_id VARCHAR(255) NOT NULL UNIQUE,
+    secret_hash VARCHAR(64),
+    certificate_fingerprint VARCHAR(64) UNIQUE,
+    rate_limit_per_minute INTEGER NOT NULL,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX api_clients_tsp_user_id_idx ON api_clients (tsp_user_id);
+
+-- Scopes limit a client to the public API operations that need those permissions
+CREATE TABLE api_client_scopes (
+    api_client_id UUID NOT NULL REFERENCES api_clients (id) ON DELETE CASCADE,
+    scope VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (api_client_id, scope)
+);
+
+CREATE TABLE api_access_tokens (
+    key VARCHAR(64) PRIMARY KEY,
+    api_client_id UUID NOT NULL REFERENCES api_clients (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX api_access_tokens_api_client_id_idx ON api_access_tokens (api_client_id);
+CREATE INDEX api_access_tokens_expires_at_idx ON api_access_tokens (expires_at);
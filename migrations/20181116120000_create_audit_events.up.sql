@@ -0,0 +1,22 @@
+-- Audit events record each change in status of a move, or of its shipments, PPMs, documents and
+-- reimbursements: who made it, when, what changed and why. Events are only ever inserted.
+CREATE TABLE audit_events (
+    id UUID PRIMARY KEY,
+    actor_type VARCHAR(255) NOT NULL,
+    user_id UUID REFERENCES users (id),
+    api_client_id UUID REFERENCES api_clients (id),
+    actor_name VARCHAR(255) NOT NULL,
+    entity_type VARCHAR(255) NOT NULL,
+    entity_id UUID NOT NULL,
+    move_id UUID REFERENCES moves (id),
+    event VARCHAR(255) NOT NULL,
+    old_values JSONB NOT NULL DEFAULT '{}',
+    new_values JSONB NOT NULL DEFAULT '{}',
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX audit_events_move_id_created_at_idx ON audit_events (move_id, created_at);
+CREATE INDEX audit_events_entity_idx ON audit_events (entity_type, entity_id);
+CREATE INDEX audit_events_user_id_idx ON audit_events (user_id);
+CREATE INDEX audit_events_created_at_idx ON audit_events (created_at);
@@ -0,0 +1,10 @@
+-- Peak rate adjustments for the 2018 peak rate cycle. The rate engine multiplies these items'
+-- charges during the peak rate cycle; items without a row here aren't adjusted. Later cycles are
+-- published with cmd/import_tariff400ng, from peak_rate_adjustments.csv.
+INSERT INTO tariff400ng_peak_rate_adjustments (id, created_at, updated_at, code, multiplier, effective_date_lower, effective_date_upper) VALUES ('3d1a2a6c-2b0e-4b7e-9f0e-6a4f0c1d5e01', now(), now(), 'LHS', 1.1, '2018-05-15', '2018-10-01');
+INSERT INTO tariff400ng_peak_rate_adjustments (id, created_at, updated_at, code, multiplier, effective_date_lower, effective_date_upper) VALUES ('3d1a2a6c-2b0e-4b7e-9f0e-6a4f0c1d5e02', now(), now(), '105A', 1.1, '2018-05-15', '2018-10-01');
+INSERT INTO tariff400ng_peak_rate_adjustments (id, created_at, updated_at, code, multiplier, effective_date_lower, effective_date_upper) VALUES ('3d1a2a6c-2b0e-4b7e-9f0e-6a4f0c1d5e03', now(), now(), '105C', 1.1, '2018-05-15', '2018-10-01');
+INSERT INTO tariff400ng_peak_rate_adjustments (id, created_at, updated_at, code, multiplier, effective_date_lower, effective_date_upper) VALUES ('3d1a2a6c-2b0e-4b7e-9f0e-6a4f0c1d5e04', now(), now(), '135A', 1.1, '2018-05-15', '2018-10-01');
+INSERT INTO tariff400ng_peak_rate_adjustments (id, created_at, updated_at, code, multiplier, effective_date_lower, effective_date_upper) VALUES ('3d1a2a6c-2b0e-4b7e-9f0e-6a4f0c1d5e05', now(), now(), '135B', 1.1, '2018-05-15', '2018-10-01');
+INSERT INTO tariff400ng_peak_rate_adjustments (id, created_at, updated_at, code, multiplier, effective_date_lower, effective_date_upper) VALUES ('3d1a2a6c-2b0e-4b7e-9f0e-6a4f0c1d5e06', now(), now(), '185A', 1.1, '2018-05-15', '2018-10-01');
+INSERT INTO tariff400ng_peak_rate_adjustments (id, created_at, updated_at, code, multiplier, effective_date_lower, effective_date_upper) VALUES ('3d1a2a6c-2b0e-4b7e-9f0e-6a4f0c1d5e07', now(), now(), '185B', 1.1, '2018-05-15', '2018-10-01');
@@ -0,0 +1,5 @@
+-- Office users need moves.edit to change a service member's records on their behalf.
+-- The auditor role is left without it, so that auditors can't edit moves.
+INSERT INTO role_permissions (role_id, permission, created_at) VALUES
+    ('0895e296-ca27-41b5-8b5d-12f7d653897f', 'moves.edit', now()),
+    ('b360c496-7f7d-4ad0-8bd1-ac2fae5cd4c5', 'moves.edit', now());
@@ -0,0 +1,163 @@
+package addressverifier
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/transcom/mymove/pkg/models"
+)
+
+// streetSuffixes are the USPS abbreviations of common street suffixes, from Publication 28
+// appendix C1. Abbreviations map to themselves so that their punctuation is standardized too.
+var streetSuffixes = map[string]string{
+	"ALLEY": "ALY", "ALY": "ALY",
+	"AVENUE": "AVE", "AVE": "AVE", "AV": "AVE",
+	"BOULEVARD": "BLVD", "BLVD": "BLVD",
+	"CIRCLE": "CIR", "CIR": "CIR",
+	"COURT": "CT", "CT": "CT",
+	"COVE": "CV", "CV": "CV",
+	"CREEK": "CRK", "CRK": "CRK",
+	"CROSSING": "XING", "XING": "XING",
+	"DRIVE": "DR", "DR": "DR",
+	"EXPRESSWAY": "EXPY", "EXPY": "EXPY",
+	"FREEWAY": "FWY", "FWY": "FWY",
+	"HEIGHTS": "HTS", "HTS": "HTS",
+	"HIGHWAY": "HWY", "HWY": "HWY",
+	"HILL": "HL", "HL": "HL",
+	"HOLLOW": "HOLW", "HOLW": "HOLW",
+	"JUNCTION": "JCT", "JCT": "JCT",
+	"LANE": "LN", "LN": "LN",
+	"MOUNTAIN": "MTN", "MTN": "MTN",
+	"PARKWAY": "PKWY", "PKWY": "PKWY",
+	"PLACE": "PL", "PL": "PL",
+	"PLAZA": "PLZ", "PLZ": "PLZ",
+	"POINT": "PT", "PT": "PT",
+	"RIDGE": "RDG", "RDG": "RDG",
+	"ROAD": "RD", "RD": "RD",
+	"ROUTE": "RTE", "RTE": "RTE",
+	"SQUARE": "SQ", "SQ": "SQ",
+	"STREET": "ST", "ST": "ST",
+	"TERRACE": "TER", "TER": "TER",
+	"TRAIL": "TRL", "TRL": "TRL",
+	"TURNPIKE": "TPKE", "TPKE": "TPKE",
+	"VIEW": "VW", "VW": "VW",
+}
+
+// directionals are the USPS abbreviations of the directions that prefix or follow a street name
+var directionals = map[string]string{
+	"NORTH": "N", "N": "N",
+	"SOUTH": "S", "S": "S",
+	"EAST": "E", "E": "E",
+	"WEST": "W", "W": "W",
+	"NORTHEAST": "NE", "NE": "NE",
+	"NORTHWEST": "NW", "NW": "NW",
+	"SOUTHEAST": "SE", "SE": "SE",
+	"SOUTHWEST": "SW", "SW": "SW",
+}
+
+// unitDesignators are the USPS abbreviations of the designators that start a secondary address line
+var unitDesignators = map[string]string{
+	"APARTMENT": "APT", "APT": "APT",
+	"BUILDING": "BLDG", "BLDG": "BLDG",
+	"DEPARTMENT": "DEPT", "DEPT": "DEPT",
+	"FLOOR": "FL", "FL": "FL",
+	"ROOM": "RM", "RM": "RM",
+	"SUITE": "STE", "STE": "STE",
+	"UNIT": "UNIT",
+}
+
+// stateCodes are the USPS codes of the states, territories and military states, by name
+var stateCodes = map[string]string{
+	"ALABAMA": "AL", "ALASKA": "AK", "AMERICAN SAMOA": "AS", "ARIZONA": "AZ", "ARKANSAS": "AR",
+	"CALIFORNIA": "CA", "COLORADO": "CO", "CONNECTICUT": "CT", "DELAWARE": "DE",
+	"DISTRICT OF COLUMBIA": "DC", "FEDERATED STATES OF MICRONESIA": "FM", "FLORIDA": "FL",
+	"GEORGIA": "GA", "GUAM": "GU", "HAWAII": "HI", "IDAHO": "ID", "ILLINOIS": "IL",
+	"INDIANA": "IN", "IOWA": "IA", "KANSAS": "KS", "KENTUCKY": "KY", "LOUISIANA": "LA",
+	"MAINE": "ME", "MARSHALL ISLANDS": "MH", "MARYLAND": "MD", "MASSACHUSETTS": "MA",
+	"MICHIGAN": "MI", "MINNESOTA": "MN", "MISSISSIPPI": "MS", "MISSOURI": "MO", "MONTANA": "MT",
+	"NEBRASKA": "NE", "NEVADA": "NV", "NEW HAMPSHIRE": "NH", "NEW JERSEY": "NJ",
+	"NEW MEXICO": "NM", "NEW YORK": "NY", "NORTH CAROLINA": "NC", "NORTH DAKOTA": "ND",
+	"NORTHERN MARIANA ISLANDS": "MP", "OHIO": "OH", "OKLAHOMA": "OK", "OREGON": "OR",
+	"PALAU": "PW", "PENNSYLVANIA": "PA", "PUERTO RICO": "PR", "RHODE ISLAND": "RI",
+	"SOUTH CAROLINA": "SC", "SOUTH DAKOTA": "SD", "TENNESSEE": "TN", "TEXAS": "TX", "UTAH": "UT",
+	"VERMONT": "VT", "VIRGIN ISLANDS": "VI", "VIRGINIA": "VA", "WASHINGTON": "WA",
+	"WEST VIRGINIA": "WV", "WISCONSIN": "WI", "WYOMING": "WY",
+	"ARMED FORCES AMERICAS": "AA", "ARMED FORCES EUROPE": "AE", "ARMED FORCES PACIFIC": "AP",
+}
+
+var zipPlus4 = regexp.MustCompile(`^(\d{5})-?(\d{4})$`)
+
+// abbreviate replaces a word with its abbreviation, keeping the word's case: an upper case
+// word gets an upper case abbreviation, and any other word a capitalized one
+func abbreviate(word string, abbreviations map[string]string) (string, bool) {
+	abbreviation, ok := abbreviations[strings.ToUpper(strings.TrimSuffix(word, "."))]
+	if !ok {
+		return word, false
+	}
+	if word == strings.ToUpper(word) {
+		return abbreviation, true
+	}
+	return abbreviation[:1] + strings.ToLower(abbreviation[1:]), true
+}
+
+// standardizeStreet abbreviates the suffix and directionals of a street line, and the designator
+// that starts a secondary line, such as "Apartment 2" or "Suite 100"
+func standardizeStreet(line string) string {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return ""
+	}
+
+	if len(words) > 1 {
+		if abbreviation, ok := abbreviate(words[0], unitDesignators); ok {
+			words[0] = abbreviation
+			return strings.Join(words, " ")
+		}
+	}
+
+	// The suffix is the last word, or the last before a trailing directional
+	last := len(words) - 1
+	if abbreviation, ok := abbreviate(words[last], directionals); ok && last > 1 {
+		words[last] = abbreviation
+		last--
+	}
+	// A street must be more than its suffix, as in "100 Court", so a suffix follows a name
+	if last > 1 {
+		words[last], _ = abbreviate(words[last], streetSuffixes)
+	}
+	// A leading directional follows the house number, unless it is the street's name, as in
+	// "1 West St"
+	if last > 2 {
+		words[1], _ = abbreviate(words[1], directionals)
+	}
+	return strings.Join(words, " ")
+}
+
+// Standardize rewrites an address in the USPS standard form: street suffixes, directionals and
+// unit designators are abbreviated, states are given by their USPS code, and ZIP+4 codes are
+// hyphenated. Extra spaces are removed.
+func Standardize(address *models.Address) {
+	address.StreetAddress1 = standardizeStreet(address.StreetAddress1)
+	if address.StreetAddress2 != nil {
+		line := standardizeStreet(*address.StreetAddress2)
+		address.StreetAddress2 = &line
+	}
+	if address.StreetAddress3 != nil {
+		line := standardizeStreet(*address.StreetAddress3)
+		address.StreetAddress3 = &line
+	}
+
+	address.City = strings.Join(strings.Fields(address.City), " ")
+
+	state := strings.ToUpper(strings.Join(strings.Fields(strings.Replace(address.State, ".", "", -1)), " "))
+	if code, ok := stateCodes[state]; ok {
+		state = code
+	}
+	address.State = state
+
+	postalCode := strings.Replace(strings.TrimSpace(address.PostalCode), " ", "", -1)
+	if match := zipPlus4.FindStringSubmatch(postalCode); match != nil {
+		postalCode = match[1] + "-" + match[2]
+	}
+	address.PostalCode = postalCode
+}
@@ -0,0 +1,83 @@
+package addressverifier
+
+import (
+	"testing"
+
+	"github.com/transcom/mymove/pkg/models"
+)
+
+func TestStandardizeStreet(t *testing.T) {
+	lines := map[string]string{
+		"1333 Minna Street":         "1333 Minna St",
+		"1333  MINNA STREET":        "1333 MINNA ST",
+		"100 North Main Avenue":     "100 N Main Ave",
+		"100 Main Ave. Southwest":   "100 Main Ave SW",
+		"1 West Street":             "1 West St",
+		"100 Court":                 "100 Court",
+		"Apartment 2":               "Apt 2",
+		"suite 100":                 "Ste 100",
+		"2 Pennsylvania Avenue Nw.": "2 Pennsylvania Ave NW",
+		"":                          "",
+	}
+	for line, expected := range lines {
+		if actual := standardizeStreet(line); actual != expected {
+			t.Errorf("standardizeStreet(%q) = %q, expected %q", line, actual, expected)
+		}
+	}
+}
+
+func TestStandardize(t *testing.T) {
+	apartment := "apartment 4b"
+	address := models.Address{
+		StreetAddress1: " 1333 Minna  Street ",
+		StreetAddress2: &apartment,
+		City:           "San  Francisco",
+		State:          "California",
+		PostalCode:     "941031234",
+	}
+	Standardize(&address)
+
+	if address.StreetAddress1 != "1333 Minna St" {
+		t.Errorf("StreetAddress1 = %q", address.StreetAddress1)
+	}
+	if *address.StreetAddress2 != "Apt 4b" {
+		t.Errorf("StreetAddress2 = %q", *address.StreetAddress2)
+	}
+	if address.City != "San Francisco" {
+		t.Errorf("City = %q", address.City)
+	}
+	if address.State != "CA" {
+		t.Errorf("State = %q", address.State)
+	}
+	if address.PostalCode != "94103-1234" {
+		t.Errorf("PostalCode = %q", address.PostalCode)
+	}
+
+	address.State = "d.c."
+	Standardize(&address)
+	if address.State != "DC" {
+		t.Errorf("State = %q", address.State)
+	}
+}
+
+func TestStatesForZip5(t *testing.T) {
+	zips := map[string][]string{
+		"94103": {"CA"},
+		"20301": {"DC"},
+		"96799": {"HI", "AS"},
+		"00001": nil,
+		"abc":   nil,
+	}
+	for zip5, expected := range zips {
+		actual := statesForZip5(zip5)
+		if len(actual) != len(expected) {
+			t.Errorf("statesForZip5(%q) = %v, expected %v", zip5, actual, expected)
+			continue
+		}
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Errorf("statesForZip5(%q) = %v, expected %v", zip5, actual, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,222 @@
+// Package addressverifier standardizes addresses and checks that they exist before they are saved.
+package addressverifier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gobuffalo/validate"
+	"github.com/gobuffalo/validate/validators"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/route"
+)
+
+// minVerifiedRelevance is the least relevant geocoder match that verifies an address
+const minVerifiedRelevance = 0.8
+
+// ambiguityMargin is how close in relevance another match must be to the best for an address
+// to be ambiguous
+const ambiguityMargin = 0.05
+
+// zip5MatchLevel is the match level of a geocode taken from the location of the ZIP code
+const zip5MatchLevel = "zip5"
+
+// Verifier is the interface needed to verify an address before it is saved. Verify may
+// standardize the address and record its geocode. It returns validation errors describing
+// anything the user needs to fix.
+type Verifier interface {
+	Verify(address *models.Address) (*validate.Errors, error)
+}
+
+// NoopVerifier accepts every address as it is. It is intended only for use in development and
+// tests to avoid dependency on an external service.
+type NoopVerifier struct{}
+
+// NewNoopVerifier creates a new NoopVerifier
+func NewNoopVerifier() *NoopVerifier {
+	return &NoopVerifier{}
+}
+
+// Verify accepts the address without changing it
+func (v *NoopVerifier) Verify(address *models.Address) (*validate.Errors, error) {
+	return validate.NewErrors(), nil
+}
+
+// verifier checks addresses against the bundled ZIP code data and a geocoder
+type verifier struct {
+	logger   *zap.Logger
+	geocoder route.Geocoder
+}
+
+// NewVerifier creates a Verifier that standardizes addresses, checks that their ZIP code,
+// state and city agree, and asks the geocoder where they are. Without a geocoder, or when it
+// fails, addresses are placed at their ZIP code.
+func NewVerifier(logger *zap.Logger, geocoder route.Geocoder) Verifier {
+	return &verifier{logger: logger, geocoder: geocoder}
+}
+
+func setGeocode(address *models.Address, location route.LatLong, matchLevel string, relevance *float64) {
+	latitude := float64(location.Latitude)
+	longitude := float64(location.Longitude)
+	address.Latitude = &latitude
+	address.Longitude = &longitude
+	address.GeocodeMatchLevel = &matchLevel
+	address.GeocodeRelevance = relevance
+}
+
+func zip5Of(postalCode string) string {
+	if len(postalCode) > 5 {
+		return postalCode[:5]
+	}
+	return postalCode
+}
+
+func (v *verifier) Verify(address *models.Address) (*validate.Errors, error) {
+	verrs := validate.NewErrors()
+	Standardize(address)
+	// Missing fields are left for the address's own validation to report
+	if address.State == "" || address.PostalCode == "" {
+		return verrs, nil
+	}
+
+	zip5 := zip5Of(address.PostalCode)
+	zipLocation, err := route.Zip5ToLatLong(zip5)
+	if err != nil {
+		verrs.Add(validators.GenerateKey("PostalCode"), fmt.Sprintf("%s is not a known ZIP code.", address.PostalCode))
+		return verrs, nil
+	}
+	states := statesForZip5(zip5)
+	inState := false
+	for _, state := range states {
+		inState = inState || state == address.State
+	}
+	if len(states) > 0 && !inState {
+		verrs.Add(validators.GenerateKey("State"), fmt.Sprintf("ZIP code %s is not in %s. Did you mean %s?", zip5, address.State, strings.Join(states, " or ")))
+		return verrs, nil
+	}
+	if address.City != "" && !cityInZip5(address.City, zip5) {
+		verrs.Add(validators.GenerateKey("City"), fmt.Sprintf("%s is not in ZIP code %s. Did you mean %s?", address.City, zip5, strings.Join(citiesForZip5(zip5), " or ")))
+		return verrs, nil
+	}
+	setGeocode(address, zipLocation, zip5MatchLevel, nil)
+
+	if v.geocoder == nil {
+		return verrs, nil
+	}
+	matches, err := v.geocoder.Geocode(address)
+	if err != nil {
+		// The address can still be saved; it is placed at its ZIP code until it is verified again
+		v.logger.Warn("Could not geocode address, using the location of its ZIP code", zap.Error(err), zap.Object("address", address))
+		return verrs, nil
+	}
+
+	if len(matches) == 0 || matches[0].Relevance < minVerifiedRelevance {
+		verrs.Add(validators.GenerateKey("StreetAddress1"), "Could not find this address. Check the street, city and ZIP code.")
+		return verrs, nil
+	}
+	best := matches[0]
+	candidates := []string{best.Label}
+	for _, other := range matches[1:] {
+		if other.Relevance >= best.Relevance-ambiguityMargin &&
+			(zip5Of(other.PostalCode) != zip5Of(best.PostalCode) || !strings.EqualFold(other.City, best.City)) {
+			candidates = append(candidates, other.Label)
+		}
+	}
+	if len(candidates) > 1 {
+		verrs.Add(validators.GenerateKey("StreetAddress1"), fmt.Sprintf("This address matches more than one place: %s. Add more detail to choose one.", strings.Join(candidates, "; ")))
+		return verrs, nil
+	}
+
+	// A ZIP code can have more than one acceptable city name, so the city only has to match
+	// when the ZIP code doesn't
+	if best.PostalCode != "" && zip5Of(best.PostalCode) != zip5 {
+		if strings.EqualFold(best.City, address.City) {
+			verrs.Add(validators.GenerateKey("PostalCode"), fmt.Sprintf("%s is not the ZIP code of this address. Did you mean %s?", address.PostalCode, zip5Of(best.PostalCode)))
+		} else {
+			verrs.Add(validators.GenerateKey("City"), fmt.Sprintf("%s is not in ZIP code %s. Did you mean %s, %s %s?", address.City, zip5, best.City, best.State, zip5Of(best.PostalCode)))
+		}
+		return verrs, nil
+	}
+
+	relevance := best.Relevance
+	setGeocode(address, best.Location, best.MatchLevel, &relevance)
+	return verrs, nil
+}
+
+// VerifyAll verifies each of the named addresses, skipping any that are nil. Validation errors
+// are keyed by the name of the address they are about, as in "pickup_address.postal_code".
+func VerifyAll(v Verifier, addresses map[string]*models.Address) (*validate.Errors, error) {
+	verrs := validate.NewErrors()
+	for name, address := range addresses {
+		if address == nil {
+			continue
+		}
+		addressVerrs, err := v.Verify(address)
+		if err != nil {
+			return verrs, err
+		}
+		for key, messages := range addressVerrs.Errors {
+			for _, message := range messages {
+				verrs.Add(name+"."+key, message)
+			}
+		}
+	}
+	return verrs, nil
+}
+
+// VerifyShipmentAddresses verifies the pickup and delivery addresses of a shipment
+func VerifyShipmentAddresses(v Verifier, shipment *models.Shipment) (*validate.Errors, error) {
+	return VerifyAll(v, ShipmentAddresses(shipment))
+}
+
+// ShipmentAddresses names the pickup and delivery addresses of a shipment
+func ShipmentAddresses(shipment *models.Shipment) map[string]*models.Address {
+	return map[string]*models.Address{
+		"pickup_address":               shipment.PickupAddress,
+		"secondary_pickup_address":     shipment.SecondaryPickupAddress,
+		"delivery_address":             shipment.DeliveryAddress,
+		"partial_sit_delivery_address": shipment.PartialSITDeliveryAddress,
+	}
+}
+
+// CopyAddresses copies each of the named addresses, skipping any that are nil, so that they
+// can be compared with the addresses after a patch changes them in place
+func CopyAddresses(addresses map[string]*models.Address) map[string]models.Address {
+	copies := map[string]models.Address{}
+	for name, address := range addresses {
+		if address != nil {
+			copies[name] = *address
+		}
+	}
+	return copies
+}
+
+// VerifyChanged verifies each of the named addresses that is new, or isn't the same as its copy
+// in previous. Addresses that haven't changed were verified when they were saved, so they
+// aren't verified again.
+func VerifyChanged(v Verifier, previous map[string]models.Address, addresses map[string]*models.Address) (*validate.Errors, error) {
+	changed := map[string]*models.Address{}
+	for name, address := range addresses {
+		if before, ok := previous[name]; ok && address != nil && sameAddress(&before, address) {
+			continue
+		}
+		changed[name] = address
+	}
+	return VerifyAll(v, changed)
+}
+
+// sameAddress returns true if two addresses have the same lines
+func sameAddress(a *models.Address, b *models.Address) bool {
+	sameString := func(x *string, y *string) bool {
+		return (x == nil && y == nil) || (x != nil && y != nil && *x == *y)
+	}
+	return a.StreetAddress1 == b.StreetAddress1 &&
+		sameString(a.StreetAddress2, b.StreetAddress2) &&
+		sameString(a.StreetAddress3, b.StreetAddress3) &&
+		a.City == b.City &&
+		a.State == b.State &&
+		a.PostalCode == b.PostalCode &&
+		sameString(a.Country, b.Country)
+}
@@ -0,0 +1,186 @@
+package addressverifier
+
+import (
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/route"
+)
+
+// fakeGeocoder answers every address with the same matches
+type fakeGeocoder struct {
+	matches []route.GeocodeMatch
+	err     error
+}
+
+func (g fakeGeocoder) Geocode(address *models.Address) ([]route.GeocodeMatch, error) {
+	return g.matches, g.err
+}
+
+func minnaStreet() *models.Address {
+	return &models.Address{
+		StreetAddress1: "1333 Minna Street",
+		City:           "San Francisco",
+		State:          "CA",
+		PostalCode:     "94103",
+	}
+}
+
+func minnaStreetMatch(relevance float64) route.GeocodeMatch {
+	return route.GeocodeMatch{
+		Location:   route.LatLong{Latitude: 37.7752, Longitude: -122.4146},
+		Relevance:  relevance,
+		MatchLevel: "houseNumber",
+		City:       "San Francisco",
+		State:      "CA",
+		PostalCode: "94103",
+		Label:      "1333 Minna St, San Francisco, CA 94103, United States",
+	}
+}
+
+func TestVerifyStoresGeocode(t *testing.T) {
+	v := NewVerifier(zap.NewNop(), fakeGeocoder{matches: []route.GeocodeMatch{minnaStreetMatch(0.95)}})
+	address := minnaStreet()
+
+	verrs, err := v.Verify(address)
+	if err != nil || verrs.HasAny() {
+		t.Fatalf("Expected the address to verify, got %v, %v", verrs, err)
+	}
+	if address.StreetAddress1 != "1333 Minna St" {
+		t.Errorf("Expected the address to be standardized, got %q", address.StreetAddress1)
+	}
+	if address.Latitude == nil || *address.Latitude != float64(float32(37.7752)) {
+		t.Errorf("Expected the geocoded latitude, got %v", address.Latitude)
+	}
+	if *address.GeocodeMatchLevel != "houseNumber" || *address.GeocodeRelevance != 0.95 {
+		t.Errorf("Expected the match quality to be stored, got %s %v", *address.GeocodeMatchLevel, *address.GeocodeRelevance)
+	}
+}
+
+func TestVerifyWithoutGeocoder(t *testing.T) {
+	v := NewVerifier(zap.NewNop(), nil)
+
+	address := minnaStreet()
+	verrs, err := v.Verify(address)
+	if err != nil || verrs.HasAny() {
+		t.Fatalf("Expected the address to verify, got %v, %v", verrs, err)
+	}
+	if *address.GeocodeMatchLevel != zip5MatchLevel || address.GeocodeRelevance != nil {
+		t.Errorf("Expected the address to be placed at its ZIP code, got %s", *address.GeocodeMatchLevel)
+	}
+
+	address = minnaStreet()
+	address.State = "TX"
+	verrs, _ = v.Verify(address)
+	if len(verrs.Get("state")) == 0 {
+		t.Errorf("Expected a state that doesn't match the ZIP code to be an error, got %v", verrs)
+	}
+
+	address = minnaStreet()
+	address.City = "Oakland"
+	verrs, _ = v.Verify(address)
+	if len(verrs.Get("city")) == 0 {
+		t.Errorf("Expected a city that doesn't match the ZIP code to be an error, got %v", verrs)
+	}
+
+	address = minnaStreet()
+	address.City = "san francisco"
+	verrs, _ = v.Verify(address)
+	if verrs.HasAny() {
+		t.Errorf("Expected the city to match regardless of case, got %v", verrs)
+	}
+
+	address = minnaStreet()
+	address.PostalCode = "00001"
+	verrs, _ = v.Verify(address)
+	if len(verrs.Get("postal_code")) == 0 {
+		t.Errorf("Expected an unknown ZIP code to be an error, got %v", verrs)
+	}
+}
+
+func TestVerifyGeocoderFailure(t *testing.T) {
+	v := NewVerifier(zap.NewNop(), fakeGeocoder{err: errors.New("geocoder is down")})
+	address := minnaStreet()
+
+	verrs, err := v.Verify(address)
+	if err != nil || verrs.HasAny() {
+		t.Fatalf("Expected the address to be saved when the geocoder is down, got %v, %v", verrs, err)
+	}
+	if *address.GeocodeMatchLevel != zip5MatchLevel {
+		t.Errorf("Expected the address to be placed at its ZIP code, got %s", *address.GeocodeMatchLevel)
+	}
+}
+
+func TestVerifyRejectsPoorMatches(t *testing.T) {
+	v := NewVerifier(zap.NewNop(), fakeGeocoder{matches: []route.GeocodeMatch{minnaStreetMatch(0.6)}})
+	verrs, _ := v.Verify(minnaStreet())
+	if len(verrs.Get("street_address1")) == 0 {
+		t.Errorf("Expected an irrelevant match to be an error, got %v", verrs)
+	}
+
+	oakland := minnaStreetMatch(0.93)
+	oakland.City = "Oakland"
+	oakland.PostalCode = "94607"
+	oakland.Label = "1333 Minna St, Oakland, CA 94607, United States"
+	v = NewVerifier(zap.NewNop(), fakeGeocoder{matches: []route.GeocodeMatch{minnaStreetMatch(0.95), oakland}})
+	verrs, _ = v.Verify(minnaStreet())
+	if len(verrs.Get("street_address1")) == 0 {
+		t.Errorf("Expected an ambiguous address to be an error, got %v", verrs)
+	}
+
+	v = NewVerifier(zap.NewNop(), fakeGeocoder{matches: []route.GeocodeMatch{oakland}})
+	verrs, _ = v.Verify(minnaStreet())
+	if len(verrs.Get("city")) == 0 {
+		t.Errorf("Expected a city in another ZIP code to be an error, got %v", verrs)
+	}
+}
+
+func TestVerifyAll(t *testing.T) {
+	v := NewVerifier(zap.NewNop(), nil)
+	wrongState := minnaStreet()
+	wrongState.State = "TX"
+
+	verrs, err := VerifyAll(v, map[string]*models.Address{
+		"pickup_address":   minnaStreet(),
+		"delivery_address": wrongState,
+		"missing_address":  nil,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verrs.Count() != 1 || len(verrs.Get("delivery_address.state")) != 1 {
+		t.Errorf("Expected an error about the delivery address's state, got %v", verrs)
+	}
+}
+
+func TestVerifyChanged(t *testing.T) {
+	v := NewVerifier(zap.NewNop(), nil)
+	unchanged := minnaStreet()
+	unchanged.State = "TX"
+	changed := minnaStreet()
+	previous := CopyAddresses(map[string]*models.Address{
+		"pickup_address":   unchanged,
+		"delivery_address": changed,
+	})
+	changed.State = "TX"
+	added := minnaStreet()
+	added.State = "TX"
+
+	verrs, err := VerifyChanged(v, previous, map[string]*models.Address{
+		"pickup_address":           unchanged,
+		"delivery_address":         changed,
+		"secondary_pickup_address": added,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(verrs.Get("pickup_address.state")) != 0 {
+		t.Errorf("Expected an unchanged address not to be verified again, got %v", verrs)
+	}
+	if len(verrs.Get("delivery_address.state")) != 1 || len(verrs.Get("secondary_pickup_address.state")) != 1 {
+		t.Errorf("Expected the changed and added addresses to be verified, got %v", verrs)
+	}
+}
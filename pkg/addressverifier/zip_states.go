@@ -0,0 +1,143 @@
+package addressverifier
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// zip3Range is a range of zip3s that the USPS assigns to the same states
+type zip3Range struct {
+	lower  int
+	upper  int
+	states []string
+}
+
+// zip3Ranges are the states each zip3 is assigned to, in order. A few zip3s serve more than one
+// state or territory.
+var zip3Ranges = []zip3Range{
+	{5, 5, []string{"NY"}},
+	{6, 7, []string{"PR"}},
+	{8, 8, []string{"VI"}},
+	{9, 9, []string{"PR"}},
+	{10, 27, []string{"MA"}},
+	{28, 29, []string{"RI"}},
+	{30, 38, []string{"NH"}},
+	{39, 49, []string{"ME"}},
+	{50, 54, []string{"VT"}},
+	{55, 55, []string{"MA"}},
+	{56, 59, []string{"VT"}},
+	{60, 69, []string{"CT"}},
+	{70, 89, []string{"NJ"}},
+	{90, 99, []string{"AE"}},
+	{100, 149, []string{"NY"}},
+	{150, 196, []string{"PA"}},
+	{197, 199, []string{"DE"}},
+	{200, 200, []string{"DC"}},
+	{201, 201, []string{"VA"}},
+	{202, 205, []string{"DC"}},
+	{206, 219, []string{"MD"}},
+	{220, 246, []string{"VA"}},
+	{247, 268, []string{"WV"}},
+	{270, 289, []string{"NC"}},
+	{290, 299, []string{"SC"}},
+	{300, 319, []string{"GA"}},
+	{320, 339, []string{"FL"}},
+	{340, 340, []string{"AA"}},
+	{341, 349, []string{"FL"}},
+	{350, 369, []string{"AL"}},
+	{370, 385, []string{"TN"}},
+	{386, 397, []string{"MS"}},
+	{398, 399, []string{"GA"}},
+	{400, 427, []string{"KY"}},
+	{430, 459, []string{"OH"}},
+	{460, 479, []string{"IN"}},
+	{480, 499, []string{"MI"}},
+	{500, 528, []string{"IA"}},
+	{530, 549, []string{"WI"}},
+	{550, 567, []string{"MN"}},
+	{569, 569, []string{"DC"}},
+	{570, 577, []string{"SD"}},
+	{580, 588, []string{"ND"}},
+	{590, 599, []string{"MT"}},
+	{600, 629, []string{"IL"}},
+	{630, 658, []string{"MO"}},
+	{660, 679, []string{"KS"}},
+	{680, 693, []string{"NE"}},
+	{700, 714, []string{"LA"}},
+	{716, 729, []string{"AR"}},
+	{730, 732, []string{"OK"}},
+	{733, 733, []string{"TX"}},
+	{734, 749, []string{"OK"}},
+	{750, 799, []string{"TX"}},
+	{800, 816, []string{"CO"}},
+	{820, 831, []string{"WY"}},
+	{832, 838, []string{"ID"}},
+	{840, 847, []string{"UT"}},
+	{850, 865, []string{"AZ"}},
+	{870, 884, []string{"NM"}},
+	{885, 885, []string{"TX"}},
+	{889, 898, []string{"NV"}},
+	{900, 961, []string{"CA"}},
+	{962, 966, []string{"AP"}},
+	{967, 968, []string{"HI", "AS"}},
+	{969, 969, []string{"GU", "MP", "PW", "FM", "MH"}},
+	{970, 979, []string{"OR"}},
+	{980, 994, []string{"WA"}},
+	{995, 999, []string{"AK"}},
+}
+
+// statesForZip5 returns the states a zip5 can be in, or nothing if its zip3 isn't assigned
+func statesForZip5(zip5 string) []string {
+	if len(zip5) < 3 {
+		return nil
+	}
+	zip3, err := strconv.Atoi(zip5[:3])
+	if err != nil {
+		return nil
+	}
+	index := sort.Search(len(zip3Ranges), func(i int) bool { return zip3Ranges[i].upper >= zip3 })
+	if index == len(zip3Ranges) || zip3Ranges[index].lower > zip3 {
+		return nil
+	}
+	return zip3Ranges[index].states
+}
+
+// zip5Cities are the city names the USPS accepts for some zip5s, with its preferred name first.
+// Only the ZIP codes of duty stations and cities that moves often start or end in are listed;
+// the city of an address in any other ZIP code is left for the geocoder to check.
+var zip5Cities = map[string][]string{
+	"10001": {"New York"},
+	"20001": {"Washington"},
+	"20301": {"Washington"},
+	"32542": {"Eglin AFB"},
+	"60601": {"Chicago"},
+	"77002": {"Houston"},
+	"78234": {"San Antonio", "Fort Sam Houston"},
+	"78626": {"Georgetown"},
+	"80011": {"Aurora"},
+	"90210": {"Beverly Hills"},
+	"94103": {"San Francisco"},
+	"94115": {"San Francisco"},
+	"94535": {"Travis AFB", "Fairfield"},
+	"94540": {"Hayward"},
+	"94607": {"Oakland"},
+	"98101": {"Seattle"},
+	"99835": {"Sitka"},
+}
+
+// citiesForZip5 returns the city names a zip5 can have, or nothing if they aren't known
+func citiesForZip5(zip5 string) []string {
+	return zip5Cities[zip5]
+}
+
+// cityInZip5 returns false only if the zip5's city names are known and city isn't one of them
+func cityInZip5(city string, zip5 string) bool {
+	cities := citiesForZip5(zip5)
+	for _, name := range cities {
+		if strings.EqualFold(name, city) {
+			return true
+		}
+	}
+	return len(cities) == 0
+}
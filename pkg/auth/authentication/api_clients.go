@@ -0,0 +1,269 @@
+package authentication
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/auth"
+	"github.com/transcom/mymove/pkg/models"
+)
+
+// APIAccessTokenLifetime is how long the access tokens issued to API clients last
+const APIAccessTokenLifetime = time.Hour
+
+// NewAPIClientSecret returns a new random secret for an API client. Only its hash is stored.
+func NewAPIClientSecret() string {
+	return generateNonce()
+}
+
+// CertificateFingerprint identifies the client certificate of an API client
+func CertificateFingerprint(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(hash[:])
+}
+
+// APIClientRateLimiter limits how many requests each API client can make a minute. Requests
+// are counted by each server, so a client's limit applies to each server separately.
+type APIClientRateLimiter struct {
+	mutex   sync.Mutex
+	windows map[uuid.UUID]rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// NewAPIClientRateLimiter creates a new APIClientRateLimiter
+func NewAPIClientRateLimiter() *APIClientRateLimiter {
+	return &APIClientRateLimiter{windows: map[uuid.UUID]rateWindow{}}
+}
+
+// Allow counts a request by a client. If the client is over its limit, it returns false and
+// how long until it can make requests again.
+func (l *APIClientRateLimiter) Allow(client models.APIClient, now time.Time) (bool, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	window := l.windows[client.ID]
+	if now.Sub(window.start) >= time.Minute {
+		window = rateWindow{start: now}
+	}
+	if window.count >= client.RateLimitPerMinute {
+		return false, window.start.Add(time.Minute).Sub(now)
+	}
+	window.count++
+	l.windows[client.ID] = window
+	return true, 0
+}
+
+// APIClientTokenHandler issues access tokens to API clients that authenticate with their
+// secret, as in the OAuth 2.0 client credentials grant
+type APIClientTokenHandler struct {
+	logger *zap.Logger
+	db     *pop.Connection
+}
+
+// NewAPIClientTokenHandler creates a new APIClientTokenHandler
+func NewAPIClientTokenHandler(logger *zap.Logger, db *pop.Connection) APIClientTokenHandler {
+	return APIClientTokenHandler{logger: logger, db: db}
+}
+
+type accessTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+func writeOAuthError(w http.ResponseWriter, code int, oauthError string) {
+	if code == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="api"`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	/* #nosec G104 nothing more can be done if the client has gone away */
+	json.NewEncoder(w).Encode(map[string]string{"error": oauthError})
+}
+
+func (h APIClientTokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+	clientID, secret, ok := r.BasicAuth()
+	if !ok {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	client, err := models.FetchAPIClientByCredentials(h.db, clientID, auth.SessionKey(secret))
+	if err != nil {
+		if err == models.ErrFetchNotFound {
+			h.logger.Error("API client failed to authenticate", zap.String("client_id", clientID))
+			writeOAuthError(w, http.StatusUnauthorized, "invalid_client")
+			return
+		}
+		h.logger.Error("Fetching API client", zap.Error(err))
+		http.Error(w, http.StatusText(500), http.StatusInternalServerError)
+		return
+	}
+	scopes, err := models.FetchAPIClientScopes(h.db, client.ID)
+	if err != nil {
+		h.logger.Error("Fetching API client scopes", zap.Error(err))
+		http.Error(w, http.StatusText(500), http.StatusInternalServerError)
+		return
+	}
+
+	accessToken := generateNonce()
+	now := time.Now()
+	token := models.APIAccessToken{
+		Key:         auth.SessionKey(accessToken),
+		APIClientID: client.ID,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(APIAccessTokenLifetime),
+	}
+	err = models.CreateAPIAccessToken(h.db, &token)
+	if err != nil {
+		h.logger.Error("Creating API access token", zap.Error(err))
+		http.Error(w, http.StatusText(500), http.StatusInternalServerError)
+		return
+	}
+	h.logger.Info("Issued API access token", zap.String("api_client_id", client.ID.String()), zap.String("api_client_name", client.Name))
+
+	scopeNames := make([]string, len(scopes))
+	for i, scope := range scopes {
+		scopeNames[i] = string(scope)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	/* #nosec G104 nothing more can be done if the client has gone away */
+	json.NewEncoder(w).Encode(accessTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(APIAccessTokenLifetime.Seconds()),
+		Scope:       strings.Join(scopeNames, " "),
+	})
+}
+
+var errNoAPIClientCredentials = errors.New("no API client credentials")
+
+// authenticateAPIClient returns the client a request was made by. An access token is used if
+// there is one, and otherwise a client certificate, if it was registered to a client.
+func authenticateAPIClient(db *pop.Connection, r *http.Request, now time.Time) (models.APIClient, error) {
+	authorization := r.Header.Get("Authorization")
+	if strings.HasPrefix(authorization, "Bearer ") {
+		accessToken := strings.TrimPrefix(authorization, "Bearer ")
+		return models.FetchAPIClientByAccessToken(db, auth.SessionKey(accessToken), now)
+	}
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		client, err := models.FetchAPIClientByCertificate(db, CertificateFingerprint(r.TLS.PeerCertificates[0]))
+		if err == models.ErrFetchNotFound {
+			return client, errNoAPIClientCredentials
+		}
+		return client, err
+	}
+	return models.APIClient{}, errNoAPIClientCredentials
+}
+
+// APIClientAuthMiddleware authenticates requests made by API clients, which act on behalf of
+// their TSP user, limited to their scopes. Requests that aren't from API clients are left to the
+// session cookie. Every request a client makes is logged, and clients over their rate limit are
+// turned away. It must come before UserAuthMiddleware and PermissionsMiddleware.
+func APIClientAuthMiddleware(logger *zap.Logger, db *pop.Connection, limiter *APIClientRateLimiter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		mw := func(w http.ResponseWriter, r *http.Request) {
+			now := time.Now()
+			client, err := authenticateAPIClient(db, r, now)
+			if err == errNoAPIClientCredentials {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err != nil {
+				if err == models.ErrFetchNotFound {
+					logger.Error("API client access token is invalid or expired")
+					w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+					http.Error(w, http.StatusText(401), http.StatusUnauthorized)
+					return
+				}
+				logger.Error("Authenticating API client", zap.Error(err))
+				http.Error(w, http.StatusText(500), http.StatusInternalServerError)
+				return
+			}
+			clientFields := []zap.Field{zap.String("api_client_id", client.ID.String()), zap.String("api_client_name", client.Name)}
+
+			session := auth.SessionFromRequestContext(r)
+			if session == nil || !session.IsTspApp() {
+				logger.Error("API client called an API other than the TSP's", clientFields...)
+				http.Error(w, http.StatusText(401), http.StatusUnauthorized)
+				return
+			}
+
+			allowed, retryAfter := limiter.Allow(client, now)
+			if !allowed {
+				logger.Error("API client is over its rate limit", clientFields...)
+				w.Header().Set("Retry-After", fmt.Sprintf("%d", int(math.Ceil(retryAfter.Seconds()))))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+
+			tspUser, err := models.FetchTspUserByID(db, client.TspUserID)
+			if err != nil {
+				logger.Error("Fetching API client TSP user", append(clientFields, zap.Error(err))...)
+				http.Error(w, http.StatusText(500), http.StatusInternalServerError)
+				return
+			}
+			if tspUser.UserID == nil {
+				logger.Error("API client's TSP user has never logged in", clientFields...)
+				http.Error(w, http.StatusText(401), http.StatusUnauthorized)
+				return
+			}
+			scopes, err := models.FetchAPIClientScopes(db, client.ID)
+			if err != nil {
+				logger.Error("Fetching API client scopes", append(clientFields, zap.Error(err))...)
+				http.Error(w, http.StatusText(500), http.StatusInternalServerError)
+				return
+			}
+			err = models.TouchAPIClient(db, client.ID, now)
+			if err != nil {
+				logger.Error("Touching API client", append(clientFields, zap.Error(err))...)
+			}
+
+			clientSession := auth.Session{
+				ApplicationName: session.ApplicationName,
+				Hostname:        session.Hostname,
+				UserID:          *tspUser.UserID,
+				Email:           tspUser.Email,
+				FirstName:       tspUser.FirstName,
+				LastName:        tspUser.LastName,
+				TspUserID:       tspUser.ID,
+				APIClientID:     client.ID,
+				Scopes:          scopes,
+			}
+			ctx := auth.SetSessionInRequestContext(r, &clientSession)
+			metrics := httpsnoop.CaptureMetrics(next, w, r.WithContext(ctx))
+			logger.Info("API client request", append(clientFields,
+				zap.String("tsp_user_id", tspUser.ID.String()),
+				zap.String("method", r.Method),
+				zap.String("url", r.URL.String()),
+				zap.Int("resp-status", metrics.Code),
+				zap.Duration("duration", metrics.Duration),
+			)...)
+		}
+		return http.HandlerFunc(mw)
+	}
+}
@@ -0,0 +1,160 @@
+package authentication
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/transcom/mymove/pkg/auth"
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/testdatagen"
+)
+
+// apiRequest returns a request to the TSP's public API, as routed by the app detector
+func apiRequest(session auth.Session) *http.Request {
+	req := httptest.NewRequest("GET", "/api/v1/shipments", nil)
+	return req.WithContext(auth.SetSessionInRequestContext(req, &session))
+}
+
+var tspApp = auth.Session{ApplicationName: auth.TspApp, Hostname: "tsp.move.host"}
+
+// serveAPIClient serves a request through the API client and permissions middleware, returning
+// the session the API would see
+func (suite *AuthSuite) serveAPIClient(req *http.Request, limiter *APIClientRateLimiter) (*httptest.ResponseRecorder, *auth.Session) {
+	var handlerSession *auth.Session
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerSession = auth.SessionFromRequestContext(r)
+	})
+	middleware := APIClientAuthMiddleware(suite.logger, suite.db, limiter)(
+		UserAuthMiddleware(suite.logger)(PermissionsMiddleware(suite.logger, suite.db)(handler)))
+	rr := httptest.NewRecorder()
+	middleware.ServeHTTP(rr, req)
+	return rr, handlerSession
+}
+
+func (suite *AuthSuite) requestAccessToken(clientID string, secret string) *httptest.ResponseRecorder {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req := httptest.NewRequest("POST", "/api/v1/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(clientID, secret)
+	rr := httptest.NewRecorder()
+	NewAPIClientTokenHandler(suite.logger, suite.db).ServeHTTP(rr, req)
+	return rr
+}
+
+func (suite *AuthSuite) TestAPIClientAccessToken() {
+	client := testdatagen.MakeDefaultAPIClient(suite.db)
+	err := models.SetAPIClientScopes(suite.db, client.ID, auth.PermissionViewShipments)
+	suite.NoError(err)
+
+	rr := suite.requestAccessToken(client.ClientID, testdatagen.DefaultAPIClientSecret)
+	suite.Equal(http.StatusOK, rr.Code)
+	var response accessTokenResponse
+	suite.NoError(json.Unmarshal(rr.Body.Bytes(), &response))
+	suite.Equal("Bearer", response.TokenType)
+	suite.Equal("shipments.view", response.Scope)
+
+	req := apiRequest(tspApp)
+	req.Header.Set("Authorization", "Bearer "+response.AccessToken)
+	rr, session := suite.serveAPIClient(req, NewAPIClientRateLimiter())
+	suite.Equal(http.StatusOK, rr.Code)
+	suite.Equal(client.ID, session.APIClientID)
+	suite.Equal(client.TspUserID, session.TspUserID)
+	// The TSP user's other permissions are limited to the client's scopes
+	suite.Equal([]auth.Permission{auth.PermissionViewShipments}, session.Permissions)
+
+	// Access tokens stop working when the client is revoked
+	suite.NoError(models.RevokeAPIClient(suite.db, client.ClientID, time.Now()))
+	rr, _ = suite.serveAPIClient(req, NewAPIClientRateLimiter())
+	suite.Equal(http.StatusUnauthorized, rr.Code)
+}
+
+func (suite *AuthSuite) TestAPIClientAccessTokenWithWrongCredentials() {
+	client := testdatagen.MakeDefaultAPIClient(suite.db)
+
+	rr := suite.requestAccessToken(client.ClientID, "wrong secret")
+	suite.Equal(http.StatusUnauthorized, rr.Code)
+	rr = suite.requestAccessToken("unknown", testdatagen.DefaultAPIClientSecret)
+	suite.Equal(http.StatusUnauthorized, rr.Code)
+
+	req := apiRequest(tspApp)
+	req.Header.Set("Authorization", "Bearer forged")
+	rr, session := suite.serveAPIClient(req, NewAPIClientRateLimiter())
+	suite.Equal(http.StatusUnauthorized, rr.Code)
+	suite.Nil(session)
+}
+
+func (suite *AuthSuite) TestAPIClientCertificate() {
+	cert := &x509.Certificate{Raw: []byte("client certificate")}
+	fingerprint := CertificateFingerprint(cert)
+	client := testdatagen.MakeAPIClient(suite.db, testdatagen.Assertions{
+		APIClient: models.APIClient{CertificateFingerprint: &fingerprint},
+	})
+
+	req := apiRequest(tspApp)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	rr, session := suite.serveAPIClient(req, NewAPIClientRateLimiter())
+	suite.Equal(http.StatusOK, rr.Code)
+	suite.Equal(client.ID, session.APIClientID)
+
+	// Certificates that aren't registered to a client are left to the session cookie
+	req = apiRequest(tspApp)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Raw: []byte("another certificate")}}}
+	rr, session = suite.serveAPIClient(req, NewAPIClientRateLimiter())
+	suite.Equal(http.StatusUnauthorized, rr.Code, "expected a logged in user to be required")
+	suite.Nil(session)
+}
+
+func (suite *AuthSuite) TestAPIClientOnlyCallsTSPAPI() {
+	cert := &x509.Certificate{Raw: []byte("client certificate")}
+	fingerprint := CertificateFingerprint(cert)
+	testdatagen.MakeAPIClient(suite.db, testdatagen.Assertions{
+		APIClient: models.APIClient{CertificateFingerprint: &fingerprint},
+	})
+
+	req := apiRequest(auth.Session{ApplicationName: auth.OfficeApp, Hostname: "office.move.host"})
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	rr, _ := suite.serveAPIClient(req, NewAPIClientRateLimiter())
+	suite.Equal(http.StatusUnauthorized, rr.Code)
+}
+
+func (suite *AuthSuite) TestAPIClientRateLimit() {
+	cert := &x509.Certificate{Raw: []byte("client certificate")}
+	fingerprint := CertificateFingerprint(cert)
+	testdatagen.MakeAPIClient(suite.db, testdatagen.Assertions{
+		APIClient: models.APIClient{CertificateFingerprint: &fingerprint, RateLimitPerMinute: 2},
+	})
+	limiter := NewAPIClientRateLimiter()
+
+	for i := 0; i < 2; i++ {
+		req := apiRequest(tspApp)
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+		rr, _ := suite.serveAPIClient(req, limiter)
+		suite.Equal(http.StatusOK, rr.Code)
+	}
+
+	req := apiRequest(tspApp)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	rr, _ := suite.serveAPIClient(req, limiter)
+	suite.Equal(http.StatusTooManyRequests, rr.Code)
+	suite.NotEmpty(rr.Header().Get("Retry-After"))
+}
+
+func (suite *AuthSuite) TestAPIClientRateLimiterWindow() {
+	client := models.APIClient{RateLimitPerMinute: 1}
+	limiter := NewAPIClientRateLimiter()
+	now := time.Now()
+
+	allowed, _ := limiter.Allow(client, now)
+	suite.True(allowed)
+	allowed, retryAfter := limiter.Allow(client, now.Add(20*time.Second))
+	suite.False(allowed)
+	suite.Equal(40*time.Second, retryAfter)
+	allowed, _ = limiter.Allow(client, now.Add(time.Minute))
+	suite.True(allowed, "expected the limit to reset each minute")
+}
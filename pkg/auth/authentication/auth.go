@@ -57,6 +57,29 @@ func UserAuthMiddleware(logger *zap.Logger) func(next http.Handler) http.Handler
 	}
 }
 
+// PermissionsMiddleware loads the permissions granted to the session's user by their roles,
+// limited to the scopes of the API client if the request was made by one. It must come after
+// UserAuthMiddleware, which ensures there is a user.
+func PermissionsMiddleware(logger *zap.Logger, db *pop.Connection) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		mw := func(w http.ResponseWriter, r *http.Request) {
+			session := auth.SessionFromRequestContext(r)
+			permissions, err := models.FetchPermissionsForUser(db, session.UserID)
+			if err != nil {
+				logger.Error("Loading permissions", zap.String("user_id", session.UserID.String()), zap.Error(err))
+				http.Error(w, http.StatusText(500), http.StatusInternalServerError)
+				return
+			}
+			if session.IsAPIClient() {
+				permissions = auth.LimitToScopes(permissions, session.Scopes)
+			}
+			session.Permissions = permissions
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(mw)
+	}
+}
+
 func (context Context) landingURL(session *auth.Session) string {
 	return fmt.Sprintf(context.callbackTemplate, session.Hostname)
 }
@@ -66,6 +89,7 @@ type Context struct {
 	logger           *zap.Logger
 	loginGovProvider LoginGovProvider
 	callbackTemplate string
+	secureCookies    bool
 }
 
 // NewAuthContext creates an Context
@@ -74,6 +98,7 @@ func NewAuthContext(logger *zap.Logger, loginGovProvider LoginGovProvider, callb
 		logger:           logger,
 		loginGovProvider: loginGovProvider,
 		callbackTemplate: fmt.Sprintf("%s%%s:%d/", callbackProtocol, callbackPort),
+		secureCookies:    callbackProtocol == "https://",
 	}
 	return context
 }
@@ -81,16 +106,14 @@ func NewAuthContext(logger *zap.Logger, loginGovProvider LoginGovProvider, callb
 // LogoutHandler handles logging the user out of login.gov
 type LogoutHandler struct {
 	Context
-	clientAuthSecretKey string
-	noSessionTimeout    bool
+	sessions *auth.SessionManager
 }
 
 // NewLogoutHandler creates a new LogoutHandler
-func NewLogoutHandler(ac Context, clientAuthSecretKey string, noSessionTimeout bool) LogoutHandler {
+func NewLogoutHandler(ac Context, sessions *auth.SessionManager) LogoutHandler {
 	handler := LogoutHandler{
-		Context:             ac,
-		clientAuthSecretKey: clientAuthSecretKey,
-		noSessionTimeout:    noSessionTimeout,
+		Context:  ac,
+		sessions: sessions,
 	}
 	return handler
 }
@@ -109,9 +132,12 @@ func (h LogoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			} else {
 				logoutURL = h.loginGovProvider.LogoutURL(redirectURL, session.IDToken)
 			}
-			session.IDToken = ""
-			session.UserID = uuid.Nil
-			auth.WriteSessionCookie(w, session, h.clientAuthSecretKey, h.noSessionTimeout, h.logger)
+			err := h.sessions.End(w, session)
+			if err != nil {
+				h.logger.Error("Ending session", zap.Error(err))
+				http.Error(w, http.StatusText(500), http.StatusInternalServerError)
+				return
+			}
 			http.Redirect(w, r, logoutURL, http.StatusTemporaryRedirect)
 		} else {
 			// Can't log out of login.gov without a token, redirect and let them re-auth
@@ -123,6 +149,16 @@ func (h LogoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // RedirectHandler handles redirection
 type RedirectHandler struct {
 	Context
+	loginAttempts LoginAttemptStore
+}
+
+// NewRedirectHandler creates a new RedirectHandler
+func NewRedirectHandler(ac Context, loginAttempts LoginAttemptStore) RedirectHandler {
+	handler := RedirectHandler{
+		Context:       ac,
+		loginAttempts: loginAttempts,
+	}
+	return handler
 }
 
 // RedirectHandler constructs the Login.gov authentication URL and redirects to it
@@ -134,7 +170,14 @@ func (h RedirectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	authURL, err := h.loginGovProvider.AuthorizationURL(r)
+	attempt, err := startLoginAttempt(w, h.loginAttempts, time.Now(), h.secureCookies)
+	if err != nil {
+		h.logger.Error("Starting login attempt", zap.Error(err))
+		http.Error(w, http.StatusText(500), http.StatusInternalServerError)
+		return
+	}
+
+	authURL, err := h.loginGovProvider.AuthorizationURL(r, attempt.State, attempt.Nonce)
 	if err != nil {
 		http.Error(w, http.StatusText(500), http.StatusInternalServerError)
 		return
@@ -147,20 +190,20 @@ func (h RedirectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 type CallbackHandler struct {
 	Context
 	db                     *pop.Connection
-	clientAuthSecretKey    string
-	noSessionTimeout       bool
+	sessions               *auth.SessionManager
+	loginAttempts          LoginAttemptStore
 	loginGovMyClientID     string
 	loginGovOfficeClientID string
 	loginGovTspClientID    string
 }
 
 // NewCallbackHandler creates a new CallbackHandler
-func NewCallbackHandler(ac Context, db *pop.Connection, clientAuthSecretKey string, noSessionTimeout bool) CallbackHandler {
+func NewCallbackHandler(ac Context, db *pop.Connection, sessions *auth.SessionManager, loginAttempts LoginAttemptStore) CallbackHandler {
 	handler := CallbackHandler{
-		Context:             ac,
-		db:                  db,
-		clientAuthSecretKey: clientAuthSecretKey,
-		noSessionTimeout:    noSessionTimeout,
+		Context:       ac,
+		db:            db,
+		sessions:      sessions,
+		loginAttempts: loginAttempts,
 	}
 	return handler
 }
@@ -176,6 +219,14 @@ func (h CallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	lURL := h.landingURL(session)
 
+	// The callback must be for a login this browser started, and can only be used once
+	attempt, err := finishLoginAttempt(w, r, h.loginAttempts, time.Now())
+	if err != nil {
+		h.logger.Error("Invalid login attempt", zap.Error(err))
+		http.Error(w, http.StatusText(401), http.StatusUnauthorized)
+		return
+	}
+
 	authError := r.URL.Query().Get("error")
 	// The user has either cancelled or declined to authorize the client
 	if authError == "access_denied" {
@@ -195,7 +246,6 @@ func (h CallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// TODO: validate the state is the same (pull from session)
 	code := r.URL.Query().Get("code")
 	openIDSession, err := fetchToken(h.logger, code, provider.ClientKey, h.loginGovProvider)
 	if err != nil {
@@ -203,12 +253,26 @@ func (h CallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	idTokenClaims, err := h.loginGovProvider.VerifyIDToken(openIDSession.IDToken, provider.ClientKey, attempt.Nonce)
+	if err != nil {
+		h.logger.Error("Invalid ID token", zap.Error(err))
+		http.Error(w, http.StatusText(401), http.StatusUnauthorized)
+		return
+	}
+
 	openIDUser, err := provider.FetchUser(openIDSession)
 	if err != nil {
 		h.logger.Error("Login.gov user info request", zap.Error(err))
 		http.Error(w, http.StatusText(500), http.StatusInternalServerError)
 		return
 	}
+	// The user info must be for the user the ID token was issued to
+	if openIDUser.UserID != idTokenClaims.Subject {
+		h.logger.Error("ID token is for another user",
+			zap.String("OID_User", openIDUser.UserID), zap.String("ID_Token_Subject", idTokenClaims.Subject))
+		http.Error(w, http.StatusText(401), http.StatusUnauthorized)
+		return
+	}
 
 	session.IDToken = openIDSession.IDToken
 	session.Email = openIDUser.Email
@@ -242,6 +306,9 @@ func (h CallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			beeline.AddField(r.Context(), "session.office_user_id", session.OfficeUserID)
 			officeUser.UserID = &userIdentity.ID
 			err = h.db.Save(officeUser)
+			if err == nil {
+				err = models.GrantRoles(h.db, userIdentity.ID, models.DefaultOfficeRoles...)
+			}
 			if err != nil {
 				h.logger.Error("Updating office user", zap.String("email", session.Email), zap.Error(err))
 				http.Error(w, http.StatusText(500), http.StatusInternalServerError)
@@ -267,6 +334,9 @@ func (h CallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			beeline.AddField(r.Context(), "session.tsp_user_id", session.TspUserID)
 			tspUser.UserID = &userIdentity.ID
 			err = h.db.Save(tspUser)
+			if err == nil {
+				err = models.GrantRoles(h.db, userIdentity.ID, models.DefaultTspRoles...)
+			}
 			if err != nil {
 				h.logger.Error("Updating TSP user", zap.String("email", session.Email), zap.Error(err))
 				http.Error(w, http.StatusText(500), http.StatusInternalServerError)
@@ -316,11 +386,17 @@ func (h CallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				beeline.AddField(r.Context(), "session.office_user_id", session.OfficeUserID)
 				officeUser.UserID = &user.ID
 				err = h.db.Save(officeUser)
+				if err == nil {
+					err = models.GrantRoles(h.db, user.ID, models.DefaultOfficeRoles...)
+				}
 			} else if tspUser != nil {
 				session.TspUserID = tspUser.ID
 				beeline.AddField(r.Context(), "session.tsp_user_id", session.TspUserID)
 				tspUser.UserID = &user.ID
 				err = h.db.Save(tspUser)
+				if err == nil {
+					err = models.GrantRoles(h.db, user.ID, models.DefaultTspRoles...)
+				}
 			}
 		}
 		if err != nil {
@@ -333,8 +409,13 @@ func (h CallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, http.StatusText(500), http.StatusInternalServerError)
 		return
 	}
+	err = h.sessions.Start(w, r, session)
+	if err != nil {
+		h.logger.Error("Starting session", zap.Error(err))
+		http.Error(w, http.StatusText(500), http.StatusInternalServerError)
+		return
+	}
 	h.logger.Info("logged in", zap.Any("session", session))
-	auth.WriteSessionCookie(w, session, h.clientAuthSecretKey, h.noSessionTimeout, h.logger)
 	http.Redirect(w, r, lURL, http.StatusTemporaryRedirect)
 }
 
@@ -348,7 +429,7 @@ func fetchToken(logger *zap.Logger, code string, clientID string, loginGovProvid
 	}
 
 	/* #nosec G107 */
-	response, err := http.PostForm(tokenURL, params)
+	response, err := loginGovProvider.client.PostForm(tokenURL, params)
 	if err != nil {
 		logger.Error("Post to Login.gov token endpoint", zap.Error(err))
 		return nil, err
@@ -15,6 +15,7 @@ import (
 
 	"github.com/transcom/mymove/pkg/auth"
 	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/testdatagen"
 )
 
 type AuthSuite struct {
@@ -90,7 +91,8 @@ func (suite *AuthSuite) TestAuthorizationLogoutHandler() {
 	req = req.WithContext(ctx)
 
 	authContext := NewAuthContext(suite.logger, fakeLoginGovProvider(suite.logger), "http://", callbackPort)
-	handler := LogoutHandler{authContext, "fake key", false}
+	sessions := auth.NewSessionManager(suite.logger, auth.NewMemorySessionStore(), auth.DefaultSessionTimeouts)
+	handler := LogoutHandler{authContext, sessions}
 	wrappedHandler := auth.DetectorMiddleware(suite.logger, myMoveMil, officeMoveMil, tspMoveMil)(handler)
 
 	rr := httptest.NewRecorder()
@@ -162,3 +164,31 @@ func (suite *AuthSuite) TestRequireAuthMiddlewareUnauthorized() {
 		t.Errorf("handler returned wrong status code: got %v wanted %v", status, http.StatusUnauthorized)
 	}
 }
+
+func (suite *AuthSuite) TestPermissionsMiddleware() {
+	// Given: an office user with the default office roles
+	officeUser := testdatagen.MakeDefaultOfficeUser(suite.db)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/queues/new", nil)
+	session := auth.Session{UserID: *officeUser.UserID, OfficeUserID: officeUser.ID, IDToken: "fake Token"}
+	req = req.WithContext(auth.SetSessionInRequestContext(req, &session))
+
+	var handlerSession *auth.Session
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerSession = auth.SessionFromRequestContext(r)
+	})
+	PermissionsMiddleware(suite.logger, suite.db)(handler).ServeHTTP(rr, req)
+
+	// The handler sees the permissions of their roles
+	suite.Equal(http.StatusOK, rr.Code)
+	suite.True(handlerSession.Can(auth.PermissionViewMoveQueues, auth.PermissionApprovePPMs, auth.PermissionApproveHHGs))
+	suite.False(handlerSession.Can(auth.PermissionDispatchShipments))
+
+	// And: a revoked role takes effect on the next request
+	err := models.RevokeRoles(suite.db, *officeUser.UserID, models.RoleNameHHGOfficeApprover)
+	suite.NoError(err)
+	PermissionsMiddleware(suite.logger, suite.db)(handler).ServeHTTP(httptest.NewRecorder(), req)
+	suite.True(handlerSession.Can(auth.PermissionApprovePPMs))
+	suite.False(handlerSession.Can(auth.PermissionApproveHHGs))
+}
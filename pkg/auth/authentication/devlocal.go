@@ -90,9 +90,8 @@ func (h UserListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 type devlocalAuthHandler struct {
 	Context
-	db                  *pop.Connection
-	clientAuthSecretKey string
-	noSessionTimeout    bool
+	db       *pop.Connection
+	sessions *auth.SessionManager
 }
 
 // AssignUserHandler logs a user in directly
@@ -102,12 +101,11 @@ type AssignUserHandler devlocalAuthHandler
 type CreateUserHandler devlocalAuthHandler
 
 // NewAssignUserHandler creates a new AssignUserHandler
-func NewAssignUserHandler(ac Context, db *pop.Connection, clientAuthSecretKey string, noSessionTimeout bool) AssignUserHandler {
+func NewAssignUserHandler(ac Context, db *pop.Connection, sessions *auth.SessionManager) AssignUserHandler {
 	handler := AssignUserHandler{
-		Context:             ac,
-		db:                  db,
-		clientAuthSecretKey: clientAuthSecretKey,
-		noSessionTimeout:    noSessionTimeout,
+		Context:  ac,
+		db:       db,
+		sessions: sessions,
 	}
 	return handler
 }
@@ -134,12 +132,11 @@ func (h AssignUserHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 // NewCreateUserHandler creates a new CreateUserHandler
-func NewCreateUserHandler(ac Context, db *pop.Connection, clientAuthSecretKey string, noSessionTimeout bool) CreateUserHandler {
+func NewCreateUserHandler(ac Context, db *pop.Connection, sessions *auth.SessionManager) CreateUserHandler {
 	handler := CreateUserHandler{
-		Context:             ac,
-		db:                  db,
-		clientAuthSecretKey: clientAuthSecretKey,
-		noSessionTimeout:    noSessionTimeout,
+		Context:  ac,
+		db:       db,
+		sessions: sessions,
 	}
 	return handler
 }
@@ -211,8 +208,13 @@ func loginUser(handler devlocalAuthHandler, user *models.User, w http.ResponseWr
 		return
 	}
 
+	err = handler.sessions.Start(w, r, session)
+	if err != nil {
+		handler.logger.Error("Starting session", zap.Error(err))
+		http.Error(w, http.StatusText(500), http.StatusInternalServerError)
+		return
+	}
 	handler.logger.Info("logged in", zap.Any("session", session))
-	auth.WriteSessionCookie(w, session, handler.clientAuthSecretKey, handler.noSessionTimeout, handler.logger)
 
 	lURL := handler.landingURL(session)
 	http.Redirect(w, r, lURL, http.StatusSeeOther)
@@ -0,0 +1,156 @@
+package authentication
+
+import (
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+)
+
+// jwksRefreshInterval is how often the signing keys are fetched again, so that keys login.gov
+// has retired stop being trusted
+const jwksRefreshInterval = time.Hour
+
+// jwksMinimumRefreshInterval limits how often tokens signed with unknown keys can make us
+// fetch the signing keys
+const jwksMinimumRefreshInterval = time.Minute
+
+// jsonWebKey is an RSA public key, as published in a JSON Web Key Set
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+}
+
+func (k jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding key modulus")
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding key exponent")
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("key exponent is too large")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+// jwksCache holds the keys login.gov signs ID tokens with, by key ID
+type jwksCache struct {
+	url       string
+	client    *http.Client
+	mutex     sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func newJWKSCache(url string, client *http.Client) *jwksCache {
+	return &jwksCache{url: url, client: client}
+}
+
+// key returns a signing key by ID. Keys are fetched again if the key is unknown, as login.gov
+// may have started signing with a new one.
+func (c *jwksCache) key(keyID string, now time.Time) (*rsa.PublicKey, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.keys == nil || now.Sub(c.fetchedAt) > jwksRefreshInterval {
+		if err := c.fetch(now); err != nil {
+			return nil, err
+		}
+	}
+	key, ok := c.keys[keyID]
+	if !ok && now.Sub(c.fetchedAt) > jwksMinimumRefreshInterval {
+		if err := c.fetch(now); err != nil {
+			return nil, err
+		}
+		key, ok = c.keys[keyID]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", keyID)
+	}
+	return key, nil
+}
+
+func (c *jwksCache) fetch(now time.Time) error {
+	response, err := c.client.Get(c.url)
+	if err != nil {
+		return errors.Wrap(err, "fetching signing keys")
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching signing keys: unexpected status %d", response.StatusCode)
+	}
+
+	var keySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	err = json.NewDecoder(response.Body).Decode(&keySet)
+	if err != nil {
+		return errors.Wrap(err, "parsing signing keys")
+	}
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range keySet.Keys {
+		if k.KeyType != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := k.rsaPublicKey()
+		if err != nil {
+			return errors.Wrapf(err, "parsing signing key %q", k.KeyID)
+		}
+		keys[k.KeyID] = key
+	}
+	c.keys = keys
+	c.fetchedAt = now
+	return nil
+}
+
+// IDTokenClaims are the claims of a login.gov ID token that are checked when logging in
+type IDTokenClaims struct {
+	jwt.StandardClaims
+	Nonce string `json:"nonce"`
+}
+
+// VerifyIDToken checks that an ID token was signed by login.gov for a client, and that it was
+// issued for the login attempt with a nonce, so that it can't be replayed to log in elsewhere
+func (p LoginGovProvider) VerifyIDToken(idToken string, clientID string, nonce string) (*IDTokenClaims, error) {
+	now := time.Now()
+	claims := IDTokenClaims{}
+	_, err := jwt.ParseWithClaims(idToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodRS256 {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		keyID, _ := token.Header["kid"].(string)
+		return p.keys.key(keyID, now)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "verifying ID token")
+	}
+
+	if !claims.VerifyIssuer(p.Issuer(), true) {
+		return nil, fmt.Errorf("ID token issued by %q", claims.Issuer)
+	}
+	if !claims.VerifyAudience(clientID, true) {
+		return nil, fmt.Errorf("ID token issued for %q", claims.Audience)
+	}
+	if !claims.VerifyExpiresAt(now.Unix(), true) {
+		return nil, errors.New("ID token has no expiry")
+	}
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, errors.New("ID token nonce doesn't match the login attempt")
+	}
+	return &claims, nil
+}
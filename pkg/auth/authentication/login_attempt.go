@@ -0,0 +1,196 @@
+package authentication
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/pkg/errors"
+
+	"github.com/transcom/mymove/pkg/auth"
+)
+
+// LoginAttemptCookieName is the key at which we're storing the ID of the login attempt in
+// progress, between redirecting to login.gov and its callback
+const LoginAttemptCookieName = "login_attempt"
+
+// LoginAttemptTimeout is how long users have to log in to login.gov
+const LoginAttemptTimeout = 10 * time.Minute
+
+// ErrLoginAttemptNotFound is returned when a login attempt doesn't exist or was already used
+var ErrLoginAttemptNotFound = errors.New("login attempt not found")
+
+// LoginAttempt is the state and nonce sent to login.gov when a user starts to log in, which its
+// callback must return. Like sessions, attempts are stored by a key hashed from the cookie's ID.
+type LoginAttempt struct {
+	Key       string    `db:"key"`
+	State     string    `db:"state"`
+	Nonce     string    `db:"nonce"`
+	CreatedAt time.Time `db:"created_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// LoginAttemptStore is the interface needed to keep login attempts on the server
+type LoginAttemptStore interface {
+	// Create stores a new login attempt
+	Create(attempt *LoginAttempt) error
+	// Take removes and returns a login attempt, so that each can only be used once. It returns
+	// ErrLoginAttemptNotFound if there is none.
+	Take(key string) (*LoginAttempt, error)
+	// DeleteExpired removes the attempts that have expired as of now, and returns how many
+	// were removed
+	DeleteExpired(now time.Time) (int, error)
+}
+
+// startLoginAttempt stores a new login attempt, and sets the cookie that identifies it. A secure
+// cookie is only sent over HTTPS.
+func startLoginAttempt(w http.ResponseWriter, store LoginAttemptStore, now time.Time, secure bool) (*LoginAttempt, error) {
+	id := generateNonce()
+	attempt := LoginAttempt{
+		Key:       auth.SessionKey(id),
+		State:     generateNonce(),
+		Nonce:     generateNonce(),
+		CreatedAt: now,
+		ExpiresAt: now.Add(LoginAttemptTimeout),
+	}
+	err := store.Create(&attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	cookie := http.Cookie{
+		Name:     LoginAttemptCookieName,
+		Value:    id,
+		Path:     "/auth/login-gov",
+		Expires:  attempt.ExpiresAt,
+		MaxAge:   int(LoginAttemptTimeout.Seconds()),
+		HttpOnly: true,
+		Secure:   secure,
+	}
+	// The cookie is SameSite=Lax, so it is still sent with the redirect back from login.gov
+	auth.SetCookie(w, &cookie)
+	return &attempt, nil
+}
+
+// finishLoginAttempt returns the login attempt a callback is for, checking the state it returned.
+// The attempt is used up, whether or not it matches.
+func finishLoginAttempt(w http.ResponseWriter, r *http.Request, store LoginAttemptStore, now time.Time) (*LoginAttempt, error) {
+	cookie, err := r.Cookie(LoginAttemptCookieName)
+	if err != nil {
+		return nil, ErrLoginAttemptNotFound
+	}
+	auth.SetCookie(w, &http.Cookie{
+		Name:    LoginAttemptCookieName,
+		Value:   "blank",
+		Path:    "/auth/login-gov",
+		Expires: time.Unix(0, 0),
+		MaxAge:  -1,
+	})
+
+	attempt, err := store.Take(auth.SessionKey(cookie.Value))
+	if err != nil {
+		return nil, err
+	}
+	if !now.Before(attempt.ExpiresAt) {
+		return nil, errors.New("login attempt has expired")
+	}
+	state := r.URL.Query().Get("state")
+	if subtle.ConstantTimeCompare([]byte(state), []byte(attempt.State)) != 1 {
+		return nil, errors.New("state doesn't match the login attempt")
+	}
+	return attempt, nil
+}
+
+// MemoryLoginAttemptStore keeps login attempts in memory, for use in development and tests
+type MemoryLoginAttemptStore struct {
+	mutex    sync.Mutex
+	attempts map[string]LoginAttempt
+}
+
+// NewMemoryLoginAttemptStore creates a new MemoryLoginAttemptStore
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{attempts: map[string]LoginAttempt{}}
+}
+
+// Create stores a new login attempt
+func (s *MemoryLoginAttemptStore) Create(attempt *LoginAttempt) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.attempts[attempt.Key] = *attempt
+	return nil
+}
+
+// Take removes and returns a login attempt
+func (s *MemoryLoginAttemptStore) Take(key string) (*LoginAttempt, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	attempt, ok := s.attempts[key]
+	if !ok {
+		return nil, ErrLoginAttemptNotFound
+	}
+	delete(s.attempts, key)
+	return &attempt, nil
+}
+
+// DeleteExpired removes the attempts that have expired as of now
+func (s *MemoryLoginAttemptStore) DeleteExpired(now time.Time) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	count := 0
+	for key, attempt := range s.attempts {
+		if !now.Before(attempt.ExpiresAt) {
+			delete(s.attempts, key)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// PostgresLoginAttemptStore keeps login attempts in the login_attempts table, so that the
+// callback can be handled by a different server than the one the user started on
+type PostgresLoginAttemptStore struct {
+	db *pop.Connection
+}
+
+// NewPostgresLoginAttemptStore creates a new PostgresLoginAttemptStore
+func NewPostgresLoginAttemptStore(db *pop.Connection) *PostgresLoginAttemptStore {
+	return &PostgresLoginAttemptStore{db: db}
+}
+
+// Create stores a new login attempt
+func (s *PostgresLoginAttemptStore) Create(attempt *LoginAttempt) error {
+	sql := `INSERT INTO login_attempts (key, state, nonce, created_at, expires_at)
+			VALUES ($1, $2, $3, $4, $5)`
+
+	err := s.db.RawQuery(sql, attempt.Key, attempt.State, attempt.Nonce, attempt.CreatedAt, attempt.ExpiresAt).Exec()
+	if err != nil {
+		return errors.Wrap(err, "Error while creating login attempt")
+	}
+	return nil
+}
+
+// Take removes and returns a login attempt
+func (s *PostgresLoginAttemptStore) Take(key string) (*LoginAttempt, error) {
+	var attempt LoginAttempt
+	sql := `DELETE FROM login_attempts WHERE key = $1 RETURNING *`
+
+	err := s.db.RawQuery(sql, key).First(&attempt)
+	if err != nil {
+		if errors.Cause(err).Error() == "sql: no rows in result set" {
+			return nil, ErrLoginAttemptNotFound
+		}
+		return nil, errors.Wrap(err, "Error while taking login attempt")
+	}
+	return &attempt, nil
+}
+
+// DeleteExpired removes the attempts that have expired as of now
+func (s *PostgresLoginAttemptStore) DeleteExpired(now time.Time) (int, error) {
+	count, err := s.db.RawQuery(`DELETE FROM login_attempts WHERE expires_at <= $1`, now).ExecWithCount()
+	if err != nil {
+		return 0, errors.Wrap(err, "Error while deleting expired login attempts")
+	}
+	return count, nil
+}
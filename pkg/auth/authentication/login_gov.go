@@ -1,14 +1,13 @@
 package authentication
 
 import (
+	"crypto/rand"
 	"encoding/base64"
 	"fmt"
-	"math/rand"
+	"net/http"
 	"net/url"
 	"time"
 
-	"net/http"
-
 	"github.com/dgrijalva/jwt-go"
 	"github.com/markbates/goth"
 	"github.com/markbates/goth/providers/openidConnect"
@@ -37,16 +36,26 @@ func getLoginGovProviderForRequest(r *http.Request) (*openidConnect.Provider, er
 
 // LoginGovProvider facilitates generating URLs and parameters for interfacing with Login.gov
 type LoginGovProvider struct {
-	hostname  string
+	baseURL   string
 	secretKey string
+	client    *http.Client
+	keys      *jwksCache
 	logger    *zap.Logger
 }
 
 // NewLoginGovProvider returns a new LoginGovProvider
 func NewLoginGovProvider(hostname string, secretKey string, logger *zap.Logger) LoginGovProvider {
+	return newLoginGovProvider(fmt.Sprintf("https://%s", hostname), secretKey, http.DefaultClient, logger)
+}
+
+// newLoginGovProvider returns a LoginGovProvider for an identity provider at any URL, such as a
+// local stand-in for login.gov
+func newLoginGovProvider(baseURL string, secretKey string, client *http.Client, logger *zap.Logger) LoginGovProvider {
 	return LoginGovProvider{
-		hostname:  hostname,
+		baseURL:   baseURL,
 		secretKey: secretKey,
+		client:    client,
+		keys:      newJWKSCache(fmt.Sprintf("%s/api/openid_connect/certs", baseURL), client),
 		logger:    logger,
 	}
 }
@@ -56,7 +65,7 @@ func (p LoginGovProvider) getOpenIDProvider(hostname string, clientID string, ca
 		clientID,
 		p.secretKey,
 		fmt.Sprintf("%s%s:%d/auth/login-gov/callback", callbackProtocol, hostname, callbackPort),
-		fmt.Sprintf("https://%s/.well-known/openid-configuration", p.hostname),
+		fmt.Sprintf("%s/.well-known/openid-configuration", p.baseURL),
 	)
 }
 
@@ -88,21 +97,21 @@ func (p LoginGovProvider) RegisterProvider(myHostname string, myClientID string,
 
 func generateNonce() string {
 	nonceBytes := make([]byte, 64)
-	random := rand.New(rand.NewSource(time.Now().UnixNano()))
-	for i := 0; i < 64; i++ {
-		nonceBytes[i] = byte(random.Int63() % 256)
+	// crypto/rand only fails if the operating system can't supply randomness at all
+	if _, err := rand.Read(nonceBytes); err != nil {
+		panic(err)
 	}
 	return base64.URLEncoding.EncodeToString(nonceBytes)
 }
 
-// AuthorizationURL returns a URL for login.gov authorization with required params
-func (p LoginGovProvider) AuthorizationURL(r *http.Request) (string, error) {
+// AuthorizationURL returns a URL for login.gov authorization with required params. The state
+// is returned to the callback, and the nonce is included in the ID token.
+func (p LoginGovProvider) AuthorizationURL(r *http.Request, state string, nonce string) (string, error) {
 	provider, err := getLoginGovProviderForRequest(r)
 	if err != nil {
 		p.logger.Error("Get Goth provider", zap.Error(err))
 		return "", err
 	}
-	state := generateNonce()
 	sess, err := provider.BeginAuth(state)
 	if err != nil {
 		p.logger.Error("Goth begin auth", zap.Error(err))
@@ -123,7 +132,7 @@ func (p LoginGovProvider) AuthorizationURL(r *http.Request) (string, error) {
 
 	params := authURL.Query()
 	params.Add("acr_values", "http://idmanagement.gov/ns/assurance/loa/1")
-	params.Add("nonce", state)
+	params.Add("nonce", nonce)
 	params.Set("scope", "openid email")
 
 	authURL.RawQuery = params.Encode()
@@ -133,7 +142,7 @@ func (p LoginGovProvider) AuthorizationURL(r *http.Request) (string, error) {
 // LogoutURL returns a full URL to log out of login.gov with required params
 func (p LoginGovProvider) LogoutURL(redirectURL string, idToken string) string {
 	/* #nosec URL is known to be good */
-	logoutPath, _ := url.Parse(fmt.Sprintf("https://%s/openid_connect/logout", p.hostname))
+	logoutPath, _ := url.Parse(fmt.Sprintf("%s/openid_connect/logout", p.baseURL))
 	// Parameters taken from https://developers.login.gov/oidc/#logout
 	params := url.Values{
 		"id_token_hint":            {idToken},
@@ -149,7 +158,12 @@ func (p LoginGovProvider) LogoutURL(redirectURL string, idToken string) string {
 func (p LoginGovProvider) TokenURL() string {
 	// TODO: Get the token endpoint URL from Goth instead when
 	// https://github.com/markbates/goth/pull/207 is resolved
-	return fmt.Sprintf("https://%s/api/openid_connect/token", p.hostname)
+	return fmt.Sprintf("%s/api/openid_connect/token", p.baseURL)
+}
+
+// Issuer returns the issuer of login.gov ID tokens
+func (p LoginGovProvider) Issuer() string {
+	return fmt.Sprintf("%s/", p.baseURL)
 }
 
 // TokenParams creates query params for use in the token endpoint
@@ -0,0 +1,241 @@
+package authentication
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/transcom/mymove/pkg/auth"
+	"github.com/transcom/mymove/pkg/auth/oidctest"
+	"github.com/transcom/mymove/pkg/models"
+)
+
+const (
+	standInMyHost   = "my.move.host"
+	standInCallback = 1234
+	standInClientID = "urn:gov:gsa:openidconnect.profiles:sp:sso:dod:mymovemillocal"
+)
+
+// loginFlow logs in to a local stand-in for login.gov, as a browser would
+type loginFlow struct {
+	suite         *AuthSuite
+	standIn       *oidctest.Server
+	sessions      *auth.SessionManager
+	loginAttempts *MemoryLoginAttemptStore
+	redirect      http.Handler
+	callback      http.Handler
+}
+
+func (suite *AuthSuite) newLoginFlow() *loginFlow {
+	standIn, err := oidctest.NewServer()
+	suite.NoError(err)
+
+	clientKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	suite.NoError(err)
+	clientKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(clientKey)})
+
+	provider := newLoginGovProvider(standIn.URL, string(clientKeyPEM), standIn.Client(), suite.logger)
+	err = provider.RegisterProvider(standInMyHost, standInClientID, "office.move.host", "office-client",
+		"tsp.move.host", "tsp-client", "http://", standInCallback)
+	suite.NoError(err)
+
+	authContext := NewAuthContext(suite.logger, provider, "http://", standInCallback)
+	sessions := auth.NewSessionManager(suite.logger, auth.NewMemorySessionStore(), auth.DefaultSessionTimeouts)
+	loginAttempts := NewMemoryLoginAttemptStore()
+	detector := auth.DetectorMiddleware(suite.logger, standInMyHost, "office.move.host", "tsp.move.host")
+	return &loginFlow{
+		suite:         suite,
+		standIn:       standIn,
+		sessions:      sessions,
+		loginAttempts: loginAttempts,
+		redirect:      sessions.SessionCookieMiddleware(detector(NewRedirectHandler(authContext, loginAttempts))),
+		callback:      sessions.SessionCookieMiddleware(detector(NewCallbackHandler(authContext, suite.db, sessions, loginAttempts))),
+	}
+}
+
+// start begins to log in, returning the login attempt cookie and the URL login.gov redirects
+// back to
+func (f *loginFlow) start() (*http.Cookie, *url.URL) {
+	req := httptest.NewRequest("GET", "/auth/login-gov", nil)
+	req.Host = standInMyHost
+	rr := httptest.NewRecorder()
+	f.redirect.ServeHTTP(rr, req)
+	f.suite.Equal(http.StatusTemporaryRedirect, rr.Code)
+
+	var attemptCookie *http.Cookie
+	for _, cookie := range (&http.Response{Header: rr.HeaderMap}).Cookies() {
+		if cookie.Name == LoginAttemptCookieName {
+			attemptCookie = cookie
+		}
+	}
+	f.suite.NotNil(attemptCookie, "expected a login attempt cookie")
+	f.suite.True(attemptCookie.HttpOnly)
+	f.suite.Contains(rr.HeaderMap.Get("Set-Cookie"), "; SameSite=Lax")
+
+	// The stand-in logs the user in immediately and redirects back
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	response, err := client.Get(rr.Header().Get("Location"))
+	f.suite.NoError(err)
+	defer response.Body.Close()
+	f.suite.Equal(http.StatusFound, response.StatusCode)
+	callbackURL, err := url.Parse(response.Header.Get("Location"))
+	f.suite.NoError(err)
+	return attemptCookie, callbackURL
+}
+
+// finish handles login.gov's callback, with the login attempt cookie if there is one
+func (f *loginFlow) finish(attemptCookie *http.Cookie, callbackURL *url.URL) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", callbackURL.RequestURI(), nil)
+	req.Host = standInMyHost
+	if attemptCookie != nil {
+		req.AddCookie(&http.Cookie{Name: attemptCookie.Name, Value: attemptCookie.Value})
+	}
+	rr := httptest.NewRecorder()
+	f.callback.ServeHTTP(rr, req)
+	return rr
+}
+
+func (suite *AuthSuite) TestLoginGovCallback() {
+	flow := suite.newLoginFlow()
+	defer flow.standIn.Close()
+
+	attemptCookie, callbackURL := flow.start()
+	rr := flow.finish(attemptCookie, callbackURL)
+
+	suite.Equal(http.StatusTemporaryRedirect, rr.Code)
+	user := models.User{}
+	err := suite.db.Where("login_gov_email = $1", flow.standIn.User.Email).First(&user)
+	suite.NoError(err, "expected the user to be created")
+	suite.Equal(uuid.Must(uuid.FromString(flow.standIn.User.Subject)), user.LoginGovUUID)
+
+	sessionsForUser, err := flow.sessions.Store().ListForUser(user.ID, time.Now())
+	suite.NoError(err)
+	suite.Len(sessionsForUser, 1, "expected the user to be logged in")
+
+	// The callback can't be replayed
+	rr = flow.finish(attemptCookie, callbackURL)
+	suite.Equal(http.StatusUnauthorized, rr.Code)
+}
+
+func (suite *AuthSuite) TestLoginGovCallbackWithoutAttempt() {
+	flow := suite.newLoginFlow()
+	defer flow.standIn.Close()
+
+	_, callbackURL := flow.start()
+	rr := flow.finish(nil, callbackURL)
+	suite.Equal(http.StatusUnauthorized, rr.Code)
+}
+
+func (suite *AuthSuite) TestLoginGovCallbackWithWrongState() {
+	flow := suite.newLoginFlow()
+	defer flow.standIn.Close()
+
+	// A callback for one login attempt can't complete another, as in login CSRF
+	attemptCookie, _ := flow.start()
+	_, otherCallbackURL := flow.start()
+	rr := flow.finish(attemptCookie, otherCallbackURL)
+	suite.Equal(http.StatusUnauthorized, rr.Code)
+}
+
+func (suite *AuthSuite) TestLoginGovCallbackWithExpiredAttempt() {
+	flow := suite.newLoginFlow()
+	defer flow.standIn.Close()
+
+	attemptCookie, callbackURL := flow.start()
+	attempt, err := flow.loginAttempts.Take(auth.SessionKey(attemptCookie.Value))
+	suite.NoError(err)
+	attempt.ExpiresAt = time.Now().Add(-time.Second)
+	suite.NoError(flow.loginAttempts.Create(attempt))
+
+	rr := flow.finish(attemptCookie, callbackURL)
+	suite.Equal(http.StatusUnauthorized, rr.Code)
+}
+
+func (suite *AuthSuite) TestLoginGovCallbackWithInvalidIDTokens() {
+	forger, err := rsa.GenerateKey(rand.Reader, 2048)
+	suite.NoError(err)
+
+	cases := map[string]func(standIn *oidctest.Server){
+		"wrong nonce": func(standIn *oidctest.Server) {
+			standIn.Tamper = func(claims *oidctest.IDTokenClaims) { claims.Nonce = "replayed" }
+		},
+		"wrong audience": func(standIn *oidctest.Server) {
+			standIn.Tamper = func(claims *oidctest.IDTokenClaims) { claims.Audience = "another-client" }
+		},
+		"wrong issuer": func(standIn *oidctest.Server) {
+			standIn.Tamper = func(claims *oidctest.IDTokenClaims) { claims.Issuer = "https://evil.example.com/" }
+		},
+		"expired": func(standIn *oidctest.Server) {
+			standIn.Tamper = func(claims *oidctest.IDTokenClaims) { claims.ExpiresAt = time.Now().Add(-time.Minute).Unix() }
+		},
+		"forged signature": func(standIn *oidctest.Server) {
+			standIn.SigningKey = forger
+		},
+	}
+	for name, tamper := range cases {
+		flow := suite.newLoginFlow()
+		attemptCookie, callbackURL := flow.start()
+		tamper(flow.standIn)
+
+		rr := flow.finish(attemptCookie, callbackURL)
+		suite.Equal(http.StatusUnauthorized, rr.Code, name)
+		flow.standIn.Close()
+	}
+
+	count, err := suite.db.Count(&models.Users{})
+	suite.NoError(err)
+	suite.Equal(0, count, "expected nobody to be logged in")
+}
+
+func (suite *AuthSuite) TestLoginAttemptStore() {
+	store := NewPostgresLoginAttemptStore(suite.db)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	attempt := LoginAttempt{Key: auth.SessionKey("attempt"), State: "state", Nonce: "nonce", CreatedAt: now, ExpiresAt: now.Add(LoginAttemptTimeout)}
+	suite.NoError(store.Create(&attempt))
+	expired := LoginAttempt{Key: auth.SessionKey("expired"), State: "state", Nonce: "nonce", CreatedAt: now, ExpiresAt: now.Add(-time.Minute)}
+	suite.NoError(store.Create(&expired))
+
+	count, err := store.DeleteExpired(now)
+	suite.NoError(err)
+	suite.Equal(1, count)
+
+	taken, err := store.Take(attempt.Key)
+	suite.NoError(err)
+	suite.Equal("nonce", taken.Nonce)
+	_, err = store.Take(attempt.Key)
+	suite.Equal(ErrLoginAttemptNotFound, err, "expected attempts to be used only once")
+}
+
+func (suite *AuthSuite) TestLoginGovCallbackWithTokenForAnotherUser() {
+	flow := suite.newLoginFlow()
+	defer flow.standIn.Close()
+
+	// The ID token is valid, but isn't for the user the user info is about
+	flow.standIn.Tamper = func(claims *oidctest.IDTokenClaims) {
+		claims.Subject = "0b7f3c2e-4a1d-4b8e-9f6a-2c5d8e1f3a47"
+	}
+	attemptCookie, callbackURL := flow.start()
+	rr := flow.finish(attemptCookie, callbackURL)
+	suite.Equal(http.StatusUnauthorized, rr.Code)
+}
+
+func (suite *AuthSuite) TestLoginAttemptCookieIsSecure() {
+	rr := httptest.NewRecorder()
+	_, err := startLoginAttempt(rr, NewMemoryLoginAttemptStore(), time.Now(), true)
+	suite.NoError(err)
+
+	cookies := (&http.Response{Header: rr.HeaderMap}).Cookies()
+	suite.Len(cookies, 1)
+	suite.True(cookies[0].Secure)
+	suite.Contains(rr.HeaderMap.Get("Set-Cookie"), "; SameSite=Lax")
+}
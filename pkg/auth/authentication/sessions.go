@@ -0,0 +1,150 @@
+package authentication
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"go.uber.org/zap"
+	"goji.io/pat"
+
+	"github.com/transcom/mymove/pkg/auth"
+)
+
+// sessionPayload describes one of a user's sessions, so they can recognize and revoke it
+type sessionPayload struct {
+	Key        string    `json:"key"`
+	Current    bool      `json:"current"`
+	UserAgent  string    `json:"user_agent"`
+	RemoteAddr string    `json:"remote_addr"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// SessionListHandler lists the logged in user's active sessions
+type SessionListHandler struct {
+	Context
+	sessions *auth.SessionManager
+}
+
+// NewSessionListHandler creates a new SessionListHandler
+func NewSessionListHandler(ac Context, sessions *auth.SessionManager) SessionListHandler {
+	return SessionListHandler{Context: ac, sessions: sessions}
+}
+
+func (h SessionListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	session := auth.SessionFromRequestContext(r)
+	if session == nil || session.UserID == uuid.Nil {
+		http.Error(w, http.StatusText(401), http.StatusUnauthorized)
+		return
+	}
+
+	stored, err := h.sessions.Store().ListForUser(session.UserID, time.Now())
+	if err != nil {
+		h.logger.Error("Listing sessions", zap.Error(err))
+		http.Error(w, http.StatusText(500), http.StatusInternalServerError)
+		return
+	}
+	currentKey := auth.SessionKey(session.ID)
+	payload := make([]sessionPayload, len(stored))
+	for i, s := range stored {
+		expiresAt := s.ExpiresAt
+		if s.IdleExpiresAt.Before(expiresAt) {
+			expiresAt = s.IdleExpiresAt
+		}
+		payload[i] = sessionPayload{
+			Key:        s.Key,
+			Current:    s.Key == currentKey,
+			UserAgent:  s.UserAgent,
+			RemoteAddr: s.RemoteAddr,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			ExpiresAt:  expiresAt,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(payload)
+	if err != nil {
+		h.logger.Error("Encoding sessions", zap.Error(err))
+	}
+}
+
+// RevokeSessionHandler logs the user out of one of their sessions, by key
+type RevokeSessionHandler struct {
+	Context
+	sessions *auth.SessionManager
+}
+
+// NewRevokeSessionHandler creates a new RevokeSessionHandler
+func NewRevokeSessionHandler(ac Context, sessions *auth.SessionManager) RevokeSessionHandler {
+	return RevokeSessionHandler{Context: ac, sessions: sessions}
+}
+
+func (h RevokeSessionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	session := auth.SessionFromRequestContext(r)
+	if session == nil || session.UserID == uuid.Nil {
+		http.Error(w, http.StatusText(401), http.StatusUnauthorized)
+		return
+	}
+
+	key := pat.Param(r, "key")
+	if key == auth.SessionKey(session.ID) {
+		err := h.sessions.End(w, session)
+		if err != nil {
+			h.logger.Error("Ending session", zap.Error(err))
+			http.Error(w, http.StatusText(500), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Users can only revoke their own sessions
+	stored, err := h.sessions.Store().Fetch(key, time.Now())
+	if err == auth.ErrSessionNotFound || (err == nil && stored.UserID != session.UserID) {
+		http.Error(w, http.StatusText(404), http.StatusNotFound)
+		return
+	}
+	if err == nil {
+		err = h.sessions.Store().Delete(key)
+	}
+	if err != nil {
+		h.logger.Error("Revoking session", zap.Error(err))
+		http.Error(w, http.StatusText(500), http.StatusInternalServerError)
+		return
+	}
+	h.logger.Info("Revoked session", zap.String("user_id", session.UserID.String()))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutEverywhereHandler logs the user out of every one of their sessions
+type LogoutEverywhereHandler struct {
+	Context
+	sessions *auth.SessionManager
+}
+
+// NewLogoutEverywhereHandler creates a new LogoutEverywhereHandler
+func NewLogoutEverywhereHandler(ac Context, sessions *auth.SessionManager) LogoutEverywhereHandler {
+	return LogoutEverywhereHandler{Context: ac, sessions: sessions}
+}
+
+func (h LogoutEverywhereHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	session := auth.SessionFromRequestContext(r)
+	if session == nil || session.UserID == uuid.Nil {
+		http.Error(w, http.StatusText(401), http.StatusUnauthorized)
+		return
+	}
+
+	userID := session.UserID
+	count, err := h.sessions.EndAllForUser(w, session)
+	if err != nil {
+		h.logger.Error("Ending sessions", zap.Error(err))
+		http.Error(w, http.StatusText(500), http.StatusInternalServerError)
+		return
+	}
+	h.logger.Info("Logged out everywhere", zap.String("user_id", userID.String()), zap.Int("sessions", count))
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,192 @@
+package authentication
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"goji.io"
+	"goji.io/pat"
+
+	"github.com/transcom/mymove/pkg/auth"
+	"github.com/transcom/mymove/pkg/models"
+)
+
+func (suite *AuthSuite) makeUser(email string) models.User {
+	user := models.User{
+		LoginGovUUID:  uuid.Must(uuid.NewV4()),
+		LoginGovEmail: email,
+	}
+	suite.mustSave(&user)
+	return user
+}
+
+// startSession logs a user in and returns their session, as the next request would see it
+func (suite *AuthSuite) startSession(sessions *auth.SessionManager, user models.User) auth.Session {
+	session := auth.Session{UserID: user.ID, Email: user.LoginGovEmail, IDToken: "fake Token"}
+	req := httptest.NewRequest("GET", "/auth/login-gov/callback", nil)
+	err := sessions.Start(httptest.NewRecorder(), req, &session)
+	suite.NoError(err)
+	return session
+}
+
+func (suite *AuthSuite) TestPostgresSessionStore() {
+	user := suite.makeUser("sessions@example.com")
+	otherUser := suite.makeUser("other@example.com")
+	store := auth.NewPostgresSessionStore(suite.db)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	stored := auth.StoredSession{
+		Key:           auth.SessionKey("first"),
+		UserID:        user.ID,
+		Session:       auth.Session{UserID: user.ID, Email: user.LoginGovEmail, IDToken: "fake Token"},
+		UserAgent:     "Mozilla/5.0",
+		RemoteAddr:    "10.0.0.1",
+		CreatedAt:     now,
+		LastSeenAt:    now,
+		IdleExpiresAt: now.Add(time.Hour),
+		ExpiresAt:     now.Add(2 * time.Hour),
+	}
+	suite.NoError(store.Create(&stored))
+	expired := stored
+	expired.Key = auth.SessionKey("expired")
+	expired.CreatedAt = now.Add(-3 * time.Hour)
+	expired.ExpiresAt = now.Add(-time.Hour)
+	suite.NoError(store.Create(&expired))
+	other := stored
+	other.Key = auth.SessionKey("other")
+	other.UserID = otherUser.ID
+	other.Session.UserID = otherUser.ID
+	suite.NoError(store.Create(&other))
+
+	fetched, err := store.Fetch(stored.Key, now)
+	suite.NoError(err)
+	suite.Equal(user.ID, fetched.UserID)
+	suite.Equal("fake Token", fetched.Session.IDToken)
+	suite.Equal("Mozilla/5.0", fetched.UserAgent)
+
+	// Expired and missing sessions aren't found
+	_, err = store.Fetch(expired.Key, now)
+	suite.Equal(auth.ErrSessionNotFound, err)
+	_, err = store.Fetch(auth.SessionKey("missing"), now)
+	suite.Equal(auth.ErrSessionNotFound, err)
+	_, err = store.Fetch(stored.Key, now.Add(time.Hour))
+	suite.Equal(auth.ErrSessionNotFound, err, "Expected the idle timeout to apply")
+
+	// Touching a session extends its idle timeout
+	suite.NoError(store.Touch(stored.Key, now.Add(30*time.Minute), now.Add(90*time.Minute)))
+	fetched, err = store.Fetch(stored.Key, now.Add(time.Hour))
+	suite.NoError(err)
+	suite.True(now.Add(30 * time.Minute).Equal(fetched.LastSeenAt))
+
+	// Saving a session replaces what is stored about the user
+	fetched.Session.FirstName = "Sally"
+	suite.NoError(store.Save(stored.Key, &fetched.Session))
+	fetched, err = store.Fetch(stored.Key, now)
+	suite.NoError(err)
+	suite.Equal("Sally", fetched.Session.FirstName)
+
+	listed, err := store.ListForUser(user.ID, now)
+	suite.NoError(err)
+	suite.Len(listed, 1)
+	suite.Equal(stored.Key, listed[0].Key)
+
+	count, err := store.DeleteExpired(now)
+	suite.NoError(err)
+	suite.Equal(1, count)
+
+	count, err = store.DeleteForUser(user.ID)
+	suite.NoError(err)
+	suite.Equal(1, count)
+	_, err = store.Fetch(stored.Key, now)
+	suite.Equal(auth.ErrSessionNotFound, err)
+
+	suite.NoError(store.Delete(other.Key))
+	_, err = store.Fetch(other.Key, now)
+	suite.Equal(auth.ErrSessionNotFound, err)
+}
+
+func (suite *AuthSuite) TestSessionListHandler() {
+	user := suite.makeUser("sessions@example.com")
+	sessions := auth.NewSessionManager(suite.logger, auth.NewMemorySessionStore(), auth.DefaultSessionTimeouts)
+	authContext := NewAuthContext(suite.logger, fakeLoginGovProvider(suite.logger), "http://", 1234)
+	suite.startSession(sessions, user)
+	session := suite.startSession(sessions, user)
+
+	req := httptest.NewRequest("GET", "/auth/sessions", nil)
+	req = req.WithContext(auth.SetSessionInRequestContext(req, &session))
+	rr := httptest.NewRecorder()
+	NewSessionListHandler(authContext, sessions).ServeHTTP(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var payload []sessionPayload
+	suite.NoError(json.Unmarshal(rr.Body.Bytes(), &payload))
+	suite.Len(payload, 2)
+	current := 0
+	for _, p := range payload {
+		if p.Current {
+			current++
+			suite.Equal(auth.SessionKey(session.ID), p.Key)
+		}
+	}
+	suite.Equal(1, current, "Expected exactly one current session")
+}
+
+func (suite *AuthSuite) TestRevokeSessionHandler() {
+	user := suite.makeUser("sessions@example.com")
+	otherUser := suite.makeUser("other@example.com")
+	sessions := auth.NewSessionManager(suite.logger, auth.NewMemorySessionStore(), auth.DefaultSessionTimeouts)
+	authContext := NewAuthContext(suite.logger, fakeLoginGovProvider(suite.logger), "http://", 1234)
+	revoked := suite.startSession(sessions, user)
+	session := suite.startSession(sessions, user)
+	otherSession := suite.startSession(sessions, otherUser)
+
+	mux := goji.NewMux()
+	mux.Handle(pat.Delete("/auth/sessions/:key"), NewRevokeSessionHandler(authContext, sessions))
+	revoke := func(key string) int {
+		req := httptest.NewRequest("DELETE", "/auth/sessions/"+key, nil)
+		req = req.WithContext(auth.SetSessionInRequestContext(req, &session))
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	// Users can't revoke the sessions of other users
+	suite.Equal(http.StatusNotFound, revoke(auth.SessionKey(otherSession.ID)))
+	_, err := sessions.Store().Fetch(auth.SessionKey(otherSession.ID), time.Now())
+	suite.NoError(err)
+
+	suite.Equal(http.StatusNoContent, revoke(auth.SessionKey(revoked.ID)))
+	_, err = sessions.Store().Fetch(auth.SessionKey(revoked.ID), time.Now())
+	suite.Equal(auth.ErrSessionNotFound, err)
+	_, err = sessions.Store().Fetch(auth.SessionKey(session.ID), time.Now())
+	suite.NoError(err, "Expected the current session to remain")
+}
+
+func (suite *AuthSuite) TestLogoutEverywhereHandler() {
+	user := suite.makeUser("sessions@example.com")
+	otherUser := suite.makeUser("other@example.com")
+	sessions := auth.NewSessionManager(suite.logger, auth.NewMemorySessionStore(), auth.DefaultSessionTimeouts)
+	authContext := NewAuthContext(suite.logger, fakeLoginGovProvider(suite.logger), "http://", 1234)
+	elsewhere := suite.startSession(sessions, user)
+	session := suite.startSession(sessions, user)
+	otherSession := suite.startSession(sessions, otherUser)
+
+	endedIDs := []string{elsewhere.ID, session.ID}
+
+	req := httptest.NewRequest("POST", "/auth/logout-everywhere", nil)
+	req = req.WithContext(auth.SetSessionInRequestContext(req, &session))
+	rr := httptest.NewRecorder()
+	NewLogoutEverywhereHandler(authContext, sessions).ServeHTTP(rr, req)
+
+	suite.Equal(http.StatusNoContent, rr.Code)
+	suite.Equal(uuid.Nil, session.UserID, "Expected the current session to be logged out")
+	for _, id := range endedIDs {
+		_, err := sessions.Store().Fetch(auth.SessionKey(id), time.Now())
+		suite.Equal(auth.ErrSessionNotFound, err)
+	}
+	_, err := sessions.Store().Fetch(auth.SessionKey(otherSession.ID), time.Now())
+	suite.NoError(err, "Expected other users' sessions to remain")
+}
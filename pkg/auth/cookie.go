@@ -1,137 +1,280 @@
 package auth
 
 import (
-	"github.com/dgrijalva/jwt-go"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/gofrs/uuid"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
-	"net/http"
-	"time"
 )
 
-// UserSessionCookieName is the key at which we're storing our token cookie
+// UserSessionCookieName is the key at which we're storing our session ID cookie
 const UserSessionCookieName = "session_token"
 
+// UserInfoCookieName is the key at which we're storing the cookie the client reads to show who
+// is logged in. It grants nothing: the session is only found through the session ID cookie.
+const UserInfoCookieName = "user_info"
+
 // SessionExpiryInMinutes is the number of minutes before a fallow session is harvested
 const SessionExpiryInMinutes = 15
-const sessionExpiryInSeconds = 15 * 60
 
 // A representable date far in the future.  The trouble with something like https://stackoverflow.com/a/32620397
 // is that it produces a date which may not marshall well into JSON which makes logging problematic
 var likeForever = time.Date(9999, 1, 1, 12, 0, 0, 0, time.UTC)
-var likeForeverInSeconds = 99999999
 
 // GetExpiryTimeFromMinutes returns 'min' minutes from now
 func GetExpiryTimeFromMinutes(min int64) time.Time {
 	return time.Now().Add(time.Minute * time.Duration(min))
 }
 
-// SessionClaims wraps StandardClaims with some Session info
-type SessionClaims struct {
-	jwt.StandardClaims
-	SessionValue Session
+// SessionTimeouts are how long sessions last. A zero timeout never expires.
+type SessionTimeouts struct {
+	// Idle is how long a session lasts without being used
+	Idle time.Duration
+	// Absolute is how long a session lasts after logging in, however much it is used
+	Absolute time.Duration
+}
+
+// DefaultSessionTimeouts are the timeouts used unless the server is configured otherwise
+var DefaultSessionTimeouts = SessionTimeouts{
+	Idle:     SessionExpiryInMinutes * time.Minute,
+	Absolute: 12 * time.Hour,
 }
 
-func signTokenStringWithUserInfo(expiry time.Time, session *Session, secret string) (string, error) {
-	claims := SessionClaims{
-		StandardClaims: jwt.StandardClaims{ExpiresAt: expiry.Unix()},
-		SessionValue:   *session,
+func expiryAfter(now time.Time, timeout time.Duration) time.Time {
+	if timeout == 0 {
+		return likeForever
 	}
+	return now.Add(timeout)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(secret))
-	if err != nil {
-		err = errors.Wrap(err, "Parsing RSA key from PEM")
-		return "", err
+// userInfo is what the client is told about the logged in user
+type userInfo struct {
+	Email     string
+	UserID    uuid.UUID
+	FirstName string
+}
+
+// SessionManager keeps the sessions of logged in users in a SessionStore, and keeps the ID of
+// the current session in a cookie
+type SessionManager struct {
+	logger        *zap.Logger
+	store         SessionStore
+	timeouts      SessionTimeouts
+	secureCookies bool
+	now           func() time.Time
+}
+
+// NewSessionManager creates a new SessionManager
+func NewSessionManager(logger *zap.Logger, store SessionStore, timeouts SessionTimeouts) *SessionManager {
+	return &SessionManager{
+		logger:   logger,
+		store:    store,
+		timeouts: timeouts,
+		now:      time.Now,
+	}
+}
+
+// SetSecureCookies sets whether the session cookies may only be sent over HTTPS, as they must be
+// whenever the site is served over HTTPS
+func (m *SessionManager) SetSecureCookies(secure bool) {
+	m.secureCookies = secure
+}
+
+// Store returns the store sessions are kept in
+func (m *SessionManager) Store() SessionStore {
+	return m.store
+}
+
+// Start logs a user in, starting a new session for them. Any session the request already had
+// is ended first, so a session ID set before logging in can never be used afterwards.
+func (m *SessionManager) Start(w http.ResponseWriter, r *http.Request, session *Session) error {
+	if session.UserID == uuid.Nil {
+		return errors.New("Can't start a session without a user")
+	}
+	if session.ID != "" {
+		err := m.store.Delete(SessionKey(session.ID))
+		if err != nil {
+			return err
+		}
 	}
 
-	ss, err := token.SignedString(rsaKey)
+	id, err := newSessionID()
 	if err != nil {
-		err = errors.Wrap(err, "Signing string with token")
-		return "", err
+		return err
+	}
+	now := m.now()
+	stored := StoredSession{
+		Key:           SessionKey(id),
+		UserID:        session.UserID,
+		Session:       *session,
+		UserAgent:     r.UserAgent(),
+		RemoteAddr:    remoteAddr(r),
+		CreatedAt:     now,
+		LastSeenAt:    now,
+		IdleExpiresAt: expiryAfter(now, m.timeouts.Idle),
+		ExpiresAt:     expiryAfter(now, m.timeouts.Absolute),
 	}
-	return ss, err
+	err = m.store.Create(&stored)
+	if err != nil {
+		return err
+	}
+	session.ID = id
+
+	m.writeCookies(w, id, session, stored.ExpiresAt)
+	return nil
 }
 
-func sessionClaimsFromRequest(logger *zap.Logger, secret string, r *http.Request) (claims *SessionClaims, ok bool) {
-	cookie, err := r.Cookie(UserSessionCookieName)
+// Save stores changes to the session of a logged in user, such as their name
+func (m *SessionManager) Save(w http.ResponseWriter, session *Session) error {
+	if session.ID == "" {
+		return errors.New("Can't save a session that hasn't been started")
+	}
+	key := SessionKey(session.ID)
+	err := m.store.Save(key, session)
 	if err != nil {
-		// No cookie set on client
-		return
+		return err
 	}
+	stored, err := m.store.Fetch(key, m.now())
+	if err != nil {
+		return err
+	}
+	m.writeCookies(w, session.ID, session, stored.ExpiresAt)
+	return nil
+}
 
-	token, err := jwt.ParseWithClaims(cookie.Value, &SessionClaims{}, func(token *jwt.Token) (interface{}, error) {
-		rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(secret))
-		return &rsaKey.PublicKey, err
-	})
-
-	if err != nil || token == nil || !token.Valid {
-		logger.Error("Failed token validation", zap.Error(err))
-		return
+// End logs the user out of the current session
+func (m *SessionManager) End(w http.ResponseWriter, session *Session) error {
+	m.clearCookies(w)
+	if session.ID == "" {
+		return nil
+	}
+	err := m.store.Delete(SessionKey(session.ID))
+	if err != nil {
+		return err
 	}
+	*session = Session{ApplicationName: session.ApplicationName, Hostname: session.Hostname}
+	return nil
+}
 
-	// The token actually just stores a Claims interface, so we need to explicitly cast back to UserClaims
-	claims, ok = token.Claims.(*SessionClaims)
-	if !ok {
-		logger.Error("Failed getting claims from token")
-		return
+// EndAllForUser logs the user out of every one of their sessions, including the current one
+func (m *SessionManager) EndAllForUser(w http.ResponseWriter, session *Session) (int, error) {
+	m.clearCookies(w)
+	if session.UserID == uuid.Nil {
+		return 0, nil
+	}
+	count, err := m.store.DeleteForUser(session.UserID)
+	if err != nil {
+		return 0, err
 	}
-	return claims, ok
+	*session = Session{ApplicationName: session.ApplicationName, Hostname: session.Hostname}
+	return count, nil
 }
 
-// WriteSessionCookie update the cookie for the session
-func WriteSessionCookie(w http.ResponseWriter, session *Session, secret string, noSessionTimeout bool, logger *zap.Logger) {
+func (m *SessionManager) writeCookies(w http.ResponseWriter, id string, session *Session, expiresAt time.Time) {
+	info, err := json.Marshal(userInfo{
+		Email:     session.Email,
+		UserID:    session.UserID,
+		FirstName: session.FirstName,
+	})
+	if err != nil {
+		m.logger.Error("Encoding user info", zap.Error(err))
+	}
+	maxAge := int(expiresAt.Sub(m.now()).Seconds())
 
-	// Delete the cookie
-	cookie := http.Cookie{
-		Name:    UserSessionCookieName,
-		Value:   "blank",
+	sessionCookie := http.Cookie{
+		Name:     UserSessionCookieName,
+		Value:    id,
+		Path:     "/",
+		Expires:  expiresAt,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   m.secureCookies,
+	}
+	infoCookie := http.Cookie{
+		Name:    UserInfoCookieName,
+		Value:   base64.StdEncoding.EncodeToString(info),
 		Path:    "/",
-		Expires: time.Unix(0, 0),
-		MaxAge:  -1,
-	}
-
-	// unless we have a valid session
-	if session.IDToken != "" && session.UserID != uuid.Nil {
-		expiry := GetExpiryTimeFromMinutes(SessionExpiryInMinutes)
-		maxAge := sessionExpiryInSeconds
-		// Never expire token if in development
-		if noSessionTimeout {
-			expiry = likeForever
-			maxAge = likeForeverInSeconds
-		}
+		Expires: expiresAt,
+		MaxAge:  maxAge,
+		Secure:  m.secureCookies,
+	}
+	SetCookie(w, &sessionCookie)
+	SetCookie(w, &infoCookie)
+}
 
-		ss, err := signTokenStringWithUserInfo(expiry, session, secret)
-		if err != nil {
-			logger.Error("Generating signed token string", zap.Error(err))
-		} else {
-			logger.Info("Cookie", zap.Int("Size", len(ss)))
-			cookie.Value = ss
-			cookie.Expires = expiry
-			cookie.MaxAge = maxAge
+func (m *SessionManager) clearCookies(w http.ResponseWriter) {
+	for _, name := range []string{UserSessionCookieName, UserInfoCookieName} {
+		cookie := http.Cookie{
+			Name:    name,
+			Value:   "blank",
+			Path:    "/",
+			Expires: time.Unix(0, 0),
+			MaxAge:  -1,
+			Secure:  m.secureCookies,
 		}
+		SetCookie(w, &cookie)
 	}
-	// http.SetCookie calls Header().Add() instead of .Set(), which can result in duplicate cookies
-	w.Header().Set("Set-Cookie", cookie.String())
 }
 
-// SessionCookieMiddleware handle serializing and de-serializing the session betweem the user_session cookie and the request context
-func SessionCookieMiddleware(logger *zap.Logger, secret string, noSessionTimeout bool) func(next http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		mw := func(w http.ResponseWriter, r *http.Request) {
-			session := Session{}
-			claims, ok := sessionClaimsFromRequest(logger, secret, r)
-			if ok {
-				session = claims.SessionValue
-			}
+// SetCookie sets a cookie, replacing any already set with the same name. http.SetCookie calls
+// Header().Add() instead of .Set(), which can result in duplicate cookies.
+//
+// The cookie is only sent on requests from other sites when they are top-level navigations, such
+// as following a link. http.Cookie can't express SameSite yet, so it is added to the header here.
+func SetCookie(w http.ResponseWriter, cookie *http.Cookie) {
+	prefix := cookie.Name + "="
+	var kept []string
+	for _, existing := range w.Header()["Set-Cookie"] {
+		if !strings.HasPrefix(existing, prefix) {
+			kept = append(kept, existing)
+		}
+	}
+	w.Header()["Set-Cookie"] = append(kept, cookie.String()+"; SameSite=Lax")
+}
 
-			// And put the session info into the request context
-			ctx := SetSessionInRequestContext(r, &session)
-			// And update the cookie. May get over-ridden later
-			WriteSessionCookie(w, &session, secret, noSessionTimeout, logger)
-			next.ServeHTTP(w, r.WithContext(ctx))
+func remoteAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
 
+// SessionCookieMiddleware loads the session named by the session cookie into the request
+// context, and extends its idle timeout. Requests without a current session get an empty one.
+func (m *SessionManager) SessionCookieMiddleware(next http.Handler) http.Handler {
+	mw := func(w http.ResponseWriter, r *http.Request) {
+		session := Session{}
+		cookie, err := r.Cookie(UserSessionCookieName)
+		if err == nil {
+			now := m.now()
+			key := SessionKey(cookie.Value)
+			stored, err := m.store.Fetch(key, now)
+			if err == nil {
+				session = stored.Session
+				session.ID = cookie.Value
+				err = m.store.Touch(key, now, expiryAfter(now, m.timeouts.Idle))
+				if err != nil {
+					m.logger.Error("Touching session", zap.Error(err))
+				}
+			} else {
+				if err != ErrSessionNotFound {
+					m.logger.Error("Fetching session", zap.Error(err))
+				}
+				// The session has expired or been revoked
+				m.clearCookies(w)
+			}
 		}
-		return http.HandlerFunc(mw)
+
+		// And put the session info into the request context
+		ctx := SetSessionInRequestContext(r, &session)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	}
+	return http.HandlerFunc(mw)
 }
@@ -1,205 +1,239 @@
 package auth
 
 import (
-	"crypto/rand"
-	"crypto/rsa"
-	"crypto/x509"
-	"encoding/pem"
-	"github.com/gofrs/uuid"
-	"github.com/pkg/errors"
+	"encoding/base64"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"time"
-)
-
-func createRandomRSAPEM() (s string, err error) {
-	priv, err := rsa.GenerateKey(rand.Reader, 1024)
-	if err != nil {
-		err = errors.Wrap(err, "failed to generate key")
-		return
-	}
 
-	asn1 := x509.MarshalPKCS1PrivateKey(priv)
-	privBytes := pem.EncodeToMemory(&pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: asn1,
-	})
-	s = string(privBytes[:])
+	"github.com/gofrs/uuid"
+)
 
-	return
-}
+var fakeUserID = uuid.Must(uuid.FromString("39b28c92-0506-4bef-8b57-e39519f42dc2"))
 
-func getHandlerParamsWithToken(ss string, expiry time.Time) (*httptest.ResponseRecorder, *http.Request) {
+func getHandlerParamsWithCookie(id string) (*httptest.ResponseRecorder, *http.Request) {
 	rr := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/protected", nil)
 
-	// Set a secure cookie on the request
 	cookie := http.Cookie{
-		Name:    UserSessionCookieName,
-		Value:   ss,
-		Path:    "/",
-		Expires: expiry,
+		Name:  UserSessionCookieName,
+		Value: id,
+		Path:  "/",
 	}
 	req.AddCookie(&cookie)
 	return rr, req
 }
 
-func (suite *authSuite) TestSessionCookieMiddlewareWithBadToken() {
-	t := suite.T()
-	fakeToken := "some_token"
-	pem, err := createRandomRSAPEM()
-	if err != nil {
-		t.Error("error creating RSA key", err)
+// startSession logs in a fake user and returns the session ID set in the cookie
+func (suite *authSuite) startSession(manager *SessionManager) string {
+	session := Session{
+		UserID:    fakeUserID,
+		Email:     "some_email@domain.com",
+		FirstName: "Some",
+		IDToken:   "fake_id_token",
 	}
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/auth/login-gov/callback", nil)
+	err := manager.Start(rr, req, &session)
+	suite.NoError(err)
+	suite.NotEmpty(session.ID)
+	return session.ID
+}
 
+// serveWithSession runs the middleware for a request with a session ID and returns the session
+// put in the request context
+func (suite *authSuite) serveWithSession(manager *SessionManager, id string) (*httptest.ResponseRecorder, *Session) {
 	var resultingSession *Session
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		resultingSession = SessionFromRequestContext(r)
 	})
-	middleware := SessionCookieMiddleware(suite.logger, pem, false)(handler)
-
-	expiry := GetExpiryTimeFromMinutes(SessionExpiryInMinutes)
-	rr, req := getHandlerParamsWithToken(fakeToken, expiry)
-
-	middleware.ServeHTTP(rr, req)
+	rr, req := getHandlerParamsWithCookie(id)
+	manager.SessionCookieMiddleware(handler).ServeHTTP(rr, req)
 
 	// We should be not be redirected since we're not enforcing auth
 	suite.Equal(http.StatusOK, rr.Code, "handler returned wrong status code")
-
-	// And there should be no token passed through
 	suite.NotNil(resultingSession, "Session should not be nil")
-	suite.Equal("", resultingSession.IDToken, "Expected empty IDToken from bad cookie")
+	return rr, resultingSession
 }
 
-func (suite *authSuite) TestSessionCookieMiddlewareWithValidToken() {
-	t := suite.T()
-	email := "some_email@domain.com"
-	idToken := "fake_id_token"
-	fakeUUID, _ := uuid.FromString("39b28c92-0506-4bef-8b57-e39519f42dc2")
+func (suite *authSuite) TestSessionCookieMiddlewareWithBadToken() {
+	manager := NewSessionManager(suite.logger, NewMemorySessionStore(), DefaultSessionTimeouts)
+
+	rr, session := suite.serveWithSession(manager, "some_token")
 
-	pem, err := createRandomRSAPEM()
-	if err != nil {
-		t.Fatal(err)
-	}
+	// And there should be no session passed through
+	suite.Equal("", session.IDToken, "Expected empty IDToken from bad cookie")
+	suite.Equal(uuid.Nil, session.UserID)
+	// And the cookies should be cleared
+	suite.Equal(2, len(rr.HeaderMap["Set-Cookie"]), "expected cookies to be cleared")
+}
 
-	expiry := GetExpiryTimeFromMinutes(SessionExpiryInMinutes)
-	incomingSession := Session{
-		UserID:  fakeUUID,
-		Email:   email,
-		IDToken: idToken,
-	}
-	ss, err := signTokenStringWithUserInfo(expiry, &incomingSession, pem)
-	if err != nil {
-		t.Fatal(err)
-	}
-	rr, req := getHandlerParamsWithToken(ss, expiry)
+func (suite *authSuite) TestSessionCookieMiddlewareWithValidSession() {
+	store := NewMemorySessionStore()
+	manager := NewSessionManager(suite.logger, store, DefaultSessionTimeouts)
+	id := suite.startSession(manager)
+
+	later := time.Now().Add(10 * time.Minute)
+	manager.now = func() time.Time { return later }
+	rr, session := suite.serveWithSession(manager, id)
+
+	suite.Equal("fake_id_token", session.IDToken, "handler returned wrong id_token")
+	suite.Equal(fakeUserID, session.UserID)
+	suite.Equal(id, session.ID)
+	// The cookie doesn't need to be rewritten
+	suite.Equal(0, len(rr.HeaderMap["Set-Cookie"]))
+
+	// And the idle timeout should be extended
+	stored, err := store.Fetch(SessionKey(id), later)
+	suite.NoError(err)
+	suite.Equal(later, stored.LastSeenAt)
+	suite.Equal(later.Add(DefaultSessionTimeouts.Idle), stored.IdleExpiresAt)
+}
 
-	var resultingSession *Session
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		resultingSession = SessionFromRequestContext(r)
-	})
-	middleware := SessionCookieMiddleware(suite.logger, pem, false)(handler)
+func (suite *authSuite) TestSessionCookieMiddlewareWithIdleSession() {
+	manager := NewSessionManager(suite.logger, NewMemorySessionStore(), DefaultSessionTimeouts)
+	id := suite.startSession(manager)
 
-	middleware.ServeHTTP(rr, req)
+	later := time.Now().Add(DefaultSessionTimeouts.Idle + time.Minute)
+	manager.now = func() time.Time { return later }
+	rr, session := suite.serveWithSession(manager, id)
 
-	// We should get a 200 OK
-	suite.Equal(http.StatusOK, rr.Code, "handler returned wrong status code")
+	suite.Equal("", session.IDToken, "Expected empty IDToken from idle session")
+	suite.Equal(uuid.Nil, session.UserID, "Expected no UUID from idle session")
+	suite.Equal(2, len(rr.HeaderMap["Set-Cookie"]), "expected cookies to be cleared")
+}
 
-	// And there should be an ID token in the request context
-	suite.NotNil(resultingSession)
-	suite.Equal(idToken, resultingSession.IDToken, "handler returned wrong id_token")
+func (suite *authSuite) TestSessionCookieMiddlewareWithExpiredSession() {
+	timeouts := SessionTimeouts{Idle: time.Hour, Absolute: 2 * time.Hour}
+	manager := NewSessionManager(suite.logger, NewMemorySessionStore(), timeouts)
+	id := suite.startSession(manager)
+
+	// Staying active extends the idle timeout, but never past the absolute timeout
+	start := time.Now()
+	for _, elapsed := range []time.Duration{50 * time.Minute, 100 * time.Minute} {
+		now := start.Add(elapsed)
+		manager.now = func() time.Time { return now }
+		_, session := suite.serveWithSession(manager, id)
+		suite.Equal(fakeUserID, session.UserID)
+	}
 
-	// And the cookie should be renewed
-	setCookies := rr.HeaderMap["Set-Cookie"]
-	suite.Equal(1, len(setCookies), "expected cookie to be set")
+	later := start.Add(2*time.Hour + time.Minute)
+	manager.now = func() time.Time { return later }
+	_, session := suite.serveWithSession(manager, id)
+	suite.Equal(uuid.Nil, session.UserID, "Expected no UUID from expired session")
 }
 
-func (suite *authSuite) TestSessionCookieMiddlewareWithExpiredToken() {
-	t := suite.T()
-	email := "some_email@domain.com"
-	idToken := "fake_id_token"
-	fakeUUID, _ := uuid.FromString("39b28c92-0506-4bef-8b57-e39519f42dc2")
+func (suite *authSuite) TestSessionCookieMiddlewareWithNoTimeouts() {
+	manager := NewSessionManager(suite.logger, NewMemorySessionStore(), SessionTimeouts{})
+	id := suite.startSession(manager)
 
-	pem, err := createRandomRSAPEM()
-	if err != nil {
-		t.Fatal(err)
-	}
+	later := time.Now().Add(365 * 24 * time.Hour)
+	manager.now = func() time.Time { return later }
+	_, session := suite.serveWithSession(manager, id)
+	suite.Equal(fakeUserID, session.UserID)
+}
 
-	expiry := GetExpiryTimeFromMinutes(-1)
-	incomingSession := Session{
-		UserID:  fakeUUID,
-		Email:   email,
-		IDToken: idToken,
-	}
-	ss, err := signTokenStringWithUserInfo(expiry, &incomingSession, pem)
-	if err != nil {
-		t.Fatal(err)
+func (suite *authSuite) TestSessionStartSetsCookies() {
+	manager := NewSessionManager(suite.logger, NewMemorySessionStore(), DefaultSessionTimeouts)
+	session := Session{UserID: fakeUserID, Email: "some_email@domain.com", FirstName: "Some", IDToken: "fake_id_token"}
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/auth/login-gov/callback", nil)
+
+	suite.NoError(manager.Start(rr, req, &session))
+
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range (&http.Response{Header: rr.HeaderMap}).Cookies() {
+		cookies[cookie.Name] = cookie
 	}
+	suite.Equal(session.ID, cookies[UserSessionCookieName].Value)
+	suite.True(cookies[UserSessionCookieName].HttpOnly)
+	// The ID token never leaves the server
+	suite.False(strings.Contains(rr.HeaderMap.Get("Set-Cookie"), "fake_id_token"))
+
+	data, err := base64.StdEncoding.DecodeString(cookies[UserInfoCookieName].Value)
+	suite.NoError(err)
+	var info userInfo
+	suite.NoError(json.Unmarshal(data, &info))
+	suite.Equal(userInfo{Email: "some_email@domain.com", UserID: fakeUserID, FirstName: "Some"}, info)
+}
 
-	var resultingSession *Session
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		resultingSession = SessionFromRequestContext(r)
-	})
-	middleware := SessionCookieMiddleware(suite.logger, pem, false)(handler)
+func (suite *authSuite) TestSessionStartReplacesSession() {
+	manager := NewSessionManager(suite.logger, NewMemorySessionStore(), DefaultSessionTimeouts)
+	id := suite.startSession(manager)
 
-	rr, req := getHandlerParamsWithToken(ss, expiry)
+	session := Session{ID: id, UserID: fakeUserID}
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/auth/login-gov/callback", nil)
+	suite.NoError(manager.Start(rr, req, &session))
+	suite.NotEqual(id, session.ID)
 
-	middleware.ServeHTTP(rr, req)
+	_, old := suite.serveWithSession(manager, id)
+	suite.Equal(uuid.Nil, old.UserID, "Expected the old session to be ended")
+}
 
-	// We should be not be redirected since we're not enforcing auth
-	suite.Equal(http.StatusOK, rr.Code, "handler returned wrong status code")
+func (suite *authSuite) TestSessionSave() {
+	manager := NewSessionManager(suite.logger, NewMemorySessionStore(), DefaultSessionTimeouts)
+	id := suite.startSession(manager)
 
-	// And there should be no token passed through
-	// And there should be no token passed through
-	suite.NotNil(resultingSession)
-	suite.Equal("", resultingSession.IDToken, "Expected empty IDToken from expired")
-	suite.Equal(uuid.Nil, resultingSession.UserID, "Expected no UUID from expired cookie")
+	_, session := suite.serveWithSession(manager, id)
+	session.FirstName = "Other"
+	rr := httptest.NewRecorder()
+	suite.NoError(manager.Save(rr, session))
+	suite.Equal(2, len(rr.HeaderMap["Set-Cookie"]))
 
-	// And the cookie should be set
-	setCookies := rr.HeaderMap["Set-Cookie"]
-	suite.Equal(1, len(setCookies), "expected cookie to be set")
+	_, session = suite.serveWithSession(manager, id)
+	suite.Equal("Other", session.FirstName)
 }
 
-func (suite *authSuite) TestSessionCookiePR161162731() {
-	t := suite.T()
-	email := "some_email@domain.com"
-	idToken := "fake_id_token"
-	fakeUUID, _ := uuid.FromString("39b28c92-0506-4bef-8b57-e39519f42dc2")
+func (suite *authSuite) TestSessionEnd() {
+	manager := NewSessionManager(suite.logger, NewMemorySessionStore(), DefaultSessionTimeouts)
+	id := suite.startSession(manager)
+	otherID := suite.startSession(manager)
 
-	pem, err := createRandomRSAPEM()
-	if err != nil {
-		t.Fatal(err)
-	}
+	_, session := suite.serveWithSession(manager, id)
+	rr := httptest.NewRecorder()
+	suite.NoError(manager.End(rr, session))
+	suite.Equal(uuid.Nil, session.UserID)
+	suite.Equal(2, len(rr.HeaderMap["Set-Cookie"]), "expected cookies to be cleared")
+
+	_, session = suite.serveWithSession(manager, id)
+	suite.Equal(uuid.Nil, session.UserID, "Expected the session to be ended")
+	_, session = suite.serveWithSession(manager, otherID)
+	suite.Equal(fakeUserID, session.UserID, "Expected other sessions to remain")
+}
 
-	expiry := GetExpiryTimeFromMinutes(SessionExpiryInMinutes)
-	incomingSession := Session{
-		UserID:  fakeUUID,
-		Email:   email,
-		IDToken: idToken,
-	}
-	ss, err := signTokenStringWithUserInfo(expiry, &incomingSession, pem)
-	if err != nil {
-		t.Fatal(err)
-	}
-	rr, req := getHandlerParamsWithToken(ss, expiry)
+func (suite *authSuite) TestSessionEndAllForUser() {
+	manager := NewSessionManager(suite.logger, NewMemorySessionStore(), DefaultSessionTimeouts)
+	id := suite.startSession(manager)
+	otherID := suite.startSession(manager)
 
-	var resultingSession *Session
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		resultingSession = SessionFromRequestContext(r)
-		WriteSessionCookie(w, resultingSession, "freddy", false, suite.logger)
-	})
-	middleware := SessionCookieMiddleware(suite.logger, pem, false)(handler)
+	_, session := suite.serveWithSession(manager, id)
+	count, err := manager.EndAllForUser(httptest.NewRecorder(), session)
+	suite.NoError(err)
+	suite.Equal(2, count)
 
-	middleware.ServeHTTP(rr, req)
+	for _, ended := range []string{id, otherID} {
+		_, session = suite.serveWithSession(manager, ended)
+		suite.Equal(uuid.Nil, session.UserID, "Expected every session to be ended")
+	}
+}
 
-	// We should get a 200 OK
-	suite.Equal(http.StatusOK, rr.Code, "handler returned wrong status code")
+func (suite *authSuite) TestSessionCookiesAreSecure() {
+	manager := NewSessionManager(suite.logger, NewMemorySessionStore(), DefaultSessionTimeouts)
+	manager.SetSecureCookies(true)
+	session := Session{UserID: fakeUserID}
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/auth/login-gov/callback", nil)
 
-	// And there should be an ID token in the request context
-	suite.NotNil(resultingSession)
-	suite.Equal(idToken, resultingSession.IDToken, "handler returned wrong id_token")
+	suite.NoError(manager.Start(rr, req, &session))
 
-	// And the cookie should be renewed
-	setCookies := rr.HeaderMap["Set-Cookie"]
-	suite.Equal(1, len(setCookies), "expected cookie to be set")
+	for _, header := range rr.HeaderMap["Set-Cookie"] {
+		suite.Contains(header, "; Secure")
+		suite.Contains(header, "; SameSite=Lax")
+	}
+	for _, cookie := range (&http.Response{Header: rr.HeaderMap}).Cookies() {
+		suite.True(cookie.Secure, "Expected %s to be secure", cookie.Name)
+	}
 }
@@ -0,0 +1,210 @@
+// Package oidctest provides a local stand-in for login.gov, so that logging in can be tested
+// without it. It implements just enough of login.gov's OpenID Connect endpoints to complete
+// the authorization code flow: discovery, authorization, token, user info and signing keys.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// User is who the stand-in logs everyone in as
+type User struct {
+	Subject string
+	Email   string
+}
+
+// IDTokenClaims are the claims of the ID tokens the stand-in issues
+type IDTokenClaims struct {
+	jwt.StandardClaims
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+type authorization struct {
+	clientID string
+	nonce    string
+}
+
+// Server is a running stand-in for login.gov
+type Server struct {
+	*httptest.Server
+	// User is who is logged in
+	User User
+	// KeyID identifies the key ID tokens are signed with
+	KeyID string
+	// Key is the key ID tokens are signed with, and whose public key is published
+	Key *rsa.PrivateKey
+	// SigningKey, if set, signs ID tokens instead of Key, to test tokens that are forged
+	SigningKey *rsa.PrivateKey
+	// Tamper, if set, can change the claims of ID tokens before they are signed
+	Tamper func(claims *IDTokenClaims)
+
+	mutex sync.Mutex
+	codes map[string]authorization
+}
+
+// NewServer starts a new stand-in for login.gov. Close it when done.
+func NewServer() (*Server, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		User:  User{Subject: "c7b6a0d1-1e1b-4ba3-8c8e-4e1b5d6e2f10", Email: "standin@example.com"},
+		KeyID: "oidctest",
+		Key:   key,
+		codes: map[string]authorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/openid_connect/authorize", s.authorize)
+	mux.HandleFunc("/api/openid_connect/token", s.token)
+	mux.HandleFunc("/api/openid_connect/userinfo", s.userInfo)
+	mux.HandleFunc("/api/openid_connect/certs", s.certs)
+	s.Server = httptest.NewServer(mux)
+	return s, nil
+}
+
+// Issuer returns the issuer of the stand-in's ID tokens
+func (s *Server) Issuer() string {
+	return s.URL + "/"
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	/* #nosec G104 nothing more can be done if the client has gone away */
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"issuer":                 s.Issuer(),
+		"authorization_endpoint": s.URL + "/openid_connect/authorize",
+		"token_endpoint":         s.URL + "/api/openid_connect/token",
+		"userinfo_endpoint":      s.URL + "/api/openid_connect/userinfo",
+		"jwks_uri":               s.URL + "/api/openid_connect/certs",
+		"end_session_endpoint":   s.URL + "/openid_connect/logout",
+	})
+}
+
+// authorize logs the user in immediately, redirecting back to the client with a code
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	redirectURL, err := url.Parse(params.Get("redirect_uri"))
+	if err != nil || params.Get("client_id") == "" || params.Get("state") == "" || params.Get("nonce") == "" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+	s.mutex.Lock()
+	s.codes[code] = authorization{clientID: params.Get("client_id"), nonce: params.Get("nonce")}
+	s.mutex.Unlock()
+
+	query := redirectURL.Query()
+	query.Set("code", code)
+	query.Set("state", params.Get("state"))
+	redirectURL.RawQuery = query.Encode()
+	http.Redirect(w, r, redirectURL.String(), http.StatusFound)
+}
+
+// token exchanges a code for an ID token. Each code can only be used once.
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	code := r.PostFormValue("code")
+	s.mutex.Lock()
+	auth, ok := s.codes[code]
+	delete(s.codes, code)
+	s.mutex.Unlock()
+	if !ok {
+		writeJSON(w, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	// The client assertion is signed with the client's key, which the stand-in doesn't know,
+	// so only who it claims to be from is checked
+	assertion := jwt.StandardClaims{}
+	_, _, err := new(jwt.Parser).ParseUnverified(r.PostFormValue("client_assertion"), &assertion)
+	if err != nil || assertion.Issuer != auth.clientID {
+		writeJSON(w, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	now := time.Now()
+	claims := IDTokenClaims{
+		StandardClaims: jwt.StandardClaims{
+			Issuer:    s.Issuer(),
+			Subject:   s.User.Subject,
+			Audience:  auth.clientID,
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: now.Add(time.Hour).Unix(),
+		},
+		Nonce:         auth.nonce,
+		Email:         s.User.Email,
+		EmailVerified: true,
+	}
+	if s.Tamper != nil {
+		s.Tamper(&claims)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.KeyID
+	signingKey := s.Key
+	if s.SigningKey != nil {
+		signingKey = s.SigningKey
+	}
+	idToken, err := token.SignedString(signingKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (s *Server) userInfo(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"sub":            s.User.Subject,
+		"email":          s.User.Email,
+		"email_verified": true,
+	})
+}
+
+func (s *Server) certs(w http.ResponseWriter, r *http.Request) {
+	e := big.NewInt(int64(s.Key.PublicKey.E)).Bytes()
+	writeJSON(w, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": s.KeyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(s.Key.PublicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(e),
+		}},
+	})
+}
@@ -0,0 +1,78 @@
+package auth
+
+// Permission is something a user is allowed to do. Users are granted permissions by the roles
+// they have, which are stored in the database.
+type Permission string
+
+const (
+	// PermissionViewMoveQueues allows viewing the office queues of moves
+	PermissionViewMoveQueues Permission = "move_queues.view"
+	// PermissionViewMoves allows viewing any move, and the incentives and charges calculated for it
+	PermissionViewMoves Permission = "moves.view"
+	// PermissionEditMoves allows editing service members' profiles, orders, moves, PPMs and
+	// documents on their behalf
+	PermissionEditMoves Permission = "moves.edit"
+	// PermissionApprovePPMs allows approving and canceling moves, PPMs and their reimbursements
+	PermissionApprovePPMs Permission = "ppms.approve"
+	// PermissionApproveHHGs allows approving, completing and invoicing HHG shipments, and reviewing
+	// their line items and storage in transit
+	PermissionApproveHHGs Permission = "hhgs.approve"
+	// PermissionViewShipments allows viewing shipments
+	PermissionViewShipments Permission = "shipments.view"
+	// PermissionEditShipments allows editing shipments and their line items
+	PermissionEditShipments Permission = "shipments.edit"
+	// PermissionDispatchShipments allows accepting, rejecting, transporting and delivering shipments
+	PermissionDispatchShipments Permission = "shipments.dispatch"
+	// PermissionManageServiceAgents allows managing the service agents of shipments
+	PermissionManageServiceAgents Permission = "service_agents.manage"
+)
+
+// APIClientScopes are the permissions that API clients can be limited to. Clients act on
+// behalf of a TSP user, so these are the permissions TSP users can be granted.
+var APIClientScopes = []Permission{
+	PermissionViewShipments,
+	PermissionEditShipments,
+	PermissionDispatchShipments,
+	PermissionManageServiceAgents,
+}
+
+// IsAPIClientScope checks whether API clients can be limited to a permission
+func IsAPIClientScope(permission Permission) bool {
+	for _, scope := range APIClientScopes {
+		if scope == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// LimitToScopes returns the permissions that are also among the scopes
+func LimitToScopes(permissions []Permission, scopes []Permission) []Permission {
+	limited := []Permission{}
+	for _, permission := range permissions {
+		for _, scope := range scopes {
+			if permission == scope {
+				limited = append(limited, permission)
+				break
+			}
+		}
+	}
+	return limited
+}
+
+// Can checks whether the session has been granted all of the permissions
+func (s *Session) Can(permissions ...Permission) bool {
+	for _, permission := range permissions {
+		granted := false
+		for _, sessionPermission := range s.Permissions {
+			if sessionPermission == permission {
+				granted = true
+				break
+			}
+		}
+		if !granted {
+			return false
+		}
+	}
+	return true
+}
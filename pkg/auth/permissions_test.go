@@ -0,0 +1,24 @@
+package auth
+
+func (suite *authSuite) TestSessionCan() {
+	session := Session{Permissions: []Permission{PermissionViewMoves, PermissionApprovePPMs}}
+
+	suite.True(session.Can(PermissionViewMoves))
+	suite.True(session.Can(PermissionViewMoves, PermissionApprovePPMs))
+	suite.False(session.Can(PermissionApproveHHGs))
+	suite.False(session.Can(PermissionViewMoves, PermissionApproveHHGs))
+
+	// Service members aren't granted any permissions
+	suite.False((&Session{}).Can(PermissionViewMoves))
+	suite.True((&Session{}).Can())
+}
+
+func (suite *authSuite) TestLimitToScopes() {
+	permissions := []Permission{PermissionViewShipments, PermissionEditShipments, PermissionApproveHHGs}
+
+	suite.Equal([]Permission{PermissionViewShipments}, LimitToScopes(permissions, []Permission{PermissionViewShipments, PermissionManageServiceAgents}))
+	suite.Empty(LimitToScopes(permissions, nil))
+
+	suite.True(IsAPIClientScope(PermissionDispatchShipments))
+	suite.False(IsAPIClientScope(PermissionApproveHHGs), "expected clients to act only as TSP users")
+}
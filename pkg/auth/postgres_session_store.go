@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+)
+
+// PostgresSessionStore keeps sessions in the user_sessions table, so that they are shared
+// between servers and survive restarts
+type PostgresSessionStore struct {
+	db *pop.Connection
+}
+
+// NewPostgresSessionStore creates a new PostgresSessionStore
+func NewPostgresSessionStore(db *pop.Connection) *PostgresSessionStore {
+	return &PostgresSessionStore{db: db}
+}
+
+const recordNotFoundErrorString = "sql: no rows in result set"
+
+type userSessionRow struct {
+	Key           string    `db:"key"`
+	UserID        uuid.UUID `db:"user_id"`
+	Data          string    `db:"data"`
+	UserAgent     string    `db:"user_agent"`
+	RemoteAddr    string    `db:"remote_addr"`
+	CreatedAt     time.Time `db:"created_at"`
+	LastSeenAt    time.Time `db:"last_seen_at"`
+	IdleExpiresAt time.Time `db:"idle_expires_at"`
+	ExpiresAt     time.Time `db:"expires_at"`
+}
+
+func (row userSessionRow) storedSession() (StoredSession, error) {
+	stored := StoredSession{
+		Key:           row.Key,
+		UserID:        row.UserID,
+		UserAgent:     row.UserAgent,
+		RemoteAddr:    row.RemoteAddr,
+		CreatedAt:     row.CreatedAt,
+		LastSeenAt:    row.LastSeenAt,
+		IdleExpiresAt: row.IdleExpiresAt,
+		ExpiresAt:     row.ExpiresAt,
+	}
+	err := json.Unmarshal([]byte(row.Data), &stored.Session)
+	if err != nil {
+		return stored, errors.Wrap(err, "Error while decoding session")
+	}
+	return stored, nil
+}
+
+// Create stores a new session
+func (s *PostgresSessionStore) Create(stored *StoredSession) error {
+	data, err := json.Marshal(stored.Session)
+	if err != nil {
+		return errors.Wrap(err, "Error while encoding session")
+	}
+	sql := `INSERT INTO user_sessions
+			(key, user_id, data, user_agent, remote_addr, created_at, last_seen_at, idle_expires_at, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	err = s.db.RawQuery(sql, stored.Key, stored.UserID, string(data), stored.UserAgent, stored.RemoteAddr,
+		stored.CreatedAt, stored.LastSeenAt, stored.IdleExpiresAt, stored.ExpiresAt).Exec()
+	if err != nil {
+		return errors.Wrap(err, "Error while creating session")
+	}
+	return nil
+}
+
+// Fetch returns a session, unless it has expired as of now
+func (s *PostgresSessionStore) Fetch(key string, now time.Time) (*StoredSession, error) {
+	var row userSessionRow
+	sql := `SELECT * FROM user_sessions
+		WHERE key = $1 AND idle_expires_at > $2 AND expires_at > $2`
+
+	err := s.db.RawQuery(sql, key, now).First(&row)
+	if err != nil {
+		if errors.Cause(err).Error() == recordNotFoundErrorString {
+			return nil, ErrSessionNotFound
+		}
+		return nil, errors.Wrap(err, "Error while fetching session")
+	}
+	stored, err := row.storedSession()
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// Touch records that a session was used
+func (s *PostgresSessionStore) Touch(key string, lastSeenAt time.Time, idleExpiresAt time.Time) error {
+	sql := `UPDATE user_sessions SET last_seen_at = $2, idle_expires_at = $3 WHERE key = $1`
+
+	err := s.db.RawQuery(sql, key, lastSeenAt, idleExpiresAt).Exec()
+	if err != nil {
+		return errors.Wrap(err, "Error while touching session")
+	}
+	return nil
+}
+
+// Save replaces what is stored about the user in a session
+func (s *PostgresSessionStore) Save(key string, session *Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return errors.Wrap(err, "Error while encoding session")
+	}
+	sql := `UPDATE user_sessions SET data = $2, user_id = $3 WHERE key = $1`
+
+	err = s.db.RawQuery(sql, key, string(data), session.UserID).Exec()
+	if err != nil {
+		return errors.Wrap(err, "Error while saving session")
+	}
+	return nil
+}
+
+// Delete revokes a session
+func (s *PostgresSessionStore) Delete(key string) error {
+	err := s.db.RawQuery(`DELETE FROM user_sessions WHERE key = $1`, key).Exec()
+	if err != nil {
+		return errors.Wrap(err, "Error while deleting session")
+	}
+	return nil
+}
+
+// DeleteForUser revokes every session of a user
+func (s *PostgresSessionStore) DeleteForUser(userID uuid.UUID) (int, error) {
+	count, err := s.db.RawQuery(`DELETE FROM user_sessions WHERE user_id = $1`, userID).ExecWithCount()
+	if err != nil {
+		return 0, errors.Wrap(err, "Error while deleting sessions for user")
+	}
+	return count, nil
+}
+
+// ListForUser returns the sessions of a user that haven't expired, newest first
+func (s *PostgresSessionStore) ListForUser(userID uuid.UUID, now time.Time) ([]StoredSession, error) {
+	var rows []userSessionRow
+	sql := `SELECT * FROM user_sessions
+		WHERE user_id = $1 AND idle_expires_at > $2 AND expires_at > $2
+		ORDER BY created_at DESC`
+
+	err := s.db.RawQuery(sql, userID, now).All(&rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while listing sessions for user")
+	}
+	sessions := make([]StoredSession, len(rows))
+	for i, row := range rows {
+		sessions[i], err = row.storedSession()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sessions, nil
+}
+
+// DeleteExpired removes the sessions that have expired as of now
+func (s *PostgresSessionStore) DeleteExpired(now time.Time) (int, error) {
+	sql := `DELETE FROM user_sessions WHERE idle_expires_at <= $1 OR expires_at <= $1`
+
+	count, err := s.db.RawQuery(sql, now).ExecWithCount()
+	if err != nil {
+		return 0, errors.Wrap(err, "Error while deleting expired sessions")
+	}
+	return count, nil
+}
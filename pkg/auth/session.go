@@ -12,6 +12,8 @@ const sessionContextKey authSessionKey = "session"
 
 // Session stores information about the currently logged in session
 type Session struct {
+	// ID is the secret held by the session cookie. It is never stored with the session.
+	ID              string `json:"-"`
 	ApplicationName application
 	Hostname        string
 	IDToken         string
@@ -23,6 +25,13 @@ type Session struct {
 	ServiceMemberID uuid.UUID
 	OfficeUserID    uuid.UUID
 	TspUserID       uuid.UUID
+	// Permissions are loaded from the user's roles on every request, so that changes to them
+	// take effect immediately, and are never written to the session cookie
+	Permissions []Permission `json:"-"`
+	// APIClientID is set when the request was made by an API client on behalf of the TSP user,
+	// rather than by the user logged in to login.gov. Clients are limited to their Scopes.
+	APIClientID uuid.UUID    `json:"-"`
+	Scopes      []Permission `json:"-"`
 }
 
 // SetSessionInRequestContext modifies the request's Context() to add the session data
@@ -52,3 +61,8 @@ func (s *Session) IsOfficeUser() bool {
 func (s *Session) IsTspUser() bool {
 	return s.TspUserID != uuid.Nil
 }
+
+// IsAPIClient checks whether the request was made by an API client
+func (s *Session) IsAPIClient() bool {
+	return s.APIClientID != uuid.Nil
+}
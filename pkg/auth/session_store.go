@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+)
+
+// ErrSessionNotFound is returned when a session doesn't exist or has expired
+var ErrSessionNotFound = errors.New("session not found")
+
+// StoredSession is a logged in session as it is kept on the server. The cookie holds only
+// the session's ID, which is never stored: sessions are kept by a key hashed from it, which
+// can be shown to users and admins to revoke a session without letting them use it.
+type StoredSession struct {
+	Key           string
+	UserID        uuid.UUID
+	Session       Session
+	UserAgent     string
+	RemoteAddr    string
+	CreatedAt     time.Time
+	LastSeenAt    time.Time
+	IdleExpiresAt time.Time
+	ExpiresAt     time.Time
+}
+
+// SessionStore is the interface needed to keep sessions on the server
+type SessionStore interface {
+	// Create stores a new session
+	Create(stored *StoredSession) error
+	// Fetch returns a session, unless it has expired as of now. It returns ErrSessionNotFound
+	// if there is none.
+	Fetch(key string, now time.Time) (*StoredSession, error)
+	// Touch records that a session was used, extending its idle timeout
+	Touch(key string, lastSeenAt time.Time, idleExpiresAt time.Time) error
+	// Save replaces what is stored about the user in a session
+	Save(key string, session *Session) error
+	// Delete revokes a session. Sessions that don't exist are ignored.
+	Delete(key string) error
+	// DeleteForUser revokes every session of a user, and returns how many there were
+	DeleteForUser(userID uuid.UUID) (int, error)
+	// ListForUser returns the sessions of a user that haven't expired as of now, newest first
+	ListForUser(userID uuid.UUID, now time.Time) ([]StoredSession, error)
+	// DeleteExpired removes the sessions that have expired as of now, and returns how many
+	// were removed
+	DeleteExpired(now time.Time) (int, error)
+}
+
+// newSessionID returns a new random session ID for a cookie
+func newSessionID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "generating session ID")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// SessionKey returns the key a session is stored by
+func SessionKey(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *StoredSession) expired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt) || !now.Before(s.IdleExpiresAt)
+}
+
+// MemorySessionStore keeps sessions in memory. It is intended only for use in development and
+// tests, as sessions are lost when the server restarts and aren't shared between servers.
+type MemorySessionStore struct {
+	mutex    sync.Mutex
+	sessions map[string]StoredSession
+}
+
+// NewMemorySessionStore creates a new MemorySessionStore
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: map[string]StoredSession{}}
+}
+
+// Create stores a new session
+func (s *MemorySessionStore) Create(stored *StoredSession) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.sessions[stored.Key]; ok {
+		return errors.New("session already exists")
+	}
+	s.sessions[stored.Key] = *stored
+	return nil
+}
+
+// Fetch returns a session, unless it has expired as of now
+func (s *MemorySessionStore) Fetch(key string, now time.Time) (*StoredSession, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	stored, ok := s.sessions[key]
+	if !ok || stored.expired(now) {
+		return nil, ErrSessionNotFound
+	}
+	return &stored, nil
+}
+
+// Touch records that a session was used
+func (s *MemorySessionStore) Touch(key string, lastSeenAt time.Time, idleExpiresAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	stored, ok := s.sessions[key]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	stored.LastSeenAt = lastSeenAt
+	stored.IdleExpiresAt = idleExpiresAt
+	s.sessions[key] = stored
+	return nil
+}
+
+// Save replaces what is stored about the user in a session
+func (s *MemorySessionStore) Save(key string, session *Session) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	stored, ok := s.sessions[key]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	stored.Session = *session
+	stored.UserID = session.UserID
+	s.sessions[key] = stored
+	return nil
+}
+
+// Delete revokes a session
+func (s *MemorySessionStore) Delete(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.sessions, key)
+	return nil
+}
+
+// DeleteForUser revokes every session of a user
+func (s *MemorySessionStore) DeleteForUser(userID uuid.UUID) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	count := 0
+	for key, stored := range s.sessions {
+		if stored.UserID == userID {
+			delete(s.sessions, key)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ListForUser returns the sessions of a user that haven't expired, newest first
+func (s *MemorySessionStore) ListForUser(userID uuid.UUID, now time.Time) ([]StoredSession, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var sessions []StoredSession
+	for _, stored := range s.sessions {
+		if stored.UserID == userID && !stored.expired(now) {
+			sessions = append(sessions, stored)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].CreatedAt.After(sessions[j].CreatedAt) })
+	return sessions, nil
+}
+
+// DeleteExpired removes the sessions that have expired as of now
+func (s *MemorySessionStore) DeleteExpired(now time.Time) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	count := 0
+	for key, stored := range s.sessions {
+		if stored.expired(now) {
+			delete(s.sessions, key)
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+func makeStoredSession(key string, userID uuid.UUID, createdAt time.Time, timeout time.Duration) StoredSession {
+	return StoredSession{
+		Key:           key,
+		UserID:        userID,
+		Session:       Session{UserID: userID},
+		CreatedAt:     createdAt,
+		LastSeenAt:    createdAt,
+		IdleExpiresAt: createdAt.Add(timeout),
+		ExpiresAt:     createdAt.Add(timeout),
+	}
+}
+
+func (suite *authSuite) TestMemorySessionStore() {
+	store := NewMemorySessionStore()
+	now := time.Now()
+	otherUserID := uuid.Must(uuid.NewV4())
+
+	sessions := []StoredSession{
+		makeStoredSession("old", fakeUserID, now.Add(-2*time.Hour), time.Hour),
+		makeStoredSession("first", fakeUserID, now.Add(-time.Minute), time.Hour),
+		makeStoredSession("second", fakeUserID, now, time.Hour),
+		makeStoredSession("other", otherUserID, now, time.Hour),
+	}
+	for i := range sessions {
+		suite.NoError(store.Create(&sessions[i]))
+	}
+	suite.Error(store.Create(&sessions[0]), "Expected keys to be unique")
+
+	_, err := store.Fetch("old", now)
+	suite.Equal(ErrSessionNotFound, err)
+	_, err = store.Fetch("missing", now)
+	suite.Equal(ErrSessionNotFound, err)
+
+	listed, err := store.ListForUser(fakeUserID, now)
+	suite.NoError(err)
+	suite.Len(listed, 2)
+	suite.Equal("second", listed[0].Key, "Expected the newest session first")
+	suite.Equal("first", listed[1].Key)
+
+	count, err := store.DeleteExpired(now)
+	suite.NoError(err)
+	suite.Equal(1, count)
+
+	count, err = store.DeleteForUser(fakeUserID)
+	suite.NoError(err)
+	suite.Equal(2, count)
+
+	stored, err := store.Fetch("other", now)
+	suite.NoError(err)
+	suite.Equal(otherUserID, stored.UserID)
+}
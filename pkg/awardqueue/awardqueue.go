@@ -20,6 +20,7 @@ import (
 
 	"github.com/transcom/mymove/pkg/logging/hnyzap"
 	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/notifications"
 )
 
 const awardQueueLockID = 1
@@ -32,8 +33,12 @@ const mps = 0
 
 // AwardQueue encapsulates the TSP award queue process
 type AwardQueue struct {
-	db     *pop.Connection
-	logger *hnyzap.Logger
+	db                 *pop.Connection
+	logger             *hnyzap.Logger
+	notificationSender notifications.NotificationSender
+
+	// offers made during the current run, so the TSPs can be notified once they are committed
+	shipmentOfferIDs []uuid.UUID
 }
 
 func (aq *AwardQueue) findAllUnassignedShipments() (models.Shipments, error) {
@@ -95,6 +100,7 @@ func (aq *AwardQueue) attemptShipmentOffer(ctx context.Context, shipment models.
 
 			shipmentOffer, err = models.CreateShipmentOffer(aq.db, shipment.ID, tsp.ID, tspPerformance.ID, isAdministrativeShipment)
 			if err == nil {
+				aq.shipmentOfferIDs = append(aq.shipmentOfferIDs, shipmentOffer.ID)
 				if tspPerformance, err = models.IncrementTSPPerformanceOfferCount(aq.db, tspPerformance.ID); err == nil {
 					if isAdministrativeShipment == true {
 						aq.logger.TraceInfo(ctx, "Shipment pickup date is during a blackout period. Awarding Administrative Shipment to TSP.")
@@ -264,7 +270,8 @@ func (aq *AwardQueue) Run(ctx context.Context) error {
 	originalDB := aq.db
 	defer func() { aq.db = originalDB }()
 
-	return aq.db.Transaction(func(tx *pop.Connection) error {
+	aq.shipmentOfferIDs = nil
+	err := aq.db.Transaction(func(tx *pop.Connection) error {
 		// ensure that all parts of the AQ run inside the transaction
 		aq.db = tx
 
@@ -286,7 +293,28 @@ func (aq *AwardQueue) Run(ctx context.Context) error {
 		aq.assignShipments(ctx)
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	aq.db = originalDB
+	aq.notifyOfferedTSPs(ctx)
+	return nil
+}
 
+// notifyOfferedTSPs lets TSP users know about the offers made during this run.
+// A failed email shouldn't undo an offer, so errors are only logged.
+func (aq *AwardQueue) notifyOfferedTSPs(ctx context.Context) {
+	for _, shipmentOfferID := range aq.shipmentOfferIDs {
+		err := aq.notificationSender.SendNotification(
+			notifications.NewShipmentOfferAwarded(aq.db, aq.logger.Logger, shipmentOfferID),
+		)
+		if err != nil {
+			aq.logger.TraceError(ctx, "Failed to notify TSP of shipment offer",
+				zap.String("shipment_offer_id", shipmentOfferID.String()),
+				zap.Error(err))
+		}
+	}
 }
 
 // waitForLock MUST be called within a transaction!
@@ -300,9 +328,10 @@ func waitForLock(ctx context.Context, db *pop.Connection, id int) error {
 }
 
 // NewAwardQueue creates a new AwardQueue
-func NewAwardQueue(db *pop.Connection, logger *hnyzap.Logger) *AwardQueue {
+func NewAwardQueue(db *pop.Connection, logger *hnyzap.Logger, notificationSender notifications.NotificationSender) *AwardQueue {
 	return &AwardQueue{
-		db:     db,
-		logger: logger,
+		db:                 db,
+		logger:             logger,
+		notificationSender: notificationSender,
 	}
 }
@@ -14,13 +14,14 @@ import (
 
 	"github.com/transcom/mymove/pkg/logging/hnyzap"
 	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/notifications"
 	"github.com/transcom/mymove/pkg/testdatagen"
 	"github.com/transcom/mymove/pkg/unit"
 )
 
 func (suite *AwardQueueSuite) Test_CheckAllTSPsBlackedOut() {
 	t := suite.T()
-	queue := NewAwardQueue(suite.db, suite.logger)
+	queue := NewAwardQueue(suite.db, suite.logger, notifications.NewStubNotificationSender(suite.logger.Logger))
 
 	tsp := testdatagen.MakeDefaultTSP(suite.db)
 
@@ -73,7 +74,7 @@ func (suite *AwardQueueSuite) Test_CheckAllTSPsBlackedOut() {
 
 func (suite *AwardQueueSuite) Test_CheckShipmentDuringBlackOut() {
 	t := suite.T()
-	queue := NewAwardQueue(suite.db, suite.logger)
+	queue := NewAwardQueue(suite.db, suite.logger, notifications.NewStubNotificationSender(suite.logger.Logger))
 
 	tsp := testdatagen.MakeDefaultTSP(suite.db)
 
@@ -170,7 +171,7 @@ func (suite *AwardQueueSuite) Test_CheckShipmentDuringBlackOut() {
 
 func (suite *AwardQueueSuite) Test_ShipmentWithinBlackoutDates() {
 	t := suite.T()
-	queue := NewAwardQueue(suite.db, suite.logger)
+	queue := NewAwardQueue(suite.db, suite.logger, notifications.NewStubNotificationSender(suite.logger.Logger))
 	// Creates a TSP with a blackout date connected to both.
 	testTSP1 := testdatagen.MakeDefaultTSP(suite.db)
 
@@ -254,7 +255,7 @@ func (suite *AwardQueueSuite) Test_ShipmentWithinBlackoutDates() {
 
 func (suite *AwardQueueSuite) Test_FindAllUnassignedShipments() {
 	t := suite.T()
-	queue := NewAwardQueue(suite.db, suite.logger)
+	queue := NewAwardQueue(suite.db, suite.logger, notifications.NewStubNotificationSender(suite.logger.Logger))
 	_, err := queue.findAllUnassignedShipments()
 
 	if err != nil {
@@ -266,7 +267,7 @@ func (suite *AwardQueueSuite) Test_FindAllUnassignedShipments() {
 // it actually gets offered.
 func (suite *AwardQueueSuite) Test_OfferSingleShipment() {
 	t := suite.T()
-	queue := NewAwardQueue(suite.db, suite.logger)
+	queue := NewAwardQueue(suite.db, suite.logger, notifications.NewStubNotificationSender(suite.logger.Logger))
 
 	// Make a shipment
 	market := testdatagen.DefaultMarket
@@ -318,7 +319,7 @@ func (suite *AwardQueueSuite) Test_OfferSingleShipment() {
 // any enabled TSPs.
 func (suite *AwardQueueSuite) Test_FailOfferingSingleShipment() {
 	t := suite.T()
-	queue := NewAwardQueue(suite.db, suite.logger)
+	queue := NewAwardQueue(suite.db, suite.logger, notifications.NewStubNotificationSender(suite.logger.Logger))
 
 	// Make a shipment in a new TDL, which inherently has no TSPs
 	market := "dHHG"
@@ -365,7 +366,7 @@ func (suite *AwardQueueSuite) Test_FailOfferingSingleShipment() {
 
 func (suite *AwardQueueSuite) TestAssignShipmentsSingleTSP() {
 	t := suite.T()
-	queue := NewAwardQueue(suite.db, suite.logger)
+	queue := NewAwardQueue(suite.db, suite.logger, notifications.NewStubNotificationSender(suite.logger.Logger))
 
 	const shipmentsToMake = 10
 
@@ -429,7 +430,7 @@ func (suite *AwardQueueSuite) TestAssignShipmentsToMultipleTSPs() {
 
 	suite.db.TruncateAll()
 
-	queue := NewAwardQueue(suite.db, suite.logger)
+	queue := NewAwardQueue(suite.db, suite.logger, notifications.NewStubNotificationSender(suite.logger.Logger))
 
 	const shipmentsToMake = 17
 
@@ -514,7 +515,7 @@ func (suite *AwardQueueSuite) Test_GetTSPsPerBandNoRemainder() {
 
 func (suite *AwardQueueSuite) Test_AssignTSPsToBands() {
 	t := suite.T()
-	queue := NewAwardQueue(suite.db, suite.logger)
+	queue := NewAwardQueue(suite.db, suite.logger, notifications.NewStubNotificationSender(suite.logger.Logger))
 	tspsToMake := 5
 
 	tdl := testdatagen.MakeDefaultTDL(suite.db)
@@ -567,7 +568,7 @@ func (suite *AwardQueueSuite) Test_AssignTSPsToBands() {
 // rate cycles get awarded shipments appropriately
 func (suite *AwardQueueSuite) Test_AwardTSPsInDifferentRateCycles() {
 	t := suite.T()
-	queue := NewAwardQueue(suite.db, suite.logger)
+	queue := NewAwardQueue(suite.db, suite.logger, notifications.NewStubNotificationSender(suite.logger.Logger))
 	sm := testdatagen.MakeDefaultServiceMember(suite.db)
 
 	twoMonths, _ := time.ParseDuration("2 months")
@@ -1,9 +1,9 @@
 package dpsauth
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
-	"os"
-	"strconv"
 	"strings"
 	"time"
 
@@ -11,26 +11,92 @@ import (
 	"github.com/pkg/errors"
 )
 
-var cookieExpiresInMinutes = initCookieExpiration()
-var secretKey = initKey()
-
 const prefix = "mymove-"
 
+// SigningKey is a secret that cookies are signed with. Keys are identified so that they can be
+// rotated: cookies name the key they were signed with.
+type SigningKey struct {
+	ID     string
+	Secret []byte
+}
+
+// Params configure DPS authentication cookies
+type Params struct {
+	// SigningKey signs new cookies, and verifies them
+	SigningKey SigningKey
+	// VerificationKeys only verify cookies. To rotate keys, sign with a new key and keep the old
+	// one here until the cookies signed with it have expired.
+	VerificationKeys []SigningKey
+	// Issuer identifies us as the issuer of cookies
+	Issuer string
+	// Audience identifies DPS as who cookies are for
+	Audience string
+	// CookieExpiration is how long cookies can be used for
+	CookieExpiration time.Duration
+}
+
+// Authenticator issues and verifies the cookies DPS uses to identify users
+type Authenticator struct {
+	params  Params
+	keys    map[string][]byte
+	replays ReplayCache
+	now     func() time.Time
+}
+
+// NewAuthenticator returns a new Authenticator. Cookies can only be used once, which is
+// enforced by the replay cache.
+func NewAuthenticator(params Params, replays ReplayCache) (*Authenticator, error) {
+	if params.Issuer == "" || params.Audience == "" {
+		return nil, errors.New("DPS auth cookies need an issuer and audience")
+	}
+	if params.CookieExpiration <= 0 {
+		return nil, errors.New("DPS auth cookies need to expire")
+	}
+	keys := map[string][]byte{}
+	for _, key := range append([]SigningKey{params.SigningKey}, params.VerificationKeys...) {
+		if key.ID == "" || len(key.Secret) == 0 {
+			return nil, errors.New("DPS auth cookie keys need an ID and secret")
+		}
+		if _, ok := keys[key.ID]; ok {
+			return nil, errors.Errorf("DPS auth cookie key %s is repeated", key.ID)
+		}
+		keys[key.ID] = key.Secret
+	}
+	return &Authenticator{
+		params:  params,
+		keys:    keys,
+		replays: replays,
+		now:     time.Now,
+	}, nil
+}
+
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "Generating token ID")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
 // LoginGovIDToCookie takes the Login.gov UUID of the current user and returns the cookie value.
-func LoginGovIDToCookie(userID string) (string, error) {
-	expiration, err := strconv.Atoi(cookieExpiresInMinutes)
+func (a *Authenticator) LoginGovIDToCookie(userID string) (string, error) {
+	tokenID, err := newTokenID()
 	if err != nil {
-		return "", errors.Wrap(err, "Converting DPS_COOKIE_EXPIRES_IN_MINUTES to int")
+		return "", err
 	}
-	expirationTime := time.Now().Add(time.Minute * time.Duration(expiration)).Unix()
-
+	now := a.now()
 	claims := &jwt.StandardClaims{
+		Id:        tokenID,
 		Subject:   userID,
-		ExpiresAt: expirationTime,
+		Issuer:    a.params.Issuer,
+		Audience:  a.params.Audience,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(a.params.CookieExpiration).Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	jwt, err := token.SignedString(secretKey)
+	token.Header["kid"] = a.params.SigningKey.ID
+	jwt, err := token.SignedString(a.params.SigningKey.Secret)
 	if err != nil {
 		return "", errors.Wrap(err, "Signing JWT")
 	}
@@ -38,13 +104,21 @@ func LoginGovIDToCookie(userID string) (string, error) {
 }
 
 // CookieToLoginGovID takes a cookie value and returns the Login.gov UUID only if it's a
-// valid, unexpired cookie.
-func CookieToLoginGovID(cookieValue string) (string, error) {
+// valid, unexpired cookie for DPS that hasn't been used before.
+func (a *Authenticator) CookieToLoginGovID(cookieValue string) (string, error) {
 	if !strings.HasPrefix(cookieValue, prefix) {
 		return "", &ErrInvalidCookie{errMessage: "Invalid cookie: missing prefix"}
 	}
-	token, err := jwt.ParseWithClaims(cookieValue[len(prefix):], &jwt.StandardClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return secretKey, nil
+	now := a.now()
+	parser := jwt.Parser{ValidMethods: []string{jwt.SigningMethodHS256.Alg()}}
+	claims := &jwt.StandardClaims{}
+	token, err := parser.ParseWithClaims(cookieValue[len(prefix):], claims, func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		secret, ok := a.keys[keyID]
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", keyID)
+		}
+		return secret, nil
 	})
 
 	if err != nil {
@@ -55,14 +129,19 @@ func CookieToLoginGovID(cookieValue string) (string, error) {
 		return "", &ErrInvalidCookie{errMessage: "Invalid cookie: failed JWT validation"}
 	}
 
-	claims := token.Claims.(*jwt.StandardClaims)
-	return claims.Subject, nil
-}
-
-func initCookieExpiration() string {
-	return os.Getenv("DPS_COOKIE_EXPIRES_IN_MINUTES")
-}
+	if !claims.VerifyIssuer(a.params.Issuer, true) || !claims.VerifyAudience(a.params.Audience, true) {
+		return "", &ErrInvalidCookie{errMessage: "Invalid cookie: wrong issuer or audience"}
+	}
+	if !claims.VerifyExpiresAt(now.Unix(), true) || !claims.VerifyIssuedAt(now.Unix(), true) || claims.Id == "" {
+		return "", &ErrInvalidCookie{errMessage: "Invalid cookie: missing required claims"}
+	}
 
-func initKey() []byte {
-	return []byte(os.Getenv("DPS_AUTH_COOKIE_SECRET_KEY"))
+	firstUse, err := a.replays.Use(claims.Id, time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		return "", errors.Wrap(err, "Checking cookie for reuse")
+	}
+	if !firstUse {
+		return "", &ErrInvalidCookie{errMessage: "Invalid cookie: already used"}
+	}
+	return claims.Subject, nil
 }
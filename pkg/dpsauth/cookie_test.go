@@ -2,9 +2,10 @@ package dpsauth
 
 import (
 	"net/url"
-	"os"
 	"testing"
+	"time"
 
+	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/suite"
 )
@@ -13,31 +14,141 @@ type dpsAuthSuite struct {
 	suite.Suite
 }
 
-func (suite *dpsAuthSuite) SetupSuite() {
-	key := os.Getenv("DPS_AUTH_COOKIE_SECRET_KEY")
-	exp := os.Getenv("DPS_COOKIE_EXPIRES_IN_MINUTES")
-	if len(key) == 0 || len(exp) == 0 {
-		suite.T().Fatal("You must set the DPS_AUTH_COOKIE_SECRET_KEY and DPS_COOKIE_EXPIRES_IN_MINUTES environment variables to run this test")
+var (
+	oldKey = SigningKey{ID: "2018-10", Secret: []byte("old secret")}
+	newKey = SigningKey{ID: "2018-11", Secret: []byte("new secret")}
+)
+
+func testParams(signingKey SigningKey, verificationKeys ...SigningKey) Params {
+	return Params{
+		SigningKey:       signingKey,
+		VerificationKeys: verificationKeys,
+		Issuer:           "my.move.host",
+		Audience:         "dps.move.host",
+		CookieExpiration: 240 * time.Minute,
 	}
 }
 
+func (suite *dpsAuthSuite) mustAuthenticator(params Params) *Authenticator {
+	authenticator, err := NewAuthenticator(params, NewMemoryReplayCache())
+	suite.NoError(err)
+	return authenticator
+}
+
 func (suite *dpsAuthSuite) TestCookie() {
 	t := suite.T()
+	authenticator := suite.mustAuthenticator(testParams(newKey))
 	userID := uuid.Must(uuid.NewV4()).String()
-	cookie, err := LoginGovIDToCookie(userID)
+	cookie, err := authenticator.LoginGovIDToCookie(userID)
 	if err != nil {
 		t.Error("Error generating cookie value from user ID", err)
 	}
 
 	// Mimic cookie being passed back in an API call via query param
 	escaped := url.QueryEscape(cookie)
-	userIDFromCookie, err := CookieToLoginGovID(escaped)
+	userIDFromCookie, err := authenticator.CookieToLoginGovID(escaped)
 	if err != nil {
 		t.Error("Error extracting user ID from cookie value", err)
 	}
 	suite.Equal(userID, userIDFromCookie)
 }
 
+func (suite *dpsAuthSuite) TestCookieReplay() {
+	authenticator := suite.mustAuthenticator(testParams(newKey))
+	cookie, err := authenticator.LoginGovIDToCookie(uuid.Must(uuid.NewV4()).String())
+	suite.NoError(err)
+
+	_, err = authenticator.CookieToLoginGovID(cookie)
+	suite.NoError(err)
+	_, err = authenticator.CookieToLoginGovID(cookie)
+	suite.IsType(&ErrInvalidCookie{}, err, "expected cookies to be used only once")
+}
+
+func (suite *dpsAuthSuite) TestKeyRotation() {
+	userID := uuid.Must(uuid.NewV4()).String()
+	cookie, err := suite.mustAuthenticator(testParams(oldKey)).LoginGovIDToCookie(userID)
+	suite.NoError(err)
+
+	// While rotating, cookies signed with the old key are still accepted
+	rotating := suite.mustAuthenticator(testParams(newKey, oldKey))
+	userIDFromCookie, err := rotating.CookieToLoginGovID(cookie)
+	suite.NoError(err)
+	suite.Equal(userID, userIDFromCookie)
+
+	// And new cookies are signed with the new key
+	newCookie, err := rotating.LoginGovIDToCookie(userID)
+	suite.NoError(err)
+	_, err = suite.mustAuthenticator(testParams(newKey)).CookieToLoginGovID(newCookie)
+	suite.NoError(err)
+
+	// Once rotated, the old key isn't
+	_, err = suite.mustAuthenticator(testParams(newKey)).CookieToLoginGovID(cookie)
+	suite.IsType(&ErrInvalidCookie{}, err)
+}
+
+func (suite *dpsAuthSuite) TestInvalidCookies() {
+	userID := uuid.Must(uuid.NewV4()).String()
+	sign := func(key SigningKey, claims jwt.StandardClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		token.Header["kid"] = key.ID
+		signed, err := token.SignedString(key.Secret)
+		suite.NoError(err)
+		return prefix + signed
+	}
+	now := time.Now()
+	valid := jwt.StandardClaims{
+		Id:        "token",
+		Subject:   userID,
+		Issuer:    "my.move.host",
+		Audience:  "dps.move.host",
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(time.Hour).Unix(),
+	}
+
+	wrongAudience := valid
+	wrongAudience.Audience = "another.host"
+	wrongIssuer := valid
+	wrongIssuer.Issuer = "another.host"
+	expired := valid
+	expired.ExpiresAt = now.Add(-time.Minute).Unix()
+	missingID := valid
+	missingID.Id = ""
+	missingIssuedAt := valid
+	missingIssuedAt.IssuedAt = 0
+
+	cases := map[string]string{
+		"missing prefix": sign(newKey, valid)[len(prefix):],
+		"unknown key":    sign(SigningKey{ID: "unknown", Secret: newKey.Secret}, valid),
+		"wrong secret":   sign(SigningKey{ID: newKey.ID, Secret: oldKey.Secret}, valid),
+		"wrong audience": sign(newKey, wrongAudience),
+		"wrong issuer":   sign(newKey, wrongIssuer),
+		"expired":        sign(newKey, expired),
+		"missing ID":     sign(newKey, missingID),
+		"missing iat":    sign(newKey, missingIssuedAt),
+	}
+	authenticator := suite.mustAuthenticator(testParams(newKey))
+	for name, cookie := range cases {
+		_, err := authenticator.CookieToLoginGovID(cookie)
+		suite.IsType(&ErrInvalidCookie{}, err, name)
+	}
+
+	_, err := authenticator.CookieToLoginGovID(sign(newKey, valid))
+	suite.NoError(err)
+}
+
+func (suite *dpsAuthSuite) TestNewAuthenticatorValidatesParams() {
+	cases := map[string]Params{
+		"missing signing key": testParams(SigningKey{}),
+		"repeated key ID":     testParams(newKey, SigningKey{ID: newKey.ID, Secret: oldKey.Secret}),
+		"missing audience":    {SigningKey: newKey, Issuer: "my.move.host", CookieExpiration: time.Hour},
+		"no expiration":       {SigningKey: newKey, Issuer: "my.move.host", Audience: "dps.move.host"},
+	}
+	for name, params := range cases {
+		_, err := NewAuthenticator(params, NewMemoryReplayCache())
+		suite.Error(err, name)
+	}
+}
+
 func TestDPSAuthSuite(t *testing.T) {
 	s := &dpsAuthSuite{}
 	suite.Run(t, s)
@@ -0,0 +1,75 @@
+package dpsauth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/pkg/errors"
+)
+
+// ReplayCache remembers which cookies have been used, so that each can only be used once
+type ReplayCache interface {
+	// Use records that the cookie with the given token ID was used, returning false if it
+	// already had been. Cookies only need to be remembered until they expire.
+	Use(tokenID string, expiresAt time.Time) (bool, error)
+}
+
+// MemoryReplayCache remembers used cookies in memory, for use in development and tests
+type MemoryReplayCache struct {
+	mutex sync.Mutex
+	used  map[string]time.Time
+	now   func() time.Time
+}
+
+// NewMemoryReplayCache creates a new MemoryReplayCache
+func NewMemoryReplayCache() *MemoryReplayCache {
+	return &MemoryReplayCache{used: map[string]time.Time{}, now: time.Now}
+}
+
+// Use records that a cookie was used, returning false if it already had been
+func (c *MemoryReplayCache) Use(tokenID string, expiresAt time.Time) (bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := c.now()
+	for id, expiry := range c.used {
+		if !now.Before(expiry) {
+			delete(c.used, id)
+		}
+	}
+	if _, ok := c.used[tokenID]; ok {
+		return false, nil
+	}
+	c.used[tokenID] = expiresAt
+	return true, nil
+}
+
+// PostgresReplayCache remembers used cookies in the dps_cookie_uses table, so that a cookie
+// used with one server can't be used again with another
+type PostgresReplayCache struct {
+	db *pop.Connection
+}
+
+// NewPostgresReplayCache creates a new PostgresReplayCache
+func NewPostgresReplayCache(db *pop.Connection) *PostgresReplayCache {
+	return &PostgresReplayCache{db: db}
+}
+
+// Use records that a cookie was used, returning false if it already had been
+func (c *PostgresReplayCache) Use(tokenID string, expiresAt time.Time) (bool, error) {
+	now := time.Now()
+	err := c.db.RawQuery(`DELETE FROM dps_cookie_uses WHERE expires_at <= $1`, now).Exec()
+	if err != nil {
+		return false, errors.Wrap(err, "Error while deleting expired DPS cookie uses")
+	}
+
+	sql := `INSERT INTO dps_cookie_uses (token_id, expires_at, created_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (token_id) DO NOTHING`
+
+	count, err := c.db.RawQuery(sql, tokenID, expiresAt, now).ExecWithCount()
+	if err != nil {
+		return false, errors.Wrap(err, "Error while recording DPS cookie use")
+	}
+	return count == 1, nil
+}
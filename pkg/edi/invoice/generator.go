@@ -247,7 +247,7 @@ func getLineItemSegments(shipmentWithCost rateengine.CostByShipment) ([]edisegme
 	// that are ready to be invoiced
 	cost := shipmentWithCost.Cost
 
-	return []edisegment.Segment{
+	segments := []edisegment.Segment{
 		// Linehaul. Not sure why this uses the 303 code, but that's what I saw from DPS
 		&edisegment.HL{
 			HierarchicalIDNumber:  "303", // Accessorial services performed at origin
@@ -348,5 +348,56 @@ func getLineItemSegments(shipmentWithCost rateengine.CostByShipment) ([]edisegme
 			Charge:             227.42, // TODO: add a calculation of this value to rate engine
 			SpecialChargeDescription: "16A", // Fuel surchage - linehaul
 		},
-	}, nil
+	}
+
+	return append(segments, getStorageInTransitSegments(shipmentWithCost)...), nil
+}
+
+// getStorageInTransitSegments bills the first day and additional days of each stay in SIT
+func getStorageInTransitSegments(shipmentWithCost rateengine.CostByShipment) []edisegment.Segment {
+	var segments []edisegment.Segment
+	for _, charge := range shipmentWithCost.StorageInTransitCharges {
+		hierarchicalIDNumber := "304" // Accessorial services performed at destination
+		if charge.Location == models.StorageInTransitLocationORIGIN {
+			hierarchicalIDNumber = "303" // Accessorial services performed at origin
+		}
+
+		segments = append(segments,
+			&edisegment.HL{
+				HierarchicalIDNumber:  hierarchicalIDNumber,
+				HierarchicalLevelCode: "SS", // Services
+			},
+			&edisegment.L0{
+				LadingLineItemNumber:   1,
+				BilledRatedAsQuantity:  1,
+				BilledRatedAsQualifier: "FR", // Flat rate
+			},
+			&edisegment.L1{
+				FreightRate:              0,
+				RateValueQualifier:       "RC", // Rate
+				Charge:                   charge.FirstDayCharge.ToDollarFloat(),
+				SpecialChargeDescription: "185A", // SIT first day and warehouse handling
+			},
+		)
+		if charge.BillableDays > 1 {
+			segments = append(segments,
+				&edisegment.HL{
+					HierarchicalIDNumber:  hierarchicalIDNumber,
+					HierarchicalLevelCode: "SS", // Services
+				},
+				&edisegment.L0{
+					LadingLineItemNumber:   1,
+					BilledRatedAsQuantity:  float64(charge.BillableDays - 1),
+					BilledRatedAsQualifier: "DY", // Days
+				},
+				&edisegment.L1{
+					FreightRate:              0,
+					RateValueQualifier:       "RC", // Rate
+					Charge:                   charge.AdditionalDaysCharge.ToDollarFloat(),
+					SpecialChargeDescription: "185B", // SIT additional days
+				},
+			)
+		}
+	}
+	return segments
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/go-openapi/runtime"
 	"github.com/go-openapi/runtime/middleware"
 	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/zap"
 
@@ -135,6 +136,16 @@ func (suite *BaseTestSuite) CheckResponseTeapot(resp middleware.Responder) {
 	suite.CheckErrorResponse(resp, http.StatusTeapot, "Teapot")
 }
 
+// permissionsForUser returns the permissions granted to a user by their roles, as they would
+// be loaded for a request
+func (suite *BaseTestSuite) permissionsForUser(userID uuid.UUID) []auth.Permission {
+	permissions, err := models.FetchPermissionsForUser(suite.db, userID)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	return permissions
+}
+
 // AuthenticateRequest Request authenticated with a service member
 func (suite *BaseTestSuite) AuthenticateRequest(req *http.Request, serviceMember models.ServiceMember) *http.Request {
 	session := auth.Session{
@@ -165,6 +176,7 @@ func (suite *BaseTestSuite) AuthenticateOfficeRequest(req *http.Request, user mo
 		UserID:          *user.UserID,
 		IDToken:         "fake token",
 		OfficeUserID:    user.ID,
+		Permissions:     suite.permissionsForUser(*user.UserID),
 	}
 	ctx := auth.SetSessionInRequestContext(req, &session)
 	return req.WithContext(ctx)
@@ -177,6 +189,7 @@ func (suite *BaseTestSuite) AuthenticateTspRequest(req *http.Request, user model
 		UserID:          *user.UserID,
 		IDToken:         "fake token",
 		TspUserID:       user.ID,
+		Permissions:     suite.permissionsForUser(*user.UserID),
 	}
 	ctx := auth.SetSessionInRequestContext(req, &session)
 	return req.WithContext(ctx)
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/auth"
+)
+
+// PermissionRequirements are the permissions a user needs for each operation of an API, by
+// operation ID. Operations that aren't listed only need a logged in user, but API clients,
+// which are limited by their scopes, can't use them at all.
+type PermissionRequirements map[string][]auth.Permission
+
+// CanActForServiceMember checks that a session either belongs to a service member, who is limited
+// to their own records, or has been granted the permissions to act on a service member's behalf
+func CanActForServiceMember(session *auth.Session, permissions ...auth.Permission) bool {
+	return session.IsMyApp() || session.Can(permissions...)
+}
+
+// RequirePermissions returns a middleware builder for an API that forbids any operation the
+// user doesn't have the required permissions for. It runs after the request has been routed,
+// so the operation is known, and before any handler is called.
+func RequirePermissions(logger *zap.Logger, requirements PermissionRequirements) middleware.Builder {
+	return requirePermissions(logger, requirements, func(session *auth.Session) bool {
+		return false
+	})
+}
+
+// RequireDelegatedPermissions returns a middleware builder for the operations that service
+// members use on their own records, and that office users use on their behalf. Service members
+// are limited to their own records by the handlers, so only other users need the required
+// permissions.
+func RequireDelegatedPermissions(logger *zap.Logger, requirements PermissionRequirements) middleware.Builder {
+	return requirePermissions(logger, requirements, func(session *auth.Session) bool {
+		return session != nil && session.IsMyApp()
+	})
+}
+
+// requirePermissions forbids any operation the user doesn't have the required permissions for,
+// unless their session is exempt
+func requirePermissions(logger *zap.Logger, requirements PermissionRequirements, exempt func(*auth.Session) bool) middleware.Builder {
+	return func(next http.Handler) http.Handler {
+		mw := func(w http.ResponseWriter, r *http.Request) {
+			route := middleware.MatchedRouteFrom(r)
+			if route == nil || route.Operation == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			session := auth.SessionFromRequestContext(r)
+			permissions, ok := requirements[route.Operation.ID]
+			if !ok {
+				if session != nil && session.IsAPIClient() {
+					logger.Error("operation isn't available to API clients",
+						zap.String("operation", route.Operation.ID))
+					http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			if exempt(session) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if session == nil || !session.Can(permissions...) {
+				logger.Error("forbidden operation",
+					zap.String("operation", route.Operation.ID),
+					zap.Any("required_permissions", permissions))
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(mw)
+	}
+}
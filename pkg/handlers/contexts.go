@@ -2,11 +2,16 @@ package handlers
 
 import (
 	"github.com/gobuffalo/pop"
+	"github.com/transcom/mymove/pkg/addressverifier"
+	"github.com/transcom/mymove/pkg/auth"
+	"github.com/transcom/mymove/pkg/dpsauth"
 	"github.com/transcom/mymove/pkg/iws"
 	"github.com/transcom/mymove/pkg/logging/hnyzap"
 	"github.com/transcom/mymove/pkg/notifications"
 	"github.com/transcom/mymove/pkg/route"
+	"github.com/transcom/mymove/pkg/scanner"
 	"github.com/transcom/mymove/pkg/storage"
+	"github.com/transcom/mymove/pkg/uploader"
 	"go.uber.org/zap"
 )
 
@@ -17,35 +22,49 @@ type HandlerContext interface {
 	HoneyZapLogger() *hnyzap.Logger
 	FileStorer() storage.FileStorer
 	SetFileStorer(storer storage.FileStorer)
+	FileScanner() scanner.Scanner
+	SetFileScanner(fileScanner scanner.Scanner)
+	ImageConverter() uploader.ImageConverter
+	SetImageConverter(converter uploader.ImageConverter)
 	NotificationSender() notifications.NotificationSender
 	SetNotificationSender(sender notifications.NotificationSender)
 	Planner() route.Planner
 	SetPlanner(planner route.Planner)
-	CookieSecret() string
-	SetCookieSecret(secret string)
-	NoSessionTimeout() bool
-	SetNoSessionTimeout()
+	AddressVerifier() addressverifier.Verifier
+	SetAddressVerifier(verifier addressverifier.Verifier)
+	SessionManager() *auth.SessionManager
+	SetSessionManager(sessions *auth.SessionManager)
 	IWSRealTimeBrokerService() iws.RealTimeBrokerService
 	SetIWSRealTimeBrokerService(rbs iws.RealTimeBrokerService)
+	DPSAuthenticator() *dpsauth.Authenticator
+	SetDPSAuthenticator(authenticator *dpsauth.Authenticator)
 }
 
 // A single handlerContext is passed to each handler
 type handlerContext struct {
 	db                       *pop.Connection
 	logger                   *zap.Logger
-	cookieSecret             string
-	noSessionTimeout         bool
+	sessionManager           *auth.SessionManager
 	planner                  route.Planner
+	addressVerifier          addressverifier.Verifier
 	storage                  storage.FileStorer
+	fileScanner              scanner.Scanner
+	imageConverter           uploader.ImageConverter
 	notificationSender       notifications.NotificationSender
 	iwsRealTimeBrokerService iws.RealTimeBrokerService
+	dpsAuthenticator         *dpsauth.Authenticator
 }
 
 // NewHandlerContext returns a new handlerContext with its required private fields set.
+// Uploads are refused until a scanner is set with SetFileScanner, and addresses are saved
+// as entered until a real verifier is set with SetAddressVerifier.
+// Sessions are kept in memory until a session manager is set with SetSessionManager.
 func NewHandlerContext(db *pop.Connection, logger *zap.Logger) HandlerContext {
 	return &handlerContext{
-		db:     db,
-		logger: logger,
+		db:              db,
+		logger:          logger,
+		addressVerifier: addressverifier.NewNoopVerifier(),
+		sessionManager:  auth.NewSessionManager(logger, auth.NewMemorySessionStore(), auth.DefaultSessionTimeouts),
 	}
 }
 
@@ -74,6 +93,26 @@ func (context *handlerContext) SetFileStorer(storer storage.FileStorer) {
 	context.storage = storer
 }
 
+// FileScanner returns the malware scanner to use in the current context
+func (context *handlerContext) FileScanner() scanner.Scanner {
+	return context.fileScanner
+}
+
+// SetFileScanner is a simple setter for the fileScanner private field
+func (context *handlerContext) SetFileScanner(fileScanner scanner.Scanner) {
+	context.fileScanner = fileScanner
+}
+
+// ImageConverter returns the converter for uploaded images that can't be decoded directly
+func (context *handlerContext) ImageConverter() uploader.ImageConverter {
+	return context.imageConverter
+}
+
+// SetImageConverter is a simple setter for the imageConverter private field
+func (context *handlerContext) SetImageConverter(converter uploader.ImageConverter) {
+	context.imageConverter = converter
+}
+
 // NotificationSender returns the sender to use in the current context
 func (context *handlerContext) NotificationSender() notifications.NotificationSender {
 	return context.notificationSender
@@ -94,24 +133,24 @@ func (context *handlerContext) SetPlanner(planner route.Planner) {
 	context.planner = planner
 }
 
-// CookieSecret returns the secret key to use when signing cookies
-func (context *handlerContext) CookieSecret() string {
-	return context.cookieSecret
+// AddressVerifier returns the verifier that checks addresses before they are saved
+func (context *handlerContext) AddressVerifier() addressverifier.Verifier {
+	return context.addressVerifier
 }
 
-// SetCookieSecret is a simple setter for the cookieSeecret private Field
-func (context *handlerContext) SetCookieSecret(cookieSecret string) {
-	context.cookieSecret = cookieSecret
+// SetAddressVerifier is a simple setter for the addressVerifier private field
+func (context *handlerContext) SetAddressVerifier(verifier addressverifier.Verifier) {
+	context.addressVerifier = verifier
 }
 
-// NoSessionTimeout is a flag which, when true, indicates that sessions should not timeout. Used in dev.
-func (context *handlerContext) NoSessionTimeout() bool {
-	return context.noSessionTimeout
+// SessionManager returns the manager of logged in users' sessions
+func (context *handlerContext) SessionManager() *auth.SessionManager {
+	return context.sessionManager
 }
 
-// SetNoSessionTimeout is a simple setter for the noSessionTimeout private Field
-func (context *handlerContext) SetNoSessionTimeout() {
-	context.noSessionTimeout = true
+// SetSessionManager is a simple setter for the sessionManager private field
+func (context *handlerContext) SetSessionManager(sessions *auth.SessionManager) {
+	context.sessionManager = sessions
 }
 
 func (context *handlerContext) IWSRealTimeBrokerService() iws.RealTimeBrokerService {
@@ -121,3 +160,13 @@ func (context *handlerContext) IWSRealTimeBrokerService() iws.RealTimeBrokerServ
 func (context *handlerContext) SetIWSRealTimeBrokerService(rbs iws.RealTimeBrokerService) {
 	context.iwsRealTimeBrokerService = rbs
 }
+
+// DPSAuthenticator returns the authenticator of DPS authentication cookies
+func (context *handlerContext) DPSAuthenticator() *dpsauth.Authenticator {
+	return context.dpsAuthenticator
+}
+
+// SetDPSAuthenticator is a simple setter for the dpsAuthenticator private field
+func (context *handlerContext) SetDPSAuthenticator(authenticator *dpsauth.Authenticator) {
+	context.dpsAuthenticator = authenticator
+}
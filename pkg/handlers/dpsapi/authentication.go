@@ -33,7 +33,7 @@ var affiliationMap = map[models.ServiceMemberAffiliation]dpsmessages.Affiliation
 // Handle returns user information given an encrypted token
 func (h GetUserHandler) Handle(params dps.GetUserParams) middleware.Responder {
 	token := params.Token
-	loginGovID, err := dpsauth.CookieToLoginGovID(token)
+	loginGovID, err := h.DPSAuthenticator().CookieToLoginGovID(token)
 	if err != nil {
 		h.Logger().Error("Extracting user ID from token", zap.Error(err))
 
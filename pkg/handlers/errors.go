@@ -68,6 +68,12 @@ func ResponseForError(logger *zap.Logger, err error) middleware.Responder {
 	case uploaderpkg.ErrZeroLengthFile:
 		skipLogger.Debug("uploaded zero length file", zap.Error(err))
 		return newErrResponse(http.StatusBadRequest, err)
+	case uploaderpkg.ErrUnsupportedContent:
+		skipLogger.Debug("uploaded file with unsupported content", zap.Error(err))
+		return newErrResponse(http.StatusBadRequest, err)
+	case models.ErrUploadQuarantined:
+		skipLogger.Info("upload quarantined", zap.Error(err))
+		return newErrResponse(http.StatusUnprocessableEntity, err)
 	case models.ErrInvalidPatchGate:
 		skipLogger.Debug("invalid patch gate", zap.Error(err))
 		return newErrResponse(http.StatusBadRequest, err)
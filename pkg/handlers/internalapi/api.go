@@ -5,11 +5,74 @@ import (
 	"net/http"
 
 	"github.com/go-openapi/loads"
+
+	"github.com/transcom/mymove/pkg/auth"
 	"github.com/transcom/mymove/pkg/gen/internalapi"
 	internalops "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations"
 	"github.com/transcom/mymove/pkg/handlers"
 )
 
+// permissionRequirements are the permissions needed for the office operations of the internal
+// API. The service member operations only need a logged in user, and check that the records
+// they touch belong to them.
+var permissionRequirements = handlers.PermissionRequirements{
+	"showQueue":        {auth.PermissionViewMoveQueues},
+	"showOfficeOrders": {auth.PermissionViewMoves},
+
+	"showPPMIncentive":           {auth.PermissionViewMoves},
+	"showPPMIncentiveTrace":      {auth.PermissionViewMoves},
+	"indexPPMIncentiveSnapshots": {auth.PermissionViewMoves},
+	"showShipmentRateTrace":      {auth.PermissionViewMoves},
+	"showMoveTimeline":           {auth.PermissionViewMoves},
+	"indexAuditEvents":           {auth.PermissionViewMoves},
+
+	"approveMove":             {auth.PermissionApprovePPMs},
+	"cancelMove":              {auth.PermissionApprovePPMs},
+	"approvePPM":              {auth.PermissionApprovePPMs},
+	"approveReimbursement":    {auth.PermissionApprovePPMs},
+	"createPPMCloseoutPacket": {auth.PermissionApprovePPMs},
+
+	"approveHHG":            {auth.PermissionApproveHHGs},
+	"completeHHG":           {auth.PermissionApproveHHGs},
+	"sendHHGInvoice":        {auth.PermissionApproveHHGs},
+	"createGovBillOfLading": {auth.PermissionApproveHHGs},
+	"sendGexRequest":        {auth.PermissionApproveHHGs},
+}
+
+// delegatedPermissionRequirements are the permissions needed for the operations service members
+// use to change their own records, when anyone else uses them on a service member's behalf
+var delegatedPermissionRequirements = handlers.PermissionRequirements{
+	"createServiceMember":              {auth.PermissionEditMoves},
+	"patchServiceMember":               {auth.PermissionEditMoves},
+	"createServiceMemberBackupContact": {auth.PermissionEditMoves},
+	"updateServiceMemberBackupContact": {auth.PermissionEditMoves},
+
+	"createOrders":              {auth.PermissionEditMoves},
+	"updateOrders":              {auth.PermissionEditMoves},
+	"patchOrders":               {auth.PermissionEditMoves},
+	"createMove":                {auth.PermissionEditMoves},
+	"patchMove":                 {auth.PermissionEditMoves},
+	"submitMoveForApproval":     {auth.PermissionEditMoves},
+	"createSignedCertification": {auth.PermissionEditMoves},
+
+	"createPersonallyProcuredMove": {auth.PermissionEditMoves},
+	"updatePersonallyProcuredMove": {auth.PermissionEditMoves},
+	"patchPersonallyProcuredMove":  {auth.PermissionEditMoves},
+	"requestPPMPayment":            {auth.PermissionEditMoves},
+	"createPPMAttachments":         {auth.PermissionEditMoves},
+
+	"createShipment": {auth.PermissionEditShipments},
+	"patchShipment":  {auth.PermissionEditShipments},
+
+	"createGenericMoveDocument":   {auth.PermissionEditMoves},
+	"createMovingExpenseDocument": {auth.PermissionEditMoves},
+	"updateMoveDocument":          {auth.PermissionEditMoves},
+	"createDocument":              {auth.PermissionEditMoves},
+	"createUpload":                {auth.PermissionEditMoves},
+	"deleteUpload":                {auth.PermissionEditMoves},
+	"deleteUploads":               {auth.PermissionEditMoves},
+}
+
 // NewInternalAPIHandler returns a handler for the internal API
 func NewInternalAPIHandler(context handlers.HandlerContext) http.Handler {
 
@@ -33,6 +96,7 @@ func NewInternalAPIHandler(context handlers.HandlerContext) http.Handler {
 	internalAPI.PpmShowPPMEstimateHandler = ShowPPMEstimateHandler{context}
 	internalAPI.PpmShowPPMSitEstimateHandler = ShowPPMSitEstimateHandler{context}
 	internalAPI.PpmShowPPMIncentiveHandler = ShowPPMIncentiveHandler{context}
+	internalAPI.PpmShowPPMIncentiveTraceHandler = ShowPPMIncentiveTraceHandler{context}
 	internalAPI.PpmRequestPPMPaymentHandler = RequestPPMPaymentHandler{context}
 	internalAPI.PpmCreatePPMAttachmentsHandler = CreatePersonallyProcuredMoveAttachmentsHandler{context}
 	internalAPI.PpmRequestPPMExpenseSummaryHandler = RequestPPMExpenseSummaryHandler{context}
@@ -81,11 +145,23 @@ func NewInternalAPIHandler(context handlers.HandlerContext) http.Handler {
 	internalAPI.ShipmentsApproveHHGHandler = ApproveHHGHandler{context}
 	internalAPI.ShipmentsCompleteHHGHandler = CompleteHHGHandler{context}
 	internalAPI.ShipmentsSendHHGInvoiceHandler = ShipmentInvoiceHandler{context}
+	internalAPI.ShipmentsCreateGovBillOfLadingHandler = CreateGovBillOfLadingHandler{context}
+	internalAPI.ShipmentsShowShipmentRateTraceHandler = ShowShipmentRateTraceHandler{context}
 
 	internalAPI.OfficeApproveMoveHandler = ApproveMoveHandler{context}
 	internalAPI.OfficeApprovePPMHandler = ApprovePPMHandler{context}
 	internalAPI.OfficeApproveReimbursementHandler = ApproveReimbursementHandler{context}
 	internalAPI.OfficeCancelMoveHandler = CancelMoveHandler{context}
+	internalAPI.OfficeCreatePPMCloseoutPacketHandler = CreatePPMCloseoutPacketHandler{context}
+	internalAPI.OfficeIndexPPMIncentiveSnapshotsHandler = IndexPPMIncentiveSnapshotsHandler{context}
+
+	internalAPI.AuditEventsShowMoveTimelineHandler = ShowMoveTimelineHandler{context}
+	internalAPI.AuditEventsIndexAuditEventsHandler = IndexAuditEventsHandler{context}
+
+	internalAPI.PaperworkCreatePaperworkJobHandler = CreatePaperworkJobHandler{context}
+	internalAPI.PaperworkShowPaperworkJobHandler = ShowPaperworkJobHandler{context}
+	internalAPI.PaperworkShowPaperworkJobResultHandler = ShowPaperworkJobResultHandler{context}
+	internalAPI.PaperworkRetryPaperworkJobHandler = RetryPaperworkJobHandler{context}
 
 	internalAPI.EntitlementsValidateEntitlementHandler = ValidateEntitlementHandler{context}
 
@@ -93,5 +169,9 @@ func NewInternalAPIHandler(context handlers.HandlerContext) http.Handler {
 
 	internalAPI.CalendarShowAvailableMoveDatesHandler = ShowAvailableMoveDatesHandler{context}
 
-	return internalAPI.Serve(nil)
+	requirePermissions := handlers.RequirePermissions(context.Logger(), permissionRequirements)
+	requireDelegatedPermissions := handlers.RequireDelegatedPermissions(context.Logger(), delegatedPermissionRequirements)
+	return internalAPI.Serve(func(next http.Handler) http.Handler {
+		return requirePermissions(requireDelegatedPermissions(next))
+	})
 }
@@ -0,0 +1,121 @@
+package internalapi
+
+import (
+	"time"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/gofrs/uuid"
+
+	"github.com/transcom/mymove/pkg/auth"
+	auditop "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations/audit_events"
+	"github.com/transcom/mymove/pkg/gen/internalmessages"
+	"github.com/transcom/mymove/pkg/handlers"
+	"github.com/transcom/mymove/pkg/models"
+)
+
+func payloadForAuditEventModel(event models.AuditEvent) *internalmessages.AuditEventPayload {
+	return &internalmessages.AuditEventPayload{
+		ID:          handlers.FmtUUID(event.ID),
+		CreatedAt:   handlers.FmtDateTime(event.CreatedAt),
+		ActorType:   swag.String(string(event.ActorType)),
+		UserID:      handlers.FmtUUIDPtr(event.UserID),
+		APIClientID: handlers.FmtUUIDPtr(event.APIClientID),
+		ActorName:   swag.String(event.ActorName),
+		EntityType:  internalmessages.AuditEntityType(event.EntityType),
+		EntityID:    handlers.FmtUUID(event.EntityID),
+		MoveID:      handlers.FmtUUIDPtr(event.MoveID),
+		Event:       swag.String(event.Event),
+		OldValues:   event.OldValues,
+		NewValues:   event.NewValues,
+		Reason:      event.Reason,
+	}
+}
+
+func payloadForAuditEventModels(events models.AuditEvents) internalmessages.AuditEvents {
+	payloads := make(internalmessages.AuditEvents, len(events))
+	for i, event := range events {
+		payloads[i] = payloadForAuditEventModel(event)
+	}
+	return payloads
+}
+
+// ShowMoveTimelineHandler returns the audit trail of a move
+type ShowMoveTimelineHandler struct {
+	handlers.HandlerContext
+}
+
+// Handle returns every audited change to a move and the records that belong to it, oldest first
+func (h ShowMoveTimelineHandler) Handle(params auditop.ShowMoveTimelineParams) middleware.Responder {
+	session := auth.SessionFromRequestContext(params.HTTPRequest)
+
+	if !session.Can(auth.PermissionViewMoves) {
+		return auditop.NewShowMoveTimelineForbidden()
+	}
+
+	// #nosec UUID is pattern matched by swagger and will be ok
+	moveID, _ := uuid.FromString(params.MoveID.String())
+
+	move, err := models.FetchMove(h.DB(), session, moveID)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+
+	events, err := models.FetchMoveTimeline(h.DB(), move.ID)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+	return auditop.NewShowMoveTimelineOK().WithPayload(payloadForAuditEventModels(events))
+}
+
+// IndexAuditEventsHandler searches the audit trail
+type IndexAuditEventsHandler struct {
+	handlers.HandlerContext
+}
+
+func uuidPtrFromParam(param *strfmt.UUID) *uuid.UUID {
+	if param == nil {
+		return nil
+	}
+	// #nosec UUID is pattern matched by swagger and will be ok
+	id, _ := uuid.FromString(param.String())
+	return &id
+}
+
+// Handle returns the audit events that match every filter given, newest first
+func (h IndexAuditEventsHandler) Handle(params auditop.IndexAuditEventsParams) middleware.Responder {
+	session := auth.SessionFromRequestContext(params.HTTPRequest)
+
+	if !session.Can(auth.PermissionViewMoves) {
+		return auditop.NewIndexAuditEventsForbidden()
+	}
+
+	filter := models.AuditEventFilter{
+		EntityID: uuidPtrFromParam(params.EntityID),
+		MoveID:   uuidPtrFromParam(params.MoveID),
+		UserID:   uuidPtrFromParam(params.UserID),
+		Event:    params.Event,
+	}
+	if params.EntityType != nil {
+		entityType := models.AuditEntityType(*params.EntityType)
+		filter.EntityType = &entityType
+	}
+	if params.Since != nil {
+		since := time.Time(*params.Since)
+		filter.Since = &since
+	}
+	if params.Until != nil {
+		until := time.Time(*params.Until)
+		filter.Until = &until
+	}
+	if params.Limit != nil {
+		filter.Limit = int(*params.Limit)
+	}
+
+	events, err := models.FetchAuditEvents(h.DB(), filter)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+	return auditop.NewIndexAuditEventsOK().WithPayload(payloadForAuditEventModels(events))
+}
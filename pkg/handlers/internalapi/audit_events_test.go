@@ -0,0 +1,99 @@
+package internalapi
+
+import (
+	"net/http/httptest"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+
+	auditop "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations/audit_events"
+	officeop "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations/office"
+	"github.com/transcom/mymove/pkg/gen/internalmessages"
+	"github.com/transcom/mymove/pkg/handlers"
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/testdatagen"
+)
+
+func (suite *HandlerSuite) TestShowMoveTimelineHandler() {
+	// Given: a submitted move with complete orders, and an office user who approves it
+	hhgPermitted := internalmessages.OrdersTypeDetailHHGPERMITTED
+	move := testdatagen.MakeMove(suite.TestDB(), testdatagen.Assertions{
+		Order: models.Order{
+			OrdersNumber:        handlers.FmtString("1234"),
+			OrdersTypeDetail:    &hhgPermitted,
+			TAC:                 handlers.FmtString("1234"),
+			DepartmentIndicator: handlers.FmtString("17 - United States Marines"),
+		},
+	})
+	suite.NoError(move.Submit())
+	suite.MustSave(&move)
+	officeUser := testdatagen.MakeDefaultOfficeUser(suite.TestDB())
+	context := handlers.NewHandlerContext(suite.TestDB(), suite.TestLogger())
+
+	req := httptest.NewRequest("POST", "/moves/some_id/approve", nil)
+	req = suite.AuthenticateOfficeRequest(req, officeUser)
+	response := ApproveMoveHandler{context}.Handle(officeop.ApproveMoveParams{
+		HTTPRequest: req,
+		MoveID:      strfmt.UUID(move.ID.String()),
+	})
+	suite.Assertions.IsType(&officeop.ApproveMoveOK{}, response)
+
+	// When: the office user views the move's timeline
+	req = httptest.NewRequest("GET", "/moves/some_id/timeline", nil)
+	req = suite.AuthenticateOfficeRequest(req, officeUser)
+	response = ShowMoveTimelineHandler{context}.Handle(auditop.ShowMoveTimelineParams{
+		HTTPRequest: req,
+		MoveID:      strfmt.UUID(move.ID.String()),
+	})
+
+	// Then: the approval is recorded, along with who made it
+	suite.Assertions.IsType(&auditop.ShowMoveTimelineOK{}, response)
+	timeline := response.(*auditop.ShowMoveTimelineOK).Payload
+	if suite.Len(timeline, 1) {
+		suite.Equal("approve", *timeline[0].Event)
+		suite.Equal(string(models.AuditActorTypeOFFICEUSER), *timeline[0].ActorType)
+		suite.Equal(officeUser.UserID.String(), timeline[0].UserID.String())
+		suite.Equal(string(models.MoveStatusSUBMITTED), timeline[0].OldValues["status"])
+		suite.Equal(string(models.MoveStatusAPPROVED), timeline[0].NewValues["status"])
+	}
+
+	// And: the event can be found by searching the audit trail
+	req = httptest.NewRequest("GET", "/audit_events", nil)
+	req = suite.AuthenticateOfficeRequest(req, officeUser)
+	userID := strfmt.UUID(officeUser.UserID.String())
+	response = IndexAuditEventsHandler{context}.Handle(auditop.IndexAuditEventsParams{
+		HTTPRequest: req,
+		EntityType:  swag.String(string(models.AuditEntityTypeMOVE)),
+		UserID:      &userID,
+		Event:       swag.String("approve"),
+	})
+	suite.Assertions.IsType(&auditop.IndexAuditEventsOK{}, response)
+	suite.Len(response.(*auditop.IndexAuditEventsOK).Payload, 1)
+
+	response = IndexAuditEventsHandler{context}.Handle(auditop.IndexAuditEventsParams{
+		HTTPRequest: req,
+		Event:       swag.String("cancel"),
+	})
+	suite.Len(response.(*auditop.IndexAuditEventsOK).Payload, 0)
+}
+
+func (suite *HandlerSuite) TestShowMoveTimelineHandlerForbidden() {
+	// Given: a move, and a service member
+	move := testdatagen.MakeDefaultMove(suite.TestDB())
+
+	req := httptest.NewRequest("GET", "/moves/some_id/timeline", nil)
+	req = suite.AuthenticateRequest(req, move.Orders.ServiceMember)
+	context := handlers.NewHandlerContext(suite.TestDB(), suite.TestLogger())
+
+	// When: the service member views the audit trail
+	response := ShowMoveTimelineHandler{context}.Handle(auditop.ShowMoveTimelineParams{
+		HTTPRequest: req,
+		MoveID:      strfmt.UUID(move.ID.String()),
+	})
+
+	// Then: they aren't allowed to, even for their own move
+	suite.Assertions.IsType(&auditop.ShowMoveTimelineForbidden{}, response)
+
+	response = IndexAuditEventsHandler{context}.Handle(auditop.IndexAuditEventsParams{HTTPRequest: req})
+	suite.Assertions.IsType(&auditop.IndexAuditEventsForbidden{}, response)
+}
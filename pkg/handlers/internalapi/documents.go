@@ -14,15 +14,20 @@ import (
 )
 
 func payloadForDocumentModel(storer storage.FileStorer, document models.Document) (*internalmessages.DocumentPayload, error) {
-	uploads := make([]*internalmessages.UploadPayload, len(document.Uploads))
-	for i, upload := range document.Uploads {
+	uploads := make([]*internalmessages.UploadPayload, 0, len(document.Uploads))
+	for _, upload := range document.Uploads {
+		// Quarantined uploads are never presigned
+		if upload.IsQuarantined() {
+			continue
+		}
+
 		url, err := storer.PresignedURL(upload.StorageKey, upload.ContentType)
 		if err != nil {
 			return nil, err
 		}
 
 		uploadPayload := payloadForUploadModel(upload, url)
-		uploads[i] = uploadPayload
+		uploads = append(uploads, uploadPayload)
 	}
 
 	documentPayload := &internalmessages.DocumentPayload{
@@ -13,6 +13,13 @@ import (
 // PPMDiscountFetch attempts to fetch the discount rates first for COS D, then 2
 // Most PPMs use COS D, but when there is no COS D rate, the calculation is based on Code 2
 func PPMDiscountFetch(db *pop.Connection, logger *zap.Logger, originZip string, destZip string, moveDate time.Time) (unit.DiscountRate, unit.DiscountRate, error) {
+	lhDiscount, sitDiscount, _, err := ppmDiscountFetchWithCOS(db, logger, originZip, destZip, moveDate)
+	return lhDiscount, sitDiscount, err
+}
+
+// ppmDiscountFetchWithCOS works like PPMDiscountFetch, but also returns the code of
+// service whose rates were used
+func ppmDiscountFetchWithCOS(db *pop.Connection, logger *zap.Logger, originZip string, destZip string, moveDate time.Time) (unit.DiscountRate, unit.DiscountRate, string, error) {
 	// Try to fetch with COS D.
 	lhDiscount, sitDiscount, err := models.FetchDiscountRates(db,
 		originZip,
@@ -26,11 +33,11 @@ func PPMDiscountFetch(db *pop.Connection, logger *zap.Logger, originZip string,
 			zap.String("destination_zip", destZip),
 			zap.Time("move_date", moveDate),
 		)
-		return lhDiscount, sitDiscount, err
+		return lhDiscount, sitDiscount, "D", err
 	}
 
 	if err != models.ErrFetchNotFound {
-		return 0, 0, err
+		return 0, 0, "", err
 	}
 	// When COS D not found, COS 2 may have rates.
 	lhDiscount, sitDiscount, err = models.FetchDiscountRates(db,
@@ -45,7 +52,7 @@ func PPMDiscountFetch(db *pop.Connection, logger *zap.Logger, originZip string,
 			zap.String("destination_zip", destZip),
 			zap.Time("move_date", moveDate),
 		)
-		return lhDiscount, sitDiscount, err
+		return lhDiscount, sitDiscount, "2", err
 	}
 
 	logger.Info("Couldn't find Discount for COS D or 2.",
@@ -54,5 +61,5 @@ func PPMDiscountFetch(db *pop.Connection, logger *zap.Logger, originZip string,
 		zap.Time("move_date", moveDate),
 		zap.Error(err),
 	)
-	return 0, 0, err
+	return 0, 0, "", err
 }
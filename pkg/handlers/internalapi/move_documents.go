@@ -142,13 +142,16 @@ func (h UpdateMoveDocumentHandler) Handle(params movedocop.UpdateMoveDocumentPar
 	moveDoc.MoveDocumentType = newType
 
 	newStatus := models.MoveDocumentStatus(payload.Status)
+	var events []*models.AuditEvent
 
 	// If this is a shipment summary and it has been approved, we process the ppm.
 	if newStatus != moveDoc.Status {
+		oldStatus := moveDoc.Status
 		err = moveDoc.AttemptTransition(newStatus)
 		if err != nil {
 			return handlers.ResponseForError(h.Logger(), err)
 		}
+		events = append(events, moveDoc.TransitionAuditEvent(session, oldStatus))
 
 		if newStatus == models.MoveDocumentStatusOK && moveDoc.MoveDocumentType == models.MoveDocumentTypeSHIPMENTSUMMARY {
 			if moveDoc.PersonallyProcuredMoveID == nil {
@@ -160,10 +163,13 @@ func (h UpdateMoveDocumentHandler) Handle(params movedocop.UpdateMoveDocumentPar
 			// (because the document has been toggled between OK and HAS_ISSUE and back)
 			// then don't complete it again.
 			if ppm.Status != models.PPMStatusCOMPLETED {
+				oldPPMStatus := ppm.Status
 				err := ppm.Complete()
 				if err != nil {
 					return handlers.ResponseForError(h.Logger(), err)
 				}
+				events = append(events, models.NewAuditEvent(session, models.AuditEntityTypePPM, ppm.ID, &ppm.MoveID, "complete").
+					Change("status", oldPPMStatus, ppm.Status))
 			}
 		}
 	}
@@ -199,7 +205,7 @@ func (h UpdateMoveDocumentHandler) Handle(params movedocop.UpdateMoveDocumentPar
 		}
 	}
 
-	verrs, err := models.SaveMoveDocument(h.DB(), moveDoc, saveAction)
+	verrs, err := models.SaveMoveDocument(h.DB(), moveDoc, saveAction, events...)
 	if err != nil || verrs.HasAny() {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
 	}
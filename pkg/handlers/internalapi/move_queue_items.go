@@ -41,7 +41,7 @@ type ShowQueueHandler struct {
 func (h ShowQueueHandler) Handle(params queueop.ShowQueueParams) middleware.Responder {
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
 
-	if !session.IsOfficeUser() {
+	if !session.Can(auth.PermissionViewMoveQueues) {
 		return queueop.NewShowQueueForbidden()
 	}
 
@@ -126,6 +126,9 @@ type PatchMoveHandler struct {
 // Handle ... patches a Move from a request payload
 func (h PatchMoveHandler) Handle(params moveop.PatchMoveParams) middleware.Responder {
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
+	if !handlers.CanActForServiceMember(session, auth.PermissionEditMoves) {
+		return moveop.NewPatchMoveForbidden()
+	}
 	/* #nosec UUID is pattern matched by swagger which checks the format */
 	moveID, _ := uuid.FromString(params.MoveID.String())
 
@@ -182,6 +185,7 @@ func (h SubmitMoveHandler) Handle(params moveop.SubmitMoveForApprovalParams) mid
 		return handlers.ResponseForError(h.Logger(), err)
 	}
 
+	before := models.SnapshotMoveStatuses(move)
 	err = move.Submit()
 	span.AddField("move-status", string(move.Status))
 	if err != nil {
@@ -192,7 +196,7 @@ func (h SubmitMoveHandler) Handle(params moveop.SubmitMoveForApprovalParams) mid
 	}
 
 	// Transaction to save move and dependencies
-	verrs, err := models.SaveMoveDependencies(h.DB(), move)
+	verrs, err := models.SaveMoveDependencies(h.DB(), move, before.AuditEvents(session, move, "submit")...)
 	if err != nil || verrs.HasAny() {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
 	}
@@ -206,7 +210,7 @@ func (h SubmitMoveHandler) Handle(params moveop.SubmitMoveForApprovalParams) mid
 	}
 
 	if len(move.Shipments) > 0 {
-		go awardqueue.NewAwardQueue(h.DB(), h.HoneyZapLogger()).Run(ctx)
+		go awardqueue.NewAwardQueue(h.DB(), h.HoneyZapLogger(), h.NotificationSender()).Run(ctx)
 	}
 
 	movePayload, err := payloadForMoveModel(h.FileStorer(), move.Orders, *move)
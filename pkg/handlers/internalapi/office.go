@@ -21,7 +21,7 @@ type ApproveMoveHandler struct {
 func (h ApproveMoveHandler) Handle(params officeop.ApproveMoveParams) middleware.Responder {
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
 
-	if !session.IsOfficeUser() {
+	if !session.Can(auth.PermissionApprovePPMs) {
 		return officeop.NewApproveMoveForbidden()
 	}
 	// #nosec UUID is pattern matched by swagger and will be ok
@@ -39,13 +39,14 @@ func (h ApproveMoveHandler) Handle(params officeop.ApproveMoveParams) middleware
 		return officeop.NewApprovePPMBadRequest()
 	}
 
+	before := models.SnapshotMoveStatuses(move)
 	err = move.Approve()
 	if err != nil {
 		h.Logger().Info("Attempted to approve move, got invalid transition", zap.Error(err), zap.String("move_status", string(move.Status)))
 		return handlers.ResponseForError(h.Logger(), err)
 	}
 
-	verrs, err := h.DB().ValidateAndUpdate(move)
+	verrs, err := models.SaveWithAuditEvents(h.DB(), move, before.AuditEvents(session, move, "approve")...)
 	if err != nil || verrs.HasAny() {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
 	}
@@ -67,7 +68,7 @@ type CancelMoveHandler struct {
 // Handle ... cancels a Move from a request payload
 func (h CancelMoveHandler) Handle(params officeop.CancelMoveParams) middleware.Responder {
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
-	if !session.IsOfficeUser() {
+	if !session.Can(auth.PermissionApprovePPMs) {
 		return officeop.NewCancelMoveForbidden()
 	}
 
@@ -80,6 +81,7 @@ func (h CancelMoveHandler) Handle(params officeop.CancelMoveParams) middleware.R
 	}
 
 	// Canceling move will result in canceled associated PPMs
+	before := models.SnapshotMoveStatuses(move)
 	err = move.Cancel(*params.CancelMove.CancelReason)
 	if err != nil {
 		h.Logger().Error("Attempted to cancel move, got invalid transition", zap.Error(err), zap.String("move_status", string(move.Status)))
@@ -87,7 +89,7 @@ func (h CancelMoveHandler) Handle(params officeop.CancelMoveParams) middleware.R
 	}
 
 	// Save move, orders, and PPMs statuses
-	verrs, err := models.SaveMoveDependencies(h.DB(), move)
+	verrs, err := models.SaveMoveDependencies(h.DB(), move, before.AuditEvents(session, move, "cancel")...)
 	if err != nil || verrs.HasAny() {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
 	}
@@ -116,7 +118,7 @@ type ApprovePPMHandler struct {
 // Handle ... approves a Personally Procured Move from a request payload
 func (h ApprovePPMHandler) Handle(params officeop.ApprovePPMParams) middleware.Responder {
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
-	if !session.IsOfficeUser() {
+	if !session.Can(auth.PermissionApprovePPMs) {
 		return officeop.NewApprovePPMForbidden()
 	}
 
@@ -128,13 +130,16 @@ func (h ApprovePPMHandler) Handle(params officeop.ApprovePPMParams) middleware.R
 		return handlers.ResponseForError(h.Logger(), err)
 	}
 	moveID := ppm.MoveID
+	oldStatus := ppm.Status
 	err = ppm.Approve()
 	if err != nil {
 		h.Logger().Error("Attempted to approve PPM, got invalid transition", zap.Error(err), zap.String("move_status", string(ppm.Status)))
 		return handlers.ResponseForError(h.Logger(), err)
 	}
 
-	verrs, err := h.DB().ValidateAndUpdate(ppm)
+	event := models.NewAuditEvent(session, models.AuditEntityTypePPM, ppm.ID, &moveID, "approve").
+		Change("status", oldStatus, ppm.Status)
+	verrs, err := models.SaveWithAuditEvents(h.DB(), ppm, event)
 	if err != nil || verrs.HasAny() {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
 	}
@@ -163,7 +168,7 @@ type ApproveReimbursementHandler struct {
 func (h ApproveReimbursementHandler) Handle(params officeop.ApproveReimbursementParams) middleware.Responder {
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
 
-	if !session.IsOfficeUser() {
+	if !session.Can(auth.PermissionApprovePPMs) {
 		return officeop.NewApproveReimbursementForbidden()
 	}
 
@@ -175,13 +180,17 @@ func (h ApproveReimbursementHandler) Handle(params officeop.ApproveReimbursement
 		return handlers.ResponseForError(h.Logger(), err)
 	}
 
+	oldStatus := reimbursement.Status
 	err = reimbursement.Approve()
 	if err != nil {
 		h.Logger().Error("Attempted to approve, got invalid transition", zap.Error(err), zap.String("reimbursement_status", string(reimbursement.Status)))
 		return handlers.ResponseForError(h.Logger(), err)
 	}
 
-	verrs, err := h.DB().ValidateAndUpdate(reimbursement)
+	// The event is put on the timeline of the move the reimbursement advances when it's saved
+	event := models.NewAuditEvent(session, models.AuditEntityTypeREIMBURSEMENT, reimbursement.ID, nil, "approve").
+		Change("status", oldStatus, reimbursement.Status)
+	verrs, err := models.SaveWithAuditEvents(h.DB(), reimbursement, event)
 	if err != nil || verrs.HasAny() {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
 	}
@@ -150,6 +150,9 @@ type UpdateOrdersHandler struct {
 // Handle ... updates an order from a request payload
 func (h UpdateOrdersHandler) Handle(params ordersop.UpdateOrdersParams) middleware.Responder {
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
+	if !handlers.CanActForServiceMember(session, auth.PermissionEditMoves) {
+		return ordersop.NewUpdateOrdersForbidden()
+	}
 
 	orderID, err := uuid.FromString(params.OrdersID.String())
 	if err != nil {
@@ -0,0 +1,188 @@
+package internalapi
+
+import (
+	"time"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/gofrs/uuid"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/auth"
+	paperworkop "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations/paperwork"
+	"github.com/transcom/mymove/pkg/gen/internalmessages"
+	"github.com/transcom/mymove/pkg/handlers"
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/storage"
+)
+
+func payloadForPaperworkJobModel(storer storage.FileStorer, job models.PaperworkJob) (*internalmessages.PaperworkJobPayload, error) {
+	payload := internalmessages.PaperworkJobPayload{
+		ID:                       handlers.FmtUUID(job.ID),
+		JobType:                  internalmessages.PaperworkJobType(job.JobType),
+		Status:                   internalmessages.PaperworkJobStatus(job.Status),
+		PersonallyProcuredMoveID: handlers.FmtUUID(job.PersonallyProcuredMoveID),
+		Attempts:                 swag.Int64(int64(job.Attempts)),
+		MaxAttempts:              swag.Int64(int64(job.MaxAttempts)),
+		LastError:                job.LastError,
+		CreatedAt:                handlers.FmtDateTime(job.CreatedAt),
+		UpdatedAt:                handlers.FmtDateTime(job.UpdatedAt),
+		CompletedAt:              handlers.FmtDateTimePtr(job.CompletedAt),
+	}
+	if job.Upload != nil {
+		url, err := storer.PresignedURL(job.Upload.StorageKey, job.Upload.ContentType)
+		if err != nil {
+			return nil, err
+		}
+		payload.Upload = payloadForUploadModel(*job.Upload, url)
+	}
+	return &payload, nil
+}
+
+// CreatePaperworkJobHandler queues paperwork to be generated via POST /paperwork_jobs
+type CreatePaperworkJobHandler struct {
+	handlers.HandlerContext
+}
+
+// Handle checks that the paperwork can be generated and queues a job to generate it
+func (h CreatePaperworkJobHandler) Handle(params paperworkop.CreatePaperworkJobParams) middleware.Responder {
+	session := auth.SessionFromRequestContext(params.HTTPRequest)
+	payload := params.CreatePaperworkJobPayload
+
+	// #nosec UUID is pattern matched by swagger and will be ok
+	ppmID, _ := uuid.FromString(payload.PersonallyProcuredMoveID.String())
+
+	ppm, err := models.FetchPersonallyProcuredMove(h.DB(), session, ppmID)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+
+	job := models.NewPaperworkJob(models.PaperworkJobType(payload.JobType), ppm.ID, session.UserID)
+	switch job.JobType {
+	case models.PaperworkJobTypePPMATTACHMENTS:
+		docTypes := make([]string, len(payload.DocTypes))
+		for i, docType := range payload.DocTypes {
+			docTypes[i] = string(docType)
+		}
+		// Fail now, rather than in the worker, if there is nothing to merge
+		moveDocs, err := ppm.FetchMoveDocumentsForTypes(h.DB(), docTypes)
+		if err != nil {
+			return handlers.ResponseForError(h.Logger(), err)
+		}
+		if len(moveDocs) == 0 {
+			return paperworkop.NewCreatePaperworkJobFailedDependency()
+		}
+		job.SetDocTypes(docTypes)
+	case models.PaperworkJobTypePPMCLOSEOUT:
+		if !session.Can(auth.PermissionApprovePPMs) {
+			return paperworkop.NewCreatePaperworkJobForbidden()
+		}
+		if ppm.Status != models.PPMStatusPAYMENTREQUESTED && ppm.Status != models.PPMStatusCOMPLETED {
+			return paperworkop.NewCreatePaperworkJobConflict()
+		}
+		// The incentive is priced when the packet is requested, which is quick, so the
+		// worker only has to do the slow work of assembling the packet
+		incentive, err := ppmIncentive(h, session, *ppm)
+		if err != nil {
+			h.Logger().Error("failed to calculate PPM incentive", zap.Error(err), zap.String("ppm_id", ppmID.String()))
+			return paperworkop.NewCreatePaperworkJobUnprocessableEntity()
+		}
+		job.IncentiveCents = &incentive
+	default:
+		return paperworkop.NewCreatePaperworkJobBadRequest()
+	}
+
+	verrs, err := h.DB().ValidateAndCreate(&job)
+	if err != nil || verrs.HasAny() {
+		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
+	}
+
+	jobPayload, err := payloadForPaperworkJobModel(h.FileStorer(), job)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+	return paperworkop.NewCreatePaperworkJobCreated().WithPayload(jobPayload)
+}
+
+// ShowPaperworkJobHandler returns a paperwork job via GET /paperwork_jobs/{paperworkJobId}
+type ShowPaperworkJobHandler struct {
+	handlers.HandlerContext
+}
+
+// Handle returns the status of a paperwork job
+func (h ShowPaperworkJobHandler) Handle(params paperworkop.ShowPaperworkJobParams) middleware.Responder {
+	session := auth.SessionFromRequestContext(params.HTTPRequest)
+
+	// #nosec UUID is pattern matched by swagger and will be ok
+	jobID, _ := uuid.FromString(params.PaperworkJobID.String())
+
+	job, err := models.FetchPaperworkJob(h.DB(), session, jobID)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+
+	jobPayload, err := payloadForPaperworkJobModel(h.FileStorer(), *job)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+	return paperworkop.NewShowPaperworkJobOK().WithPayload(jobPayload)
+}
+
+// ShowPaperworkJobResultHandler returns the paperwork a job generated via GET /paperwork_jobs/{paperworkJobId}/result
+type ShowPaperworkJobResultHandler struct {
+	handlers.HandlerContext
+}
+
+// Handle returns the upload of the paperwork a completed job generated
+func (h ShowPaperworkJobResultHandler) Handle(params paperworkop.ShowPaperworkJobResultParams) middleware.Responder {
+	session := auth.SessionFromRequestContext(params.HTTPRequest)
+
+	// #nosec UUID is pattern matched by swagger and will be ok
+	jobID, _ := uuid.FromString(params.PaperworkJobID.String())
+
+	job, err := models.FetchPaperworkJob(h.DB(), session, jobID)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+	if job.Status != models.PaperworkJobStatusCOMPLETED || job.Upload == nil {
+		return paperworkop.NewShowPaperworkJobResultConflict()
+	}
+
+	url, err := h.FileStorer().PresignedURL(job.Upload.StorageKey, job.Upload.ContentType)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+	return paperworkop.NewShowPaperworkJobResultOK().WithPayload(payloadForUploadModel(*job.Upload, url))
+}
+
+// RetryPaperworkJobHandler retries a failed paperwork job via POST /paperwork_jobs/{paperworkJobId}/retry
+type RetryPaperworkJobHandler struct {
+	handlers.HandlerContext
+}
+
+// Handle queues a failed paperwork job to run again
+func (h RetryPaperworkJobHandler) Handle(params paperworkop.RetryPaperworkJobParams) middleware.Responder {
+	session := auth.SessionFromRequestContext(params.HTTPRequest)
+
+	// #nosec UUID is pattern matched by swagger and will be ok
+	jobID, _ := uuid.FromString(params.PaperworkJobID.String())
+
+	job, err := models.FetchPaperworkJob(h.DB(), session, jobID)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+	if err := job.Retry(time.Now()); err != nil {
+		return paperworkop.NewRetryPaperworkJobConflict()
+	}
+
+	verrs, err := h.DB().ValidateAndUpdate(job)
+	if err != nil || verrs.HasAny() {
+		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
+	}
+
+	jobPayload, err := payloadForPaperworkJobModel(h.FileStorer(), *job)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+	return paperworkop.NewRetryPaperworkJobOK().WithPayload(jobPayload)
+}
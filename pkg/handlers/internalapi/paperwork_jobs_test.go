@@ -0,0 +1,119 @@
+package internalapi
+
+import (
+	"net/http/httptest"
+
+	"github.com/go-openapi/strfmt"
+
+	paperworkop "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations/paperwork"
+	"github.com/transcom/mymove/pkg/gen/internalmessages"
+	"github.com/transcom/mymove/pkg/handlers"
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/testdatagen"
+)
+
+func (suite *HandlerSuite) TestCreatePaperworkJobHandler() {
+	ppm := testdatagen.MakeDefaultPPM(suite.TestDB())
+	sm := ppm.Move.Orders.ServiceMember
+	testdatagen.MakeMoveDocument(suite.TestDB(), testdatagen.Assertions{
+		MoveDocument: models.MoveDocument{
+			PersonallyProcuredMoveID: &ppm.ID,
+			MoveDocumentType:         models.MoveDocumentTypeWEIGHTTICKET,
+			Status:                   models.MoveDocumentStatusOK,
+		},
+	})
+	context := suite.createHandlerContext()
+
+	request := httptest.NewRequest("POST", "/paperwork_jobs", nil)
+	request = suite.AuthenticateRequest(request, sm)
+	params := paperworkop.CreatePaperworkJobParams{
+		HTTPRequest: request,
+		CreatePaperworkJobPayload: &internalmessages.CreatePaperworkJobPayload{
+			JobType:                  internalmessages.PaperworkJobTypePPMATTACHMENTS,
+			PersonallyProcuredMoveID: handlers.FmtUUID(ppm.ID),
+			DocTypes:                 []internalmessages.MoveDocumentType{internalmessages.MoveDocumentTypeWEIGHTTICKET},
+		},
+	}
+	response := CreatePaperworkJobHandler{context}.Handle(params)
+	suite.Assertions.IsType(&paperworkop.CreatePaperworkJobCreated{}, response)
+	jobPayload := response.(*paperworkop.CreatePaperworkJobCreated).Payload
+	suite.Equal(internalmessages.PaperworkJobStatusQUEUED, jobPayload.Status)
+	suite.Equal(int64(0), *jobPayload.Attempts)
+	suite.Nil(jobPayload.Upload)
+
+	// The job can be polled until it completes
+	request = httptest.NewRequest("GET", "/paperwork_jobs/id", nil)
+	request = suite.AuthenticateRequest(request, sm)
+	showResponse := ShowPaperworkJobHandler{context}.Handle(paperworkop.ShowPaperworkJobParams{
+		HTTPRequest:    request,
+		PaperworkJobID: *jobPayload.ID,
+	})
+	suite.Assertions.IsType(&paperworkop.ShowPaperworkJobOK{}, showResponse)
+	suite.Equal(*jobPayload.ID, *showResponse.(*paperworkop.ShowPaperworkJobOK).Payload.ID)
+
+	resultResponse := ShowPaperworkJobResultHandler{context}.Handle(paperworkop.ShowPaperworkJobResultParams{
+		HTTPRequest:    request,
+		PaperworkJobID: *jobPayload.ID,
+	})
+	suite.Assertions.IsType(&paperworkop.ShowPaperworkJobResultConflict{}, resultResponse)
+
+	// Only failed jobs can be retried
+	retryResponse := RetryPaperworkJobHandler{context}.Handle(paperworkop.RetryPaperworkJobParams{
+		HTTPRequest:    request,
+		PaperworkJobID: *jobPayload.ID,
+	})
+	suite.Assertions.IsType(&paperworkop.RetryPaperworkJobConflict{}, retryResponse)
+}
+
+func (suite *HandlerSuite) TestCreatePaperworkJobHandlerNoAttachments() {
+	ppm := testdatagen.MakeDefaultPPM(suite.TestDB())
+
+	request := httptest.NewRequest("POST", "/paperwork_jobs", nil)
+	request = suite.AuthenticateRequest(request, ppm.Move.Orders.ServiceMember)
+	params := paperworkop.CreatePaperworkJobParams{
+		HTTPRequest: request,
+		CreatePaperworkJobPayload: &internalmessages.CreatePaperworkJobPayload{
+			JobType:                  internalmessages.PaperworkJobTypePPMATTACHMENTS,
+			PersonallyProcuredMoveID: handlers.FmtUUID(ppm.ID),
+			DocTypes:                 []internalmessages.MoveDocumentType{internalmessages.MoveDocumentTypeWEIGHTTICKET},
+		},
+	}
+	response := CreatePaperworkJobHandler{suite.createHandlerContext()}.Handle(params)
+	suite.Assertions.IsType(&paperworkop.CreatePaperworkJobFailedDependency{}, response)
+}
+
+func (suite *HandlerSuite) TestCreatePaperworkJobHandlerCloseoutForbidden() {
+	ppm := testdatagen.MakePPM(suite.TestDB(), testdatagen.Assertions{
+		PersonallyProcuredMove: models.PersonallyProcuredMove{
+			Status: models.PPMStatusPAYMENTREQUESTED,
+		},
+	})
+
+	// Only the office can request a closeout packet
+	request := httptest.NewRequest("POST", "/paperwork_jobs", nil)
+	request = suite.AuthenticateRequest(request, ppm.Move.Orders.ServiceMember)
+	params := paperworkop.CreatePaperworkJobParams{
+		HTTPRequest: request,
+		CreatePaperworkJobPayload: &internalmessages.CreatePaperworkJobPayload{
+			JobType:                  internalmessages.PaperworkJobTypePPMCLOSEOUT,
+			PersonallyProcuredMoveID: handlers.FmtUUID(ppm.ID),
+		},
+	}
+	response := CreatePaperworkJobHandler{suite.createHandlerContext()}.Handle(params)
+	suite.Assertions.IsType(&paperworkop.CreatePaperworkJobForbidden{}, response)
+}
+
+func (suite *HandlerSuite) TestShowPaperworkJobHandlerForbidden() {
+	ppm := testdatagen.MakeDefaultPPM(suite.TestDB())
+	job := models.NewPaperworkJob(models.PaperworkJobTypePPMATTACHMENTS, ppm.ID, ppm.Move.Orders.ServiceMember.UserID)
+	suite.MustSave(&job)
+
+	other := testdatagen.MakeDefaultServiceMember(suite.TestDB())
+	request := httptest.NewRequest("GET", "/paperwork_jobs/id", nil)
+	request = suite.AuthenticateRequest(request, other)
+	response := ShowPaperworkJobHandler{suite.createHandlerContext()}.Handle(paperworkop.ShowPaperworkJobParams{
+		HTTPRequest:    request,
+		PaperworkJobID: strfmt.UUID(job.ID.String()),
+	})
+	suite.CheckResponseForbidden(response)
+}
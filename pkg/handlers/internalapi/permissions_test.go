@@ -0,0 +1,98 @@
+package internalapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/go-openapi/loads"
+	"github.com/go-openapi/spec"
+
+	"github.com/transcom/mymove/pkg/gen/internalapi"
+	"github.com/transcom/mymove/pkg/handlers"
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/testdatagen"
+)
+
+func (suite *HandlerSuite) TestPermissionRequirementsAreOperations() {
+	document, err := loads.Analyzed(internalapi.SwaggerJSON, "")
+	suite.NoError(err)
+
+	operationIDs := map[string]bool{}
+	for _, path := range document.Spec().Paths.Paths {
+		for _, operation := range []*spec.Operation{path.Get, path.Put, path.Post, path.Delete, path.Patch} {
+			if operation != nil {
+				operationIDs[operation.ID] = true
+			}
+		}
+	}
+	for id := range permissionRequirements {
+		suite.True(operationIDs[id], "%s is not an operation of the internal API", id)
+	}
+	for id := range delegatedPermissionRequirements {
+		suite.True(operationIDs[id], "%s is not an operation of the internal API", id)
+	}
+}
+
+func (suite *HandlerSuite) TestInternalAPIRequiresPermissions() {
+	context := handlers.NewHandlerContext(suite.TestDB(), suite.TestLogger())
+	api := NewInternalAPIHandler(context)
+
+	// Given: a service member, who has no roles
+	serviceMember := testdatagen.MakeDefaultServiceMember(suite.TestDB())
+	req := httptest.NewRequest("GET", "/internal/queues/new", nil)
+	req = suite.AuthenticateRequest(req, serviceMember)
+
+	// Then: they can't see the office queue
+	rr := httptest.NewRecorder()
+	api.ServeHTTP(rr, req)
+	suite.Equal(http.StatusForbidden, rr.Code)
+
+	// Given: an office user who can view the queues
+	officeUser := testdatagen.MakeDefaultOfficeUser(suite.TestDB())
+	req = httptest.NewRequest("GET", "/internal/queues/new", nil)
+	req = suite.AuthenticateOfficeRequest(req, officeUser)
+
+	rr = httptest.NewRecorder()
+	api.ServeHTTP(rr, req)
+	suite.Equal(http.StatusOK, rr.Code)
+
+	// Given: an office user who has lost their roles
+	err := models.RevokeRoles(suite.TestDB(), *officeUser.UserID, models.DefaultOfficeRoles...)
+	suite.NoError(err)
+	req = httptest.NewRequest("GET", "/internal/queues/new", nil)
+	req = suite.AuthenticateOfficeRequest(req, officeUser)
+
+	rr = httptest.NewRecorder()
+	api.ServeHTTP(rr, req)
+	suite.Equal(http.StatusForbidden, rr.Code)
+}
+
+func (suite *HandlerSuite) TestInternalAPIRequiresDelegatedPermissions() {
+	context := handlers.NewHandlerContext(suite.TestDB(), suite.TestLogger())
+	api := NewInternalAPIHandler(context)
+	move := testdatagen.MakeDefaultMove(suite.TestDB())
+	path := fmt.Sprintf("/internal/moves/%s", move.ID)
+
+	// Given: an office user who is only an auditor
+	officeUser := testdatagen.MakeDefaultOfficeUser(suite.TestDB())
+	err := models.RevokeRoles(suite.TestDB(), *officeUser.UserID, models.DefaultOfficeRoles...)
+	suite.NoError(err)
+	testdatagen.GrantRoles(suite.TestDB(), *officeUser.UserID, models.RoleNameAuditor)
+	req := httptest.NewRequest("PATCH", path, nil)
+	req = suite.AuthenticateOfficeRequest(req, officeUser)
+
+	// Then: they can't edit the service member's move
+	rr := httptest.NewRecorder()
+	api.ServeHTTP(rr, req)
+	suite.Equal(http.StatusForbidden, rr.Code)
+
+	// Given: the service member who owns the move, who has no roles
+	req = httptest.NewRequest("PATCH", path, nil)
+	req = suite.AuthenticateRequest(req, move.Orders.ServiceMember)
+
+	// Then: they aren't stopped before the handler
+	rr = httptest.NewRecorder()
+	api.ServeHTTP(rr, req)
+	suite.NotEqual(http.StatusForbidden, rr.Code)
+}
@@ -215,6 +215,9 @@ type PatchPersonallyProcuredMoveHandler struct {
 // Handle is the handler
 func (h PatchPersonallyProcuredMoveHandler) Handle(params ppmop.PatchPersonallyProcuredMoveParams) middleware.Responder {
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
+	if !handlers.CanActForServiceMember(session, auth.PermissionEditMoves) {
+		return ppmop.NewPatchPersonallyProcuredMoveForbidden()
+	}
 
 	// #nosec UUID is pattern matched by swagger and will be ok
 	moveID, _ := uuid.FromString(params.MoveID.String())
@@ -235,15 +238,20 @@ func (h PatchPersonallyProcuredMoveHandler) Handle(params ppmop.PatchPersonallyP
 
 	patchPPMWithPayload(ppm, params.PatchPersonallyProcuredMovePayload)
 
+	var snapshot *models.PPMIncentiveSnapshot
 	if needsEstimatesRecalculated {
-		err = h.updateEstimates(ppm)
+		snapshot, err = h.updateEstimates(ppm)
 		if err != nil {
 			h.Logger().Error("Unable to set calculated fields on PPM", zap.Error(err))
 			return handlers.ResponseForError(h.Logger(), err)
 		}
 	}
 
-	verrs, err := models.SavePersonallyProcuredMove(h.DB(), ppm)
+	// Keep a record of how the new estimates were calculated
+	if snapshot != nil {
+		snapshot.RequestedByUserID = session.UserID
+	}
+	verrs, err := models.SavePersonallyProcuredMoveWithSnapshot(h.DB(), ppm, snapshot)
 	if err != nil || verrs.HasAny() {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
 	}
@@ -333,16 +341,16 @@ func dateForComparison(previousValue, newValue *time.Time) (value time.Time, val
 	return value, false, false
 }
 
-func (h PatchPersonallyProcuredMoveHandler) updateEstimates(ppm *models.PersonallyProcuredMove) error {
+func (h PatchPersonallyProcuredMoveHandler) updateEstimates(ppm *models.PersonallyProcuredMove) (*models.PPMIncentiveSnapshot, error) {
 	re := rateengine.NewRateEngine(h.DB(), h.Logger(), h.Planner())
 	daysInSIT := 0
 	if ppm.HasSit != nil && *ppm.HasSit && ppm.DaysInStorage != nil {
 		daysInSIT = int(*ppm.DaysInStorage)
 	}
 
-	lhDiscount, sitDiscount, err := PPMDiscountFetch(h.DB(), h.Logger(), *ppm.PickupPostalCode, *ppm.DestinationPostalCode, *ppm.PlannedMoveDate)
+	snapshot, err := estimatePPMIncentive(h, unit.Pound(*ppm.WeightEstimate), *ppm.PickupPostalCode, *ppm.DestinationPostalCode, *ppm.PlannedMoveDate, daysInSIT)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Update SIT estimate
@@ -351,28 +359,25 @@ func (h PatchPersonallyProcuredMoveHandler) updateEstimates(ppm *models.Personal
 		sitZip3 := rateengine.Zip5ToZip3(*ppm.DestinationPostalCode)
 		sitTotal, err := re.SitCharge(cwtWeight, daysInSIT, sitZip3, *ppm.PlannedMoveDate, true)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		sitCharge := float64(sitDiscount.Apply(sitTotal))
+		sitCharge := float64(snapshot.SITDiscount.Apply(sitTotal))
 		reimbursementString := fmt.Sprintf("$%.2f", sitCharge/100)
 		ppm.EstimatedStorageReimbursement = &reimbursementString
 	}
 
-	cost, err := re.ComputePPM(unit.Pound(*ppm.WeightEstimate), *ppm.PickupPostalCode, *ppm.DestinationPostalCode, *ppm.PlannedMoveDate, daysInSIT, lhDiscount, sitDiscount)
-	if err != nil {
-		return err
-	}
-
-	mileage := int64(cost.LinehaulCostComputation.Mileage)
+	mileage := int64(snapshot.Mileage)
 	ppm.Mileage = &mileage
-	ppm.PlannedSITMax = &cost.SITFee
-	ppm.SITMax = &cost.SITMax
-	min := cost.GCC.MultiplyFloat64(0.95)
-	max := cost.GCC.MultiplyFloat64(1.05)
+	ppm.PlannedSITMax = &snapshot.SITFee
+	ppm.SITMax = &snapshot.SITMax
+	min := snapshot.GCC.MultiplyFloat64(0.95)
+	max := snapshot.GCC.MultiplyFloat64(1.05)
 	ppm.IncentiveEstimateMin = &min
 	ppm.IncentiveEstimateMax = &max
 
-	return nil
+	snapshot.PersonallyProcuredMoveID = ppm.ID
+	snapshot.Source = models.PPMIncentiveSnapshotSourceESTIMATE
+	return &snapshot, nil
 }
 
 // RequestPPMPaymentHandler requests a payment for a PPM
@@ -392,12 +397,15 @@ func (h RequestPPMPaymentHandler) Handle(params ppmop.RequestPPMPaymentParams) m
 		return handlers.ResponseForError(h.Logger(), err)
 	}
 
+	oldStatus := ppm.Status
 	err = ppm.RequestPayment()
 	if err != nil {
 		return handlers.ResponseForError(h.Logger(), err)
 	}
 
-	verrs, err := models.SavePersonallyProcuredMove(h.DB(), ppm)
+	event := models.NewAuditEvent(session, models.AuditEntityTypePPM, ppm.ID, &ppm.MoveID, "request_payment").
+		Change("status", oldStatus, ppm.Status)
+	verrs, err := models.SavePersonallyProcuredMove(h.DB(), ppm, event)
 	if err != nil || verrs.HasAny() {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
 	}
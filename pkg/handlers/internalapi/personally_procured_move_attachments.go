@@ -3,6 +3,7 @@ package internalapi
 import (
 	"github.com/go-openapi/runtime/middleware"
 	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
 	"github.com/transcom/mymove/pkg/auth"
@@ -35,36 +36,21 @@ func (h CreatePersonallyProcuredMoveAttachmentsHandler) Handle(params ppmop.Crea
 		return handlers.ResponseForError(h.Logger(), err)
 	}
 
-	moveDocs, err := ppm.FetchMoveDocumentsForTypes(h.DB(), params.DocTypes)
-	if err != nil {
-		return ppmop.NewCreatePPMAttachmentsInternalServerError()
-	}
-	if len(moveDocs) == 0 {
-		return ppmop.NewCreatePPMAttachmentsFailedDependency()
-	}
-
 	// Init our tools
-	loader := uploader.NewUploader(h.DB(), h.Logger(), h.FileStorer())
+	loader := uploader.NewUploader(h.DB(), h.Logger(), h.FileStorer(), h.FileScanner())
 	generator, err := paperwork.NewGenerator(h.DB(), h.Logger(), loader)
 	if err != nil {
 		h.Logger().Error("failed to initialize generator", zap.Error(err))
 		return ppmop.NewCreatePPMAttachmentsInternalServerError()
 	}
-
-	// Start with uploaded orders info
-	uploads := ppm.Move.Orders.UploadedOrders.Uploads
-
-	// Flatten out uploads into a slice
-	for _, moveDoc := range moveDocs {
-		uploads = append(uploads, moveDoc.Document.Uploads...)
-	}
-	if len(uploads) == 0 {
-		return ppmop.NewCreatePPMAttachmentsFailedDependency()
-	}
+	defer generator.Cleanup()
 
 	// Convert to PDF and merge into single PDF
-	mergedPdf, err := generator.CreateMergedPDFUpload(uploads)
+	mergedPdf, err := paperwork.GeneratePPMAttachments(generator, *ppm, params.DocTypes)
 	if err != nil {
+		if errors.Cause(err) == paperwork.ErrNoAttachments {
+			return ppmop.NewCreatePPMAttachmentsFailedDependency()
+		}
 		h.Logger().Error("failed to merge PDF files", zap.Error(err))
 		return ppmop.NewCreatePPMAttachmentsUnprocessableEntity()
 	}
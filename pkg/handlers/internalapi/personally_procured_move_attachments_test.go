@@ -12,6 +12,7 @@ import (
 	ppmop "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations/ppm"
 	"github.com/transcom/mymove/pkg/handlers"
 	"github.com/transcom/mymove/pkg/models"
+	scannerTest "github.com/transcom/mymove/pkg/scanner/test"
 	"github.com/transcom/mymove/pkg/storage"
 	storageTest "github.com/transcom/mymove/pkg/storage/test"
 	"github.com/transcom/mymove/pkg/testdatagen"
@@ -35,6 +36,7 @@ func (suite *HandlerSuite) createHandlerContext() handlers.HandlerContext {
 	context := handlers.NewHandlerContext(suite.TestDB(), suite.TestLogger())
 	fakeS3 := storageTest.NewFakeS3Storage(true)
 	context.SetFileStorer(fakeS3)
+	context.SetFileScanner(scannerTest.NewFakeScanner())
 
 	return context
 }
@@ -71,7 +73,7 @@ func (suite *HandlerSuite) TestCreatePPMAttachmentsHandler() {
 	suite.NoError(err)
 
 	// Create upload for expense document model
-	loader := uploader.NewUploader(suite.TestDB(), suite.TestLogger(), context.FileStorer())
+	loader := uploader.NewUploader(suite.TestDB(), suite.TestLogger(), context.FileStorer(), context.FileScanner())
 	loader.CreateUpload(&expDoc.MoveDocument.DocumentID, *officeUser.UserID, f)
 
 	request := httptest.NewRequest("POST", "/fake/path", nil)
@@ -0,0 +1,110 @@
+package internalapi
+
+import (
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/auth"
+	officeop "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations/office"
+	"github.com/transcom/mymove/pkg/handlers"
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/paperwork"
+	"github.com/transcom/mymove/pkg/unit"
+	"github.com/transcom/mymove/pkg/uploader"
+)
+
+// CreatePPMCloseoutPacketHandler creates a PPM closeout packet via POST /personally_procured_moves/{personallyProcuredMoveId}/closeout_packet
+type CreatePPMCloseoutPacketHandler struct {
+	handlers.HandlerContext
+}
+
+// Handle builds the closeout packet for a PPM that has requested payment and saves it as one of
+// the PPM's move documents
+func (h CreatePPMCloseoutPacketHandler) Handle(params officeop.CreatePPMCloseoutPacketParams) middleware.Responder {
+	session := auth.SessionFromRequestContext(params.HTTPRequest)
+	if !session.Can(auth.PermissionApprovePPMs) {
+		return officeop.NewCreatePPMCloseoutPacketForbidden()
+	}
+
+	// #nosec UUID is pattern matched by swagger and will be ok
+	ppmID, _ := uuid.FromString(params.PersonallyProcuredMoveID.String())
+
+	ppm, err := models.FetchPersonallyProcuredMove(h.DB(), session, ppmID)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+	if ppm.Status != models.PPMStatusPAYMENTREQUESTED && ppm.Status != models.PPMStatusCOMPLETED {
+		return officeop.NewCreatePPMCloseoutPacketConflict()
+	}
+
+	incentive, err := ppmIncentive(h, session, *ppm)
+	if err != nil {
+		h.Logger().Error("failed to calculate PPM incentive", zap.Error(err), zap.String("ppm_id", ppmID.String()))
+		return officeop.NewCreatePPMCloseoutPacketUnprocessableEntity()
+	}
+
+	loader := uploader.NewUploader(h.DB(), h.Logger(), h.FileStorer(), h.FileScanner())
+	generator, err := paperwork.NewGenerator(h.DB(), h.Logger(), loader)
+	if err != nil {
+		h.Logger().Error("failed to initialize generator", zap.Error(err))
+		return officeop.NewCreatePPMCloseoutPacketInternalServerError()
+	}
+	defer generator.Cleanup()
+
+	packet, err := paperwork.GeneratePPMCloseoutPacket(generator, *ppm, incentive)
+	if err != nil {
+		if errors.Cause(err) == models.ErrUploadQuarantined {
+			return handlers.ResponseForError(h.Logger(), err)
+		}
+		h.Logger().Error("failed to generate PPM closeout packet", zap.Error(err))
+		return officeop.NewCreatePPMCloseoutPacketUnprocessableEntity()
+	}
+
+	moveDoc, verrs, err := paperwork.SavePPMCloseoutPacket(h.DB(), loader, *ppm, session.UserID, packet)
+	if verrs.HasAny() || err != nil {
+		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
+	}
+	packetUpload := &moveDoc.Document.Uploads[0]
+
+	url, err := loader.PresignedURL(packetUpload)
+	if err != nil {
+		h.Logger().Error("failed to get presigned url", zap.Error(err))
+		return officeop.NewCreatePPMCloseoutPacketInternalServerError()
+	}
+
+	return officeop.NewCreatePPMCloseoutPacketOK().WithPayload(payloadForUploadModel(*packetUpload, url))
+}
+
+// ppmIncentive is 95% of the GCC for the PPM's weight and route, without SIT, which is
+// what the service member is paid for doing the move themselves. The calculation is saved
+// as a snapshot on the PPM, so the amount paid can be explained later.
+func ppmIncentive(h handlers.HandlerContext, session *auth.Session, ppm models.PersonallyProcuredMove) (unit.Cents, error) {
+	if ppm.WeightEstimate == nil || ppm.PickupPostalCode == nil || ppm.DestinationPostalCode == nil || ppm.PlannedMoveDate == nil {
+		return 0, errors.New("PPM is missing the weight, route or move date needed to calculate its incentive")
+	}
+
+	snapshot, err := estimatePPMIncentive(h,
+		unit.Pound(*ppm.WeightEstimate),
+		*ppm.PickupPostalCode,
+		*ppm.DestinationPostalCode,
+		*ppm.PlannedMoveDate,
+		0, // The incentive doesn't include SIT
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	snapshot.PersonallyProcuredMoveID = ppm.ID
+	snapshot.RequestedByUserID = session.UserID
+	snapshot.Source = models.PPMIncentiveSnapshotSourceCLOSEOUT
+	verrs, err := h.DB().ValidateAndCreate(&snapshot)
+	if err != nil {
+		return 0, err
+	}
+	if verrs.HasAny() {
+		return 0, errors.New(verrs.Error())
+	}
+	return snapshot.Incentive, nil
+}
@@ -0,0 +1,118 @@
+package internalapi
+
+import (
+	"net/http/httptest"
+	"os"
+	"regexp"
+
+	"github.com/spf13/afero"
+
+	officeop "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations/office"
+	"github.com/transcom/mymove/pkg/handlers"
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/route"
+	"github.com/transcom/mymove/pkg/testdatagen"
+	"github.com/transcom/mymove/pkg/testdatagen/scenario"
+	"github.com/transcom/mymove/pkg/uploader"
+)
+
+func (suite *HandlerSuite) TestCreatePPMCloseoutPacketHandlerForbidden() {
+	ppm := testdatagen.MakeDefaultPPM(suite.TestDB())
+
+	request := httptest.NewRequest("POST", "/fake/path", nil)
+	request = suite.AuthenticateRequest(request, ppm.Move.Orders.ServiceMember)
+	params := officeop.CreatePPMCloseoutPacketParams{
+		PersonallyProcuredMoveID: *handlers.FmtUUID(ppm.ID),
+		HTTPRequest:              request,
+	}
+
+	handler := CreatePPMCloseoutPacketHandler{suite.createHandlerContext()}
+	response := handler.Handle(params)
+	suite.Assertions.IsType(&officeop.CreatePPMCloseoutPacketForbidden{}, response)
+}
+
+func (suite *HandlerSuite) TestCreatePPMCloseoutPacketHandlerBeforePaymentRequested() {
+	officeUser := testdatagen.MakeDefaultOfficeUser(suite.TestDB())
+	ppm := testdatagen.MakePPM(suite.TestDB(), testdatagen.Assertions{
+		PersonallyProcuredMove: models.PersonallyProcuredMove{
+			Status: models.PPMStatusAPPROVED,
+		},
+	})
+
+	request := httptest.NewRequest("POST", "/fake/path", nil)
+	request = suite.AuthenticateOfficeRequest(request, officeUser)
+	params := officeop.CreatePPMCloseoutPacketParams{
+		PersonallyProcuredMoveID: *handlers.FmtUUID(ppm.ID),
+		HTTPRequest:              request,
+	}
+
+	handler := CreatePPMCloseoutPacketHandler{suite.createHandlerContext()}
+	response := handler.Handle(params)
+	suite.Assertions.IsType(&officeop.CreatePPMCloseoutPacketConflict{}, response)
+}
+
+func (suite *HandlerSuite) TestCreatePPMCloseoutPacketHandler() {
+	if err := scenario.RunRateEngineScenario2(suite.TestDB()); err != nil {
+		suite.FailNow("failed to run scenario 2: %+v", err)
+	}
+	uploadKeyRe := regexp.MustCompile(`(user/.+/uploads/.+)\?`)
+
+	officeUser := testdatagen.MakeDefaultOfficeUser(suite.TestDB())
+	ppm := testdatagen.MakePPM(suite.TestDB(), testdatagen.Assertions{
+		PersonallyProcuredMove: models.PersonallyProcuredMove{
+			Status:                models.PPMStatusPAYMENTREQUESTED,
+			WeightEstimate:        models.Int64Pointer(7500),
+			PlannedMoveDate:       models.TimePointer(scenario.Oct1_2018),
+			PickupPostalCode:      models.StringPointer("94540"),
+			DestinationPostalCode: models.StringPointer("78626"),
+		},
+	})
+	expDoc := testdatagen.MakeMovingExpenseDocument(suite.TestDB(), testdatagen.Assertions{
+		MoveDocument: models.MoveDocument{
+			PersonallyProcuredMoveID: &ppm.ID,
+			Status:                   models.MoveDocumentStatusOK,
+			MoveDocumentType:         models.MoveDocumentTypeEXPENSE,
+		},
+	})
+
+	context := suite.createHandlerContext()
+	context.SetPlanner(route.NewTestingPlanner(1693))
+
+	// Attach a receipt to the expense document
+	f, err := os.Open("../fixtures/test.pdf")
+	suite.NoError(err)
+	loader := uploader.NewUploader(suite.TestDB(), suite.TestLogger(), context.FileStorer(), context.FileScanner())
+	_, verrs, err := loader.CreateUpload(&expDoc.MoveDocument.DocumentID, *officeUser.UserID, f)
+	suite.NoError(err)
+	suite.False(verrs.HasAny())
+
+	request := httptest.NewRequest("POST", "/fake/path", nil)
+	request = suite.AuthenticateOfficeRequest(request, officeUser)
+	params := officeop.CreatePPMCloseoutPacketParams{
+		PersonallyProcuredMoveID: *handlers.FmtUUID(ppm.ID),
+		HTTPRequest:              request,
+	}
+
+	handler := CreatePPMCloseoutPacketHandler{context}
+	response := handler.Handle(params)
+	suite.IsNotErrResponse(response)
+	okResponse := response.(*officeop.CreatePPMCloseoutPacketOK)
+	suite.NotNil(okResponse.Payload.URL)
+
+	// The packet is the summary page followed by the receipt
+	uploadKey := uploadKeyRe.FindStringSubmatch(string(*okResponse.Payload.URL))[1]
+	packet, err := context.FileStorer().Fetch(uploadKey)
+	suite.NoError(err)
+	suite.assertPDFPageCount(2, packet.(afero.File), context.FileStorer())
+
+	// The packet is saved as one of the PPM's move documents
+	var moveDocs models.MoveDocuments
+	err = suite.TestDB().Eager("Document.Uploads").
+		Where("personally_procured_move_id = ?", ppm.ID).
+		Where("title = ?", "PPM Closeout Packet").
+		All(&moveDocs)
+	suite.NoError(err)
+	if suite.Len(moveDocs, 1) && suite.Len(moveDocs[0].Document.Uploads, 1) {
+		suite.Equal(moveDocs[0].Document.Uploads[0].ID.String(), okResponse.Payload.ID.String())
+	}
+}
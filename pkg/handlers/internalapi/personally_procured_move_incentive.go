@@ -5,15 +5,114 @@ import (
 
 	"github.com/go-openapi/runtime/middleware"
 	"github.com/go-openapi/swag"
+	"github.com/gofrs/uuid"
 
 	"github.com/transcom/mymove/pkg/auth"
+	officeop "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations/office"
 	ppmop "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations/ppm"
 	"github.com/transcom/mymove/pkg/gen/internalmessages"
 	"github.com/transcom/mymove/pkg/handlers"
+	"github.com/transcom/mymove/pkg/models"
 	"github.com/transcom/mymove/pkg/rateengine"
 	"github.com/transcom/mymove/pkg/unit"
 )
 
+// estimatePPMIncentive prices a PPM and returns the result as an unsaved snapshot, which
+// records every rate input the calculation was based on
+func estimatePPMIncentive(h handlers.HandlerContext, weight unit.Pound, originZip string, destinationZip string, date time.Time, daysInSIT int) (models.PPMIncentiveSnapshot, error) {
+	lhDiscount, sitDiscount, cos, err := ppmDiscountFetchWithCOS(h.DB(), h.Logger(), originZip, destinationZip, date)
+	if err != nil {
+		return models.PPMIncentiveSnapshot{}, err
+	}
+
+	engine := rateengine.NewRateEngine(h.DB(), h.Logger(), h.Planner())
+	cost, err := engine.ComputePPM(weight, originZip, destinationZip, date, daysInSIT, lhDiscount, sitDiscount)
+	if err != nil {
+		return models.PPMIncentiveSnapshot{}, err
+	}
+
+	return models.PPMIncentiveSnapshot{
+		Weight:                    cost.Inputs.Weight,
+		ProrateFactor:             cost.Inputs.ProrateFactor,
+		OriginZip:                 cost.Inputs.OriginZip5,
+		DestinationZip:            cost.Inputs.DestinationZip5,
+		OriginZip3:                cost.Inputs.OriginZip3,
+		DestinationZip3:           cost.Inputs.DestinationZip3,
+		OriginServiceArea:         cost.Inputs.OriginServiceArea,
+		DestinationServiceArea:    cost.Inputs.DestinationServiceArea,
+		PlannedMoveDate:           cost.Inputs.Date,
+		DaysInSIT:                 cost.Inputs.DaysInSIT,
+		DiscountCodeOfService:     cos,
+		LinehaulDiscount:          cost.Inputs.LinehaulDiscount,
+		SITDiscount:               cost.Inputs.SITDiscount,
+		Mileage:                   cost.Mileage,
+		BaseLinehaul:              cost.BaseLinehaul,
+		OriginLinehaulFactor:      cost.OriginLinehaulFactor,
+		DestinationLinehaulFactor: cost.DestinationLinehaulFactor,
+		ShorthaulCharge:           cost.ShorthaulCharge,
+		LinehaulChargeTotal:       cost.LinehaulChargeTotal,
+		OriginServiceFee:          cost.OriginServiceFee,
+		DestinationServiceFee:     cost.DestinationServiceFee,
+		PackFee:                   cost.PackFee,
+		UnpackFee:                 cost.UnpackFee,
+		SITFee:                    cost.SITFee,
+		SITMax:                    cost.SITMax,
+		GCC:                       cost.GCC,
+		IncentiveRate:             models.PPMIncentiveRate,
+		Incentive:                 cost.GCC.MultiplyFloat64(models.PPMIncentiveRate),
+	}, nil
+}
+
+func payloadForPPMIncentiveInputs(snapshot models.PPMIncentiveSnapshot) *internalmessages.PPMIncentiveInputs {
+	return &internalmessages.PPMIncentiveInputs{
+		Weight:                 swag.Int64(int64(snapshot.Weight)),
+		ProrateFactor:          swag.Float64(snapshot.ProrateFactor),
+		OriginZip:              swag.String(snapshot.OriginZip),
+		DestinationZip:         swag.String(snapshot.DestinationZip),
+		OriginZip3:             swag.String(snapshot.OriginZip3),
+		DestinationZip3:        swag.String(snapshot.DestinationZip3),
+		OriginServiceArea:      swag.String(snapshot.OriginServiceArea),
+		DestinationServiceArea: swag.String(snapshot.DestinationServiceArea),
+		PlannedMoveDate:        handlers.FmtDate(snapshot.PlannedMoveDate),
+		DaysInSit:              swag.Int64(int64(snapshot.DaysInSIT)),
+		DiscountCodeOfService:  swag.String(snapshot.DiscountCodeOfService),
+		LinehaulDiscount:       swag.Float64(snapshot.LinehaulDiscount.Float64()),
+		SitDiscount:            swag.Float64(snapshot.SITDiscount.Float64()),
+		Mileage:                swag.Int64(int64(snapshot.Mileage)),
+	}
+}
+
+func payloadForPPMCostBreakdown(snapshot models.PPMIncentiveSnapshot) *internalmessages.PPMCostBreakdown {
+	return &internalmessages.PPMCostBreakdown{
+		BaseLinehaul:              swag.Int64(snapshot.BaseLinehaul.Int64()),
+		OriginLinehaulFactor:      swag.Int64(snapshot.OriginLinehaulFactor.Int64()),
+		DestinationLinehaulFactor: swag.Int64(snapshot.DestinationLinehaulFactor.Int64()),
+		ShorthaulCharge:           swag.Int64(snapshot.ShorthaulCharge.Int64()),
+		LinehaulChargeTotal:       swag.Int64(snapshot.LinehaulChargeTotal.Int64()),
+		OriginServiceFee:          swag.Int64(snapshot.OriginServiceFee.Int64()),
+		DestinationServiceFee:     swag.Int64(snapshot.DestinationServiceFee.Int64()),
+		PackFee:                   swag.Int64(snapshot.PackFee.Int64()),
+		UnpackFee:                 swag.Int64(snapshot.UnpackFee.Int64()),
+		SitFee:                    swag.Int64(snapshot.SITFee.Int64()),
+		SitMax:                    swag.Int64(snapshot.SITMax.Int64()),
+		Gcc:                       swag.Int64(snapshot.GCC.Int64()),
+	}
+}
+
+func payloadForPPMIncentiveSnapshotModel(snapshot models.PPMIncentiveSnapshot) *internalmessages.PPMIncentiveSnapshotPayload {
+	return &internalmessages.PPMIncentiveSnapshotPayload{
+		ID:                       handlers.FmtUUID(snapshot.ID),
+		PersonallyProcuredMoveID: handlers.FmtUUID(snapshot.PersonallyProcuredMoveID),
+		RequestedByUserID:        handlers.FmtUUID(snapshot.RequestedByUserID),
+		Source:                   internalmessages.PPMIncentiveSnapshotSource(snapshot.Source),
+		Inputs:                   payloadForPPMIncentiveInputs(snapshot),
+		Breakdown:                payloadForPPMCostBreakdown(snapshot),
+		IncentiveRate:            swag.Float64(snapshot.IncentiveRate),
+		Incentive:                swag.Int64(snapshot.Incentive.Int64()),
+		CreatedAt:                handlers.FmtDateTime(snapshot.CreatedAt),
+	}
+}
+
 // ShowPPMIncentiveHandler returns PPM SIT estimate for a weight, move date,
 type ShowPPMIncentiveHandler struct {
 	handlers.HandlerContext
@@ -23,40 +122,75 @@ type ShowPPMIncentiveHandler struct {
 func (h ShowPPMIncentiveHandler) Handle(params ppmop.ShowPPMIncentiveParams) middleware.Responder {
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
 
-	if !session.IsOfficeUser() {
+	if !session.Can(auth.PermissionViewMoves) {
 		return ppmop.NewShowPPMIncentiveForbidden()
 	}
-	engine := rateengine.NewRateEngine(h.DB(), h.Logger(), h.Planner())
 
-	lhDiscount, _, err := PPMDiscountFetch(h.DB(),
-		h.Logger(),
+	snapshot, err := estimatePPMIncentive(h,
+		unit.Pound(params.Weight),
 		params.OriginZip,
 		params.DestinationZip,
 		time.Time(params.PlannedMoveDate),
+		0, // We don't want any SIT charges
 	)
 	if err != nil {
 		return handlers.ResponseForError(h.Logger(), err)
 	}
 
-	cost, err := engine.ComputePPM(unit.Pound(params.Weight),
-		params.OriginZip,
-		params.DestinationZip,
-		time.Time(params.PlannedMoveDate),
-		0, // We don't want any SIT charges
-		lhDiscount,
-		0.0,
-	)
+	ppmObligation := internalmessages.PPMIncentive{
+		Gcc:                 swag.Int64(snapshot.GCC.Int64()),
+		IncentivePercentage: swag.Int64(snapshot.Incentive.Int64()),
+		IncentiveRate:       swag.Float64(snapshot.IncentiveRate),
+		Inputs:              payloadForPPMIncentiveInputs(snapshot),
+		Breakdown:           payloadForPPMCostBreakdown(snapshot),
+	}
+
+	// Save the calculation on the PPM it was made for, so it can be explained later
+	if params.PersonallyProcuredMoveID != nil {
+		// #nosec UUID is pattern matched by swagger and will be ok
+		ppmID, _ := uuid.FromString(params.PersonallyProcuredMoveID.String())
+		ppm, err := models.FetchPersonallyProcuredMove(h.DB(), session, ppmID)
+		if err != nil {
+			return handlers.ResponseForError(h.Logger(), err)
+		}
+
+		snapshot.PersonallyProcuredMoveID = ppm.ID
+		snapshot.RequestedByUserID = session.UserID
+		snapshot.Source = models.PPMIncentiveSnapshotSourceOFFICE
+		verrs, err := h.DB().ValidateAndCreate(&snapshot)
+		if err != nil || verrs.HasAny() {
+			return handlers.ResponseForVErrors(h.Logger(), verrs, err)
+		}
+		ppmObligation.SnapshotID = handlers.FmtUUID(snapshot.ID)
+	}
+
+	return ppmop.NewShowPPMIncentiveOK().WithPayload(&ppmObligation)
+}
 
+// IndexPPMIncentiveSnapshotsHandler returns the incentive snapshots saved for a PPM
+type IndexPPMIncentiveSnapshotsHandler struct {
+	handlers.HandlerContext
+}
+
+// Handle returns every incentive calculated for a PPM, newest first
+func (h IndexPPMIncentiveSnapshotsHandler) Handle(params officeop.IndexPPMIncentiveSnapshotsParams) middleware.Responder {
+	session := auth.SessionFromRequestContext(params.HTTPRequest)
+
+	if !session.Can(auth.PermissionViewMoves) {
+		return officeop.NewIndexPPMIncentiveSnapshotsForbidden()
+	}
+
+	// #nosec UUID is pattern matched by swagger and will be ok
+	ppmID, _ := uuid.FromString(params.PersonallyProcuredMoveID.String())
+
+	snapshots, err := models.FetchPPMIncentiveSnapshots(h.DB(), session, ppmID)
 	if err != nil {
 		return handlers.ResponseForError(h.Logger(), err)
 	}
 
-	gcc := cost.GCC
-	incentivePercentage := cost.GCC.MultiplyFloat64(0.95)
-
-	ppmObligation := internalmessages.PPMIncentive{
-		Gcc:                 swag.Int64(gcc.Int64()),
-		IncentivePercentage: swag.Int64(incentivePercentage.Int64()),
+	snapshotPayloads := make(internalmessages.IndexPPMIncentiveSnapshotsPayload, len(snapshots))
+	for i, snapshot := range snapshots {
+		snapshotPayloads[i] = payloadForPPMIncentiveSnapshotModel(snapshot)
 	}
-	return ppmop.NewShowPPMIncentiveOK().WithPayload(&ppmObligation)
+	return officeop.NewIndexPPMIncentiveSnapshotsOK().WithPayload(snapshotPayloads)
 }
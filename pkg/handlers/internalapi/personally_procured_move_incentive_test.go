@@ -3,7 +3,9 @@ package internalapi
 import (
 	"net/http/httptest"
 
+	officeop "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations/office"
 	ppmop "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations/ppm"
+	"github.com/transcom/mymove/pkg/gen/internalmessages"
 	"github.com/transcom/mymove/pkg/handlers"
 	"github.com/transcom/mymove/pkg/route"
 	"github.com/transcom/mymove/pkg/testdatagen"
@@ -63,6 +65,23 @@ func (suite *HandlerSuite) TestShowPPMIncentiveHandler() {
 
 	suite.Equal(int64(637056), *cost.Gcc, "Gcc was not equal")
 	suite.Equal(int64(605203), *cost.IncentivePercentage, "IncentivePercentage was not equal")
+
+	// The rate inputs and charges behind the GCC are returned with it
+	suite.Equal(0.95, *cost.IncentiveRate)
+	suite.Equal(int64(637056), *cost.Breakdown.Gcc)
+	suite.Equal(*cost.Breakdown.Gcc,
+		*cost.Breakdown.LinehaulChargeTotal+
+			*cost.Breakdown.OriginServiceFee+
+			*cost.Breakdown.DestinationServiceFee+
+			*cost.Breakdown.PackFee+
+			*cost.Breakdown.UnpackFee)
+	suite.Equal("2", *cost.Inputs.DiscountCodeOfService)
+	suite.Equal(0.67, *cost.Inputs.LinehaulDiscount)
+	suite.Equal(int64(1693), *cost.Inputs.Mileage)
+	suite.Equal("80", *cost.Inputs.OriginServiceArea)
+	suite.Equal("744", *cost.Inputs.DestinationServiceArea)
+	suite.Equal(1.0, *cost.Inputs.ProrateFactor)
+	suite.Nil(cost.SnapshotID)
 }
 func (suite *HandlerSuite) TestShowPPMIncentiveHandlerLowWeight() {
 	if err := scenario.RunRateEngineScenario2(suite.TestDB()); err != nil {
@@ -92,4 +111,59 @@ func (suite *HandlerSuite) TestShowPPMIncentiveHandlerLowWeight() {
 
 	suite.Equal(int64(270252), *cost.Gcc, "Gcc was not equal")
 	suite.Equal(int64(256739), *cost.IncentivePercentage, "IncentivePercentage was not equal")
+	suite.Equal(int64(600), *cost.Inputs.Weight)
+	suite.Equal(0.6, *cost.Inputs.ProrateFactor)
+}
+
+func (suite *HandlerSuite) TestShowPPMIncentiveHandlerSavesSnapshot() {
+	if err := scenario.RunRateEngineScenario2(suite.TestDB()); err != nil {
+		suite.FailNow("failed to run scenario 2: %+v", err)
+	}
+
+	ppm := testdatagen.MakeDefaultPPM(suite.TestDB())
+	officeUser := testdatagen.MakeDefaultOfficeUser(suite.TestDB())
+
+	req := httptest.NewRequest("GET", "/personally_procured_moves/incentive", nil)
+	req = suite.AuthenticateOfficeRequest(req, officeUser)
+
+	params := ppmop.ShowPPMIncentiveParams{
+		HTTPRequest:              req,
+		PlannedMoveDate:          *handlers.FmtDate(scenario.Oct1_2018),
+		OriginZip:                "94540",
+		DestinationZip:           "78626",
+		Weight:                   7500,
+		PersonallyProcuredMoveID: handlers.FmtUUID(ppm.ID),
+	}
+
+	context := handlers.NewHandlerContext(suite.TestDB(), suite.TestLogger())
+	context.SetPlanner(route.NewTestingPlanner(1693))
+	showResponse := ShowPPMIncentiveHandler{context}.Handle(params)
+	suite.Assertions.IsType(&ppmop.ShowPPMIncentiveOK{}, showResponse)
+	cost := showResponse.(*ppmop.ShowPPMIncentiveOK).Payload
+	suite.NotNil(cost.SnapshotID)
+
+	// The office can see what the member was quoted, and why
+	req = httptest.NewRequest("GET", "/personally_procured_moves/id/incentive_snapshots", nil)
+	req = suite.AuthenticateOfficeRequest(req, officeUser)
+	indexResponse := IndexPPMIncentiveSnapshotsHandler{context}.Handle(officeop.IndexPPMIncentiveSnapshotsParams{
+		HTTPRequest:              req,
+		PersonallyProcuredMoveID: *handlers.FmtUUID(ppm.ID),
+	})
+	suite.Assertions.IsType(&officeop.IndexPPMIncentiveSnapshotsOK{}, indexResponse)
+	snapshots := indexResponse.(*officeop.IndexPPMIncentiveSnapshotsOK).Payload
+	suite.Len(snapshots, 1)
+	suite.Equal(*cost.SnapshotID, *snapshots[0].ID)
+	suite.Equal(internalmessages.PPMIncentiveSnapshotSourceOFFICE, snapshots[0].Source)
+	suite.Equal(*cost.IncentivePercentage, *snapshots[0].Incentive)
+	suite.Equal(*cost.Breakdown, *snapshots[0].Breakdown)
+	suite.Equal(*cost.Inputs.Mileage, *snapshots[0].Inputs.Mileage)
+
+	// Service members can't list the snapshots
+	req = httptest.NewRequest("GET", "/personally_procured_moves/id/incentive_snapshots", nil)
+	req = suite.AuthenticateRequest(req, ppm.Move.Orders.ServiceMember)
+	indexResponse = IndexPPMIncentiveSnapshotsHandler{context}.Handle(officeop.IndexPPMIncentiveSnapshotsParams{
+		HTTPRequest:              req,
+		PersonallyProcuredMoveID: *handlers.FmtUUID(ppm.ID),
+	})
+	suite.CheckResponseForbidden(indexResponse)
 }
@@ -15,6 +15,7 @@ import (
 	"github.com/transcom/mymove/pkg/route"
 	"github.com/transcom/mymove/pkg/testdatagen"
 	"github.com/transcom/mymove/pkg/testdatagen/scenario"
+	"github.com/transcom/mymove/pkg/unit"
 )
 
 func (suite *HandlerSuite) TestCreatePPMHandler() {
@@ -285,6 +286,15 @@ func (suite *HandlerSuite) TestPatchPPMHandlerSetWeightLater() {
 
 	suite.Assertions.Equal("$32.60", *patchPPMPayload.EstimatedStorageReimbursement)
 	suite.Assertions.Equal(int64(3260), *patchPPMPayload.PlannedSitMax)
+
+	// Each recalculation is kept as a snapshot
+	var snapshots models.PPMIncentiveSnapshots
+	suite.NoError(suite.TestDB().Where("personally_procured_move_id = ?", ppm1.ID).Order("created_at").All(&snapshots))
+	suite.Len(snapshots, 2)
+	suite.Equal(models.PPMIncentiveSnapshotSourceESTIMATE, snapshots[1].Source)
+	suite.Equal(move.Orders.ServiceMember.UserID, snapshots[1].RequestedByUserID)
+	suite.Equal(3, snapshots[1].DaysInSIT)
+	suite.Equal(unit.Cents(3260), snapshots[1].SITFee)
 }
 
 func (suite *HandlerSuite) TestPatchPPMHandlerWrongUser() {
@@ -0,0 +1,123 @@
+package internalapi
+
+import (
+	"time"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/gofrs/uuid"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/auth"
+	ppmop "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations/ppm"
+	shipmentop "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations/shipments"
+	"github.com/transcom/mymove/pkg/gen/internalmessages"
+	"github.com/transcom/mymove/pkg/handlers"
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/rateengine"
+	"github.com/transcom/mymove/pkg/unit"
+)
+
+func payloadForRateEngineTrace(gcc unit.Cents, trace *rateengine.Trace) *internalmessages.RateEngineTracePayload {
+	steps := make([]*internalmessages.RateEngineTraceStep, len(trace.Steps))
+	for i, step := range trace.Steps {
+		steps[i] = &internalmessages.RateEngineTraceStep{
+			Charge:      swag.String(step.Charge),
+			Type:        swag.String(string(step.Type)),
+			Description: swag.String(step.Description),
+			Table:       step.Table,
+			Values:      step.Values,
+			Result:      step.Result,
+		}
+		if step.RowID != nil {
+			steps[i].RowID = handlers.FmtUUID(*step.RowID)
+		}
+	}
+	return &internalmessages.RateEngineTracePayload{
+		Gcc:   swag.Int64(gcc.Int64()),
+		Steps: steps,
+	}
+}
+
+// ShowPPMIncentiveTraceHandler explains how a PPM incentive is calculated
+type ShowPPMIncentiveTraceHandler struct {
+	handlers.HandlerContext
+}
+
+// Handle computes the GCC for a PPM and returns the trace of every lookup and calculation
+func (h ShowPPMIncentiveTraceHandler) Handle(params ppmop.ShowPPMIncentiveTraceParams) middleware.Responder {
+	session := auth.SessionFromRequestContext(params.HTTPRequest)
+
+	if !session.Can(auth.PermissionViewMoves) {
+		return ppmop.NewShowPPMIncentiveTraceForbidden()
+	}
+
+	daysInSIT := 0
+	if params.DaysInStorage != nil {
+		daysInSIT = int(*params.DaysInStorage)
+	}
+
+	lhDiscount, sitDiscount, err := PPMDiscountFetch(h.DB(),
+		h.Logger(),
+		params.OriginZip,
+		params.DestinationZip,
+		time.Time(params.PlannedMoveDate),
+	)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+
+	engine := rateengine.NewRateEngine(h.DB(), h.Logger(), h.Planner())
+	trace := engine.EnableTrace()
+	cost, err := engine.ComputePPM(unit.Pound(params.Weight),
+		params.OriginZip,
+		params.DestinationZip,
+		time.Time(params.PlannedMoveDate),
+		daysInSIT,
+		lhDiscount,
+		sitDiscount,
+	)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+
+	return ppmop.NewShowPPMIncentiveTraceOK().WithPayload(payloadForRateEngineTrace(cost.GCC, trace))
+}
+
+// ShowShipmentRateTraceHandler explains how a shipment's charges are calculated
+type ShowShipmentRateTraceHandler struct {
+	handlers.HandlerContext
+}
+
+// Handle runs the rate engine on a shipment and returns the trace of every lookup and calculation
+func (h ShowShipmentRateTraceHandler) Handle(params shipmentop.ShowShipmentRateTraceParams) middleware.Responder {
+	session := auth.SessionFromRequestContext(params.HTTPRequest)
+	if !session.Can(auth.PermissionViewMoves) {
+		return shipmentop.NewShowShipmentRateTraceForbidden()
+	}
+
+	// #nosec UUID is pattern matched by swagger and will be ok
+	shipmentID, _ := uuid.FromString(params.ShipmentID.String())
+
+	var shipment models.Shipment
+	err := h.DB().Eager(
+		"PickupAddress",
+		"SecondaryPickupAddress",
+		"PartialSITDeliveryAddress",
+		"Move.Orders.NewDutyStation.Address",
+		"ShipmentOffers.TransportationServiceProviderPerformance",
+	).Find(&shipment, shipmentID)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+
+	engine := rateengine.NewRateEngine(h.DB(), h.Logger(), h.Planner())
+	trace := engine.EnableTrace()
+	shipmentCost, err := engine.HandleRunOnShipment(shipment)
+	if err != nil {
+		h.Logger().Info("Unable to rate shipment", zap.Error(err), zap.String("shipment_id", shipmentID.String()))
+		return shipmentop.NewShowShipmentRateTraceUnprocessableEntity()
+	}
+
+	return shipmentop.NewShowShipmentRateTraceOK().WithPayload(payloadForRateEngineTrace(shipmentCost.Cost.GCC, trace))
+}
@@ -0,0 +1,100 @@
+package internalapi
+
+import (
+	"net/http/httptest"
+
+	"github.com/go-openapi/strfmt"
+
+	ppmop "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations/ppm"
+	shipmentop "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations/shipments"
+	"github.com/transcom/mymove/pkg/handlers"
+	"github.com/transcom/mymove/pkg/route"
+	"github.com/transcom/mymove/pkg/testdatagen"
+	"github.com/transcom/mymove/pkg/testdatagen/scenario"
+)
+
+func (suite *HandlerSuite) TestShowPPMIncentiveTraceHandler() {
+	if err := scenario.RunRateEngineScenario2(suite.TestDB()); err != nil {
+		suite.FailNow("failed to run scenario 2: %+v", err)
+	}
+
+	officeUser := testdatagen.MakeDefaultOfficeUser(suite.TestDB())
+
+	req := httptest.NewRequest("GET", "/personally_procured_moves/incentive/trace", nil)
+	req = suite.AuthenticateOfficeRequest(req, officeUser)
+
+	params := ppmop.ShowPPMIncentiveTraceParams{
+		HTTPRequest:     req,
+		PlannedMoveDate: *handlers.FmtDate(scenario.Oct1_2018),
+		OriginZip:       "94540",
+		DestinationZip:  "78626",
+		Weight:          7500,
+	}
+
+	context := handlers.NewHandlerContext(suite.TestDB(), suite.TestLogger())
+	context.SetPlanner(route.NewTestingPlanner(1693))
+	response := ShowPPMIncentiveTraceHandler{context}.Handle(params)
+	suite.Assertions.IsType(&ppmop.ShowPPMIncentiveTraceOK{}, response)
+	payload := response.(*ppmop.ShowPPMIncentiveTraceOK).Payload
+
+	suite.Equal(int64(637056), *payload.Gcc)
+	suite.NotEmpty(payload.Steps)
+	last := payload.Steps[len(payload.Steps)-1]
+	suite.Equal("GCC", *last.Charge)
+
+	foundLinehaulRow := false
+	for _, step := range payload.Steps {
+		if *step.Charge == "BaseLinehaul" {
+			suite.Equal("tariff400ng_linehaul_rates", step.Table)
+			suite.NotNil(step.RowID)
+			foundLinehaulRow = true
+		}
+	}
+	suite.True(foundLinehaulRow, "the base linehaul lookup was not traced")
+}
+
+func (suite *HandlerSuite) TestShowPPMIncentiveTraceHandlerForbidden() {
+	sm := testdatagen.MakeDefaultServiceMember(suite.TestDB())
+
+	req := httptest.NewRequest("GET", "/personally_procured_moves/incentive/trace", nil)
+	req = suite.AuthenticateRequest(req, sm)
+
+	params := ppmop.ShowPPMIncentiveTraceParams{
+		HTTPRequest:     req,
+		PlannedMoveDate: *handlers.FmtDate(scenario.Oct1_2018),
+		OriginZip:       "94540",
+		DestinationZip:  "78626",
+		Weight:          7500,
+	}
+
+	context := handlers.NewHandlerContext(suite.TestDB(), suite.TestLogger())
+	context.SetPlanner(route.NewTestingPlanner(1693))
+	response := ShowPPMIncentiveTraceHandler{context}.Handle(params)
+	suite.Assertions.IsType(&ppmop.ShowPPMIncentiveTraceForbidden{}, response)
+}
+
+func (suite *HandlerSuite) TestShowShipmentRateTraceHandler() {
+	officeUser := testdatagen.MakeDefaultOfficeUser(suite.TestDB())
+	shipment := testdatagen.MakeDefaultShipment(suite.TestDB())
+
+	context := handlers.NewHandlerContext(suite.TestDB(), suite.TestLogger())
+	context.SetPlanner(route.NewTestingPlanner(1693))
+
+	// Service members can't see how shipments are rated
+	req := httptest.NewRequest("GET", "/shipments/shipment_id/rate_trace", nil)
+	req = suite.AuthenticateRequest(req, shipment.ServiceMember)
+	response := ShowShipmentRateTraceHandler{context}.Handle(shipmentop.ShowShipmentRateTraceParams{
+		HTTPRequest: req,
+		ShipmentID:  strfmt.UUID(shipment.ID.String()),
+	})
+	suite.Assertions.IsType(&shipmentop.ShowShipmentRateTraceForbidden{}, response)
+
+	// A shipment that hasn't been awarded can't be rated
+	req = httptest.NewRequest("GET", "/shipments/shipment_id/rate_trace", nil)
+	req = suite.AuthenticateOfficeRequest(req, officeUser)
+	response = ShowShipmentRateTraceHandler{context}.Handle(shipmentop.ShowShipmentRateTraceParams{
+		HTTPRequest: req,
+		ShipmentID:  strfmt.UUID(shipment.ID.String()),
+	})
+	suite.Assertions.IsType(&shipmentop.ShowShipmentRateTraceUnprocessableEntity{}, response)
+}
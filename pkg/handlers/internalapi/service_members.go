@@ -5,6 +5,7 @@ import (
 	"github.com/gobuffalo/validate"
 	"github.com/gofrs/uuid"
 
+	"github.com/transcom/mymove/pkg/addressverifier"
 	"github.com/transcom/mymove/pkg/auth"
 	servicememberop "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations/service_members"
 	"github.com/transcom/mymove/pkg/gen/internalmessages"
@@ -119,6 +120,11 @@ func (h CreateServiceMemberHandler) Handle(params servicememberop.CreateServiceM
 		DutyStation:            station,
 		DutyStationID:          stationID,
 	}
+	addressVerrs, err := verifyServiceMemberAddresses(h.AddressVerifier(), &newServiceMember)
+	verrs.Append(addressVerrs)
+	if verrs.HasAny() || err != nil {
+		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
+	}
 	smVerrs, err := models.SaveServiceMember(h.DB(), &newServiceMember)
 	verrs.Append(smVerrs)
 	if verrs.HasAny() || err != nil {
@@ -138,7 +144,7 @@ func (h CreateServiceMemberHandler) Handle(params servicememberop.CreateServiceM
 	// And return
 	serviceMemberPayload := payloadForServiceMemberModel(h.FileStorer(), newServiceMember)
 	responder := servicememberop.NewCreateServiceMemberCreated().WithPayload(serviceMemberPayload)
-	return handlers.NewCookieUpdateResponder(params.HTTPRequest, h.CookieSecret(), h.NoSessionTimeout(), h.Logger(), responder)
+	return handlers.NewCookieUpdateResponder(params.HTTPRequest, h.SessionManager(), h.Logger(), responder)
 }
 
 // ShowServiceMemberHandler returns a serviceMember for a user and service member ID
@@ -160,6 +166,19 @@ func (h ShowServiceMemberHandler) Handle(params servicememberop.ShowServiceMembe
 	return servicememberop.NewShowServiceMemberOK().WithPayload(serviceMemberPayload)
 }
 
+// serviceMemberAddresses names the addresses where a service member lives and gets mail
+func serviceMemberAddresses(serviceMember *models.ServiceMember) map[string]*models.Address {
+	return map[string]*models.Address{
+		"residential_address":    serviceMember.ResidentialAddress,
+		"backup_mailing_address": serviceMember.BackupMailingAddress,
+	}
+}
+
+// verifyServiceMemberAddresses verifies the addresses where a service member lives and gets mail
+func verifyServiceMemberAddresses(verifier addressverifier.Verifier, serviceMember *models.ServiceMember) (*validate.Errors, error) {
+	return addressverifier.VerifyAll(verifier, serviceMemberAddresses(serviceMember))
+}
+
 // PatchServiceMemberHandler patches a serviceMember via PATCH /serviceMembers/{serviceMemberId}
 type PatchServiceMemberHandler struct {
 	handlers.HandlerContext
@@ -176,9 +195,14 @@ func (h PatchServiceMemberHandler) Handle(params servicememberop.PatchServiceMem
 	}
 
 	payload := params.PatchServiceMemberPayload
+	previousAddresses := addressverifier.CopyAddresses(serviceMemberAddresses(&serviceMember))
 	if verrs, err := h.patchServiceMemberWithPayload(&serviceMember, payload); verrs.HasAny() || err != nil {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
 	}
+	// Only the addresses the patch changed are verified again
+	if verrs, err := addressverifier.VerifyChanged(h.AddressVerifier(), previousAddresses, serviceMemberAddresses(&serviceMember)); verrs.HasAny() || err != nil {
+		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
+	}
 	if verrs, err := models.SaveServiceMember(h.DB(), &serviceMember); verrs.HasAny() || err != nil {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
 	}
@@ -2,6 +2,7 @@ package internalapi
 
 import (
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/go-openapi/runtime/middleware"
@@ -9,6 +10,7 @@ import (
 	"github.com/gofrs/uuid"
 	"go.uber.org/zap"
 
+	"github.com/transcom/mymove/pkg/addressverifier"
 	"github.com/transcom/mymove/pkg/auth"
 	"github.com/transcom/mymove/pkg/edi/gex"
 	"github.com/transcom/mymove/pkg/edi/invoice"
@@ -16,7 +18,9 @@ import (
 	"github.com/transcom/mymove/pkg/gen/internalmessages"
 	"github.com/transcom/mymove/pkg/handlers"
 	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/paperwork"
 	"github.com/transcom/mymove/pkg/rateengine"
+	uploaderpkg "github.com/transcom/mymove/pkg/uploader"
 )
 
 func payloadForShipmentModel(s models.Shipment) (*internalmessages.Shipment, error) {
@@ -114,6 +118,9 @@ type CreateShipmentHandler struct {
 // Handle is the handler
 func (h CreateShipmentHandler) Handle(params shipmentop.CreateShipmentParams) middleware.Responder {
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
+	if !handlers.CanActForServiceMember(session, auth.PermissionEditShipments) {
+		return shipmentop.NewCreateShipmentForbidden()
+	}
 	// #nosec UUID is pattern matched by swagger and will be ok
 	moveID, _ := uuid.FromString(params.MoveID.String())
 
@@ -175,7 +182,12 @@ func (h CreateShipmentHandler) Handle(params shipmentop.CreateShipmentParams) mi
 		return handlers.ResponseForError(h.Logger(), err)
 	}
 
-	verrs, err := models.SaveShipmentAndAddresses(h.DB(), &newShipment)
+	verrs, err := addressverifier.VerifyShipmentAddresses(h.AddressVerifier(), &newShipment)
+	if err != nil || verrs.HasAny() {
+		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
+	}
+
+	verrs, err = models.SaveShipmentAndAddresses(h.DB(), &newShipment)
 
 	if err != nil || verrs.HasAny() {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
@@ -297,6 +309,9 @@ type PatchShipmentHandler struct {
 // Handle is the handler
 func (h PatchShipmentHandler) Handle(params shipmentop.PatchShipmentParams) middleware.Responder {
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
+	if !handlers.CanActForServiceMember(session, auth.PermissionEditShipments) {
+		return shipmentop.NewPatchShipmentForbidden()
+	}
 
 	// #nosec UUID is pattern matched by swagger and will be ok
 	shipmentID, _ := uuid.FromString(params.ShipmentID.String())
@@ -306,17 +321,23 @@ func (h PatchShipmentHandler) Handle(params shipmentop.PatchShipmentParams) midd
 		return handlers.ResponseForError(h.Logger(), err)
 	}
 
+	previousAddresses := addressverifier.CopyAddresses(addressverifier.ShipmentAddresses(shipment))
 	patchShipmentWithPayload(shipment, params.Shipment)
 	if err = updateShipmentDatesWithPayload(h, shipment, params.Shipment); err != nil {
 		return handlers.ResponseForError(h.Logger(), err)
 	}
 
-	// Premove survey info can only be edited by office users or TSPs
-	if session.IsOfficeUser() {
+	// Premove survey info can only be edited by users who can edit shipments
+	if session.Can(auth.PermissionEditShipments) {
 		patchShipmentWithPremoveSurveyFields(shipment, params.Shipment)
 	}
 
-	verrs, err := models.SaveShipmentAndAddresses(h.DB(), shipment)
+	verrs, err := addressverifier.VerifyChanged(h.AddressVerifier(), previousAddresses, addressverifier.ShipmentAddresses(shipment))
+	if err != nil || verrs.HasAny() {
+		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
+	}
+
+	verrs, err = models.SaveShipmentAndAddresses(h.DB(), shipment)
 
 	if err != nil || verrs.HasAny() {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
@@ -389,7 +410,7 @@ type ApproveHHGHandler struct {
 // Handle is the handler
 func (h ApproveHHGHandler) Handle(params shipmentop.ApproveHHGParams) middleware.Responder {
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
-	if !session.IsOfficeUser() {
+	if !session.Can(auth.PermissionApproveHHGs) {
 		return shipmentop.NewApproveHHGForbidden()
 	}
 
@@ -400,12 +421,15 @@ func (h ApproveHHGHandler) Handle(params shipmentop.ApproveHHGParams) middleware
 	if err != nil {
 		return handlers.ResponseForError(h.Logger(), err)
 	}
+	oldStatus := shipment.Status
 	err = shipment.Approve()
 	if err != nil {
 		h.Logger().Error("Attempted to approve HHG, got invalid transition", zap.Error(err), zap.String("shipment_status", string(shipment.Status)))
 		return handlers.ResponseForError(h.Logger(), err)
 	}
-	verrs, err := h.DB().ValidateAndUpdate(shipment)
+	event := models.NewAuditEvent(session, models.AuditEntityTypeSHIPMENT, shipment.ID, &shipment.MoveID, "approve").
+		Change("status", oldStatus, shipment.Status)
+	verrs, err := models.SaveWithAuditEvents(h.DB(), shipment, event)
 	if err != nil || verrs.HasAny() {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
 	}
@@ -426,7 +450,7 @@ type CompleteHHGHandler struct {
 // Handle is the handler
 func (h CompleteHHGHandler) Handle(params shipmentop.CompleteHHGParams) middleware.Responder {
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
-	if !session.IsOfficeUser() {
+	if !session.Can(auth.PermissionApproveHHGs) {
 		return shipmentop.NewCompleteHHGForbidden()
 	}
 
@@ -436,12 +460,15 @@ func (h CompleteHHGHandler) Handle(params shipmentop.CompleteHHGParams) middlewa
 	if err != nil {
 		return handlers.ResponseForError(h.Logger(), err)
 	}
+	oldStatus := shipment.Status
 	err = shipment.Complete()
 	if err != nil {
 		h.Logger().Error("Attempted to complete HHG, got invalid transition", zap.Error(err), zap.String("shipment_status", string(shipment.Status)))
 		return handlers.ResponseForError(h.Logger(), err)
 	}
-	verrs, err := h.DB().ValidateAndUpdate(shipment)
+	event := models.NewAuditEvent(session, models.AuditEntityTypeSHIPMENT, shipment.ID, &shipment.MoveID, "complete").
+		Change("status", oldStatus, shipment.Status)
+	verrs, err := models.SaveWithAuditEvents(h.DB(), shipment, event)
 	if err != nil || verrs.HasAny() {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
 	}
@@ -454,6 +481,63 @@ func (h CompleteHHGHandler) Handle(params shipmentop.CompleteHHGParams) middlewa
 	return shipmentop.NewCompleteHHGOK().WithPayload(shipmentPayload)
 }
 
+// CreateGovBillOfLadingHandler creates or regenerates the GBL for a shipment on behalf of an office user
+type CreateGovBillOfLadingHandler struct {
+	handlers.HandlerContext
+}
+
+// Handle generates the GBL PDF with the office user as issuing officer, and stores it as the shipment's GBL move document
+func (h CreateGovBillOfLadingHandler) Handle(params shipmentop.CreateGovBillOfLadingParams) middleware.Responder {
+	session := auth.SessionFromRequestContext(params.HTTPRequest)
+	if !session.Can(auth.PermissionApproveHHGs) {
+		return shipmentop.NewCreateGovBillOfLadingForbidden()
+	}
+
+	// #nosec UUID is pattern matched by swagger and will be ok
+	shipmentID, _ := uuid.FromString(params.ShipmentID.String())
+	shipment, err := models.FetchShipment(h.DB(), session, shipmentID)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+	officeUser, err := models.FetchOfficeUserByID(h.DB(), session.OfficeUserID)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+
+	orders, err := models.FetchOrder(h.DB(), shipment.Move.OrdersID)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+	if !orders.IsCompleteForGBL() {
+		return handlers.ResponseForCustomErrors(h.Logger(), fmt.Errorf("the orders are missing information needed for the GBL"), http.StatusExpectationFailed)
+	}
+
+	gbl, err := models.FetchGovBillOfLadingExtractor(h.DB(), shipmentID)
+	if err != nil {
+		h.Logger().Error("Failed retrieving the GBL data.", zap.Error(err))
+		return shipmentop.NewCreateGovBillOfLadingExpectationFailed()
+	}
+	gbl.SetIssuingOfficer(*officeUser)
+
+	gblFile, err := paperwork.GenerateGBL(h.DB(), h.Logger(), h.Planner(), h.FileStorer().FileSystem(), shipmentID, gbl)
+	if err != nil {
+		h.Logger().Error("Failure generating GBL form.", zap.Error(err))
+		return shipmentop.NewCreateGovBillOfLadingInternalServerError()
+	}
+
+	uploader := uploaderpkg.NewUploader(h.DB(), h.Logger(), h.FileStorer(), h.FileScanner())
+	moveDoc, verrs, err := paperwork.SaveGBL(h.DB(), uploader, shipment.Move, shipmentID, session.UserID, gblFile)
+	if err != nil || verrs.HasAny() {
+		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
+	}
+
+	moveDocPayload, err := payloadForMoveDocument(h.FileStorer(), *moveDoc)
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+	return shipmentop.NewCreateGovBillOfLadingCreated().WithPayload(moveDocPayload)
+}
+
 // ShipmentInvoiceHandler sends an invoice through GEX to Syncada
 type ShipmentInvoiceHandler struct {
 	handlers.HandlerContext
@@ -462,7 +546,7 @@ type ShipmentInvoiceHandler struct {
 // Handle is the handler
 func (h ShipmentInvoiceHandler) Handle(params shipmentop.SendHHGInvoiceParams) middleware.Responder {
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
-	if !session.IsOfficeUser() {
+	if !session.Can(auth.PermissionApproveHHGs) {
 		return shipmentop.NewSendHHGInvoiceForbidden()
 	}
 
@@ -473,6 +557,8 @@ func (h ShipmentInvoiceHandler) Handle(params shipmentop.SendHHGInvoiceParams) m
 
 	err := h.DB().Eager(
 		"PickupAddress",
+		"SecondaryPickupAddress",
+		"PartialSITDeliveryAddress",
 		"Move.Orders.NewDutyStation.Address",
 		"ServiceMember",
 		"ShipmentOffers.TransportationServiceProviderPerformance",
@@ -482,6 +568,12 @@ func (h ShipmentInvoiceHandler) Handle(params shipmentop.SendHHGInvoiceParams) m
 		return handlers.ResponseForError(h.Logger(), err)
 	}
 
+	// Invoicing fixes the tariff version, so a later re-run gives the invoiced numbers
+	err = shipment.MarkRated(h.DB(), time.Now())
+	if err != nil {
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+
 	engine := rateengine.NewRateEngine(h.DB(), h.Logger(), h.Planner())
 	// Run rate engine on shipment --> returns CostByShipment Struct
 	shipmentCost, err := engine.HandleRunOnShipment(shipment)
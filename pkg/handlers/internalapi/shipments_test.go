@@ -12,7 +12,10 @@ import (
 	"github.com/transcom/mymove/pkg/handlers"
 	"github.com/transcom/mymove/pkg/models"
 	"github.com/transcom/mymove/pkg/route"
+	scannerTest "github.com/transcom/mymove/pkg/scanner/test"
+	storageTest "github.com/transcom/mymove/pkg/storage/test"
 	"github.com/transcom/mymove/pkg/testdatagen"
+	"github.com/transcom/mymove/pkg/testdatagen/scenario"
 )
 
 func (suite *HandlerSuite) verifyAddressFields(expected, actual *internalmessages.Address) {
@@ -339,6 +342,47 @@ func (suite *HandlerSuite) TestCompleteHHGHandler() {
 	suite.Equal("COMPLETED", string(okResponse.Payload.Status))
 }
 
+func (suite *HandlerSuite) TestCreateGovBillOfLadingHandler() {
+	// Given: an office user and a shipment that is ready for a GBL
+	officeUser := testdatagen.MakeDefaultOfficeUser(suite.TestDB())
+	tspUser := testdatagen.MakeDefaultTspUser(suite.TestDB())
+	shipment := scenario.MakeHhgFromAwardedToAcceptedGBLReady(suite.TestDB(), tspUser)
+
+	context := handlers.NewHandlerContext(suite.TestDB(), suite.TestLogger())
+	context.SetFileStorer(storageTest.NewFakeS3Storage(true))
+	context.SetFileScanner(scannerTest.NewFakeScanner())
+	handler := CreateGovBillOfLadingHandler{context}
+
+	path := "/shipments/shipment_id/gov_bill_of_lading"
+	req := httptest.NewRequest("POST", path, nil)
+
+	// When: a service member tries to generate the GBL
+	params := shipmentop.CreateGovBillOfLadingParams{
+		HTTPRequest: suite.AuthenticateRequest(req, shipment.ServiceMember),
+		ShipmentID:  strfmt.UUID(shipment.ID.String()),
+	}
+	response := handler.Handle(params)
+
+	// Then: they are forbidden
+	suite.Assertions.IsType(&shipmentop.CreateGovBillOfLadingForbidden{}, response)
+
+	// When: the office user generates the GBL, then regenerates it
+	params.HTTPRequest = suite.AuthenticateOfficeRequest(req, officeUser)
+	response = handler.Handle(params)
+	suite.Assertions.IsType(&shipmentop.CreateGovBillOfLadingCreated{}, response)
+	response = handler.Handle(params)
+	suite.Assertions.IsType(&shipmentop.CreateGovBillOfLadingCreated{}, response)
+
+	// Then: the shipment has a single GBL with the latest upload
+	created := response.(*shipmentop.CreateGovBillOfLadingCreated)
+	suite.Equal(string(models.MoveDocumentTypeGOVBILLOFLADING), string(created.Payload.MoveDocumentType))
+	suite.Len(created.Payload.Document.Uploads, 1)
+
+	var gbls models.MoveDocuments
+	suite.NoError(suite.TestDB().Where("shipment_id = ?", shipment.ID).All(&gbls))
+	suite.Len(gbls, 1)
+}
+
 //func (suite *HandlerSuite) TestShipmentInvoiceHandler() {
 //	// Given: an office User
 //	officeUser := testdatagen.MakeDefaultOfficeUser(suite.TestDB())
@@ -77,7 +77,8 @@ func (h CreateUploadHandler) Handle(params uploadop.CreateUploadParams) middlewa
 		return uploadop.NewCreateUploadInternalServerError()
 	}
 
-	uploader := uploaderpkg.NewUploader(h.DB(), h.Logger(), h.FileStorer())
+	uploader := uploaderpkg.NewUploader(h.DB(), h.Logger(), h.FileStorer(), h.FileScanner())
+	uploader.SetImageConverter(h.ImageConverter())
 	newUpload, verrs, err := uploader.CreateUpload(docID, session.UserID, aFile)
 	if err != nil || verrs.HasAny() {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
@@ -107,7 +108,7 @@ func (h DeleteUploadHandler) Handle(params uploadop.DeleteUploadParams) middlewa
 		return handlers.ResponseForError(h.Logger(), err)
 	}
 
-	uploader := uploaderpkg.NewUploader(h.DB(), h.Logger(), h.FileStorer())
+	uploader := uploaderpkg.NewUploader(h.DB(), h.Logger(), h.FileStorer(), h.FileScanner())
 	if err = uploader.DeleteUpload(&upload); err != nil {
 		return handlers.ResponseForError(h.Logger(), err)
 	}
@@ -124,7 +125,7 @@ type DeleteUploadsHandler struct {
 func (h DeleteUploadsHandler) Handle(params uploadop.DeleteUploadsParams) middleware.Responder {
 	// User should always be populated by middleware
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
-	uploader := uploaderpkg.NewUploader(h.DB(), h.Logger(), h.FileStorer())
+	uploader := uploaderpkg.NewUploader(h.DB(), h.Logger(), h.FileStorer(), h.FileScanner())
 
 	for _, uploadID := range params.UploadIds {
 		uuid, _ := uuid.FromString(uploadID.String())
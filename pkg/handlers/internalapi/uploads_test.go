@@ -10,6 +10,7 @@ import (
 	uploadop "github.com/transcom/mymove/pkg/gen/internalapi/internaloperations/uploads"
 	"github.com/transcom/mymove/pkg/handlers"
 	"github.com/transcom/mymove/pkg/models"
+	scannerTest "github.com/transcom/mymove/pkg/scanner/test"
 	storageTest "github.com/transcom/mymove/pkg/storage/test"
 	"github.com/transcom/mymove/pkg/testdatagen"
 )
@@ -32,6 +33,7 @@ func makeRequest(suite *HandlerSuite, params uploadop.CreateUploadParams, servic
 
 	context := handlers.NewHandlerContext(suite.TestDB(), suite.TestLogger())
 	context.SetFileStorer(fakeS3)
+	context.SetFileScanner(scannerTest.NewFakeScanner())
 	handler := CreateUploadHandler{context}
 	response := handler.Handle(params)
 
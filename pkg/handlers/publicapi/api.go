@@ -6,11 +6,64 @@ import (
 
 	"github.com/go-openapi/loads"
 
+	"github.com/transcom/mymove/pkg/auth"
 	"github.com/transcom/mymove/pkg/gen/restapi"
 	publicops "github.com/transcom/mymove/pkg/gen/restapi/apioperations"
 	"github.com/transcom/mymove/pkg/handlers"
 )
 
+// permissionRequirements are the permissions needed for each operation of the public API.
+// TSP users are further limited to the shipments awarded to their TSP. API clients can't use
+// operations that aren't listed, so every operation is.
+var permissionRequirements = handlers.PermissionRequirements{
+	"indexMoveDocuments":        {auth.PermissionViewShipments},
+	"createGenericMoveDocument": {auth.PermissionEditShipments},
+	"updateMoveDocument":        {auth.PermissionEditShipments},
+	"createUpload":              {auth.PermissionEditShipments},
+	"deleteUpload":              {auth.PermissionEditShipments},
+	"deleteUploads":             {auth.PermissionEditShipments},
+
+	"indexShipments":            {auth.PermissionViewShipments},
+	"getShipment":               {auth.PermissionViewShipments},
+	"getShipmentClaims":         {auth.PermissionViewShipments},
+	"getShipmentContactDetails": {auth.PermissionViewShipments},
+	"patchShipment":             {auth.PermissionEditShipments},
+	"acceptShipment":            {auth.PermissionDispatchShipments},
+	"rejectShipment":            {auth.PermissionDispatchShipments},
+	"transportShipment":         {auth.PermissionDispatchShipments},
+	"deliverShipment":           {auth.PermissionDispatchShipments},
+	"createGovBillOfLading":     {auth.PermissionDispatchShipments},
+
+	"getShipmentLineItems":    {auth.PermissionViewShipments},
+	"createShipmentLineItem":  {auth.PermissionEditShipments},
+	"updateShipmentLineItem":  {auth.PermissionEditShipments},
+	"deleteShipmentLineItem":  {auth.PermissionEditShipments},
+	"approveShipmentLineItem": {auth.PermissionApproveHHGs},
+
+	"indexStorageInTransits":           {auth.PermissionViewShipments},
+	"createStorageInTransit":           {auth.PermissionEditShipments},
+	"releaseStorageInTransit":          {auth.PermissionEditShipments},
+	"createStorageInTransitExtension":  {auth.PermissionEditShipments},
+	"approveStorageInTransitExtension": {auth.PermissionApproveHHGs},
+	"denyStorageInTransitExtension":    {auth.PermissionApproveHHGs},
+
+	"indexServiceAgents": {auth.PermissionViewShipments},
+	"createServiceAgent": {auth.PermissionManageServiceAgents},
+	"patchServiceAgent":  {auth.PermissionManageServiceAgents},
+	"deleteServiceAgent": {auth.PermissionManageServiceAgents},
+
+	"indexTSPs":       {auth.PermissionViewShipments},
+	"getTspShipments": {auth.PermissionViewShipments},
+	"getTspBlackouts": {auth.PermissionViewShipments},
+	"indexBlackouts":  {auth.PermissionViewShipments},
+	"getBlackout":     {auth.PermissionViewShipments},
+	"createBlackout":  {auth.PermissionDispatchShipments},
+	"patchBlackout":   {auth.PermissionDispatchShipments},
+	"deleteBlackout":  {auth.PermissionDispatchShipments},
+
+	"getTariff400ngItems": {auth.PermissionViewShipments},
+}
+
 // NewPublicAPIHandler returns a handler for the public API
 func NewPublicAPIHandler(context handlers.HandlerContext) http.Handler {
 
@@ -51,6 +104,14 @@ func NewPublicAPIHandler(context handlers.HandlerContext) http.Handler {
 
 	publicAPI.AccessorialsGetTariff400ngItemsHandler = GetTariff400ngItemsHandler{context}
 
+	// Storage in transit
+	publicAPI.StorageInTransitsIndexStorageInTransitsHandler = IndexStorageInTransitsHandler{context}
+	publicAPI.StorageInTransitsCreateStorageInTransitHandler = CreateStorageInTransitHandler{context}
+	publicAPI.StorageInTransitsReleaseStorageInTransitHandler = ReleaseStorageInTransitHandler{context}
+	publicAPI.StorageInTransitsCreateStorageInTransitExtensionHandler = CreateStorageInTransitExtensionHandler{context}
+	publicAPI.StorageInTransitsApproveStorageInTransitExtensionHandler = ApproveStorageInTransitExtensionHandler{context}
+	publicAPI.StorageInTransitsDenyStorageInTransitExtensionHandler = DenyStorageInTransitExtensionHandler{context}
+
 	// Service Agents
 	publicAPI.ServiceAgentsIndexServiceAgentsHandler = IndexServiceAgentsHandler{context}
 	publicAPI.ServiceAgentsCreateServiceAgentHandler = CreateServiceAgentHandler{context}
@@ -60,5 +121,5 @@ func NewPublicAPIHandler(context handlers.HandlerContext) http.Handler {
 	publicAPI.TspsIndexTSPsHandler = TspsIndexTSPsHandler{context}
 	publicAPI.TspsGetTspShipmentsHandler = TspsGetTspShipmentsHandler{context}
 
-	return publicAPI.Serve(nil)
+	return publicAPI.Serve(handlers.RequirePermissions(context.Logger(), permissionRequirements))
 }
@@ -15,8 +15,13 @@ import (
 )
 
 func payloadForDocumentModel(storer storage.FileStorer, document models.Document) (*apimessages.DocumentPayload, error) {
-	uploads := make([]*apimessages.UploadPayload, len(document.Uploads))
-	for i, upload := range document.Uploads {
+	uploads := make([]*apimessages.UploadPayload, 0, len(document.Uploads))
+	for _, upload := range document.Uploads {
+		// Quarantined uploads are never presigned
+		if upload.IsQuarantined() {
+			continue
+		}
+
 		url, err := storer.PresignedURL(upload.StorageKey, upload.ContentType)
 		if err != nil {
 			return nil, err
@@ -31,7 +36,7 @@ func payloadForDocumentModel(storer storage.FileStorer, document models.Document
 			CreatedAt:   handlers.FmtDateTime(upload.CreatedAt),
 			UpdatedAt:   handlers.FmtDateTime(upload.UpdatedAt),
 		}
-		uploads[i] = uploadPayload
+		uploads = append(uploads, uploadPayload)
 	}
 
 	documentPayload := &apimessages.DocumentPayload{
@@ -119,18 +119,21 @@ func (h UpdateMoveDocumentHandler) Handle(params movedocop.UpdateMoveDocumentPar
 	moveDoc.Notes = payload.Notes
 	moveDoc.MoveDocumentType = newType
 	newStatus := models.MoveDocumentStatus(payload.Status)
+	var events []*models.AuditEvent
 
 	// If this is a shipment summary and it has been approved, we process the shipment.
 	if newStatus != moveDoc.Status {
+		oldStatus := moveDoc.Status
 		err = moveDoc.AttemptTransition(newStatus)
 		if err != nil {
 			return handlers.ResponseForError(h.Logger(), err)
 		}
+		events = append(events, moveDoc.TransitionAuditEvent(session, oldStatus))
 	}
 
 	var saveAction models.MoveDocumentSaveAction
 
-	verrs, err := models.SaveMoveDocument(h.DB(), moveDoc, saveAction)
+	verrs, err := models.SaveMoveDocument(h.DB(), moveDoc, saveAction, events...)
 	if err != nil || verrs.HasAny() {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
 	}
@@ -0,0 +1,59 @@
+package publicapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/go-openapi/loads"
+	"github.com/go-openapi/spec"
+	"github.com/gofrs/uuid"
+
+	"github.com/transcom/mymove/pkg/auth"
+	"github.com/transcom/mymove/pkg/gen/restapi"
+	"github.com/transcom/mymove/pkg/handlers"
+	"github.com/transcom/mymove/pkg/testdatagen"
+)
+
+func (suite *HandlerSuite) TestPermissionRequirementsAreOperations() {
+	document, err := loads.Analyzed(restapi.SwaggerJSON, "")
+	suite.NoError(err)
+
+	operationIDs := map[string]bool{}
+	for _, path := range document.Spec().Paths.Paths {
+		for _, operation := range []*spec.Operation{path.Get, path.Put, path.Post, path.Delete, path.Patch} {
+			if operation != nil {
+				operationIDs[operation.ID] = true
+			}
+		}
+	}
+	for id := range permissionRequirements {
+		suite.True(operationIDs[id], "%s is not an operation of the public API", id)
+	}
+	// API clients can't use operations that aren't listed
+	for id := range operationIDs {
+		_, ok := permissionRequirements[id]
+		suite.True(ok, "%s has no permission requirements", id)
+	}
+}
+
+func (suite *HandlerSuite) TestAPIClientScopesLimitOperations() {
+	context := handlers.NewHandlerContext(suite.TestDB(), suite.TestLogger())
+	api := NewPublicAPIHandler(context)
+
+	// Given: an API client for a TSP user that is only scoped to view shipments
+	tspUser := testdatagen.MakeDefaultTspUser(suite.TestDB())
+	session := auth.Session{
+		ApplicationName: auth.TspApp,
+		UserID:          *tspUser.UserID,
+		TspUserID:       tspUser.ID,
+		APIClientID:     uuid.Must(uuid.NewV4()),
+		Permissions:     []auth.Permission{auth.PermissionViewShipments},
+	}
+	req := httptest.NewRequest("DELETE", "/api/v1/uploads?uploadIds="+uuid.Must(uuid.NewV4()).String(), nil)
+	req = req.WithContext(auth.SetSessionInRequestContext(req, &session))
+
+	// Then: it can't delete uploads
+	rr := httptest.NewRecorder()
+	api.ServeHTTP(rr, req)
+	suite.Equal(http.StatusForbidden, rr.Code)
+}
@@ -13,6 +13,7 @@ import (
 	accessorialop "github.com/transcom/mymove/pkg/gen/restapi/apioperations/accessorials"
 	"github.com/transcom/mymove/pkg/handlers"
 	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/notifications"
 	"github.com/transcom/mymove/pkg/unit"
 )
 
@@ -58,15 +59,9 @@ func (h GetShipmentLineItemsHandler) Handle(params accessorialop.GetShipmentLine
 
 	shipmentID := uuid.Must(uuid.FromString(params.ShipmentID.String()))
 
-	if session.IsTspUser() {
-		// Check that the TSP user can access the shipment
-		_, _, err := models.FetchShipmentForVerifiedTSPUser(h.DB(), session.TspUserID, shipmentID)
-		if err != nil {
-			h.Logger().Error("Error fetching shipment for TSP user", zap.Error(err))
-			return handlers.ResponseForError(h.Logger(), err)
-		}
-	} else if !session.IsOfficeUser() {
-		return accessorialop.NewGetShipmentLineItemsForbidden()
+	if _, err := fetchShipmentWithPermission(h.DB(), session, shipmentID, auth.PermissionViewShipments); err != nil {
+		h.Logger().Error("Error fetching shipment", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
 	}
 
 	shipmentLineItems, err := models.FetchLineItemsByShipmentID(h.DB(), &shipmentID)
@@ -88,26 +83,11 @@ func (h CreateShipmentLineItemHandler) Handle(params accessorialop.CreateShipmen
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
 
 	shipmentID := uuid.Must(uuid.FromString(params.ShipmentID.String()))
-	var shipment *models.Shipment
-	var err error
-	// If TSP user, verify TSP has shipment
-	// If office user, no verification necessary
-	// If myApp user, user is forbidden
-	if session.IsTspUser() {
-		// Check that the TSP user can access the shipment
-		_, shipment, err = models.FetchShipmentForVerifiedTSPUser(h.DB(), session.TspUserID, shipmentID)
-		if err != nil {
-			h.Logger().Error("Error fetching shipment for TSP user", zap.Error(err))
-			return handlers.ResponseForError(h.Logger(), err)
-		}
-	} else if session.IsOfficeUser() {
-		shipment, err = models.FetchShipment(h.DB(), session, shipmentID)
-		if err != nil {
-			h.Logger().Error("Error fetching shipment for office user", zap.Error(err))
-			return handlers.ResponseForError(h.Logger(), err)
-		}
-	} else {
-		return accessorialop.NewCreateShipmentLineItemForbidden()
+	// TSP users can only add line items to the shipments awarded to their TSP
+	shipment, err := fetchShipmentWithPermission(h.DB(), session, shipmentID, auth.PermissionEditShipments)
+	if err != nil {
+		h.Logger().Error("Error fetching shipment", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
 	}
 
 	tariff400ngItemID := uuid.Must(uuid.FromString(params.Payload.Tariff400ngItemID.String()))
@@ -153,21 +133,9 @@ func (h UpdateShipmentLineItemHandler) Handle(params accessorialop.UpdateShipmen
 	}
 
 	// authorization
-	if session.IsTspUser() {
-		// Check that the TSP user can access the shipment
-		_, _, err := models.FetchShipmentForVerifiedTSPUser(h.DB(), session.TspUserID, shipmentLineItem.ShipmentID)
-		if err != nil {
-			h.Logger().Error("Error fetching shipment for TSP user", zap.Error(err))
-			return handlers.ResponseForError(h.Logger(), err)
-		}
-	} else if session.IsOfficeUser() {
-		_, err := models.FetchShipment(h.DB(), session, shipmentLineItem.ShipmentID)
-		if err != nil {
-			h.Logger().Error("Error fetching shipment for office user", zap.Error(err))
-			return handlers.ResponseForError(h.Logger(), err)
-		}
-	} else {
-		return accessorialop.NewUpdateShipmentLineItemForbidden()
+	if _, err := fetchShipmentWithPermission(h.DB(), session, shipmentLineItem.ShipmentID, auth.PermissionEditShipments); err != nil {
+		h.Logger().Error("Error fetching shipment", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
 	}
 
 	tariff400ngItemID := uuid.Must(uuid.FromString(params.Payload.Tariff400ngItemID.String()))
@@ -229,21 +197,9 @@ func (h DeleteShipmentLineItemHandler) Handle(params accessorialop.DeleteShipmen
 	// authorization
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
 	shipmentID := uuid.Must(uuid.FromString(shipmentLineItem.ShipmentID.String()))
-	if session.IsTspUser() {
-		// Check that the TSP user can access the shipment
-		_, _, err := models.FetchShipmentForVerifiedTSPUser(h.DB(), session.TspUserID, shipmentID)
-		if err != nil {
-			h.Logger().Error("Error fetching shipment for TSP user", zap.Error(err))
-			return handlers.ResponseForError(h.Logger(), err)
-		}
-	} else if session.IsOfficeUser() {
-		_, err := models.FetchShipment(h.DB(), session, shipmentID)
-		if err != nil {
-			h.Logger().Error("Error fetching shipment for office user", zap.Error(err))
-			return handlers.ResponseForError(h.Logger(), err)
-		}
-	} else {
-		return accessorialop.NewDeleteShipmentLineItemForbidden()
+	if _, err := fetchShipmentWithPermission(h.DB(), session, shipmentID, auth.PermissionEditShipments); err != nil {
+		h.Logger().Error("Error fetching shipment", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
 	}
 
 	// Delete the shipment line item
@@ -253,6 +209,17 @@ func (h DeleteShipmentLineItemHandler) Handle(params accessorialop.DeleteShipmen
 		return handlers.ResponseForError(h.Logger(), err)
 	}
 
+	// An office user removing a request that is still awaiting approval is how it gets denied
+	if session.Can(auth.PermissionApproveHHGs) && shipmentLineItem.Status == models.ShipmentLineItemStatusSUBMITTED {
+		err = h.NotificationSender().SendNotification(
+			notifications.NewShipmentLineItemReviewed(h.DB(), h.Logger(), shipmentLineItem, false),
+		)
+		if err != nil {
+			h.Logger().Error("problem sending email to user", zap.Error(err))
+			return handlers.ResponseForError(h.Logger(), err)
+		}
+	}
+
 	payload := payloadForShipmentLineItemModel(&shipmentLineItem)
 	return accessorialop.NewDeleteShipmentLineItemOK().WithPayload(payload)
 }
@@ -275,9 +242,10 @@ func (h ApproveShipmentLineItemHandler) Handle(params accessorialop.ApproveShipm
 	}
 
 	// Non-accessorial line items shouldn't require approval
-	// Only office users can approve a shipment line item
-	if shipmentLineItem.Tariff400ngItem.RequiresPreApproval && session.IsOfficeUser() {
-		_, err := models.FetchShipment(h.DB(), session, shipmentLineItem.ShipmentID)
+	// Only HHG approvers can approve a shipment line item
+	var shipment *models.Shipment
+	if shipmentLineItem.Tariff400ngItem.RequiresPreApproval && session.Can(auth.PermissionApproveHHGs) {
+		shipment, err = models.FetchShipment(h.DB(), session, shipmentLineItem.ShipmentID)
 		if err != nil {
 			h.Logger().Error("Error fetching shipment for office user", zap.Error(err))
 			return handlers.ResponseForError(h.Logger(), err)
@@ -288,12 +256,26 @@ func (h ApproveShipmentLineItemHandler) Handle(params accessorialop.ApproveShipm
 	}
 
 	// Approve and save the shipment line item
+	oldStatus := shipmentLineItem.Status
 	err = shipmentLineItem.Approve()
 	if err != nil {
 		h.Logger().Error("Error approving shipment line item for shipment", zap.Error(err))
 		return accessorialop.NewApproveShipmentLineItemForbidden()
 	}
-	h.DB().ValidateAndUpdate(&shipmentLineItem)
+	event := models.NewAuditEvent(session, models.AuditEntityTypeSHIPMENTLINEITEM, shipmentLineItem.ID, &shipment.MoveID, "approve").
+		Change("status", oldStatus, shipmentLineItem.Status)
+	verrs, err := models.SaveWithAuditEvents(h.DB(), &shipmentLineItem, event)
+	if err != nil || verrs.HasAny() {
+		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
+	}
+
+	err = h.NotificationSender().SendNotification(
+		notifications.NewShipmentLineItemReviewed(h.DB(), h.Logger(), shipmentLineItem, true),
+	)
+	if err != nil {
+		h.Logger().Error("problem sending email to user", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
+	}
 
 	payload := payloadForShipmentLineItemModel(&shipmentLineItem)
 	return accessorialop.NewApproveShipmentLineItemOK().WithPayload(payload)
@@ -7,15 +7,16 @@ import (
 
 	"github.com/go-openapi/runtime/middleware"
 	"github.com/go-openapi/strfmt"
-	"github.com/go-openapi/swag"
+	"github.com/gobuffalo/pop"
 	"github.com/gofrs/uuid"
-	"github.com/transcom/mymove/pkg/assets"
+	"github.com/transcom/mymove/pkg/addressverifier"
 	"github.com/transcom/mymove/pkg/auth"
 	"github.com/transcom/mymove/pkg/awardqueue"
 	"github.com/transcom/mymove/pkg/gen/apimessages"
 	shipmentop "github.com/transcom/mymove/pkg/gen/restapi/apioperations/shipments"
 	"github.com/transcom/mymove/pkg/handlers"
 	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/notifications"
 	"github.com/transcom/mymove/pkg/paperwork"
 	uploaderpkg "github.com/transcom/mymove/pkg/uploader"
 	"go.uber.org/zap"
@@ -114,6 +115,19 @@ func (h IndexShipmentsHandler) Handle(params shipmentop.IndexShipmentsParams) mi
 	return shipmentop.NewIndexShipmentsOK().WithPayload(isp)
 }
 
+// fetchShipmentWithPermission fetches a shipment for a user who has been granted a permission.
+// TSP users are further limited to the shipments awarded to their TSP.
+func fetchShipmentWithPermission(db *pop.Connection, session *auth.Session, shipmentID uuid.UUID, permission auth.Permission) (*models.Shipment, error) {
+	if !session.Can(permission) {
+		return nil, models.ErrFetchForbidden
+	}
+	if session.IsTspUser() {
+		_, shipment, err := models.FetchShipmentForVerifiedTSPUser(db, session.TspUserID, shipmentID)
+		return shipment, err
+	}
+	return models.FetchShipment(db, session, shipmentID)
+}
+
 // GetShipmentHandler returns a particular shipment
 type GetShipmentHandler struct {
 	handlers.HandlerContext
@@ -121,26 +135,12 @@ type GetShipmentHandler struct {
 
 // Handle returns a specified shipment
 func (h GetShipmentHandler) Handle(params shipmentop.GetShipmentParams) middleware.Responder {
-	var shipment *models.Shipment
-	var err error
 	shipmentID, _ := uuid.FromString(params.ShipmentID.String())
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
 
-	if session.IsTspUser() {
-		// Check that the TSP user can access the shipment
-		tspUser, err := models.FetchTspUserByID(h.DB(), session.TspUserID)
-		shipment, err = models.FetchShipmentByTSP(h.DB(), tspUser.TransportationServiceProviderID, shipmentID)
-		if err != nil {
-			h.Logger().Error("Error fetching shipment for TSP user", zap.Error(err))
-			return shipmentop.NewGetShipmentForbidden()
-		}
-	} else if session.IsOfficeUser() {
-		shipment, err = models.FetchShipment(h.DB(), session, shipmentID)
-		if err != nil {
-			h.Logger().Error("Error fetching shipment for office user", zap.Error(err))
-			return shipmentop.NewGetShipmentForbidden()
-		}
-	} else {
+	shipment, err := fetchShipmentWithPermission(h.DB(), session, shipmentID, auth.PermissionViewShipments)
+	if err != nil {
+		h.Logger().Error("Error fetching shipment", zap.Error(err))
 		return shipmentop.NewGetShipmentForbidden()
 	}
 
@@ -169,7 +169,7 @@ func (h AcceptShipmentHandler) Handle(params shipmentop.AcceptShipmentParams) mi
 	}
 
 	// Accept the shipment
-	shipment, shipmentOffer, verrs, err := models.AcceptShipmentForTSP(h.DB(), tspUser.TransportationServiceProviderID, shipmentID)
+	shipment, shipmentOffer, verrs, err := models.AcceptShipmentForTSP(h.DB(), session, tspUser.TransportationServiceProviderID, shipmentID)
 	if err != nil || verrs.HasAny() {
 		if err == models.ErrFetchNotFound {
 			h.Logger().Error("DB Query", zap.Error(err))
@@ -210,7 +210,7 @@ func (h RejectShipmentHandler) Handle(params shipmentop.RejectShipmentParams) mi
 	}
 
 	// Reject the shipment
-	shipment, shipmentOffer, verrs, err := models.RejectShipmentForTSP(h.DB(), tspUser.TransportationServiceProviderID, shipmentID, *params.Payload.Reason)
+	shipment, shipmentOffer, verrs, err := models.RejectShipmentForTSP(h.DB(), session, tspUser.TransportationServiceProviderID, shipmentID, *params.Payload.Reason)
 	if err != nil || verrs.HasAny() {
 		if err == models.ErrFetchNotFound {
 			h.HoneyZapLogger().TraceError(ctx, "DB Query", zap.Error(err))
@@ -229,7 +229,7 @@ func (h RejectShipmentHandler) Handle(params shipmentop.RejectShipmentParams) mi
 		}
 	}
 
-	go awardqueue.NewAwardQueue(h.DB(), h.HoneyZapLogger()).Run(ctx)
+	go awardqueue.NewAwardQueue(h.DB(), h.HoneyZapLogger(), h.NotificationSender()).Run(ctx)
 
 	sp := payloadForShipmentModel(*shipment)
 	return shipmentop.NewRejectShipmentOK().WithPayload(sp)
@@ -261,6 +261,7 @@ func (h TransportShipmentHandler) Handle(params shipmentop.TransportShipmentPara
 		return shipmentop.NewTransportShipmentBadRequest()
 	}
 
+	old := *shipment
 	actualPackDate := (time.Time)(*params.Payload.ActualPackDate)
 
 	err = shipment.Pack(actualPackDate)
@@ -285,10 +286,26 @@ func (h TransportShipmentHandler) Handle(params shipmentop.TransportShipmentPara
 		shipment.TareWeight = handlers.PoundPtrFromInt64Ptr(params.Payload.TareWeight)
 	}
 
-	verrs, err := h.DB().ValidateAndUpdate(shipment)
+	event := models.NewAuditEvent(session, models.AuditEntityTypeSHIPMENT, shipment.ID, &shipment.MoveID, "transport").
+		Change("status", old.Status, shipment.Status).
+		Change("actual_pack_date", old.ActualPackDate, shipment.ActualPackDate).
+		Change("actual_pickup_date", old.ActualPickupDate, shipment.ActualPickupDate).
+		Change("net_weight", old.NetWeight, shipment.NetWeight).
+		Change("gross_weight", old.GrossWeight, shipment.GrossWeight).
+		Change("tare_weight", old.TareWeight, shipment.TareWeight)
+	verrs, err := models.SaveWithAuditEvents(h.DB(), shipment, event)
 	if err != nil || verrs.HasAny() {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
 	}
+
+	err = h.NotificationSender().SendNotification(
+		notifications.NewShipmentPickedUp(h.DB(), h.Logger(), shipment.ID),
+	)
+	if err != nil {
+		h.Logger().Error("problem sending email to user", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+
 	sp := payloadForShipmentModel(*shipment)
 	return shipmentop.NewTransportShipmentOK().WithPayload(sp)
 }
@@ -321,14 +338,27 @@ func (h DeliverShipmentHandler) Handle(params shipmentop.DeliverShipmentParams)
 
 	actualDeliveryDate := (time.Time)(*params.Payload.ActualDeliveryDate)
 
+	old := *shipment
 	err = shipment.Deliver(actualDeliveryDate)
 	if err != nil {
 		return handlers.ResponseForError(h.Logger(), err)
 	}
-	verrs, err := h.DB().ValidateAndUpdate(shipment)
+	event := models.NewAuditEvent(session, models.AuditEntityTypeSHIPMENT, shipment.ID, &shipment.MoveID, "deliver").
+		Change("status", old.Status, shipment.Status).
+		Change("actual_delivery_date", old.ActualDeliveryDate, shipment.ActualDeliveryDate)
+	verrs, err := models.SaveWithAuditEvents(h.DB(), shipment, event)
 	if err != nil || verrs.HasAny() {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
 	}
+
+	err = h.NotificationSender().SendNotification(
+		notifications.NewShipmentDelivered(h.DB(), h.Logger(), shipment.ID),
+	)
+	if err != nil {
+		h.Logger().Error("problem sending email to user", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+
 	sp := payloadForShipmentModel(*shipment)
 	return shipmentop.NewDeliverShipmentOK().WithPayload(sp)
 }
@@ -487,32 +517,24 @@ type PatchShipmentHandler struct {
 
 // Handle updates the shipment - checks that currently logged in user is authorized to act for the TSP assigned the shipment
 func (h PatchShipmentHandler) Handle(params shipmentop.PatchShipmentParams) middleware.Responder {
-	var shipment *models.Shipment
-	var err error
 	shipmentID, _ := uuid.FromString(params.ShipmentID.String())
 	session := auth.SessionFromRequestContext(params.HTTPRequest)
 
 	// authorization
-	if session.IsTspUser() {
-		// Check that the TSP user can access the shipment
-		tspUser, err := models.FetchTspUserByID(h.DB(), session.TspUserID)
-		shipment, err = models.FetchShipmentByTSP(h.DB(), tspUser.TransportationServiceProviderID, shipmentID)
-		if err != nil {
-			h.Logger().Error("Error fetching shipment for TSP user", zap.Error(err))
-			return shipmentop.NewPatchShipmentBadRequest()
-		}
-	} else if session.IsOfficeUser() {
-		shipment, err = models.FetchShipment(h.DB(), session, shipmentID)
-		if err != nil {
-			h.Logger().Error("Error fetching shipment for office user", zap.Error(err))
-			return shipmentop.NewPatchShipmentBadRequest()
-		}
-	} else {
+	shipment, err := fetchShipmentWithPermission(h.DB(), session, shipmentID, auth.PermissionEditShipments)
+	if err != nil {
+		h.Logger().Error("Error fetching shipment", zap.Error(err))
 		return shipmentop.NewPatchShipmentBadRequest()
 	}
 
+	previousAddresses := addressverifier.CopyAddresses(addressverifier.ShipmentAddresses(shipment))
 	patchShipmentWithPayload(shipment, params.Update)
-	verrs, err := models.SaveShipmentAndAddresses(h.DB(), shipment)
+	verrs, err := addressverifier.VerifyChanged(h.AddressVerifier(), previousAddresses, addressverifier.ShipmentAddresses(shipment))
+	if err != nil || verrs.HasAny() {
+		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
+	}
+
+	verrs, err = models.SaveShipmentAndAddresses(h.DB(), shipment)
 
 	if err != nil || verrs.HasAny() {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
@@ -522,7 +544,7 @@ func (h PatchShipmentHandler) Handle(params shipmentop.PatchShipmentParams) midd
 	return shipmentop.NewPatchShipmentOK().WithPayload(shipmentPayload)
 }
 
-// CreateGovBillOfLadingHandler creates a GBL PDF & uploads it as a document associated to a move doc, shipment and move
+// CreateGovBillOfLadingHandler creates or regenerates a GBL PDF & uploads it as a document associated to a move doc, shipment and move
 type CreateGovBillOfLadingHandler struct {
 	handlers.HandlerContext
 }
@@ -545,12 +567,6 @@ func (h CreateGovBillOfLadingHandler) Handle(params shipmentop.CreateGovBillOfLa
 		}
 		return handlers.ResponseForError(h.Logger(), err)
 	}
-	// Don't allow GBL generation for shipments that already have a GBL move document
-	extantGBLS, _ := models.FetchMoveDocumentsByTypeForShipment(h.DB(), session, models.MoveDocumentTypeGOVBILLOFLADING, shipmentID)
-	if len(extantGBLS) > 0 {
-		return handlers.ResponseForCustomErrors(h.Logger(), fmt.Errorf("there is already a Bill of Lading for this shipment"), http.StatusBadRequest)
-	}
-
 	// Don't allow GBL generation for incomplete orders
 	orders, ordersErr := models.FetchOrder(h.DB(), shipment.Move.OrdersID)
 	if ordersErr != nil {
@@ -567,64 +583,16 @@ func (h CreateGovBillOfLadingHandler) Handle(params shipmentop.CreateGovBillOfLa
 		h.Logger().Error("Failed retrieving the GBL data.", zap.Error(err))
 		return shipmentop.NewCreateGovBillOfLadingExpectationFailed()
 	}
-	formLayout := paperwork.Form1203Layout
-
-	// Read in bytes from Asset pkg
-	data, err := assets.Asset(formLayout.TemplateImagePath)
-	if err != nil {
-		h.Logger().Error("Error reading template file", zap.Error(err))
-		return shipmentop.NewCreateGovBillOfLadingInternalServerError()
-	}
-	f, err := h.FileStorer().FileSystem().Create("something.png")
-	_, err = f.Write(data)
-	if err != nil {
-		h.Logger().Error("Error writing template bytes to file", zap.Error(err))
-		return shipmentop.NewCreateGovBillOfLadingInternalServerError()
-	}
-	f.Seek(0, 0)
-
-	form, err := paperwork.NewTemplateForm(f, formLayout.FieldsLayout)
-	if err != nil {
-		h.Logger().Error("Error initializing GBL template form.", zap.Error(err))
-		return shipmentop.NewCreateGovBillOfLadingInternalServerError()
-	}
 
-	// Populate form fields with GBL data
-	err = form.DrawData(gbl)
+	gblFile, err := paperwork.GenerateGBL(h.DB(), h.Logger(), h.Planner(), h.FileStorer().FileSystem(), shipmentID, gbl)
 	if err != nil {
-		h.Logger().Error("Failure writing GBL data to form.", zap.Error(err))
+		h.Logger().Error("Failure generating GBL form.", zap.Error(err))
 		return shipmentop.NewCreateGovBillOfLadingInternalServerError()
 	}
 
-	aFile, err := h.FileStorer().FileSystem().Create(gbl.GBLNumber1)
-	if err != nil {
-		h.Logger().Error("Error creating a new afero file for GBL form.", zap.Error(err))
-		return shipmentop.NewCreateGovBillOfLadingInternalServerError()
-	}
-
-	err = form.Output(aFile)
-	if err != nil {
-		h.Logger().Error("Failure exporting GBL form to file.", zap.Error(err))
-		return shipmentop.NewCreateGovBillOfLadingInternalServerError()
-	}
-
-	uploader := uploaderpkg.NewUploader(h.DB(), h.Logger(), h.FileStorer())
-	upload, verrs, err := uploader.CreateUpload(nil, *tspUser.UserID, aFile)
-	if err != nil || verrs.HasAny() {
-		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
-	}
-
-	uploads := []models.Upload{*upload}
-
-	// Create GBL move document associated to the shipment
-	doc, verrs, err := shipment.Move.CreateMoveDocument(h.DB(),
-		uploads,
-		&shipmentID,
-		models.MoveDocumentTypeGOVBILLOFLADING,
-		string("Government Bill Of Lading"),
-		swag.String(""),
-		string(apimessages.SelectedMoveTypeHHG),
-	)
+	// Create or replace the GBL move document associated to the shipment
+	uploader := uploaderpkg.NewUploader(h.DB(), h.Logger(), h.FileStorer(), h.FileScanner())
+	doc, verrs, err := paperwork.SaveGBL(h.DB(), uploader, shipment.Move, shipmentID, *tspUser.UserID, gblFile)
 	if err != nil || verrs.HasAny() {
 		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
 	}
@@ -13,6 +13,7 @@ import (
 	shipmentop "github.com/transcom/mymove/pkg/gen/restapi/apioperations/shipments"
 	"github.com/transcom/mymove/pkg/handlers"
 	"github.com/transcom/mymove/pkg/models"
+	scannerTest "github.com/transcom/mymove/pkg/scanner/test"
 	storageTest "github.com/transcom/mymove/pkg/storage/test"
 	"github.com/transcom/mymove/pkg/testdatagen"
 	"github.com/transcom/mymove/pkg/testdatagen/scenario"
@@ -253,6 +254,7 @@ func (suite *HandlerSuite) TestCreateGovBillOfLadingHandler() {
 	fakeS3 := storageTest.NewFakeS3Storage(true)
 	context := handlers.NewHandlerContext(suite.TestDB(), suite.TestLogger())
 	context.SetFileStorer(fakeS3)
+	context.SetFileScanner(scannerTest.NewFakeScanner())
 
 	// And: the Orders are missing required data
 	shipment.Move.Orders.TAC = nil
@@ -279,13 +281,14 @@ func (suite *HandlerSuite) TestCreateGovBillOfLadingHandler() {
 	suite.Assertions.IsType(&shipmentop.CreateGovBillOfLadingCreated{}, response)
 
 	// When: there is an existing GBL for a shipment and handler is called
-	handler = CreateGovBillOfLadingHandler{handlers.NewHandlerContext(suite.TestDB(), suite.TestLogger())}
 	response = handler.Handle(params)
 
-	// Then: expect a 400 status code
-	suite.Assertions.IsType(&handlers.ErrResponse{}, response)
-	errResponse = response.(*handlers.ErrResponse)
-	suite.Assertions.Equal(http.StatusBadRequest, errResponse.Code)
+	// Then: expect the GBL to be regenerated in place of the existing one
+	suite.Assertions.IsType(&shipmentop.CreateGovBillOfLadingCreated{}, response)
+	var gbls models.MoveDocuments
+	suite.NoError(suite.TestDB().Eager("Document.Uploads").Where("shipment_id = ?", shipment.ID).All(&gbls))
+	suite.Len(gbls, 1)
+	suite.Len(gbls[0].Document.Uploads, 1)
 
 	// When: an unauthed TSP user hits the handler
 	req = suite.AuthenticateTspRequest(req, unauthedTSPUser)
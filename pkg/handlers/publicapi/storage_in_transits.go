@@ -0,0 +1,288 @@
+package publicapi
+
+import (
+	"time"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/strfmt"
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/auth"
+	"github.com/transcom/mymove/pkg/gen/apimessages"
+	sitop "github.com/transcom/mymove/pkg/gen/restapi/apioperations/storage_in_transits"
+	"github.com/transcom/mymove/pkg/handlers"
+	"github.com/transcom/mymove/pkg/models"
+)
+
+func payloadForStorageInTransitModels(s models.StorageInTransits) apimessages.StorageInTransits {
+	payloads := make(apimessages.StorageInTransits, len(s))
+
+	for i, sit := range s {
+		payloads[i] = payloadForStorageInTransitModel(&sit)
+	}
+
+	return payloads
+}
+
+func payloadForStorageInTransitModel(s *models.StorageInTransit) *apimessages.StorageInTransit {
+	if s == nil {
+		return nil
+	}
+
+	extensions := make([]*apimessages.StorageInTransitExtension, len(s.Extensions))
+	for i, extension := range s.Extensions {
+		extensions[i] = payloadForStorageInTransitExtensionModel(&extension)
+	}
+
+	return &apimessages.StorageInTransit{
+		ID:               *handlers.FmtUUID(s.ID),
+		ShipmentID:       *handlers.FmtUUID(s.ShipmentID),
+		Location:         apimessages.StorageInTransitLocation(s.Location),
+		Status:           apimessages.StorageInTransitStatus(s.Status),
+		WarehouseID:      handlers.FmtString(s.WarehouseID),
+		WarehouseName:    handlers.FmtString(s.WarehouseName),
+		WarehouseAddress: payloadForAddressModel(&s.WarehouseAddress),
+		InDate:           handlers.FmtDate(s.InDate),
+		OutDate:          handlers.FmtDatePtr(s.OutDate),
+		AuthorizedDays:   int64(s.AuthorizedDays),
+		AllowedDays:      int64(s.AllowedDays()),
+		BillableDays:     int64(s.BillableDays()),
+		Notes:            s.Notes,
+		Extensions:       extensions,
+		CreatedAt:        strfmt.DateTime(s.CreatedAt),
+		UpdatedAt:        strfmt.DateTime(s.UpdatedAt),
+	}
+}
+
+func payloadForStorageInTransitExtensionModel(e *models.StorageInTransitExtension) *apimessages.StorageInTransitExtension {
+	if e == nil {
+		return nil
+	}
+
+	return &apimessages.StorageInTransitExtension{
+		ID:                 *handlers.FmtUUID(e.ID),
+		StorageInTransitID: *handlers.FmtUUID(e.StorageInTransitID),
+		RequestedDays:      handlers.FmtInt64(int64(e.RequestedDays)),
+		Reason:             handlers.FmtString(e.Reason),
+		Status:             apimessages.StorageInTransitExtensionStatus(e.Status),
+		ReviewedAt:         handlers.FmtDateTimePtr(e.ReviewedAt),
+		CreatedAt:          strfmt.DateTime(e.CreatedAt),
+	}
+}
+
+// authorizeStorageInTransitShipment checks that the user has been granted a permission for the
+// shipment, and that a TSP user has been awarded it. Service members can't see or record SIT.
+func authorizeStorageInTransitShipment(db *pop.Connection, session *auth.Session, shipmentID uuid.UUID, permission auth.Permission) error {
+	_, err := fetchShipmentWithPermission(db, session, shipmentID, permission)
+	return err
+}
+
+// IndexStorageInTransitsHandler returns the stays in SIT for a shipment
+type IndexStorageInTransitsHandler struct {
+	handlers.HandlerContext
+}
+
+// Handle returns the stays in SIT for a shipment
+func (h IndexStorageInTransitsHandler) Handle(params sitop.IndexStorageInTransitsParams) middleware.Responder {
+	session := auth.SessionFromRequestContext(params.HTTPRequest)
+	shipmentID := uuid.Must(uuid.FromString(params.ShipmentID.String()))
+
+	if err := authorizeStorageInTransitShipment(h.DB(), session, shipmentID, auth.PermissionViewShipments); err != nil {
+		h.Logger().Error("Error fetching shipment for storage in transit", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+
+	sits, err := models.FetchStorageInTransitsByShipmentID(h.DB(), shipmentID)
+	if err != nil {
+		h.Logger().Error("Error fetching storage in transit for shipment", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+
+	payload := payloadForStorageInTransitModels(sits)
+	return sitop.NewIndexStorageInTransitsOK().WithPayload(payload)
+}
+
+// CreateStorageInTransitHandler records a shipment's goods going into SIT
+type CreateStorageInTransitHandler struct {
+	handlers.HandlerContext
+}
+
+// Handle records a shipment's goods going into SIT
+func (h CreateStorageInTransitHandler) Handle(params sitop.CreateStorageInTransitParams) middleware.Responder {
+	session := auth.SessionFromRequestContext(params.HTTPRequest)
+	shipmentID := uuid.Must(uuid.FromString(params.ShipmentID.String()))
+
+	if err := authorizeStorageInTransitShipment(h.DB(), session, shipmentID, auth.PermissionEditShipments); err != nil {
+		h.Logger().Error("Error fetching shipment for storage in transit", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+
+	payload := params.Payload
+	sit := models.StorageInTransit{
+		ShipmentID:       shipmentID,
+		Location:         models.StorageInTransitLocation(payload.Location),
+		Status:           models.StorageInTransitStatusINSIT,
+		WarehouseID:      *payload.WarehouseID,
+		WarehouseName:    *payload.WarehouseName,
+		WarehouseAddress: *addressModelFromPayload(payload.WarehouseAddress),
+		InDate:           time.Time(*payload.InDate),
+		AuthorizedDays:   models.StorageInTransitMaxDays,
+		Notes:            payload.Notes,
+	}
+
+	verrs, err := models.CreateStorageInTransit(h.DB(), &sit)
+	if verrs.HasAny() || err != nil {
+		h.Logger().Error("Error creating storage in transit", zap.Error(err))
+		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
+	}
+
+	return sitop.NewCreateStorageInTransitCreated().WithPayload(payloadForStorageInTransitModel(&sit))
+}
+
+// ReleaseStorageInTransitHandler records a shipment's goods coming out of SIT
+type ReleaseStorageInTransitHandler struct {
+	handlers.HandlerContext
+}
+
+// Handle records a shipment's goods coming out of SIT
+func (h ReleaseStorageInTransitHandler) Handle(params sitop.ReleaseStorageInTransitParams) middleware.Responder {
+	session := auth.SessionFromRequestContext(params.HTTPRequest)
+	sitID := uuid.Must(uuid.FromString(params.StorageInTransitID.String()))
+
+	sit, err := models.FetchStorageInTransitByID(h.DB(), sitID)
+	if err != nil {
+		h.Logger().Error("Error fetching storage in transit", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+	if err := authorizeStorageInTransitShipment(h.DB(), session, sit.ShipmentID, auth.PermissionEditShipments); err != nil {
+		h.Logger().Error("Error fetching shipment for storage in transit", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+
+	if err := sit.Release(time.Time(*params.Payload.OutDate)); err != nil {
+		h.Logger().Error("Error releasing storage in transit", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+	verrs, err := h.DB().ValidateAndUpdate(sit)
+	if verrs.HasAny() || err != nil {
+		h.Logger().Error("Error saving storage in transit", zap.Error(err))
+		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
+	}
+
+	return sitop.NewReleaseStorageInTransitOK().WithPayload(payloadForStorageInTransitModel(sit))
+}
+
+// CreateStorageInTransitExtensionHandler requests more days of SIT than were authorized
+type CreateStorageInTransitExtensionHandler struct {
+	handlers.HandlerContext
+}
+
+// Handle requests more days of SIT than were authorized
+func (h CreateStorageInTransitExtensionHandler) Handle(params sitop.CreateStorageInTransitExtensionParams) middleware.Responder {
+	session := auth.SessionFromRequestContext(params.HTTPRequest)
+	sitID := uuid.Must(uuid.FromString(params.StorageInTransitID.String()))
+
+	sit, err := models.FetchStorageInTransitByID(h.DB(), sitID)
+	if err != nil {
+		h.Logger().Error("Error fetching storage in transit", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+	if err := authorizeStorageInTransitShipment(h.DB(), session, sit.ShipmentID, auth.PermissionEditShipments); err != nil {
+		h.Logger().Error("Error fetching shipment for storage in transit", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+
+	extension := models.StorageInTransitExtension{
+		StorageInTransitID: sit.ID,
+		RequestedDays:      int(*params.Payload.RequestedDays),
+		Reason:             *params.Payload.Reason,
+		Status:             models.StorageInTransitExtensionStatusREQUESTED,
+		RequestedByUserID:  session.UserID,
+	}
+	verrs, err := h.DB().ValidateAndCreate(&extension)
+	if verrs.HasAny() || err != nil {
+		h.Logger().Error("Error creating storage in transit extension", zap.Error(err))
+		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
+	}
+
+	return sitop.NewCreateStorageInTransitExtensionCreated().WithPayload(payloadForStorageInTransitExtensionModel(&extension))
+}
+
+// fetchStorageInTransitExtensionForOfficeUser returns an extension request the office user can review
+func fetchStorageInTransitExtensionForOfficeUser(db *pop.Connection, session *auth.Session, id uuid.UUID) (*models.StorageInTransitExtension, error) {
+	if !session.Can(auth.PermissionApproveHHGs) {
+		return nil, models.ErrFetchForbidden
+	}
+	extension, err := models.FetchStorageInTransitExtensionByID(db, id)
+	if err != nil {
+		return nil, err
+	}
+	sit, err := models.FetchStorageInTransitByID(db, extension.StorageInTransitID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := models.FetchShipment(db, session, sit.ShipmentID); err != nil {
+		return nil, err
+	}
+	return extension, nil
+}
+
+// ApproveStorageInTransitExtensionHandler grants a request for more days of SIT
+type ApproveStorageInTransitExtensionHandler struct {
+	handlers.HandlerContext
+}
+
+// Handle grants a request for more days of SIT. Only HHG approvers can approve extensions.
+func (h ApproveStorageInTransitExtensionHandler) Handle(params sitop.ApproveStorageInTransitExtensionParams) middleware.Responder {
+	session := auth.SessionFromRequestContext(params.HTTPRequest)
+	extensionID := uuid.Must(uuid.FromString(params.StorageInTransitExtensionID.String()))
+
+	extension, err := fetchStorageInTransitExtensionForOfficeUser(h.DB(), session, extensionID)
+	if err != nil {
+		h.Logger().Error("Error fetching storage in transit extension", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+
+	if err := extension.Approve(session.OfficeUserID, time.Now()); err != nil {
+		h.Logger().Error("Error approving storage in transit extension", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+	verrs, err := h.DB().ValidateAndUpdate(extension)
+	if verrs.HasAny() || err != nil {
+		h.Logger().Error("Error saving storage in transit extension", zap.Error(err))
+		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
+	}
+
+	return sitop.NewApproveStorageInTransitExtensionOK().WithPayload(payloadForStorageInTransitExtensionModel(extension))
+}
+
+// DenyStorageInTransitExtensionHandler refuses a request for more days of SIT
+type DenyStorageInTransitExtensionHandler struct {
+	handlers.HandlerContext
+}
+
+// Handle refuses a request for more days of SIT. Only HHG approvers can deny extensions.
+func (h DenyStorageInTransitExtensionHandler) Handle(params sitop.DenyStorageInTransitExtensionParams) middleware.Responder {
+	session := auth.SessionFromRequestContext(params.HTTPRequest)
+	extensionID := uuid.Must(uuid.FromString(params.StorageInTransitExtensionID.String()))
+
+	extension, err := fetchStorageInTransitExtensionForOfficeUser(h.DB(), session, extensionID)
+	if err != nil {
+		h.Logger().Error("Error fetching storage in transit extension", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+
+	if err := extension.Deny(session.OfficeUserID, time.Now()); err != nil {
+		h.Logger().Error("Error denying storage in transit extension", zap.Error(err))
+		return handlers.ResponseForError(h.Logger(), err)
+	}
+	verrs, err := h.DB().ValidateAndUpdate(extension)
+	if verrs.HasAny() || err != nil {
+		h.Logger().Error("Error saving storage in transit extension", zap.Error(err))
+		return handlers.ResponseForVErrors(h.Logger(), verrs, err)
+	}
+
+	return sitop.NewDenyStorageInTransitExtensionOK().WithPayload(payloadForStorageInTransitExtensionModel(extension))
+}
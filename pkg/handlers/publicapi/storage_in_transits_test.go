@@ -0,0 +1,178 @@
+package publicapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+
+	"github.com/transcom/mymove/pkg/gen/apimessages"
+	sitop "github.com/transcom/mymove/pkg/gen/restapi/apioperations/storage_in_transits"
+	"github.com/transcom/mymove/pkg/handlers"
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/testdatagen"
+)
+
+func (suite *HandlerSuite) TestCreateAndReleaseStorageInTransitTSPHandlers() {
+	numTspUsers := 1
+	numShipments := 1
+	numShipmentOfferSplit := []int{1}
+	status := []models.ShipmentStatus{models.ShipmentStatusINTRANSIT}
+	tspUsers, shipments, _, err := testdatagen.CreateShipmentOfferData(suite.TestDB(), numTspUsers, numShipments, numShipmentOfferSplit, status)
+	suite.NoError(err)
+
+	tspUser := tspUsers[0]
+	shipment := shipments[0]
+	context := handlers.NewHandlerContext(suite.TestDB(), suite.TestLogger())
+
+	// When: the TSP records the goods going into storage
+	req := httptest.NewRequest("POST", "/shipments/shipmentId/storage_in_transits", nil)
+	req = suite.AuthenticateTspRequest(req, tspUser)
+	inDate := time.Date(2018, time.December, 10, 0, 0, 0, 0, time.UTC)
+	createParams := sitop.CreateStorageInTransitParams{
+		HTTPRequest: req,
+		ShipmentID:  strfmt.UUID(shipment.ID.String()),
+		Payload: &apimessages.StorageInTransit{
+			Location:      apimessages.StorageInTransitLocationDESTINATION,
+			WarehouseID:   swag.String("000383"),
+			WarehouseName: swag.String("Hercules Hauling"),
+			WarehouseAddress: &apimessages.Address{
+				StreetAddress1: swag.String("123 Any Street"),
+				City:           swag.String("Beverly Hills"),
+				State:          swag.String("CA"),
+				PostalCode:     swag.String("90210"),
+			},
+			InDate: handlers.FmtDate(inDate),
+		},
+	}
+	response := CreateStorageInTransitHandler{context}.Handle(createParams)
+
+	// Then: the stay is in SIT with the standard authorized days
+	suite.Assertions.IsType(&sitop.CreateStorageInTransitCreated{}, response)
+	created := response.(*sitop.CreateStorageInTransitCreated).Payload
+	suite.Equal(apimessages.StorageInTransitStatusINSIT, created.Status)
+	suite.Equal(int64(models.StorageInTransitMaxDays), created.AuthorizedDays)
+	suite.Equal(int64(0), created.BillableDays)
+
+	// When: the goods are released
+	req = httptest.NewRequest("POST", "/storage_in_transits/storageInTransitId/release", nil)
+	req = suite.AuthenticateTspRequest(req, tspUser)
+	releaseParams := sitop.ReleaseStorageInTransitParams{
+		HTTPRequest:        req,
+		StorageInTransitID: created.ID,
+		Payload: &apimessages.ReleaseStorageInTransitPayload{
+			OutDate: handlers.FmtDate(inDate.AddDate(0, 0, 12)),
+		},
+	}
+	response = ReleaseStorageInTransitHandler{context}.Handle(releaseParams)
+
+	// Then: the stay is billable
+	suite.Assertions.IsType(&sitop.ReleaseStorageInTransitOK{}, response)
+	released := response.(*sitop.ReleaseStorageInTransitOK).Payload
+	suite.Equal(apimessages.StorageInTransitStatusRELEASED, released.Status)
+	suite.Equal(int64(12), released.BillableDays)
+
+	// And: the goods can't be released twice
+	response = ReleaseStorageInTransitHandler{context}.Handle(releaseParams)
+	suite.Assertions.IsType(&handlers.ErrResponse{}, response)
+	suite.Equal(http.StatusBadRequest, response.(*handlers.ErrResponse).Code)
+
+	// And: the stay is listed for the shipment
+	req = httptest.NewRequest("GET", "/shipments/shipmentId/storage_in_transits", nil)
+	req = suite.AuthenticateTspRequest(req, tspUser)
+	indexParams := sitop.IndexStorageInTransitsParams{
+		HTTPRequest: req,
+		ShipmentID:  strfmt.UUID(shipment.ID.String()),
+	}
+	response = IndexStorageInTransitsHandler{context}.Handle(indexParams)
+	suite.Assertions.IsType(&sitop.IndexStorageInTransitsOK{}, response)
+	suite.Len(response.(*sitop.IndexStorageInTransitsOK).Payload, 1)
+}
+
+func (suite *HandlerSuite) TestIndexStorageInTransitsUnauthorized() {
+	// A TSP user who wasn't awarded the shipment
+	tspUser := testdatagen.MakeDefaultTspUser(suite.TestDB())
+	sit := testdatagen.MakeDefaultStorageInTransit(suite.TestDB())
+
+	req := httptest.NewRequest("GET", "/shipments/shipmentId/storage_in_transits", nil)
+	req = suite.AuthenticateTspRequest(req, tspUser)
+	params := sitop.IndexStorageInTransitsParams{
+		HTTPRequest: req,
+		ShipmentID:  strfmt.UUID(sit.ShipmentID.String()),
+	}
+	response := IndexStorageInTransitsHandler{handlers.NewHandlerContext(suite.TestDB(), suite.TestLogger())}.Handle(params)
+
+	suite.Assertions.IsType(&handlers.ErrResponse{}, response)
+	suite.Equal(http.StatusUnauthorized, response.(*handlers.ErrResponse).Code)
+}
+
+func (suite *HandlerSuite) TestStorageInTransitExtensionHandlers() {
+	numTspUsers := 1
+	numShipments := 1
+	numShipmentOfferSplit := []int{1}
+	status := []models.ShipmentStatus{models.ShipmentStatusINTRANSIT}
+	tspUsers, shipments, _, err := testdatagen.CreateShipmentOfferData(suite.TestDB(), numTspUsers, numShipments, numShipmentOfferSplit, status)
+	suite.NoError(err)
+
+	tspUser := tspUsers[0]
+	officeUser := testdatagen.MakeDefaultOfficeUser(suite.TestDB())
+	sit := testdatagen.MakeStorageInTransit(suite.TestDB(), testdatagen.Assertions{
+		StorageInTransit: models.StorageInTransit{
+			ShipmentID: shipments[0].ID,
+		},
+	})
+	context := handlers.NewHandlerContext(suite.TestDB(), suite.TestLogger())
+
+	// When: the TSP requests more days
+	req := httptest.NewRequest("POST", "/storage_in_transits/storageInTransitId/extensions", nil)
+	req = suite.AuthenticateTspRequest(req, tspUser)
+	createParams := sitop.CreateStorageInTransitExtensionParams{
+		HTTPRequest:        req,
+		StorageInTransitID: strfmt.UUID(sit.ID.String()),
+		Payload: &apimessages.StorageInTransitExtension{
+			RequestedDays: handlers.FmtInt64(30),
+			Reason:        swag.String("The new home isn't ready"),
+		},
+	}
+	response := CreateStorageInTransitExtensionHandler{context}.Handle(createParams)
+	suite.Assertions.IsType(&sitop.CreateStorageInTransitExtensionCreated{}, response)
+	extension := response.(*sitop.CreateStorageInTransitExtensionCreated).Payload
+	suite.Equal(apimessages.StorageInTransitExtensionStatusREQUESTED, extension.Status)
+
+	// Then: the TSP can't approve their own request
+	req = httptest.NewRequest("POST", "/storage_in_transit_extensions/storageInTransitExtensionId/approve", nil)
+	req = suite.AuthenticateTspRequest(req, tspUser)
+	approveParams := sitop.ApproveStorageInTransitExtensionParams{
+		HTTPRequest:                 req,
+		StorageInTransitExtensionID: extension.ID,
+	}
+	response = ApproveStorageInTransitExtensionHandler{context}.Handle(approveParams)
+	suite.Assertions.IsType(&handlers.ErrResponse{}, response)
+	suite.Equal(http.StatusForbidden, response.(*handlers.ErrResponse).Code)
+
+	// But: the office can
+	approveParams.HTTPRequest = suite.AuthenticateOfficeRequest(req, officeUser)
+	response = ApproveStorageInTransitExtensionHandler{context}.Handle(approveParams)
+	suite.Assertions.IsType(&sitop.ApproveStorageInTransitExtensionOK{}, response)
+	approved := response.(*sitop.ApproveStorageInTransitExtensionOK).Payload
+	suite.Equal(apimessages.StorageInTransitExtensionStatusAPPROVED, approved.Status)
+	suite.NotNil(approved.ReviewedAt)
+
+	// And: a reviewed request can't be denied
+	req = httptest.NewRequest("POST", "/storage_in_transit_extensions/storageInTransitExtensionId/deny", nil)
+	req = suite.AuthenticateOfficeRequest(req, officeUser)
+	denyParams := sitop.DenyStorageInTransitExtensionParams{
+		HTTPRequest:                 req,
+		StorageInTransitExtensionID: extension.ID,
+	}
+	response = DenyStorageInTransitExtensionHandler{context}.Handle(denyParams)
+	suite.Assertions.IsType(&handlers.ErrResponse{}, response)
+	suite.Equal(http.StatusBadRequest, response.(*handlers.ErrResponse).Code)
+
+	// And: the approved days are allowed
+	reloaded, err := models.FetchStorageInTransitByID(suite.TestDB(), sit.ID)
+	suite.NoError(err)
+	suite.Equal(models.StorageInTransitMaxDays+30, reloaded.AllowedDays())
+}
@@ -11,6 +11,7 @@ import (
 	uploadop "github.com/transcom/mymove/pkg/gen/restapi/apioperations/uploads"
 	"github.com/transcom/mymove/pkg/handlers"
 	"github.com/transcom/mymove/pkg/models"
+	scannerTest "github.com/transcom/mymove/pkg/scanner/test"
 	storageTest "github.com/transcom/mymove/pkg/storage/test"
 	"github.com/transcom/mymove/pkg/testdatagen"
 )
@@ -33,6 +34,7 @@ func makeRequest(suite *HandlerSuite, params uploadop.CreateUploadParams, tspUse
 
 	context := handlers.NewHandlerContext(suite.TestDB(), suite.TestLogger())
 	context.SetFileStorer(fakeS3)
+	context.SetFileScanner(scannerTest.NewFakeScanner())
 	handler := CreateUploadHandler{context}
 	response := handler.Handle(params)
 
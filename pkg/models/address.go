@@ -25,6 +25,11 @@ type Address struct {
 	State          string    `json:"state" db:"state"`
 	PostalCode     string    `json:"postal_code" db:"postal_code"`
 	Country        *string   `json:"country" db:"country"`
+	// The geocode of the address when it was verified, and how well the address matched it
+	Latitude          *float64 `json:"latitude" db:"latitude"`
+	Longitude         *float64 `json:"longitude" db:"longitude"`
+	GeocodeMatchLevel *string  `json:"geocode_match_level" db:"geocode_match_level"`
+	GeocodeRelevance  *float64 `json:"geocode_relevance" db:"geocode_relevance"`
 }
 
 // GetAddressID facilitates grabbing the ID from an address that may be nil
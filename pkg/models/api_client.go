@@ -0,0 +1,204 @@
+package models
+
+import (
+	"crypto/subtle"
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gobuffalo/validate/validators"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/transcom/mymove/pkg/auth"
+)
+
+// APIClient is a TSP system that calls the public API on behalf of a TSP user. Clients
+// authenticate with a secret, exchanged for access tokens, or with a client certificate.
+type APIClient struct {
+	ID                     uuid.UUID  `json:"id" db:"id"`
+	Name                   string     `json:"name" db:"name"`
+	TspUserID              uuid.UUID  `json:"tsp_user_id" db:"tsp_user_id"`
+	TspUser                TspUser    `belongs_to:"tsp_user"`
+	ClientID               string     `json:"client_id" db:"client_id"`
+	SecretHash             *string    `json:"-" db:"secret_hash"`
+	CertificateFingerprint *string    `json:"certificate_fingerprint" db:"certificate_fingerprint"`
+	RateLimitPerMinute     int        `json:"rate_limit_per_minute" db:"rate_limit_per_minute"`
+	LastUsedAt             *time.Time `json:"last_used_at" db:"last_used_at"`
+	RevokedAt              *time.Time `json:"revoked_at" db:"revoked_at"`
+	CreatedAt              time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt              time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// TableName overrides the table name pop would infer from the acronym
+func (c APIClient) TableName() string {
+	return "api_clients"
+}
+
+// APIAccessToken is an access token issued to an API client, stored by a key hashed from it
+type APIAccessToken struct {
+	Key         string    `db:"key"`
+	APIClientID uuid.UUID `db:"api_client_id"`
+	CreatedAt   time.Time `db:"created_at"`
+	ExpiresAt   time.Time `db:"expires_at"`
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+func (c *APIClient) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	verrs := validate.Validate(
+		&validators.StringIsPresent{Field: c.Name, Name: "Name"},
+		&validators.StringIsPresent{Field: c.ClientID, Name: "ClientID"},
+		&validators.UUIDIsPresent{Field: c.TspUserID, Name: "TspUserID"},
+		&validators.IntIsGreaterThan{Field: c.RateLimitPerMinute, Name: "RateLimitPerMinute", Compared: 0},
+	)
+	if c.SecretHash == nil && c.CertificateFingerprint == nil {
+		verrs.Add("credentials", "API clients need a secret or a certificate to authenticate with")
+	}
+	return verrs, nil
+}
+
+// FetchAPIClientByCredentials returns the unrevoked client with a client ID and the hash of its
+// secret. It returns ErrFetchNotFound if there is none or the secret doesn't match.
+func FetchAPIClientByCredentials(db *pop.Connection, clientID string, secretHash string) (APIClient, error) {
+	var client APIClient
+	err := db.Where("client_id = $1 AND revoked_at IS NULL", clientID).First(&client)
+	if err != nil {
+		if errors.Cause(err).Error() == recordNotFoundErrorString {
+			return client, ErrFetchNotFound
+		}
+		return client, errors.Wrap(err, "Error while fetching API client")
+	}
+	if client.SecretHash == nil || subtle.ConstantTimeCompare([]byte(*client.SecretHash), []byte(secretHash)) != 1 {
+		return APIClient{}, ErrFetchNotFound
+	}
+	return client, nil
+}
+
+// FetchAPIClientByCertificate returns the unrevoked client with a certificate. It returns
+// ErrFetchNotFound if there is none.
+func FetchAPIClientByCertificate(db *pop.Connection, fingerprint string) (APIClient, error) {
+	var client APIClient
+	err := db.Where("certificate_fingerprint = $1 AND revoked_at IS NULL", fingerprint).First(&client)
+	if err != nil {
+		if errors.Cause(err).Error() == recordNotFoundErrorString {
+			return client, ErrFetchNotFound
+		}
+		return client, errors.Wrap(err, "Error while fetching API client")
+	}
+	return client, nil
+}
+
+// FetchAPIClientByAccessToken returns the unrevoked client that was issued an access token
+// which hasn't expired as of now. It returns ErrFetchNotFound if there is none.
+func FetchAPIClientByAccessToken(db *pop.Connection, key string, now time.Time) (APIClient, error) {
+	var client APIClient
+	sql := `SELECT api_clients.* FROM api_clients
+		JOIN api_access_tokens ON api_access_tokens.api_client_id = api_clients.id
+		WHERE api_access_tokens.key = $1 AND api_access_tokens.expires_at > $2
+			AND api_clients.revoked_at IS NULL`
+
+	err := db.RawQuery(sql, key, now).First(&client)
+	if err != nil {
+		if errors.Cause(err).Error() == recordNotFoundErrorString {
+			return client, ErrFetchNotFound
+		}
+		return client, errors.Wrap(err, "Error while fetching API client by access token")
+	}
+	return client, nil
+}
+
+// FetchAPIClientScopes returns the scopes an API client is limited to
+func FetchAPIClientScopes(db *pop.Connection, clientID uuid.UUID) ([]auth.Permission, error) {
+	var rows []struct {
+		Scope auth.Permission `db:"scope"`
+	}
+	sql := `SELECT scope FROM api_client_scopes WHERE api_client_id = $1 ORDER BY scope`
+
+	err := db.RawQuery(sql, clientID).All(&rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while fetching API client scopes")
+	}
+	scopes := make([]auth.Permission, len(rows))
+	for i, row := range rows {
+		scopes[i] = row.Scope
+	}
+	return scopes, nil
+}
+
+// SetAPIClientScopes replaces the scopes an API client is limited to. Only
+// auth.APIClientScopes can be set.
+func SetAPIClientScopes(db *pop.Connection, clientID uuid.UUID, scopes ...auth.Permission) error {
+	for _, scope := range scopes {
+		if !auth.IsAPIClientScope(scope) {
+			return errors.Errorf("%s is not a scope API clients can have", scope)
+		}
+	}
+	err := db.RawQuery(`DELETE FROM api_client_scopes WHERE api_client_id = $1`, clientID).Exec()
+	if err != nil {
+		return errors.Wrap(err, "Error while removing API client scopes")
+	}
+	sql := `INSERT INTO api_client_scopes (api_client_id, scope, created_at)
+			VALUES ($1, $2, now())
+		ON CONFLICT DO NOTHING`
+
+	for _, scope := range scopes {
+		err = db.RawQuery(sql, clientID, scope).Exec()
+		if err != nil {
+			return errors.Wrap(err, "Error while adding API client scope")
+		}
+	}
+	return nil
+}
+
+// TouchAPIClient records when an API client was last used
+func TouchAPIClient(db *pop.Connection, clientID uuid.UUID, now time.Time) error {
+	err := db.RawQuery(`UPDATE api_clients SET last_used_at = $2 WHERE id = $1`, clientID, now).Exec()
+	if err != nil {
+		return errors.Wrap(err, "Error while touching API client")
+	}
+	return nil
+}
+
+// RevokeAPIClient stops an API client from authenticating, and expires the access tokens it
+// was issued. It returns ErrFetchNotFound if there is no such unrevoked client.
+func RevokeAPIClient(db *pop.Connection, clientID string, now time.Time) error {
+	var client APIClient
+	sql := `UPDATE api_clients SET revoked_at = $2, updated_at = $2
+		WHERE client_id = $1 AND revoked_at IS NULL
+		RETURNING *`
+
+	err := db.RawQuery(sql, clientID, now).First(&client)
+	if err != nil {
+		if errors.Cause(err).Error() == recordNotFoundErrorString {
+			return ErrFetchNotFound
+		}
+		return errors.Wrap(err, "Error while revoking API client")
+	}
+	err = db.RawQuery(`DELETE FROM api_access_tokens WHERE api_client_id = $1`, client.ID).Exec()
+	if err != nil {
+		return errors.Wrap(err, "Error while deleting API access tokens")
+	}
+	return nil
+}
+
+// CreateAPIAccessToken stores an access token issued to an API client
+func CreateAPIAccessToken(db *pop.Connection, token *APIAccessToken) error {
+	sql := `INSERT INTO api_access_tokens (key, api_client_id, created_at, expires_at)
+			VALUES ($1, $2, $3, $4)`
+
+	err := db.RawQuery(sql, token.Key, token.APIClientID, token.CreatedAt, token.ExpiresAt).Exec()
+	if err != nil {
+		return errors.Wrap(err, "Error while creating API access token")
+	}
+	return nil
+}
+
+// DeleteExpiredAPIAccessTokens removes the access tokens that have expired as of now, and
+// returns how many were removed
+func DeleteExpiredAPIAccessTokens(db *pop.Connection, now time.Time) (int, error) {
+	count, err := db.RawQuery(`DELETE FROM api_access_tokens WHERE expires_at <= $1`, now).ExecWithCount()
+	if err != nil {
+		return 0, errors.Wrap(err, "Error while deleting expired API access tokens")
+	}
+	return count, nil
+}
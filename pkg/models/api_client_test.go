@@ -0,0 +1,87 @@
+package models_test
+
+import (
+	"time"
+
+	"github.com/transcom/mymove/pkg/auth"
+	. "github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/testdatagen"
+)
+
+func (suite *ModelSuite) Test_APIClientValidations() {
+	client := &APIClient{}
+
+	expErrors := map[string][]string{
+		"name":                  {"Name can not be blank."},
+		"client_id":             {"ClientID can not be blank."},
+		"tsp_user_id":           {"TspUserID can not be blank."},
+		"rate_limit_per_minute": {"0 is not greater than 0."},
+		"credentials":           {"API clients need a secret or a certificate to authenticate with"},
+	}
+
+	suite.verifyValidationErrors(client, expErrors)
+}
+
+func (suite *ModelSuite) Test_APIClientCredentials() {
+	client := testdatagen.MakeDefaultAPIClient(suite.db)
+	secretHash := auth.SessionKey(testdatagen.DefaultAPIClientSecret)
+
+	fetched, err := FetchAPIClientByCredentials(suite.db, client.ClientID, secretHash)
+	suite.NoError(err)
+	suite.Equal(client.ID, fetched.ID)
+
+	_, err = FetchAPIClientByCredentials(suite.db, client.ClientID, auth.SessionKey("wrong secret"))
+	suite.Equal(ErrFetchNotFound, err)
+
+	now := time.Now()
+	token := APIAccessToken{Key: auth.SessionKey("token"), APIClientID: client.ID, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	suite.NoError(CreateAPIAccessToken(suite.db, &token))
+	expired := APIAccessToken{Key: auth.SessionKey("expired"), APIClientID: client.ID, CreatedAt: now, ExpiresAt: now.Add(-time.Minute)}
+	suite.NoError(CreateAPIAccessToken(suite.db, &expired))
+
+	fetched, err = FetchAPIClientByAccessToken(suite.db, token.Key, now)
+	suite.NoError(err)
+	suite.Equal(client.ID, fetched.ID)
+	_, err = FetchAPIClientByAccessToken(suite.db, expired.Key, now)
+	suite.Equal(ErrFetchNotFound, err)
+
+	count, err := DeleteExpiredAPIAccessTokens(suite.db, now)
+	suite.NoError(err)
+	suite.Equal(1, count)
+
+	// Revoked clients can't authenticate, even with tokens they were already issued
+	suite.NoError(RevokeAPIClient(suite.db, client.ClientID, now))
+	_, err = FetchAPIClientByCredentials(suite.db, client.ClientID, secretHash)
+	suite.Equal(ErrFetchNotFound, err)
+	_, err = FetchAPIClientByAccessToken(suite.db, token.Key, now)
+	suite.Equal(ErrFetchNotFound, err)
+	suite.Equal(ErrFetchNotFound, RevokeAPIClient(suite.db, client.ClientID, now))
+}
+
+func (suite *ModelSuite) Test_APIClientCertificate() {
+	fingerprint := auth.SessionKey("certificate")
+	client := testdatagen.MakeAPIClient(suite.db, testdatagen.Assertions{
+		APIClient: APIClient{CertificateFingerprint: &fingerprint},
+	})
+
+	fetched, err := FetchAPIClientByCertificate(suite.db, fingerprint)
+	suite.NoError(err)
+	suite.Equal(client.ID, fetched.ID)
+
+	_, err = FetchAPIClientByCertificate(suite.db, auth.SessionKey("another certificate"))
+	suite.Equal(ErrFetchNotFound, err)
+}
+
+func (suite *ModelSuite) Test_APIClientScopes() {
+	client := testdatagen.MakeDefaultAPIClient(suite.db)
+
+	err := SetAPIClientScopes(suite.db, client.ID, auth.PermissionViewShipments)
+	suite.NoError(err)
+	scopes, err := FetchAPIClientScopes(suite.db, client.ID)
+	suite.NoError(err)
+	suite.Equal([]auth.Permission{auth.PermissionViewShipments}, scopes)
+
+	// Clients act on behalf of TSP users, so can't have office permissions
+	err = SetAPIClientScopes(suite.db, client.ID, auth.PermissionApproveHHGs)
+	suite.Error(err)
+}
@@ -0,0 +1,360 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gobuffalo/validate/validators"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/transcom/mymove/pkg/auth"
+)
+
+// AuditActorType is the kind of actor that made an audited change
+type AuditActorType string
+
+const (
+	// AuditActorTypeSYSTEM is a change made by the system itself, such as the award queue
+	AuditActorTypeSYSTEM AuditActorType = "SYSTEM"
+	// AuditActorTypeSERVICEMEMBER is a change made by a service member
+	AuditActorTypeSERVICEMEMBER AuditActorType = "SERVICE_MEMBER"
+	// AuditActorTypeOFFICEUSER is a change made by an office user
+	AuditActorTypeOFFICEUSER AuditActorType = "OFFICE_USER"
+	// AuditActorTypeTSPUSER is a change made by a TSP user
+	AuditActorTypeTSPUSER AuditActorType = "TSP_USER"
+	// AuditActorTypeAPICLIENT is a change made by an API client on behalf of a TSP user
+	AuditActorTypeAPICLIENT AuditActorType = "API_CLIENT"
+)
+
+// AuditEntityType is the kind of record an audited change was made to
+type AuditEntityType string
+
+const (
+	// AuditEntityTypeMOVE is a Move
+	AuditEntityTypeMOVE AuditEntityType = "MOVE"
+	// AuditEntityTypeSHIPMENT is a Shipment
+	AuditEntityTypeSHIPMENT AuditEntityType = "SHIPMENT"
+	// AuditEntityTypePPM is a PersonallyProcuredMove
+	AuditEntityTypePPM AuditEntityType = "PPM"
+	// AuditEntityTypeMOVEDOCUMENT is a MoveDocument
+	AuditEntityTypeMOVEDOCUMENT AuditEntityType = "MOVE_DOCUMENT"
+	// AuditEntityTypeREIMBURSEMENT is a Reimbursement
+	AuditEntityTypeREIMBURSEMENT AuditEntityType = "REIMBURSEMENT"
+	// AuditEntityTypeSHIPMENTLINEITEM is a ShipmentLineItem
+	AuditEntityTypeSHIPMENTLINEITEM AuditEntityType = "SHIPMENT_LINE_ITEM"
+)
+
+// AuditValues are the values of the fields an audited change made, by field name
+type AuditValues map[string]interface{}
+
+// Value stores the values as JSON
+func (v AuditValues) Value() (driver.Value, error) {
+	if v == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(map[string]interface{}(v))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan reads the values from JSON
+func (v *AuditValues) Scan(src interface{}) error {
+	var data []byte
+	switch src := src.(type) {
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	case nil:
+		*v = AuditValues{}
+		return nil
+	default:
+		return fmt.Errorf("Cannot scan %T into AuditValues", src)
+	}
+	values := AuditValues{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*v = values
+	return nil
+}
+
+// AuditEvent records a change to a move, or to one of its shipments, PPMs, documents or
+// reimbursements. Events are only ever created, in the same transaction as the change.
+type AuditEvent struct {
+	ID          uuid.UUID       `json:"id" db:"id"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	ActorType   AuditActorType  `json:"actor_type" db:"actor_type"`
+	UserID      *uuid.UUID      `json:"user_id" db:"user_id"`
+	APIClientID *uuid.UUID      `json:"api_client_id" db:"api_client_id"`
+	ActorName   string          `json:"actor_name" db:"actor_name"`
+	EntityType  AuditEntityType `json:"entity_type" db:"entity_type"`
+	EntityID    uuid.UUID       `json:"entity_id" db:"entity_id"`
+	MoveID      *uuid.UUID      `json:"move_id" db:"move_id"`
+	Event       string          `json:"event" db:"event"`
+	OldValues   AuditValues     `json:"old_values" db:"old_values"`
+	NewValues   AuditValues     `json:"new_values" db:"new_values"`
+	Reason      *string         `json:"reason" db:"reason"`
+}
+
+// AuditEvents is a list of AuditEvents
+type AuditEvents []AuditEvent
+
+// NewAuditEvent begins an event recording a change made to an entity of a move by the user of a
+// session, or by the system itself if there is no session. The event is the name of the state
+// machine method that made the change, such as "approve".
+func NewAuditEvent(session *auth.Session, entityType AuditEntityType, entityID uuid.UUID, moveID *uuid.UUID, event string) *AuditEvent {
+	e := &AuditEvent{
+		ActorType:  AuditActorTypeSYSTEM,
+		ActorName:  "System",
+		EntityType: entityType,
+		EntityID:   entityID,
+		MoveID:     moveID,
+		Event:      event,
+		OldValues:  AuditValues{},
+		NewValues:  AuditValues{},
+	}
+	if session == nil {
+		return e
+	}
+
+	switch {
+	case session.IsAPIClient():
+		e.ActorType = AuditActorTypeAPICLIENT
+		apiClientID := session.APIClientID
+		e.APIClientID = &apiClientID
+	case session.IsOfficeApp() && session.IsOfficeUser():
+		e.ActorType = AuditActorTypeOFFICEUSER
+	case session.IsTspApp() && session.IsTspUser():
+		e.ActorType = AuditActorTypeTSPUSER
+	case session.IsServiceMember():
+		e.ActorType = AuditActorTypeSERVICEMEMBER
+	}
+	if session.UserID != uuid.Nil {
+		userID := session.UserID
+		e.UserID = &userID
+	}
+	// Name the actor as they would be shown in the office, falling back to something that still
+	// identifies them
+	e.ActorName = strings.TrimSpace(session.FirstName + " " + session.LastName)
+	if e.ActorName == "" {
+		e.ActorName = session.Email
+	}
+	if e.ActorName == "" && e.UserID != nil {
+		e.ActorName = e.UserID.String()
+	}
+	if e.ActorName == "" {
+		e.ActorName = string(e.ActorType)
+	}
+	return e
+}
+
+// Change records that a change set a field from one value to another
+func (e *AuditEvent) Change(field string, oldValue interface{}, newValue interface{}) *AuditEvent {
+	e.OldValues[field] = oldValue
+	e.NewValues[field] = newValue
+	return e
+}
+
+// Because records the reason given for a change, if there was one
+func (e *AuditEvent) Because(reason string) *AuditEvent {
+	if reason != "" {
+		e.Reason = &reason
+	}
+	return e
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+func (e *AuditEvent) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.StringIsPresent{Field: string(e.ActorType), Name: "ActorType"},
+		&validators.StringIsPresent{Field: e.ActorName, Name: "ActorName"},
+		&validators.StringIsPresent{Field: string(e.EntityType), Name: "EntityType"},
+		&validators.UUIDIsPresent{Field: e.EntityID, Name: "EntityID"},
+		&validators.StringIsPresent{Field: e.Event, Name: "Event"},
+	), nil
+}
+
+// CreateAuditEvents saves audit events. Call it in the same transaction as the changes they record.
+func CreateAuditEvents(db *pop.Connection, events ...*AuditEvent) error {
+	for _, event := range events {
+		// Reimbursements don't belong to a move directly, but through the PPM they advance
+		if event.MoveID == nil && event.EntityType == AuditEntityTypeREIMBURSEMENT {
+			var ppm PersonallyProcuredMove
+			err := db.Where("advance_id = $1", event.EntityID).First(&ppm)
+			if err == nil {
+				event.MoveID = &ppm.MoveID
+			} else if errors.Cause(err).Error() != recordNotFoundErrorString {
+				return errors.Wrap(err, "Error while fetching reimbursement's PPM")
+			}
+		}
+
+		verrs, err := db.ValidateAndCreate(event)
+		if err != nil {
+			return errors.Wrap(err, "Error while creating audit event")
+		}
+		if verrs.HasAny() {
+			return errors.Errorf("Invalid audit event: %s", verrs)
+		}
+	}
+	return nil
+}
+
+// SaveWithAuditEvents saves a model and the audit events recording how it changed atomically
+func SaveWithAuditEvents(db *pop.Connection, model interface{}, events ...*AuditEvent) (*validate.Errors, error) {
+	responseVErrors := validate.NewErrors()
+	var responseError error
+
+	db.Transaction(func(db *pop.Connection) error {
+		transactionError := errors.New("Rollback The transaction")
+
+		if verrs, err := db.ValidateAndSave(model); verrs.HasAny() || err != nil {
+			responseVErrors.Append(verrs)
+			responseError = errors.Wrap(err, "Error Saving Model")
+			return transactionError
+		}
+
+		if err := CreateAuditEvents(db, events...); err != nil {
+			responseError = err
+			return transactionError
+		}
+
+		return nil
+	})
+
+	return responseVErrors, responseError
+}
+
+// AuditEventFilter narrows the audit events returned by FetchAuditEvents. Empty fields match
+// every event.
+type AuditEventFilter struct {
+	EntityType *AuditEntityType
+	EntityID   *uuid.UUID
+	MoveID     *uuid.UUID
+	UserID     *uuid.UUID
+	Event      *string
+	Since      *time.Time
+	Until      *time.Time
+	Limit      int
+}
+
+// DefaultAuditEventLimit is how many events FetchAuditEvents returns when the filter has no limit
+const DefaultAuditEventLimit = 100
+
+// FetchAuditEvents returns the audit events that match a filter, newest first
+func FetchAuditEvents(db *pop.Connection, filter AuditEventFilter) (AuditEvents, error) {
+	query := db.Q()
+	if filter.EntityType != nil {
+		query = query.Where("entity_type = ?", *filter.EntityType)
+	}
+	if filter.EntityID != nil {
+		query = query.Where("entity_id = ?", *filter.EntityID)
+	}
+	if filter.MoveID != nil {
+		query = query.Where("move_id = ?", *filter.MoveID)
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Event != nil {
+		query = query.Where("event = ?", *filter.Event)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultAuditEventLimit
+	}
+
+	var events AuditEvents
+	err := query.Order("created_at desc").Limit(limit).All(&events)
+	if err != nil {
+		return events, errors.Wrap(err, "Error while fetching audit events")
+	}
+	return events, nil
+}
+
+// FetchMoveTimeline returns the audit events recording each change to a move and the records
+// that belong to it, oldest first
+func FetchMoveTimeline(db *pop.Connection, moveID uuid.UUID) (AuditEvents, error) {
+	var events AuditEvents
+	err := db.Where("move_id = ?", moveID).Order("created_at asc").All(&events)
+	if err != nil {
+		return events, errors.Wrap(err, "Error while fetching move timeline")
+	}
+	return events, nil
+}
+
+// MoveStatusSnapshot holds the statuses of a move and of its PPMs, advances and shipments, taken
+// before a transition so that the changes it made can be audited afterwards
+type MoveStatusSnapshot struct {
+	move           MoveStatus
+	ppms           map[uuid.UUID]PPMStatus
+	reimbursements map[uuid.UUID]ReimbursementStatus
+	shipments      map[uuid.UUID]ShipmentStatus
+}
+
+// SnapshotMoveStatuses takes a snapshot of the statuses of a move and the records it transitions
+func SnapshotMoveStatuses(move *Move) MoveStatusSnapshot {
+	snapshot := MoveStatusSnapshot{
+		move:           move.Status,
+		ppms:           map[uuid.UUID]PPMStatus{},
+		reimbursements: map[uuid.UUID]ReimbursementStatus{},
+		shipments:      map[uuid.UUID]ShipmentStatus{},
+	}
+	for _, ppm := range move.PersonallyProcuredMoves {
+		snapshot.ppms[ppm.ID] = ppm.Status
+		if ppm.Advance != nil {
+			snapshot.reimbursements[ppm.Advance.ID] = ppm.Advance.Status
+		}
+	}
+	for _, shipment := range move.Shipments {
+		snapshot.shipments[shipment.ID] = shipment.Status
+	}
+	return snapshot
+}
+
+// AuditEvents returns the events recording a transition of a move since the snapshot was taken,
+// and of each of its PPMs, advances and shipments whose status the transition changed
+func (s MoveStatusSnapshot) AuditEvents(session *auth.Session, move *Move, event string) []*AuditEvent {
+	moveID := move.ID
+	moveEvent := NewAuditEvent(session, AuditEntityTypeMOVE, move.ID, &moveID, event).
+		Change("status", s.move, move.Status)
+	if move.Status == MoveStatusCANCELED && move.CancelReason != nil {
+		moveEvent.Because(*move.CancelReason)
+	}
+
+	events := []*AuditEvent{moveEvent}
+	for _, ppm := range move.PersonallyProcuredMoves {
+		if oldStatus, ok := s.ppms[ppm.ID]; ok && oldStatus != ppm.Status {
+			events = append(events, NewAuditEvent(session, AuditEntityTypePPM, ppm.ID, &moveID, event).
+				Change("status", oldStatus, ppm.Status))
+		}
+		if ppm.Advance == nil {
+			continue
+		}
+		if oldStatus, ok := s.reimbursements[ppm.Advance.ID]; ok && oldStatus != ppm.Advance.Status {
+			events = append(events, NewAuditEvent(session, AuditEntityTypeREIMBURSEMENT, ppm.Advance.ID, &moveID, event).
+				Change("status", oldStatus, ppm.Advance.Status))
+		}
+	}
+	for _, shipment := range move.Shipments {
+		if oldStatus, ok := s.shipments[shipment.ID]; ok && oldStatus != shipment.Status {
+			events = append(events, NewAuditEvent(session, AuditEntityTypeSHIPMENT, shipment.ID, &moveID, event).
+				Change("status", oldStatus, shipment.Status))
+		}
+	}
+	return events
+}
@@ -0,0 +1,117 @@
+package models_test
+
+import (
+	"github.com/gofrs/uuid"
+
+	"github.com/transcom/mymove/pkg/auth"
+	. "github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/testdatagen"
+)
+
+func (suite *ModelSuite) Test_AuditEventValidations() {
+	event := &AuditEvent{}
+
+	expErrors := map[string][]string{
+		"actor_type":  {"ActorType can not be blank."},
+		"actor_name":  {"ActorName can not be blank."},
+		"entity_type": {"EntityType can not be blank."},
+		"entity_id":   {"EntityID can not be blank."},
+		"event":       {"Event can not be blank."},
+	}
+
+	suite.verifyValidationErrors(event, expErrors)
+}
+
+func (suite *ModelSuite) Test_AuditEventActors() {
+	entityID := uuid.Must(uuid.NewV4())
+	userID := uuid.Must(uuid.NewV4())
+
+	event := NewAuditEvent(nil, AuditEntityTypeSHIPMENT, entityID, nil, "award")
+	suite.Equal(AuditActorTypeSYSTEM, event.ActorType)
+	suite.Nil(event.UserID)
+
+	officeSession := &auth.Session{
+		ApplicationName: auth.OfficeApp,
+		UserID:          userID,
+		OfficeUserID:    uuid.Must(uuid.NewV4()),
+		ServiceMemberID: uuid.Must(uuid.NewV4()),
+		FirstName:       "Sam",
+		LastName:        "Bollinger",
+	}
+	event = NewAuditEvent(officeSession, AuditEntityTypeMOVE, entityID, nil, "approve")
+	suite.Equal(AuditActorTypeOFFICEUSER, event.ActorType, "expected the office app to act as the office user")
+	suite.Equal(userID, *event.UserID)
+	suite.Equal("Sam Bollinger", event.ActorName)
+
+	clientSession := &auth.Session{
+		ApplicationName: auth.TspApp,
+		UserID:          userID,
+		TspUserID:       uuid.Must(uuid.NewV4()),
+		APIClientID:     uuid.Must(uuid.NewV4()),
+		Email:           "dispatch@example.com",
+	}
+	event = NewAuditEvent(clientSession, AuditEntityTypeSHIPMENT, entityID, nil, "transport")
+	suite.Equal(AuditActorTypeAPICLIENT, event.ActorType)
+	suite.Equal(clientSession.APIClientID, *event.APIClientID)
+	suite.Equal("dispatch@example.com", event.ActorName)
+}
+
+func (suite *ModelSuite) Test_MoveStatusSnapshotAuditEvents() {
+	ppm := testdatagen.MakeDefaultPPM(suite.db)
+	move := ppm.Move
+	session := &auth.Session{
+		ApplicationName: auth.MyApp,
+		UserID:          move.Orders.ServiceMember.UserID,
+		ServiceMemberID: move.Orders.ServiceMemberID,
+		FirstName:       "Nino",
+		LastName:        "Thedog",
+	}
+
+	before := SnapshotMoveStatuses(&move)
+	suite.NoError(move.Submit())
+	events := before.AuditEvents(session, &move, "submit")
+	suite.Len(events, 3, "expected the move, its PPM and its advance to be audited")
+	verrs, err := SaveMoveDependencies(suite.db, &move, events...)
+	suite.noValidationErrors(verrs, err)
+
+	timeline, err := FetchMoveTimeline(suite.db, move.ID)
+	suite.NoError(err)
+	suite.Len(timeline, 3)
+	for _, event := range timeline {
+		suite.Equal(AuditActorTypeSERVICEMEMBER, event.ActorType)
+		suite.Equal("submit", event.Event)
+	}
+
+	entityType := AuditEntityTypePPM
+	ppmEvents, err := FetchAuditEvents(suite.db, AuditEventFilter{EntityType: &entityType})
+	suite.NoError(err)
+	if suite.Len(ppmEvents, 1) {
+		suite.Equal(ppm.ID, ppmEvents[0].EntityID)
+		suite.Equal(string(PPMStatusDRAFT), ppmEvents[0].OldValues["status"])
+		suite.Equal(string(PPMStatusSUBMITTED), ppmEvents[0].NewValues["status"])
+	}
+
+	// The queue names whoever last changed the move, and when
+	items, err := GetMoveQueueItems(suite.db, "new")
+	suite.NoError(err)
+	if suite.Len(items, 1) {
+		suite.Equal("Nino Thedog", items[0].LastModifiedName)
+		suite.Equal(timeline[len(timeline)-1].CreatedAt.UTC(), items[0].LastModifiedDate.UTC())
+	}
+}
+
+func (suite *ModelSuite) Test_CreateAuditEventsForReimbursement() {
+	ppm := testdatagen.MakeDefaultPPM(suite.db)
+
+	event := NewAuditEvent(nil, AuditEntityTypeREIMBURSEMENT, ppm.Advance.ID, nil, "approve").
+		Change("status", ReimbursementStatusREQUESTED, ReimbursementStatusAPPROVED).
+		Because("Orders verified")
+	suite.NoError(CreateAuditEvents(suite.db, event))
+
+	events, err := FetchAuditEvents(suite.db, AuditEventFilter{EntityID: &ppm.Advance.ID})
+	suite.NoError(err)
+	if suite.Len(events, 1) {
+		suite.Equal(ppm.MoveID, *events[0].MoveID, "expected the move to be found through the PPM")
+		suite.Equal("Orders verified", *events[0].Reason)
+	}
+}
@@ -3,10 +3,12 @@ package models
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gobuffalo/pop"
 	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
 
 	"github.com/transcom/mymove/pkg/gen/internalmessages"
 	"github.com/transcom/mymove/pkg/unit"
@@ -78,17 +80,19 @@ type GovBillOfLadingExtractor struct {
 	PackagesKind   string
 	// Hardcoded for now - “Household Goods. Containers: 0 Shipment is released at full replacement protection of $4.00 times the net weight in pounds of the shipment or $5,000, whichever is greater.”
 	DescriptionOfShipment string
-	// TSP enters weight values
-	WeightGrossPounds *int64
-	WeightTarePounds  *int64
-	WeightNetPounds   *int64
-	// TSP enters - see description
-	LineHaulTransportationRate     *float64 `db:"linehaul_transportation_rate"`
+	// From the weights the TSP enters on the Shipment
+	WeightGrossPounds *unit.Pound `db:"weight_gross_pounds"`
+	WeightTarePounds  *unit.Pound `db:"weight_tare_pounds"`
+	WeightNetPounds   *unit.Pound `db:"weight_net_pounds"`
+	// Rate from the TSP's performance; charges from the rate engine once the shipment is weighed and picked up
+	LineHaulTransportationRate     *unit.DiscountRate `db:"linehaul_transportation_rate"`
 	LineHaulTransportationCharges  *unit.Cents
 	PackingUnpackingCharges        *unit.Cents
 	OtherAccessorialServices       *unit.Cents
 	TariffOrSpecialRateAuthorities string
-	// ¿Officer in JPPSO/PPSO - the one who approved orders? leave blank for now
+	// Approved line items, which are totalled in OtherAccessorialServices and itemized on a continuation page
+	Accessorials []ShipmentLineItem
+	// The office user generating the GBL. Left blank when a TSP generates it.
 	IssuingOfficerFullName string
 	IssuingOfficerTitle    string
 	// From Shipment.SourceGBLOC (look up Transportation office name from gbloc)
@@ -96,8 +100,9 @@ type GovBillOfLadingExtractor struct {
 	IssuingOfficeAddressID uuid.UUID `db:"issuing_office_address_id"`
 	IssuingOfficeAddress   Address   `belongs_to:"address"`
 	IssuingOfficeGBLOC     string    `db:"issuing_office_gbloc"`
-	// TSP enters - actual date the shipment is picked up
-	DateOfReceiptOfShipment  time.Time `db:"date_of_receipt_of_shipment"`
+	// From Shipment.ActualPickupDate
+	DateOfReceiptOfShipment *time.Time `db:"date_of_receipt_of_shipment"`
+	// The first certification signed on the move by the TSP's users, at pickup
 	SignatureOfAgentOrDriver *SignedCertification
 	// TSP enters - enter if the signature above is the agent's authorized representative
 	PerInitials string
@@ -107,18 +112,24 @@ type GovBillOfLadingExtractor struct {
 	ForUsePayingOfficerExcessValuation   *bool
 	ForUsePayingOfficerExcessWeight      *bool
 	ForUsePayingOfficerOther             *bool
-	// TSP enters post delivery
-	CertOfTSPBillingDate                     *time.Time
-	CertOfTSPBillingDeliveryPoint            string
-	CertOfTSPBillingNameOfDeliveringCarrier  string
-	CertOfTSPBillingPlaceDelivered           string
-	CertOfTSPBillingShortage                 *bool
-	CertOfTSPBillingDamage                   *bool
-	CertOfTSPBillingCarrierOSD               *bool
-	CertOfTSPBillingDestinationCarrierName   string
+	// Filled in once the shipment is delivered, from Shipment.ActualDeliveryDate and the TSP
+	CertOfTSPBillingDate                    *time.Time `db:"cert_of_tsp_billing_date"`
+	CertOfTSPBillingDeliveryPoint           string
+	CertOfTSPBillingNameOfDeliveringCarrier string
+	// Checked for storage in transit if the goods were in destination SIT on the delivery date
+	CertOfTSPBillingPlaceDeliveredSIT       *bool
+	CertOfTSPBillingPlaceDeliveredResidence *bool
+	CertOfTSPBillingShortage                *bool
+	CertOfTSPBillingDamage                  *bool
+	CertOfTSPBillingCarrierOSD              *bool
+	CertOfTSPBillingDestinationCarrierName  string
+	// A certification signed on the move by the TSP's users on or after delivery
 	CertOfTSPBillingAuthorizedAgentSignature *SignedCertification
 }
 
+// GBLIssuingOfficerTitle is shown as the title of the office user who generates a GBL
+const GBLIssuingOfficerTitle = "Transportation Officer"
+
 // FetchGovBillOfLadingExtractor fetches a single GovBillOfLadingExtractor for a given Shipment ID
 func FetchGovBillOfLadingExtractor(db *pop.Connection, shipmentID uuid.UUID) (GovBillOfLadingExtractor, error) {
 	var gbl GovBillOfLadingExtractor
@@ -151,7 +162,12 @@ func FetchGovBillOfLadingExtractor(db *pop.Connection, shipmentID uuid.UUID) (Go
 				concat('DI: ', o.department_indicator) AS department_indicator,
 				concat('SAC: ', o.sac) AS sac,
 				concat('TAC: ', o.tac) AS tac,
-				perf.linehaul_rate AS linehaul_transportation_rate
+				perf.linehaul_rate AS linehaul_transportation_rate,
+				s.gross_weight AS weight_gross_pounds,
+				s.tare_weight AS weight_tare_pounds,
+				s.net_weight AS weight_net_pounds,
+				s.actual_pickup_date AS date_of_receipt_of_shipment,
+				s.actual_delivery_date AS cert_of_tsp_billing_date
 			FROM shipments s
 			INNER JOIN service_members sm
 				ON s.service_member_id = sm.id
@@ -201,6 +217,8 @@ func FetchGovBillOfLadingExtractor(db *pop.Connection, shipmentID uuid.UUID) (Go
 		"PowerTrack@usbank.com"
 	gbl.DescriptionOfShipment = "Household Goods. Containers: 0 Shipment is released at full replacement protection of $4.00 times the net weight in pounds of the shipment or $5,000, whichever is greater."
 	gbl.Remarks = "Direct Delivery Requested"
+	gbl.PackagesNumber = 1
+	gbl.PackagesKind = "LOT"
 	if gbl.LineHaulTransportationRate != nil {
 		// Field has the following format:
 		// Domestic shipments: "400NG-2006 15%" using the linehaul rate
@@ -208,8 +226,104 @@ func FetchGovBillOfLadingExtractor(db *pop.Connection, shipmentID uuid.UUID) (Go
 		// Note: Only handling domestic shipments for now
 		gbl.TariffOrSpecialRateAuthorities = "400NG-" +
 			strconv.Itoa(gbl.DateIssued.Year()) + " " +
-			fmt.Sprintf("%.2f%%", *gbl.LineHaulTransportationRate*100.0)
+			fmt.Sprintf("%.2f%%", gbl.LineHaulTransportationRate.Float64()*100.0)
+	}
+
+	gbl.Accessorials, err = fetchGBLAccessorials(db, shipmentID)
+	if err != nil {
+		return gbl, err
+	}
+	if len(gbl.Accessorials) > 0 {
+		var total unit.Cents
+		for _, item := range gbl.Accessorials {
+			if item.AmountCents != nil {
+				total = total.AddCents(*item.AmountCents)
+			}
+		}
+		gbl.OtherAccessorialServices = &total
+	}
+
+	signatures, err := fetchGBLSignatures(db, shipmentID)
+	if err != nil {
+		return gbl, err
+	}
+	if len(signatures) > 0 {
+		gbl.SignatureOfAgentOrDriver = &signatures[0]
+	}
+
+	if gbl.CertOfTSPBillingDate != nil {
+		gbl.CertOfTSPBillingDeliveryPoint = gbl.ConsigneeAddress.Format()
+		gbl.CertOfTSPBillingNameOfDeliveringCarrier = gbl.TSPName
+		gbl.CertOfTSPBillingDestinationCarrierName = gbl.TSPName
+
+		sits, err := FetchStorageInTransitsByShipmentID(db, shipmentID)
+		if err != nil {
+			return gbl, err
+		}
+		// SIT is stored by date, while the delivery date has a time
+		deliveryDay := gbl.CertOfTSPBillingDate.UTC().Truncate(24 * time.Hour)
+		deliveredToSIT := false
+		for _, sit := range sits {
+			if sit.Location != StorageInTransitLocationDESTINATION || sit.InDate.After(deliveryDay) {
+				continue
+			}
+			if sit.OutDate == nil || sit.OutDate.After(deliveryDay) {
+				deliveredToSIT = true
+				gbl.CertOfTSPBillingDeliveryPoint = sit.WarehouseAddress.Format()
+			}
+		}
+		deliveredToResidence := !deliveredToSIT
+		gbl.CertOfTSPBillingPlaceDeliveredSIT = &deliveredToSIT
+		gbl.CertOfTSPBillingPlaceDeliveredResidence = &deliveredToResidence
+		for i := len(signatures) - 1; i >= 0; i-- {
+			if !signatures[i].Date.Before(*gbl.CertOfTSPBillingDate) {
+				gbl.CertOfTSPBillingAuthorizedAgentSignature = &signatures[i]
+				break
+			}
+		}
 	}
 
 	return gbl, nil
 }
+
+// SetIssuingOfficer fills in the issuing officer block with the office user generating the GBL
+func (g *GovBillOfLadingExtractor) SetIssuingOfficer(officeUser OfficeUser) {
+	g.IssuingOfficerFullName = strings.Join([]string{officeUser.FirstName, officeUser.LastName}, " ")
+	g.IssuingOfficerTitle = GBLIssuingOfficerTitle
+}
+
+// fetchGBLAccessorials returns the approved and invoiced line items for a shipment
+func fetchGBLAccessorials(db *pop.Connection, shipmentID uuid.UUID) ([]ShipmentLineItem, error) {
+	var items []ShipmentLineItem
+	err := db.Eager("Tariff400ngItem").
+		Where("shipment_id = ?", shipmentID).
+		Where("status IN (?, ?)", ShipmentLineItemStatusAPPROVED, ShipmentLineItemStatusINVOICED).
+		Order("approved_date asc").
+		All(&items)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch accessorials for GBL")
+	}
+	return items, nil
+}
+
+// fetchGBLSignatures returns the certifications signed on a shipment's move by users of the
+// TSP that accepted the shipment, oldest first
+func fetchGBLSignatures(db *pop.Connection, shipmentID uuid.UUID) (SignedCertifications, error) {
+	var signatures SignedCertifications
+	sql := `SELECT sc.*
+			FROM signed_certifications sc
+			INNER JOIN shipments s
+				ON sc.move_id = s.move_id
+			INNER JOIN shipment_offers so
+				ON s.id = so.shipment_id AND so.accepted = true
+			INNER JOIN tsp_users tu
+				ON tu.transportation_service_provider_id = so.transportation_service_provider_id
+				AND tu.user_id = sc.submitting_user_id
+			WHERE s.id = $1
+			ORDER BY sc.date ASC`
+	err := db.RawQuery(sql, shipmentID).All(&signatures)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch signatures for GBL")
+	}
+	return signatures, nil
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/transcom/mymove/pkg/models"
 	"github.com/transcom/mymove/pkg/testdatagen"
+	"github.com/transcom/mymove/pkg/unit"
 )
 
 func (suite *ModelSuite) TestFetchGovBillOfLadingExtractor() {
@@ -16,6 +17,9 @@ func (suite *ModelSuite) TestFetchGovBillOfLadingExtractor() {
 	deliveryDate := time.Now().AddDate(0, 0, 2)
 	edipi := "123456"
 	gblNumber := "ABC12345"
+	grossWeight := unit.Pound(8000)
+	tareWeight := unit.Pound(3000)
+	netWeight := unit.Pound(5000)
 	shipment := testdatagen.MakeShipment(suite.db, testdatagen.Assertions{
 		Shipment: models.Shipment{
 			SourceGBLOC:                 &SourceTransOffice.Gbloc,
@@ -24,6 +28,9 @@ func (suite *ModelSuite) TestFetchGovBillOfLadingExtractor() {
 			PmSurveyPlannedPickupDate:   &pickupDate,
 			PmSurveyPlannedPackDate:     &packDate,
 			GBLNumber:                   &gblNumber,
+			GrossWeight:                 &grossWeight,
+			TareWeight:                  &tareWeight,
+			NetWeight:                   &netWeight,
 		},
 		ServiceMember: models.ServiceMember{
 			Edipi: &edipi,
@@ -50,10 +57,110 @@ func (suite *ModelSuite) TestFetchGovBillOfLadingExtractor() {
 		},
 	})
 
+	// Only approved accessorials go on the GBL
+	amount := unit.Cents(12345)
+	approved := testdatagen.MakeShipmentLineItem(suite.db, testdatagen.Assertions{
+		ShipmentLineItem: models.ShipmentLineItem{
+			ShipmentID:  shipment.ID,
+			Status:      models.ShipmentLineItemStatusAPPROVED,
+			AmountCents: &amount,
+		},
+	})
+	testdatagen.MakeShipmentLineItem(suite.db, testdatagen.Assertions{
+		ShipmentLineItem: models.ShipmentLineItem{
+			ShipmentID: shipment.ID,
+		},
+	})
+
 	gbl, err := models.FetchGovBillOfLadingExtractor(suite.db, shipment.ID)
 
 	suite.NoError(err)
 
 	suite.Equal(SourceTransOffice.Gbloc, gbl.IssuingOfficeGBLOC)
 	suite.Equal(DestinationTransOffice.Gbloc, gbl.DestinationGbloc)
+	suite.Equal(grossWeight, *gbl.WeightGrossPounds)
+	suite.Equal(tareWeight, *gbl.WeightTarePounds)
+	suite.Equal(netWeight, *gbl.WeightNetPounds)
+	suite.Equal("LOT", gbl.PackagesKind)
+	if suite.Len(gbl.Accessorials, 1) {
+		suite.Equal(approved.ID, gbl.Accessorials[0].ID)
+	}
+	suite.Equal(amount, *gbl.OtherAccessorialServices)
+	suite.Nil(gbl.DateOfReceiptOfShipment)
+	suite.Nil(gbl.CertOfTSPBillingDate)
+}
+
+func (suite *ModelSuite) TestFetchGovBillOfLadingExtractorSingleSignature() {
+	SourceTransOffice := testdatagen.MakeDefaultTransportationOffice(suite.db)
+	DestinationTransOffice := testdatagen.MakeDefaultTransportationOffice(suite.db)
+
+	packDate := time.Now()
+	pickupDate := time.Now().AddDate(0, 0, 1)
+	deliveryDate := time.Now().AddDate(0, 0, 2)
+	shipment := testdatagen.MakeShipment(suite.db, testdatagen.Assertions{
+		Shipment: models.Shipment{
+			SourceGBLOC:                 &SourceTransOffice.Gbloc,
+			DestinationGBLOC:            &DestinationTransOffice.Gbloc,
+			PmSurveyPlannedDeliveryDate: &deliveryDate,
+			PmSurveyPlannedPickupDate:   &pickupDate,
+			PmSurveyPlannedPackDate:     &packDate,
+			ActualDeliveryDate:          &deliveryDate,
+		},
+		ServiceMember: models.ServiceMember{
+			Edipi: models.StringPointer("123456"),
+		},
+	})
+	testdatagen.MakeServiceAgent(suite.db, testdatagen.Assertions{
+		ServiceAgent: models.ServiceAgent{
+			ShipmentID: shipment.ID,
+			Shipment:   &shipment,
+		},
+	})
+
+	tspUser := testdatagen.MakeDefaultTspUser(suite.db)
+	testdatagen.MakeShipmentOffer(suite.db, testdatagen.Assertions{
+		ShipmentOffer: models.ShipmentOffer{
+			ShipmentID:                      shipment.ID,
+			Shipment:                        shipment,
+			TransportationServiceProviderID: tspUser.TransportationServiceProviderID,
+		},
+	})
+
+	// The driver signs once, at delivery, which also certifies the billing
+	signature := models.SignedCertification{
+		SubmittingUserID:  *tspUser.UserID,
+		MoveID:            shipment.MoveID,
+		CertificationText: "Delivered",
+		Signature:         "Leo Spaceman",
+		Date:              deliveryDate,
+	}
+	suite.mustSave(&signature)
+
+	gbl, err := models.FetchGovBillOfLadingExtractor(suite.db, shipment.ID)
+
+	suite.NoError(err)
+	if suite.NotNil(gbl.SignatureOfAgentOrDriver) {
+		suite.Equal(signature.ID, gbl.SignatureOfAgentOrDriver.ID)
+	}
+	if suite.NotNil(gbl.CertOfTSPBillingAuthorizedAgentSignature) {
+		suite.Equal(signature.ID, gbl.CertOfTSPBillingAuthorizedAgentSignature.ID)
+	}
+	suite.True(*gbl.CertOfTSPBillingPlaceDeliveredResidence)
+	suite.False(*gbl.CertOfTSPBillingPlaceDeliveredSIT)
+
+	// Goods still in destination SIT on the delivery date were delivered to the warehouse
+	sit := testdatagen.MakeStorageInTransit(suite.db, testdatagen.Assertions{
+		StorageInTransit: models.StorageInTransit{
+			ShipmentID: shipment.ID,
+			Location:   models.StorageInTransitLocationDESTINATION,
+			InDate:     deliveryDate,
+		},
+	})
+
+	gbl, err = models.FetchGovBillOfLadingExtractor(suite.db, shipment.ID)
+
+	suite.NoError(err)
+	suite.True(*gbl.CertOfTSPBillingPlaceDeliveredSIT)
+	suite.False(*gbl.CertOfTSPBillingPlaceDeliveredResidence)
+	suite.Equal(sit.WarehouseAddress.Format(), gbl.CertOfTSPBillingDeliveryPoint)
 }
@@ -226,7 +226,9 @@ func FetchMove(db *pop.Connection, session *auth.Session, id uuid.UUID) (*Move,
 	return &move, nil
 }
 
-func (m Move) createMoveDocumentWithoutTransaction(
+// CreateMoveDocumentWithoutTransaction creates a move document like CreateMoveDocument, for
+// callers that create it as part of their own transaction
+func (m Move) CreateMoveDocumentWithoutTransaction(
 	db *pop.Connection,
 	uploads Uploads,
 	modelID *uuid.UUID,
@@ -315,7 +317,7 @@ func (m Move) CreateMoveDocument(
 	db.Transaction(func(db *pop.Connection) error {
 		transactionError := errors.New("Rollback The transaction")
 
-		newMoveDocument, responseVErrors, responseError = m.createMoveDocumentWithoutTransaction(
+		newMoveDocument, responseVErrors, responseError = m.CreateMoveDocumentWithoutTransaction(
 			db,
 			uploads,
 			modelID,
@@ -356,7 +358,7 @@ func (m Move) CreateMovingExpenseDocument(
 		transactionError := errors.New("Rollback The transaction")
 
 		var newMoveDocument *MoveDocument
-		newMoveDocument, responseVErrors, responseError = m.createMoveDocumentWithoutTransaction(
+		newMoveDocument, responseVErrors, responseError = m.CreateMoveDocumentWithoutTransaction(
 			db,
 			uploads,
 			personallyProcuredMoveID,
@@ -519,8 +521,8 @@ func createNewMove(db *pop.Connection,
 }
 
 // SaveMoveDependencies safely saves a Move status, ppms' advances' statuses, orders statuses,
-// and shipment GBLOCs.
-func SaveMoveDependencies(db *pop.Connection, move *Move) (*validate.Errors, error) {
+// and shipment GBLOCs, along with any audit events recording the changes.
+func SaveMoveDependencies(db *pop.Connection, move *Move, events ...*AuditEvent) (*validate.Errors, error) {
 	responseVErrors := validate.NewErrors()
 	var responseError error
 
@@ -599,6 +601,11 @@ func SaveMoveDependencies(db *pop.Connection, move *Move) (*validate.Errors, err
 			responseError = errors.Wrap(err, "Error Saving Move")
 			return transactionError
 		}
+
+		if err := CreateAuditEvents(db, events...); err != nil {
+			responseError = err
+			return transactionError
+		}
 		return nil
 	})
 
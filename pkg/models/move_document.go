@@ -107,6 +107,17 @@ func (m *MoveDocument) AttemptTransition(targetStatus MoveDocumentStatus) error
 	return errors.Wrap(ErrInvalidTransition, string(targetStatus))
 }
 
+// TransitionAuditEvent returns the audit event recording a transition made by AttemptTransition
+// from an old status, on behalf of the user of a session
+func (m *MoveDocument) TransitionAuditEvent(session *auth.Session, oldStatus MoveDocumentStatus) *AuditEvent {
+	event := "reject"
+	if m.Status == MoveDocumentStatusOK {
+		event = "approve"
+	}
+	return NewAuditEvent(session, AuditEntityTypeMOVEDOCUMENT, m.ID, &m.MoveID, event).
+		Change("status", oldStatus, m.Status)
+}
+
 // Approve marks the Document as OK
 func (m *MoveDocument) Approve() error {
 	if m.Status == MoveDocumentStatusOK {
@@ -187,6 +198,12 @@ func FetchApprovedMovingExpenseDocuments(db *pop.Connection, session *auth.Sessi
 		return nil, ErrFetchForbidden
 	}
 
+	return FetchApprovedMovingExpenseDocumentsForPPM(db, ppmID)
+}
+
+// FetchApprovedMovingExpenseDocumentsForPPM fetches all approved move expense documents for a ppm
+// without checking who is asking, for work that was authorized when it was requested
+func FetchApprovedMovingExpenseDocumentsForPPM(db *pop.Connection, ppmID uuid.UUID) (MoveDocuments, error) {
 	var moveDocuments MoveDocuments
 	err := db.Where("move_document_type = $1", string(MoveDocumentTypeEXPENSE)).Where("status = $2", string(MoveDocumentStatusOK)).Where("personally_procured_move_id = $3", ppmID.String()).All(&moveDocuments)
 	if err != nil {
@@ -248,8 +265,8 @@ func FetchMoveDocumentsByTypeForShipment(db *pop.Connection, session *auth.Sessi
 	return moveDocuments, nil
 }
 
-// SaveMoveDocument saves a move document
-func SaveMoveDocument(db *pop.Connection, moveDocument *MoveDocument, saveAction MoveDocumentSaveAction) (*validate.Errors, error) {
+// SaveMoveDocument saves a move document, along with any audit events recording its changes
+func SaveMoveDocument(db *pop.Connection, moveDocument *MoveDocument, saveAction MoveDocumentSaveAction, events ...*AuditEvent) (*validate.Errors, error) {
 	var responseError error
 	responseVErrors := validate.NewErrors()
 
@@ -303,6 +320,11 @@ func SaveMoveDocument(db *pop.Connection, moveDocument *MoveDocument, saveAction
 			return transactionError
 		}
 
+		if err := CreateAuditEvents(db, events...); err != nil {
+			responseError = err
+			return transactionError
+		}
+
 		return nil
 	})
 
@@ -5,6 +5,7 @@ import (
 	"github.com/gobuffalo/validate"
 	"github.com/gobuffalo/validate/validators"
 	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
 	"strings"
 	"time"
 )
@@ -65,3 +66,16 @@ func FetchOfficeUserByEmail(tx *pop.Connection, email string) (*OfficeUser, erro
 	}
 	return &users[0], nil
 }
+
+// FetchOfficeUserByID fetches an office user by ID
+func FetchOfficeUserByID(tx *pop.Connection, id uuid.UUID) (*OfficeUser, error) {
+	var user OfficeUser
+	err := tx.Find(&user, id)
+	if err != nil {
+		if errors.Cause(err).Error() == recordNotFoundErrorString {
+			return nil, ErrFetchNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
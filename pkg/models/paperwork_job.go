@@ -0,0 +1,261 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gobuffalo/validate/validators"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/transcom/mymove/pkg/auth"
+	"github.com/transcom/mymove/pkg/unit"
+)
+
+// PaperworkJobType is the kind of paperwork a job generates
+type PaperworkJobType string
+
+const (
+	// PaperworkJobTypePPMATTACHMENTS merges a PPM's orders and move documents
+	PaperworkJobTypePPMATTACHMENTS PaperworkJobType = "PPM_ATTACHMENTS"
+	// PaperworkJobTypePPMCLOSEOUT builds a PPM's closeout packet
+	PaperworkJobTypePPMCLOSEOUT PaperworkJobType = "PPM_CLOSEOUT"
+)
+
+// PaperworkJobStatus is the status of a paperwork job
+type PaperworkJobStatus string
+
+const (
+	// PaperworkJobStatusQUEUED captures enum value "QUEUED"
+	PaperworkJobStatusQUEUED PaperworkJobStatus = "QUEUED"
+	// PaperworkJobStatusRUNNING captures enum value "RUNNING"
+	PaperworkJobStatusRUNNING PaperworkJobStatus = "RUNNING"
+	// PaperworkJobStatusCOMPLETED captures enum value "COMPLETED"
+	PaperworkJobStatusCOMPLETED PaperworkJobStatus = "COMPLETED"
+	// PaperworkJobStatusFAILED captures enum value "FAILED"
+	PaperworkJobStatusFAILED PaperworkJobStatus = "FAILED"
+)
+
+// PaperworkJobMaxAttempts is how many times a job is run before it is marked as failed
+const PaperworkJobMaxAttempts = 3
+
+// PaperworkJobRetryDelay is how long a failed job waits before its first retry. The
+// delay doubles with each attempt.
+const PaperworkJobRetryDelay = time.Minute
+
+// PaperworkJobLease is how long a worker may run a job before the job is assumed to have
+// been abandoned, for instance because the server restarted, and is run again
+const PaperworkJobLease = 15 * time.Minute
+
+// PaperworkJob is a request to generate paperwork in the background. Generating packets
+// means downloading and converting every upload, which takes too long to do in a request.
+type PaperworkJob struct {
+	ID                       uuid.UUID              `json:"id" db:"id"`
+	CreatedAt                time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt                time.Time              `json:"updated_at" db:"updated_at"`
+	JobType                  PaperworkJobType       `json:"job_type" db:"job_type"`
+	Status                   PaperworkJobStatus     `json:"status" db:"status"`
+	PersonallyProcuredMoveID uuid.UUID              `json:"personally_procured_move_id" db:"personally_procured_move_id"`
+	PersonallyProcuredMove   PersonallyProcuredMove `belongs_to:"personally_procured_moves"`
+	RequestedByUserID        uuid.UUID              `json:"requested_by_user_id" db:"requested_by_user_id"`
+	DocTypes                 *string                `json:"doc_types" db:"doc_types"`
+	IncentiveCents           *unit.Cents            `json:"incentive_cents" db:"incentive_cents"`
+	Attempts                 int                    `json:"attempts" db:"attempts"`
+	MaxAttempts              int                    `json:"max_attempts" db:"max_attempts"`
+	RunAfter                 time.Time              `json:"run_after" db:"run_after"`
+	LastError                *string                `json:"last_error" db:"last_error"`
+	UploadID                 *uuid.UUID             `json:"upload_id" db:"upload_id"`
+	Upload                   *Upload                `belongs_to:"uploads"`
+	StartedAt                *time.Time             `json:"started_at" db:"started_at"`
+	CompletedAt              *time.Time             `json:"completed_at" db:"completed_at"`
+}
+
+// PaperworkJobs is a list of paperwork jobs
+type PaperworkJobs []PaperworkJob
+
+// NewPaperworkJob builds a queued job that is ready to run
+func NewPaperworkJob(jobType PaperworkJobType, ppmID uuid.UUID, userID uuid.UUID) PaperworkJob {
+	return PaperworkJob{
+		JobType:                  jobType,
+		Status:                   PaperworkJobStatusQUEUED,
+		PersonallyProcuredMoveID: ppmID,
+		RequestedByUserID:        userID,
+		MaxAttempts:              PaperworkJobMaxAttempts,
+		RunAfter:                 time.Now(),
+	}
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+func (j *PaperworkJob) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.StringIsPresent{Field: string(j.JobType), Name: "JobType"},
+		&validators.StringIsPresent{Field: string(j.Status), Name: "Status"},
+		&validators.UUIDIsPresent{Field: j.PersonallyProcuredMoveID, Name: "PersonallyProcuredMoveID"},
+		&validators.UUIDIsPresent{Field: j.RequestedByUserID, Name: "RequestedByUserID"},
+		&validators.IntIsGreaterThan{Field: j.MaxAttempts, Name: "MaxAttempts", Compared: 0},
+	), nil
+}
+
+// SetDocTypes sets the move document types that a PPM_ATTACHMENTS job merges
+func (j *PaperworkJob) SetDocTypes(docTypes []string) {
+	joined := strings.Join(docTypes, ",")
+	j.DocTypes = &joined
+}
+
+// DocTypeList returns the move document types that a PPM_ATTACHMENTS job merges
+func (j *PaperworkJob) DocTypeList() []string {
+	if j.DocTypes == nil || *j.DocTypes == "" {
+		return nil
+	}
+	return strings.Split(*j.DocTypes, ",")
+}
+
+// State Machinery
+// Avoid calling PaperworkJob.Status = ... ever. Use these methods, and ClaimPaperworkJob, to change the state.
+
+// Complete records the upload that a running job generated
+func (j *PaperworkJob) Complete(upload Upload, now time.Time) error {
+	if j.Status != PaperworkJobStatusRUNNING {
+		return errors.Wrap(ErrInvalidTransition, "Complete")
+	}
+
+	j.Status = PaperworkJobStatusCOMPLETED
+	j.UploadID = &upload.ID
+	j.Upload = &upload
+	j.LastError = nil
+	j.CompletedAt = &now
+	return nil
+}
+
+// Fail records why a running job failed. The job is queued to be retried after a delay
+// that doubles with each attempt, until it runs out of attempts.
+func (j *PaperworkJob) Fail(cause error, now time.Time) error {
+	if j.Status != PaperworkJobStatusRUNNING {
+		return errors.Wrap(ErrInvalidTransition, "Fail")
+	}
+
+	message := cause.Error()
+	j.LastError = &message
+	if j.Attempts < j.MaxAttempts {
+		delay := PaperworkJobRetryDelay
+		for i := 1; i < j.Attempts; i++ {
+			delay *= 2
+		}
+		j.Status = PaperworkJobStatusQUEUED
+		j.RunAfter = now.Add(delay)
+		return nil
+	}
+
+	j.Status = PaperworkJobStatusFAILED
+	j.CompletedAt = &now
+	return nil
+}
+
+// Retry queues a failed job to run again, with a fresh set of attempts
+func (j *PaperworkJob) Retry(now time.Time) error {
+	if j.Status != PaperworkJobStatusFAILED {
+		return errors.Wrap(ErrInvalidTransition, "Retry")
+	}
+
+	j.Status = PaperworkJobStatusQUEUED
+	j.MaxAttempts = j.Attempts + PaperworkJobMaxAttempts
+	j.RunAfter = now
+	j.CompletedAt = nil
+	return nil
+}
+
+// ClaimPaperworkJob marks the next job that is ready to run as RUNNING and returns it, or
+// returns nil if there is nothing to do. Jobs are claimed in a single statement that skips
+// rows other workers have locked, so any number of workers can share the queue. Jobs that
+// have been running for longer than PaperworkJobLease are run again, or failed if they are
+// out of attempts.
+func ClaimPaperworkJob(db *pop.Connection, now time.Time) (*PaperworkJob, error) {
+	staleBefore := now.Add(-PaperworkJobLease)
+
+	err := db.RawQuery(`UPDATE paperwork_jobs
+			SET status = $1, last_error = 'The job was abandoned while running', completed_at = $2, updated_at = $2
+			WHERE status = $3 AND started_at < $4 AND attempts >= max_attempts`,
+		PaperworkJobStatusFAILED, now, PaperworkJobStatusRUNNING, staleBefore).Exec()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while failing abandoned paperwork jobs")
+	}
+
+	var job PaperworkJob
+	sql := `UPDATE paperwork_jobs
+			SET status = $1, attempts = attempts + 1, started_at = $2, updated_at = $2
+			WHERE id = (
+				SELECT id FROM paperwork_jobs
+				WHERE (status = $3 AND run_after <= $2) OR (status = $1 AND started_at < $4)
+				ORDER BY run_after
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+		RETURNING *
+	`
+	err = db.RawQuery(sql, PaperworkJobStatusRUNNING, now, PaperworkJobStatusQUEUED, staleBefore).First(&job)
+	if err != nil {
+		if errors.Cause(err).Error() == recordNotFoundErrorString {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "Error while claiming paperwork job")
+	}
+	return &job, nil
+}
+
+// SavePaperworkJobResult saves the outcome of a run recorded with Complete or Fail. It only
+// saves it if the job is still on the attempt that was run, since a run that outlived its
+// lease has been reclaimed by another worker, and returns false if the result was dropped.
+func SavePaperworkJobResult(db *pop.Connection, job *PaperworkJob, now time.Time) (bool, error) {
+	verrs, err := job.Validate(db)
+	if err != nil {
+		return false, err
+	}
+	if verrs.HasAny() {
+		return false, errors.Errorf("invalid paperwork job: %s", verrs)
+	}
+
+	sql := `UPDATE paperwork_jobs
+			SET status = $1, run_after = $2, last_error = $3, upload_id = $4, completed_at = $5, updated_at = $6
+			WHERE id = $7 AND status = $8 AND attempts = $9`
+	count, err := db.RawQuery(sql,
+		job.Status, job.RunAfter, job.LastError, job.UploadID, job.CompletedAt, now,
+		job.ID, PaperworkJobStatusRUNNING, job.Attempts).ExecWithCount()
+	if err != nil {
+		return false, errors.Wrap(err, "Error while saving paperwork job")
+	}
+	if count == 0 {
+		return false, nil
+	}
+	job.UpdatedAt = now
+	return true, nil
+}
+
+// FetchPaperworkJob fetches a paperwork job, along with its upload once it has completed.
+// Users who can view moves can see any job; everyone else can only see the jobs they requested.
+func FetchPaperworkJob(db *pop.Connection, session *auth.Session, id uuid.UUID) (*PaperworkJob, error) {
+	var job PaperworkJob
+	err := db.Find(&job, id)
+	if err != nil {
+		if errors.Cause(err).Error() == recordNotFoundErrorString {
+			return nil, ErrFetchNotFound
+		}
+		return nil, err
+	}
+
+	if !session.Can(auth.PermissionViewMoves) && job.RequestedByUserID != session.UserID {
+		return nil, ErrFetchForbidden
+	}
+
+	// Pointer associations are buggy, so we manually load the upload
+	if job.UploadID != nil {
+		var upload Upload
+		if err := db.Find(&upload, *job.UploadID); err != nil {
+			return nil, err
+		}
+		job.Upload = &upload
+	}
+
+	return &job, nil
+}
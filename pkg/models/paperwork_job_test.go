@@ -0,0 +1,156 @@
+package models_test
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/transcom/mymove/pkg/auth"
+	. "github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/testdatagen"
+)
+
+func (suite *ModelSuite) Test_PaperworkJobValidations() {
+	job := &PaperworkJob{}
+
+	expErrors := map[string][]string{
+		"job_type":                    {"JobType can not be blank."},
+		"status":                      {"Status can not be blank."},
+		"personally_procured_move_id": {"PersonallyProcuredMoveID can not be blank."},
+		"requested_by_user_id":        {"RequestedByUserID can not be blank."},
+		"max_attempts":                {"0 is not greater than 0."},
+	}
+
+	suite.verifyValidationErrors(job, expErrors)
+}
+
+func (suite *ModelSuite) Test_PaperworkJobRetries() {
+	ppm := testdatagen.MakeDefaultPPM(suite.db)
+	job := NewPaperworkJob(PaperworkJobTypePPMATTACHMENTS, ppm.ID, ppm.Move.Orders.ServiceMember.UserID)
+	job.SetDocTypes([]string{"WEIGHT_TICKET", "EXPENSE"})
+	suite.mustSave(&job)
+
+	now := time.Now()
+	claimed, err := ClaimPaperworkJob(suite.db, now)
+	suite.Nil(err)
+	suite.NotNil(claimed)
+	suite.Equal(job.ID, claimed.ID)
+	suite.Equal(PaperworkJobStatusRUNNING, claimed.Status)
+	suite.Equal(1, claimed.Attempts)
+	suite.Equal([]string{"WEIGHT_TICKET", "EXPENSE"}, claimed.DocTypeList())
+
+	// Nothing else is ready to run
+	next, err := ClaimPaperworkJob(suite.db, now)
+	suite.Nil(err)
+	suite.Nil(next)
+
+	// A failed job waits before it is retried
+	suite.Nil(claimed.Fail(errors.New("storage is down"), now))
+	suite.Equal(PaperworkJobStatusQUEUED, claimed.Status)
+	suite.Equal(now.Add(PaperworkJobRetryDelay), claimed.RunAfter)
+	suite.Equal("storage is down", *claimed.LastError)
+	suite.mustSave(claimed)
+
+	next, err = ClaimPaperworkJob(suite.db, now)
+	suite.Nil(err)
+	suite.Nil(next)
+
+	later := now.Add(PaperworkJobRetryDelay)
+	claimed, err = ClaimPaperworkJob(suite.db, later)
+	suite.Nil(err)
+	suite.NotNil(claimed)
+	suite.Equal(2, claimed.Attempts)
+
+	// The delay doubles, until the job runs out of attempts
+	suite.Nil(claimed.Fail(errors.New("storage is down"), later))
+	suite.Equal(later.Add(2*PaperworkJobRetryDelay), claimed.RunAfter)
+	claimed.Attempts = claimed.MaxAttempts
+	claimed.Status = PaperworkJobStatusRUNNING
+	suite.Nil(claimed.Fail(errors.New("storage is down"), later))
+	suite.Equal(PaperworkJobStatusFAILED, claimed.Status)
+	suite.NotNil(claimed.CompletedAt)
+
+	// A failed job can be retried by hand
+	suite.Nil(claimed.Retry(later))
+	suite.Equal(PaperworkJobStatusQUEUED, claimed.Status)
+	suite.Equal(claimed.Attempts+PaperworkJobMaxAttempts, claimed.MaxAttempts)
+	suite.Nil(claimed.CompletedAt)
+	suite.Equal(ErrInvalidTransition, errors.Cause(claimed.Retry(later)))
+}
+
+func (suite *ModelSuite) Test_ClaimAbandonedPaperworkJob() {
+	ppm := testdatagen.MakeDefaultPPM(suite.db)
+	job := NewPaperworkJob(PaperworkJobTypePPMATTACHMENTS, ppm.ID, ppm.Move.Orders.ServiceMember.UserID)
+	suite.mustSave(&job)
+
+	now := time.Now()
+	claimed, err := ClaimPaperworkJob(suite.db, now)
+	suite.Nil(err)
+	suite.NotNil(claimed)
+
+	// A job that has been running for too long is run again
+	later := now.Add(PaperworkJobLease + time.Minute)
+	reclaimed, err := ClaimPaperworkJob(suite.db, later)
+	suite.Nil(err)
+	suite.NotNil(reclaimed)
+	suite.Equal(job.ID, reclaimed.ID)
+	suite.Equal(2, reclaimed.Attempts)
+}
+
+func (suite *ModelSuite) Test_SavePaperworkJobResult() {
+	ppm := testdatagen.MakeDefaultPPM(suite.db)
+	job := NewPaperworkJob(PaperworkJobTypePPMATTACHMENTS, ppm.ID, ppm.Move.Orders.ServiceMember.UserID)
+	suite.mustSave(&job)
+
+	now := time.Now()
+	abandoned, err := ClaimPaperworkJob(suite.db, now)
+	suite.Nil(err)
+	suite.NotNil(abandoned)
+
+	later := now.Add(PaperworkJobLease + time.Minute)
+	reclaimed, err := ClaimPaperworkJob(suite.db, later)
+	suite.Nil(err)
+	suite.NotNil(reclaimed)
+
+	// The first run finishes after the job was reclaimed, so its result is dropped
+	suite.Nil(abandoned.Fail(errors.New("took too long"), later))
+	saved, err := SavePaperworkJobResult(suite.db, abandoned, later)
+	suite.Nil(err)
+	suite.False(saved)
+
+	suite.Nil(reclaimed.Fail(errors.New("storage is down"), later))
+	saved, err = SavePaperworkJobResult(suite.db, reclaimed, later)
+	suite.Nil(err)
+	suite.True(saved)
+
+	suite.Nil(suite.db.Find(&job, job.ID))
+	suite.Equal(PaperworkJobStatusQUEUED, job.Status)
+	suite.Equal("storage is down", *job.LastError)
+}
+
+func (suite *ModelSuite) Test_FetchPaperworkJob() {
+	ppm := testdatagen.MakeDefaultPPM(suite.db)
+	sm := ppm.Move.Orders.ServiceMember
+	job := NewPaperworkJob(PaperworkJobTypePPMATTACHMENTS, ppm.ID, sm.UserID)
+	suite.mustSave(&job)
+
+	session := &auth.Session{
+		ApplicationName: auth.MyApp,
+		UserID:          sm.UserID,
+		ServiceMemberID: sm.ID,
+	}
+	fetched, err := FetchPaperworkJob(suite.db, session, job.ID)
+	suite.Nil(err)
+	suite.Equal(job.ID, fetched.ID)
+	suite.Nil(fetched.Upload)
+
+	// Other service members can't see the job
+	other := testdatagen.MakeDefaultServiceMember(suite.db)
+	session = &auth.Session{
+		ApplicationName: auth.MyApp,
+		UserID:          other.UserID,
+		ServiceMemberID: other.ID,
+	}
+	_, err = FetchPaperworkJob(suite.db, session, job.ID)
+	suite.Equal(ErrFetchForbidden, err)
+}
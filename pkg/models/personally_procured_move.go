@@ -184,8 +184,16 @@ func FetchPersonallyProcuredMove(db *pop.Connection, session *auth.Session, id u
 	return &ppm, nil
 }
 
-// SavePersonallyProcuredMove Safely saves a PPM and it's associated Advance.
-func SavePersonallyProcuredMove(db *pop.Connection, ppm *PersonallyProcuredMove) (*validate.Errors, error) {
+// SavePersonallyProcuredMove Safely saves a PPM and it's associated Advance, along with any audit
+// events recording their changes.
+func SavePersonallyProcuredMove(db *pop.Connection, ppm *PersonallyProcuredMove, events ...*AuditEvent) (*validate.Errors, error) {
+	return SavePersonallyProcuredMoveWithSnapshot(db, ppm, nil, events...)
+}
+
+// SavePersonallyProcuredMoveWithSnapshot saves a PPM like SavePersonallyProcuredMove, and creates
+// the incentive snapshot its new estimates were calculated from in the same transaction, so
+// neither is saved without the other.
+func SavePersonallyProcuredMoveWithSnapshot(db *pop.Connection, ppm *PersonallyProcuredMove, snapshot *PPMIncentiveSnapshot, events ...*AuditEvent) (*validate.Errors, error) {
 	responseVErrors := validate.NewErrors()
 	var responseError error
 
@@ -239,6 +247,19 @@ func SavePersonallyProcuredMove(db *pop.Connection, ppm *PersonallyProcuredMove)
 			return transactionError
 		}
 
+		if snapshot != nil {
+			if verrs, err := db.ValidateAndCreate(snapshot); verrs.HasAny() || err != nil {
+				responseVErrors.Append(verrs)
+				responseError = errors.Wrap(err, "Error Saving Incentive Snapshot")
+				return transactionError
+			}
+		}
+
+		if err := CreateAuditEvents(db, events...); err != nil {
+			responseError = err
+			return transactionError
+		}
+
 		return nil
 
 	})
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gobuffalo/validate/validators"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/transcom/mymove/pkg/auth"
+	"github.com/transcom/mymove/pkg/unit"
+)
+
+// PPMIncentiveRate is the share of the government's constructed cost that a member is paid for a PPM
+const PPMIncentiveRate = 0.95
+
+// PPMIncentiveSnapshotSource is what asked for an incentive to be calculated
+type PPMIncentiveSnapshotSource string
+
+const (
+	// PPMIncentiveSnapshotSourceESTIMATE is recorded when a PPM's estimates are updated
+	PPMIncentiveSnapshotSourceESTIMATE PPMIncentiveSnapshotSource = "ESTIMATE"
+	// PPMIncentiveSnapshotSourceOFFICE is recorded when the office calculates an incentive
+	PPMIncentiveSnapshotSourceOFFICE PPMIncentiveSnapshotSource = "OFFICE"
+	// PPMIncentiveSnapshotSourceCLOSEOUT is recorded when a closeout packet is requested
+	PPMIncentiveSnapshotSourceCLOSEOUT PPMIncentiveSnapshotSource = "CLOSEOUT"
+)
+
+// PPMIncentiveSnapshot records an incentive calculation for a PPM along with every rate
+// input it was based on, so that the amount a member was quoted can be explained or
+// reproduced later. Snapshots are never updated; the database rejects any attempt to.
+type PPMIncentiveSnapshot struct {
+	ID                        uuid.UUID                  `json:"id" db:"id"`
+	CreatedAt                 time.Time                  `json:"created_at" db:"created_at"`
+	PersonallyProcuredMoveID  uuid.UUID                  `json:"personally_procured_move_id" db:"personally_procured_move_id"`
+	RequestedByUserID         uuid.UUID                  `json:"requested_by_user_id" db:"requested_by_user_id"`
+	Source                    PPMIncentiveSnapshotSource `json:"source" db:"source"`
+	Weight                    unit.Pound                 `json:"weight" db:"weight"`
+	ProrateFactor             float64                    `json:"prorate_factor" db:"prorate_factor"`
+	OriginZip                 string                     `json:"origin_zip" db:"origin_zip"`
+	DestinationZip            string                     `json:"destination_zip" db:"destination_zip"`
+	OriginZip3                string                     `json:"origin_zip3" db:"origin_zip3"`
+	DestinationZip3           string                     `json:"destination_zip3" db:"destination_zip3"`
+	OriginServiceArea         string                     `json:"origin_service_area" db:"origin_service_area"`
+	DestinationServiceArea    string                     `json:"destination_service_area" db:"destination_service_area"`
+	PlannedMoveDate           time.Time                  `json:"planned_move_date" db:"planned_move_date"`
+	DaysInSIT                 int                        `json:"days_in_sit" db:"days_in_sit"`
+	DiscountCodeOfService     string                     `json:"discount_code_of_service" db:"discount_code_of_service"`
+	LinehaulDiscount          unit.DiscountRate          `json:"linehaul_discount" db:"linehaul_discount"`
+	SITDiscount               unit.DiscountRate          `json:"sit_discount" db:"sit_discount"`
+	Mileage                   int                        `json:"mileage" db:"mileage"`
+	BaseLinehaul              unit.Cents                 `json:"base_linehaul" db:"base_linehaul"`
+	OriginLinehaulFactor      unit.Cents                 `json:"origin_linehaul_factor" db:"origin_linehaul_factor"`
+	DestinationLinehaulFactor unit.Cents                 `json:"destination_linehaul_factor" db:"destination_linehaul_factor"`
+	ShorthaulCharge           unit.Cents                 `json:"shorthaul_charge" db:"shorthaul_charge"`
+	LinehaulChargeTotal       unit.Cents                 `json:"linehaul_charge_total" db:"linehaul_charge_total"`
+	OriginServiceFee          unit.Cents                 `json:"origin_service_fee" db:"origin_service_fee"`
+	DestinationServiceFee     unit.Cents                 `json:"destination_service_fee" db:"destination_service_fee"`
+	PackFee                   unit.Cents                 `json:"pack_fee" db:"pack_fee"`
+	UnpackFee                 unit.Cents                 `json:"unpack_fee" db:"unpack_fee"`
+	SITFee                    unit.Cents                 `json:"sit_fee" db:"sit_fee"`
+	SITMax                    unit.Cents                 `json:"sit_max" db:"sit_max"`
+	GCC                       unit.Cents                 `json:"gcc" db:"gcc"`
+	IncentiveRate             float64                    `json:"incentive_rate" db:"incentive_rate"`
+	Incentive                 unit.Cents                 `json:"incentive" db:"incentive"`
+}
+
+// PPMIncentiveSnapshots is a list of incentive snapshots
+type PPMIncentiveSnapshots []PPMIncentiveSnapshot
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+func (s *PPMIncentiveSnapshot) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.UUIDIsPresent{Field: s.PersonallyProcuredMoveID, Name: "PersonallyProcuredMoveID"},
+		&validators.UUIDIsPresent{Field: s.RequestedByUserID, Name: "RequestedByUserID"},
+		&validators.StringIsPresent{Field: string(s.Source), Name: "Source"},
+		&validators.StringIsPresent{Field: s.OriginZip, Name: "OriginZip"},
+		&validators.StringIsPresent{Field: s.DestinationZip, Name: "DestinationZip"},
+		&validators.StringIsPresent{Field: s.DiscountCodeOfService, Name: "DiscountCodeOfService"},
+		&validators.TimeIsPresent{Field: s.PlannedMoveDate, Name: "PlannedMoveDate"},
+		&validators.IntIsGreaterThan{Field: s.Weight.Int(), Name: "Weight", Compared: 0},
+		&DiscountRateIsValid{Field: s.LinehaulDiscount, Name: "LinehaulDiscount"},
+		&DiscountRateIsValid{Field: s.SITDiscount, Name: "SITDiscount"},
+	), nil
+}
+
+// FetchPPMIncentiveSnapshots returns the incentive snapshots for a PPM, newest first
+func FetchPPMIncentiveSnapshots(db *pop.Connection, session *auth.Session, ppmID uuid.UUID) (PPMIncentiveSnapshots, error) {
+	// Fetching the PPM checks that the session is allowed to see it
+	if _, err := FetchPersonallyProcuredMove(db, session, ppmID); err != nil {
+		return nil, err
+	}
+
+	var snapshots PPMIncentiveSnapshots
+	err := db.Where("personally_procured_move_id = ?", ppmID).Order("created_at desc").All(&snapshots)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error fetching PPM incentive snapshots")
+	}
+	return snapshots, nil
+}
@@ -0,0 +1,109 @@
+package models_test
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/transcom/mymove/pkg/auth"
+	. "github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/testdatagen"
+	"github.com/transcom/mymove/pkg/unit"
+)
+
+func (suite *ModelSuite) Test_PPMIncentiveSnapshotValidations() {
+	snapshot := &PPMIncentiveSnapshot{
+		LinehaulDiscount: unit.DiscountRate(1.5),
+	}
+
+	expErrors := map[string][]string{
+		"personally_procured_move_id": {"PersonallyProcuredMoveID can not be blank."},
+		"requested_by_user_id":        {"RequestedByUserID can not be blank."},
+		"source":                      {"Source can not be blank."},
+		"origin_zip":                  {"OriginZip can not be blank."},
+		"destination_zip":             {"DestinationZip can not be blank."},
+		"discount_code_of_service":    {"DiscountCodeOfService can not be blank."},
+		"planned_move_date":           {"PlannedMoveDate can not be blank."},
+		"weight":                      {"0 is not greater than 0."},
+		"linehaul_discount":           {"LinehaulDiscount must be between 0.0 and 1.0, got 1.500000"},
+	}
+
+	suite.verifyValidationErrors(snapshot, expErrors)
+}
+
+func (suite *ModelSuite) Test_FetchPPMIncentiveSnapshots() {
+	ppm := testdatagen.MakeDefaultPPM(suite.db)
+	sm := ppm.Move.Orders.ServiceMember
+
+	first := PPMIncentiveSnapshot{
+		PersonallyProcuredMoveID: ppm.ID,
+		RequestedByUserID:        sm.UserID,
+		Source:                   PPMIncentiveSnapshotSourceESTIMATE,
+		Weight:                   unit.Pound(7500),
+		ProrateFactor:            1,
+		OriginZip:                "94540",
+		DestinationZip:           "78626",
+		OriginZip3:               "945",
+		DestinationZip3:          "786",
+		OriginServiceArea:        "80",
+		DestinationServiceArea:   "748",
+		PlannedMoveDate:          testdatagen.RateEngineDate,
+		DiscountCodeOfService:    "D",
+		LinehaulDiscount:         unit.DiscountRate(.4),
+		SITDiscount:              unit.DiscountRate(.3),
+		Mileage:                  1693,
+		GCC:                      unit.Cents(637056),
+		IncentiveRate:            PPMIncentiveRate,
+		Incentive:                unit.Cents(605203),
+	}
+	suite.mustSave(&first)
+	second := first
+	second.ID = uuid.Nil
+	second.CreatedAt = time.Time{}
+	second.Source = PPMIncentiveSnapshotSourceOFFICE
+	suite.mustSave(&second)
+
+	session := &auth.Session{
+		ApplicationName: auth.MyApp,
+		UserID:          sm.UserID,
+		ServiceMemberID: sm.ID,
+	}
+	snapshots, err := FetchPPMIncentiveSnapshots(suite.db, session, ppm.ID)
+	suite.Nil(err)
+	suite.Len(snapshots, 2)
+	suite.Equal(second.ID, snapshots[0].ID)
+	suite.Equal(unit.DiscountRate(.4), snapshots[0].LinehaulDiscount)
+	suite.Equal("D", snapshots[0].DiscountCodeOfService)
+
+	// Snapshots can't be changed once they are saved
+	first.Incentive = unit.Cents(1)
+	suite.NotNil(suite.db.Update(&first))
+
+	// Other service members can't see the snapshots
+	other := testdatagen.MakeDefaultServiceMember(suite.db)
+	session = &auth.Session{
+		ApplicationName: auth.MyApp,
+		UserID:          other.UserID,
+		ServiceMemberID: other.ID,
+	}
+	_, err = FetchPPMIncentiveSnapshots(suite.db, session, ppm.ID)
+	suite.Equal(ErrFetchForbidden, err)
+}
+
+func (suite *ModelSuite) Test_SavePersonallyProcuredMoveWithSnapshot() {
+	ppm := testdatagen.MakeDefaultPPM(suite.db)
+	weight := int64(9000)
+	ppm.WeightEstimate = &weight
+
+	// A snapshot that can't be saved rolls back the PPM's new estimates with it
+	verrs, err := SavePersonallyProcuredMoveWithSnapshot(suite.db, &ppm, &PPMIncentiveSnapshot{PersonallyProcuredMoveID: ppm.ID})
+	suite.True(verrs.HasAny())
+	suite.Nil(err)
+
+	var saved PersonallyProcuredMove
+	suite.NoError(suite.db.Find(&saved, ppm.ID))
+	suite.NotEqual(weight, *saved.WeightEstimate)
+	count, err := suite.db.Where("personally_procured_move_id = ?", ppm.ID).Count(&PPMIncentiveSnapshot{})
+	suite.NoError(err)
+	suite.Equal(0, count)
+}
@@ -8,7 +8,8 @@ import (
 	"github.com/transcom/mymove/pkg/gen/internalmessages"
 )
 
-// MoveQueueItem represents a single move queue item within a queue.
+// MoveQueueItem represents a single move queue item within a queue. LastModifiedName and
+// LastModifiedDate are who made the latest change in the move's audit trail, and when.
 type MoveQueueItem struct {
 	ID               uuid.UUID                           `json:"id" db:"id"`
 	CreatedAt        time.Time                           `json:"created_at" db:"created_at"`
@@ -26,6 +27,18 @@ type MoveQueueItem struct {
 	LastModifiedName string                              `json:"last_modified_name" db:"last_modified_name"`
 }
 
+// lastModifiedColumns selects who made the latest change in a move's audit trail, and when.
+// Moves that have no audit events yet fall back to when the move was last updated.
+const lastModifiedColumns = `COALESCE(last_event.created_at, moves.updated_at) as last_modified_date,
+				COALESCE(last_event.actor_name, '') as last_modified_name,`
+
+// lastEventJoin joins the latest event in each move's audit trail as last_event
+const lastEventJoin = `LEFT JOIN LATERAL (
+				SELECT actor_name, created_at FROM audit_events
+				WHERE audit_events.move_id = moves.id
+				ORDER BY audit_events.created_at DESC LIMIT 1
+			) AS last_event ON true`
+
 // GetMoveQueueItems gets all moveQueueItems for a specific lifecycleState
 func GetMoveQueueItems(db *pop.Connection, lifecycleState string) ([]MoveQueueItem, error) {
 	var moveQueueItems []MoveQueueItem
@@ -41,13 +54,14 @@ func GetMoveQueueItems(db *pop.Connection, lifecycleState string) ([]MoveQueueIt
 				ord.orders_type as orders_type,
 				ppm.planned_move_date as move_date,
 				moves.created_at as created_at,
-				moves.updated_at as last_modified_date,
+				` + lastModifiedColumns + `
 				moves.status as status,
 				ppm.status as ppm_status
 			FROM moves
 			JOIN orders as ord ON moves.orders_id = ord.id
 			JOIN service_members AS sm ON ord.service_member_id = sm.id
 			LEFT JOIN personally_procured_moves AS ppm ON moves.id = ppm.move_id
+			` + lastEventJoin + `
 			WHERE moves.status = 'SUBMITTED'
 		`
 	} else if lifecycleState == "ppm" {
@@ -60,13 +74,14 @@ func GetMoveQueueItems(db *pop.Connection, lifecycleState string) ([]MoveQueueIt
 				ord.orders_type as orders_type,
 				ppm.planned_move_date as move_date,
 				moves.created_at as created_at,
-				moves.updated_at as last_modified_date,
+				` + lastModifiedColumns + `
 				moves.status as status,
 				ppm.status as ppm_status
 			FROM moves
 			JOIN orders as ord ON moves.orders_id = ord.id
 			JOIN service_members AS sm ON ord.service_member_id = sm.id
 			JOIN personally_procured_moves AS ppm ON moves.id = ppm.move_id
+			` + lastEventJoin + `
 			WHERE moves.status = 'APPROVED'
 		`
 	} else if lifecycleState == "hhg_accepted" {
@@ -81,13 +96,14 @@ func GetMoveQueueItems(db *pop.Connection, lifecycleState string) ([]MoveQueueIt
 				ord.orders_type as orders_type,
 				shipment.requested_pickup_date as move_date,
 				moves.created_at as created_at,
-				moves.updated_at as last_modified_date,
+				` + lastModifiedColumns + `
 				moves.status as status,
 				shipment.status as hhg_status
 			FROM moves
 			JOIN orders as ord ON moves.orders_id = ord.id
 			JOIN service_members AS sm ON ord.service_member_id = sm.id
 			LEFT JOIN shipments as shipment ON moves.id = shipment.move_id
+			` + lastEventJoin + `
 			WHERE shipment.status = 'ACCEPTED'
 		`
 	} else if lifecycleState == "hhg_in_transit" {
@@ -101,13 +117,14 @@ func GetMoveQueueItems(db *pop.Connection, lifecycleState string) ([]MoveQueueIt
 				ord.orders_type as orders_type,
 				shipment.actual_pickup_date as move_date,
 				moves.created_at as created_at,
-				moves.updated_at as last_modified_date,
+				` + lastModifiedColumns + `
 				moves.status as status,
 				shipment.status as hhg_status
 			FROM moves
 			JOIN orders as ord ON moves.orders_id = ord.id
 			JOIN service_members AS sm ON ord.service_member_id = sm.id
 			LEFT JOIN shipments as shipment ON moves.id = shipment.move_id
+			` + lastEventJoin + `
 			WHERE shipment.status = 'IN_TRANSIT'
 		`
 	} else if lifecycleState == "hhg_delivered" {
@@ -121,13 +138,14 @@ func GetMoveQueueItems(db *pop.Connection, lifecycleState string) ([]MoveQueueIt
 				ord.orders_type as orders_type,
 				shipment.actual_pickup_date as move_date,
 				moves.created_at as created_at,
-				moves.updated_at as last_modified_date,
+				` + lastModifiedColumns + `
 				moves.status as status,
 				shipment.status as hhg_status
 			FROM moves
 			JOIN orders as ord ON moves.orders_id = ord.id
 			JOIN service_members AS sm ON ord.service_member_id = sm.id
 			LEFT JOIN shipments as shipment ON moves.id = shipment.move_id
+			` + lastEventJoin + `
 			WHERE shipment.status = 'DELIVERED'
 		`
 	} else if lifecycleState == "hhg_completed" {
@@ -141,13 +159,14 @@ func GetMoveQueueItems(db *pop.Connection, lifecycleState string) ([]MoveQueueIt
 				ord.orders_type as orders_type,
 				shipment.actual_pickup_date as move_date,
 				moves.created_at as created_at,
-				moves.updated_at as last_modified_date,
+				` + lastModifiedColumns + `
 				moves.status as status,
 				shipment.status as hhg_status
 			FROM moves
 			JOIN orders as ord ON moves.orders_id = ord.id
 			JOIN service_members AS sm ON ord.service_member_id = sm.id
 			LEFT JOIN shipments as shipment ON moves.id = shipment.move_id
+			` + lastEventJoin + `
 			WHERE shipment.status = 'COMPLETED'
 		`
 	} else if lifecycleState == "all" {
@@ -160,13 +179,14 @@ func GetMoveQueueItems(db *pop.Connection, lifecycleState string) ([]MoveQueueIt
 				ord.orders_type as orders_type,
 				ppm.planned_move_date as move_date,
 				moves.created_at as created_at,
-				moves.updated_at as last_modified_date,
+				` + lastModifiedColumns + `
 				moves.status as status,
 				ppm.status as ppm_status
 			FROM moves
 			JOIN orders as ord ON moves.orders_id = ord.id
 			JOIN service_members AS sm ON ord.service_member_id = sm.id
 			LEFT JOIN personally_procured_moves AS ppm ON moves.id = ppm.move_id
+			` + lastEventJoin + `
 		`
 	}
 
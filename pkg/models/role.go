@@ -0,0 +1,151 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/transcom/mymove/pkg/auth"
+)
+
+// RoleName is the unique name of a role
+type RoleName string
+
+const (
+	// RoleNamePPMOfficeApprover approves moves, PPMs and reimbursements
+	RoleNamePPMOfficeApprover RoleName = "ppm_office_approver"
+	// RoleNameHHGOfficeApprover approves, completes and invoices HHG shipments
+	RoleNameHHGOfficeApprover RoleName = "hhg_office_approver"
+	// RoleNameTSPDispatcher accepts and moves the shipments awarded to a TSP
+	RoleNameTSPDispatcher RoleName = "tsp_dispatcher"
+	// RoleNameTSPAdmin dispatches shipments and manages the service agents of a TSP
+	RoleNameTSPAdmin RoleName = "tsp_admin"
+	// RoleNameAuditor views moves and shipments without changing them
+	RoleNameAuditor RoleName = "auditor"
+)
+
+// DefaultOfficeRoles are granted to office users when they first log in
+var DefaultOfficeRoles = []RoleName{RoleNamePPMOfficeApprover, RoleNameHHGOfficeApprover}
+
+// DefaultTspRoles are granted to TSP users when they first log in
+var DefaultTspRoles = []RoleName{RoleNameTSPAdmin}
+
+// AccessRole is a named set of permissions that can be granted to users. Role is already the
+// type of a service agent.
+type AccessRole struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        RoleName  `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TableName overrides the table name pop would infer from the type name
+func (r AccessRole) TableName() string {
+	return "roles"
+}
+
+// AccessRoles is not required by pop and may be deleted
+type AccessRoles []AccessRole
+
+// FetchRoleByName returns the role with a name. It returns ErrFetchNotFound if there is none.
+func FetchRoleByName(db *pop.Connection, name RoleName) (AccessRole, error) {
+	var role AccessRole
+	err := db.Where("name = $1", name).First(&role)
+	if err != nil {
+		if errors.Cause(err).Error() == recordNotFoundErrorString {
+			return role, ErrFetchNotFound
+		}
+		return role, errors.Wrap(err, "Error while fetching role")
+	}
+	return role, nil
+}
+
+// FetchRolesForUser returns the roles granted to a user, by name
+func FetchRolesForUser(db *pop.Connection, userID uuid.UUID) (AccessRoles, error) {
+	var roles AccessRoles
+	sql := `SELECT roles.* FROM roles
+		JOIN users_roles ON users_roles.role_id = roles.id
+		WHERE users_roles.user_id = $1
+		ORDER BY roles.name`
+
+	err := db.RawQuery(sql, userID).All(&roles)
+	if err != nil {
+		return roles, errors.Wrap(err, "Error while fetching roles for user")
+	}
+	return roles, nil
+}
+
+// FetchPermissionsForUser returns every permission granted to a user by their roles
+func FetchPermissionsForUser(db *pop.Connection, userID uuid.UUID) ([]auth.Permission, error) {
+	var rows []struct {
+		Permission auth.Permission `db:"permission"`
+	}
+	sql := `SELECT DISTINCT role_permissions.permission FROM role_permissions
+		JOIN users_roles ON users_roles.role_id = role_permissions.role_id
+		WHERE users_roles.user_id = $1
+		ORDER BY role_permissions.permission`
+
+	err := db.RawQuery(sql, userID).All(&rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while fetching permissions for user")
+	}
+	permissions := make([]auth.Permission, len(rows))
+	for i, row := range rows {
+		permissions[i] = row.Permission
+	}
+	return permissions, nil
+}
+
+// AddPermissionsToRole grants permissions to everyone with a role. Permissions the role
+// already has are ignored.
+func AddPermissionsToRole(db *pop.Connection, roleID uuid.UUID, permissions ...auth.Permission) error {
+	sql := `INSERT INTO role_permissions (role_id, permission, created_at)
+			VALUES ($1, $2, now())
+		ON CONFLICT DO NOTHING`
+
+	for _, permission := range permissions {
+		err := db.RawQuery(sql, roleID, permission).Exec()
+		if err != nil {
+			return errors.Wrap(err, "Error while adding permission to role")
+		}
+	}
+	return nil
+}
+
+// GrantRoles grants roles to a user. Roles the user already has are ignored. It returns
+// ErrFetchNotFound if any of the roles doesn't exist.
+func GrantRoles(db *pop.Connection, userID uuid.UUID, names ...RoleName) error {
+	sql := `INSERT INTO users_roles (user_id, role_id, created_at)
+			VALUES ($1, $2, now())
+		ON CONFLICT DO NOTHING`
+
+	for _, name := range names {
+		role, err := FetchRoleByName(db, name)
+		if err != nil {
+			return err
+		}
+		err = db.RawQuery(sql, userID, role.ID).Exec()
+		if err != nil {
+			return errors.Wrap(err, "Error while granting role")
+		}
+	}
+	return nil
+}
+
+// RevokeRoles removes roles from a user. Roles the user doesn't have are ignored.
+func RevokeRoles(db *pop.Connection, userID uuid.UUID, names ...RoleName) error {
+	sql := `DELETE FROM users_roles
+		USING roles
+		WHERE users_roles.role_id = roles.id AND users_roles.user_id = $1 AND roles.name = $2`
+
+	for _, name := range names {
+		err := db.RawQuery(sql, userID, name).Exec()
+		if err != nil {
+			return errors.Wrap(err, "Error while revoking role")
+		}
+	}
+	return nil
+}
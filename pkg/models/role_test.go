@@ -0,0 +1,51 @@
+package models_test
+
+import (
+	"github.com/transcom/mymove/pkg/auth"
+	. "github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/testdatagen"
+)
+
+func (suite *ModelSuite) Test_RolePermissions() {
+	user := testdatagen.MakeDefaultUser(suite.db)
+	testdatagen.MakeRole(suite.db, RoleNamePPMOfficeApprover)
+	testdatagen.MakeRole(suite.db, RoleNameAuditor)
+
+	// A user without roles has no permissions
+	permissions, err := FetchPermissionsForUser(suite.db, user.ID)
+	suite.NoError(err)
+	suite.Empty(permissions)
+
+	// Permissions shared by roles are only listed once
+	err = GrantRoles(suite.db, user.ID, RoleNamePPMOfficeApprover, RoleNameAuditor)
+	suite.NoError(err)
+	err = GrantRoles(suite.db, user.ID, RoleNameAuditor)
+	suite.NoError(err)
+	permissions, err = FetchPermissionsForUser(suite.db, user.ID)
+	suite.NoError(err)
+	suite.Equal([]auth.Permission{
+		auth.PermissionViewMoveQueues,
+		auth.PermissionViewMoves,
+		auth.PermissionApprovePPMs,
+		auth.PermissionViewShipments,
+	}, permissions)
+
+	roles, err := FetchRolesForUser(suite.db, user.ID)
+	suite.NoError(err)
+	suite.Len(roles, 2)
+	suite.Equal(RoleNameAuditor, roles[0].Name)
+
+	err = RevokeRoles(suite.db, user.ID, RoleNamePPMOfficeApprover)
+	suite.NoError(err)
+	permissions, err = FetchPermissionsForUser(suite.db, user.ID)
+	suite.NoError(err)
+	suite.Equal([]auth.Permission{
+		auth.PermissionViewMoveQueues,
+		auth.PermissionViewMoves,
+		auth.PermissionViewShipments,
+	}, permissions)
+
+	// Roles must exist to be granted
+	err = GrantRoles(suite.db, user.ID, RoleName("superuser"))
+	suite.Equal(ErrFetchNotFound, err)
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/pkg/errors"
+)
+
+// RouteDistance is a distance that a route planner found between two places, cached so that
+// mapping services aren't asked for the same route again until it expires. Places are named
+// by a key normalized from a zip code or address, and kind says which.
+type RouteDistance struct {
+	Kind           string    `db:"kind"`
+	SourceKey      string    `db:"source_key"`
+	DestinationKey string    `db:"destination_key"`
+	Miles          int       `db:"miles"`
+	Provider       string    `db:"provider"`
+	ExpiresAt      time.Time `db:"expires_at"`
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+// FetchRouteDistance returns the cached distance between two places, unless it has expired
+// as of now. It returns ErrFetchNotFound if there is none.
+func FetchRouteDistance(db *pop.Connection, kind string, sourceKey string, destinationKey string, now time.Time) (RouteDistance, error) {
+	var distance RouteDistance
+	sql := `SELECT * FROM route_distances
+		WHERE kind = $1 AND source_key = $2 AND destination_key = $3 AND expires_at > $4`
+
+	err := db.RawQuery(sql, kind, sourceKey, destinationKey, now).First(&distance)
+	if err != nil {
+		if errors.Cause(err).Error() == recordNotFoundErrorString {
+			return distance, ErrFetchNotFound
+		}
+		return distance, errors.Wrap(err, "Error while fetching route distance")
+	}
+	return distance, nil
+}
+
+// SaveRouteDistance caches a distance, replacing any distance already cached between the
+// same places
+func SaveRouteDistance(db *pop.Connection, distance RouteDistance) error {
+	sql := `INSERT INTO route_distances
+			(kind, source_key, destination_key, miles, provider, expires_at, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, now(), now())
+		ON CONFLICT (kind, source_key, destination_key)
+		DO
+			UPDATE
+				SET miles = $4, provider = $5, expires_at = $6, updated_at = now()
+	`
+
+	err := db.RawQuery(sql, distance.Kind, distance.SourceKey, distance.DestinationKey,
+		distance.Miles, distance.Provider, distance.ExpiresAt).Exec()
+	if err != nil {
+		return errors.Wrap(err, "Error while saving route distance")
+	}
+	return nil
+}
+
+// DeleteExpiredRouteDistances removes distances that expired before now, and returns how many
+// were removed
+func DeleteExpiredRouteDistances(db *pop.Connection, now time.Time) (int, error) {
+	var count int
+	sql := `WITH deleted AS (DELETE FROM route_distances WHERE expires_at <= $1 RETURNING 1)
+		SELECT COUNT(*) FROM deleted`
+
+	err := db.RawQuery(sql, now).First(&count)
+	if err != nil {
+		return 0, errors.Wrap(err, "Error while deleting expired route distances")
+	}
+	return count, nil
+}
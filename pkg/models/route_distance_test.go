@@ -0,0 +1,52 @@
+package models_test
+
+import (
+	"time"
+
+	. "github.com/transcom/mymove/pkg/models"
+)
+
+func (suite *ModelSuite) Test_RouteDistanceCache() {
+	now := time.Date(2018, time.November, 9, 12, 0, 0, 0, time.UTC)
+	distance := RouteDistance{
+		Kind:           "zip5",
+		SourceKey:      "94103",
+		DestinationKey: "20301",
+		Miles:          2832,
+		Provider:       "here",
+		ExpiresAt:      now.AddDate(0, 0, 30),
+	}
+
+	_, err := FetchRouteDistance(suite.db, "zip5", "94103", "20301", now)
+	suite.Equal(ErrFetchNotFound, err)
+
+	suite.NoError(SaveRouteDistance(suite.db, distance))
+	cached, err := FetchRouteDistance(suite.db, "zip5", "94103", "20301", now)
+	suite.NoError(err)
+	suite.Equal(2832, cached.Miles)
+	suite.Equal("here", cached.Provider)
+
+	// Distances are cached in one direction
+	_, err = FetchRouteDistance(suite.db, "zip5", "20301", "94103", now)
+	suite.Equal(ErrFetchNotFound, err)
+
+	// Saving again replaces the cached distance
+	distance.Miles = 2900
+	distance.Provider = "offline"
+	suite.NoError(SaveRouteDistance(suite.db, distance))
+	cached, err = FetchRouteDistance(suite.db, "zip5", "94103", "20301", now)
+	suite.NoError(err)
+	suite.Equal(2900, cached.Miles)
+	suite.Equal("offline", cached.Provider)
+
+	// Expired distances aren't returned, and can be cleaned up
+	expired := now.AddDate(0, 0, 31)
+	_, err = FetchRouteDistance(suite.db, "zip5", "94103", "20301", expired)
+	suite.Equal(ErrFetchNotFound, err)
+	count, err := DeleteExpiredRouteDistances(suite.db, now)
+	suite.NoError(err)
+	suite.Equal(0, count)
+	count, err = DeleteExpiredRouteDistances(suite.db, expired)
+	suite.NoError(err)
+	suite.Equal(1, count)
+}
@@ -94,6 +94,17 @@ func FetchServiceAgentsByTSP(tx *pop.Connection, tspID uuid.UUID, shipmentID uui
 	return serviceAgents, err
 }
 
+// FetchServiceAgentsOnShipment looks up all service agents assigned to a shipment
+func FetchServiceAgentsOnShipment(tx *pop.Connection, shipmentID uuid.UUID) (ServiceAgents, error) {
+	var serviceAgents ServiceAgents
+	err := tx.Where("shipment_id = $1", shipmentID).Order("created_at desc").All(&serviceAgents)
+	if err != nil {
+		return nil, err
+	}
+
+	return serviceAgents, nil
+}
+
 // FetchServiceAgentByTSP looks up all service agents beloning to a TSP and a shipment
 func FetchServiceAgentByTSP(tx *pop.Connection, tspID uuid.UUID, shipmentID uuid.UUID, serviceAgentID uuid.UUID) (*ServiceAgent, error) {
 
@@ -67,6 +67,7 @@ type Shipment struct {
 	RequestedPickupDate  *time.Time `json:"requested_pickup_date" db:"requested_pickup_date"`   // when shipment was originally scheduled to be picked up
 	OriginalDeliveryDate *time.Time `json:"original_delivery_date" db:"original_delivery_date"` // when shipment is to be delivered
 	OriginalPackDate     *time.Time `json:"original_pack_date" db:"original_pack_date"`         // when packing is to begin
+	RatedAt              *time.Time `json:"rated_at" db:"rated_at"`                             // the version of the tariff the shipment is rated against
 
 	// calculated durations
 	EstimatedPackDays    *int64 `json:"estimated_pack_days" db:"estimated_pack_days"`       // how many days it will take to pack
@@ -356,6 +357,28 @@ func (s *Shipment) AssignGBLNumber(db *pop.Connection) error {
 	return nil
 }
 
+// MarkRated fixes the version of the tariff tables the shipment is rated against, the first
+// time it is invoiced, so that rating it again gives the same numbers after the tables are updated.
+func (s *Shipment) MarkRated(db *pop.Connection, at time.Time) error {
+	if s.RatedAt != nil {
+		return nil
+	}
+
+	var ratedAt time.Time
+	sql := `UPDATE shipments
+			SET rated_at = COALESCE(rated_at, $1)
+			WHERE id = $2
+		RETURNING rated_at
+	`
+	err := db.RawQuery(sql, at, s.ID).First(&ratedAt)
+	if err != nil {
+		return errors.Wrap(err, "Error while marking the shipment rated")
+	}
+
+	s.RatedAt = &ratedAt
+	return nil
+}
+
 // FetchUnofferedShipments will return submitted shipments that do not already have a shipment offer.
 func FetchUnofferedShipments(db *pop.Connection) (Shipments, error) {
 	var shipments Shipments
@@ -523,8 +546,8 @@ func FetchShipmentForVerifiedTSPUser(db *pop.Connection, tspUserID uuid.UUID, sh
 
 }
 
-// saveShipmentAndOffer Validates and updates the Shipment and Shipment Offer
-func saveShipmentAndOffer(db *pop.Connection, shipment *Shipment, offer *ShipmentOffer) (*Shipment, *ShipmentOffer, *validate.Errors, error) {
+// saveShipmentAndOffer Validates and updates the Shipment and Shipment Offer, and creates the audit events recording the change
+func saveShipmentAndOffer(db *pop.Connection, shipment *Shipment, offer *ShipmentOffer, events ...*AuditEvent) (*Shipment, *ShipmentOffer, *validate.Errors, error) {
 	// wrapped in a transaction because if one fails this actions should roll back.
 	responseVErrors := validate.NewErrors()
 	var responseError error
@@ -543,19 +566,25 @@ func saveShipmentAndOffer(db *pop.Connection, shipment *Shipment, offer *Shipmen
 			return transactionError
 		}
 
+		if err := CreateAuditEvents(db, events...); err != nil {
+			responseError = err
+			return transactionError
+		}
+
 		return nil
 	})
 
 	return shipment, offer, responseVErrors, responseError
 }
 
-// AwardShipment sets the shipment as awarded.
+// AwardShipment sets the shipment as awarded. Awards are made by the system, so are audited as such.
 func AwardShipment(db *pop.Connection, shipmentID uuid.UUID) error {
 	var shipment Shipment
 	if err := db.Find(&shipment, shipmentID); err != nil {
 		return err
 	}
 
+	oldStatus := shipment.Status
 	if err := shipment.Award(); err != nil {
 		return err
 	}
@@ -567,11 +596,14 @@ func AwardShipment(db *pop.Connection, shipmentID uuid.UUID) error {
 		return fmt.Errorf("Validation failure: %s", verrs)
 	}
 
-	return nil
+	// The award queue runs in a transaction, so this is saved along with the award
+	event := NewAuditEvent(nil, AuditEntityTypeSHIPMENT, shipment.ID, &shipment.MoveID, "award").
+		Change("status", oldStatus, shipment.Status)
+	return CreateAuditEvents(db, event)
 }
 
-// AcceptShipmentForTSP accepts a shipment and shipment_offer
-func AcceptShipmentForTSP(db *pop.Connection, tspID uuid.UUID, shipmentID uuid.UUID) (*Shipment, *ShipmentOffer, *validate.Errors, error) {
+// AcceptShipmentForTSP accepts a shipment and shipment_offer on behalf of the user of a session
+func AcceptShipmentForTSP(db *pop.Connection, session *auth.Session, tspID uuid.UUID, shipmentID uuid.UUID) (*Shipment, *ShipmentOffer, *validate.Errors, error) {
 
 	// Get the Shipment and Shipment Offer
 	shipment, err := FetchShipmentByTSP(db, tspID, shipmentID)
@@ -585,6 +617,7 @@ func AcceptShipmentForTSP(db *pop.Connection, tspID uuid.UUID, shipmentID uuid.U
 	}
 
 	// Accept the Shipment and Shipment Offer
+	oldStatus := shipment.Status
 	err = shipment.Accept()
 	if err != nil {
 		return shipment, shipmentOffer, nil, err
@@ -595,11 +628,13 @@ func AcceptShipmentForTSP(db *pop.Connection, tspID uuid.UUID, shipmentID uuid.U
 		return shipment, shipmentOffer, nil, err
 	}
 
-	return saveShipmentAndOffer(db, shipment, shipmentOffer)
+	event := NewAuditEvent(session, AuditEntityTypeSHIPMENT, shipment.ID, &shipment.MoveID, "accept").
+		Change("status", oldStatus, shipment.Status)
+	return saveShipmentAndOffer(db, shipment, shipmentOffer, event)
 }
 
-// RejectShipmentForTSP accepts a shipment and shipment_offer
-func RejectShipmentForTSP(db *pop.Connection, tspID uuid.UUID, shipmentID uuid.UUID, rejectionReason string) (*Shipment, *ShipmentOffer, *validate.Errors, error) {
+// RejectShipmentForTSP rejects a shipment and shipment_offer on behalf of the user of a session
+func RejectShipmentForTSP(db *pop.Connection, session *auth.Session, tspID uuid.UUID, shipmentID uuid.UUID, rejectionReason string) (*Shipment, *ShipmentOffer, *validate.Errors, error) {
 
 	// Get the Shipment and Shipment Offer
 	shipment, err := FetchShipmentByTSP(db, tspID, shipmentID)
@@ -613,6 +648,7 @@ func RejectShipmentForTSP(db *pop.Connection, tspID uuid.UUID, shipmentID uuid.U
 	}
 
 	// Move the shipment back to Submitted and Reject the shipment offer.
+	oldStatus := shipment.Status
 	err = shipment.Reject()
 	if err != nil {
 		return shipment, shipmentOffer, nil, err
@@ -623,7 +659,10 @@ func RejectShipmentForTSP(db *pop.Connection, tspID uuid.UUID, shipmentID uuid.U
 		return shipment, shipmentOffer, nil, err
 	}
 
-	return saveShipmentAndOffer(db, shipment, shipmentOffer)
+	event := NewAuditEvent(session, AuditEntityTypeSHIPMENT, shipment.ID, &shipment.MoveID, "reject").
+		Change("status", oldStatus, shipment.Status).
+		Because(rejectionReason)
+	return saveShipmentAndOffer(db, shipment, shipmentOffer, event)
 
 }
 
@@ -26,8 +26,12 @@ type ShipmentOffer struct {
 	AdministrativeShipment                     bool                                     `json:"administrative_shipment" db:"administrative_shipment"`
 	Accepted                                   *bool                                    `json:"accepted" db:"accepted"`
 	RejectionReason                            *string                                  `json:"rejection_reason" db:"rejection_reason"`
+	ExpirationNotifiedAt                       *time.Time                               `json:"expiration_notified_at" db:"expiration_notified_at"`
 }
 
+// ShipmentOfferAcceptanceWindow is how long a TSP has to accept or reject an offer before it expires
+const ShipmentOfferAcceptanceWindow = 48 * time.Hour
+
 // String is not required by pop and may be deleted
 func (so ShipmentOffer) String() string {
 	ja, _ := json.Marshal(so)
@@ -76,6 +80,16 @@ func (so *ShipmentOffer) Reject(rejectionReason string) error {
 	return nil
 }
 
+// ExpiresAt returns the time at which the offer can no longer be accepted by the TSP
+func (so *ShipmentOffer) ExpiresAt() time.Time {
+	return so.CreatedAt.Add(ShipmentOfferAcceptanceWindow)
+}
+
+// MarkExpirationNotified records that the TSP has been warned that the offer is about to expire
+func (so *ShipmentOffer) MarkExpirationNotified(notifiedAt time.Time) {
+	so.ExpirationNotifiedAt = &notifiedAt
+}
+
 // CreateShipmentOffer connects a shipment to a transportation service provider. This
 // function assumes that the match has been validated by the caller.
 func CreateShipmentOffer(tx *pop.Connection,
@@ -115,3 +129,43 @@ func FetchShipmentOfferByTSP(tx *pop.Connection, tspID uuid.UUID, shipmentID uui
 
 	return &shipmentOffers[0], err
 }
+
+// FetchShipmentOffersNearingExpiration returns the offers which have not yet been accepted or rejected,
+// will expire within the given notice period, and whose TSP has not already been warned about it.
+func FetchShipmentOffersNearingExpiration(tx *pop.Connection, now time.Time, notice time.Duration) (ShipmentOffers, error) {
+	var shipmentOffers ShipmentOffers
+
+	// An offer expires ShipmentOfferAcceptanceWindow after it was created, so anything created before
+	// this cutoff will expire within the notice period
+	cutoff := now.Add(notice).Add(-ShipmentOfferAcceptanceWindow)
+	oldest := now.Add(-ShipmentOfferAcceptanceWindow)
+
+	err := tx.
+		Where("accepted IS NULL").
+		Where("expiration_notified_at IS NULL").
+		Where("created_at <= ? AND created_at > ?", cutoff, oldest).
+		All(&shipmentOffers)
+	if err != nil {
+		return nil, err
+	}
+
+	return shipmentOffers, nil
+}
+
+// FetchAcceptedShipmentOffer returns the offer a TSP has accepted for a shipment
+func FetchAcceptedShipmentOffer(tx *pop.Connection, shipmentID uuid.UUID) (*ShipmentOffer, error) {
+	shipmentOffers := []ShipmentOffer{}
+
+	err := tx.
+		Where("shipment_id = $1 AND accepted = true", shipmentID).
+		All(&shipmentOffers)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(shipmentOffers) != 1 {
+		return nil, ErrFetchNotFound
+	}
+
+	return &shipmentOffers[0], nil
+}
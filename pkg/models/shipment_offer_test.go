@@ -74,3 +74,30 @@ func (suite *ModelSuite) TestShipmentOfferStateMachine() {
 	suite.False(*shipmentOffer.Accepted)
 	suite.Equal("DO NOT WANT", *shipmentOffer.RejectionReason)
 }
+
+func (suite *ModelSuite) TestFetchShipmentOffersNearingExpiration() {
+	now := time.Now()
+	notice := 12 * time.Hour
+
+	expiringOffer := testdatagen.MakeDefaultShipmentOffer(suite.db)
+	freshOffer := testdatagen.MakeDefaultShipmentOffer(suite.db)
+	notifiedOffer := testdatagen.MakeDefaultShipmentOffer(suite.db)
+
+	// Backdate the offers so that two of them will expire within the notice period
+	nearlyExpired := now.Add(-ShipmentOfferAcceptanceWindow).Add(time.Hour)
+	err := suite.db.RawQuery("UPDATE shipment_offers SET created_at = $1 WHERE id IN ($2, $3)",
+		nearlyExpired, expiringOffer.ID, notifiedOffer.ID).Exec()
+	suite.Nil(err)
+	err = suite.db.RawQuery("UPDATE shipment_offers SET expiration_notified_at = $1 WHERE id = $2",
+		now, notifiedOffer.ID).Exec()
+	suite.Nil(err)
+
+	offers, err := FetchShipmentOffersNearingExpiration(suite.db, now, notice)
+	suite.Nil(err)
+
+	// The fresh offer has plenty of time left and the TSP was already warned about the other one
+	if suite.Len(offers, 1) {
+		suite.Equal(expiringOffer.ID, offers[0].ID)
+		suite.NotEqual(freshOffer.ID, offers[0].ID)
+	}
+}
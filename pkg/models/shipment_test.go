@@ -3,6 +3,7 @@ package models_test
 import (
 	"time"
 
+	"github.com/transcom/mymove/pkg/auth"
 	. "github.com/transcom/mymove/pkg/models"
 	"github.com/transcom/mymove/pkg/testdatagen"
 	"github.com/transcom/mymove/pkg/unit"
@@ -164,12 +165,26 @@ func (suite *ModelSuite) TestAcceptShipmentForTSP() {
 	suite.Nil(shipmentOffer.Accepted)
 	suite.Nil(shipmentOffer.RejectionReason)
 
-	newShipment, newShipmentOffer, _, err := AcceptShipmentForTSP(suite.db, tspUser.TransportationServiceProviderID, shipment.ID)
+	session := &auth.Session{
+		ApplicationName: auth.TspApp,
+		TspUserID:       tspUser.ID,
+		FirstName:       tspUser.FirstName,
+		LastName:        tspUser.LastName,
+	}
+	newShipment, newShipmentOffer, _, err := AcceptShipmentForTSP(suite.db, session, tspUser.TransportationServiceProviderID, shipment.ID)
 	suite.NoError(err)
 
 	suite.Equal(ShipmentStatusACCEPTED, newShipment.Status, "expected Awarded")
 	suite.True(*newShipmentOffer.Accepted)
 	suite.Nil(newShipmentOffer.RejectionReason)
+
+	timeline, err := FetchMoveTimeline(suite.db, shipment.MoveID)
+	suite.NoError(err)
+	if suite.Len(timeline, 1) {
+		suite.Equal(AuditActorTypeTSPUSER, timeline[0].ActorType)
+		suite.Equal("accept", timeline[0].Event)
+		suite.Equal(string(ShipmentStatusACCEPTED), timeline[0].NewValues["status"])
+	}
 }
 
 // TestShipmentAssignGBLNumber tests that a GBL number is created correctly
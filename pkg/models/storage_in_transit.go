@@ -0,0 +1,267 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gobuffalo/validate/validators"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+)
+
+// StorageInTransitMaxDays is how many days of SIT are authorized before an extension is needed
+const StorageInTransitMaxDays = 90
+
+// StorageInTransitLocation is where a shipment's goods were put into storage
+type StorageInTransitLocation string
+
+const (
+	// StorageInTransitLocationORIGIN captures enum value "ORIGIN"
+	StorageInTransitLocationORIGIN StorageInTransitLocation = "ORIGIN"
+	// StorageInTransitLocationDESTINATION captures enum value "DESTINATION"
+	StorageInTransitLocationDESTINATION StorageInTransitLocation = "DESTINATION"
+)
+
+// StorageInTransitStatus represents the status of a stay in SIT
+type StorageInTransitStatus string
+
+const (
+	// StorageInTransitStatusINSIT captures enum value "IN_SIT"
+	StorageInTransitStatusINSIT StorageInTransitStatus = "IN_SIT"
+	// StorageInTransitStatusRELEASED captures enum value "RELEASED"
+	StorageInTransitStatusRELEASED StorageInTransitStatus = "RELEASED"
+)
+
+// StorageInTransitExtensionStatus represents the status of a request for more days of SIT
+type StorageInTransitExtensionStatus string
+
+const (
+	// StorageInTransitExtensionStatusREQUESTED captures enum value "REQUESTED"
+	StorageInTransitExtensionStatusREQUESTED StorageInTransitExtensionStatus = "REQUESTED"
+	// StorageInTransitExtensionStatusAPPROVED captures enum value "APPROVED"
+	StorageInTransitExtensionStatusAPPROVED StorageInTransitExtensionStatus = "APPROVED"
+	// StorageInTransitExtensionStatusDENIED captures enum value "DENIED"
+	StorageInTransitExtensionStatusDENIED StorageInTransitExtensionStatus = "DENIED"
+)
+
+// StorageInTransit is a stay of a shipment's goods in a warehouse, at origin or destination
+type StorageInTransit struct {
+	ID                 uuid.UUID                  `json:"id" db:"id"`
+	CreatedAt          time.Time                  `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time                  `json:"updated_at" db:"updated_at"`
+	ShipmentID         uuid.UUID                  `json:"shipment_id" db:"shipment_id"`
+	Location           StorageInTransitLocation   `json:"location" db:"location"`
+	Status             StorageInTransitStatus     `json:"status" db:"status"`
+	WarehouseID        string                     `json:"warehouse_id" db:"warehouse_id"`
+	WarehouseName      string                     `json:"warehouse_name" db:"warehouse_name"`
+	WarehouseAddressID uuid.UUID                  `json:"warehouse_address_id" db:"warehouse_address_id"`
+	WarehouseAddress   Address                    `belongs_to:"addresses"`
+	InDate             time.Time                  `json:"in_date" db:"in_date"`
+	OutDate            *time.Time                 `json:"out_date" db:"out_date"`
+	AuthorizedDays     int                        `json:"authorized_days" db:"authorized_days"`
+	Notes              *string                    `json:"notes" db:"notes"`
+	Extensions         StorageInTransitExtensions `has_many:"storage_in_transit_extensions" order_by:"created_at asc"`
+}
+
+// StorageInTransits is a list of stays in SIT
+type StorageInTransits []StorageInTransit
+
+// StorageInTransitExtension is a request for more days of SIT than were authorized, which
+// the office approves or denies
+type StorageInTransitExtension struct {
+	ID                     uuid.UUID                       `json:"id" db:"id"`
+	CreatedAt              time.Time                       `json:"created_at" db:"created_at"`
+	UpdatedAt              time.Time                       `json:"updated_at" db:"updated_at"`
+	StorageInTransitID     uuid.UUID                       `json:"storage_in_transit_id" db:"storage_in_transit_id"`
+	RequestedDays          int                             `json:"requested_days" db:"requested_days"`
+	Reason                 string                          `json:"reason" db:"reason"`
+	Status                 StorageInTransitExtensionStatus `json:"status" db:"status"`
+	RequestedByUserID      uuid.UUID                       `json:"requested_by_user_id" db:"requested_by_user_id"`
+	ReviewedByOfficeUserID *uuid.UUID                      `json:"reviewed_by_office_user_id" db:"reviewed_by_office_user_id"`
+	ReviewedAt             *time.Time                      `json:"reviewed_at" db:"reviewed_at"`
+}
+
+// StorageInTransitExtensions is a list of extension requests
+type StorageInTransitExtensions []StorageInTransitExtension
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+func (s *StorageInTransit) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	validLocations := []string{
+		string(StorageInTransitLocationORIGIN),
+		string(StorageInTransitLocationDESTINATION),
+	}
+	validStatuses := []string{
+		string(StorageInTransitStatusINSIT),
+		string(StorageInTransitStatusRELEASED),
+	}
+
+	verrs := validate.Validate(
+		&validators.UUIDIsPresent{Field: s.ShipmentID, Name: "ShipmentID"},
+		&validators.StringInclusion{Field: string(s.Location), Name: "Location", List: validLocations},
+		&validators.StringInclusion{Field: string(s.Status), Name: "Status", List: validStatuses},
+		&validators.StringIsPresent{Field: s.WarehouseID, Name: "WarehouseID"},
+		&validators.StringIsPresent{Field: s.WarehouseName, Name: "WarehouseName"},
+		&validators.UUIDIsPresent{Field: s.WarehouseAddressID, Name: "WarehouseAddressID"},
+		&validators.TimeIsPresent{Field: s.InDate, Name: "InDate"},
+		&validators.IntIsGreaterThan{Field: s.AuthorizedDays, Name: "AuthorizedDays", Compared: -1},
+	)
+	if s.OutDate != nil {
+		verrs.Append(validate.Validate(&validators.TimeAfterTime{
+			FirstTime: *s.OutDate, FirstName: "OutDate",
+			SecondTime: s.InDate, SecondName: "InDate"}))
+	}
+	return verrs, nil
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+func (e *StorageInTransitExtension) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	validStatuses := []string{
+		string(StorageInTransitExtensionStatusREQUESTED),
+		string(StorageInTransitExtensionStatusAPPROVED),
+		string(StorageInTransitExtensionStatusDENIED),
+	}
+
+	return validate.Validate(
+		&validators.UUIDIsPresent{Field: e.StorageInTransitID, Name: "StorageInTransitID"},
+		&validators.IntIsGreaterThan{Field: e.RequestedDays, Name: "RequestedDays", Compared: 0},
+		&validators.StringIsPresent{Field: e.Reason, Name: "Reason"},
+		&validators.StringInclusion{Field: string(e.Status), Name: "Status", List: validStatuses},
+		&validators.UUIDIsPresent{Field: e.RequestedByUserID, Name: "RequestedByUserID"},
+	), nil
+}
+
+// DaysInStorage returns how many days the goods were in storage by a date. The day the goods
+// went into storage counts and the day they were taken out doesn't, but a stay is always at
+// least a day.
+func (s StorageInTransit) DaysInStorage(through time.Time) int {
+	days := int(through.Sub(s.InDate).Hours() / 24)
+	if days < 1 {
+		return 1
+	}
+	return days
+}
+
+// AllowedDays returns the days of SIT that were authorized, including approved extensions
+func (s StorageInTransit) AllowedDays() int {
+	days := s.AuthorizedDays
+	for _, extension := range s.Extensions {
+		if extension.Status == StorageInTransitExtensionStatusAPPROVED {
+			days += extension.RequestedDays
+		}
+	}
+	return days
+}
+
+// BillableDays returns the days of SIT that can be billed, which are capped at the allowed
+// days. Goods that are still in storage aren't billed until they are released.
+func (s StorageInTransit) BillableDays() int {
+	if s.Status != StorageInTransitStatusRELEASED || s.OutDate == nil {
+		return 0
+	}
+	days := s.DaysInStorage(*s.OutDate)
+	if allowed := s.AllowedDays(); days > allowed {
+		return allowed
+	}
+	return days
+}
+
+// State Machinery
+// Avoid calling StorageInTransit.Status = ... ever. Use these methods to change the state.
+
+// Release records the date the goods were taken out of storage. Must be in SIT.
+func (s *StorageInTransit) Release(outDate time.Time) error {
+	if s.Status != StorageInTransitStatusINSIT {
+		return errors.Wrap(ErrInvalidTransition, "Release")
+	}
+	s.Status = StorageInTransitStatusRELEASED
+	s.OutDate = &outDate
+	return nil
+}
+
+// Approve grants the requested days. Must be requested.
+func (e *StorageInTransitExtension) Approve(officeUserID uuid.UUID, now time.Time) error {
+	if e.Status != StorageInTransitExtensionStatusREQUESTED {
+		return errors.Wrap(ErrInvalidTransition, "Approve")
+	}
+	e.Status = StorageInTransitExtensionStatusAPPROVED
+	e.ReviewedByOfficeUserID = &officeUserID
+	e.ReviewedAt = &now
+	return nil
+}
+
+// Deny refuses the requested days. Must be requested.
+func (e *StorageInTransitExtension) Deny(officeUserID uuid.UUID, now time.Time) error {
+	if e.Status != StorageInTransitExtensionStatusREQUESTED {
+		return errors.Wrap(ErrInvalidTransition, "Deny")
+	}
+	e.Status = StorageInTransitExtensionStatusDENIED
+	e.ReviewedByOfficeUserID = &officeUserID
+	e.ReviewedAt = &now
+	return nil
+}
+
+// CreateStorageInTransit saves a new stay in SIT along with its warehouse address
+func CreateStorageInTransit(db *pop.Connection, sit *StorageInTransit) (*validate.Errors, error) {
+	responseVErrors := validate.NewErrors()
+	var responseError error
+	db.Transaction(func(db *pop.Connection) error {
+		transactionError := errors.New("rollback")
+
+		if verrs, err := db.ValidateAndCreate(&sit.WarehouseAddress); verrs.HasAny() || err != nil {
+			responseVErrors.Append(verrs)
+			responseError = errors.Wrap(err, "Error creating warehouse address")
+			return transactionError
+		}
+		sit.WarehouseAddressID = sit.WarehouseAddress.ID
+
+		if verrs, err := db.ValidateAndCreate(sit); verrs.HasAny() || err != nil {
+			responseVErrors.Append(verrs)
+			responseError = errors.Wrap(err, "Error creating storage in transit")
+			return transactionError
+		}
+
+		return nil
+	})
+
+	return responseVErrors, responseError
+}
+
+// FetchStorageInTransitsByShipmentID returns the stays in SIT for a shipment, oldest first
+func FetchStorageInTransitsByShipmentID(db *pop.Connection, shipmentID uuid.UUID) (StorageInTransits, error) {
+	var sits StorageInTransits
+	err := db.Eager("WarehouseAddress", "Extensions").
+		Where("shipment_id = $1", shipmentID).
+		Order("in_date asc").
+		All(&sits)
+	if err != nil {
+		return sits, errors.Wrap(err, "Storage in transit query failed")
+	}
+	return sits, nil
+}
+
+// FetchStorageInTransitByID returns a stay in SIT
+func FetchStorageInTransitByID(db *pop.Connection, id uuid.UUID) (*StorageInTransit, error) {
+	var sit StorageInTransit
+	err := db.Eager("WarehouseAddress", "Extensions").Find(&sit, id)
+	if err != nil {
+		if errors.Cause(err).Error() == recordNotFoundErrorString {
+			return nil, ErrFetchNotFound
+		}
+		return nil, err
+	}
+	return &sit, nil
+}
+
+// FetchStorageInTransitExtensionByID returns a request for more days of SIT
+func FetchStorageInTransitExtensionByID(db *pop.Connection, id uuid.UUID) (*StorageInTransitExtension, error) {
+	var extension StorageInTransitExtension
+	err := db.Find(&extension, id)
+	if err != nil {
+		if errors.Cause(err).Error() == recordNotFoundErrorString {
+			return nil, ErrFetchNotFound
+		}
+		return nil, err
+	}
+	return &extension, nil
+}
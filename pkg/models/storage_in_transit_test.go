@@ -0,0 +1,141 @@
+package models_test
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/testdatagen"
+)
+
+func (suite *ModelSuite) TestStorageInTransitValidations() {
+	sit := &models.StorageInTransit{}
+
+	expErrors := map[string][]string{
+		"shipment_id":          {"ShipmentID can not be blank."},
+		"location":             {"Location is not in the list [ORIGIN, DESTINATION]."},
+		"status":               {"Status is not in the list [IN_SIT, RELEASED]."},
+		"warehouse_id":         {"WarehouseID can not be blank."},
+		"warehouse_name":       {"WarehouseName can not be blank."},
+		"warehouse_address_id": {"WarehouseAddressID can not be blank."},
+		"in_date":              {"InDate can not be blank."},
+	}
+	suite.verifyValidationErrors(sit, expErrors)
+}
+
+func (suite *ModelSuite) TestStorageInTransitExtensionValidations() {
+	extension := &models.StorageInTransitExtension{}
+
+	expErrors := map[string][]string{
+		"storage_in_transit_id": {"StorageInTransitID can not be blank."},
+		"requested_days":        {"0 is not greater than 0."},
+		"reason":                {"Reason can not be blank."},
+		"status":                {"Status is not in the list [REQUESTED, APPROVED, DENIED]."},
+		"requested_by_user_id":  {"RequestedByUserID can not be blank."},
+	}
+	suite.verifyValidationErrors(extension, expErrors)
+}
+
+func (suite *ModelSuite) TestStorageInTransitBillableDays() {
+	inDate := time.Date(2018, time.December, 10, 0, 0, 0, 0, time.UTC)
+	sit := models.StorageInTransit{
+		Status:         models.StorageInTransitStatusINSIT,
+		InDate:         inDate,
+		AuthorizedDays: models.StorageInTransitMaxDays,
+	}
+
+	// Goods still in storage aren't billed yet
+	suite.Equal(0, sit.BillableDays())
+
+	suite.NoError(sit.Release(inDate.AddDate(0, 0, 100)))
+	suite.Equal(100, sit.DaysInStorage(*sit.OutDate))
+	suite.Equal(90, sit.BillableDays())
+
+	// Only approved extensions add to the allowed days
+	sit.Extensions = models.StorageInTransitExtensions{
+		{RequestedDays: 5, Status: models.StorageInTransitExtensionStatusAPPROVED},
+		{RequestedDays: 30, Status: models.StorageInTransitExtensionStatusDENIED},
+		{RequestedDays: 30, Status: models.StorageInTransitExtensionStatusREQUESTED},
+	}
+	suite.Equal(95, sit.AllowedDays())
+	suite.Equal(95, sit.BillableDays())
+
+	// Goods released the day they went in are billed for a day
+	sameDay := models.StorageInTransit{InDate: inDate, AuthorizedDays: models.StorageInTransitMaxDays}
+	sameDay.Status = models.StorageInTransitStatusINSIT
+	suite.NoError(sameDay.Release(inDate))
+	suite.Equal(1, sameDay.BillableDays())
+}
+
+func (suite *ModelSuite) TestStorageInTransitStateMachine() {
+	sit := models.StorageInTransit{Status: models.StorageInTransitStatusINSIT}
+	outDate := time.Date(2018, time.December, 20, 0, 0, 0, 0, time.UTC)
+
+	suite.NoError(sit.Release(outDate))
+	suite.Equal(models.StorageInTransitStatusRELEASED, sit.Status)
+	suite.Equal(models.ErrInvalidTransition, errors.Cause(sit.Release(outDate)))
+
+	officeUserID := uuid.Must(uuid.NewV4())
+	now := time.Now()
+
+	approved := models.StorageInTransitExtension{Status: models.StorageInTransitExtensionStatusREQUESTED}
+	suite.NoError(approved.Approve(officeUserID, now))
+	suite.Equal(models.StorageInTransitExtensionStatusAPPROVED, approved.Status)
+	suite.Equal(officeUserID, *approved.ReviewedByOfficeUserID)
+	suite.Equal(models.ErrInvalidTransition, errors.Cause(approved.Deny(officeUserID, now)))
+
+	denied := models.StorageInTransitExtension{Status: models.StorageInTransitExtensionStatusREQUESTED}
+	suite.NoError(denied.Deny(officeUserID, now))
+	suite.Equal(models.StorageInTransitExtensionStatusDENIED, denied.Status)
+	suite.Equal(models.ErrInvalidTransition, errors.Cause(denied.Approve(officeUserID, now)))
+}
+
+func (suite *ModelSuite) TestCreateAndFetchStorageInTransits() {
+	shipment := testdatagen.MakeDefaultShipment(suite.db)
+	address := testdatagen.MakeDefaultAddress(suite.db)
+	address.ID = uuid.Nil
+
+	later := models.StorageInTransit{
+		ShipmentID:       shipment.ID,
+		Location:         models.StorageInTransitLocationDESTINATION,
+		Status:           models.StorageInTransitStatusINSIT,
+		WarehouseID:      "000383",
+		WarehouseName:    "Hercules Hauling",
+		WarehouseAddress: address,
+		InDate:           time.Date(2018, time.December, 20, 0, 0, 0, 0, time.UTC),
+		AuthorizedDays:   models.StorageInTransitMaxDays,
+	}
+	verrs, err := models.CreateStorageInTransit(suite.db, &later)
+	suite.NoError(err)
+	suite.False(verrs.HasAny())
+	suite.NotEqual(uuid.Nil, later.WarehouseAddressID)
+
+	earlier := testdatagen.MakeStorageInTransit(suite.db, testdatagen.Assertions{
+		StorageInTransit: models.StorageInTransit{
+			ShipmentID: shipment.ID,
+			Location:   models.StorageInTransitLocationORIGIN,
+			InDate:     time.Date(2018, time.December, 1, 0, 0, 0, 0, time.UTC),
+		},
+	})
+	testdatagen.MakeStorageInTransitExtension(suite.db, testdatagen.Assertions{
+		StorageInTransitExtension: models.StorageInTransitExtension{
+			StorageInTransitID: earlier.ID,
+		},
+	})
+	// A stay for another shipment
+	testdatagen.MakeDefaultStorageInTransit(suite.db)
+
+	sits, err := models.FetchStorageInTransitsByShipmentID(suite.db, shipment.ID)
+	suite.NoError(err)
+	if suite.Len(sits, 2) {
+		suite.Equal(earlier.ID, sits[0].ID)
+		suite.Len(sits[0].Extensions, 1)
+		suite.Equal(later.ID, sits[1].ID)
+		suite.Equal(address.PostalCode, sits[1].WarehouseAddress.PostalCode)
+	}
+
+	_, err = models.FetchStorageInTransitByID(suite.db, uuid.Must(uuid.NewV4()))
+	suite.Equal(models.ErrFetchNotFound, err)
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"github.com/gobuffalo/pop"
+	"github.com/pkg/errors"
+)
+
+// Uploads with identical content share one stored object. The stored_objects table
+// counts how many uploads refer to each object so that it is only removed from storage
+// once nothing refers to it.
+
+// AddStoredObjectReference records another reference to the object at storageKey and
+// returns the number of references it now has. A count of 1 means that the object is
+// new and must be stored.
+//
+// The row stays locked until the transaction ends, so call this from the transaction
+// that creates the reference.
+func AddStoredObjectReference(tx *pop.Connection, storageKey string) (int, error) {
+	var count int
+	sql := `INSERT INTO stored_objects AS so (storage_key, reference_count)
+			VALUES ($1, 1)
+		ON CONFLICT (storage_key)
+		DO
+			UPDATE
+				SET reference_count = so.reference_count + 1
+				WHERE so.storage_key = $1
+		RETURNING so.reference_count
+	`
+
+	err := tx.RawQuery(sql, storageKey).First(&count)
+	if err != nil {
+		return 0, errors.Wrap(err, "Error while incrementing stored object references")
+	}
+	return count, nil
+}
+
+// RemoveStoredObjectReference removes a reference to the object at storageKey and
+// returns the number of references left. The object should be removed from storage
+// when none are left.
+//
+// The row stays locked until the transaction ends, so call this from the transaction
+// that removes the reference.
+func RemoveStoredObjectReference(tx *pop.Connection, storageKey string) (int, error) {
+	var count int
+	sql := `UPDATE stored_objects
+			SET reference_count = reference_count - 1
+			WHERE storage_key = $1
+		RETURNING reference_count
+	`
+
+	err := tx.RawQuery(sql, storageKey).First(&count)
+	if err != nil {
+		if errors.Cause(err).Error() == recordNotFoundErrorString {
+			// Nothing was tracking the object, so nothing else refers to it
+			return 0, nil
+		}
+		return 0, errors.Wrap(err, "Error while decrementing stored object references")
+	}
+
+	if count <= 0 {
+		err = tx.RawQuery("DELETE FROM stored_objects WHERE storage_key = $1", storageKey).Exec()
+		if err != nil {
+			return 0, errors.Wrap(err, "Error while removing stored object")
+		}
+	}
+	return count, nil
+}
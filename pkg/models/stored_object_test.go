@@ -0,0 +1,30 @@
+package models_test
+
+import (
+	. "github.com/transcom/mymove/pkg/models"
+)
+
+func (suite *ModelSuite) Test_StoredObjectReferences() {
+	key := "content/sha256/1234"
+
+	for expected := 1; expected <= 2; expected++ {
+		count, err := AddStoredObjectReference(suite.db, key)
+		suite.Nil(err)
+		suite.Equal(expected, count)
+	}
+
+	count, err := RemoveStoredObjectReference(suite.db, key)
+	suite.Nil(err)
+	suite.Equal(1, count)
+	count, err = RemoveStoredObjectReference(suite.db, key)
+	suite.Nil(err)
+	suite.Equal(0, count)
+
+	// Once no references are left the object is forgotten, and adding one starts over
+	count, err = RemoveStoredObjectReference(suite.db, key)
+	suite.Nil(err)
+	suite.Equal(0, count)
+	count, err = AddStoredObjectReference(suite.db, key)
+	suite.Nil(err)
+	suite.Equal(1, count)
+}
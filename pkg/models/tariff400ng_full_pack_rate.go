@@ -23,6 +23,8 @@ type Tariff400ngFullPackRate struct {
 	RateCents          unit.Cents `json:"rate_cents" db:"rate_cents"`
 	EffectiveDateLower time.Time  `json:"effective_date_lower" db:"effective_date_lower"`
 	EffectiveDateUpper time.Time  `json:"effective_date_upper" db:"effective_date_upper"`
+	PublishedAt        time.Time  `json:"published_at" db:"published_at"`
+	SupersededAt       *time.Time `json:"superseded_at" db:"superseded_at"`
 }
 
 // Tariff400ngFullPackRates is not required by pop and may be deleted
@@ -44,6 +46,17 @@ func (t *Tariff400ngFullPackRate) Validate(tx *pop.Connection) (*validate.Errors
 // FetchTariff400ngFullPackRateCents returns the full unpack rate for a service
 // schedule and weight.
 func FetchTariff400ngFullPackRateCents(tx *pop.Connection, weight unit.Pound, schedule int, date time.Time) (unit.Cents, error) {
+	rate, err := FetchTariff400ngFullPackRate(tx, weight, schedule, date, time.Time{})
+	if err != nil {
+		return 0, err
+	}
+	return rate.RateCents, nil
+}
+
+// FetchTariff400ngFullPackRate returns the tariff400ng_full_pack_rates row for a service
+// schedule and weight. The row is read as of asOf, where a zero asOf reads the current
+// version of the table.
+func FetchTariff400ngFullPackRate(tx *pop.Connection, weight unit.Pound, schedule int, date time.Time, asOf time.Time) (Tariff400ngFullPackRate, error) {
 	rate := Tariff400ngFullPackRate{}
 
 	sql := `SELECT
@@ -56,12 +69,14 @@ func FetchTariff400ngFullPackRateCents(tx *pop.Connection, weight unit.Pound, sc
 			weight_lbs_lower <= $2 AND $2 < weight_lbs_upper
 		AND
 			effective_date_lower <= $3 AND $3 < effective_date_upper
+		AND
+			` + tariff400ngVersionClause("tariff400ng_full_pack_rates", 4) + `
 		;
 		`
 
-	err := tx.RawQuery(sql, schedule, weight, date).First(&rate)
+	err := tx.RawQuery(sql, schedule, weight, date, tariff400ngAsOf(asOf)).First(&rate)
 	if err != nil {
-		return 0, errors.Wrap(err, "could not find a matching Tariff400ngFullPackRate")
+		return rate, errors.Wrap(err, "could not find a matching Tariff400ngFullPackRate")
 	}
-	return rate.RateCents, nil
+	return rate, nil
 }
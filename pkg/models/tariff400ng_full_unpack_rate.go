@@ -12,13 +12,15 @@ import (
 
 // Tariff400ngFullUnpackRate describes the rates paid to unpack various weights of goods
 type Tariff400ngFullUnpackRate struct {
-	ID                 uuid.UUID `json:"id" db:"id"`
-	CreatedAt          time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
-	Schedule           int       `json:"schedule" db:"schedule"`
-	RateMillicents     int       `json:"rate_millicents" db:"rate_millicents"`
-	EffectiveDateLower time.Time `json:"effective_date_lower" db:"effective_date_lower"`
-	EffectiveDateUpper time.Time `json:"effective_date_upper" db:"effective_date_upper"`
+	ID                 uuid.UUID  `json:"id" db:"id"`
+	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at" db:"updated_at"`
+	Schedule           int        `json:"schedule" db:"schedule"`
+	RateMillicents     int        `json:"rate_millicents" db:"rate_millicents"`
+	EffectiveDateLower time.Time  `json:"effective_date_lower" db:"effective_date_lower"`
+	EffectiveDateUpper time.Time  `json:"effective_date_upper" db:"effective_date_upper"`
+	PublishedAt        time.Time  `json:"published_at" db:"published_at"`
+	SupersededAt       *time.Time `json:"superseded_at" db:"superseded_at"`
 }
 
 // Tariff400ngFullUnpackRates is not required by pop and may be deleted
@@ -38,6 +40,17 @@ func (t *Tariff400ngFullUnpackRate) Validate(tx *pop.Connection) (*validate.Erro
 // FetchTariff400ngFullUnpackRateMillicents returns the full unpack rate for a service
 // schedule.
 func FetchTariff400ngFullUnpackRateMillicents(tx *pop.Connection, serviceSchedule int, date time.Time) (int, error) {
+	rate, err := FetchTariff400ngFullUnpackRate(tx, serviceSchedule, date, time.Time{})
+	if err != nil {
+		return 0, err
+	}
+	return rate.RateMillicents, nil
+}
+
+// FetchTariff400ngFullUnpackRate returns the tariff400ng_full_unpack_rates row for a
+// service schedule. The row is read as of asOf, where a zero asOf reads the current version
+// of the table.
+func FetchTariff400ngFullUnpackRate(tx *pop.Connection, serviceSchedule int, date time.Time, asOf time.Time) (Tariff400ngFullUnpackRate, error) {
 	rate := Tariff400ngFullUnpackRate{}
 
 	sql := `SELECT *
@@ -47,12 +60,14 @@ func FetchTariff400ngFullUnpackRateMillicents(tx *pop.Connection, serviceSchedul
 			schedule = $1
 		AND
 			effective_date_lower <= $2 AND $2 < effective_date_upper
+		AND
+			` + tariff400ngVersionClause("tariff400ng_full_unpack_rates", 3) + `
 		;`
 
-	err := tx.RawQuery(sql, serviceSchedule, date).First(&rate)
+	err := tx.RawQuery(sql, serviceSchedule, date, tariff400ngAsOf(asOf)).First(&rate)
 
 	if err != nil {
-		return 0, errors.Wrap(err, "could not find a matching Tariff400ngFullUnpackRate")
+		return rate, errors.Wrap(err, "could not find a matching Tariff400ngFullUnpackRate")
 	}
-	return rate.RateMillicents, nil
+	return rate, nil
 }
@@ -4,9 +4,11 @@ import (
 	"time"
 
 	"github.com/gobuffalo/pop"
-	"github.com/gobuffalo/uuid"
 	"github.com/gobuffalo/validate"
 	"github.com/gobuffalo/validate/validators"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
 	"github.com/transcom/mymove/pkg/unit"
 )
 
@@ -14,22 +16,26 @@ import (
 type Tariff400ngItemRate struct {
 	ID                 uuid.UUID  `json:"id" db:"id"`
 	Code               string     `json:"code" db:"code"`
-	ServicesSchedule   int        `json:"services_schedule" db:"services_schedule"`
+	Schedule           *int       `json:"schedule" db:"schedule"`
 	WeightLbsLower     unit.Pound `json:"weight_lbs_lower" db:"weight_lbs_lower"`
 	WeightLbsUpper     unit.Pound `json:"weight_lbs_upper" db:"weight_lbs_upper"`
 	RateCents          unit.Cents `json:"rate_cents" db:"rate_cents"`
 	EffectiveDateLower time.Time  `json:"effective_date_lower" db:"effective_date_lower"`
 	EffectiveDateUpper time.Time  `json:"effective_date_upper" db:"effective_date_upper"`
+	PublishedAt        time.Time  `json:"published_at" db:"published_at"`
+	SupersededAt       *time.Time `json:"superseded_at" db:"superseded_at"`
 	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt          time.Time  `json:"updated_at" db:"updated_at"`
 }
 
+// Tariff400ngItemRates is not required by pop and may be deleted
+type Tariff400ngItemRates []Tariff400ngItemRate
+
 // Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
 // This method is not required and may be deleted.
 func (t *Tariff400ngItemRate) Validate(tx *pop.Connection) (*validate.Errors, error) {
 	return validate.Validate(
 		&validators.StringIsPresent{Field: t.Code, Name: "Code"},
-		&validators.IntIsPresent{Field: t.ServicesSchedule, Name: "ServicesSchedule"},
 		&validators.IntIsGreaterThan{Field: t.RateCents.Int(), Name: "RateCents", Compared: -1},
 		&validators.IntIsLessThan{Field: t.WeightLbsLower.Int(), Name: "WeightLbsLower",
 			Compared: t.WeightLbsUpper.Int()},
@@ -38,3 +44,37 @@ func (t *Tariff400ngItemRate) Validate(tx *pop.Connection) (*validate.Errors, er
 			SecondTime: t.EffectiveDateLower, SecondName: "EffectiveDateLower"},
 	), nil
 }
+
+// FetchTariff400ngItemRate returns the tariff400ng_item_rates row for an item code, service
+// schedule and weight. Items that aren't priced by schedule match rows without one. The row
+// is read as of asOf, where a zero asOf reads the current version of the table.
+func FetchTariff400ngItemRate(tx *pop.Connection, code string, schedule int, weight unit.Pound, date time.Time, asOf time.Time) (Tariff400ngItemRate, error) {
+	rate := Tariff400ngItemRate{}
+
+	sql := `SELECT
+			*
+		FROM
+			tariff400ng_item_rates
+		WHERE
+			code = $1
+		AND
+			(schedule IS NULL OR schedule = $2)
+		AND
+			weight_lbs_lower <= $3 AND $3 < weight_lbs_upper
+		AND
+			effective_date_lower <= $4 AND $4 < effective_date_upper
+		AND
+			` + tariff400ngVersionClause("tariff400ng_item_rates", 5) + `
+		;
+		`
+
+	err := tx.RawQuery(sql, code, schedule, weight, date, tariff400ngAsOf(asOf)).First(&rate)
+	if err != nil {
+		if errors.Cause(err).Error() == recordNotFoundErrorString {
+			return rate, ErrFetchNotFound
+		}
+		return rate, errors.Wrapf(err, "could not fetch the Tariff400ngItemRate for %s", code)
+	}
+
+	return rate, nil
+}
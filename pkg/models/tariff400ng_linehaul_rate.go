@@ -26,6 +26,8 @@ type Tariff400ngLinehaulRate struct {
 	RateCents          unit.Cents `json:"rate_cents" db:"rate_cents"`
 	EffectiveDateLower time.Time  `json:"effective_date_lower" db:"effective_date_lower"`
 	EffectiveDateUpper time.Time  `json:"effective_date_upper" db:"effective_date_upper"`
+	PublishedAt        time.Time  `json:"published_at" db:"published_at"`
+	SupersededAt       *time.Time `json:"superseded_at" db:"superseded_at"`
 }
 
 // Tariff400ngLinehaulRates is not required by pop and may be deleted
@@ -60,12 +62,23 @@ func (t *Tariff400ngLinehaulRate) ValidateUpdate(tx *pop.Connection) (*validate.
 
 // FetchBaseLinehaulRate takes a move's distance and weight and queries the tariff400ng_linehaul_rates table to find a move's base linehaul rate.
 func FetchBaseLinehaulRate(tx *pop.Connection, mileage int, weight unit.Pound, date time.Time) (linehaulRate unit.Cents, err error) {
+	rate, err := FetchTariff400ngLinehaulRate(tx, mileage, weight, date, time.Time{})
+	if err != nil {
+		return 0, err
+	}
+	return rate.RateCents, nil
+}
+
+// FetchTariff400ngLinehaulRate returns the tariff400ng_linehaul_rates row, including its
+// mileage and weight bands, that a move's base linehaul rate comes from. The row is read as
+// of asOf, where a zero asOf reads the current version of the table.
+func FetchTariff400ngLinehaulRate(tx *pop.Connection, mileage int, weight unit.Pound, date time.Time, asOf time.Time) (Tariff400ngLinehaulRate, error) {
 	// TODO: change to a parameter once we're serving more move types
 	moveType := "ConusLinehaul"
-	var linehaulRates []unit.Cents
+	var linehaulRates Tariff400ngLinehaulRates
 
 	sql := `SELECT
-		rate_cents
+		*
 	FROM
 		tariff400ng_linehaul_rates
 	WHERE
@@ -75,17 +88,19 @@ func FetchBaseLinehaulRate(tx *pop.Connection, mileage int, weight unit.Pound, d
 	AND
 		type = $3
 	AND
-		(effective_date_lower <= $4 AND $4 < effective_date_upper);`
+		(effective_date_lower <= $4 AND $4 < effective_date_upper)
+	AND
+		` + tariff400ngVersionClause("tariff400ng_linehaul_rates", 5) + `;`
 
-	err = tx.RawQuery(sql, mileage, weight.Int(), moveType, date).All(&linehaulRates)
+	err := tx.RawQuery(sql, mileage, weight.Int(), moveType, date, tariff400ngAsOf(asOf)).All(&linehaulRates)
 
 	if err != nil {
-		return 0, fmt.Errorf("Error fetching linehaul rate: %s", err)
+		return Tariff400ngLinehaulRate{}, fmt.Errorf("Error fetching linehaul rate: %s", err)
 	}
 	if len(linehaulRates) != 1 {
-		return 0, fmt.Errorf("Wanted 1 rate, found %d rates for parameters: %v, %v, %v",
+		return Tariff400ngLinehaulRate{}, fmt.Errorf("Wanted 1 rate, found %d rates for parameters: %v, %v, %v",
 			len(linehaulRates), mileage, weight, date)
 	}
 
-	return linehaulRates[0], err
+	return linehaulRates[0], nil
 }
@@ -3,6 +3,8 @@ package models_test
 import (
 	"time"
 
+	"github.com/gofrs/uuid"
+
 	. "github.com/transcom/mymove/pkg/models"
 	"github.com/transcom/mymove/pkg/testdatagen"
 	"github.com/transcom/mymove/pkg/unit"
@@ -182,3 +184,47 @@ func (suite *ModelSuite) Test_FetchBaseLinehaulRate() {
 	}
 
 }
+
+func (suite *ModelSuite) Test_FetchTariff400ngLinehaulRateAsOf() {
+	published := time.Date(2018, time.May, 1, 0, 0, 0, 0, time.UTC)
+	updated := time.Date(2018, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+	original := Tariff400ngLinehaulRate{
+		DistanceMilesLower: 3101,
+		DistanceMilesUpper: 3300,
+		WeightLbsLower:     unit.Pound(5000),
+		WeightLbsUpper:     unit.Pound(10000),
+		RateCents:          unit.Cents(474747),
+		Type:               "ConusLinehaul",
+		EffectiveDateLower: testdatagen.PeakRateCycleStart,
+		EffectiveDateUpper: testdatagen.PeakRateCycleEnd,
+		PublishedAt:        published,
+	}
+	suite.mustSave(&original)
+
+	// The rate is corrected mid-cycle
+	suite.NoError(SupersedeTariff400ngRow(suite.db, "tariff400ng_linehaul_rates", original.ID, updated))
+	correction := original
+	correction.ID = uuid.Nil
+	correction.RateCents = unit.Cents(484848)
+	correction.PublishedAt = updated
+	suite.mustSave(&correction)
+
+	// Current rates read the correction
+	rate, err := FetchTariff400ngLinehaulRate(suite.db, 3200, unit.Pound(6000), testdatagen.DateInsidePeakRateCycle, time.Time{})
+	suite.NoError(err)
+	suite.Equal(correction.ID, rate.ID)
+
+	// Rates as of before the correction read the original
+	rate, err = FetchTariff400ngLinehaulRate(suite.db, 3200, unit.Pound(6000), testdatagen.DateInsidePeakRateCycle, updated.AddDate(0, 0, -1))
+	suite.NoError(err)
+	suite.Equal(original.ID, rate.ID)
+
+	// Rates as of the correction read the correction
+	rate, err = FetchTariff400ngLinehaulRate(suite.db, 3200, unit.Pound(6000), testdatagen.DateInsidePeakRateCycle, updated)
+	suite.NoError(err)
+	suite.Equal(correction.ID, rate.ID)
+
+	// Rows of other tables can't be superseded
+	suite.Error(SupersedeTariff400ngRow(suite.db, "shipments", original.ID, updated))
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gobuffalo/validate/validators"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+)
+
+// Tariff400ngPeakRateAdjustment is the multiplier applied to a 400NG item's rate during the
+// peak rate cycle
+type Tariff400ngPeakRateAdjustment struct {
+	ID                 uuid.UUID  `json:"id" db:"id"`
+	Code               string     `json:"code" db:"code"`
+	Multiplier         float64    `json:"multiplier" db:"multiplier"`
+	EffectiveDateLower time.Time  `json:"effective_date_lower" db:"effective_date_lower"`
+	EffectiveDateUpper time.Time  `json:"effective_date_upper" db:"effective_date_upper"`
+	PublishedAt        time.Time  `json:"published_at" db:"published_at"`
+	SupersededAt       *time.Time `json:"superseded_at" db:"superseded_at"`
+	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Tariff400ngPeakRateAdjustments is not required by pop and may be deleted
+type Tariff400ngPeakRateAdjustments []Tariff400ngPeakRateAdjustment
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+// This method is not required and may be deleted.
+func (t *Tariff400ngPeakRateAdjustment) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.StringIsPresent{Field: t.Code, Name: "Code"},
+		&MultiplierIsPositive{Field: t.Multiplier, Name: "Multiplier"},
+		&validators.TimeAfterTime{
+			FirstTime: t.EffectiveDateUpper, FirstName: "EffectiveDateUpper",
+			SecondTime: t.EffectiveDateLower, SecondName: "EffectiveDateLower"},
+	), nil
+}
+
+// FetchTariff400ngPeakRateAdjustment returns the peak rate adjustment for an item code on a
+// date. The row is read as of asOf, where a zero asOf reads the current version of the table.
+// ErrFetchNotFound is returned when the item's rate isn't adjusted.
+func FetchTariff400ngPeakRateAdjustment(tx *pop.Connection, code string, date time.Time, asOf time.Time) (Tariff400ngPeakRateAdjustment, error) {
+	adjustment := Tariff400ngPeakRateAdjustment{}
+
+	sql := `SELECT
+			*
+		FROM
+			tariff400ng_peak_rate_adjustments
+		WHERE
+			code = $1
+		AND
+			effective_date_lower <= $2 AND $2 < effective_date_upper
+		AND
+			` + tariff400ngVersionClause("tariff400ng_peak_rate_adjustments", 3) + `
+		;
+		`
+
+	err := tx.RawQuery(sql, code, date, tariff400ngAsOf(asOf)).First(&adjustment)
+	if err != nil {
+		if errors.Cause(err).Error() == recordNotFoundErrorString {
+			return adjustment, ErrFetchNotFound
+		}
+		return adjustment, errors.Wrapf(err, "could not fetch the Tariff400ngPeakRateAdjustment for %s", code)
+	}
+
+	return adjustment, nil
+}
@@ -24,6 +24,8 @@ type Tariff400ngServiceArea struct {
 	ServiceChargeCents unit.Cents `json:"service_charge_cents" db:"service_charge_cents"`
 	EffectiveDateLower time.Time  `json:"effective_date_lower" db:"effective_date_lower"`
 	EffectiveDateUpper time.Time  `json:"effective_date_upper" db:"effective_date_upper"`
+	PublishedAt        time.Time  `json:"published_at" db:"published_at"`
+	SupersededAt       *time.Time `json:"superseded_at" db:"superseded_at"`
 	SIT185ARateCents   unit.Cents `json:"sit_185a_rate_cents" db:"sit_185a_rate_cents"`
 	SIT185BRateCents   unit.Cents `json:"sit_185b_rate_cents" db:"sit_185b_rate_cents"`
 	SITPDSchedule      int        `json:"sit_pd_schedule" db:"sit_pd_schedule"`
@@ -49,7 +51,7 @@ func (t *Tariff400ngServiceArea) Validate(tx *pop.Connection) (*validate.Errors,
 }
 
 // FetchTariff400ngServiceAreaForZip3 returns the service area for a specified Zip3.
-func FetchTariff400ngServiceAreaForZip3(tx *pop.Connection, zip3 string, date time.Time) (Tariff400ngServiceArea, error) {
+func FetchTariff400ngServiceAreaForZip3(tx *pop.Connection, zip3 string, date time.Time, asOf time.Time) (Tariff400ngServiceArea, error) {
 	serviceArea := Tariff400ngServiceArea{}
 	sql := `SELECT
 				tariff400ng_service_areas.*
@@ -61,9 +63,11 @@ func FetchTariff400ngServiceAreaForZip3(tx *pop.Connection, zip3 string, date ti
 				tariff400ng_zip3s.zip3 = $1
 			AND
 				effective_date_lower <= $2
-			AND effective_date_upper > $2;
+			AND effective_date_upper > $2
+			AND
+				` + tariff400ngVersionClause("tariff400ng_service_areas", 3) + `;
 			`
-	err := tx.RawQuery(sql, zip3, date).First(&serviceArea)
+	err := tx.RawQuery(sql, zip3, date, tariff400ngAsOf(asOf)).First(&serviceArea)
 	if err != nil {
 		return serviceArea, errors.Wrapf(err, "could not find a matching Tariff400ngServiceArea for zip3 %s", zip3)
 	}
@@ -22,6 +22,8 @@ type Tariff400ngShorthaulRate struct {
 	RateCents          unit.Cents `json:"rate_cents" db:"rate_cents"`
 	EffectiveDateLower time.Time  `json:"effective_date_lower" db:"effective_date_lower"`
 	EffectiveDateUpper time.Time  `json:"effective_date_upper" db:"effective_date_upper"`
+	PublishedAt        time.Time  `json:"published_at" db:"published_at"`
+	SupersededAt       *time.Time `json:"superseded_at" db:"superseded_at"`
 }
 
 // Tariff400ngShorthaulRates is not required by pop and may be deleted
@@ -56,25 +58,38 @@ func (t *Tariff400ngShorthaulRate) ValidateUpdate(tx *pop.Connection) (*validate
 // (cwtMiles is a unit capturing the movement of 100lbs by 1 mile.) The value returned
 // is in cents of 1 USD.
 func FetchShorthaulRateCents(tx *pop.Connection, cwtMiles int, date time.Time) (rateCents unit.Cents, err error) {
+	rate, err := FetchTariff400ngShorthaulRate(tx, cwtMiles, date, time.Time{})
+	if err != nil {
+		return 0, err
+	}
+	return rate.RateCents, nil
+}
+
+// FetchTariff400ngShorthaulRate returns the tariff400ng_shorthaul_rates row, including
+// its CWT-miles band, for a number of CWT-miles. The row is read as of asOf, where a zero
+// asOf reads the current version of the table.
+func FetchTariff400ngShorthaulRate(tx *pop.Connection, cwtMiles int, date time.Time, asOf time.Time) (Tariff400ngShorthaulRate, error) {
 	sh := Tariff400ngShorthaulRates{}
 
 	sql := `SELECT
-		rate_cents
+		*
 	FROM
 		tariff400ng_shorthaul_rates
 	WHERE
 		cwt_miles_lower <= $1 AND $1 < cwt_miles_upper
 	AND
-		effective_date_lower <= $2 AND $2 < effective_date_upper`
+		effective_date_lower <= $2 AND $2 < effective_date_upper
+	AND
+		` + tariff400ngVersionClause("tariff400ng_shorthaul_rates", 3)
 
-	err = tx.RawQuery(sql, cwtMiles, date).All(&sh)
+	err := tx.RawQuery(sql, cwtMiles, date, tariff400ngAsOf(asOf)).All(&sh)
 	if err != nil {
-		return 0, errors.Wrapf(err, "error fetching shorthaul rate for %d cwtmiles on %s", cwtMiles, date)
+		return Tariff400ngShorthaulRate{}, errors.Wrapf(err, "error fetching shorthaul rate for %d cwtmiles on %s", cwtMiles, date)
 	}
 	if len(sh) != 1 {
-		return 0, errors.Errorf("Wanted 1 shorthaul rate, found %d rates for parameters: %v cwtMiles, %v",
+		return Tariff400ngShorthaulRate{}, errors.Errorf("Wanted 1 shorthaul rate, found %d rates for parameters: %v cwtMiles, %v",
 			len(sh), cwtMiles, date)
 	}
 
-	return sh[0].RateCents, nil
+	return sh[0], nil
 }
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+)
+
+// Tariff400ngVersionedTables are the tariff tables whose rows are superseded and published
+// rather than updated, so that a shipment can be rated again against the rows that were
+// current when it was invoiced.
+var Tariff400ngVersionedTables = []string{
+	"tariff400ng_linehaul_rates",
+	"tariff400ng_shorthaul_rates",
+	"tariff400ng_service_areas",
+	"tariff400ng_full_pack_rates",
+	"tariff400ng_full_unpack_rates",
+	"tariff400ng_item_rates",
+	"tariff400ng_peak_rate_adjustments",
+}
+
+// tariff400ngCurrent is the time the current version of the tariff tables is read as of
+var tariff400ngCurrent = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// tariff400ngAsOf returns the time to read the tariff tables as of. A zero time reads the
+// current version.
+func tariff400ngAsOf(asOf time.Time) time.Time {
+	if asOf.IsZero() {
+		return tariff400ngCurrent
+	}
+	return asOf
+}
+
+// tariff400ngVersionClause is a SQL condition that selects a table's rows as they were as of
+// the time in placeholder $n
+func tariff400ngVersionClause(table string, n int) string {
+	return fmt.Sprintf("(%[1]s.published_at <= $%[2]d AND (%[1]s.superseded_at IS NULL OR $%[2]d < %[1]s.superseded_at))", table, n)
+}
+
+// checkTariff400ngVersioned returns an error unless table is a versioned tariff table
+func checkTariff400ngVersioned(table string) error {
+	for _, t := range Tariff400ngVersionedTables {
+		if t == table {
+			return nil
+		}
+	}
+	return errors.Errorf("%s is not a versioned tariff table", table)
+}
+
+// SupersedeTariff400ngRow marks a row of a versioned tariff table as replaced. Its replacement
+// should be published at the same time.
+func SupersedeTariff400ngRow(tx *pop.Connection, table string, id uuid.UUID, at time.Time) error {
+	if err := checkTariff400ngVersioned(table); err != nil {
+		return err
+	}
+
+	sql := fmt.Sprintf("UPDATE %s SET superseded_at = $1 WHERE id = $2 AND superseded_at IS NULL", table)
+	if err := tx.RawQuery(sql, at, id).Exec(); err != nil {
+		return errors.Wrapf(err, "could not supersede %s row %s", table, id)
+	}
+	return nil
+}
+
+// SupersedeTariff400ngRows marks the current rows of a versioned tariff table that a staged
+// version of it replaces: those effective on any date from lower up to upper whose key columns
+// match a row of the staged table, so that a file with only some keys leaves the rest current.
+// Without key columns, every current row effective on those dates is replaced. The replacements
+// should be published at the same time.
+func SupersedeTariff400ngRows(tx *pop.Connection, table string, staged string, key []string, lower time.Time, upper time.Time, at time.Time) error {
+	if err := checkTariff400ngVersioned(table); err != nil {
+		return err
+	}
+
+	sql := fmt.Sprintf(`UPDATE %s
+		SET superseded_at = $1
+		WHERE superseded_at IS NULL
+		AND daterange(effective_date_lower, effective_date_upper) && daterange($2, $3)`, table)
+	if len(key) > 0 {
+		var matches []string
+		for _, column := range key {
+			matches = append(matches, fmt.Sprintf("s.%[1]s IS NOT DISTINCT FROM %[2]s.%[1]s", column, table))
+		}
+		sql += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM %s s WHERE %s)", staged, strings.Join(matches, " AND "))
+	}
+	if err := tx.RawQuery(sql, at, lower, upper).Exec(); err != nil {
+		return errors.Wrapf(err, "could not supersede the current rows of %s", table)
+	}
+	return nil
+}
@@ -297,6 +297,32 @@ func GetRateCycle(year int, peak bool) (start time.Time, end time.Time) {
 	return start, end
 }
 
+// IsPeakRateCycle returns true if a date falls in the peak rate cycle of its year.
+func IsPeakRateCycle(date time.Time) bool {
+	start, end := GetRateCycle(date.Year(), true)
+	return !date.Before(start) && date.Before(end)
+}
+
+// FetchTSPPerformanceForRateCycle returns a TSP's performance in a TDL for the rate cycle
+// that a date falls in. A TSP's discount rates differ between peak and non-peak rate cycles.
+func FetchTSPPerformanceForRateCycle(db *pop.Connection, tspID uuid.UUID, tdlID uuid.UUID, date time.Time) (TransportationServiceProviderPerformance, error) {
+	var tspPerformance TransportationServiceProviderPerformance
+
+	err := db.Where("transportation_service_provider_id = ?", tspID).
+		Where("traffic_distribution_list_id = ?", tdlID).
+		Where("rate_cycle_start <= ?", date).
+		Where("? < rate_cycle_end", date).
+		First(&tspPerformance)
+
+	if err != nil {
+		if errors.Cause(err).Error() == recordNotFoundErrorString {
+			return tspPerformance, ErrFetchNotFound
+		}
+		return tspPerformance, errors.Wrap(err, "could not fetch the tsp performance for the rate cycle")
+	}
+	return tspPerformance, nil
+}
+
 // FetchDiscountRates returns the discount linehaul and SIT rates for the TSP with the highest
 // BVS during the specified data, limited to those TSPs in the channel defined by the
 // originZip and destinationZip.
@@ -90,6 +90,41 @@ func (suite *ModelSuite) Test_GetRateCycle() {
 	}
 }
 
+func (suite *ModelSuite) Test_IsPeakRateCycle() {
+	suite.True(IsPeakRateCycle(testdatagen.PeakRateCycleStart))
+	suite.True(IsPeakRateCycle(testdatagen.DateInsidePeakRateCycle))
+	suite.False(IsPeakRateCycle(testdatagen.PeakRateCycleEnd))
+	suite.False(IsPeakRateCycle(testdatagen.DateOutsidePeakRateCycle))
+	suite.False(IsPeakRateCycle(testdatagen.PeakRateCycleStart.AddDate(0, 0, -1)))
+}
+
+func (suite *ModelSuite) Test_FetchTSPPerformanceForRateCycle() {
+	peak := testdatagen.MakeDefaultTSPPerformance(suite.db)
+	nonPeak := testdatagen.MakeTSPPerformance(suite.db, testdatagen.Assertions{
+		TransportationServiceProviderPerformance: TransportationServiceProviderPerformance{
+			TransportationServiceProviderID: peak.TransportationServiceProviderID,
+			TransportationServiceProvider:   peak.TransportationServiceProvider,
+			TrafficDistributionListID:       peak.TrafficDistributionListID,
+			TrafficDistributionList:         peak.TrafficDistributionList,
+			RateCycleStart:                  testdatagen.NonPeakRateCycleStart,
+			RateCycleEnd:                    testdatagen.NonPeakRateCycleEnd,
+			LinehaulRate:                    0.5,
+		},
+	})
+
+	performance, err := FetchTSPPerformanceForRateCycle(suite.db, peak.TransportationServiceProviderID, peak.TrafficDistributionListID, testdatagen.DateInsidePeakRateCycle)
+	suite.NoError(err)
+	suite.Equal(peak.ID, performance.ID)
+
+	// The peak rate cycle's end is the start of the non-peak rate cycle
+	performance, err = FetchTSPPerformanceForRateCycle(suite.db, peak.TransportationServiceProviderID, peak.TrafficDistributionListID, testdatagen.PeakRateCycleEnd)
+	suite.NoError(err)
+	suite.Equal(nonPeak.ID, performance.ID)
+
+	_, err = FetchTSPPerformanceForRateCycle(suite.db, peak.TransportationServiceProviderID, peak.TrafficDistributionListID, testdatagen.NonPeakRateCycleEnd)
+	suite.Equal(ErrFetchNotFound, err)
+}
+
 func (suite *ModelSuite) Test_IncrementTSPPerformanceOfferCount() {
 	t := suite.T()
 
@@ -75,3 +75,13 @@ func FetchTspUserByEmail(tx *pop.Connection, email string) (*TspUser, error) {
 	}
 	return &users[0], nil
 }
+
+// FetchTspUsersByTSP looks up all tsp users who work for a specific TSP
+func FetchTspUsersByTSP(tx *pop.Connection, tspID uuid.UUID) (TspUsers, error) {
+	var users TspUsers
+	err := tx.Where("transportation_service_provider_id = $1", tspID).All(&users)
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -12,19 +12,49 @@ import (
 	"github.com/transcom/mymove/pkg/auth"
 )
 
+// UploadStatus is the status of an Upload
+type UploadStatus string
+
+const (
+	// UploadStatusCLEAN captures enum value "CLEAN"
+	UploadStatusCLEAN UploadStatus = "CLEAN"
+	// UploadStatusQUARANTINED captures enum value "QUARANTINED"
+	// Quarantined uploads failed a malware scan and must never be presigned or merged into paperwork
+	UploadStatusQUARANTINED UploadStatus = "QUARANTINED"
+)
+
+// ErrUploadQuarantined means that the upload failed a malware scan and its content can't be accessed
+var ErrUploadQuarantined = errors.New("UPLOAD_QUARANTINED")
+
 // An Upload represents an uploaded file, such as an image or PDF.
+//
+// Checksum is a base64 encoded MD5, and SHA256Checksum is hex encoded. Uploads created
+// before storage was content addressed don't have a SHA256Checksum.
 type Upload struct {
-	ID          uuid.UUID  `db:"id"`
-	DocumentID  *uuid.UUID `db:"document_id"`
-	Document    Document   `belongs_to:"documents"`
-	UploaderID  uuid.UUID  `db:"uploader_id"`
-	Filename    string     `db:"filename"`
-	Bytes       int64      `db:"bytes"`
-	ContentType string     `db:"content_type"`
-	Checksum    string     `db:"checksum"`
-	StorageKey  string     `db:"storage_key"`
-	CreatedAt   time.Time  `db:"created_at"`
-	UpdatedAt   time.Time  `db:"updated_at"`
+	ID             uuid.UUID  `db:"id"`
+	DocumentID     *uuid.UUID `db:"document_id"`
+	Document       Document   `belongs_to:"documents"`
+	UploaderID     uuid.UUID  `db:"uploader_id"`
+	Filename       string     `db:"filename"`
+	Bytes          int64      `db:"bytes"`
+	ContentType    string     `db:"content_type"`
+	Checksum       string     `db:"checksum"`
+	SHA256Checksum *string    `db:"sha256_checksum"`
+	StorageKey     string     `db:"storage_key"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+
+	// malware scanning
+	Status           UploadStatus `db:"status"`
+	QuarantineReason *string      `db:"quarantine_reason"`
+	ScannedAt        *time.Time   `db:"scanned_at"`
+
+	// Images are normalized before they are stored. These describe the file as it
+	// was uploaded, which is kept alongside the normalized one.
+	OriginalContentType *string `db:"original_content_type"`
+	OriginalBytes       *int64  `db:"original_bytes"`
+	OriginalChecksum    *string `db:"original_checksum"`
+	OriginalStorageKey  *string `db:"original_storage_key"`
 }
 
 // Uploads is not required by pop and may be deleted
@@ -52,9 +82,44 @@ func (u *Upload) BeforeCreate(tx *pop.Connection) error {
 		u.StorageKey = path.Join("user", u.UploaderID.String(), "uploads", u.ID.String())
 	}
 
+	if u.HasOriginal() && u.OriginalStorageKey == nil {
+		originalKey := path.Join("user", u.UploaderID.String(), "uploads", "originals", u.ID.String())
+		u.OriginalStorageKey = &originalKey
+	}
+
+	if u.Status == "" {
+		u.Status = UploadStatusCLEAN
+	}
+
 	return nil
 }
 
+// HasOriginal returns true if the stored file was normalized from a separately stored original
+func (u *Upload) HasOriginal() bool {
+	return u.OriginalContentType != nil
+}
+
+// State Machinery
+// Avoid calling Upload.Status = ... ever. Use these methods to change the state.
+
+// MarkScanned records that the upload passed a malware scan
+func (u *Upload) MarkScanned(scannedAt time.Time) {
+	u.ScannedAt = &scannedAt
+	u.Status = UploadStatusCLEAN
+}
+
+// Quarantine records that the upload failed a malware scan
+func (u *Upload) Quarantine(scannedAt time.Time, reason string) {
+	u.ScannedAt = &scannedAt
+	u.QuarantineReason = &reason
+	u.Status = UploadStatusQUARANTINED
+}
+
+// IsQuarantined returns true if the upload's content must not be served or merged
+func (u *Upload) IsQuarantined() bool {
+	return u.Status == UploadStatusQUARANTINED
+}
+
 // FetchUpload returns an Upload if the user has access to that upload
 func FetchUpload(db *pop.Connection, session *auth.Session, id uuid.UUID) (Upload, error) {
 	var upload Upload
@@ -117,6 +117,19 @@ func (v *DiscountRateIsValid) IsValid(errors *validate.Errors) {
 	}
 }
 
+// MultiplierIsPositive validates that a multiplier is greater than 0
+type MultiplierIsPositive struct {
+	Name  string
+	Field float64
+}
+
+// IsValid adds an error if the value is not greater than 0.
+func (v *MultiplierIsPositive) IsValid(errors *validate.Errors) {
+	if v.Field <= 0 {
+		errors.Add(validators.GenerateKey(v.Name), fmt.Sprintf("%s must be greater than 0, got %f", v.Name, v.Field))
+	}
+}
+
 // AllowedFileType validates that a content-type is contained in our list of accepted types.
 type AllowedFileType struct {
 	validators.StringInclusion
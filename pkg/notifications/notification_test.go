@@ -3,12 +3,14 @@ package notifications
 import (
 	"log"
 	"testing"
+	"time"
 
 	"github.com/gobuffalo/pop"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/zap"
 
 	"github.com/transcom/mymove/pkg/auth"
+	"github.com/transcom/mymove/pkg/models"
 	"github.com/transcom/mymove/pkg/testdatagen"
 )
 
@@ -85,6 +87,139 @@ func (suite *NotificationSuite) TestMoveSubmitted() {
 	suite.NotEmpty(email.textBody)
 }
 
+func (suite *NotificationSuite) TestShipmentOfferAwarded() {
+	t := suite.T()
+
+	offer := testdatagen.MakeDefaultShipmentOffer(suite.db)
+	tspUser := testdatagen.MakeTspUser(suite.db, testdatagen.Assertions{
+		TspUser: models.TspUser{
+			TransportationServiceProviderID: offer.TransportationServiceProviderID,
+		},
+	})
+	notification := NewShipmentOfferAwarded(suite.db, suite.logger, offer.ID)
+
+	emails, err := notification.emails()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suite.Equal(1, len(emails))
+
+	email := emails[0]
+	suite.Equal(tspUser.Email, email.recipientEmail)
+	suite.NotEmpty(email.subject)
+	suite.NotEmpty(email.textBody)
+}
+
+func (suite *NotificationSuite) TestShipmentOfferExpiringAfterResponse() {
+	t := suite.T()
+
+	accepted := true
+	offer := testdatagen.MakeShipmentOffer(suite.db, testdatagen.Assertions{
+		ShipmentOffer: models.ShipmentOffer{
+			Accepted: &accepted,
+		},
+	})
+	testdatagen.MakeTspUser(suite.db, testdatagen.Assertions{
+		TspUser: models.TspUser{
+			TransportationServiceProviderID: offer.TransportationServiceProviderID,
+		},
+	})
+	notification := NewShipmentOfferExpiring(suite.db, suite.logger, offer.ID)
+
+	emails, err := notification.emails()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Accepted offers can't expire, so there is nobody to warn
+	suite.Empty(emails)
+}
+
+func (suite *NotificationSuite) TestShipmentPickedUp() {
+	t := suite.T()
+
+	pickupDate := time.Now()
+	shipment := testdatagen.MakeShipment(suite.db, testdatagen.Assertions{
+		Shipment: models.Shipment{
+			Status:           models.ShipmentStatusINTRANSIT,
+			ActualPickupDate: &pickupDate,
+		},
+	})
+	agent := testdatagen.MakeServiceAgent(suite.db, testdatagen.Assertions{
+		ServiceAgent: models.ServiceAgent{
+			Shipment: &shipment,
+			Role:     models.RoleDESTINATION,
+		},
+	})
+	notification := NewShipmentPickedUp(suite.db, suite.logger, shipment.ID)
+
+	emails, err := notification.emails()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suite.Equal(2, len(emails))
+	suite.Equal(*shipment.ServiceMember.PersonalEmail, emails[0].recipientEmail)
+	suite.Equal(*agent.Email, emails[1].recipientEmail)
+}
+
+func (suite *NotificationSuite) TestShipmentDelivered() {
+	t := suite.T()
+
+	deliveryDate := time.Now()
+	shipment := testdatagen.MakeShipment(suite.db, testdatagen.Assertions{
+		Shipment: models.Shipment{
+			Status:             models.ShipmentStatusDELIVERED,
+			ActualDeliveryDate: &deliveryDate,
+		},
+	})
+	notification := NewShipmentDelivered(suite.db, suite.logger, shipment.ID)
+
+	emails, err := notification.emails()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suite.Equal(1, len(emails))
+
+	email := emails[0]
+	suite.Equal(*shipment.ServiceMember.PersonalEmail, email.recipientEmail)
+	suite.NotEmpty(email.subject)
+	suite.NotEmpty(email.htmlBody)
+	suite.NotEmpty(email.textBody)
+}
+
+func (suite *NotificationSuite) TestShipmentLineItemDenied() {
+	t := suite.T()
+
+	lineItem := testdatagen.MakeDefaultShipmentLineItem(suite.db)
+	accepted := true
+	offer := testdatagen.MakeShipmentOffer(suite.db, testdatagen.Assertions{
+		ShipmentOffer: models.ShipmentOffer{
+			ShipmentID: lineItem.ShipmentID,
+			Accepted:   &accepted,
+		},
+	})
+	tspUser := testdatagen.MakeTspUser(suite.db, testdatagen.Assertions{
+		TspUser: models.TspUser{
+			TransportationServiceProviderID: offer.TransportationServiceProviderID,
+		},
+	})
+	notification := NewShipmentLineItemReviewed(suite.db, suite.logger, lineItem, false)
+
+	emails, err := notification.emails()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suite.Equal(1, len(emails))
+
+	email := emails[0]
+	suite.Equal(tspUser.Email, email.recipientEmail)
+	suite.Contains(email.textBody, "denied")
+}
+
 func (suite *NotificationSuite) GetTestEmailContent() emailContent {
 	return emailContent{
 		recipientEmail: "lucky@winner.com",
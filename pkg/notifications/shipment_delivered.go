@@ -0,0 +1,57 @@
+package notifications
+
+import (
+	"fmt"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"go.uber.org/zap"
+)
+
+// ShipmentDelivered has notification content for shipments the TSP has delivered
+type ShipmentDelivered struct {
+	db         *pop.Connection
+	logger     *zap.Logger
+	shipmentID uuid.UUID
+}
+
+// NewShipmentDelivered returns a new shipment delivered notification
+func NewShipmentDelivered(db *pop.Connection,
+	logger *zap.Logger,
+	shipmentID uuid.UUID) *ShipmentDelivered {
+
+	return &ShipmentDelivered{
+		db:         db,
+		logger:     logger,
+		shipmentID: shipmentID,
+	}
+}
+
+func (m ShipmentDelivered) emails() ([]emailContent, error) {
+	shipment, err := fetchShipmentForNotification(m.db, m.shipmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if shipment.ActualDeliveryDate == nil {
+		return nil, fmt.Errorf("shipment %s has not been delivered", shipment.ID)
+	}
+
+	recipient, err := serviceMemberRecipient(shipment.ServiceMember)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveredText := fmt.Sprintf("Your household goods shipment for move %s was delivered on %s.",
+		shipment.Move.Locator, shipment.ActualDeliveryDate.Format(shipmentDateFormat))
+	closingText := "If anything is missing or damaged, contact your moving company to file a claim."
+
+	smEmail := emailContent{
+		recipientEmail: recipient,
+		subject:        "MOVE.MIL: Your shipment has been delivered.",
+		htmlBody:       fmt.Sprintf("%s<br/>%s", deliveredText, closingText),
+		textBody:       fmt.Sprintf("%s\n%s", deliveredText, closingText),
+	}
+
+	return []emailContent{smEmail}, nil
+}
@@ -0,0 +1,74 @@
+package notifications
+
+import (
+	"fmt"
+
+	"github.com/gobuffalo/pop"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/models"
+)
+
+// ShipmentLineItemReviewed has notification content for accessorials the office has approved or denied
+type ShipmentLineItemReviewed struct {
+	db               *pop.Connection
+	logger           *zap.Logger
+	shipmentLineItem models.ShipmentLineItem
+	approved         bool
+}
+
+// NewShipmentLineItemReviewed returns a new shipment line item reviewed notification.
+// The line item is passed by value since a denied line item may no longer exist.
+func NewShipmentLineItemReviewed(db *pop.Connection,
+	logger *zap.Logger,
+	shipmentLineItem models.ShipmentLineItem,
+	approved bool) *ShipmentLineItemReviewed {
+
+	return &ShipmentLineItemReviewed{
+		db:               db,
+		logger:           logger,
+		shipmentLineItem: shipmentLineItem,
+		approved:         approved,
+	}
+}
+
+func (m ShipmentLineItemReviewed) emails() ([]emailContent, error) {
+	shipment, err := fetchShipmentForNotification(m.db, m.shipmentLineItem.ShipmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the TSP that accepted the shipment is told; before that there is nobody to tell
+	var recipients []string
+	offer, err := models.FetchAcceptedShipmentOffer(m.db, shipment.ID)
+	if err == nil {
+		recipients, err = tspUserRecipients(m.db, offer.TransportationServiceProviderID)
+		if err != nil {
+			return nil, err
+		}
+	} else if err != models.ErrFetchNotFound {
+		return nil, err
+	}
+
+	// The agent doing the work at the line item's location needs to know whether to go ahead
+	role := models.RoleORIGIN
+	if m.shipmentLineItem.Location == models.ShipmentLineItemLocationDESTINATION {
+		role = models.RoleDESTINATION
+	}
+	agentRecipients, err := serviceAgentRecipients(m.db, shipment.ID, role)
+	if err != nil {
+		return nil, err
+	}
+	recipients = append(recipients, agentRecipients...)
+
+	decision := "denied"
+	if m.approved {
+		decision = "approved"
+	}
+
+	item := m.shipmentLineItem.Tariff400ngItem
+	text := fmt.Sprintf("The request for %s %s (%s) on %s has been %s by the transportation office.",
+		item.Code, item.Item, m.shipmentLineItem.Location, shipmentReference(shipment), decision)
+
+	return emailsForRecipients(recipients, fmt.Sprintf("MOVE.MIL: An accessorial request has been %s.", decision), text, text), nil
+}
@@ -0,0 +1,64 @@
+package notifications
+
+import (
+	"fmt"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/models"
+)
+
+// ShipmentOfferAwarded has notification content for TSPs who have been offered a new shipment
+type ShipmentOfferAwarded struct {
+	db              *pop.Connection
+	logger          *zap.Logger
+	shipmentOfferID uuid.UUID
+}
+
+// NewShipmentOfferAwarded returns a new shipment offer awarded notification
+func NewShipmentOfferAwarded(db *pop.Connection,
+	logger *zap.Logger,
+	shipmentOfferID uuid.UUID) *ShipmentOfferAwarded {
+
+	return &ShipmentOfferAwarded{
+		db:              db,
+		logger:          logger,
+		shipmentOfferID: shipmentOfferID,
+	}
+}
+
+func (m ShipmentOfferAwarded) emails() ([]emailContent, error) {
+	var offer models.ShipmentOffer
+	if err := m.db.Find(&offer, m.shipmentOfferID); err != nil {
+		return nil, err
+	}
+
+	shipment, err := fetchShipmentForNotification(m.db, offer.ShipmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients, err := tspUserRecipients(m.db, offer.TransportationServiceProviderID)
+	if err != nil {
+		return nil, err
+	}
+
+	pickupText := ""
+	if shipment.RequestedPickupDate != nil {
+		pickupText = fmt.Sprintf("The requested pickup date is %s. ", shipment.RequestedPickupDate.Format(shipmentDateFormat))
+	}
+	introText := fmt.Sprintf("You have been offered a new shipment for %s. ", shipmentReference(shipment))
+	acceptText := fmt.Sprintf("Please accept or reject this offer by %s.", offer.ExpiresAt().Format(shipmentDateFormat))
+	if offer.AdministrativeShipment {
+		acceptText = "This is an administrative shipment that falls during one of your blackout periods. No action is needed."
+	}
+
+	text := introText + pickupText + acceptText
+	m.logger.Info("Generated shipment offer awarded emails to TSP users",
+		zap.String("shipment_offer_id", offer.ID.String()),
+		zap.Int("recipient_count", len(recipients)))
+
+	return emailsForRecipients(recipients, "MOVE.MIL: You have been offered a new shipment.", text, text), nil
+}
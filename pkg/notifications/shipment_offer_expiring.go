@@ -0,0 +1,60 @@
+package notifications
+
+import (
+	"fmt"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/models"
+)
+
+// ShipmentOfferExpiring has notification content for TSPs whose shipment offer is about to expire
+type ShipmentOfferExpiring struct {
+	db              *pop.Connection
+	logger          *zap.Logger
+	shipmentOfferID uuid.UUID
+}
+
+// NewShipmentOfferExpiring returns a new shipment offer expiring notification
+func NewShipmentOfferExpiring(db *pop.Connection,
+	logger *zap.Logger,
+	shipmentOfferID uuid.UUID) *ShipmentOfferExpiring {
+
+	return &ShipmentOfferExpiring{
+		db:              db,
+		logger:          logger,
+		shipmentOfferID: shipmentOfferID,
+	}
+}
+
+func (m ShipmentOfferExpiring) emails() ([]emailContent, error) {
+	var offer models.ShipmentOffer
+	if err := m.db.Find(&offer, m.shipmentOfferID); err != nil {
+		return nil, err
+	}
+
+	// Nothing to warn about once the TSP has responded
+	if offer.Accepted != nil {
+		return nil, nil
+	}
+
+	shipment, err := fetchShipmentForNotification(m.db, offer.ShipmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients, err := tspUserRecipients(m.db, offer.TransportationServiceProviderID)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := offer.ExpiresAt()
+	text := fmt.Sprintf("Your offer for %s expires on %s at %s UTC. Please accept or reject it before then.",
+		shipmentReference(shipment),
+		expiresAt.Format(shipmentDateFormat),
+		expiresAt.UTC().Format("15:04"))
+
+	return emailsForRecipients(recipients, "MOVE.MIL: A shipment offer is about to expire.", text, text), nil
+}
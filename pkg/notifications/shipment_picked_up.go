@@ -0,0 +1,75 @@
+package notifications
+
+import (
+	"fmt"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/models"
+)
+
+// ShipmentPickedUp has notification content for shipments the TSP has picked up
+type ShipmentPickedUp struct {
+	db         *pop.Connection
+	logger     *zap.Logger
+	shipmentID uuid.UUID
+}
+
+// NewShipmentPickedUp returns a new shipment picked up notification
+func NewShipmentPickedUp(db *pop.Connection,
+	logger *zap.Logger,
+	shipmentID uuid.UUID) *ShipmentPickedUp {
+
+	return &ShipmentPickedUp{
+		db:         db,
+		logger:     logger,
+		shipmentID: shipmentID,
+	}
+}
+
+func (m ShipmentPickedUp) emails() ([]emailContent, error) {
+	shipment, err := fetchShipmentForNotification(m.db, m.shipmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if shipment.ActualPickupDate == nil {
+		return nil, fmt.Errorf("shipment %s has not been picked up", shipment.ID)
+	}
+
+	smEmail, err := serviceMemberRecipient(shipment.ServiceMember)
+	if err != nil {
+		return nil, err
+	}
+
+	pickupDate := shipment.ActualPickupDate.Format(shipmentDateFormat)
+	destination := shipment.Move.Orders.NewDutyStation.Name
+
+	deliveryText := ""
+	if shipment.OriginalDeliveryDate != nil {
+		deliveryText = fmt.Sprintf(" It is expected to be delivered on %s.", shipment.OriginalDeliveryDate.Format(shipmentDateFormat))
+	}
+	smText := fmt.Sprintf("Your household goods were picked up on %s and are on their way to %s.%s",
+		pickupDate, destination, deliveryText)
+	closingText := "If you have any questions, contact your moving company."
+
+	emails := []emailContent{{
+		recipientEmail: smEmail,
+		subject:        "MOVE.MIL: Your shipment has been picked up.",
+		htmlBody:       fmt.Sprintf("%s<br/>%s", smText, closingText),
+		textBody:       fmt.Sprintf("%s\n%s", smText, closingText),
+	}}
+
+	// Let the destination agent know the shipment is on its way so they can plan delivery
+	agentRecipients, err := serviceAgentRecipients(m.db, shipment.ID, models.RoleDESTINATION)
+	if err != nil {
+		return nil, err
+	}
+	agentText := fmt.Sprintf("%s for %s was picked up on %s and is on its way to %s.",
+		shipmentReference(shipment), shipment.ServiceMember.ReverseNameLineFormat(), pickupDate, destination)
+	emails = append(emails, emailsForRecipients(agentRecipients, "MOVE.MIL: A shipment is in transit to you.", agentText, agentText)...)
+
+	return emails, nil
+}
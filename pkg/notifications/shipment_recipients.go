@@ -0,0 +1,91 @@
+package notifications
+
+import (
+	"fmt"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/transcom/mymove/pkg/models"
+)
+
+// shipmentDateFormat is how dates are written in shipment emails
+const shipmentDateFormat = "January 2, 2006"
+
+// fetchShipmentForNotification loads a shipment along with what is needed to address and write its emails
+func fetchShipmentForNotification(db *pop.Connection, shipmentID uuid.UUID) (models.Shipment, error) {
+	var shipment models.Shipment
+	err := db.Eager(
+		"ServiceMember",
+		"Move.Orders.NewDutyStation",
+		"PickupAddress",
+	).Find(&shipment, shipmentID)
+	if err != nil {
+		return shipment, errors.Wrapf(err, "could not fetch shipment %s", shipmentID)
+	}
+	return shipment, nil
+}
+
+// serviceMemberRecipient returns the address we should send shipment updates to for a service member
+func serviceMemberRecipient(serviceMember models.ServiceMember) (string, error) {
+	if serviceMember.PersonalEmail == nil || *serviceMember.PersonalEmail == "" {
+		return "", fmt.Errorf("no email found for service member")
+	}
+	return *serviceMember.PersonalEmail, nil
+}
+
+// tspUserRecipients returns the addresses of everyone who works for a TSP
+func tspUserRecipients(db *pop.Connection, tspID uuid.UUID) ([]string, error) {
+	tspUsers, err := models.FetchTspUsersByTSP(db, tspID)
+	if err != nil {
+		return nil, err
+	}
+
+	var recipients []string
+	for _, tspUser := range tspUsers {
+		if tspUser.Email != "" {
+			recipients = append(recipients, tspUser.Email)
+		}
+	}
+	return recipients, nil
+}
+
+// serviceAgentRecipients returns the addresses of the shipment's service agents with the given role.
+// Agents without an email address are skipped since they have asked to be contacted some other way.
+func serviceAgentRecipients(db *pop.Connection, shipmentID uuid.UUID, role models.Role) ([]string, error) {
+	serviceAgents, err := models.FetchServiceAgentsOnShipment(db, shipmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var recipients []string
+	for _, serviceAgent := range serviceAgents {
+		if serviceAgent.Role == role && serviceAgent.Email != nil && *serviceAgent.Email != "" {
+			recipients = append(recipients, *serviceAgent.Email)
+		}
+	}
+	return recipients, nil
+}
+
+// emailsForRecipients builds one email with the same content for each recipient
+func emailsForRecipients(recipients []string, subject string, htmlBody string, textBody string) []emailContent {
+	var emails []emailContent
+	for _, recipient := range recipients {
+		emails = append(emails, emailContent{
+			recipientEmail: recipient,
+			subject:        subject,
+			htmlBody:       htmlBody,
+			textBody:       textBody,
+		})
+	}
+	return emails
+}
+
+// shipmentReference identifies a shipment in emails by GBL number when it has one, or by move locator otherwise
+func shipmentReference(shipment models.Shipment) string {
+	if shipment.GBLNumber != nil {
+		return fmt.Sprintf("GBL %s", *shipment.GBLNumber)
+	}
+	return fmt.Sprintf("move %s", shipment.Move.Locator)
+}
@@ -9,9 +9,11 @@ import (
 	"time"
 
 	"github.com/jung-kurt/gofpdf"
+	"github.com/pkg/errors"
 
 	"github.com/transcom/mymove/pkg/gen/internalmessages"
 	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/unit"
 )
 
 var rankDisplayValue = map[internalmessages.ServiceMemberRank]string{
@@ -53,8 +55,7 @@ const (
 	imageXPos       float64 = 0
 	imageYPos       float64 = 0
 	// 0-value will be auto-calculated from aspect ratio
-	letterWidthMm  float64 = 215.9
-	letterHeightMm float64 = 0
+	imageHeightMm float64 = 0
 	// Whether the cursor should be advanced after placing image
 	flow bool = false
 	// Ties image to an existing link, either by link ID or URL
@@ -62,13 +63,11 @@ const (
 	imageLinkURL string = ""
 )
 
-func floatPtr(f float64) *float64 {
-	return &f
-}
-
 // FormLayout houses both a background image form template and the layout of individual fields
 type FormLayout struct {
 	TemplateImagePath string
+	PageSize          string
+	PageOrientation   string
 	FieldsLayout      map[string]FieldPos
 }
 
@@ -79,6 +78,8 @@ type FieldPos struct {
 	width      float64
 	fontSize   *float64
 	lineHeight *float64
+	// A time layout for dates, or a fmt pattern with a single %s for other values
+	format string
 }
 
 // FormField returns a new field position
@@ -100,7 +101,17 @@ type FormFiller struct {
 }
 
 // NewTemplateForm turns a template image and fields mapping into a FormFiller instance
+// for a letter size form
 func NewTemplateForm(templateImage io.ReadSeeker, fields map[string]FieldPos) (FormFiller, error) {
+	return newTemplateForm(templateImage, pageOrientation, pageSize, fields)
+}
+
+// NewFormFromLayout turns a template image and a form layout into a FormFiller instance
+func NewFormFromLayout(templateImage io.ReadSeeker, layout FormLayout) (FormFiller, error) {
+	return newTemplateForm(templateImage, layout.PageOrientation, layout.PageSize, layout.FieldsLayout)
+}
+
+func newTemplateForm(templateImage io.ReadSeeker, orientation string, size string, fields map[string]FieldPos) (FormFiller, error) {
 	// Determine image type
 	_, format, err := image.DecodeConfig(templateImage)
 	if err != nil {
@@ -108,9 +119,10 @@ func NewTemplateForm(templateImage io.ReadSeeker, fields map[string]FieldPos) (F
 	}
 	templateImage.Seek(0, io.SeekStart)
 
-	pdf := gofpdf.New(pageOrientation, distanceUnit, pageSize, fontDir)
+	pdf := gofpdf.New(orientation, distanceUnit, size, fontDir)
 	pdf.SetMargins(0, 0, 0)
 	pdf.AddPage()
+	pageWidth, _ := pdf.GetPageSize()
 
 	// Use provided image as document background
 	opt := gofpdf.ImageOptions{
@@ -118,7 +130,7 @@ func NewTemplateForm(templateImage io.ReadSeeker, fields map[string]FieldPos) (F
 		ReadDpi:   true,
 	}
 	pdf.RegisterImageOptionsReader("form_template", opt, templateImage)
-	pdf.Image("form_template", imageXPos, imageYPos, letterWidthMm, letterHeightMm, flow, format, imageLink, imageLinkURL)
+	pdf.Image("form_template", imageXPos, imageYPos, pageWidth, imageHeightMm, flow, format, imageLink, imageLinkURL)
 
 	pdf.SetFont(fontFamily, fontStyle, fontSize)
 
@@ -137,64 +149,107 @@ func (f *FormFiller) UseBorders() {
 
 // DrawData draws the provided data set onto the form using the fields mapping
 func (f *FormFiller) DrawData(data interface{}) error {
-	borderStr := ""
-	if f.useBorder {
-		borderStr = "1"
-	}
-
 	r := reflect.ValueOf(data)
 	for k := range f.fields {
 		fieldVal := reflect.Indirect(r).FieldByName(k)
+		if !fieldVal.IsValid() {
+			return errors.Errorf("data has no field %s", k)
+		}
 		val := fieldVal.Interface()
 
 		formField := f.fields[k]
-		f.pdf.MoveTo(formField.xPos, formField.yPos)
-
-		// Turn value into a display string depending on type, will need
-		// an explicit case for each type we're accommodating
-		var displayValue string
-		switch v := val.(type) {
-		case string:
-			displayValue = v
-		case int64:
-			displayValue = strconv.FormatInt(v, 10)
-		case time.Time:
-			displayValue = v.Format("02-Jan-2006")
-		case internalmessages.ServiceMemberRank:
-			displayValue = rankDisplayValue[v]
-		case *internalmessages.ServiceMemberRank:
-			if v != nil {
-				displayValue = rankDisplayValue[*v]
-			}
-		case internalmessages.Affiliation:
-			displayValue = string(v)
-		case *internalmessages.Affiliation:
-			if v != nil {
-				displayValue = string(*v)
-			}
-		case models.Address:
-			displayValue = v.Format()
-		case *models.Address:
-			if v != nil {
-				displayValue = v.Format()
-			}
-		default:
-			fmt.Println(v)
+		displayValue, err := formatValue(val, formField.format)
+		if err != nil {
+			return errors.Wrapf(err, "could not draw field %s", k)
 		}
+		f.drawField(formField, displayValue)
+	}
 
-		// Apply custom formatting options
-		if formField.fontSize != nil {
-			f.pdf.SetFontSize(*formField.fontSize)
-		} else {
-			f.pdf.SetFontSize(fontSize)
-		}
+	return f.pdf.Error()
+}
 
-		tempLineHeight := lineHeight
-		if formField.lineHeight != nil {
-			tempLineHeight = *formField.lineHeight
-		}
+// DrawFieldNames draws the name of each field in its place, to check a layout without any data
+func (f *FormFiller) DrawFieldNames() error {
+	for name, formField := range f.fields {
+		f.drawField(formField, name)
+	}
+	return f.pdf.Error()
+}
+
+func (f *FormFiller) drawField(formField FieldPos, displayValue string) {
+	borderStr := ""
+	if f.useBorder {
+		borderStr = "1"
+	}
+
+	f.pdf.MoveTo(formField.xPos, formField.yPos)
+
+	// Apply custom formatting options
+	if formField.fontSize != nil {
+		f.pdf.SetFontSize(*formField.fontSize)
+	} else {
+		f.pdf.SetFontSize(fontSize)
+	}
+
+	tempLineHeight := lineHeight
+	if formField.lineHeight != nil {
+		tempLineHeight = *formField.lineHeight
+	}
+
+	f.pdf.MultiCell(formField.width, tempLineHeight, displayValue, borderStr, "", false)
+}
+
+// FieldLines returns the lines that text will be wrapped into when it is drawn in the named field
+func (f *FormFiller) FieldLines(fieldName string, text string) []string {
+	formField, ok := f.fields[fieldName]
+	if !ok {
+		return nil
+	}
+
+	size := fontSize
+	if formField.fontSize != nil {
+		size = *formField.fontSize
+	}
+	f.pdf.SetFontSize(size)
+
+	var lines []string
+	for _, line := range f.pdf.SplitLines([]byte(text), formField.width) {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
 
-		f.pdf.MultiCell(formField.width, tempLineHeight, displayValue, borderStr, "", false)
+// ContinuationSection is a titled block of lines on a continuation page
+type ContinuationSection struct {
+	Heading string
+	Lines   []string
+}
+
+// AddContinuationPage adds a plain page after the form for content that doesn't fit in its
+// fields. Further pages are added if the sections don't fit on one.
+func (f *FormFiller) AddContinuationPage(title string, sections []ContinuationSection) error {
+	const margin = 15.0
+
+	f.pdf.SetMargins(margin, margin, margin)
+	f.pdf.SetAutoPageBreak(true, margin)
+	f.pdf.AddPage()
+	pageWidth, _ := f.pdf.GetPageSize()
+	width := pageWidth - 2*margin
+
+	f.pdf.SetFont(fontFamily, "B", 11)
+	f.pdf.MultiCell(width, 6, title, "", "", false)
+	f.pdf.Ln(4)
+
+	for _, section := range sections {
+		f.pdf.SetFont(fontFamily, "B", 9)
+		f.pdf.MultiCell(width, 5, section.Heading, "B", "", false)
+		f.pdf.Ln(1)
+
+		f.pdf.SetFont(fontFamily, fontStyle, 9)
+		for _, line := range section.Lines {
+			f.pdf.MultiCell(width, 4.5, line, "", "", false)
+		}
+		f.pdf.Ln(4)
 	}
 
 	return f.pdf.Error()
@@ -204,3 +259,74 @@ func (f *FormFiller) DrawData(data interface{}) error {
 func (f *FormFiller) Output(output io.Writer) error {
 	return f.pdf.Output(output)
 }
+
+// formatValue turns a value into a display string depending on its type, and returns an
+// error for types that can't be drawn. New types need an explicit case here.
+func formatValue(val interface{}, format string) (string, error) {
+	dateFormat := "02-Jan-2006"
+	if format != "" {
+		dateFormat = format
+	}
+
+	var displayValue string
+	switch v := val.(type) {
+	case string:
+		displayValue = v
+	case int64:
+		displayValue = strconv.FormatInt(v, 10)
+	case *unit.DiscountRate:
+		if v != nil {
+			displayValue = fmt.Sprintf("%.2f%%", v.Float64()*100.0)
+		}
+	case *bool:
+		// Checkboxes on a form are marked with an X
+		if v != nil && *v {
+			displayValue = "X"
+		}
+	case time.Time:
+		return v.Format(dateFormat), nil
+	case *time.Time:
+		if v != nil {
+			return v.Format(dateFormat), nil
+		}
+		return "", nil
+	case *unit.Pound:
+		if v != nil {
+			displayValue = fmt.Sprintf("%d lbs", v.Int())
+		}
+	case *unit.Cents:
+		if v != nil {
+			displayValue = v.ToDollarString()
+		}
+	case *models.SignedCertification:
+		if v != nil {
+			return v.Signature + " " + v.Date.Format(dateFormat), nil
+		}
+		return "", nil
+	case internalmessages.ServiceMemberRank:
+		displayValue = rankDisplayValue[v]
+	case *internalmessages.ServiceMemberRank:
+		if v != nil {
+			displayValue = rankDisplayValue[*v]
+		}
+	case internalmessages.Affiliation:
+		displayValue = string(v)
+	case *internalmessages.Affiliation:
+		if v != nil {
+			displayValue = string(*v)
+		}
+	case models.Address:
+		displayValue = v.Format()
+	case *models.Address:
+		if v != nil {
+			displayValue = v.Format()
+		}
+	default:
+		return "", errors.Errorf("can't draw a %T", val)
+	}
+
+	if format != "" && displayValue != "" {
+		displayValue = fmt.Sprintf(format, displayValue)
+	}
+	return displayValue, nil
+}
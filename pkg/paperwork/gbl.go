@@ -0,0 +1,217 @@
+package paperwork
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/go-openapi/swag"
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/assets"
+	"github.com/transcom/mymove/pkg/gen/internalmessages"
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/rateengine"
+	"github.com/transcom/mymove/pkg/route"
+	"github.com/transcom/mymove/pkg/uploader"
+)
+
+// gblRemarksMaxLines is how many lines fit in the remarks block of Form 1203
+const gblRemarksMaxLines = 4
+
+const gblContinuedRemark = "Continued on page 2."
+
+// GenerateGBL fills Form 1203 for a shipment and writes it to a PDF in fs. Charges are
+// filled in from the rate engine once the shipment has been weighed and picked up, so the
+// GBL can be regenerated as the shipment progresses, and it fails if they can't be priced
+// by then. Remarks that don't fit on the form,
+// and the itemized accessorials, go on a continuation page.
+func GenerateGBL(db *pop.Connection, logger *zap.Logger, planner route.Planner, fs *afero.Afero, shipmentID uuid.UUID, gbl models.GovBillOfLadingExtractor) (afero.File, error) {
+	if err := fillGBLCharges(db, logger, planner, shipmentID, &gbl); err != nil {
+		return nil, err
+	}
+
+	formLayout, err := Form1203Layout()
+	if err != nil {
+		return nil, err
+	}
+	template, err := assets.Asset(formLayout.TemplateImagePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read GBL template")
+	}
+
+	form, err := NewFormFromLayout(bytes.NewReader(template), formLayout)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not initialize GBL template form")
+	}
+
+	continuation := gblContinuation(&form, &gbl)
+	if err := form.DrawData(gbl); err != nil {
+		return nil, errors.Wrap(err, "could not write GBL data to form")
+	}
+	if len(continuation) > 0 {
+		title := fmt.Sprintf("Government Bill of Lading %s - Continuation", gbl.GBLNumber1)
+		if err := form.AddContinuationPage(title, continuation); err != nil {
+			return nil, errors.Wrap(err, "could not add GBL continuation page")
+		}
+	}
+
+	output, err := fs.Create(gbl.GBLNumber1 + ".pdf")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create GBL file")
+	}
+	if err := form.Output(output); err != nil {
+		return nil, errors.Wrap(err, "could not export GBL form to file")
+	}
+	return output, nil
+}
+
+// fillGBLCharges runs the rate engine on a weighed, picked up shipment. Until then the
+// charges are left for the TSP to enter, but once the shipment can be priced a GBL isn't
+// issued with the charges missing.
+func fillGBLCharges(db *pop.Connection, logger *zap.Logger, planner route.Planner, shipmentID uuid.UUID, gbl *models.GovBillOfLadingExtractor) error {
+	if gbl.WeightNetPounds == nil || gbl.DateOfReceiptOfShipment == nil {
+		return nil
+	}
+
+	var shipment models.Shipment
+	err := db.Eager(
+		"PickupAddress",
+		"SecondaryPickupAddress",
+		"PartialSITDeliveryAddress",
+		"Move.Orders.NewDutyStation.Address",
+		"ServiceMember",
+		"ShipmentOffers.TransportationServiceProviderPerformance",
+	).Find(&shipment, shipmentID)
+	if err != nil {
+		return errors.Wrap(err, "could not fetch shipment to price GBL")
+	}
+
+	engine := rateengine.NewRateEngine(db, logger, planner)
+	shipmentCost, err := engine.HandleRunOnShipment(shipment)
+	if err != nil {
+		return errors.Wrap(err, "could not price GBL")
+	}
+
+	linehaul := shipmentCost.Cost.LinehaulChargeTotal
+	packingUnpacking := shipmentCost.Cost.PackFee.AddCents(shipmentCost.Cost.UnpackFee)
+	gbl.LineHaulTransportationCharges = &linehaul
+	gbl.PackingUnpackingCharges = &packingUnpacking
+	return nil
+}
+
+// gblContinuation returns the continuation page sections for a GBL, and cuts the remarks
+// down to what fits on the form
+func gblContinuation(form *FormFiller, gbl *models.GovBillOfLadingExtractor) []ContinuationSection {
+	var sections []ContinuationSection
+
+	remarks := gbl.Remarks
+	if len(gbl.Accessorials) > 0 {
+		remarks += "\nOther accessorial services are itemized on page 2."
+	}
+
+	lines := form.FieldLines("Remarks", remarks)
+	if len(lines) > gblRemarksMaxLines {
+		sections = append(sections, ContinuationSection{
+			Heading: "25. Remarks",
+			Lines:   strings.Split(remarks, "\n"),
+		})
+		remarks = strings.Join(append(lines[:gblRemarksMaxLines-1], gblContinuedRemark), "\n")
+	}
+	gbl.Remarks = remarks
+
+	if len(gbl.Accessorials) > 0 {
+		var accessorials []string
+		for _, item := range gbl.Accessorials {
+			amount := "Not yet priced"
+			if item.AmountCents != nil {
+				amount = item.AmountCents.ToDollarString()
+			}
+			accessorials = append(accessorials, fmt.Sprintf("%s  %s  (quantity %s)  %s",
+				item.Tariff400ngItem.Code, item.Tariff400ngItem.Item, item.Quantity1.ToUnitFloatString(), amount))
+		}
+		if gbl.OtherAccessorialServices != nil {
+			accessorials = append(accessorials, "Total: "+gbl.OtherAccessorialServices.ToDollarString())
+		}
+		sections = append(sections, ContinuationSection{
+			Heading: "30. Other/Accessorial Services",
+			Lines:   accessorials,
+		})
+	}
+
+	return sections
+}
+
+// SaveGBL stores a generated GBL as the shipment's GBL move document. The first GBL creates
+// the move document; regenerating it replaces the document's uploads, so there is only ever
+// one current GBL for a shipment. The new GBL is saved and the old one removed in a single
+// transaction, so a failure leaves the previous GBL in place.
+func SaveGBL(db *pop.Connection, upl *uploader.Uploader, move models.Move, shipmentID uuid.UUID, userID uuid.UUID, file afero.File) (*models.MoveDocument, *validate.Errors, error) {
+	var existing models.MoveDocuments
+	err := db.Eager("Document.Uploads").
+		Where("move_document_type = ?", models.MoveDocumentTypeGOVBILLOFLADING).
+		Where("shipment_id = ?", shipmentID).
+		All(&existing)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not fetch existing GBL")
+	}
+
+	var moveDoc *models.MoveDocument
+	var responseError error
+	responseVErrors := validate.NewErrors()
+
+	db.Transaction(func(db *pop.Connection) error {
+		transactionError := errors.New("Rollback The transaction")
+		txUploader := upl.WithTransaction(db)
+
+		if len(existing) == 0 {
+			upload, verrs, err := txUploader.CreateUpload(nil, userID, file)
+			if err != nil || verrs.HasAny() {
+				responseVErrors.Append(verrs)
+				responseError = err
+				return transactionError
+			}
+			moveDoc, verrs, err = move.CreateMoveDocumentWithoutTransaction(db,
+				models.Uploads{*upload},
+				&shipmentID,
+				models.MoveDocumentTypeGOVBILLOFLADING,
+				"Government Bill Of Lading",
+				swag.String(""),
+				string(internalmessages.SelectedMoveTypeHHG),
+			)
+			if err != nil || verrs.HasAny() {
+				responseVErrors.Append(verrs)
+				responseError = err
+				return transactionError
+			}
+			return nil
+		}
+
+		moveDoc = &existing[0]
+		previous := moveDoc.Document.Uploads
+		upload, verrs, err := txUploader.CreateUpload(&moveDoc.DocumentID, userID, file)
+		if err != nil || verrs.HasAny() {
+			responseVErrors.Append(verrs)
+			responseError = err
+			return transactionError
+		}
+		for i := range previous {
+			if err := txUploader.DeleteUpload(&previous[i]); err != nil {
+				responseError = errors.Wrap(err, "could not remove previous GBL")
+				return transactionError
+			}
+		}
+		moveDoc.Document.Uploads = models.Uploads{*upload}
+		return nil
+	})
+
+	if responseError != nil || responseVErrors.HasAny() {
+		return nil, responseVErrors, responseError
+	}
+	return moveDoc, responseVErrors, nil
+}
@@ -0,0 +1,73 @@
+package paperwork
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/route"
+	"github.com/transcom/mymove/pkg/unit"
+)
+
+func (suite *PaperworkSuite) TestGBLContinuation() {
+	f, err := os.Open("./testdata/example_template.png")
+	suite.FatalNil(err)
+	defer f.Close()
+
+	layout, err := Form1203Layout()
+	suite.FatalNil(err)
+	form, err := NewFormFromLayout(f, layout)
+	suite.FatalNil(err)
+
+	// Short remarks without accessorials fit on the form
+	gbl := models.GovBillOfLadingExtractor{Remarks: "Direct Delivery Requested"}
+	sections := gblContinuation(&form, &gbl)
+	suite.Empty(sections)
+	suite.Equal("Direct Delivery Requested", gbl.Remarks)
+
+	// Long remarks and accessorials go on the continuation page
+	amount := unit.Cents(12345)
+	longRemarks := strings.Repeat("The shipment includes items that need explaining. ", 20)
+	gbl = models.GovBillOfLadingExtractor{
+		Remarks: longRemarks,
+		Accessorials: []models.ShipmentLineItem{
+			{
+				Tariff400ngItem: models.Tariff400ngItem{Code: "105B", Item: "Pack Reg Crate"},
+				Quantity1:       unit.BaseQuantity(10000),
+				AmountCents:     &amount,
+			},
+		},
+		OtherAccessorialServices: &amount,
+	}
+	sections = gblContinuation(&form, &gbl)
+	suite.Len(sections, 2)
+	suite.Equal(longRemarks, sections[0].Lines[0])
+	suite.Contains(sections[1].Lines[0], "105B")
+	suite.Contains(sections[1].Lines[0], "$123.45")
+	suite.Len(form.FieldLines("Remarks", gbl.Remarks), gblRemarksMaxLines)
+	suite.True(strings.HasSuffix(gbl.Remarks, gblContinuedRemark))
+
+	suite.FatalNil(form.DrawData(gbl))
+	suite.FatalNil(form.AddContinuationPage("Continuation", sections))
+	suite.Equal(2, form.pdf.PageCount())
+}
+
+func (suite *PaperworkSuite) TestGBLChargesFailWhenUnpriced() {
+	planner := route.NewTestingPlanner(1044)
+	shipmentID := uuid.Must(uuid.NewV4())
+
+	// Charges aren't needed until the shipment has been weighed and picked up
+	gbl := models.GovBillOfLadingExtractor{}
+	suite.NoError(fillGBLCharges(suite.db, suite.logger, planner, shipmentID, &gbl))
+	suite.Nil(gbl.LineHaulTransportationCharges)
+
+	// After that, a GBL that can't be priced isn't issued
+	weight := unit.Pound(2000)
+	pickedUp := time.Now()
+	gbl = models.GovBillOfLadingExtractor{WeightNetPounds: &weight, DateOfReceiptOfShipment: &pickedUp}
+	suite.Error(fillGBLCharges(suite.db, suite.logger, planner, shipmentID, &gbl))
+	suite.Nil(gbl.LineHaulTransportationCharges)
+}
@@ -58,6 +58,12 @@ func NewGenerator(db *pop.Connection, logger *zap.Logger, uploader *uploader.Upl
 	}, nil
 }
 
+// Cleanup removes the generator's working directory and everything in it. Files that
+// the generator returned can't be used afterwards.
+func (g *Generator) Cleanup() error {
+	return errors.WithStack(g.fs.RemoveAll(g.workDir))
+}
+
 type inputFile struct {
 	Path        string
 	ContentType string
@@ -95,6 +101,10 @@ func (g *Generator) ConvertUploadsToPDF(uploads models.Uploads) ([]string, error
 	images := make([]inputFile, 0)
 
 	for _, upload := range uploads {
+		if upload.IsQuarantined() {
+			return nil, errors.Wrapf(models.ErrUploadQuarantined, "Upload %s can't be merged", upload.ID)
+		}
+
 		if upload.ContentType == "application/pdf" {
 			if len(images) > 0 {
 				// We want to retain page order and will generate a PDF for images
@@ -152,7 +162,8 @@ func (g *Generator) ConvertUploadsToPDF(uploads models.Uploads) ([]string, error
 	return pdfs, nil
 }
 
-// convert between image MIME types and the values expected by gofpdf
+// convert between image MIME types and the values expected by gofpdf. Uploaded images
+// are normalized to JPEG or PNG when they are created, see uploader.ImageNormalizer.
 var contentTypeToImageType = map[string]string{
 	"image/jpeg": "JPG",
 	"image/png":  "PNG",
@@ -0,0 +1,156 @@
+package paperwork
+
+import (
+	"context"
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/uploader"
+)
+
+// DefaultJobPollInterval is how often an idle JobWorker checks for new jobs
+const DefaultJobPollInterval = 5 * time.Second
+
+// JobWorker generates the paperwork for queued paperwork jobs and stores the result as an
+// upload. Any number of workers can run against the same database.
+type JobWorker struct {
+	db           *pop.Connection
+	logger       *zap.Logger
+	uploader     *uploader.Uploader
+	pollInterval time.Duration
+}
+
+// NewJobWorker creates a new JobWorker
+func NewJobWorker(db *pop.Connection, logger *zap.Logger, uploader *uploader.Uploader, pollInterval time.Duration) *JobWorker {
+	return &JobWorker{
+		db:           db,
+		logger:       logger,
+		uploader:     uploader,
+		pollInterval: pollInterval,
+	}
+}
+
+// Run processes jobs until the context is cancelled, checking for new jobs every poll
+// interval once the queue is empty
+func (w *JobWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := w.ProcessNextJob()
+			if err != nil {
+				w.logger.Error("Error while processing paperwork jobs", zap.Error(err))
+				break
+			}
+			if !processed || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessNextJob claims the next job that is ready to run and runs it. It returns false
+// if there was no job to run. A job that fails is recorded on the job and queued for a
+// retry; the error returned is only for problems with the queue itself.
+func (w *JobWorker) ProcessNextJob() (bool, error) {
+	job, err := models.ClaimPaperworkJob(w.db, time.Now())
+	if err != nil {
+		return false, err
+	}
+	if job == nil {
+		return false, nil
+	}
+
+	logger := w.logger.With(
+		zap.String("paperwork_job_id", job.ID.String()),
+		zap.String("job_type", string(job.JobType)),
+		zap.Int("attempt", job.Attempts),
+	)
+	logger.Info("Running paperwork job")
+
+	upload, err := w.runJob(*job)
+	if err != nil {
+		logger.Warn("Paperwork job failed", zap.Error(err))
+		err = job.Fail(err, time.Now())
+	} else {
+		logger.Info("Paperwork job completed", zap.String("upload_id", upload.ID.String()))
+		err = job.Complete(*upload, time.Now())
+	}
+	if err != nil {
+		return true, err
+	}
+
+	saved, err := models.SavePaperworkJobResult(w.db, job, time.Now())
+	if err != nil {
+		return true, errors.Wrap(err, "could not save paperwork job")
+	}
+	if !saved {
+		logger.Warn("Paperwork job was reclaimed while it ran, dropping its result")
+		if upload != nil {
+			if err := w.uploader.DeleteUpload(upload); err != nil {
+				return true, errors.Wrap(err, "could not remove dropped paperwork")
+			}
+		}
+	}
+	return true, nil
+}
+
+// runJob generates a job's paperwork in a fresh working directory, which is removed
+// whether or not the job succeeds
+func (w *JobWorker) runJob(job models.PaperworkJob) (*models.Upload, error) {
+	generator, err := NewGenerator(w.db, w.logger, w.uploader)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := generator.Cleanup(); err != nil {
+			w.logger.Error("Could not remove paperwork job working directory", zap.Error(err))
+		}
+	}()
+
+	var ppm models.PersonallyProcuredMove
+	var packet afero.File
+	switch job.JobType {
+	case models.PaperworkJobTypePPMATTACHMENTS:
+		err = w.db.Eager("Move.Orders.UploadedOrders.Uploads").Find(&ppm, job.PersonallyProcuredMoveID)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not fetch PPM")
+		}
+		packet, err = GeneratePPMAttachments(generator, ppm, job.DocTypeList())
+	case models.PaperworkJobTypePPMCLOSEOUT:
+		if job.IncentiveCents == nil {
+			return nil, errors.New("closeout job has no incentive")
+		}
+		err = w.db.Eager("Move.Orders.ServiceMember", "Advance").Find(&ppm, job.PersonallyProcuredMoveID)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not fetch PPM")
+		}
+		packet, err = GeneratePPMCloseoutPacket(generator, ppm, *job.IncentiveCents)
+	default:
+		err = errors.Errorf("unknown paperwork job type %s", job.JobType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	upload, verrs, err := w.uploader.CreateUpload(nil, job.RequestedByUserID, packet)
+	if err != nil {
+		return nil, err
+	}
+	if verrs.HasAny() {
+		return nil, errors.Errorf("could not store paperwork: %s", verrs)
+	}
+	return upload, nil
+}
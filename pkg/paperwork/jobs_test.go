@@ -0,0 +1,79 @@
+package paperwork
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/testdatagen"
+)
+
+func (suite *PaperworkSuite) generatorDirs() []string {
+	dirs, err := afero.Glob(suite.uploader.Storer.FileSystem(), filepath.Join(os.TempDir(), "generator*"))
+	suite.FatalNil(err)
+	return dirs
+}
+
+func (suite *PaperworkSuite) TestJobWorker() {
+	_, order := suite.setupOrdersDocument()
+	move := testdatagen.MakeMove(suite.db, testdatagen.Assertions{Order: order})
+	ppm := testdatagen.MakePPM(suite.db, testdatagen.Assertions{
+		PersonallyProcuredMove: models.PersonallyProcuredMove{
+			Move:   move,
+			MoveID: move.ID,
+		},
+	})
+	testdatagen.MakeMoveDocument(suite.db, testdatagen.Assertions{
+		MoveDocument: models.MoveDocument{
+			PersonallyProcuredMoveID: &ppm.ID,
+			MoveDocumentType:         models.MoveDocumentTypeWEIGHTTICKET,
+			Status:                   models.MoveDocumentStatusOK,
+		},
+	})
+
+	job := models.NewPaperworkJob(models.PaperworkJobTypePPMATTACHMENTS, ppm.ID, order.ServiceMember.UserID)
+	job.SetDocTypes([]string{string(models.MoveDocumentTypeWEIGHTTICKET)})
+	suite.mustSave(&job)
+	dirs := suite.generatorDirs()
+
+	worker := NewJobWorker(suite.db, suite.logger, suite.uploader, DefaultJobPollInterval)
+	processed, err := worker.ProcessNextJob()
+	suite.FatalNil(err)
+	suite.True(processed)
+
+	suite.FatalNil(suite.db.Find(&job, job.ID))
+	suite.Equal(models.PaperworkJobStatusCOMPLETED, job.Status)
+	suite.NotNil(job.UploadID)
+	suite.NotNil(job.CompletedAt)
+	suite.Equal(dirs, suite.generatorDirs(), "the working directory was not removed")
+
+	// The queue is empty
+	processed, err = worker.ProcessNextJob()
+	suite.FatalNil(err)
+	suite.False(processed)
+}
+
+func (suite *PaperworkSuite) TestJobWorkerFailure() {
+	ppm := testdatagen.MakeDefaultPPM(suite.db)
+
+	// There are no weight tickets to merge
+	job := models.NewPaperworkJob(models.PaperworkJobTypePPMATTACHMENTS, ppm.ID, ppm.Move.Orders.ServiceMember.UserID)
+	job.SetDocTypes([]string{string(models.MoveDocumentTypeWEIGHTTICKET)})
+	suite.mustSave(&job)
+	dirs := suite.generatorDirs()
+
+	worker := NewJobWorker(suite.db, suite.logger, suite.uploader, DefaultJobPollInterval)
+	processed, err := worker.ProcessNextJob()
+	suite.FatalNil(err)
+	suite.True(processed)
+
+	suite.FatalNil(suite.db.Find(&job, job.ID))
+	suite.Equal(models.PaperworkJobStatusQUEUED, job.Status)
+	suite.Equal(1, job.Attempts)
+	suite.Equal(ErrNoAttachments.Error(), *job.LastError)
+	suite.True(job.RunAfter.After(job.UpdatedAt))
+	suite.Nil(job.UploadID)
+	suite.Equal(dirs, suite.generatorDirs(), "the working directory was not removed")
+}
@@ -0,0 +1,188 @@
+package paperwork
+
+import (
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/transcom/mymove/pkg/assets"
+	"github.com/transcom/mymove/pkg/models"
+)
+
+// formDefinition is the format of form definition files. JSON is a subset of YAML, so
+// definitions can be written in either.
+type formDefinition struct {
+	Template string `yaml:"template"`
+	Page     struct {
+		Size        string `yaml:"size"`
+		Orientation string `yaml:"orientation"`
+	} `yaml:"page"`
+	Fields map[string]fieldDefinition `yaml:"fields"`
+}
+
+type fieldDefinition struct {
+	X          float64  `yaml:"x"`
+	Y          float64  `yaml:"y"`
+	Width      float64  `yaml:"width"`
+	FontSize   *float64 `yaml:"font_size"`
+	LineHeight *float64 `yaml:"line_height"`
+	Format     string   `yaml:"format"`
+}
+
+// Portrait page dimensions in millimeters, for the page sizes that gofpdf supports
+var pageDimensions = map[string][2]float64{
+	"letter": {215.9, 279.4},
+	"legal":  {215.9, 355.6},
+	"A4":     {210, 297},
+}
+
+var pageOrientations = map[string]string{
+	"portrait":  "P",
+	"landscape": "L",
+}
+
+// bundledFormLayouts are the form definitions bundled with the server, with the data that fills each one
+var bundledFormLayouts = map[string]interface{}{
+	Form1203DefinitionPath: models.GovBillOfLadingExtractor{},
+}
+
+// ParseFormLayout parses a form definition written in YAML or JSON
+func ParseFormLayout(content []byte) (FormLayout, error) {
+	var definition formDefinition
+	if err := yaml.UnmarshalStrict(content, &definition); err != nil {
+		return FormLayout{}, errors.Wrap(err, "could not parse form definition")
+	}
+
+	if definition.Template == "" {
+		return FormLayout{}, errors.New("form definition has no template")
+	}
+	if definition.Page.Size == "" {
+		definition.Page.Size = pageSize
+	}
+	if _, ok := pageDimensions[definition.Page.Size]; !ok {
+		return FormLayout{}, errors.Errorf("unknown page size %s", definition.Page.Size)
+	}
+	if definition.Page.Orientation == "" {
+		definition.Page.Orientation = "portrait"
+	}
+	orientation, ok := pageOrientations[definition.Page.Orientation]
+	if !ok {
+		return FormLayout{}, errors.Errorf("unknown page orientation %s", definition.Page.Orientation)
+	}
+
+	layout := FormLayout{
+		TemplateImagePath: definition.Template,
+		PageSize:          definition.Page.Size,
+		PageOrientation:   orientation,
+		FieldsLayout:      map[string]FieldPos{},
+	}
+	for name, field := range definition.Fields {
+		layout.FieldsLayout[name] = FieldPos{
+			xPos:       field.X,
+			yPos:       field.Y,
+			width:      field.Width,
+			fontSize:   field.FontSize,
+			lineHeight: field.LineHeight,
+			format:     field.Format,
+		}
+	}
+	return layout, nil
+}
+
+// ReadFormLayoutFile reads a form definition from a file
+func ReadFormLayoutFile(path string) (FormLayout, error) {
+	// #nosec the path is set by whoever is running the command
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return FormLayout{}, errors.Wrap(err, "could not read form definition")
+	}
+	return ParseFormLayout(content)
+}
+
+// LoadFormLayout loads a form definition bundled with the server
+func LoadFormLayout(path string) (FormLayout, error) {
+	content, err := assets.Asset(path)
+	if err != nil {
+		return FormLayout{}, errors.Wrapf(err, "could not read form definition %s", path)
+	}
+	return ParseFormLayout(content)
+}
+
+// Validate checks that every field is on the page, and can be drawn from the field of the
+// same name in data, which is the struct that will be passed to FormFiller.DrawData
+func (l FormLayout) Validate(data interface{}) error {
+	dataType := reflect.TypeOf(data)
+	if dataType.Kind() == reflect.Ptr {
+		dataType = dataType.Elem()
+	}
+	if dataType.Kind() != reflect.Struct {
+		return errors.Errorf("forms are filled from structs, not %s", dataType)
+	}
+
+	dimensions := pageDimensions[l.PageSize]
+	pageWidth, pageHeight := dimensions[0], dimensions[1]
+	if l.PageOrientation == "L" {
+		pageWidth, pageHeight = pageHeight, pageWidth
+	}
+
+	var problems []string
+	for name, field := range l.FieldsLayout {
+		if field.xPos < 0 || field.xPos >= pageWidth || field.yPos < 0 || field.yPos >= pageHeight {
+			problems = append(problems, name+" is off the page")
+		}
+		if field.width <= 0 {
+			problems = append(problems, name+" has no width")
+		}
+		if (field.fontSize != nil && *field.fontSize <= 0) || (field.lineHeight != nil && *field.lineHeight <= 0) {
+			problems = append(problems, name+" has a font size or line height that isn't positive")
+		}
+
+		dataField, ok := dataType.FieldByName(name)
+		if !ok {
+			problems = append(problems, name+" is not a field of "+dataType.Name())
+			continue
+		}
+		if _, err := formatValue(reflect.Zero(dataField.Type).Interface(), field.format); err != nil {
+			problems = append(problems, name+": "+err.Error())
+			continue
+		}
+		if field.format != "" && !isDateType(dataField.Type) && strings.Count(field.format, "%s") != 1 {
+			problems = append(problems, name+" has a format without exactly one %s")
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.Errorf("invalid form layout for %s: %s", l.TemplateImagePath, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func isDateType(t reflect.Type) bool {
+	timeType := reflect.TypeOf(time.Time{})
+	return t == timeType || t == reflect.PtrTo(timeType) || t == reflect.TypeOf(&models.SignedCertification{})
+}
+
+// ValidateBundledFormLayouts loads every form definition bundled with the server and checks
+// it against the data that fills it, so that mistakes are found at startup rather than
+// when someone needs the form
+func ValidateBundledFormLayouts() error {
+	for path, data := range bundledFormLayouts {
+		layout, err := LoadFormLayout(path)
+		if err != nil {
+			return err
+		}
+		if _, err := assets.Asset(layout.TemplateImagePath); err != nil {
+			return errors.Wrapf(err, "could not read template for %s", path)
+		}
+		if err := layout.Validate(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package paperwork
+
+import (
+	"time"
+)
+
+type layoutTestModel struct {
+	FieldName string
+	IssueDate time.Time
+	Amounts   []int
+}
+
+func (suite *PaperworkSuite) TestParseFormLayout() {
+	yamlLayout, err := ParseFormLayout([]byte(`
+template: testdata/example_template.png
+page:
+  size: A4
+  orientation: landscape
+fields:
+  FieldName: {x: 28, y: 11, width: 79, font_size: 5.5}
+  IssueDate: {x: 28, y: 20, width: 20, format: "2006-01-02"}
+`))
+	suite.FatalNil(err)
+	suite.Equal("testdata/example_template.png", yamlLayout.TemplateImagePath)
+	suite.Equal("A4", yamlLayout.PageSize)
+	suite.Equal("L", yamlLayout.PageOrientation)
+	suite.Equal(FieldPos{xPos: 28, yPos: 20, width: 20, format: "2006-01-02"}, yamlLayout.FieldsLayout["IssueDate"])
+	suite.Equal(5.5, *yamlLayout.FieldsLayout["FieldName"].fontSize)
+
+	// JSON definitions work too, and default to a portrait letter page
+	jsonLayout, err := ParseFormLayout([]byte(`{"template": "testdata/example_template.png", "fields": {"FieldName": {"x": 28, "y": 11, "width": 79}}}`))
+	suite.FatalNil(err)
+	suite.Equal("letter", jsonLayout.PageSize)
+	suite.Equal("P", jsonLayout.PageOrientation)
+	suite.Equal(FormField(28, 11, 79, nil, nil), jsonLayout.FieldsLayout["FieldName"])
+
+	_, err = ParseFormLayout([]byte(`{"template": "t.png", "page": {"size": "tabloid"}}`))
+	suite.Error(err)
+	_, err = ParseFormLayout([]byte(`{"template": "t.png", "fields": {"FieldName": {"x": 1, "y": 1, "wdth": 10}}}`))
+	suite.Error(err, "expected misspelled keys to be rejected")
+}
+
+func (suite *PaperworkSuite) TestFormLayoutValidate() {
+	layout := FormLayout{
+		TemplateImagePath: "testdata/example_template.png",
+		PageSize:          "letter",
+		PageOrientation:   "P",
+		FieldsLayout: map[string]FieldPos{
+			"FieldName": {xPos: 28, yPos: 11, width: 79, format: "Name: %s"},
+			"IssueDate": {xPos: 28, yPos: 20, width: 20, format: "2006-01-02"},
+		},
+	}
+	suite.NoError(layout.Validate(layoutTestModel{}))
+	suite.NoError(layout.Validate(&layoutTestModel{}))
+
+	layout.FieldsLayout["Missing"] = FormField(10, 10, 10, nil, nil)
+	layout.FieldsLayout["Amounts"] = FormField(10, 10, 10, nil, nil)
+	layout.FieldsLayout["FieldName"] = FieldPos{xPos: 300, yPos: 11, width: 79, format: "Name"}
+	err := layout.Validate(layoutTestModel{})
+	suite.Error(err)
+	for _, problem := range []string{"Missing is not a field", "Amounts: can't draw", "FieldName is off the page", "FieldName has a format"} {
+		suite.Contains(err.Error(), problem)
+	}
+}
+
+func (suite *PaperworkSuite) TestBundledFormLayouts() {
+	suite.NoError(ValidateBundledFormLayouts())
+}
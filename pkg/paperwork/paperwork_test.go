@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/zap"
 
+	scannerTest "github.com/transcom/mymove/pkg/scanner/test"
 	storageTest "github.com/transcom/mymove/pkg/storage/test"
 	"github.com/transcom/mymove/pkg/uploader"
 )
@@ -102,7 +103,7 @@ func TestPaperworkSuite(t *testing.T) {
 	hs := &PaperworkSuite{
 		db:       db,
 		logger:   logger,
-		uploader: uploader.NewUploader(db, logger, storer),
+		uploader: uploader.NewUploader(db, logger, storer, scannerTest.NewFakeScanner()),
 	}
 
 	suite.Run(t, hs)
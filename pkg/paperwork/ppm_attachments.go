@@ -0,0 +1,37 @@
+package paperwork
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+
+	"github.com/transcom/mymove/pkg/models"
+)
+
+// ErrNoAttachments is returned when a PPM has no approved move documents of the requested types
+var ErrNoAttachments = errors.New("NO_ATTACHMENTS")
+
+// GeneratePPMAttachments merges a PPM's uploaded orders and its approved move documents of
+// the given types into a single PDF. The PPM must have Move.Orders.UploadedOrders.Uploads loaded.
+func GeneratePPMAttachments(g *Generator, ppm models.PersonallyProcuredMove, docTypes []string) (afero.File, error) {
+	moveDocs, err := ppm.FetchMoveDocumentsForTypes(g.db, docTypes)
+	if err != nil {
+		return nil, err
+	}
+	if len(moveDocs) == 0 {
+		return nil, ErrNoAttachments
+	}
+
+	// Start with uploaded orders info
+	uploads := ppm.Move.Orders.UploadedOrders.Uploads
+
+	// Flatten out uploads into a slice
+	for _, moveDoc := range moveDocs {
+		uploads = append(uploads, moveDoc.Document.Uploads...)
+	}
+	if len(uploads) == 0 {
+		return nil, ErrNoAttachments
+	}
+
+	// Convert to PDF and merge into single PDF
+	return g.CreateMergedPDFUpload(uploads)
+}
@@ -0,0 +1,274 @@
+package paperwork
+
+import (
+	"io"
+	"sort"
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/gofrs/uuid"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/gen/internalmessages"
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/unit"
+	"github.com/transcom/mymove/pkg/uploader"
+)
+
+var movingExpenseTypeLabels = map[models.MovingExpenseType]string{
+	models.MovingExpenseTypeCONTRACTEDEXPENSE: "Contracted Expense",
+	models.MovingExpenseTypeRENTALEQUIPMENT:   "Rental Equipment",
+	models.MovingExpenseTypePACKINGMATERIALS:  "Packing Materials",
+	models.MovingExpenseTypeWEIGHINGFEES:      "Weighing Fees",
+	models.MovingExpenseTypeGAS:               "Gas",
+	models.MovingExpenseTypeTOLLS:             "Tolls",
+	models.MovingExpenseTypeOIL:               "Oil",
+	models.MovingExpenseTypeOTHER:             "Other",
+}
+
+// CloseoutExpense is the total of a PPM's approved expenses of one type
+type CloseoutExpense struct {
+	Type  models.MovingExpenseType
+	GTCC  unit.Cents
+	Other unit.Cents
+	Total unit.Cents
+}
+
+// PPMCloseoutSummary is the computed summary page of a PPM closeout packet.
+//
+// Expenses don't change what is owed, since they reduce the taxable part of the
+// incentive rather than being paid on top of it, but finance needs them itemized on
+// the claim. The net amount owed is the incentive less any advance the service member
+// has been paid; it is negative when the service member owes money back.
+type PPMCloseoutSummary struct {
+	PPM           models.PersonallyProcuredMove
+	Expenses      []CloseoutExpense
+	ExpenseTotal  unit.Cents
+	Incentive     unit.Cents
+	Advance       unit.Cents
+	NetAmountOwed unit.Cents
+}
+
+// NewPPMCloseoutSummary totals a PPM's approved expense documents by type and works out
+// the net amount owed for an incentive
+func NewPPMCloseoutSummary(ppm models.PersonallyProcuredMove, expenseDocs models.MoveDocuments, incentive unit.Cents) PPMCloseoutSummary {
+	summary := PPMCloseoutSummary{
+		PPM:       ppm,
+		Incentive: incentive,
+	}
+
+	byType := map[models.MovingExpenseType]*CloseoutExpense{}
+	for _, moveDoc := range expenseDocs {
+		expenseDoc := moveDoc.MovingExpenseDocument
+		if expenseDoc == nil {
+			continue
+		}
+		expense, ok := byType[expenseDoc.MovingExpenseType]
+		if !ok {
+			expense = &CloseoutExpense{Type: expenseDoc.MovingExpenseType}
+			byType[expenseDoc.MovingExpenseType] = expense
+		}
+		amount := expenseDoc.RequestedAmountCents
+		if expenseDoc.PaymentMethod == string(models.MethodOfReceiptGTCC) {
+			expense.GTCC = expense.GTCC.AddCents(amount)
+		} else {
+			expense.Other = expense.Other.AddCents(amount)
+		}
+		expense.Total = expense.Total.AddCents(amount)
+		summary.ExpenseTotal = summary.ExpenseTotal.AddCents(amount)
+	}
+	for _, expense := range byType {
+		summary.Expenses = append(summary.Expenses, *expense)
+	}
+	sort.Slice(summary.Expenses, func(i, j int) bool {
+		return summary.Expenses[i].Type < summary.Expenses[j].Type
+	})
+
+	advance := ppm.Advance
+	if advance != nil && (advance.Status == models.ReimbursementStatusAPPROVED || advance.Status == models.ReimbursementStatusPAID) {
+		summary.Advance = advance.RequestedAmount
+	}
+	summary.NetAmountOwed = incentive - summary.Advance
+
+	return summary
+}
+
+// DrawForm writes the summary page as a PDF to the provided Writer
+func (s PPMCloseoutSummary) DrawForm(outputFile io.Writer) error {
+	pdf := gofpdf.New(PdfOrientation, PdfUnit, PdfPageSize, PdfFontDir)
+	pdf.SetMargins(horizontalMargin, topMargin, horizontalMargin)
+	pdf.AddPage()
+
+	pdf.SetFont(fontFace, "B", 17)
+	pdf.Cell(bodyWidth*0.75, fieldHeight*2, "PPM CLOSEOUT SUMMARY")
+	pdf.SetFont(fontFace, "", 10)
+	pdf.CellFormat(bodyWidth*0.25, fieldHeight*2, "Prepared "+time.Now().Format("2006-01-02"), "", 1, "R", false, 0, "")
+	pdf.SetLineWidth(1.0)
+	pdf.Line(0, pdf.GetY()+2, PdfPageWidth, pdf.GetY()+2)
+	pdf.Ln(6)
+
+	sm := s.PPM.Move.Orders.ServiceMember
+	closeoutRow(pdf, "Service Member", sm.ReverseNameLineFormat())
+	closeoutRow(pdf, "DoD ID", coalesce(sm.Edipi, ""))
+	closeoutRow(pdf, "Orders Number", coalesce(s.PPM.Move.Orders.OrdersNumber, ""))
+	closeoutRow(pdf, "Move Locator", s.PPM.Move.Locator)
+
+	closeoutHeader(pdf, "APPROVED EXPENSES")
+	pdf.SetFont(fontFace, "B", 9)
+	columnWidth := bodyWidth / 4
+	pdf.CellFormat(columnWidth, fieldHeight, "Expense Type", "", 0, "L", false, 0, "")
+	pdf.CellFormat(columnWidth, fieldHeight, "Paid with GTCC", "", 0, "R", false, 0, "")
+	pdf.CellFormat(columnWidth, fieldHeight, "Paid by Member", "", 0, "R", false, 0, "")
+	pdf.CellFormat(columnWidth, fieldHeight, "Total", "", 1, "R", false, 0, "")
+	pdf.SetFont(fontFace, "", 10)
+	if len(s.Expenses) == 0 {
+		pdf.CellFormat(bodyWidth, fieldHeight, "No approved expenses", "", 1, "L", false, 0, "")
+	}
+	for _, expense := range s.Expenses {
+		label, ok := movingExpenseTypeLabels[expense.Type]
+		if !ok {
+			label = string(expense.Type)
+		}
+		pdf.CellFormat(columnWidth, fieldHeight, label, "", 0, "L", false, 0, "")
+		pdf.CellFormat(columnWidth, fieldHeight, expense.GTCC.ToDollarString(), "", 0, "R", false, 0, "")
+		pdf.CellFormat(columnWidth, fieldHeight, expense.Other.ToDollarString(), "", 0, "R", false, 0, "")
+		pdf.CellFormat(columnWidth, fieldHeight, expense.Total.ToDollarString(), "", 1, "R", false, 0, "")
+	}
+	pdf.SetFont(fontFace, "B", 10)
+	pdf.CellFormat(columnWidth*3, fieldHeight, "Total Expenses", "T", 0, "L", false, 0, "")
+	pdf.CellFormat(columnWidth, fieldHeight, s.ExpenseTotal.ToDollarString(), "T", 1, "R", false, 0, "")
+
+	closeoutHeader(pdf, "PAYMENT")
+	closeoutAmountRow(pdf, "Incentive (95% of GCC)", s.Incentive)
+	closeoutAmountRow(pdf, "Less Advance Paid", s.Advance)
+	pdf.SetFont(fontFace, "B", 10)
+	if s.NetAmountOwed < 0 {
+		pdf.CellFormat(columnWidth*3, fieldHeight, "Net Amount Owed by Service Member", "T", 0, "L", false, 0, "")
+		pdf.CellFormat(columnWidth, fieldHeight, (-s.NetAmountOwed).ToDollarString(), "T", 1, "R", false, 0, "")
+	} else {
+		pdf.CellFormat(columnWidth*3, fieldHeight, "Net Amount Owed to Service Member", "T", 0, "L", false, 0, "")
+		pdf.CellFormat(columnWidth, fieldHeight, s.NetAmountOwed.ToDollarString(), "T", 1, "R", false, 0, "")
+	}
+
+	return pdf.Output(outputFile)
+}
+
+func closeoutHeader(pdf *gofpdf.Fpdf, title string) {
+	pdf.Ln(4)
+	pdf.SetFont(fontFace, "B", 10)
+	pdf.SetFillColor(221, 231, 240)
+	pdf.CellFormat(0, 7, title, "", 1, "L", true, 0, "")
+	pdf.Ln(1)
+}
+
+func closeoutRow(pdf *gofpdf.Fpdf, label string, value string) {
+	pdf.SetFont(fontFace, "B", 9)
+	pdf.Cell(bodyWidth*0.3, fieldHeight, label)
+	pdf.SetFont(fontFace, "", 10)
+	pdf.CellFormat(bodyWidth*0.7, fieldHeight, value, "", 1, "L", false, 0, "")
+}
+
+func closeoutAmountRow(pdf *gofpdf.Fpdf, label string, amount unit.Cents) {
+	pdf.SetFont(fontFace, "", 10)
+	pdf.CellFormat(bodyWidth*0.75, fieldHeight, label, "", 0, "L", false, 0, "")
+	pdf.CellFormat(bodyWidth*0.25, fieldHeight, amount.ToDollarString(), "", 1, "R", false, 0, "")
+}
+
+// GeneratePPMCloseoutPaperwork builds the closeout packet for a PPM: the summary page,
+// followed by the weight tickets and the receipts for the approved expenses. The move
+// documents must have their Document.Uploads loaded.
+func GeneratePPMCloseoutPaperwork(g *Generator, summary PPMCloseoutSummary, weightTickets models.MoveDocuments, expenseDocs models.MoveDocuments) (afero.File, error) {
+	summaryFile, err := g.newTempFile()
+	if err != nil {
+		return nil, err
+	}
+	if err := summary.DrawForm(summaryFile); err != nil {
+		return nil, errors.Wrap(err, "could not draw closeout summary")
+	}
+	summaryFile.Close()
+
+	var uploads models.Uploads
+	for _, moveDoc := range weightTickets {
+		uploads = append(uploads, moveDoc.Document.Uploads...)
+	}
+	for _, moveDoc := range expenseDocs {
+		uploads = append(uploads, moveDoc.Document.Uploads...)
+	}
+
+	inputFiles := []string{summaryFile.Name()}
+	if len(uploads) > 0 {
+		uploadPaths, err := g.ConvertUploadsToPDF(uploads)
+		if err != nil {
+			return nil, err
+		}
+		inputFiles = append(inputFiles, uploadPaths...)
+	}
+
+	g.logger.Debug("merging PPM closeout packet", zap.Any("inputFiles", inputFiles))
+	return g.MergePDFFiles(inputFiles)
+}
+
+// GeneratePPMCloseoutPacket gathers a PPM's weight tickets and approved expense documents
+// and builds its closeout packet, with the given incentive on the summary page. The PPM
+// must have Move.Orders.ServiceMember and Advance loaded.
+func GeneratePPMCloseoutPacket(g *Generator, ppm models.PersonallyProcuredMove, incentive unit.Cents) (afero.File, error) {
+	weightTickets, err := ppm.FetchMoveDocumentsForTypes(g.db, []string{string(models.MoveDocumentTypeWEIGHTTICKET)})
+	if err != nil {
+		return nil, err
+	}
+	expenseDocs, err := models.FetchApprovedMovingExpenseDocumentsForPPM(g.db, ppm.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range expenseDocs {
+		if err := g.db.Load(&expenseDocs[i], "Document.Uploads"); err != nil {
+			return nil, errors.Wrap(err, "could not load expense receipts")
+		}
+	}
+
+	summary := NewPPMCloseoutSummary(ppm, expenseDocs, incentive)
+	return GeneratePPMCloseoutPaperwork(g, summary, weightTickets, expenseDocs)
+}
+
+// SavePPMCloseoutPacket uploads a closeout packet and attaches it to the PPM as a move
+// document, in one transaction so a packet is never left without a document. The PPM must
+// have Move.Orders loaded.
+func SavePPMCloseoutPacket(db *pop.Connection, upl *uploader.Uploader, ppm models.PersonallyProcuredMove, userID uuid.UUID, file afero.File) (*models.MoveDocument, *validate.Errors, error) {
+	var moveDoc *models.MoveDocument
+	var responseError error
+	responseVErrors := validate.NewErrors()
+
+	db.Transaction(func(db *pop.Connection) error {
+		transactionError := errors.New("Rollback The transaction")
+
+		upload, verrs, err := upl.WithTransaction(db).CreateUpload(nil, userID, file)
+		if err != nil || verrs.HasAny() {
+			responseVErrors.Append(verrs)
+			responseError = err
+			return transactionError
+		}
+		moveDoc, verrs, err = ppm.Move.CreateMoveDocumentWithoutTransaction(db,
+			models.Uploads{*upload},
+			&ppm.ID,
+			models.MoveDocumentTypeOTHER,
+			"PPM Closeout Packet",
+			nil,
+			string(internalmessages.SelectedMoveTypePPM),
+		)
+		if err != nil || verrs.HasAny() {
+			responseVErrors.Append(verrs)
+			responseError = err
+			return transactionError
+		}
+		return nil
+	})
+
+	if responseError != nil || responseVErrors.HasAny() {
+		return nil, responseVErrors, responseError
+	}
+	return moveDoc, responseVErrors, nil
+}
@@ -0,0 +1,72 @@
+package paperwork
+
+import (
+	"github.com/trussworks/pdfcpu/pkg/api"
+	"github.com/trussworks/pdfcpu/pkg/pdfcpu"
+
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/unit"
+)
+
+func expenseMoveDoc(expenseType models.MovingExpenseType, paymentMethod string, amount unit.Cents) models.MoveDocument {
+	return models.MoveDocument{
+		MovingExpenseDocument: &models.MovingExpenseDocument{
+			MovingExpenseType:    expenseType,
+			PaymentMethod:        paymentMethod,
+			RequestedAmountCents: amount,
+		},
+	}
+}
+
+func (suite *PaperworkSuite) TestNewPPMCloseoutSummary() {
+	ppm := models.PersonallyProcuredMove{
+		Advance: &models.Reimbursement{
+			RequestedAmount: unit.Cents(50000),
+			Status:          models.ReimbursementStatusPAID,
+		},
+	}
+	expenseDocs := models.MoveDocuments{
+		expenseMoveDoc(models.MovingExpenseTypeTOLLS, "OTHER", unit.Cents(1200)),
+		expenseMoveDoc(models.MovingExpenseTypeGAS, "GTCC", unit.Cents(4000)),
+		expenseMoveDoc(models.MovingExpenseTypeGAS, "OTHER", unit.Cents(2500)),
+	}
+
+	summary := NewPPMCloseoutSummary(ppm, expenseDocs, unit.Cents(200000))
+	suite.Len(summary.Expenses, 2)
+	suite.Equal(CloseoutExpense{
+		Type:  models.MovingExpenseTypeGAS,
+		GTCC:  unit.Cents(4000),
+		Other: unit.Cents(2500),
+		Total: unit.Cents(6500),
+	}, summary.Expenses[0])
+	suite.Equal(models.MovingExpenseTypeTOLLS, summary.Expenses[1].Type)
+	suite.Equal(unit.Cents(7700), summary.ExpenseTotal)
+	suite.Equal(unit.Cents(50000), summary.Advance)
+	suite.Equal(unit.Cents(150000), summary.NetAmountOwed)
+
+	// An advance that hasn't been approved isn't deducted
+	ppm.Advance.Status = models.ReimbursementStatusREQUESTED
+	summary = NewPPMCloseoutSummary(ppm, nil, unit.Cents(200000))
+	suite.Empty(summary.Expenses)
+	suite.Equal(unit.Cents(0), summary.Advance)
+	suite.Equal(unit.Cents(200000), summary.NetAmountOwed)
+}
+
+func (suite *PaperworkSuite) TestGeneratePPMCloseoutPaperwork() {
+	generator, order := suite.setupOrdersDocument()
+
+	weightTickets := models.MoveDocuments{{Document: order.UploadedOrders}}
+	expenseDocs := models.MoveDocuments{expenseMoveDoc(models.MovingExpenseTypeGAS, "OTHER", unit.Cents(2500))}
+	summary := NewPPMCloseoutSummary(models.PersonallyProcuredMove{}, expenseDocs, unit.Cents(100000))
+
+	file, err := GeneratePPMCloseoutPaperwork(generator, summary, weightTickets, expenseDocs)
+	suite.FatalNil(err)
+
+	ctx, err := api.Read(file.Name(), generator.pdfConfig)
+	suite.FatalNil(err)
+	err = pdfcpu.ValidateXRefTable(ctx.XRefTable)
+	suite.FatalNil(err)
+
+	// The summary page, then a page for each weight ticket upload
+	suite.Equal(4, ctx.PageCount)
+}
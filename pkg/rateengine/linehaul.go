@@ -3,6 +3,7 @@ package rateengine
 import (
 	"time"
 
+	"github.com/gofrs/uuid"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
@@ -30,45 +31,109 @@ func (c *LinehaulCostComputation) Scale(factor float64) {
 }
 
 func (re *RateEngine) determineMileage(originZip5 string, destinationZip5 string) (mileage int, err error) {
+	if len(re.stops) > 0 {
+		return re.determineStopsMileage()
+	}
+
 	mileage, err = re.planner.Zip5TransitDistance(originZip5, destinationZip5)
 	if err != nil {
 		re.logger.Error("Failed to get distance from planner - %v", zap.Error(err),
 			zap.String("origin_zip5", originZip5), zap.String("destination_zip5", destinationZip5))
+		return mileage, err
 	}
+	re.trace.lookup("Mileage", "Transit distance from the planner", "", uuid.Nil,
+		map[string]interface{}{"origin_zip5": originZip5, "destination_zip5": destinationZip5},
+		mileage)
 	return mileage, err
 }
 
+// determineStopsMileage plans a single route that visits the engine's stops in turn, so the
+// planner can route through each stop rather than pricing every leg on its own
+func (re *RateEngine) determineStopsMileage() (mileage int, err error) {
+	var stopZip5s []string
+	for _, stop := range re.stops {
+		stopZip5s = append(stopZip5s, stop.PostalCode)
+	}
+
+	mileage, err = re.planner.WaypointsTransitDistance(re.stops)
+	if err != nil {
+		re.logger.Error("Failed to get distance from planner - %v", zap.Error(err),
+			zap.Strings("stop_zip5s", stopZip5s))
+		return 0, err
+	}
+	re.trace.lookup("Mileage", "Transit distance from the planner of a route visiting each stop", "", uuid.Nil,
+		map[string]interface{}{"stop_zip5s": stopZip5s},
+		mileage)
+	return mileage, nil
+}
+
 // Determine the Base Linehaul (BLH)
 func (re *RateEngine) baseLinehaul(mileage int, weight unit.Pound, date time.Time) (baseLinehaulChargeCents unit.Cents, err error) {
-	baseLinehaulChargeCents, err = models.FetchBaseLinehaulRate(re.db, mileage, weight, date)
+	rate, err := models.FetchTariff400ngLinehaulRate(re.db, mileage, weight, date, re.tariffAsOf)
 	if err != nil {
 		re.logger.Error("Base Linehaul query didn't complete: ", zap.Error(err))
+		return 0, err
 	}
-
-	return baseLinehaulChargeCents, err
+	re.trace.lookup("BaseLinehaul", "Base linehaul rate for the mileage and weight bands", "tariff400ng_linehaul_rates", rate.ID,
+		map[string]interface{}{
+			"mileage":              mileage,
+			"weight_lbs":           weight.Int(),
+			"distance_miles_lower": rate.DistanceMilesLower,
+			"distance_miles_upper": rate.DistanceMilesUpper,
+			"weight_lbs_lower":     rate.WeightLbsLower.Int(),
+			"weight_lbs_upper":     rate.WeightLbsUpper.Int(),
+		},
+		rate.RateCents)
+
+	return rate.RateCents, nil
 }
 
 // Determine the Linehaul Factors (OLF and DLF)
 func (re *RateEngine) linehaulFactors(cwt unit.CWT, zip3 string, date time.Time) (linehaulFactorCents unit.Cents, err error) {
-	serviceArea, err := models.FetchTariff400ngServiceAreaForZip3(re.db, zip3, date)
+	serviceArea, err := models.FetchTariff400ngServiceAreaForZip3(re.db, zip3, date, re.tariffAsOf)
 	if err != nil {
 		return 0, err
 	}
-	return serviceArea.LinehaulFactor.Multiply(cwt.Int()), nil
+	re.trace.lookup("LinehaulFactor", "Linehaul factor for the zip3's service area", "tariff400ng_service_areas", serviceArea.ID,
+		map[string]interface{}{"zip3": zip3, "service_area": serviceArea.ServiceArea},
+		serviceArea.LinehaulFactor)
+
+	linehaulFactorCents = serviceArea.LinehaulFactor.Multiply(cwt.Int())
+	re.trace.calculate("LinehaulFactor", "Linehaul factor x CWT",
+		map[string]interface{}{"zip3": zip3, "linehaul_factor": serviceArea.LinehaulFactor, "cwt": cwt.Int()},
+		linehaulFactorCents)
+	return linehaulFactorCents, nil
 }
 
 // Determine Shorthaul (SH) Charge (ONLY applies if shipment moves 800 miles and less)
 func (re *RateEngine) shorthaulCharge(mileage int, cwt unit.CWT, date time.Time) (shorthaulChargeCents unit.Cents, err error) {
 	if mileage >= 800 {
+		re.trace.calculate("ShorthaulCharge", "Shorthaul only applies to moves of less than 800 miles",
+			map[string]interface{}{"mileage": mileage},
+			unit.Cents(0))
 		return 0, nil
 	}
 	re.logger.Debug("Shipment qualifies for shorthaul fee",
 		zap.Int("miles", mileage))
 
 	cwtMiles := mileage * cwt.Int()
-	shorthaulChargeCents, err = models.FetchShorthaulRateCents(re.db, cwtMiles, date)
+	re.trace.calculate("ShorthaulCharge", "Mileage x CWT",
+		map[string]interface{}{"mileage": mileage, "cwt": cwt.Int()},
+		cwtMiles)
 
-	return shorthaulChargeCents, err
+	rate, err := models.FetchTariff400ngShorthaulRate(re.db, cwtMiles, date, re.tariffAsOf)
+	if err != nil {
+		return 0, err
+	}
+	re.trace.lookup("ShorthaulCharge", "Shorthaul charge for the CWT-miles band", "tariff400ng_shorthaul_rates", rate.ID,
+		map[string]interface{}{
+			"cwt_miles":       cwtMiles,
+			"cwt_miles_lower": rate.CwtMilesLower,
+			"cwt_miles_upper": rate.CwtMilesUpper,
+		},
+		rate.RateCents)
+
+	return rate.RateCents, nil
 }
 
 // Determine Linehaul Charge (LC) TOTAL
@@ -87,14 +152,18 @@ func (re *RateEngine) linehaulChargeComputation(weight unit.Pound, originZip5 st
 	if err != nil {
 		return cost, errors.Wrap(err, "Failed to determine base linehaul charge")
 	}
+	mark := re.trace.mark()
 	cost.OriginLinehaulFactor, err = re.linehaulFactors(cwt, originZip3, date)
 	if err != nil {
 		return cost, errors.Wrap(err, "Failed to determine origin linehaul factor")
 	}
+	re.trace.relabel(mark, "OriginLinehaulFactor")
+	mark = re.trace.mark()
 	cost.DestinationLinehaulFactor, err = re.linehaulFactors(cwt, destinationZip3, date)
 	if err != nil {
 		return cost, errors.Wrap(err, "Failed to determine destination linehaul factor")
 	}
+	re.trace.relabel(mark, "DestinationLinehaulFactor")
 	cost.ShorthaulCharge, err = re.shorthaulCharge(mileage, cwt, date)
 	if err != nil {
 		return cost, errors.Wrap(err, "Failed to determine shorthaul charge")
@@ -104,6 +173,26 @@ func (re *RateEngine) linehaulChargeComputation(weight unit.Pound, originZip5 st
 		cost.OriginLinehaulFactor +
 		cost.DestinationLinehaulFactor +
 		cost.ShorthaulCharge
+	re.trace.calculate("LinehaulChargeTotal", "Base linehaul + origin linehaul factor + destination linehaul factor + shorthaul charge",
+		map[string]interface{}{
+			"base_linehaul":               cost.BaseLinehaul,
+			"origin_linehaul_factor":      cost.OriginLinehaulFactor,
+			"destination_linehaul_factor": cost.DestinationLinehaulFactor,
+			"shorthaul_charge":            cost.ShorthaulCharge,
+		},
+		cost.LinehaulChargeTotal)
+
+	// Peak rates scale every part of the linehaul charge
+	multiplier, err := re.peakRateMultiplier("LinehaulChargeTotal", "LHS", date)
+	if err != nil {
+		return cost, errors.Wrap(err, "Failed to determine peak rate multiplier for linehaul")
+	}
+	if multiplier != 1.0 {
+		cost.Scale(multiplier)
+		re.trace.calculate("LinehaulChargeTotal", "Linehaul charge total x peak rate multiplier",
+			map[string]interface{}{"multiplier": multiplier},
+			cost.LinehaulChargeTotal)
+	}
 
 	re.logger.Info("Linehaul charge total calculated",
 		zap.Int("linehaul total", cost.LinehaulChargeTotal.Int()),
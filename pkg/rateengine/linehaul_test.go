@@ -19,6 +19,35 @@ func (suite *RateEngineSuite) Test_CheckDetermineMileage() {
 	}
 }
 
+func (suite *RateEngineSuite) Test_CheckDetermineMileageWithStops() {
+	shipment := models.Shipment{
+		PickupAddress:                &models.Address{PostalCode: "94540"},
+		HasSecondaryPickupAddress:    true,
+		SecondaryPickupAddress:       &models.Address{PostalCode: "95630"},
+		HasPartialSITDeliveryAddress: true,
+		PartialSITDeliveryAddress:    &models.Address{PostalCode: "78628"},
+	}
+	shipment.Move.Orders.NewDutyStation.Address.PostalCode = "78626"
+
+	stops, err := shipmentStops(shipment)
+	suite.NoError(err)
+	var zips []string
+	for _, stop := range stops {
+		zips = append(zips, stop.PostalCode)
+	}
+	suite.Equal([]string{"94540", "95630", "78626", "78628"}, zips)
+
+	// The testing planner prices a route by its legs
+	engine := NewRateEngine(suite.db, suite.logger, suite.planner).withStops(stops)
+	mileage, err := engine.determineMileage("94540", "78626")
+	suite.NoError(err)
+	suite.Equal(3*1234, mileage)
+
+	shipment.SecondaryPickupAddress = nil
+	_, err = shipmentStops(shipment)
+	suite.EqualError(err, "SecondaryPickupAddress is nil")
+}
+
 func (suite *RateEngineSuite) Test_CheckBaseLinehaul() {
 	t := suite.T()
 	engine := NewRateEngine(suite.db, suite.logger, suite.planner)
@@ -28,39 +28,72 @@ func (c *NonLinehaulCostComputation) Scale(factor float64) {
 }
 
 func (re *RateEngine) serviceFeeCents(cwt unit.CWT, zip3 string, date time.Time) (unit.Cents, error) {
-	serviceArea, err := models.FetchTariff400ngServiceAreaForZip3(re.db, zip3, date)
+	serviceArea, err := models.FetchTariff400ngServiceAreaForZip3(re.db, zip3, date, re.tariffAsOf)
 	if err != nil {
 		return 0, err
 	}
-	return serviceArea.ServiceChargeCents.Multiply(cwt.Int()), nil
+	re.trace.lookup("ServiceFee", "Service charge for the zip3's service area", "tariff400ng_service_areas", serviceArea.ID,
+		map[string]interface{}{"zip3": zip3, "service_area": serviceArea.ServiceArea},
+		serviceArea.ServiceChargeCents)
+
+	fee := serviceArea.ServiceChargeCents.Multiply(cwt.Int())
+	re.trace.calculate("ServiceFee", "Service charge x CWT",
+		map[string]interface{}{"zip3": zip3, "service_charge_cents": serviceArea.ServiceChargeCents, "cwt": cwt.Int()},
+		fee)
+	return fee, nil
 }
 
 func (re *RateEngine) fullPackCents(cwt unit.CWT, zip3 string, date time.Time) (unit.Cents, error) {
-	serviceArea, err := models.FetchTariff400ngServiceAreaForZip3(re.db, zip3, date)
+	serviceArea, err := models.FetchTariff400ngServiceAreaForZip3(re.db, zip3, date, re.tariffAsOf)
 	if err != nil {
 		return 0, err
 	}
+	re.trace.lookup("PackFee", "Services schedule for the zip3's service area", "tariff400ng_service_areas", serviceArea.ID,
+		map[string]interface{}{"zip3": zip3, "service_area": serviceArea.ServiceArea},
+		serviceArea.ServicesSchedule)
 
-	fullPackRate, err := models.FetchTariff400ngFullPackRateCents(re.db, cwt.ToPounds(), serviceArea.ServicesSchedule, date)
+	fullPackRate, err := models.FetchTariff400ngFullPackRate(re.db, cwt.ToPounds(), serviceArea.ServicesSchedule, date, re.tariffAsOf)
 	if err != nil {
 		return 0, err
 	}
-
-	return fullPackRate.Multiply(cwt.Int()), nil
+	re.trace.lookup("PackFee", "Full pack rate for the services schedule and weight band", "tariff400ng_full_pack_rates", fullPackRate.ID,
+		map[string]interface{}{
+			"schedule":         serviceArea.ServicesSchedule,
+			"weight_lbs":       cwt.ToPounds().Int(),
+			"weight_lbs_lower": fullPackRate.WeightLbsLower.Int(),
+			"weight_lbs_upper": fullPackRate.WeightLbsUpper.Int(),
+		},
+		fullPackRate.RateCents)
+
+	fee := fullPackRate.RateCents.Multiply(cwt.Int())
+	re.trace.calculate("PackFee", "Full pack rate x CWT",
+		map[string]interface{}{"rate_cents": fullPackRate.RateCents, "cwt": cwt.Int()},
+		fee)
+	return fee, nil
 }
 
 func (re *RateEngine) fullUnpackCents(cwt unit.CWT, zip3 string, date time.Time) (unit.Cents, error) {
-	serviceArea, err := models.FetchTariff400ngServiceAreaForZip3(re.db, zip3, date)
+	serviceArea, err := models.FetchTariff400ngServiceAreaForZip3(re.db, zip3, date, re.tariffAsOf)
 	if err != nil {
 		return 0, err
 	}
+	re.trace.lookup("UnpackFee", "Services schedule for the zip3's service area", "tariff400ng_service_areas", serviceArea.ID,
+		map[string]interface{}{"zip3": zip3, "service_area": serviceArea.ServiceArea},
+		serviceArea.ServicesSchedule)
 
-	fullUnpackRate, err := models.FetchTariff400ngFullUnpackRateMillicents(re.db, serviceArea.ServicesSchedule, date)
+	fullUnpackRate, err := models.FetchTariff400ngFullUnpackRate(re.db, serviceArea.ServicesSchedule, date, re.tariffAsOf)
 	if err != nil {
 		return 0, err
 	}
-
-	return unit.Cents(math.Round(float64(cwt.Int()*fullUnpackRate) / 1000.0)), nil
+	re.trace.lookup("UnpackFee", "Full unpack rate, in millicents, for the services schedule", "tariff400ng_full_unpack_rates", fullUnpackRate.ID,
+		map[string]interface{}{"schedule": serviceArea.ServicesSchedule},
+		fullUnpackRate.RateMillicents)
+
+	fee := unit.Cents(math.Round(float64(cwt.Int()*fullUnpackRate.RateMillicents) / 1000.0))
+	re.trace.calculate("UnpackFee", "Full unpack rate x CWT, converted from millicents and rounded to the nearest cent",
+		map[string]interface{}{"rate_millicents": fullUnpackRate.RateMillicents, "cwt": cwt.Int()},
+		fee)
+	return fee, nil
 }
 
 // SitCharge calculates the SIT charge based on various factors.
@@ -73,22 +106,51 @@ func (re *RateEngine) SitCharge(cwt unit.CWT, daysInSIT int, zip3 string, date t
 		return 0, errors.New("requested SitCharge for negative days in SIT")
 	}
 
-	sa, err := models.FetchTariff400ngServiceAreaForZip3(re.db, zip3, date)
+	sa, err := models.FetchTariff400ngServiceAreaForZip3(re.db, zip3, date, re.tariffAsOf)
 	if err != nil {
 		return 0, err
 	}
+	re.trace.lookup("SIT", "SIT rates for the zip3's service area", "tariff400ng_service_areas", sa.ID,
+		map[string]interface{}{
+			"zip3":         zip3,
+			"service_area": sa.ServiceArea,
+			"sit_185a":     sa.SIT185ARateCents,
+			"sit_185b":     sa.SIT185BRateCents,
+		},
+		sa.ServiceArea)
 
 	var sitTotal unit.Cents
 
 	if isPPM {
 		sitTotal = sa.SIT185BRateCents.Multiply(daysInSIT).Multiply(cwt.Int())
+		sitTotal, err = re.applyPeakRateAdjustment("SIT", "185B", date, sitTotal)
+		if err != nil {
+			return 0, err
+		}
 	} else {
 		sitTotal = sa.SIT185ARateCents.Multiply(cwt.Int())
+		sitTotal, err = re.applyPeakRateAdjustment("SIT", "185A", date, sitTotal)
+		if err != nil {
+			return 0, err
+		}
 		additionalDays := daysInSIT - 1
 		if additionalDays > 0 {
-			sitTotal = sitTotal.AddCents(sa.SIT185BRateCents.Multiply(additionalDays).Multiply(cwt.Int()))
+			additionalDaysTotal, err := re.applyPeakRateAdjustment("SIT", "185B", date,
+				sa.SIT185BRateCents.Multiply(additionalDays).Multiply(cwt.Int()))
+			if err != nil {
+				return 0, err
+			}
+			sitTotal = sitTotal.AddCents(additionalDaysTotal)
 		}
 	}
+	sitDescription := "185A first day rate x CWT + 185B additional day rate x additional days x CWT"
+	if isPPM {
+		sitDescription = "185B additional day rate x days x CWT; PPMs don't pay the 185A first day rate"
+	}
+	re.trace.calculate("SIT", sitDescription,
+		map[string]interface{}{"days": daysInSIT, "cwt": cwt.Int(), "is_ppm": isPPM},
+		sitTotal)
+
 	re.logger.Info("sit calculation",
 		zap.Int("cwt", cwt.Int()),
 		zap.Int("185A", sa.SIT185ARateCents.Int()),
@@ -103,22 +165,42 @@ func (re *RateEngine) nonLinehaulChargeComputation(weight unit.Pound, originZip5
 	cwt := weight.ToCWT()
 	originZip3 := Zip5ToZip3(originZip5)
 	destinationZip3 := Zip5ToZip3(destinationZip5)
+	mark := re.trace.mark()
 	cost.OriginServiceFee, err = re.serviceFeeCents(cwt, originZip3, date)
 	if err != nil {
 		return cost, errors.Wrap(err, "Failed to  determine origin service fee")
 	}
+	re.trace.relabel(mark, "OriginServiceFee")
+	cost.OriginServiceFee, err = re.applyPeakRateAdjustment("OriginServiceFee", "135A", date, cost.OriginServiceFee)
+	if err != nil {
+		return cost, err
+	}
+	mark = re.trace.mark()
 	cost.DestinationServiceFee, err = re.serviceFeeCents(cwt, destinationZip3, date)
 	if err != nil {
 		return cost, errors.Wrap(err, "Failed to  determine destination service fee")
 	}
+	re.trace.relabel(mark, "DestinationServiceFee")
+	cost.DestinationServiceFee, err = re.applyPeakRateAdjustment("DestinationServiceFee", "135B", date, cost.DestinationServiceFee)
+	if err != nil {
+		return cost, err
+	}
 	cost.PackFee, err = re.fullPackCents(cwt, originZip3, date)
 	if err != nil {
 		return cost, errors.Wrap(err, "Failed to  determine full pack cost")
 	}
+	cost.PackFee, err = re.applyPeakRateAdjustment("PackFee", "105A", date, cost.PackFee)
+	if err != nil {
+		return cost, err
+	}
 	cost.UnpackFee, err = re.fullUnpackCents(cwt, destinationZip3, date)
 	if err != nil {
 		return cost, errors.Wrap(err, "Failed to  determine full unpack cost")
 	}
+	cost.UnpackFee, err = re.applyPeakRateAdjustment("UnpackFee", "105C", date, cost.UnpackFee)
+	if err != nil {
+		return cost, err
+	}
 
 	re.logger.Info("Non-Linehaul charge total calculated",
 		zap.Int("origin service fee", cost.OriginServiceFee.Int()),
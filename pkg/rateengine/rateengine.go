@@ -15,13 +15,43 @@ import (
 )
 
 // MaxSITDays is the maximum number of days of SIT that will be reimbursed.
-const MaxSITDays = 90
+const MaxSITDays = models.StorageInTransitMaxDays
 
 // RateEngine encapsulates the TSP rate engine process
 type RateEngine struct {
 	db      *pop.Connection
 	logger  *zap.Logger
 	planner route.Planner
+	trace   *Trace
+	// tariffAsOf is the time the tariff tables are read as of. A zero time reads the current
+	// version of the tables.
+	tariffAsOf time.Time
+	// stops are the addresses a shipment's route visits in turn, when it has more than an
+	// origin and destination. Mileage is planned along them instead of between zip codes.
+	stops []*models.Address
+}
+
+// CostInputs records the values a computation was based on, so that its result can be
+// explained or reproduced later.
+type CostInputs struct {
+	// Weight is the weight that was requested, before it was rounded up to the 1000lb minimum
+	Weight                 unit.Pound
+	ProrateFactor          float64
+	OriginZip5             string
+	DestinationZip5        string
+	OriginZip3             string
+	DestinationZip3        string
+	OriginServiceArea      string
+	DestinationServiceArea string
+	Date                   time.Time
+	DaysInSIT              int
+	LinehaulDiscount       unit.DiscountRate
+	SITDiscount            unit.DiscountRate
+	// PeakRateCycle is true if the date falls in the peak rate cycle, when peak rate
+	// multipliers apply
+	PeakRateCycle bool
+	// TariffAsOf is the time the tariff tables were read as of, or zero for the current version
+	TariffAsOf time.Time
 }
 
 // CostComputation represents the results of a computation.
@@ -31,6 +61,7 @@ type CostComputation struct {
 	SITFee unit.Cents
 	SITMax unit.Cents
 	GCC    unit.Cents
+	Inputs CostInputs
 }
 
 // Scale scales a cost computation by a multiplicative factor
@@ -60,9 +91,117 @@ func (c CostComputation) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
 
 	encoder.AddInt("GCC", c.GCC.Int())
 
+	encoder.AddInt("Weight", c.Inputs.Weight.Int())
+	encoder.AddFloat64("ProrateFactor", c.Inputs.ProrateFactor)
+	encoder.AddString("OriginServiceArea", c.Inputs.OriginServiceArea)
+	encoder.AddString("DestinationServiceArea", c.Inputs.DestinationServiceArea)
+	encoder.AddFloat64("LinehaulDiscount", c.Inputs.LinehaulDiscount.Float64())
+	encoder.AddFloat64("SITDiscount", c.Inputs.SITDiscount.Float64())
+	encoder.AddBool("PeakRateCycle", c.Inputs.PeakRateCycle)
+
 	return nil
 }
 
+// traceWeight records how the weight used for rating was arrived at
+func (re *RateEngine) traceWeight(requestedWeight unit.Pound, ratedWeight unit.Pound, prorateFactor float64) {
+	if requestedWeight != ratedWeight {
+		re.trace.calculate("Weight", "Weights below 1000lbs are rated at 1000lbs and prorated",
+			map[string]interface{}{"weight_lbs": requestedWeight.Int()},
+			prorateFactor)
+	}
+	re.trace.calculate("Weight", "Weight rounded to the nearest hundredweight (CWT)",
+		map[string]interface{}{"weight_lbs": ratedWeight.Int()},
+		ratedWeight.ToCWT().Int())
+}
+
+// applyDiscount applies a discount rate to a charge and records it in the trace
+func (re *RateEngine) applyDiscount(charge string, discount unit.DiscountRate, cents unit.Cents) unit.Cents {
+	discounted := discount.Apply(cents)
+	re.trace.calculate(charge, "Charge x (1 - discount rate)",
+		map[string]interface{}{"charge": cents, "discount_rate": discount.Float64()},
+		discounted)
+	return discounted
+}
+
+// peakRateMultiplier returns the multiplier for a 400NG item's rate on a date. Rates are only
+// adjusted during the peak rate cycle, and only for items with a peak rate adjustment.
+func (re *RateEngine) peakRateMultiplier(charge string, code string, date time.Time) (float64, error) {
+	if !models.IsPeakRateCycle(date) {
+		return 1.0, nil
+	}
+
+	adjustment, err := models.FetchTariff400ngPeakRateAdjustment(re.db, code, date, re.tariffAsOf)
+	if err == models.ErrFetchNotFound {
+		return 1.0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	re.trace.lookup(charge, "Peak rate multiplier for the item", "tariff400ng_peak_rate_adjustments", adjustment.ID,
+		map[string]interface{}{"code": code},
+		adjustment.Multiplier)
+	return adjustment.Multiplier, nil
+}
+
+// applyPeakRateAdjustment multiplies the charge for a 400NG item by its peak rate multiplier
+// and records it in the trace
+func (re *RateEngine) applyPeakRateAdjustment(charge string, code string, date time.Time, cents unit.Cents) (unit.Cents, error) {
+	multiplier, err := re.peakRateMultiplier(charge, code, date)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Failed to determine peak rate multiplier for %s", code)
+	}
+	if multiplier == 1.0 {
+		return cents, nil
+	}
+
+	adjusted := cents.MultiplyFloat64(multiplier)
+	re.trace.calculate(charge, "Charge x peak rate multiplier",
+		map[string]interface{}{"charge": cents, "code": code, "multiplier": multiplier},
+		adjusted)
+	return adjusted, nil
+}
+
+// costInputs looks up the service areas for a route and records them with the other
+// inputs to a computation
+func (re *RateEngine) costInputs(
+	weight unit.Pound,
+	prorateFactor float64,
+	originZip5 string,
+	destinationZip5 string,
+	date time.Time,
+	daysInSIT int,
+	lhDiscount unit.DiscountRate,
+	sitDiscount unit.DiscountRate) (inputs CostInputs, err error) {
+
+	inputs = CostInputs{
+		Weight:           weight,
+		ProrateFactor:    prorateFactor,
+		OriginZip5:       originZip5,
+		DestinationZip5:  destinationZip5,
+		OriginZip3:       Zip5ToZip3(originZip5),
+		DestinationZip3:  Zip5ToZip3(destinationZip5),
+		Date:             date,
+		DaysInSIT:        daysInSIT,
+		LinehaulDiscount: lhDiscount,
+		SITDiscount:      sitDiscount,
+		PeakRateCycle:    models.IsPeakRateCycle(date),
+		TariffAsOf:       re.tariffAsOf,
+	}
+
+	originServiceArea, err := models.FetchTariff400ngServiceAreaForZip3(re.db, inputs.OriginZip3, date, re.tariffAsOf)
+	if err != nil {
+		return inputs, errors.Wrap(err, "Failed to determine origin service area")
+	}
+	inputs.OriginServiceArea = originServiceArea.ServiceArea
+
+	destinationServiceArea, err := models.FetchTariff400ngServiceAreaForZip3(re.db, inputs.DestinationZip3, date, re.tariffAsOf)
+	if err != nil {
+		return inputs, errors.Wrap(err, "Failed to determine destination service area")
+	}
+	inputs.DestinationServiceArea = destinationServiceArea.ServiceArea
+
+	return inputs, nil
+}
+
 // Zip5ToZip3 takes a ZIP5 string and returns the ZIP3 representation of it.
 func Zip5ToZip3(zip5 string) string {
 	return zip5[0:3]
@@ -79,12 +218,20 @@ func (re *RateEngine) ComputePPM(
 	sitDiscount unit.DiscountRate) (cost CostComputation, err error) {
 
 	// Weights below 1000lbs are prorated to the 1000lb rate
+	requestedWeight := weight
 	prorateFactor := 1.0
 	if weight.Int() < 1000 {
 		prorateFactor = weight.Float64() / 1000.0
 		weight = unit.Pound(1000)
 	}
 
+	inputs, err := re.costInputs(requestedWeight, prorateFactor, originZip5, destinationZip5, date, daysInSIT, lhDiscount, sitDiscount)
+	if err != nil {
+		re.logger.Error("Failed to determine cost inputs", zap.Error(err))
+		return
+	}
+	re.traceWeight(requestedWeight, weight, prorateFactor)
+
 	// Linehaul charges
 	linehaulCostComputation, err := re.linehaulChargeComputation(weight, originZip5, destinationZip5, date)
 	if err != nil {
@@ -100,30 +247,34 @@ func (re *RateEngine) ComputePPM(
 	}
 
 	// Apply linehaul discounts
-	linehaulCostComputation.LinehaulChargeTotal = lhDiscount.Apply(linehaulCostComputation.LinehaulChargeTotal)
-	nonLinehaulCostComputation.OriginServiceFee = lhDiscount.Apply(nonLinehaulCostComputation.OriginServiceFee)
-	nonLinehaulCostComputation.DestinationServiceFee = lhDiscount.Apply(nonLinehaulCostComputation.DestinationServiceFee)
-	nonLinehaulCostComputation.PackFee = lhDiscount.Apply(nonLinehaulCostComputation.PackFee)
-	nonLinehaulCostComputation.UnpackFee = lhDiscount.Apply(nonLinehaulCostComputation.UnpackFee)
+	linehaulCostComputation.LinehaulChargeTotal = re.applyDiscount("LinehaulChargeTotal", lhDiscount, linehaulCostComputation.LinehaulChargeTotal)
+	nonLinehaulCostComputation.OriginServiceFee = re.applyDiscount("OriginServiceFee", lhDiscount, nonLinehaulCostComputation.OriginServiceFee)
+	nonLinehaulCostComputation.DestinationServiceFee = re.applyDiscount("DestinationServiceFee", lhDiscount, nonLinehaulCostComputation.DestinationServiceFee)
+	nonLinehaulCostComputation.PackFee = re.applyDiscount("PackFee", lhDiscount, nonLinehaulCostComputation.PackFee)
+	nonLinehaulCostComputation.UnpackFee = re.applyDiscount("UnpackFee", lhDiscount, nonLinehaulCostComputation.UnpackFee)
 
 	// SIT
 	// Note that SIT has a different discount rate than [non]linehaul charges
 	destinationZip3 := Zip5ToZip3(destinationZip5)
+	mark := re.trace.mark()
 	sit, err := re.SitCharge(weight.ToCWT(), daysInSIT, destinationZip3, date, true)
 	if err != nil {
 		re.logger.Info("Can't calculate sit")
 		return
 	}
-	sitFee := sitDiscount.Apply(sit)
+	re.trace.relabel(mark, "SITFee")
+	sitFee := re.applyDiscount("SITFee", sitDiscount, sit)
 
 	/// Max SIT
+	mark = re.trace.mark()
 	maxSIT, err := re.SitCharge(weight.ToCWT(), MaxSITDays, destinationZip3, date, true)
 	if err != nil {
 		re.logger.Info("Can't calculate max sit")
 		return
 	}
+	re.trace.relabel(mark, "SITMax")
 	// Note that SIT has a different discount rate than [non]linehaul charges
-	maxSITFee := sitDiscount.Apply(maxSIT)
+	maxSITFee := re.applyDiscount("SITMax", sitDiscount, maxSIT)
 
 	// Totals
 	gcc := linehaulCostComputation.LinehaulChargeTotal +
@@ -138,10 +289,26 @@ func (re *RateEngine) ComputePPM(
 		SITFee: sitFee,
 		SITMax: maxSITFee,
 		GCC:    gcc,
+		Inputs: inputs,
 	}
 
+	re.trace.calculate("GCC", "Discounted linehaul charge total + origin service fee + destination service fee + pack fee + unpack fee",
+		map[string]interface{}{
+			"linehaul_charge_total":   linehaulCostComputation.LinehaulChargeTotal,
+			"origin_service_fee":      nonLinehaulCostComputation.OriginServiceFee,
+			"destination_service_fee": nonLinehaulCostComputation.DestinationServiceFee,
+			"pack_fee":                nonLinehaulCostComputation.PackFee,
+			"unpack_fee":              nonLinehaulCostComputation.UnpackFee,
+		},
+		gcc)
+
 	// Finally, scale by prorate factor
 	cost.Scale(prorateFactor)
+	if prorateFactor != 1.0 {
+		re.trace.calculate("GCC", "Every charge is scaled by the prorate factor",
+			map[string]interface{}{"prorate_factor": prorateFactor, "gcc": gcc},
+			cost.GCC)
+	}
 
 	re.logger.Info("PPM cost computation", zap.Object("cost", cost))
 
@@ -159,12 +326,20 @@ func (re *RateEngine) ComputeShipment(
 	sitDiscount unit.DiscountRate) (cost CostComputation, err error) {
 
 	// Weights below 1000lbs are prorated to the 1000lb rate
+	requestedWeight := weight
 	prorateFactor := 1.0
 	if weight.Int() < 1000 {
 		prorateFactor = weight.Float64() / 1000.0
 		weight = unit.Pound(1000)
 	}
 
+	inputs, err := re.costInputs(requestedWeight, prorateFactor, originZip5, destinationZip5, date, daysInSIT, lhDiscount, sitDiscount)
+	if err != nil {
+		re.logger.Error("Failed to determine cost inputs", zap.Error(err))
+		return
+	}
+	re.traceWeight(requestedWeight, weight, prorateFactor)
+
 	// Linehaul charges
 	linehaulCostComputation, err := re.linehaulChargeComputation(weight, originZip5, destinationZip5, date)
 	if err != nil {
@@ -180,30 +355,34 @@ func (re *RateEngine) ComputeShipment(
 	}
 
 	// Apply linehaul discounts
-	linehaulCostComputation.LinehaulChargeTotal = lhDiscount.Apply(linehaulCostComputation.LinehaulChargeTotal)
-	nonLinehaulCostComputation.OriginServiceFee = lhDiscount.Apply(nonLinehaulCostComputation.OriginServiceFee)
-	nonLinehaulCostComputation.DestinationServiceFee = lhDiscount.Apply(nonLinehaulCostComputation.DestinationServiceFee)
-	nonLinehaulCostComputation.PackFee = lhDiscount.Apply(nonLinehaulCostComputation.PackFee)
-	nonLinehaulCostComputation.UnpackFee = lhDiscount.Apply(nonLinehaulCostComputation.UnpackFee)
+	linehaulCostComputation.LinehaulChargeTotal = re.applyDiscount("LinehaulChargeTotal", lhDiscount, linehaulCostComputation.LinehaulChargeTotal)
+	nonLinehaulCostComputation.OriginServiceFee = re.applyDiscount("OriginServiceFee", lhDiscount, nonLinehaulCostComputation.OriginServiceFee)
+	nonLinehaulCostComputation.DestinationServiceFee = re.applyDiscount("DestinationServiceFee", lhDiscount, nonLinehaulCostComputation.DestinationServiceFee)
+	nonLinehaulCostComputation.PackFee = re.applyDiscount("PackFee", lhDiscount, nonLinehaulCostComputation.PackFee)
+	nonLinehaulCostComputation.UnpackFee = re.applyDiscount("UnpackFee", lhDiscount, nonLinehaulCostComputation.UnpackFee)
 
 	// SIT
 	// Note that SIT has a different discount rate than [non]linehaul charges
 	destinationZip3 := Zip5ToZip3(destinationZip5)
+	mark := re.trace.mark()
 	sit, err := re.SitCharge(weight.ToCWT(), daysInSIT, destinationZip3, date, true)
 	if err != nil {
 		re.logger.Info("Can't calculate sit")
 		return
 	}
-	sitFee := sitDiscount.Apply(sit)
+	re.trace.relabel(mark, "SITFee")
+	sitFee := re.applyDiscount("SITFee", sitDiscount, sit)
 
 	/// Max SIT
+	mark = re.trace.mark()
 	maxSIT, err := re.SitCharge(weight.ToCWT(), MaxSITDays, destinationZip3, date, true)
 	if err != nil {
 		re.logger.Info("Can't calculate max sit")
 		return
 	}
+	re.trace.relabel(mark, "SITMax")
 	// Note that SIT has a different discount rate than [non]linehaul charges
-	maxSITFee := sitDiscount.Apply(maxSIT)
+	maxSITFee := re.applyDiscount("SITMax", sitDiscount, maxSIT)
 
 	// Totals
 	gcc := linehaulCostComputation.LinehaulChargeTotal +
@@ -218,10 +397,26 @@ func (re *RateEngine) ComputeShipment(
 		SITFee: sitFee,
 		SITMax: maxSITFee,
 		GCC:    gcc,
+		Inputs: inputs,
 	}
 
+	re.trace.calculate("GCC", "Discounted linehaul charge total + origin service fee + destination service fee + pack fee + unpack fee",
+		map[string]interface{}{
+			"linehaul_charge_total":   linehaulCostComputation.LinehaulChargeTotal,
+			"origin_service_fee":      nonLinehaulCostComputation.OriginServiceFee,
+			"destination_service_fee": nonLinehaulCostComputation.DestinationServiceFee,
+			"pack_fee":                nonLinehaulCostComputation.PackFee,
+			"unpack_fee":              nonLinehaulCostComputation.UnpackFee,
+		},
+		gcc)
+
 	// Finally, scale by prorate factor
 	cost.Scale(prorateFactor)
+	if prorateFactor != 1.0 {
+		re.trace.calculate("GCC", "Every charge is scaled by the prorate factor",
+			map[string]interface{}{"prorate_factor": prorateFactor, "gcc": gcc},
+			cost.GCC)
+	}
 
 	re.logger.Info("PPM cost computation", zap.Object("cost", cost))
 
@@ -230,13 +425,30 @@ func (re *RateEngine) ComputeShipment(
 
 // CostByShipment struct containing shipment and cost
 type CostByShipment struct {
-	Shipment models.Shipment
-	Cost     CostComputation
+	Shipment                models.Shipment
+	Cost                    CostComputation
+	StorageInTransitCharges []StorageInTransitCharge
+}
+
+// StorageInTransitTotal returns the charges for every stay the shipment made in SIT
+func (c CostByShipment) StorageInTransitTotal() unit.Cents {
+	var total unit.Cents
+	for _, charge := range c.StorageInTransitCharges {
+		total = total.AddCents(charge.Total())
+	}
+	return total
 }
 
 // HandleRunOnShipment runs the rate engine on a shipment and returns the shipment and cost.
-// Assumptions: Shipment model passed in has eagerly fetched PickupAddress,
-// Move.Orders.NewDutyStation.Address, and ShipmentOffers.TransportationServiceProviderPerformance.
+// Assumptions: Shipment model passed in has eagerly fetched PickupAddress, SecondaryPickupAddress,
+// PartialSITDeliveryAddress, Move.Orders.NewDutyStation.Address, and
+// ShipmentOffers.TransportationServiceProviderPerformance.
+// Mileage for a shipment with a secondary pickup or partial SIT delivery address is planned
+// along a route that visits every stop.
+// The shipment's stays in SIT are billed with the SIT discount rate of the TSP's performance.
+// Discount rates come from the TSP's performance in the rate cycle of the pickup date, and a
+// shipment that has been invoiced is rated against the version of the tariff tables it was
+// invoiced with.
 func (re *RateEngine) HandleRunOnShipment(shipment models.Shipment) (CostByShipment, error) {
 	// Validate expected model relationships are available.
 	if shipment.PickupAddress == nil {
@@ -259,13 +471,31 @@ func (re *RateEngine) HandleRunOnShipment(shipment models.Shipment) (CostByShipm
 		return CostByShipment{}, errors.New("NetWeight is nil")
 	}
 
-	// All required relationships should exist at this point.
-	daysInSIT := 0
-	var sitDiscount unit.DiscountRate
-	sitDiscount = 0.0
+	if shipment.ActualPickupDate == nil {
+		return CostByShipment{}, errors.New("ActualPickupDate is nil")
+	}
+
+	if shipment.RatedAt != nil && !shipment.RatedAt.Equal(re.tariffAsOf) {
+		return re.WithTariffAsOf(*shipment.RatedAt).HandleRunOnShipment(shipment)
+	}
+
+	stops, err := shipmentStops(shipment)
+	if err != nil {
+		return CostByShipment{}, err
+	}
+	if len(stops) > 2 && len(re.stops) == 0 {
+		return re.withStops(stops).HandleRunOnShipment(shipment)
+	}
 
+	// All required relationships should exist at this point.
 	// Assume the most recent matching shipment offer is the right one.
-	lhDiscount := shipment.ShipmentOffers[0].TransportationServiceProviderPerformance.LinehaulRate
+	performance, err := re.performanceForRateCycle(shipment.ShipmentOffers[0].TransportationServiceProviderPerformance,
+		time.Time(*shipment.ActualPickupDate))
+	if err != nil {
+		return CostByShipment{}, err
+	}
+	lhDiscount := performance.LinehaulRate
+	sitDiscount := performance.SITRate
 
 	// Apply rate engine to shipment
 	var shipmentCost CostByShipment
@@ -273,7 +503,7 @@ func (re *RateEngine) HandleRunOnShipment(shipment models.Shipment) (CostByShipm
 		shipment.PickupAddress.PostalCode,
 		shipment.Move.Orders.NewDutyStation.Address.PostalCode,
 		time.Time(*shipment.ActualPickupDate),
-		daysInSIT, // We don't want any SIT charges
+		0, // SIT is billed for each stay below
 		lhDiscount,
 		sitDiscount,
 	)
@@ -281,14 +511,101 @@ func (re *RateEngine) HandleRunOnShipment(shipment models.Shipment) (CostByShipm
 		return CostByShipment{}, err
 	}
 
+	sits, err := models.FetchStorageInTransitsByShipmentID(re.db, shipment.ID)
+	if err != nil {
+		return CostByShipment{}, err
+	}
+	sitCharges, err := re.storageInTransitCharges(sits,
+		*shipment.NetWeight,
+		shipment.PickupAddress.PostalCode,
+		shipment.Move.Orders.NewDutyStation.Address.PostalCode,
+		sitDiscount,
+	)
+	if err != nil {
+		return CostByShipment{}, err
+	}
+
 	shipmentCost = CostByShipment{
-		Shipment: shipment,
-		Cost:     cost,
+		Shipment:                shipment,
+		Cost:                    cost,
+		StorageInTransitCharges: sitCharges,
 	}
 	return shipmentCost, err
 }
 
+// shipmentStops returns the addresses a shipment's route visits in turn: its pickup address and
+// any secondary pickup address, its destination duty station, and any partial SIT delivery
+// address beyond it
+func shipmentStops(shipment models.Shipment) ([]*models.Address, error) {
+	stops := []*models.Address{shipment.PickupAddress}
+	if shipment.HasSecondaryPickupAddress {
+		if shipment.SecondaryPickupAddress == nil {
+			return nil, errors.New("SecondaryPickupAddress is nil")
+		}
+		stops = append(stops, shipment.SecondaryPickupAddress)
+	}
+	stops = append(stops, &shipment.Move.Orders.NewDutyStation.Address)
+	if shipment.HasPartialSITDeliveryAddress {
+		if shipment.PartialSITDeliveryAddress == nil {
+			return nil, errors.New("PartialSITDeliveryAddress is nil")
+		}
+		stops = append(stops, shipment.PartialSITDeliveryAddress)
+	}
+	return stops, nil
+}
+
+// performanceForRateCycle returns the offered TSP's performance for the rate cycle a date falls
+// in, since a TSP's discount rates differ between peak and non-peak rate cycles. The offered
+// performance is used if the TSP has none for that rate cycle.
+func (re *RateEngine) performanceForRateCycle(offered models.TransportationServiceProviderPerformance, date time.Time) (models.TransportationServiceProviderPerformance, error) {
+	if !date.Before(offered.RateCycleStart) && date.Before(offered.RateCycleEnd) {
+		return offered, nil
+	}
+
+	performance, err := models.FetchTSPPerformanceForRateCycle(re.db,
+		offered.TransportationServiceProviderID,
+		offered.TrafficDistributionListID,
+		date)
+	if err == models.ErrFetchNotFound {
+		return offered, nil
+	} else if err != nil {
+		return offered, errors.Wrap(err, "Failed to determine the TSP's performance for the rate cycle")
+	}
+	re.trace.lookup("Discounts", "TSP performance for the rate cycle of the pickup date", "transportation_service_provider_performances", performance.ID,
+		map[string]interface{}{
+			"date":             date,
+			"rate_cycle_start": performance.RateCycleStart,
+			"rate_cycle_end":   performance.RateCycleEnd,
+			"sit_rate":         performance.SITRate.Float64(),
+		},
+		performance.LinehaulRate.Float64())
+	return performance, nil
+}
+
 // NewRateEngine creates a new RateEngine
 func NewRateEngine(db *pop.Connection, logger *zap.Logger, planner route.Planner) *RateEngine {
 	return &RateEngine{db: db, logger: logger, planner: planner}
 }
+
+// WithTariffAsOf returns a copy of the engine that reads the tariff tables as they were at a
+// point in time. The copy records into the same trace.
+func (re *RateEngine) WithTariffAsOf(asOf time.Time) *RateEngine {
+	engine := *re
+	engine.tariffAsOf = asOf
+	return &engine
+}
+
+// withStops returns a copy of the engine that plans mileage along a route visiting each stop
+// in turn. The copy records into the same trace.
+func (re *RateEngine) withStops(stops []*models.Address) *RateEngine {
+	engine := *re
+	engine.stops = stops
+	return &engine
+}
+
+// EnableTrace starts recording every lookup and calculation the engine makes into a new
+// trace, which is returned
+func (re *RateEngine) EnableTrace() *Trace {
+	re.trace = &Trace{}
+	return re.trace
+}
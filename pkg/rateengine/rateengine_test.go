@@ -3,6 +3,7 @@ package rateengine
 import (
 	"log"
 	"testing"
+	"time"
 
 	"github.com/gobuffalo/pop"
 	"github.com/stretchr/testify/suite"
@@ -116,6 +117,15 @@ func (suite *RateEngineSuite) Test_CheckPPMTotal() {
 	if cost.GCC != expected {
 		t.Errorf("wrong GCC: expected %d, got %d", expected, cost.GCC)
 	}
+
+	// The inputs are recorded alongside the result
+	suite.Equal(unit.Pound(2000), cost.Inputs.Weight)
+	suite.Equal(1.0, cost.Inputs.ProrateFactor)
+	suite.Equal("428", cost.Inputs.OriginServiceArea)
+	suite.Equal("197", cost.Inputs.DestinationServiceArea)
+	suite.Equal(unit.DiscountRate(.6), cost.Inputs.LinehaulDiscount)
+	suite.Equal(unit.DiscountRate(.5), cost.Inputs.SITDiscount)
+	suite.Equal(1, cost.Inputs.DaysInSIT)
 }
 
 type RateEngineSuite struct {
@@ -125,6 +135,52 @@ type RateEngineSuite struct {
 	planner route.Planner
 }
 
+func (suite *RateEngineSuite) Test_PeakRateAdjustments() {
+	engine := NewRateEngine(suite.db, suite.logger, suite.planner)
+	adjustment := models.Tariff400ngPeakRateAdjustment{
+		Code:               "185A",
+		Multiplier:         1.1,
+		EffectiveDateLower: testdatagen.PeakRateCycleStart,
+		EffectiveDateUpper: testdatagen.PeakRateCycleEnd,
+	}
+	suite.mustSave(&adjustment)
+
+	// Rates are only adjusted during the peak rate cycle
+	multiplier, err := engine.peakRateMultiplier("SIT", "185A", testdatagen.DateOutsidePeakRateCycle)
+	suite.NoError(err)
+	suite.Equal(1.0, multiplier)
+
+	cents, err := engine.applyPeakRateAdjustment("SIT", "185A", testdatagen.DateInsidePeakRateCycle, unit.Cents(1000))
+	suite.NoError(err)
+	suite.Equal(unit.Cents(1100), cents)
+
+	// Items without an adjustment aren't adjusted
+	cents, err = engine.applyPeakRateAdjustment("SIT", "185B", testdatagen.DateInsidePeakRateCycle, unit.Cents(1000))
+	suite.NoError(err)
+	suite.Equal(unit.Cents(1000), cents)
+
+	// The multiplier is updated mid-cycle
+	updated := time.Date(testdatagen.TestYear, time.June, 1, 0, 0, 0, 0, time.UTC)
+	suite.NoError(models.SupersedeTariff400ngRow(suite.db, "tariff400ng_peak_rate_adjustments", adjustment.ID, updated))
+	correction := models.Tariff400ngPeakRateAdjustment{
+		Code:               "185A",
+		Multiplier:         1.2,
+		EffectiveDateLower: testdatagen.PeakRateCycleStart,
+		EffectiveDateUpper: testdatagen.PeakRateCycleEnd,
+		PublishedAt:        updated,
+	}
+	suite.mustSave(&correction)
+
+	cents, err = engine.applyPeakRateAdjustment("SIT", "185A", testdatagen.DateInsidePeakRateCycle, unit.Cents(1000))
+	suite.NoError(err)
+	suite.Equal(unit.Cents(1200), cents)
+
+	// Shipments rated before the update keep the original multiplier
+	cents, err = engine.WithTariffAsOf(updated.AddDate(0, 0, -1)).applyPeakRateAdjustment("SIT", "185A", testdatagen.DateInsidePeakRateCycle, unit.Cents(1000))
+	suite.NoError(err)
+	suite.Equal(unit.Cents(1100), cents)
+}
+
 func (suite *RateEngineSuite) SetupTest() {
 	suite.db.TruncateAll()
 }
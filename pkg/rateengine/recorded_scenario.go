@@ -0,0 +1,300 @@
+package rateengine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/route"
+	"github.com/transcom/mymove/pkg/unit"
+)
+
+// RecordedScenarioKind is the computation a recorded scenario runs
+type RecordedScenarioKind string
+
+const (
+	// RecordedScenarioKindPPM rates the scenario with ComputePPM
+	RecordedScenarioKindPPM RecordedScenarioKind = "PPM"
+	// RecordedScenarioKindSHIPMENT rates the scenario with ComputeShipment
+	RecordedScenarioKindSHIPMENT RecordedScenarioKind = "SHIPMENT"
+)
+
+// RecordedTariff holds the tariff rows a recorded scenario is rated against
+type RecordedTariff struct {
+	Zip3s           models.Tariff400ngZip3s           `json:"zip3s"`
+	ServiceAreas    models.Tariff400ngServiceAreas    `json:"service_areas"`
+	LinehaulRates   models.Tariff400ngLinehaulRates   `json:"linehaul_rates"`
+	ShorthaulRates  models.Tariff400ngShorthaulRates  `json:"shorthaul_rates"`
+	FullPackRates   models.Tariff400ngFullPackRates   `json:"full_pack_rates"`
+	FullUnpackRates models.Tariff400ngFullUnpackRates `json:"full_unpack_rates"`
+	// PeakRateAdjustments are only read for scenarios in the peak rate cycle
+	PeakRateAdjustments models.Tariff400ngPeakRateAdjustments `json:"peak_rate_adjustments,omitempty"`
+}
+
+// RecordedInputs are the arguments a recorded scenario is computed with
+type RecordedInputs struct {
+	Weight           unit.Pound        `json:"weight"`
+	OriginZip5       string            `json:"origin_zip5"`
+	DestinationZip5  string            `json:"destination_zip5"`
+	Date             time.Time         `json:"date"`
+	DaysInSIT        int               `json:"days_in_sit"`
+	LinehaulDiscount unit.DiscountRate `json:"linehaul_discount"`
+	SITDiscount      unit.DiscountRate `json:"sit_discount"`
+	// TariffAsOf is the version of the tariff tables the scenario is rated against, or zero
+	// for the current version
+	TariffAsOf time.Time `json:"tariff_as_of"`
+}
+
+// RecordedCost is the CostComputation a recorded scenario is expected to produce
+type RecordedCost struct {
+	Mileage                   int        `json:"mileage"`
+	ProrateFactor             float64    `json:"prorate_factor"`
+	OriginServiceArea         string     `json:"origin_service_area"`
+	DestinationServiceArea    string     `json:"destination_service_area"`
+	BaseLinehaul              unit.Cents `json:"base_linehaul"`
+	OriginLinehaulFactor      unit.Cents `json:"origin_linehaul_factor"`
+	DestinationLinehaulFactor unit.Cents `json:"destination_linehaul_factor"`
+	ShorthaulCharge           unit.Cents `json:"shorthaul_charge"`
+	LinehaulChargeTotal       unit.Cents `json:"linehaul_charge_total"`
+	OriginServiceFee          unit.Cents `json:"origin_service_fee"`
+	DestinationServiceFee     unit.Cents `json:"destination_service_fee"`
+	PackFee                   unit.Cents `json:"pack_fee"`
+	UnpackFee                 unit.Cents `json:"unpack_fee"`
+	SITFee                    unit.Cents `json:"sit_fee"`
+	SITMax                    unit.Cents `json:"sit_max"`
+	GCC                       unit.Cents `json:"gcc"`
+}
+
+// NewRecordedCost records the result of a computation
+func NewRecordedCost(cost CostComputation) RecordedCost {
+	return RecordedCost{
+		Mileage:                   cost.Mileage,
+		ProrateFactor:             cost.Inputs.ProrateFactor,
+		OriginServiceArea:         cost.Inputs.OriginServiceArea,
+		DestinationServiceArea:    cost.Inputs.DestinationServiceArea,
+		BaseLinehaul:              cost.BaseLinehaul,
+		OriginLinehaulFactor:      cost.OriginLinehaulFactor,
+		DestinationLinehaulFactor: cost.DestinationLinehaulFactor,
+		ShorthaulCharge:           cost.ShorthaulCharge,
+		LinehaulChargeTotal:       cost.LinehaulChargeTotal,
+		OriginServiceFee:          cost.OriginServiceFee,
+		DestinationServiceFee:     cost.DestinationServiceFee,
+		PackFee:                   cost.PackFee,
+		UnpackFee:                 cost.UnpackFee,
+		SITFee:                    cost.SITFee,
+		SITMax:                    cost.SITMax,
+		GCC:                       cost.GCC,
+	}
+}
+
+// RecordedScenario is a rate engine computation saved with everything needed to repeat
+// it: the tariff rows it read, the mileage the planner returned, its inputs and its result.
+// Recorded scenarios are replayed against an empty database to catch regressions in the
+// rate math.
+type RecordedScenario struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description,omitempty"`
+	Kind        RecordedScenarioKind `json:"kind"`
+	Mileage     int                  `json:"mileage"`
+	Inputs      RecordedInputs       `json:"inputs"`
+	Tariff      RecordedTariff       `json:"tariff"`
+	Expected    RecordedCost         `json:"expected"`
+}
+
+// ReadRecordedScenarioFile reads a recorded scenario from a JSON file
+func ReadRecordedScenarioFile(path string) (RecordedScenario, error) {
+	var scenario RecordedScenario
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return scenario, errors.Wrapf(err, "could not read recorded scenario %s", path)
+	}
+	if err := json.Unmarshal(data, &scenario); err != nil {
+		return scenario, errors.Wrapf(err, "could not parse recorded scenario %s", path)
+	}
+	if scenario.Kind != RecordedScenarioKindPPM && scenario.Kind != RecordedScenarioKindSHIPMENT {
+		return scenario, errors.Errorf("recorded scenario %s has unknown kind %q", path, scenario.Kind)
+	}
+	return scenario, nil
+}
+
+// MarshalIndentedJSON exports the scenario as indented JSON
+func (s RecordedScenario) MarshalIndentedJSON() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// Load saves the scenario's tariff rows
+func (s RecordedScenario) Load(db *pop.Connection) error {
+	var rows []interface{}
+	for i := range s.Tariff.Zip3s {
+		rows = append(rows, &s.Tariff.Zip3s[i])
+	}
+	for i := range s.Tariff.ServiceAreas {
+		rows = append(rows, &s.Tariff.ServiceAreas[i])
+	}
+	for i := range s.Tariff.LinehaulRates {
+		rows = append(rows, &s.Tariff.LinehaulRates[i])
+	}
+	for i := range s.Tariff.ShorthaulRates {
+		rows = append(rows, &s.Tariff.ShorthaulRates[i])
+	}
+	for i := range s.Tariff.FullPackRates {
+		rows = append(rows, &s.Tariff.FullPackRates[i])
+	}
+	for i := range s.Tariff.FullUnpackRates {
+		rows = append(rows, &s.Tariff.FullUnpackRates[i])
+	}
+	for i := range s.Tariff.PeakRateAdjustments {
+		rows = append(rows, &s.Tariff.PeakRateAdjustments[i])
+	}
+
+	for _, row := range rows {
+		verrs, err := db.ValidateAndCreate(row)
+		if err != nil {
+			return errors.Wrapf(err, "could not load %T for recorded scenario %s", row, s.Name)
+		}
+		if verrs.HasAny() {
+			return errors.Errorf("could not load %T for recorded scenario %s: %s", row, s.Name, verrs.Error())
+		}
+	}
+	return nil
+}
+
+// Run computes the scenario against the rows in db, using the recorded mileage
+func (s RecordedScenario) Run(db *pop.Connection, logger *zap.Logger) (CostComputation, error) {
+	engine := NewRateEngine(db, logger, route.NewTestingPlanner(s.Mileage)).WithTariffAsOf(s.Inputs.TariffAsOf)
+	compute := engine.ComputePPM
+	if s.Kind == RecordedScenarioKindSHIPMENT {
+		compute = engine.ComputeShipment
+	}
+	return compute(s.Inputs.Weight,
+		s.Inputs.OriginZip5,
+		s.Inputs.DestinationZip5,
+		s.Inputs.Date,
+		s.Inputs.DaysInSIT,
+		s.Inputs.LinehaulDiscount,
+		s.Inputs.SITDiscount,
+	)
+}
+
+// Diff compares a computation with the scenario's expected result field by field, and
+// describes each field that doesn't match
+func (s RecordedScenario) Diff(cost CostComputation) []string {
+	var diffs []string
+	expected := reflect.ValueOf(s.Expected)
+	actual := reflect.ValueOf(NewRecordedCost(cost))
+	for i := 0; i < expected.NumField(); i++ {
+		want := expected.Field(i).Interface()
+		got := actual.Field(i).Interface()
+		if want != got {
+			diffs = append(diffs, fmt.Sprintf("%s: expected %v, got %v", expected.Type().Field(i).Name, want, got))
+		}
+	}
+	return diffs
+}
+
+// CaptureShipmentScenario rates a shipment and records the computation, along with every
+// tariff row it read, as a scenario. The shipment must be loaded as HandleRunOnShipment
+// expects.
+func (re *RateEngine) CaptureShipmentScenario(shipment models.Shipment, name string) (RecordedScenario, error) {
+	trace := re.EnableTrace()
+	shipmentCost, err := re.HandleRunOnShipment(shipment)
+	if err != nil {
+		return RecordedScenario{}, err
+	}
+	cost := shipmentCost.Cost
+
+	scenario := RecordedScenario{
+		Name:        name,
+		Description: fmt.Sprintf("Captured from shipment %s", shipment.ID),
+		Kind:        RecordedScenarioKindSHIPMENT,
+		Mileage:     cost.Mileage,
+		Inputs: RecordedInputs{
+			Weight:           cost.Inputs.Weight,
+			OriginZip5:       cost.Inputs.OriginZip5,
+			DestinationZip5:  cost.Inputs.DestinationZip5,
+			Date:             cost.Inputs.Date,
+			DaysInSIT:        cost.Inputs.DaysInSIT,
+			LinehaulDiscount: cost.Inputs.LinehaulDiscount,
+			SITDiscount:      cost.Inputs.SITDiscount,
+			TariffAsOf:       cost.Inputs.TariffAsOf,
+		},
+		Expected: NewRecordedCost(cost),
+	}
+
+	for _, zip3 := range []string{cost.Inputs.OriginZip3, cost.Inputs.DestinationZip3} {
+		var row models.Tariff400ngZip3
+		if err := re.db.Where("zip3 = $1", zip3).First(&row); err != nil {
+			return scenario, errors.Wrapf(err, "could not find zip3 %s", zip3)
+		}
+		if !containsZip3(scenario.Tariff.Zip3s, row.ID) {
+			scenario.Tariff.Zip3s = append(scenario.Tariff.Zip3s, row)
+		}
+	}
+
+	// The trace names every tariff row the computation read
+	seen := map[uuid.UUID]bool{}
+	for _, step := range trace.Steps {
+		if step.RowID == nil || seen[*step.RowID] {
+			continue
+		}
+		seen[*step.RowID] = true
+
+		var row interface{}
+		switch step.Table {
+		case "tariff400ng_service_areas":
+			row = &models.Tariff400ngServiceArea{}
+		case "tariff400ng_linehaul_rates":
+			row = &models.Tariff400ngLinehaulRate{}
+		case "tariff400ng_shorthaul_rates":
+			row = &models.Tariff400ngShorthaulRate{}
+		case "tariff400ng_full_pack_rates":
+			row = &models.Tariff400ngFullPackRate{}
+		case "tariff400ng_full_unpack_rates":
+			row = &models.Tariff400ngFullUnpackRate{}
+		case "tariff400ng_peak_rate_adjustments":
+			row = &models.Tariff400ngPeakRateAdjustment{}
+		case "transportation_service_provider_performances":
+			// The discount rates are recorded with the inputs
+			continue
+		default:
+			return scenario, errors.Errorf("can't record rows from %s", step.Table)
+		}
+		if err := re.db.Find(row, *step.RowID); err != nil {
+			return scenario, errors.Wrapf(err, "could not find %s row %s", step.Table, *step.RowID)
+		}
+
+		switch r := row.(type) {
+		case *models.Tariff400ngServiceArea:
+			scenario.Tariff.ServiceAreas = append(scenario.Tariff.ServiceAreas, *r)
+		case *models.Tariff400ngLinehaulRate:
+			scenario.Tariff.LinehaulRates = append(scenario.Tariff.LinehaulRates, *r)
+		case *models.Tariff400ngShorthaulRate:
+			scenario.Tariff.ShorthaulRates = append(scenario.Tariff.ShorthaulRates, *r)
+		case *models.Tariff400ngFullPackRate:
+			scenario.Tariff.FullPackRates = append(scenario.Tariff.FullPackRates, *r)
+		case *models.Tariff400ngFullUnpackRate:
+			scenario.Tariff.FullUnpackRates = append(scenario.Tariff.FullUnpackRates, *r)
+		case *models.Tariff400ngPeakRateAdjustment:
+			scenario.Tariff.PeakRateAdjustments = append(scenario.Tariff.PeakRateAdjustments, *r)
+		}
+	}
+
+	return scenario, nil
+}
+
+func containsZip3(zip3s models.Tariff400ngZip3s, id uuid.UUID) bool {
+	for _, zip3 := range zip3s {
+		if zip3.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package rateengine
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/route"
+	"github.com/transcom/mymove/pkg/testdatagen/scenario"
+	"github.com/transcom/mymove/pkg/unit"
+)
+
+func (suite *RateEngineSuite) Test_RecordedScenarios() {
+	paths, err := filepath.Glob("testdata/scenarios/*.json")
+	suite.Nil(err)
+	suite.NotEmpty(paths, "no recorded scenarios were found")
+
+	for _, path := range paths {
+		suite.db.TruncateAll()
+
+		recorded, err := ReadRecordedScenarioFile(path)
+		if !suite.Nil(err, path) {
+			continue
+		}
+		if err := recorded.Load(suite.db); !suite.Nil(err, path) {
+			continue
+		}
+
+		cost, err := recorded.Run(suite.db, suite.logger)
+		if !suite.Nil(err, path) {
+			continue
+		}
+		suite.Empty(recorded.Diff(cost), "%s (%s) no longer computes as recorded", recorded.Name, path)
+	}
+}
+
+func (suite *RateEngineSuite) Test_RecordedScenarioDiff() {
+	recorded := RecordedScenario{
+		Expected: RecordedCost{Mileage: 1693, PackFee: unit.Cents(100), GCC: unit.Cents(500)},
+	}
+	cost := CostComputation{GCC: unit.Cents(501)}
+	cost.Mileage = 1693
+	cost.PackFee = unit.Cents(100)
+
+	suite.Equal([]string{"GCC: expected 500, got 501"}, recorded.Diff(cost))
+}
+
+func (suite *RateEngineSuite) Test_CaptureShipmentScenario() {
+	if err := scenario.RunRateEngineScenario2(suite.db); err != nil {
+		suite.FailNow("failed to run scenario 2", "%+v", err)
+	}
+
+	weight := unit.Pound(7500)
+	pickupDate := time.Date(2018, time.December, 5, 0, 0, 0, 0, time.UTC)
+	shipment := models.Shipment{
+		PickupAddress:    &models.Address{PostalCode: "94540"},
+		NetWeight:        &weight,
+		ActualPickupDate: &pickupDate,
+		ShipmentOffers: models.ShipmentOffers{
+			{
+				TransportationServiceProviderPerformance: models.TransportationServiceProviderPerformance{
+					ID:           uuid.Must(uuid.NewV4()),
+					LinehaulRate: unit.DiscountRate(0.67),
+				},
+			},
+		},
+	}
+	shipment.Move.Orders.NewDutyStation.Address.PostalCode = "78626"
+
+	engine := NewRateEngine(suite.db, suite.logger, route.NewTestingPlanner(1693))
+	recorded, err := engine.CaptureShipmentScenario(shipment, "captured")
+	suite.Nil(err, "could not capture shipment")
+
+	suite.Equal(RecordedScenarioKindSHIPMENT, recorded.Kind)
+	suite.Equal(1693, recorded.Mileage)
+	suite.Equal(unit.Cents(637056), recorded.Expected.GCC)
+	suite.Len(recorded.Tariff.Zip3s, 2)
+	suite.Len(recorded.Tariff.ServiceAreas, 2)
+	suite.Len(recorded.Tariff.LinehaulRates, 1)
+	suite.Len(recorded.Tariff.ShorthaulRates, 0)
+	suite.Len(recorded.Tariff.FullPackRates, 1)
+	suite.Len(recorded.Tariff.FullUnpackRates, 1)
+
+	// The captured file is enough to replay the computation on its own
+	exported, err := recorded.MarshalIndentedJSON()
+	suite.Nil(err)
+	file, err := ioutil.TempFile("", "recorded_scenario")
+	suite.Nil(err)
+	defer os.Remove(file.Name())
+	_, err = file.Write(exported)
+	suite.Nil(err)
+	suite.Nil(file.Close())
+
+	replayed, err := ReadRecordedScenarioFile(file.Name())
+	suite.Nil(err)
+	suite.db.TruncateAll()
+	suite.Nil(replayed.Load(suite.db))
+	cost, err := replayed.Run(suite.db, suite.logger)
+	suite.Nil(err)
+	suite.Empty(replayed.Diff(cost))
+}
@@ -0,0 +1,112 @@
+package rateengine
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/unit"
+)
+
+// StorageInTransitCharge is what a shipment is billed for one stay in SIT
+type StorageInTransitCharge struct {
+	StorageInTransitID uuid.UUID
+	Location           models.StorageInTransitLocation
+	BillableDays       int
+	// FirstDayCharge is the 185A charge for the first day and warehouse handling
+	FirstDayCharge unit.Cents
+	// AdditionalDaysCharge is the 185B charge for each day after the first
+	AdditionalDaysCharge unit.Cents
+}
+
+// Total returns the charge for the whole stay
+func (c StorageInTransitCharge) Total() unit.Cents {
+	return c.FirstDayCharge.AddCents(c.AdditionalDaysCharge)
+}
+
+// storageInTransitCharges prices each released stay in SIT at the rates of the service area
+// the goods were stored in, as of the day they went into storage, including the peak rate
+// multipliers if the goods went in during the peak rate cycle. Days past the authorized
+// days and approved extensions aren't billed.
+func (re *RateEngine) storageInTransitCharges(
+	sits models.StorageInTransits,
+	weight unit.Pound,
+	originZip5 string,
+	destinationZip5 string,
+	sitDiscount unit.DiscountRate) ([]StorageInTransitCharge, error) {
+
+	// Weights below 1000lbs are prorated to the 1000lb rate
+	prorateFactor := 1.0
+	if weight.Int() < 1000 {
+		prorateFactor = weight.Float64() / 1000.0
+		weight = unit.Pound(1000)
+	}
+	cwt := weight.ToCWT()
+
+	var charges []StorageInTransitCharge
+	for _, sit := range sits {
+		days := sit.BillableDays()
+		if days == 0 {
+			continue
+		}
+
+		label := "DestinationSIT"
+		zip3 := Zip5ToZip3(destinationZip5)
+		if sit.Location == models.StorageInTransitLocationORIGIN {
+			label = "OriginSIT"
+			zip3 = Zip5ToZip3(originZip5)
+		}
+		re.trace.calculate(label, "Days in storage, capped at the authorized days plus approved extensions",
+			map[string]interface{}{
+				"storage_in_transit_id": sit.ID.String(),
+				"days_in_storage":       sit.DaysInStorage(*sit.OutDate),
+				"allowed_days":          sit.AllowedDays(),
+			},
+			days)
+
+		sa, err := models.FetchTariff400ngServiceAreaForZip3(re.db, zip3, sit.InDate, re.tariffAsOf)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to determine SIT rates for storage in transit %s", sit.ID)
+		}
+		re.trace.lookup(label, "SIT rates for the service area the goods were stored in", "tariff400ng_service_areas", sa.ID,
+			map[string]interface{}{
+				"zip3":         zip3,
+				"service_area": sa.ServiceArea,
+				"sit_185a":     sa.SIT185ARateCents,
+				"sit_185b":     sa.SIT185BRateCents,
+			},
+			sa.ServiceArea)
+
+		firstDay := sa.SIT185ARateCents.Multiply(cwt.Int())
+		re.trace.calculate(label, "185A first day rate x CWT",
+			map[string]interface{}{"sit_185a": sa.SIT185ARateCents, "cwt": cwt.Int()},
+			firstDay)
+		firstDay, err = re.applyPeakRateAdjustment(label, "185A", sit.InDate, firstDay)
+		if err != nil {
+			return nil, err
+		}
+		additionalDays := sa.SIT185BRateCents.Multiply(days - 1).Multiply(cwt.Int())
+		re.trace.calculate(label, "185B additional day rate x additional days x CWT",
+			map[string]interface{}{"sit_185b": sa.SIT185BRateCents, "additional_days": days - 1, "cwt": cwt.Int()},
+			additionalDays)
+		additionalDays, err = re.applyPeakRateAdjustment(label, "185B", sit.InDate, additionalDays)
+		if err != nil {
+			return nil, err
+		}
+
+		charge := StorageInTransitCharge{
+			StorageInTransitID:   sit.ID,
+			Location:             sit.Location,
+			BillableDays:         days,
+			FirstDayCharge:       re.applyDiscount(label, sitDiscount, firstDay).MultiplyFloat64(prorateFactor),
+			AdditionalDaysCharge: re.applyDiscount(label, sitDiscount, additionalDays).MultiplyFloat64(prorateFactor),
+		}
+		if prorateFactor != 1.0 {
+			re.trace.calculate(label, "SIT charges are scaled by the prorate factor",
+				map[string]interface{}{"prorate_factor": prorateFactor},
+				charge.Total())
+		}
+		charges = append(charges, charge)
+	}
+	return charges, nil
+}
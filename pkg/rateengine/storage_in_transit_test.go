@@ -0,0 +1,94 @@
+package rateengine
+
+import (
+	"github.com/gofrs/uuid"
+
+	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/testdatagen"
+	"github.com/transcom/mymove/pkg/unit"
+)
+
+func (suite *RateEngineSuite) setupStorageInTransitRates() {
+	zip3 := models.Tariff400ngZip3{
+		Zip3:          "395",
+		BasepointCity: "Saucier",
+		State:         "MS",
+		ServiceArea:   "428",
+		RateArea:      "US48",
+		Region:        "11",
+	}
+	suite.mustSave(&zip3)
+
+	serviceArea := models.Tariff400ngServiceArea{
+		Name:               "Gulfport, MS",
+		ServiceArea:        "428",
+		LinehaulFactor:     57,
+		ServiceChargeCents: 350,
+		EffectiveDateLower: testdatagen.PeakRateCycleStart,
+		EffectiveDateUpper: testdatagen.PeakRateCycleEnd,
+		SIT185ARateCents:   unit.Cents(50),
+		SIT185BRateCents:   unit.Cents(50),
+		SITPDSchedule:      1,
+	}
+	suite.mustSave(&serviceArea)
+}
+
+func (suite *RateEngineSuite) Test_StorageInTransitCharges() {
+	suite.setupStorageInTransitRates()
+	engine := NewRateEngine(suite.db, suite.logger, suite.planner)
+
+	inDate := testdatagen.DateInsidePeakRateCycle
+	released := models.StorageInTransit{
+		ID:             uuid.Must(uuid.NewV4()),
+		Location:       models.StorageInTransitLocationDESTINATION,
+		Status:         models.StorageInTransitStatusINSIT,
+		InDate:         inDate,
+		AuthorizedDays: models.StorageInTransitMaxDays,
+	}
+	suite.NoError(released.Release(inDate.AddDate(0, 0, 10)))
+	stillInStorage := models.StorageInTransit{
+		ID:             uuid.Must(uuid.NewV4()),
+		Location:       models.StorageInTransitLocationORIGIN,
+		Status:         models.StorageInTransitStatusINSIT,
+		InDate:         inDate,
+		AuthorizedDays: models.StorageInTransitMaxDays,
+	}
+	sits := models.StorageInTransits{released, stillInStorage}
+
+	charges, err := engine.storageInTransitCharges(sits, unit.Pound(2000), "94540", "39503", unit.DiscountRate(0.5))
+	suite.NoError(err)
+	if suite.Len(charges, 1, "only released stays are billed") {
+		charge := charges[0]
+		suite.Equal(released.ID, charge.StorageInTransitID)
+		suite.Equal(10, charge.BillableDays)
+		// 50 cents x 20 CWT, less the 50% discount
+		suite.Equal(unit.Cents(500), charge.FirstDayCharge)
+		// 50 cents x 9 days x 20 CWT, less the 50% discount
+		suite.Equal(unit.Cents(4500), charge.AdditionalDaysCharge)
+		suite.Equal(unit.Cents(5000), charge.Total())
+	}
+
+	// Shipments under 1000lbs are charged a prorated share of the 1000lb rate
+	charges, err = engine.storageInTransitCharges(sits, unit.Pound(500), "94540", "39503", unit.DiscountRate(0.5))
+	suite.NoError(err)
+	if suite.Len(charges, 1) {
+		suite.Equal(unit.Cents(125), charges[0].FirstDayCharge)
+		suite.Equal(unit.Cents(1125), charges[0].AdditionalDaysCharge)
+	}
+}
+
+func (suite *RateEngineSuite) Test_StorageInTransitChargesWithoutRates() {
+	engine := NewRateEngine(suite.db, suite.logger, suite.planner)
+
+	inDate := testdatagen.DateInsidePeakRateCycle
+	sit := models.StorageInTransit{
+		Location:       models.StorageInTransitLocationORIGIN,
+		Status:         models.StorageInTransitStatusINSIT,
+		InDate:         inDate,
+		AuthorizedDays: models.StorageInTransitMaxDays,
+	}
+	suite.NoError(sit.Release(inDate.AddDate(0, 0, 3)))
+
+	_, err := engine.storageInTransitCharges(models.StorageInTransits{sit}, unit.Pound(2000), "39503", "94540", unit.DiscountRate(0.5))
+	suite.Error(err)
+}
@@ -0,0 +1,105 @@
+package rateengine
+
+import (
+	"encoding/json"
+
+	"github.com/gofrs/uuid"
+)
+
+// TraceStepType is the kind of work a trace step records
+type TraceStepType string
+
+const (
+	// TraceStepTypeLOOKUP records a value that was read from a tariff table or the planner
+	TraceStepTypeLOOKUP TraceStepType = "LOOKUP"
+	// TraceStepTypeCALCULATION records an arithmetic step and the values it was applied to
+	TraceStepTypeCALCULATION TraceStepType = "CALCULATION"
+)
+
+// TraceStep is a single lookup or arithmetic step the rate engine made while computing a charge
+type TraceStep struct {
+	Charge      string                 `json:"charge"`
+	Type        TraceStepType          `json:"type"`
+	Description string                 `json:"description"`
+	Table       string                 `json:"table,omitempty"`
+	RowID       *uuid.UUID             `json:"row_id,omitempty"`
+	Values      map[string]interface{} `json:"values,omitempty"`
+	Result      interface{}            `json:"result"`
+}
+
+// Trace records every step of a computation, in the order the steps were made, so that
+// how each charge was arrived at can be explained. A nil Trace records nothing.
+type Trace struct {
+	Steps []TraceStep `json:"steps"`
+}
+
+// StepsForCharge returns the steps that went into a charge
+func (t *Trace) StepsForCharge(charge string) []TraceStep {
+	var steps []TraceStep
+	if t == nil {
+		return steps
+	}
+	for _, step := range t.Steps {
+		if step.Charge == charge {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// MarshalIndentedJSON exports the trace as indented JSON
+func (t *Trace) MarshalIndentedJSON() ([]byte, error) {
+	return json.MarshalIndent(t, "", "  ")
+}
+
+// lookup records a value that was read from a row of a tariff table
+func (t *Trace) lookup(charge string, description string, table string, rowID uuid.UUID, values map[string]interface{}, result interface{}) {
+	if t == nil {
+		return
+	}
+	step := TraceStep{
+		Charge:      charge,
+		Type:        TraceStepTypeLOOKUP,
+		Description: description,
+		Table:       table,
+		Values:      values,
+		Result:      result,
+	}
+	if rowID != uuid.Nil {
+		step.RowID = &rowID
+	}
+	t.Steps = append(t.Steps, step)
+}
+
+// calculate records an arithmetic step
+func (t *Trace) calculate(charge string, description string, values map[string]interface{}, result interface{}) {
+	if t == nil {
+		return
+	}
+	t.Steps = append(t.Steps, TraceStep{
+		Charge:      charge,
+		Type:        TraceStepTypeCALCULATION,
+		Description: description,
+		Values:      values,
+		Result:      result,
+	})
+}
+
+// mark returns the position of the next step, so the steps recorded after it can be relabelled
+func (t *Trace) mark() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.Steps)
+}
+
+// relabel attributes the steps recorded since a mark to a specific charge, for helpers that
+// are used for more than one charge, such as origin and destination fees
+func (t *Trace) relabel(mark int, charge string) {
+	if t == nil {
+		return
+	}
+	for i := mark; i < len(t.Steps); i++ {
+		t.Steps[i].Charge = charge
+	}
+}
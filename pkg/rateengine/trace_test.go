@@ -0,0 +1,87 @@
+package rateengine
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/transcom/mymove/pkg/route"
+	"github.com/transcom/mymove/pkg/testdatagen/scenario"
+	"github.com/transcom/mymove/pkg/unit"
+)
+
+func (suite *RateEngineSuite) Test_Trace() {
+	if err := scenario.RunRateEngineScenario2(suite.db); err != nil {
+		suite.FailNow("failed to run scenario 2", "%+v", err)
+	}
+
+	engine := NewRateEngine(suite.db, suite.logger, route.NewTestingPlanner(1693))
+	trace := engine.EnableTrace()
+
+	date := time.Date(2018, time.December, 5, 0, 0, 0, 0, time.UTC)
+	cost, err := engine.ComputePPM(unit.Pound(7500), "94540", "78626", date, 0, unit.DiscountRate(0.67), 0)
+	suite.Nil(err, "could not compute PPM")
+
+	mileage := trace.StepsForCharge("Mileage")
+	suite.Len(mileage, 1)
+	suite.Equal(1693, mileage[0].Result)
+
+	// Lookups record the tariff row they read
+	baseLinehaul := trace.StepsForCharge("BaseLinehaul")
+	suite.Len(baseLinehaul, 1)
+	suite.Equal(TraceStepTypeLOOKUP, baseLinehaul[0].Type)
+	suite.Equal("tariff400ng_linehaul_rates", baseLinehaul[0].Table)
+	suite.NotNil(baseLinehaul[0].RowID)
+	suite.Equal(1693, baseLinehaul[0].Values["mileage"])
+
+	// Origin and destination charges are told apart
+	originFactor := trace.StepsForCharge("OriginLinehaulFactor")
+	suite.Len(originFactor, 2)
+	suite.Equal("80", originFactor[0].Values["service_area"])
+	destinationFactor := trace.StepsForCharge("DestinationLinehaulFactor")
+	suite.Len(destinationFactor, 2)
+	suite.Equal("744", destinationFactor[0].Values["service_area"])
+
+	// The last step for each charge is its final value
+	for charge, value := range map[string]unit.Cents{
+		"OriginServiceFee":      cost.OriginServiceFee,
+		"DestinationServiceFee": cost.DestinationServiceFee,
+		"PackFee":               cost.PackFee,
+		"UnpackFee":             cost.UnpackFee,
+		"LinehaulChargeTotal":   cost.LinehaulChargeTotal,
+		"SITMax":                cost.SITMax,
+		"GCC":                   cost.GCC,
+	} {
+		steps := trace.StepsForCharge(charge)
+		if suite.NotEmpty(steps, charge) {
+			suite.Equal(value, steps[len(steps)-1].Result, charge)
+		}
+	}
+
+	exported, err := trace.MarshalIndentedJSON()
+	suite.Nil(err)
+	var imported Trace
+	suite.Nil(json.Unmarshal(exported, &imported))
+	suite.Len(imported.Steps, len(trace.Steps))
+}
+
+func (suite *RateEngineSuite) Test_TraceProration() {
+	if err := scenario.RunRateEngineScenario2(suite.db); err != nil {
+		suite.FailNow("failed to run scenario 2", "%+v", err)
+	}
+
+	engine := NewRateEngine(suite.db, suite.logger, route.NewTestingPlanner(1693))
+	trace := engine.EnableTrace()
+
+	date := time.Date(2018, time.December, 5, 0, 0, 0, 0, time.UTC)
+	cost, err := engine.ComputePPM(unit.Pound(600), "94540", "78626", date, 0, unit.DiscountRate(0.67), 0)
+	suite.Nil(err, "could not compute PPM")
+
+	weight := trace.StepsForCharge("Weight")
+	suite.Len(weight, 2)
+	suite.Equal(0.6, weight[0].Result)
+	suite.Equal(10, weight[1].Result)
+
+	gcc := trace.StepsForCharge("GCC")
+	suite.Len(gcc, 2)
+	suite.Equal(cost.GCC, gcc[1].Result)
+}
@@ -37,9 +37,12 @@ type BingResponse struct {
 	ResourceSets []ResourceSet `json:"resourceSets"`
 }
 
-// Uses the Microsoft Bing Maps API to calculate the trucking distance between two endpoints
-func (p *bingPlanner) wayPointsTransitDistance(wp1 string, wp2 string) (int, error) {
-	query := fmt.Sprintf("%s&wp.1=%s&wp.2=%s", p.endPointWithKey, wp1, wp2)
+// Uses the Microsoft Bing Maps API to calculate the trucking distance of a route visiting each endpoint in turn
+func (p *bingPlanner) wayPointsTransitDistance(wayPoints ...string) (int, error) {
+	query := p.endPointWithKey
+	for index, wayPoint := range wayPoints {
+		query += fmt.Sprintf("&wp.%d=%s", index+1, wayPoint)
+	}
 
 	resp, err := p.httpClient.Get(query)
 	if err != nil {
@@ -84,6 +87,17 @@ func (p *bingPlanner) TransitDistance(source *models.Address, destination *model
 	return p.wayPointsTransitDistance(urlencodeAddress(source), urlencodeAddress(destination))
 }
 
+func (p *bingPlanner) WaypointsTransitDistance(waypoints []*models.Address) (int, error) {
+	if len(waypoints) < 2 {
+		return 0, errors.New("a route needs at least two waypoints")
+	}
+	var wayPoints []string
+	for _, waypoint := range waypoints {
+		wayPoints = append(wayPoints, urlencodeAddress(waypoint))
+	}
+	return p.wayPointsTransitDistance(wayPoints...)
+}
+
 // NewBingPlanner constructs and returns a Planner which uses the Bing Map API to plan routes.
 // endpoint should be the full URL to the Truck route REST endpoint,
 // e.g. https://dev.virtualearth.net/REST/v1/Routes/Truck and apiKey should be the Bing Maps API key associated with
@@ -94,3 +108,7 @@ func NewBingPlanner(logger *zap.Logger, endpoint *string, apiKey *string) Planne
 		httpClient:      http.Client{Timeout: bingRequestTimeout},
 		endPointWithKey: fmt.Sprintf("%s?key=%s", *endpoint, *apiKey)}
 }
+
+func (p *bingPlanner) providerName() string {
+	return "bing"
+}
@@ -0,0 +1,185 @@
+package route
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/models"
+)
+
+// DefaultRouteCacheTTL is how long a planned distance is reused before it is planned again
+const DefaultRouteCacheTTL = 30 * 24 * time.Hour
+
+// Kinds of places that distances are cached between
+const (
+	routeKindAddress   = "address"
+	routeKindLatLong   = "latlong"
+	routeKindZip5      = "zip5"
+	routeKindWaypoints = "waypoints"
+)
+
+// CacheStats counts how often a CachingPlanner found a distance in its cache
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// CachingPlanner remembers the distances another planner finds in the route_distances table,
+// so that the same route is only planned once until it expires
+type CachingPlanner struct {
+	// hits and misses come first so that they are aligned for atomic access
+	hits    uint64
+	misses  uint64
+	db      *pop.Connection
+	logger  *zap.Logger
+	planner Planner
+	ttl     time.Duration
+}
+
+// Stats returns the cache hits and misses since the planner was constructed
+func (p *CachingPlanner) Stats() CacheStats {
+	return CacheStats{
+		Hits:   atomic.LoadUint64(&p.hits),
+		Misses: atomic.LoadUint64(&p.misses),
+	}
+}
+
+// LogStats logs the cache hits and misses every interval until the context is cancelled
+func (p *CachingPlanner) LogStats(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stats := p.Stats()
+			p.logger.Info("Route distance cache stats", zap.Uint64("hits", stats.Hits), zap.Uint64("misses", stats.Misses))
+		}
+	}
+}
+
+// cachedDistance returns the cached distance between two places, or asks the planner and
+// caches its answer. Failures to read or write the cache are logged, but don't stop the
+// planner from being asked. Estimates from the offline planner aren't cached, so that the
+// route is planned online again as soon as a mapping service answers.
+func (p *CachingPlanner) cachedDistance(kind string, sourceKey string, destinationKey string, distance func(Planner) (int, error)) (int, error) {
+	now := time.Now()
+	cached, err := models.FetchRouteDistance(p.db, kind, sourceKey, destinationKey, now)
+	if err == nil {
+		atomic.AddUint64(&p.hits, 1)
+		p.logger.Debug("Route distance cache hit", zap.String("kind", kind), zap.String("provider", cached.Provider))
+		return cached.Miles, nil
+	}
+	if err != models.ErrFetchNotFound {
+		p.logger.Error("Failed to read route distance cache", zap.Error(err))
+	}
+	atomic.AddUint64(&p.misses, 1)
+	p.logger.Debug("Route distance cache miss", zap.String("kind", kind))
+
+	miles, provider, err := planWithProvider(p.planner, distance)
+	if err != nil {
+		return 0, err
+	}
+	if provider == offlineProviderName {
+		p.logger.Debug("Not caching offline route distance estimate", zap.String("kind", kind))
+		return miles, nil
+	}
+	err = models.SaveRouteDistance(p.db, models.RouteDistance{
+		Kind:           kind,
+		SourceKey:      sourceKey,
+		DestinationKey: destinationKey,
+		Miles:          miles,
+		Provider:       provider,
+		ExpiresAt:      now.Add(p.ttl),
+	})
+	if err != nil {
+		p.logger.Error("Failed to write route distance cache", zap.Error(err))
+	}
+	return miles, nil
+}
+
+// TransitDistance returns the cached distance between two addresses
+func (p *CachingPlanner) TransitDistance(source *models.Address, destination *models.Address) (int, error) {
+	return p.cachedDistance(routeKindAddress, addressKey(source), addressKey(destination), func(planner Planner) (int, error) {
+		return planner.TransitDistance(source, destination)
+	})
+}
+
+// LatLongTransitDistance returns the cached distance between two locations
+func (p *CachingPlanner) LatLongTransitDistance(source LatLong, destination LatLong) (int, error) {
+	return p.cachedDistance(routeKindLatLong, source.Coords(), destination.Coords(), func(planner Planner) (int, error) {
+		return planner.LatLongTransitDistance(source, destination)
+	})
+}
+
+// Zip5TransitDistance returns the cached distance between two zip codes
+func (p *CachingPlanner) Zip5TransitDistance(source string, destination string) (int, error) {
+	return p.cachedDistance(routeKindZip5, zip5Key(source), zip5Key(destination), func(planner Planner) (int, error) {
+		return planner.Zip5TransitDistance(source, destination)
+	})
+}
+
+// WaypointsTransitDistance returns the cached distance of a route that visits each waypoint in
+// turn. The route is cached from its first waypoint to the rest of them.
+func (p *CachingPlanner) WaypointsTransitDistance(waypoints []*models.Address) (int, error) {
+	if len(waypoints) < 2 {
+		return 0, errors.New("a route needs at least two waypoints")
+	}
+	var rest []string
+	for _, waypoint := range waypoints[1:] {
+		rest = append(rest, addressKey(waypoint))
+	}
+	return p.cachedDistance(routeKindWaypoints, addressKey(waypoints[0]), strings.Join(rest, " > "), func(planner Planner) (int, error) {
+		return planner.WaypointsTransitDistance(waypoints)
+	})
+}
+
+// zip5Key normalizes a zip code, dropping any +4 suffix
+func zip5Key(zip string) string {
+	zip = strings.TrimSpace(zip)
+	if len(zip) > 5 {
+		zip = zip[:5]
+	}
+	return zip
+}
+
+var addressPunctuation = regexp.MustCompile(`[.,#]`)
+
+// addressKey normalizes an address, so that differences in case, spacing and punctuation
+// don't stop it matching a cached route
+func addressKey(address *models.Address) string {
+	normalize := func(s string) string {
+		return strings.Join(strings.Fields(strings.ToUpper(addressPunctuation.ReplaceAllString(s, " "))), " ")
+	}
+	parts := []string{normalize(address.StreetAddress1)}
+	if address.StreetAddress2 != nil {
+		parts = append(parts, normalize(*address.StreetAddress2))
+	}
+	if address.StreetAddress3 != nil {
+		parts = append(parts, normalize(*address.StreetAddress3))
+	}
+	parts = append(parts, normalize(address.City), normalize(address.State), zip5Key(address.PostalCode))
+	if address.Country != nil {
+		parts = append(parts, normalize(*address.Country))
+	}
+	return strings.Join(parts, "|")
+}
+
+// NewCachingPlanner constructs a CachingPlanner which caches the distances planner finds for ttl
+func NewCachingPlanner(db *pop.Connection, logger *zap.Logger, planner Planner, ttl time.Duration) *CachingPlanner {
+	return &CachingPlanner{
+		db:      db,
+		logger:  logger,
+		planner: planner,
+		ttl:     ttl,
+	}
+}
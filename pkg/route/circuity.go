@@ -0,0 +1,135 @@
+package route
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+// CircuitySample is a road distance that a mapping service planned between two zip codes
+type CircuitySample struct {
+	SourceZip5      string
+	DestinationZip5 string
+	Miles           int
+}
+
+// CircuitySamplePairs picks zip5 pairs to plan when calibrating the circuity factors. Each
+// national area is the source of perArea pairs, whose destinations are spread over the whole
+// country. Pairs closer than shortHaulMiles are skipped, since the factors don't apply to them.
+// The same seed always picks the same pairs.
+func CircuitySamplePairs(seed int64, perArea int) [][2]string {
+	zipsByArea := make([][]int, len(circuityFactors))
+	var allZips []int
+	for zip5 := range zip5ToLatLongMap {
+		allZips = append(allZips, zip5)
+	}
+	sort.Ints(allZips)
+	for _, zip5 := range allZips {
+		area := zip5 / 10000
+		zipsByArea[area] = append(zipsByArea[area], zip5)
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	var pairs [][2]string
+	for _, zips := range zipsByArea {
+		for picked := 0; picked < perArea && len(zips) > 0; {
+			source := zips[rng.Intn(len(zips))]
+			dest := allZips[rng.Intn(len(allZips))]
+			if greatCircleMiles(zip5ToLatLongMap[source], zip5ToLatLongMap[dest]) < shortHaulMiles {
+				continue
+			}
+			pairs = append(pairs, [2]string{fmt.Sprintf("%05d", source), fmt.Sprintf("%05d", dest)})
+			picked++
+		}
+	}
+	return pairs
+}
+
+// FitCircuityFactors finds the circuity factor of each national area that best predicts the
+// samples' road distances from their great-circle distances. A route's circuity is the mean
+// of its two areas' factors, as in the offline planner, and the factors are fitted to the
+// samples' circuities by least squares. Samples closer than shortHaulMiles, or between zip
+// codes that aren't in the bundled data, are skipped. It also returns how many samples
+// started or ended in each area.
+func FitCircuityFactors(samples []CircuitySample) ([10]float64, [10]int, error) {
+	var factors [10]float64
+	var counts [10]int
+
+	// Normal equations of the least squares fit, with the right hand side in the last column
+	var normal [10][11]float64
+	for _, sample := range samples {
+		source, sourceArea, ok := circuitySampleZip(sample.SourceZip5)
+		if !ok {
+			continue
+		}
+		dest, destArea, ok := circuitySampleZip(sample.DestinationZip5)
+		if !ok {
+			continue
+		}
+		miles := greatCircleMiles(source, dest)
+		if miles < shortHaulMiles {
+			continue
+		}
+		circuity := float64(sample.Miles) / miles
+
+		var row [10]float64
+		row[sourceArea] += 0.5
+		row[destArea] += 0.5
+		for i := range row {
+			for j := range row {
+				normal[i][j] += row[i] * row[j]
+			}
+			normal[i][10] += row[i] * circuity
+		}
+		counts[sourceArea]++
+		if destArea != sourceArea {
+			counts[destArea]++
+		}
+	}
+
+	for area, count := range counts {
+		if count == 0 {
+			return factors, counts, fmt.Errorf("no samples start or end in area %d", area)
+		}
+	}
+
+	// Gaussian elimination with partial pivoting
+	for col := 0; col < 10; col++ {
+		pivot := col
+		for row := col + 1; row < 10; row++ {
+			if math.Abs(normal[row][col]) > math.Abs(normal[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(normal[pivot][col]) < 1e-9 {
+			return factors, counts, fmt.Errorf("samples don't determine the factor of area %d", col)
+		}
+		normal[col], normal[pivot] = normal[pivot], normal[col]
+		for row := col + 1; row < 10; row++ {
+			scale := normal[row][col] / normal[col][col]
+			for k := col; k <= 10; k++ {
+				normal[row][k] -= scale * normal[col][k]
+			}
+		}
+	}
+	for col := 9; col >= 0; col-- {
+		sum := normal[col][10]
+		for k := col + 1; k < 10; k++ {
+			sum -= normal[col][k] * factors[k]
+		}
+		factors[col] = sum / normal[col][col]
+	}
+	return factors, counts, nil
+}
+
+// circuitySampleZip returns the location and national area of a zip5 in the bundled data
+func circuitySampleZip(zip5 string) (LatLong, int, bool) {
+	zipAsInt, err := strconv.Atoi(zip5Key(zip5))
+	if err != nil {
+		return LatLong{}, 0, false
+	}
+	ll, ok := zip5ToLatLongMap[zipAsInt]
+	return ll, zipAsInt / 10000, ok
+}
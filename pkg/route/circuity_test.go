@@ -0,0 +1,42 @@
+package route
+
+import (
+	"math"
+	"strconv"
+)
+
+func (suite *PlannerSuite) TestFitCircuityFactors() {
+	want := [10]float64{1.30, 1.25, 1.20, 1.15, 1.10, 1.12, 1.14, 1.16, 1.28, 1.24}
+
+	// Plan the sample pairs as if every route's circuity were the mean of its areas' factors
+	pairs := CircuitySamplePairs(1, 20)
+	suite.Len(pairs, 200)
+	var samples []CircuitySample
+	for _, pair := range pairs {
+		source, err := Zip5ToLatLong(pair[0])
+		suite.NoError(err)
+		dest, err := Zip5ToLatLong(pair[1])
+		suite.NoError(err)
+		sourceZip, _ := strconv.Atoi(pair[0])
+		destZip, _ := strconv.Atoi(pair[1])
+		circuity := (want[sourceZip/10000] + want[destZip/10000]) / 2
+		samples = append(samples, CircuitySample{
+			SourceZip5:      pair[0],
+			DestinationZip5: pair[1],
+			Miles:           int(math.Round(greatCircleMiles(source, dest) * circuity)),
+		})
+	}
+
+	factors, counts, err := FitCircuityFactors(samples)
+	suite.NoError(err)
+	for area := range want {
+		suite.InDelta(want[area], factors[area], 0.01, "area %d", area)
+		suite.True(counts[area] >= 20, "area %d has %d samples", area, counts[area])
+	}
+
+	// Every area needs samples
+	_, _, err = FitCircuityFactors(samples[:1])
+	suite.Error(err)
+
+	suite.Equal(pairs, CircuitySamplePairs(1, 20), "the same seed picks different pairs")
+}
@@ -0,0 +1,95 @@
+package route
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/transcom/mymove/pkg/models"
+	"go.uber.org/zap"
+)
+
+// namedPlanner is a Planner that plans with a single provider, such as a mapping service
+type namedPlanner interface {
+	providerName() string
+}
+
+// reportingPlanner is a Planner that reports which of its providers planned each route
+type reportingPlanner interface {
+	planWithProvider(distance func(Planner) (int, error)) (int, string, error)
+}
+
+// providerName returns the name of the provider a planner plans with
+func providerName(planner Planner) string {
+	if named, ok := planner.(namedPlanner); ok {
+		return named.providerName()
+	}
+	return "unknown"
+}
+
+// planWithProvider asks a planner for a distance, and returns the name of the provider that answered
+func planWithProvider(planner Planner, distance func(Planner) (int, error)) (int, string, error) {
+	if reporting, ok := planner.(reportingPlanner); ok {
+		return reporting.planWithProvider(distance)
+	}
+	miles, err := distance(planner)
+	return miles, providerName(planner), err
+}
+
+// fallbackPlanner asks each of its planners in turn until one of them answers
+type fallbackPlanner struct {
+	logger   *zap.Logger
+	planners []Planner
+}
+
+func (p fallbackPlanner) planWithProvider(distance func(Planner) (int, error)) (int, string, error) {
+	var failures []string
+	for _, planner := range p.planners {
+		miles, provider, err := planWithProvider(planner, distance)
+		if err != nil {
+			p.logger.Warn("Route provider failed to plan route", zap.String("provider", provider), zap.Error(err))
+			failures = append(failures, provider+": "+err.Error())
+			continue
+		}
+		p.logger.Info("Planned route", zap.String("provider", provider), zap.Int("miles", miles), zap.Int("failed_providers", len(failures)))
+		return miles, provider, nil
+	}
+	return 0, "", errors.Errorf("every route provider failed: %s", strings.Join(failures, "; "))
+}
+
+func (p fallbackPlanner) TransitDistance(source *models.Address, destination *models.Address) (int, error) {
+	miles, _, err := p.planWithProvider(func(planner Planner) (int, error) {
+		return planner.TransitDistance(source, destination)
+	})
+	return miles, err
+}
+
+func (p fallbackPlanner) LatLongTransitDistance(source LatLong, destination LatLong) (int, error) {
+	miles, _, err := p.planWithProvider(func(planner Planner) (int, error) {
+		return planner.LatLongTransitDistance(source, destination)
+	})
+	return miles, err
+}
+
+func (p fallbackPlanner) Zip5TransitDistance(source string, destination string) (int, error) {
+	miles, _, err := p.planWithProvider(func(planner Planner) (int, error) {
+		return planner.Zip5TransitDistance(source, destination)
+	})
+	return miles, err
+}
+
+func (p fallbackPlanner) WaypointsTransitDistance(waypoints []*models.Address) (int, error) {
+	miles, _, err := p.planWithProvider(func(planner Planner) (int, error) {
+		return planner.WaypointsTransitDistance(waypoints)
+	})
+	return miles, err
+}
+
+// NewFallbackPlanner constructs a route.Planner which asks each planner in turn, such as HERE,
+// then Bing, then the offline planner, until one of them answers. It logs which provider
+// planned each route.
+func NewFallbackPlanner(logger *zap.Logger, planners ...Planner) Planner {
+	return fallbackPlanner{
+		logger:   logger,
+		planners: planners,
+	}
+}
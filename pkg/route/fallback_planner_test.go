@@ -0,0 +1,76 @@
+package route
+
+import (
+	"errors"
+
+	"github.com/transcom/mymove/pkg/models"
+)
+
+// failingPlanner is a Planner whose provider is down
+type failingPlanner struct{}
+
+func (fp failingPlanner) TransitDistance(source *models.Address, destination *models.Address) (int, error) {
+	return 0, errors.New("provider is down")
+}
+
+func (fp failingPlanner) LatLongTransitDistance(source LatLong, dest LatLong) (int, error) {
+	return 0, errors.New("provider is down")
+}
+
+func (fp failingPlanner) Zip5TransitDistance(source string, destination string) (int, error) {
+	return 0, errors.New("provider is down")
+}
+
+func (fp failingPlanner) WaypointsTransitDistance(waypoints []*models.Address) (int, error) {
+	return 0, errors.New("provider is down")
+}
+
+func (fp failingPlanner) providerName() string {
+	return "failing"
+}
+
+func (suite *PlannerSuite) TestFallbackPlanner() {
+	planner := NewFallbackPlanner(suite.logger, failingPlanner{}, NewTestingPlanner(1234), NewOfflinePlanner(suite.logger))
+
+	distance, err := planner.TransitDistance(&realAddressSource, &realAddressDestination)
+	suite.NoError(err)
+	suite.Equal(1234, distance)
+
+	// The provider that answered is reported
+	distance, provider, err := planWithProvider(planner, func(p Planner) (int, error) {
+		return p.Zip5TransitDistance(bradyTXZip, venturaCAZip)
+	})
+	suite.NoError(err)
+	suite.Equal(1234, distance)
+	suite.Equal("testing", provider)
+
+	// Fallback planners can fall back to each other
+	nested := NewFallbackPlanner(suite.logger, failingPlanner{}, NewFallbackPlanner(suite.logger, failingPlanner{}, NewOfflinePlanner(suite.logger)))
+	_, provider, err = planWithProvider(nested, func(p Planner) (int, error) {
+		return p.Zip5TransitDistance(bradyTXZip, venturaCAZip)
+	})
+	suite.NoError(err)
+	suite.Equal("offline", provider)
+
+	planner = NewFallbackPlanner(suite.logger, failingPlanner{}, failingPlanner{})
+	_, err = planner.Zip5TransitDistance(bradyTXZip, venturaCAZip)
+	suite.EqualError(err, "every route provider failed: failing: provider is down; failing: provider is down")
+}
+
+func (suite *PlannerSuite) TestRouteCacheKeys() {
+	suite.Equal("94103", zip5Key(" 94103-1234"))
+
+	address := models.Address{
+		StreetAddress1: "1333  Minna St.",
+		City:           "San Francisco",
+		State:          "ca",
+		PostalCode:     "94103-1234",
+	}
+	suite.Equal("1333 MINNA ST|SAN FRANCISCO|CA|94103", addressKey(&address))
+	suite.Equal(addressKey(&address), addressKey(&models.Address{
+		StreetAddress1: "1333 minna st",
+		City:           "SAN FRANCISCO",
+		State:          "CA",
+		PostalCode:     "94103",
+	}))
+}
@@ -0,0 +1,148 @@
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/transcom/mymove/pkg/models"
+	"go.uber.org/zap"
+)
+
+// GeocodeMatch is a place that a geocoder matched an address to
+type GeocodeMatch struct {
+	Location LatLong
+	// Relevance is how closely the place matches the address, from 0 to 1
+	Relevance float64
+	// MatchLevel is how precisely the place was found, such as houseNumber, street or city
+	MatchLevel string
+	City       string
+	State      string
+	PostalCode string
+	// Label is the place's full address
+	Label string
+}
+
+// Geocoder is the interface needed to find where an address is
+type Geocoder interface {
+	// Geocode returns the places an address matches, most relevant first. It returns an error
+	// if nothing matches.
+	Geocode(address *models.Address) ([]GeocodeMatch, error)
+}
+
+// HerePosition is a lat long position in the json response from HERE
+type HerePosition struct {
+	Lat  float32 `json:"Latitude"`
+	Long float32 `json:"Longitude"`
+}
+
+// HereAddress is the address of a place in the json response from the geocoder
+type HereAddress struct {
+	Label      string `json:"Label"`
+	State      string `json:"State"`
+	City       string `json:"City"`
+	PostalCode string `json:"PostalCode"`
+}
+
+// HereSearchLocation is part of the json response from the geocoder
+type HereSearchLocation struct {
+	NavigationPosition []HerePosition `json:"NavigationPosition"`
+	Address            HereAddress    `json:"Address"`
+}
+
+// HereSearchResultType is part of the json response from the geo
+type HereSearchResultType struct {
+	Relevance  float64            `json:"Relevance"`
+	MatchLevel string             `json:"MatchLevel"`
+	Location   HereSearchLocation `json:"Location"`
+}
+
+// HereSearchResultsViewType is part of the json response from the geocoder
+type HereSearchResultsViewType struct {
+	Result []HereSearchResultType `json:"Result"`
+}
+
+// GeocodeResponse is the json structure returned as "Response" in HERE geocode request
+type GeocodeResponse struct {
+	View []HereSearchResultsViewType `json:"View"`
+}
+
+// GeocodeResponseBody is the json structure returned from HERE geocode request
+type GeocodeResponseBody struct {
+	Response GeocodeResponse `json:"Response"`
+}
+
+// hereGeocoder finds addresses using the HERE geocoder API
+type hereGeocoder struct {
+	logger                  *zap.Logger
+	httpClient              http.Client
+	geocodeEndPointWithKeys string
+}
+
+func (g *hereGeocoder) Geocode(address *models.Address) ([]GeocodeMatch, error) {
+	query := fmt.Sprintf("%s&searchtext=%s", g.geocodeEndPointWithKeys, urlencodeAddress(address))
+	resp, err := g.httpClient.Get(query)
+	if err != nil {
+		g.logger.Error("Getting response from HERE.", zap.Error(err), zap.Object("address", address))
+		return nil, errors.Wrap(err, "calling HERE")
+	}
+	if resp.StatusCode != 200 {
+		bodyBytes, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			g.logger.Info("Got non-200 response from HERE. Unable to read response body.", zap.Int("http_status", resp.StatusCode), zap.Object("address", address))
+			return nil, errors.Wrap(err, "non-200 HERE Response")
+		}
+		g.logger.Info("Got non-200 response from HERE geocoder.", zap.Int("http_status", resp.StatusCode), zap.String("here_error", string(bodyBytes)), zap.Object("address", address))
+		return nil, errors.New("error response from HERE")
+	}
+
+	// Decode Json response and check structure
+	locationDecoder := json.NewDecoder(resp.Body)
+	var response GeocodeResponseBody
+	err = locationDecoder.Decode(&response)
+	if err != nil {
+		g.logger.Error("Failed to decode response from HERE geocode address lookup.", zap.Error(err), zap.Object("address", address))
+		return nil, errors.Wrap(err, "decoding geocode response from HERE")
+	}
+	if len(response.Response.View) == 0 {
+		g.logger.Error("Expected at least one View in geocoder response for address.", zap.Object("address", address))
+		return nil, errors.New("no View in geocoder response")
+	}
+
+	var matches []GeocodeMatch
+	for _, result := range response.Response.View[0].Result {
+		if len(result.Location.NavigationPosition) == 0 {
+			continue
+		}
+		position := result.Location.NavigationPosition[0]
+		matches = append(matches, GeocodeMatch{
+			Location:   LatLong{Latitude: position.Lat, Longitude: position.Long},
+			Relevance:  result.Relevance,
+			MatchLevel: result.MatchLevel,
+			City:       result.Location.Address.City,
+			State:      result.Location.Address.State,
+			PostalCode: result.Location.Address.PostalCode,
+			Label:      result.Location.Address.Label,
+		})
+	}
+	if len(matches) == 0 {
+		g.logger.Error("Expected at least one SearchResult with a navigation position in response for address.", zap.Object("address", address))
+		return nil, errors.New("empty Response in geocoder response")
+	}
+	return matches, nil
+}
+
+func newHEREGeocoder(logger *zap.Logger, geocodeEndpoint string, appID string, appCode string) *hereGeocoder {
+	return &hereGeocoder{
+		logger:                  logger,
+		httpClient:              http.Client{Timeout: hereRequestTimeout},
+		geocodeEndPointWithKeys: addKeysToEndpoint(geocodeEndpoint, appID, appCode),
+	}
+}
+
+// NewHEREGeocoder constructs and returns a Geocoder which uses the HERE Map API to find addresses
+func NewHEREGeocoder(logger *zap.Logger, geocodeEndpoint string, appID string, appCode string) Geocoder {
+	return newHEREGeocoder(logger, geocodeEndpoint, appID, appCode)
+}
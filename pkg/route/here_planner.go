@@ -19,10 +19,10 @@ const hereRequestTimeout = time.Duration(15) * time.Second
 
 // herePlanner holds configuration information to make calls using the HERE maps API
 type herePlanner struct {
-	logger                  *zap.Logger
-	httpClient              http.Client
-	routeEndPointWithKeys   string
-	geocodeEndPointWithKeys string
+	logger                *zap.Logger
+	httpClient            http.Client
+	routeEndPointWithKeys string
+	geocoder              *hereGeocoder
 }
 
 type addressLatLong struct {
@@ -31,36 +31,8 @@ type addressLatLong struct {
 	location LatLong
 }
 
-// HerePosition is a lat long position in the json response from HERE
-type HerePosition struct {
-	Lat  float32 `json:"Latitude"`
-	Long float32 `json:"Longitude"`
-}
-
-// HereSearchLocation is part of the json response from the geocoder
-type HereSearchLocation struct {
-	NavigationPosition []HerePosition `json:"NavigationPosition"`
-}
-
-// HereSearchResultType is part of the json response from the geo
-type HereSearchResultType struct {
-	Location HereSearchLocation `json:"Location"`
-}
-
-// HereSearchResultsViewType is part of the json response from the geocoder
-type HereSearchResultsViewType struct {
-	Result []HereSearchResultType `json:"Result"`
-}
-
-// GeocodeResponse is the json structure returned as "Response" in HERE geocode request
-type GeocodeResponse struct {
-	View []HereSearchResultsViewType `json:"View"`
-}
-
-// GeocodeResponseBody is the json structure returned from HERE geocode request
-type GeocodeResponseBody struct {
-	Response GeocodeResponse `json:"Response"`
-}
+// minPlanningRelevance is the least relevant geocoder match that a route is planned from
+const minPlanningRelevance = 0.5
 
 // getAddressLatLong is expected to run in a goroutine to look up the LatLong of an address using the HERE
 // geocoder endpoint. It returns the data via a channel so two requests can run in parallel
@@ -69,43 +41,15 @@ func (p *herePlanner) getAddressLatLong(responses chan addressLatLong, address *
 	var latLongResponse addressLatLong
 	latLongResponse.address = address
 
-	// Look up address
-	query := fmt.Sprintf("%s&searchtext=%s", p.geocodeEndPointWithKeys, urlencodeAddress(address))
-	resp, err := p.httpClient.Get(query)
+	matches, err := p.geocoder.Geocode(address)
 	if err != nil {
-		p.logger.Error("Getting response from HERE.", zap.Error(err), zap.Object("address", address))
-		latLongResponse.err = errors.Wrap(err, "calling HERE")
-	} else if resp.StatusCode != 200 {
-		bodyBytes, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			p.logger.Info("Got non-200 response from HERE. Unable to read response body.", zap.Int("http_status", resp.StatusCode), zap.Object("address", address))
-			latLongResponse.err = errors.Wrap(err, "non-200 HERE Response")
-		} else {
-			p.logger.Info("Got non-200 response from HERE routing.", zap.Int("http_status", resp.StatusCode), zap.String("here_error", string(bodyBytes)), zap.Object("address", address))
-			latLongResponse.err = errors.New("error response from HERE")
-		}
+		latLongResponse.err = err
+	} else if matches[0].Relevance < minPlanningRelevance {
+		p.logger.Info("Best geocoder match for address is not relevant enough to plan from.",
+			zap.Float64("relevance", matches[0].Relevance), zap.String("match_level", matches[0].MatchLevel), zap.Object("address", address))
+		latLongResponse.err = errors.New("no relevant match in geocoder response")
 	} else {
-		// Decode Json response and check structure
-		locationDecoder := json.NewDecoder(resp.Body)
-		var response GeocodeResponseBody
-		err = locationDecoder.Decode(&response)
-		if err != nil {
-			p.logger.Error("Failed to decode response from HERE geocode address lookup.", zap.Error(err), zap.Object("address", address))
-			latLongResponse.err = errors.Wrap(err, "decoding geocode response from HERE")
-		} else if len(response.Response.View) == 0 {
-			p.logger.Error("Expected at least one View in geocoder response for address.", zap.Error(err), zap.Object("address", address))
-			latLongResponse.err = errors.New("no View in geocoder response")
-		} else if len(response.Response.View[0].Result) == 0 {
-			p.logger.Error("Expected at least one SearchResult in response for address.", zap.Error(err), zap.Object("address", address))
-			latLongResponse.err = errors.New("empty Response in geocoder response")
-		} else if len(response.Response.View[0].Result[0].Location.NavigationPosition) == 0 {
-			p.logger.Error("Expected at least one Navigation poitions in response for address.", zap.Error(err), zap.Object("address", address))
-			latLongResponse.err = errors.New("empty navigation postiong in geocoder response")
-		} else {
-			position := &response.Response.View[0].Result[0].Location.NavigationPosition[0]
-			latLongResponse.location.Latitude = position.Lat
-			latLongResponse.location.Longitude = position.Long
-		}
+		latLongResponse.location = matches[0].Location
 	}
 	responses <- latLongResponse
 }
@@ -130,11 +74,16 @@ type RoutingResponseBody struct {
 	Response RoutingResponse `json:"response"`
 }
 
-const routeEndpointFormat = "%s&waypoint0=geo!%s&waypoint1=geo!%s&mode=fastest;truck;traffic:disabled"
+const routeModeParam = "&mode=fastest;truck;traffic:disabled"
 const metersInAMile = 1609.34
 
-func (p *herePlanner) LatLongTransitDistance(source LatLong, dest LatLong) (int, error) {
-	query := fmt.Sprintf(routeEndpointFormat, p.routeEndPointWithKeys, source.Coords(), dest.Coords())
+// latLongsTransitDistance plans a route that visits each location in turn
+func (p *herePlanner) latLongsTransitDistance(locations []LatLong) (int, error) {
+	query := p.routeEndPointWithKeys
+	for index, location := range locations {
+		query += fmt.Sprintf("&waypoint%d=geo!%s", index, location.Coords())
+	}
+	query += routeModeParam
 	resp, err := p.httpClient.Get(query)
 	if err != nil {
 		p.logger.Error("Getting route response from HERE.", zap.Error(err))
@@ -163,31 +112,42 @@ func (p *herePlanner) LatLongTransitDistance(source LatLong, dest LatLong) (int,
 	}
 }
 
+func (p *herePlanner) LatLongTransitDistance(source LatLong, dest LatLong) (int, error) {
+	return p.latLongsTransitDistance([]LatLong{source, dest})
+}
+
 func (p *herePlanner) Zip5TransitDistance(source string, destination string) (int, error) {
 	return zip5TransitDistanceHelper(p, source, destination)
 }
 
 func (p *herePlanner) TransitDistance(source *models.Address, destination *models.Address) (int, error) {
+	return p.WaypointsTransitDistance([]*models.Address{source, destination})
+}
+
+func (p *herePlanner) WaypointsTransitDistance(waypoints []*models.Address) (int, error) {
+	if len(waypoints) < 2 {
+		return 0, errors.New("a route needs at least two waypoints")
+	}
 
-	// Convert addresses to LatLong using geocode API. Do via goroutines and channel so we can do two
-	// requests in parallel
-	responses := make(chan addressLatLong)
-	var srcLatLong LatLong
-	var destLatLong LatLong
-	go p.getAddressLatLong(responses, source)
-	go p.getAddressLatLong(responses, destination)
-	for count := 0; count < 2; count++ {
+	// Convert addresses to LatLong using geocode API. Do via goroutines and channel so we can do
+	// the requests in parallel
+	responses := make(chan addressLatLong, len(waypoints))
+	locations := make([]LatLong, len(waypoints))
+	for _, waypoint := range waypoints {
+		go p.getAddressLatLong(responses, waypoint)
+	}
+	for count := 0; count < len(waypoints); count++ {
 		response := <-responses
 		if response.err != nil {
 			return 0, response.err
 		}
-		if response.address == source {
-			srcLatLong = response.location
-		} else {
-			destLatLong = response.location
+		for index, waypoint := range waypoints {
+			if response.address == waypoint {
+				locations[index] = response.location
+			}
 		}
 	}
-	return p.LatLongTransitDistance(srcLatLong, destLatLong)
+	return p.latLongsTransitDistance(locations)
 }
 
 func addKeysToEndpoint(endpoint string, id string, code string) string {
@@ -197,8 +157,12 @@ func addKeysToEndpoint(endpoint string, id string, code string) string {
 // NewHEREPlanner constructs and returns a Planner which uses the HERE Map API to plan routes.
 func NewHEREPlanner(logger *zap.Logger, geocodeEndpoint string, routeEndpoint string, appID string, appCode string) Planner {
 	return &herePlanner{
-		logger:                  logger,
-		httpClient:              http.Client{Timeout: hereRequestTimeout},
-		routeEndPointWithKeys:   addKeysToEndpoint(routeEndpoint, appID, appCode),
-		geocodeEndPointWithKeys: addKeysToEndpoint(geocodeEndpoint, appID, appCode)}
+		logger:                logger,
+		httpClient:            http.Client{Timeout: hereRequestTimeout},
+		routeEndPointWithKeys: addKeysToEndpoint(routeEndpoint, appID, appCode),
+		geocoder:              newHEREGeocoder(logger, geocodeEndpoint, appID, appCode)}
+}
+
+func (p *herePlanner) providerName() string {
+	return "here"
 }
@@ -0,0 +1,189 @@
+package route
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/transcom/mymove/pkg/models"
+)
+
+// earthRadiusMiles is the mean radius of the Earth, used for great-circle distances
+const earthRadiusMiles = 3958.8
+
+// circuityFactors are the ratios of road distance to great-circle distance for routes starting or
+// ending in each of the USPS national areas, which are numbered by the first digit of the zip code.
+// Areas with mountains, coastlines or sparse interstates have longer routes than the flat middle
+// of the country.
+//
+// The factors are fitted by cmd/calibrate_circuity to road distances that HERE plans between
+// sampled zip codes, and its samples are kept in testdata/circuity_samples.csv so that the fit
+// can be checked and rerun with -input. Until that file is committed, these are hand estimates
+// rather than fitted values, and mileage from the offline planner is only approximate.
+var circuityFactors = [10]float64{
+	1.22, // 0: New England, New Jersey, Puerto Rico
+	1.20, // 1: New York, Pennsylvania, Delaware
+	1.19, // 2: Mid-Atlantic and the Carolinas
+	1.17, // 3: Southeast
+	1.15, // 4: Great Lakes
+	1.16, // 5: Upper Midwest and the northern Plains
+	1.14, // 6: Central Plains
+	1.16, // 7: South Central
+	1.23, // 8: Mountain West
+	1.21, // 9: Pacific
+}
+
+// shortHaulMiles is the great-circle distance under which routes wind more than the area's factor
+// allows for, because local roads rarely run straight between two points
+const shortHaulMiles = 50
+
+// shortHaulCircuity is added to the circuity factor of a route that covers no distance, and
+// tapers off until shortHaulMiles
+const shortHaulCircuity = 0.15
+
+// zip3Centroid is the mean location of a zip3's zip5s
+type zip3Centroid struct {
+	zip3     int
+	location LatLong
+}
+
+var zip3CentroidsOnce sync.Once
+var zip3Centroids map[int]zip3Centroid
+
+// loadZip3Centroids averages the locations of each zip3's zip5s
+func loadZip3Centroids() {
+	zip3CentroidsOnce.Do(func() {
+		sums := map[int][3]float64{}
+		for zip5, ll := range zip5ToLatLongMap {
+			sum := sums[zip5/100]
+			sums[zip5/100] = [3]float64{sum[0] + float64(ll.Latitude), sum[1] + float64(ll.Longitude), sum[2] + 1}
+		}
+		zip3Centroids = make(map[int]zip3Centroid, len(sums))
+		for zip3, sum := range sums {
+			zip3Centroids[zip3] = zip3Centroid{
+				zip3: zip3,
+				location: LatLong{
+					Latitude:  float32(sum[0] / sum[2]),
+					Longitude: float32(sum[1] / sum[2]),
+				},
+			}
+		}
+	})
+}
+
+// Zip3ToLatLong returns the mean location of the zip5s in a zip3
+func Zip3ToLatLong(zip3 string) (LatLong, error) {
+	zipAsInt, err := strconv.Atoi(zip3)
+	if err != nil {
+		return LatLong{}, err
+	}
+	loadZip3Centroids()
+	centroid, ok := zip3Centroids[zipAsInt]
+	if !ok {
+		return LatLong{}, fmt.Errorf("could not find zip3 %s", zip3)
+	}
+	return centroid.location, nil
+}
+
+// nearestZip3 returns the zip3 whose centroid is closest to a location
+func nearestZip3(ll LatLong) int {
+	loadZip3Centroids()
+	nearest, nearestMiles := 0, math.Inf(1)
+	for _, centroid := range zip3Centroids {
+		if miles := greatCircleMiles(ll, centroid.location); miles < nearestMiles {
+			nearest, nearestMiles = centroid.zip3, miles
+		}
+	}
+	return nearest
+}
+
+// greatCircleMiles is the haversine distance between two locations
+func greatCircleMiles(source LatLong, dest LatLong) float64 {
+	toRadians := func(degrees float32) float64 { return float64(degrees) * math.Pi / 180 }
+	sLat, dLat := toRadians(source.Latitude), toRadians(dest.Latitude)
+	deltaLat := dLat - sLat
+	deltaLong := toRadians(dest.Longitude) - toRadians(source.Longitude)
+	a := math.Pow(math.Sin(deltaLat/2), 2) + math.Cos(sLat)*math.Cos(dLat)*math.Pow(math.Sin(deltaLong/2), 2)
+	return 2 * earthRadiusMiles * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// offlineProviderName names the offline planner as the provider of the distances it estimates
+const offlineProviderName = "offline"
+
+// offlinePlanner estimates road distances from the bundled zip code locations, without calling a
+// mapping service. Like DTOD, it plans between zip codes rather than street addresses.
+type offlinePlanner struct {
+	logger *zap.Logger
+}
+
+// roadMiles scales the great-circle distance between two locations in the given zip3s by the
+// circuity of their national areas
+func (p offlinePlanner) roadMiles(source LatLong, sourceZip3 int, dest LatLong, destZip3 int) int {
+	miles := greatCircleMiles(source, dest)
+	circuity := (circuityFactors[sourceZip3/100] + circuityFactors[destZip3/100]) / 2
+	if miles < shortHaulMiles {
+		circuity += shortHaulCircuity * (1 - miles/shortHaulMiles)
+	}
+	return int(math.Round(miles * circuity))
+}
+
+// zipLocation returns the location of a zip5, or of its zip3 if the zip5 isn't in the bundled data
+func (p offlinePlanner) zipLocation(zip string) (LatLong, int, error) {
+	zip = strings.TrimSpace(zip)
+	if len(zip) > 5 {
+		zip = zip[:5]
+	}
+	zipAsInt, err := strconv.Atoi(zip)
+	if err != nil {
+		return LatLong{}, 0, errors.Wrapf(err, "could not parse zip code %s", zip)
+	}
+	if ll, ok := zip5ToLatLongMap[zipAsInt]; ok {
+		return ll, zipAsInt / 100, nil
+	}
+	loadZip3Centroids()
+	centroid, ok := zip3Centroids[zipAsInt/100]
+	if !ok {
+		return LatLong{}, 0, fmt.Errorf("could not find zip code %s", zip)
+	}
+	p.logger.Debug("Planning from the zip3 of an unknown zip5", zap.String("zip5", zip))
+	return centroid.location, centroid.zip3, nil
+}
+
+func (p offlinePlanner) LatLongTransitDistance(source LatLong, dest LatLong) (int, error) {
+	return p.roadMiles(source, nearestZip3(source), dest, nearestZip3(dest)), nil
+}
+
+func (p offlinePlanner) Zip5TransitDistance(source string, destination string) (int, error) {
+	sLL, sZip3, err := p.zipLocation(source)
+	if err != nil {
+		return 0, err
+	}
+	dLL, dZip3, err := p.zipLocation(destination)
+	if err != nil {
+		return 0, err
+	}
+	return p.roadMiles(sLL, sZip3, dLL, dZip3), nil
+}
+
+func (p offlinePlanner) providerName() string {
+	return offlineProviderName
+}
+
+func (p offlinePlanner) TransitDistance(source *models.Address, destination *models.Address) (int, error) {
+	return p.Zip5TransitDistance(source.PostalCode, destination.PostalCode)
+}
+
+func (p offlinePlanner) WaypointsTransitDistance(waypoints []*models.Address) (int, error) {
+	return waypointsTransitDistanceHelper(p, waypoints)
+}
+
+// NewOfflinePlanner constructs a route.Planner which estimates distances from bundled zip code
+// data. It suits development, tests and outages of the mapping services.
+func NewOfflinePlanner(logger *zap.Logger) Planner {
+	return offlinePlanner{logger: logger}
+}
@@ -0,0 +1,81 @@
+package route
+
+import (
+	"github.com/transcom/mymove/pkg/models"
+)
+
+func (suite *PlannerSuite) TestOfflinePlanner() {
+	planner := NewOfflinePlanner(suite.logger)
+
+	distance, err := planner.TransitDistance(&realAddressSource, &realAddressDestination)
+	suite.NoError(err)
+	if distance < 2700 || distance > 3000 {
+		suite.Failf("Implausible distance from CA to DC", "got %d", distance)
+	}
+
+	distance, err = planner.Zip5TransitDistance(bradyTXZip, venturaCAZip)
+	suite.NoError(err)
+	if distance < 1300 || distance > 1500 {
+		suite.Failf("Implausible distance from TX to CA", "got %d", distance)
+	}
+
+	// The same zip code is no distance at all
+	distance, err = planner.Zip5TransitDistance(venturaCAZip, venturaCAZip)
+	suite.NoError(err)
+	suite.Equal(0, distance)
+
+	// Zip codes that aren't in the bundled data are planned from their zip3
+	distance, err = planner.Zip5TransitDistance("20302", "20301")
+	suite.NoError(err)
+	if distance > 20 {
+		suite.Failf("Implausible distance within a zip3", "got %d", distance)
+	}
+
+	_, err = planner.Zip5TransitDistance("00001", venturaCAZip)
+	suite.Error(err)
+	_, err = planner.Zip5TransitDistance("charleston", venturaCAZip)
+	suite.Error(err)
+}
+
+func (suite *PlannerSuite) TestOfflineLatLongTransitDistance() {
+	planner := NewOfflinePlanner(suite.logger)
+	source, err := Zip5ToLatLong(bradyTXZip)
+	suite.NoError(err)
+	dest, err := Zip5ToLatLong(venturaCAZip)
+	suite.NoError(err)
+
+	// Locations are planned with the circuity of the zip3s nearest them
+	fromLatLong, err := planner.LatLongTransitDistance(source, dest)
+	suite.NoError(err)
+	fromZip5, err := planner.Zip5TransitDistance(bradyTXZip, venturaCAZip)
+	suite.NoError(err)
+	suite.Equal(fromZip5, fromLatLong)
+}
+
+func (suite *PlannerSuite) TestZip3ToLatLong() {
+	ll, err := Zip3ToLatLong("941")
+	suite.NoError(err)
+	if ll.Latitude < 37 || ll.Latitude > 38.5 || ll.Longitude < -123 || ll.Longitude > -122 {
+		suite.Failf("Implausible location for zip3 941", "got %v", ll)
+	}
+
+	_, err = Zip3ToLatLong("000")
+	suite.Error(err)
+}
+
+func (suite *PlannerSuite) TestOfflineWaypointsTransitDistance() {
+	planner := NewOfflinePlanner(suite.logger)
+	brady := models.Address{StreetAddress1: "1 Main St", City: "Brady", State: "TX", PostalCode: bradyTXZip}
+
+	direct, err := planner.TransitDistance(&realAddressSource, &realAddressDestination)
+	suite.NoError(err)
+	throughTexas, err := planner.WaypointsTransitDistance([]*models.Address{&realAddressSource, &brady, &realAddressDestination})
+	suite.NoError(err)
+	firstLeg, err := planner.TransitDistance(&realAddressSource, &brady)
+	suite.NoError(err)
+	secondLeg, err := planner.TransitDistance(&brady, &realAddressDestination)
+	suite.NoError(err)
+
+	suite.Equal(firstLeg+secondLeg, throughTexas)
+	suite.True(throughTexas > direct)
+}
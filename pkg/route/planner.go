@@ -6,6 +6,8 @@ import (
 
 	"fmt"
 
+	"github.com/pkg/errors"
+
 	"github.com/transcom/mymove/pkg/models"
 )
 
@@ -36,6 +38,23 @@ func urlencodeAddress(address *models.Address) string {
 	return url.QueryEscape(strings.Join(s, ","))
 }
 
+// waypointsTransitDistanceHelper adds up the distance of each leg of a route, for planners that
+// can only plan between two places
+func waypointsTransitDistanceHelper(planner Planner, waypoints []*models.Address) (int, error) {
+	if len(waypoints) < 2 {
+		return 0, errors.New("a route needs at least two waypoints")
+	}
+	total := 0
+	for index := 1; index < len(waypoints); index++ {
+		distance, err := planner.TransitDistance(waypoints[index-1], waypoints[index])
+		if err != nil {
+			return 0, err
+		}
+		total += distance
+	}
+	return total, nil
+}
+
 func zip5TransitDistanceHelper(planner Planner, source string, destination string) (int, error) {
 	sLL, err := Zip5ToLatLong(source)
 	if err != nil {
@@ -53,4 +72,6 @@ type Planner interface {
 	TransitDistance(source *models.Address, destination *models.Address) (int, error)
 	LatLongTransitDistance(source LatLong, destination LatLong) (int, error)
 	Zip5TransitDistance(source string, destination string) (int, error)
+	// WaypointsTransitDistance is the distance of a route that visits each waypoint in turn
+	WaypointsTransitDistance(waypoints []*models.Address) (int, error)
 }
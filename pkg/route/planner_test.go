@@ -78,6 +78,22 @@ func (suite *PlannerFullSuite) TestZip5Distance() {
 	}
 }
 
+func (suite *PlannerFullSuite) TestWaypointsDistance() {
+	direct, err := suite.planner.TransitDistance(&realAddressSource, &realAddressDestination)
+	if err != nil {
+		suite.T().Errorf("Failed to get distance from Planner - %v", err)
+	}
+	// Stopping in Texas on the way is a detour
+	brady := models.Address{StreetAddress1: "101 E Main St", City: "Brady", State: "TX", PostalCode: bradyTXZip}
+	detour, err := suite.planner.WaypointsTransitDistance([]*models.Address{&realAddressSource, &brady, &realAddressDestination})
+	if err != nil {
+		suite.T().Errorf("Failed to get distance from Planner - %v", err)
+	}
+	if detour <= direct {
+		suite.Fail("Implausible distance with a stop in TX")
+	}
+}
+
 func TestHandlerSuite(t *testing.T) {
 	logger, err := zap.NewDevelopment()
 	if err != nil {
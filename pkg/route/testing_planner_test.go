@@ -31,3 +31,16 @@ func (suite *PlannerSuite) TestNewTestPlanner() {
 		t.Errorf("Expected distance from test_planner should be 1234, got %d", distance)
 	}
 }
+
+func (suite *PlannerSuite) TestTestingPlannerWaypoints() {
+	planner := NewTestingPlanner(100)
+	waypoints := []*models.Address{&realAddressSource, &testAddressOne, &realAddressDestination}
+
+	// Each leg is the planner's distance
+	distance, err := planner.WaypointsTransitDistance(waypoints)
+	suite.NoError(err)
+	suite.Equal(200, distance)
+
+	_, err = planner.WaypointsTransitDistance(waypoints[:1])
+	suite.Error(err)
+}
@@ -0,0 +1,138 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// clamdChunkSize is how much of the file is sent to clamd at a time. It must stay
+// below clamd's StreamMaxLength.
+const clamdChunkSize = 64 * 1024
+
+// ClamAV scans files by streaming them to a clamd daemon using its INSTREAM command.
+// See https://linux.die.net/man/8/clamd for the protocol.
+type ClamAV struct {
+	network string
+	address string
+	timeout time.Duration
+	logger  *zap.Logger
+}
+
+// ClamAVParams contains parameters for instantiating a ClamAV scanner
+type ClamAVParams struct {
+	// Network is "tcp" or "unix"
+	Network string
+	// Address is host:port for tcp or the socket path for unix
+	Address string
+	Timeout time.Duration
+	Logger  *zap.Logger
+}
+
+// NewClamAV creates a new ClamAV scanner talking to the clamd at the given address
+func NewClamAV(params ClamAVParams) *ClamAV {
+	timeout := params.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &ClamAV{
+		network: params.Network,
+		address: params.Address,
+		timeout: timeout,
+		logger:  params.Logger,
+	}
+}
+
+// Scan streams the file to clamd and reports whether it found anything
+func (c *ClamAV) Scan(data io.ReadSeeker) (Result, error) {
+	if _, err := data.Seek(0, io.SeekStart); err != nil {
+		return Result{}, errors.Wrap(err, "could not seek to beginning of file")
+	}
+
+	conn, err := net.DialTimeout(c.network, c.address, c.timeout)
+	if err != nil {
+		return Result{}, errors.Wrap(err, "could not connect to clamd")
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return Result{}, errors.Wrap(err, "could not set clamd deadline")
+	}
+
+	if err := streamToClamd(conn, data); err != nil {
+		return Result{}, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return Result{}, errors.Wrap(err, "could not read clamd reply")
+	}
+
+	if _, err := data.Seek(0, io.SeekStart); err != nil { // seek back to beginning of file
+		return Result{}, errors.Wrap(err, "could not seek to beginning of file")
+	}
+
+	result, err := parseClamdReply(reply)
+	if err == nil && !result.Clean {
+		c.logger.Warn("clamd found a threat", zap.String("signature", result.Signature))
+	}
+	return result, err
+}
+
+// streamToClamd sends the file as a series of length-prefixed chunks, ending with a
+// zero length chunk
+func streamToClamd(w io.Writer, data io.Reader) error {
+	if _, err := w.Write([]byte("zINSTREAM\x00")); err != nil {
+		return errors.Wrap(err, "could not start clamd stream")
+	}
+
+	buffer := make([]byte, clamdChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := data.Read(buffer)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := w.Write(size); err != nil {
+				return errors.Wrap(err, "could not write to clamd")
+			}
+			if _, err := w.Write(buffer[:n]); err != nil {
+				return errors.Wrap(err, "could not write to clamd")
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return errors.Wrap(readErr, "could not read file")
+		}
+	}
+
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := w.Write(size); err != nil {
+		return errors.Wrap(err, "could not end clamd stream")
+	}
+	return nil
+}
+
+// parseClamdReply turns a reply like "stream: OK" or "stream: Eicar-Signature FOUND"
+// into a Result
+func parseClamdReply(reply string) (Result, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	reply = strings.TrimPrefix(reply, "stream: ")
+
+	switch {
+	case reply == "OK":
+		return Result{Clean: true}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return Result{Clean: false, Signature: strings.TrimSuffix(reply, " FOUND")}, nil
+	default:
+		return Result{}, errors.Errorf("clamd could not scan file: %s", reply)
+	}
+}
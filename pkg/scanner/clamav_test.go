@@ -0,0 +1,90 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// fakeClamd accepts a single INSTREAM scan and flags any stream containing "EICAR"
+func fakeClamd(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		command := make([]byte, len("zINSTREAM\x00"))
+		if _, err := io.ReadFull(conn, command); err != nil {
+			return
+		}
+
+		var received bytes.Buffer
+		size := make([]byte, 4)
+		for {
+			if _, err := io.ReadFull(conn, size); err != nil {
+				return
+			}
+			n := binary.BigEndian.Uint32(size)
+			if n == 0 {
+				break
+			}
+			if _, err := io.CopyN(&received, conn, int64(n)); err != nil {
+				return
+			}
+		}
+
+		if strings.Contains(received.String(), "EICAR") {
+			conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+		} else {
+			conn.Write([]byte("stream: OK\x00"))
+		}
+	}()
+
+	return listener
+}
+
+func TestClamAVScan(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+
+	cases := map[string]Result{
+		"just a receipt":       {Clean: true},
+		"X5O!P%@AP EICAR test": {Clean: false, Signature: "Eicar-Test-Signature"},
+	}
+
+	for content, expected := range cases {
+		listener := fakeClamd(t)
+		clamav := NewClamAV(ClamAVParams{
+			Network: "tcp",
+			Address: listener.Addr().String(),
+			Logger:  logger,
+		})
+
+		result, err := clamav.Scan(strings.NewReader(content))
+		listener.Close()
+		if err != nil {
+			t.Fatalf("unexpected error scanning %q: %v", content, err)
+		}
+		if result != expected {
+			t.Errorf("scanning %q: expected %+v, got %+v", content, expected, result)
+		}
+	}
+}
+
+func TestParseClamdReplyError(t *testing.T) {
+	_, err := parseClamdReply("INSTREAM size limit exceeded. ERROR\x00")
+	if err == nil {
+		t.Error("expected an error for a clamd error reply")
+	}
+}
@@ -0,0 +1,35 @@
+// Package scanner inspects uploaded files for malware before they are stored.
+package scanner
+
+import (
+	"io"
+)
+
+// Result is the outcome of scanning a file
+type Result struct {
+	// Clean is true when nothing malicious was found
+	Clean bool
+	// Signature names the threat that was found, if any
+	Signature string
+}
+
+// Scanner is the set of methods needed to check a file for malware. Scan expects
+// that the passed io object will be seeked to its beginning and will seek back to
+// the beginning after reading its content.
+type Scanner interface {
+	Scan(io.ReadSeeker) (Result, error)
+}
+
+// NoopScanner reports every file as clean. It is intended only for use in
+// development to avoid dependency on an external service.
+type NoopScanner struct{}
+
+// NewNoopScanner creates a new NoopScanner
+func NewNoopScanner() *NoopScanner {
+	return &NoopScanner{}
+}
+
+// Scan reports the file as clean without looking at it
+func (s *NoopScanner) Scan(data io.ReadSeeker) (Result, error) {
+	return Result{Clean: true}, nil
+}
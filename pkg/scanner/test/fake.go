@@ -0,0 +1,37 @@
+package test
+
+import (
+	"io"
+
+	"github.com/transcom/mymove/pkg/scanner"
+)
+
+// FakeScanner is used for testing to stub out calls to a virus scanner.
+type FakeScanner struct {
+	signature string
+	scanned   int
+}
+
+// Scan reports every file as clean, or as infected if the fake was given a signature
+func (fake *FakeScanner) Scan(data io.ReadSeeker) (scanner.Result, error) {
+	fake.scanned++
+	if fake.signature != "" {
+		return scanner.Result{Clean: false, Signature: fake.signature}, nil
+	}
+	return scanner.Result{Clean: true}, nil
+}
+
+// Scanned returns how many files have been scanned
+func (fake *FakeScanner) Scanned() int {
+	return fake.scanned
+}
+
+// NewFakeScanner creates a new FakeScanner that finds nothing
+func NewFakeScanner() *FakeScanner {
+	return &FakeScanner{}
+}
+
+// NewInfectedFakeScanner creates a new FakeScanner that reports every file as infected
+func NewInfectedFakeScanner(signature string) *FakeScanner {
+	return &FakeScanner{signature: signature}
+}
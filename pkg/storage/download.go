@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// URLSigner creates and checks expiring URLs for objects served by a DownloadHandler,
+// in the same way that S3 presigns URLs for objects it serves itself.
+type URLSigner struct {
+	secret  []byte
+	webRoot string
+	expiry  time.Duration
+	now     func() time.Time
+}
+
+// NewURLSigner creates and returns a new URLSigner for URLs under webRoot. URLs are
+// valid for 15 minutes, as they are for S3.
+func NewURLSigner(secret []byte, webRoot string) *URLSigner {
+	return &URLSigner{
+		secret:  secret,
+		webRoot: strings.TrimSuffix(webRoot, "/"),
+		expiry:  15 * time.Minute,
+		now:     time.Now,
+	}
+}
+
+// Sign returns a URL that provides access to an object until it expires
+func (s *URLSigner) Sign(key string, contentType string) (string, error) {
+	expires := strconv.FormatInt(s.now().Add(s.expiry).Unix(), 10)
+
+	values := url.Values{}
+	values.Add("contentType", contentType)
+	values.Add("expires", expires)
+	values.Add("signature", s.signature(key, contentType, expires))
+	return s.webRoot + "/" + key + "?" + values.Encode(), nil
+}
+
+// Verify returns the key of the object that a request is for, and false if the request's
+// URL wasn't signed by this URLSigner or has expired.
+func (s *URLSigner) Verify(r *http.Request) (string, bool) {
+	requestPath := path.Clean("/" + r.URL.Path)
+	if !strings.HasPrefix(requestPath, s.webRoot+"/") {
+		return "", false
+	}
+	key := strings.TrimPrefix(requestPath, s.webRoot+"/")
+
+	query := r.URL.Query()
+	contentType := query.Get("contentType")
+	expires := query.Get("expires")
+	expected := s.signature(key, contentType, expires)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("signature"))) {
+		return "", false
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || s.now().Unix() > expiresAt {
+		return "", false
+	}
+	return key, true
+}
+
+func (s *URLSigner) signature(key string, contentType string, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + contentType + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// NewDownloadHandler returns a Handler that serves objects from the storer to anyone
+// with a URL signed by the signer. It lets members download objects that can't be read
+// straight from storage, such as those stored by an EncryptedStorer.
+func NewDownloadHandler(storer FileStorer, signer *URLSigner, logger *zap.Logger) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := signer.Verify(r)
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		data, err := storer.Fetch(key)
+		if err != nil {
+			logger.Info("could not fetch object for download", zap.String("key", key), zap.Error(err))
+			http.NotFound(w, r)
+			return
+		}
+		defer data.Close()
+
+		if contentType := r.URL.Query().Get("contentType"); contentType != "" {
+			w.Header().Add("Content-Type", contentType)
+		}
+		w.Header().Add("Cache-Control", "private, no-store")
+		if _, err := io.Copy(w, data); err != nil {
+			logger.Error("could not send object", zap.String("key", key), zap.Error(err))
+		}
+	})
+}
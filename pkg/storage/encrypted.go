@@ -0,0 +1,288 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+)
+
+// Encrypted objects start with this, followed by the ID of the master key, the wrapped
+// data key, the nonce and the AES-GCM sealed content.
+var encryptedObjectMagic = []byte("MMENC1")
+
+const dataKeySize = 32
+
+// ErrNotEncrypted means that a stored object is plaintext, and was probably stored
+// before encryption was enabled
+var ErrNotEncrypted = errors.New("NOT_ENCRYPTED")
+
+// EncryptedStorer wraps a FileStorer and encrypts objects before they are stored. Each
+// object is sealed with AES-GCM under its own data key, and the data key is stored
+// alongside it, wrapped by a master key from the KeyProvider.
+//
+// Objects can't be read straight from the underlying storage, so PresignedURL returns
+// signed URLs for a DownloadHandler which decrypts them instead.
+type EncryptedStorer struct {
+	storer         FileStorer
+	keys           KeyProvider
+	signer         *URLSigner
+	logger         *zap.Logger
+	allowPlaintext bool
+}
+
+// NewEncryptedStorer creates and returns a new EncryptedStorer
+func NewEncryptedStorer(storer FileStorer, keys KeyProvider, signer *URLSigner, logger *zap.Logger) *EncryptedStorer {
+	return &EncryptedStorer{
+		storer: storer,
+		keys:   keys,
+		signer: signer,
+		logger: logger,
+	}
+}
+
+// Store encrypts the content and stores it at the specified key. The checksum is of the
+// plaintext, and is checked before it is encrypted.
+func (e *EncryptedStorer) Store(key string, data io.ReadSeeker, checksum string) (*StoreResult, error) {
+	plaintext, err := ioutil.ReadAll(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read file")
+	}
+	plaintextChecksum, err := ComputeChecksum(bytes.NewReader(plaintext))
+	if err != nil {
+		return nil, err
+	}
+	if checksum != "" && checksum != plaintextChecksum {
+		return nil, ErrChecksumMismatch
+	}
+
+	encrypted, err := e.encrypt(key, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return e.storeRaw(key, encrypted)
+}
+
+// SetAllowPlaintext sets whether Fetch returns objects stored before encryption was enabled.
+// It should only be allowed until RewrapObject has encrypted all of them.
+func (e *EncryptedStorer) SetAllowPlaintext(allow bool) {
+	e.allowPlaintext = allow
+}
+
+// Fetch retrieves and decrypts the object at the specified key. Plaintext objects fail
+// with ErrNotEncrypted, unless they are allowed by SetAllowPlaintext.
+func (e *EncryptedStorer) Fetch(key string) (io.ReadCloser, error) {
+	raw, err := e.fetchRaw(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := e.decrypt(key, raw)
+	if err == ErrNotEncrypted && e.allowPlaintext {
+		e.logger.Warn("fetched an object that is not encrypted", zap.String("key", key))
+		return ioutil.NopCloser(bytes.NewReader(raw)), nil
+	} else if err == ErrNotEncrypted {
+		e.logger.Error("refusing to fetch an object that is not encrypted", zap.String("key", key))
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(plaintext)), nil
+}
+
+// Delete removes the object at the specified key
+func (e *EncryptedStorer) Delete(key string) error {
+	return e.storer.Delete(key)
+}
+
+// PresignedURL returns a signed URL for the DownloadHandler, which decrypts the object
+func (e *EncryptedStorer) PresignedURL(key string, contentType string) (string, error) {
+	return e.signer.Sign(key, contentType)
+}
+
+// FileSystem returns the underlying afero filesystem
+func (e *EncryptedStorer) FileSystem() *afero.Afero {
+	return e.storer.FileSystem()
+}
+
+// RewrapObject makes sure that the object at key is encrypted under the current master
+// key. Only the wrapped data key changes, so the content doesn't need to be decrypted
+// unless the object is still plaintext. Returns true if the object was rewritten.
+func (e *EncryptedStorer) RewrapObject(key string) (bool, error) {
+	raw, err := e.fetchRaw(key)
+	if err != nil {
+		return false, err
+	}
+
+	header, sealed, err := parseEncryptedObject(raw)
+	if err == ErrNotEncrypted {
+		encrypted, err := e.encrypt(key, raw)
+		if err != nil {
+			return false, err
+		}
+		_, err = e.storeRaw(key, encrypted)
+		return err == nil, err
+	} else if err != nil {
+		return false, err
+	}
+
+	if header.keyID == e.keys.CurrentKeyID() {
+		return false, nil
+	}
+
+	dataKey, err := e.keys.UnwrapDataKey(header.keyID, header.wrappedKey)
+	if err != nil {
+		return false, errors.Wrapf(err, "could not unwrap data key for %s", key)
+	}
+	header.keyID, header.wrappedKey, err = e.keys.WrapDataKey(dataKey)
+	if err != nil {
+		return false, errors.Wrapf(err, "could not wrap data key for %s", key)
+	}
+
+	_, err = e.storeRaw(key, header.marshal(sealed))
+	return err == nil, err
+}
+
+func (e *EncryptedStorer) fetchRaw(key string) ([]byte, error) {
+	data, err := e.storer.Fetch(key)
+	if err != nil {
+		return nil, err
+	}
+	defer data.Close()
+
+	raw, err := ioutil.ReadAll(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read object")
+	}
+	return raw, nil
+}
+
+func (e *EncryptedStorer) storeRaw(key string, raw []byte) (*StoreResult, error) {
+	content := bytes.NewReader(raw)
+	checksum, err := ComputeChecksum(content)
+	if err != nil {
+		return nil, err
+	}
+	return e.storer.Store(key, content, checksum)
+}
+
+func (e *EncryptedStorer) encrypt(key string, plaintext []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, errors.Wrap(err, "could not generate data key")
+	}
+
+	var header encryptedObjectHeader
+	var err error
+	header.keyID, header.wrappedKey, err = e.keys.WrapDataKey(dataKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not wrap data key")
+	}
+
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "could not generate nonce")
+	}
+
+	// Sealing the storage key in means that an object can't be swapped for another one
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(key))
+	return header.marshal(sealed), nil
+}
+
+func (e *EncryptedStorer) decrypt(key string, raw []byte) ([]byte, error) {
+	header, sealed, err := parseEncryptedObject(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := e.keys.UnwrapDataKey(header.keyID, header.wrappedKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not unwrap data key for %s", key)
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.Errorf("encrypted object %s is truncated", key)
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(key))
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not decrypt %s", key)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create cipher")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create GCM")
+	}
+	return aead, nil
+}
+
+type encryptedObjectHeader struct {
+	keyID      string
+	wrappedKey []byte
+}
+
+func (h encryptedObjectHeader) marshal(sealed []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write(encryptedObjectMagic)
+	writeField(&buf, []byte(h.keyID))
+	writeField(&buf, h.wrappedKey)
+	buf.Write(sealed)
+	return buf.Bytes()
+}
+
+func writeField(buf *bytes.Buffer, field []byte) {
+	length := make([]byte, 2)
+	binary.BigEndian.PutUint16(length, uint16(len(field)))
+	buf.Write(length)
+	buf.Write(field)
+}
+
+// parseEncryptedObject splits an encrypted object into its header and sealed content
+func parseEncryptedObject(raw []byte) (encryptedObjectHeader, []byte, error) {
+	if !bytes.HasPrefix(raw, encryptedObjectMagic) {
+		return encryptedObjectHeader{}, nil, ErrNotEncrypted
+	}
+	rest := raw[len(encryptedObjectMagic):]
+
+	keyID, rest, err := readField(rest)
+	if err != nil {
+		return encryptedObjectHeader{}, nil, err
+	}
+	wrappedKey, rest, err := readField(rest)
+	if err != nil {
+		return encryptedObjectHeader{}, nil, err
+	}
+	return encryptedObjectHeader{keyID: string(keyID), wrappedKey: wrappedKey}, rest, nil
+}
+
+func readField(data []byte) ([]byte, []byte, error) {
+	if len(data) < 2 {
+		return nil, nil, errors.New("encrypted object header is truncated")
+	}
+	length := int(binary.BigEndian.Uint16(data))
+	if len(data) < 2+length {
+		return nil, nil, errors.New("encrypted object header is truncated")
+	}
+	return data[2 : 2+length], data[2+length:], nil
+}
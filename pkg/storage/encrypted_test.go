@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, dataKeySize)
+}
+
+func newTestEncryptedStorer(t *testing.T, root string, keys KeyProvider) *EncryptedStorer {
+	base := NewFilesystem(FilesystemParams{root: root, logger: zap.NewNop()})
+	return NewEncryptedStorer(base, keys, NewURLSigner([]byte("secret"), "/storage"), zap.NewNop())
+}
+
+func TestEncryptedStorerRoundTrip(t *testing.T) {
+	root, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	keys, err := NewLocalKeyProvider("one", map[string][]byte{"one": testKey(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	storer := newTestEncryptedStorer(t, root, keys)
+
+	content := []byte("orders with an SSN on them")
+	checksum, err := ComputeChecksum(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storer.Store("user/1/uploads/2", bytes.NewReader(content), checksum); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := ioutil.ReadFile(filepath.Join(root, "user/1/uploads/2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, content) {
+		t.Error("expected the stored object to be encrypted")
+	}
+
+	data, err := storer.Fetch("user/1/uploads/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetched, err := ioutil.ReadAll(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fetched, content) {
+		t.Errorf("expected %q, got %q", content, fetched)
+	}
+
+	// An object moved to another key can't be decrypted
+	if err := ioutil.WriteFile(filepath.Join(root, "user/1/uploads/3"), raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storer.Fetch("user/1/uploads/3"); err == nil {
+		t.Error("expected an object under the wrong key to fail to decrypt")
+	}
+}
+
+func TestEncryptedStorerPlaintext(t *testing.T) {
+	root, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	keys, err := NewLocalKeyProvider("one", map[string][]byte{"one": testKey(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	storer := newTestEncryptedStorer(t, root, keys)
+
+	content := []byte("stored before encryption was enabled")
+	if err := ioutil.WriteFile(filepath.Join(root, "plaintext"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storer.Fetch("plaintext"); err != ErrNotEncrypted {
+		t.Errorf("expected a plaintext object to be refused, got %v", err)
+	}
+
+	// Until objects have been rewrapped, plaintext can be allowed
+	storer.SetAllowPlaintext(true)
+	data, err := storer.Fetch("plaintext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetched, err := ioutil.ReadAll(data)
+	if err != nil || !bytes.Equal(fetched, content) {
+		t.Errorf("expected %q, got %q, %v", content, fetched, err)
+	}
+}
+
+func TestEncryptedStorerRewrap(t *testing.T) {
+	root, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	oldKeys, err := NewLocalKeyProvider("one", map[string][]byte{"one": testKey(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("receipt")
+	if _, err := newTestEncryptedStorer(t, root, oldKeys).Store("encrypted", bytes.NewReader(content), ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "plaintext"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	newKeys, err := NewLocalKeyProvider("two", map[string][]byte{"one": testKey(1), "two": testKey(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	storer := newTestEncryptedStorer(t, root, newKeys)
+	for _, key := range []string{"encrypted", "plaintext"} {
+		rewrapped, err := storer.RewrapObject(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rewrapped {
+			t.Errorf("expected %s to be rewrapped", key)
+		}
+		if rewrapped, _ := storer.RewrapObject(key); rewrapped {
+			t.Errorf("expected %s to be current after rewrapping", key)
+		}
+	}
+
+	// The old key is no longer needed
+	onlyNewKeys, err := NewLocalKeyProvider("two", map[string][]byte{"two": testKey(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	storer = newTestEncryptedStorer(t, root, onlyNewKeys)
+	for _, key := range []string{"encrypted", "plaintext"} {
+		data, err := storer.Fetch(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fetched, err := ioutil.ReadAll(data)
+		if err != nil || !bytes.Equal(fetched, content) {
+			t.Errorf("expected %s to decrypt with the new key, got %q, %v", key, fetched, err)
+		}
+	}
+}
+
+func TestDownloadHandler(t *testing.T) {
+	root, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	keys, err := NewLocalKeyProvider("one", map[string][]byte{"one": testKey(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	storer := newTestEncryptedStorer(t, root, keys)
+	if _, err := storer.Store("user/1/uploads/2", bytes.NewReader([]byte("receipt")), ""); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewDownloadHandler(storer, storer.signer, zap.NewNop())
+
+	url, err := storer.PresignedURL("user/1/uploads/2", "application/pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != "receipt" {
+		t.Errorf("expected the decrypted object, got %d %q", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("Content-Type") != "application/pdf" {
+		t.Errorf("wrong content type: %s", rr.Header().Get("Content-Type"))
+	}
+
+	// Tampering with the URL invalidates it
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", url+"x", nil))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected a tampered URL to be forbidden, got %d", rr.Code)
+	}
+
+	// And it expires
+	storer.signer.now = func() time.Time { return time.Now().Add(time.Hour) }
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected an expired URL to be forbidden, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/pkg/errors"
+)
+
+// KeyProvider wraps the data keys that encrypt stored objects under a master key
+type KeyProvider interface {
+	// CurrentKeyID identifies the master key that new data keys are wrapped with
+	CurrentKeyID() string
+	// WrapDataKey encrypts a data key under the current master key
+	WrapDataKey(dataKey []byte) (keyID string, wrappedKey []byte, err error)
+	// UnwrapDataKey decrypts a data key that was wrapped under the identified master key
+	UnwrapDataKey(keyID string, wrappedKey []byte) ([]byte, error)
+}
+
+// LocalKeyProvider keeps master keys in a local file. It is meant for development and
+// for environments without KMS.
+type LocalKeyProvider struct {
+	currentKeyID string
+	keys         map[string][]byte
+}
+
+// localKeyFile is the format of the file read by NewLocalKeyProviderFromFile. Keys are
+// base64 encoded 32 byte AES keys. To rotate, add a new key, make it current and run
+// rotate-storage-keys before removing the old one.
+type localKeyFile struct {
+	Current string            `json:"current"`
+	Keys    map[string]string `json:"keys"`
+}
+
+// NewLocalKeyProvider creates and returns a new LocalKeyProvider with the given master
+// keys, which must include currentKeyID
+func NewLocalKeyProvider(currentKeyID string, keys map[string][]byte) (*LocalKeyProvider, error) {
+	if _, ok := keys[currentKeyID]; !ok {
+		return nil, errors.Errorf("current key %s is missing", currentKeyID)
+	}
+	for id, key := range keys {
+		if len(key) != dataKeySize {
+			return nil, errors.Errorf("key %s must be %d bytes", id, dataKeySize)
+		}
+	}
+	return &LocalKeyProvider{currentKeyID: currentKeyID, keys: keys}, nil
+}
+
+// NewLocalKeyProviderFromFile reads master keys from a JSON file and returns a new
+// LocalKeyProvider
+func NewLocalKeyProviderFromFile(path string) (*LocalKeyProvider, error) {
+	// #nosec the path is set by the operator
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read key file")
+	}
+
+	var file localKeyFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, errors.Wrap(err, "could not parse key file")
+	}
+
+	keys := map[string][]byte{}
+	for id, encoded := range file.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not decode key %s", id)
+		}
+		keys[id] = key
+	}
+	return NewLocalKeyProvider(file.Current, keys)
+}
+
+// CurrentKeyID identifies the master key that new data keys are wrapped with
+func (p *LocalKeyProvider) CurrentKeyID() string {
+	return p.currentKeyID
+}
+
+// WrapDataKey encrypts a data key under the current master key with AES-GCM
+func (p *LocalKeyProvider) WrapDataKey(dataKey []byte) (string, []byte, error) {
+	aead, err := newGCM(p.keys[p.currentKeyID])
+	if err != nil {
+		return "", nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, errors.Wrap(err, "could not generate nonce")
+	}
+	return p.currentKeyID, aead.Seal(nonce, nonce, dataKey, []byte(p.currentKeyID)), nil
+}
+
+// UnwrapDataKey decrypts a data key that was wrapped under the identified master key
+func (p *LocalKeyProvider) UnwrapDataKey(keyID string, wrappedKey []byte) ([]byte, error) {
+	masterKey, ok := p.keys[keyID]
+	if !ok {
+		return nil, errors.Errorf("unknown key %s", keyID)
+	}
+	aead, err := newGCM(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrappedKey) < aead.NonceSize() {
+		return nil, errors.New("wrapped key is truncated")
+	}
+	return aead.Open(nil, wrappedKey[:aead.NonceSize()], wrappedKey[aead.NonceSize():], []byte(keyID))
+}
+
+// KMSKeyProvider wraps data keys with an AWS KMS customer master key. To rotate, point it
+// at a new key and run rotate-storage-keys while the old one is still enabled.
+type KMSKeyProvider struct {
+	client kmsiface.KMSAPI
+	keyID  string
+}
+
+// NewKMSKeyProvider creates and returns a new KMSKeyProvider that wraps data keys with
+// the given KMS key ID, ARN or alias
+func NewKMSKeyProvider(client kmsiface.KMSAPI, keyID string) *KMSKeyProvider {
+	return &KMSKeyProvider{client: client, keyID: keyID}
+}
+
+// CurrentKeyID identifies the master key that new data keys are wrapped with
+func (p *KMSKeyProvider) CurrentKeyID() string {
+	return p.keyID
+}
+
+// WrapDataKey encrypts a data key under the current KMS key
+func (p *KMSKeyProvider) WrapDataKey(dataKey []byte) (string, []byte, error) {
+	output, err := p.client.Encrypt(&kms.EncryptInput{
+		KeyId:     aws.String(p.keyID),
+		Plaintext: dataKey,
+	})
+	if err != nil {
+		return "", nil, errors.Wrap(err, "KMS encrypt failed")
+	}
+	return p.keyID, output.CiphertextBlob, nil
+}
+
+// UnwrapDataKey decrypts a data key with KMS, which knows which key wrapped it
+func (p *KMSKeyProvider) UnwrapDataKey(keyID string, wrappedKey []byte) ([]byte, error) {
+	output, err := p.client.Decrypt(&kms.DecryptInput{
+		CiphertextBlob: wrappedKey,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "KMS decrypt failed")
+	}
+	return output.Plaintext, nil
+}
@@ -1,15 +1,19 @@
 package storage
 
 import (
+	"bytes"
 	/*
 		#nosec - we use md5 because it's required by the S3 API for
 		validating data integrity.
 		https://aws.amazon.com/premiumsupport/knowledge-center/data-integrity-s3/
 	*/
 	"crypto/md5"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"io"
 	"net/http"
+	"path"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/afero"
@@ -47,6 +51,44 @@ func ComputeChecksum(data io.ReadSeeker) (string, error) {
 	return base64.StdEncoding.EncodeToString(hash.Sum(nil)), nil
 }
 
+// ComputeSHA256Checksum calculates the hex encoded SHA-256 checksum for the provided
+// data. MD5 is only good enough for catching transfer errors, so this is what content
+// addressed keys are derived from. It expects that the passed io object will be seeked
+// to its beginning and will seek back to the beginning after reading its content.
+func ComputeSHA256Checksum(data io.ReadSeeker) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, data); err != nil {
+		return "", errors.Wrap(err, "could not read file")
+	}
+
+	if _, err := data.Seek(0, io.SeekStart); err != nil { // seek back to beginning of file
+		return "", errors.Wrap(err, "could not seek to beginning of file")
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// contentKeyPrefix is where content addressed objects are stored
+const contentKeyPrefix = "content/sha256"
+
+// ContentKey returns the storage key for content with the given hex encoded SHA-256
+// checksum. Identical files share a key, so they are only stored once.
+func ContentKey(sha256Checksum string) string {
+	return path.Join(contentKeyPrefix, sha256Checksum)
+}
+
+// SHA256ChecksumFromKey returns the checksum that a content addressed key was derived
+// from, and false for any other key.
+func SHA256ChecksumFromKey(key string) (string, bool) {
+	dir, checksum := path.Split(key)
+	if path.Clean(dir) != contentKeyPrefix || len(checksum) != sha256.Size*2 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(checksum); err != nil {
+		return "", false
+	}
+	return checksum, true
+}
+
 // DetectContentType leverages http.DetectContentType to identify the content type
 // of the provided data. It expects that the passed io object will be seeked to its
 // beginning and will seek back to the beginning after reading its content.
@@ -60,9 +102,38 @@ func DetectContentType(data io.ReadSeeker) (string, error) {
 	}
 
 	contentType := http.DetectContentType(buffer)
+	// http.DetectContentType doesn't know about the formats phone cameras save in
+	if contentType == "application/octet-stream" {
+		if IsHEIC(buffer) {
+			contentType = "image/heic"
+		} else if isTIFF(buffer) {
+			contentType = "image/tiff"
+		}
+	}
 
 	if _, err := data.Seek(0, io.SeekStart); err != nil { // seek back to beginning of file
 		return "", errors.Wrap(err, "could not seek to beginning of file")
 	}
 	return contentType, nil
 }
+
+// HEIC files are ISO media files whose ftyp box names one of these brands
+var heicBrands = map[string]bool{
+	"heic": true,
+	"heix": true,
+	"hevc": true,
+	"hevx": true,
+	"heim": true,
+	"heis": true,
+	"mif1": true,
+	"msf1": true,
+}
+
+// IsHEIC returns true if the data starts with the header of a HEIC image
+func IsHEIC(data []byte) bool {
+	return len(data) >= 12 && bytes.Equal(data[4:8], []byte("ftyp")) && heicBrands[string(data[8:12])]
+}
+
+func isTIFF(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*"))
+}
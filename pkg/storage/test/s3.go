@@ -18,12 +18,7 @@ type FakeS3Storage struct {
 
 // Delete removes a file.
 func (fake *FakeS3Storage) Delete(key string) error {
-	f, err := fake.fs.Open(key)
-	if err != nil {
-		return err
-	}
-
-	return f.Close()
+	return fake.fs.Remove(key)
 }
 
 // Store stores a file.
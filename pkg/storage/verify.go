@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"bytes"
+	/*
+		#nosec - we use md5 because it's the checksum we have for every upload,
+		not to protect against tampering.
+	*/
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// ErrChecksumMismatch means that a stored object's content doesn't match the checksum
+// it was stored with
+var ErrChecksumMismatch = errors.New("CHECKSUM_MISMATCH")
+
+// verifyingReader hashes content as it is read, and reports a mismatch with the
+// expected checksum instead of io.EOF
+type verifyingReader struct {
+	io.ReadCloser
+	hash     hash.Hash
+	expected []byte
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF && !bytes.Equal(r.hash.Sum(nil), r.expected) {
+		return n, ErrChecksumMismatch
+	}
+	return n, err
+}
+
+// VerifyMD5 wraps data so that reading it to the end fails with ErrChecksumMismatch
+// if it doesn't match the base64 encoded MD5 checksum, as stored on an Upload.
+func VerifyMD5(data io.ReadCloser, checksum string) (io.ReadCloser, error) {
+	expected, err := base64.StdEncoding.DecodeString(checksum)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode MD5 checksum")
+	}
+	/*
+		#nosec - we use md5 because it's the checksum we have for every upload,
+		not to protect against tampering.
+	*/
+	return &verifyingReader{ReadCloser: data, hash: md5.New(), expected: expected}, nil
+}
+
+// VerifySHA256 wraps data so that reading it to the end fails with ErrChecksumMismatch
+// if it doesn't match the hex encoded SHA-256 checksum.
+func VerifySHA256(data io.ReadCloser, checksum string) (io.ReadCloser, error) {
+	expected, err := hex.DecodeString(checksum)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode SHA-256 checksum")
+	}
+	return &verifyingReader{ReadCloser: data, hash: sha256.New(), expected: expected}, nil
+}
+
+// VerifyingStorer wraps a FileStorer so that content addressed objects are checked
+// against the checksum in their key as they are fetched. Other objects are passed
+// through as-is.
+type VerifyingStorer struct {
+	storer FileStorer
+}
+
+// NewVerifyingStorer creates and returns a new VerifyingStorer
+func NewVerifyingStorer(storer FileStorer) *VerifyingStorer {
+	return &VerifyingStorer{storer: storer}
+}
+
+// Store stores a file.
+func (v *VerifyingStorer) Store(key string, data io.ReadSeeker, md5 string) (*StoreResult, error) {
+	return v.storer.Store(key, data, md5)
+}
+
+// Fetch returns the object at the given key. Reading a content addressed object to
+// its end fails with ErrChecksumMismatch if it has been corrupted.
+func (v *VerifyingStorer) Fetch(key string) (io.ReadCloser, error) {
+	data, err := v.storer.Fetch(key)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum, ok := SHA256ChecksumFromKey(key)
+	if !ok {
+		return data, nil
+	}
+	return VerifySHA256(data, checksum)
+}
+
+// Delete removes a file.
+func (v *VerifyingStorer) Delete(key string) error {
+	return v.storer.Delete(key)
+}
+
+// PresignedURL returns a URL that can be used to retrieve a file.
+func (v *VerifyingStorer) PresignedURL(key string, contentType string) (string, error) {
+	return v.storer.PresignedURL(key, contentType)
+}
+
+// FileSystem returns the underlying afero filesystem
+func (v *VerifyingStorer) FileSystem() *afero.Afero {
+	return v.storer.FileSystem()
+}
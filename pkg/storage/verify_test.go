@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestContentKey(t *testing.T) {
+	checksum, err := ComputeSHA256Checksum(bytes.NewReader([]byte("receipt")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := ContentKey(checksum)
+	fromKey, ok := SHA256ChecksumFromKey(key)
+	if !ok || fromKey != checksum {
+		t.Errorf("expected %s to round trip through %s, got %s", checksum, key, fromKey)
+	}
+
+	if _, ok := SHA256ChecksumFromKey("user/1234/uploads/5678"); ok {
+		t.Error("expected a legacy key not to be content addressed")
+	}
+}
+
+func TestVerifyMD5(t *testing.T) {
+	content := []byte("receipt")
+	checksum, err := ComputeChecksum(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verified, err := VerifyMD5(ioutil.NopCloser(bytes.NewReader(content)), checksum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ioutil.ReadAll(verified); err != nil {
+		t.Errorf("expected matching content to verify, got %s", err)
+	}
+
+	corrupted, err := VerifyMD5(ioutil.NopCloser(bytes.NewReader([]byte("reciept"))), checksum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ioutil.ReadAll(corrupted); err != ErrChecksumMismatch {
+		t.Errorf("expected ErrChecksumMismatch, got %v", err)
+	}
+}
+
+func TestVerifyingStorerFetch(t *testing.T) {
+	root, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	storer := NewVerifyingStorer(NewFilesystem(FilesystemParams{root: root, logger: zap.NewNop()}))
+
+	content := []byte("receipt")
+	checksum, err := ComputeSHA256Checksum(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := ContentKey(checksum)
+	if _, err := storer.Store(key, bytes.NewReader(content), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := storer.Fetch(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ioutil.ReadAll(data); err != nil {
+		t.Errorf("expected stored content to verify, got %s", err)
+	}
+	data.Close()
+
+	// Corrupt the object behind the storer's back
+	if err := ioutil.WriteFile(filepath.Join(root, key), []byte("reciept"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, err = storer.Fetch(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer data.Close()
+	if _, err := ioutil.ReadAll(data); err != ErrChecksumMismatch {
+		t.Errorf("expected ErrChecksumMismatch, got %v", err)
+	}
+}
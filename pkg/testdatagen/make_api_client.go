@@ -0,0 +1,45 @@
+package testdatagen
+
+import (
+	"log"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+
+	"github.com/transcom/mymove/pkg/auth"
+	"github.com/transcom/mymove/pkg/models"
+)
+
+// DefaultAPIClientSecret is the secret of API clients made without one
+const DefaultAPIClientSecret = "api client secret"
+
+// MakeAPIClient creates an API client acting on behalf of a TSP user, with every scope
+func MakeAPIClient(db *pop.Connection, assertions Assertions) models.APIClient {
+	tspUser := assertions.APIClient.TspUser
+	if isZeroUUID(assertions.APIClient.TspUserID) {
+		tspUser = MakeTspUser(db, assertions)
+	}
+
+	client := models.APIClient{
+		Name:               "Truss TMS",
+		TspUserID:          tspUser.ID,
+		TspUser:            tspUser,
+		ClientID:           uuid.Must(uuid.NewV4()).String(),
+		SecretHash:         stringPointer(auth.SessionKey(DefaultAPIClientSecret)),
+		RateLimitPerMinute: 60,
+	}
+
+	mergeModels(&client, assertions.APIClient)
+
+	mustCreate(db, &client)
+	if err := models.SetAPIClientScopes(db, client.ID, auth.APIClientScopes...); err != nil {
+		log.Panic(err)
+	}
+
+	return client
+}
+
+// MakeDefaultAPIClient makes an APIClient with default values
+func MakeDefaultAPIClient(db *pop.Connection) models.APIClient {
+	return MakeAPIClient(db, Assertions{})
+}
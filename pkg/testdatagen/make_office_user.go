@@ -41,6 +41,7 @@ func MakeOfficeUser(db *pop.Connection, assertions Assertions) models.OfficeUser
 	mergeModels(&officeUser, assertions.OfficeUser)
 
 	mustCreate(db, &officeUser)
+	GrantRoles(db, *officeUser.UserID, models.DefaultOfficeRoles...)
 
 	return officeUser
 }
@@ -0,0 +1,78 @@
+package testdatagen
+
+import (
+	"log"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gofrs/uuid"
+
+	"github.com/transcom/mymove/pkg/auth"
+	"github.com/transcom/mymove/pkg/models"
+)
+
+// rolePermissions are the permissions each role is seeded with by its migration
+var rolePermissions = map[models.RoleName][]auth.Permission{
+	models.RoleNamePPMOfficeApprover: {
+		auth.PermissionViewMoveQueues,
+		auth.PermissionViewMoves,
+		auth.PermissionEditMoves,
+		auth.PermissionViewShipments,
+		auth.PermissionApprovePPMs,
+	},
+	models.RoleNameHHGOfficeApprover: {
+		auth.PermissionViewMoveQueues,
+		auth.PermissionViewMoves,
+		auth.PermissionEditMoves,
+		auth.PermissionViewShipments,
+		auth.PermissionEditShipments,
+		auth.PermissionApproveHHGs,
+	},
+	models.RoleNameTSPDispatcher: {
+		auth.PermissionViewShipments,
+		auth.PermissionEditShipments,
+		auth.PermissionDispatchShipments,
+	},
+	models.RoleNameTSPAdmin: {
+		auth.PermissionViewShipments,
+		auth.PermissionEditShipments,
+		auth.PermissionDispatchShipments,
+		auth.PermissionManageServiceAgents,
+	},
+	models.RoleNameAuditor: {
+		auth.PermissionViewMoveQueues,
+		auth.PermissionViewMoves,
+		auth.PermissionViewShipments,
+	},
+}
+
+// MakeRole returns the role with a name, creating it with the permissions it is seeded with
+// if it doesn't exist, as it won't once the test database has been truncated
+func MakeRole(db *pop.Connection, name models.RoleName) models.AccessRole {
+	role, err := models.FetchRoleByName(db, name)
+	if err == nil {
+		return role
+	}
+	if err != models.ErrFetchNotFound {
+		log.Panic(err)
+	}
+
+	role = models.AccessRole{
+		Name:        name,
+		Description: string(name),
+	}
+	mustCreate(db, &role)
+	if err := models.AddPermissionsToRole(db, role.ID, rolePermissions[name]...); err != nil {
+		log.Panic(err)
+	}
+	return role
+}
+
+// GrantRoles grants roles to a user, making the roles if they don't exist
+func GrantRoles(db *pop.Connection, userID uuid.UUID, names ...models.RoleName) {
+	for _, name := range names {
+		MakeRole(db, name)
+	}
+	if err := models.GrantRoles(db, userID, names...); err != nil {
+		log.Panic(err)
+	}
+}
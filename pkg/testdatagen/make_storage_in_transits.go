@@ -0,0 +1,79 @@
+package testdatagen
+
+import (
+	"time"
+
+	"github.com/gobuffalo/pop"
+
+	"github.com/transcom/mymove/pkg/models"
+)
+
+// MakeStorageInTransit creates a single stay in SIT, with its shipment and warehouse address
+func MakeStorageInTransit(db *pop.Connection, assertions Assertions) models.StorageInTransit {
+	shipmentID := assertions.StorageInTransit.ShipmentID
+	if isZeroUUID(shipmentID) {
+		shipment := MakeShipment(db, assertions)
+		shipmentID = shipment.ID
+	}
+
+	warehouseAddress := assertions.StorageInTransit.WarehouseAddress
+	if isZeroUUID(warehouseAddress.ID) {
+		warehouseAddress = MakeAddress(db, assertions)
+	}
+
+	//filled in dummy data
+	sit := models.StorageInTransit{
+		ShipmentID:         shipmentID,
+		Location:           models.StorageInTransitLocationDESTINATION,
+		Status:             models.StorageInTransitStatusINSIT,
+		WarehouseID:        "000383",
+		WarehouseName:      "Hercules Hauling",
+		WarehouseAddressID: warehouseAddress.ID,
+		WarehouseAddress:   warehouseAddress,
+		InDate:             time.Date(2018, time.December, 10, 0, 0, 0, 0, time.UTC),
+		AuthorizedDays:     models.StorageInTransitMaxDays,
+	}
+
+	// Overwrite values with those from assertions
+	mergeModels(&sit, assertions.StorageInTransit)
+
+	mustCreate(db, &sit)
+
+	return sit
+}
+
+// MakeDefaultStorageInTransit makes a stay in SIT with default values
+func MakeDefaultStorageInTransit(db *pop.Connection) models.StorageInTransit {
+	return MakeStorageInTransit(db, Assertions{})
+}
+
+// MakeStorageInTransitExtension creates a single request for more days of SIT
+func MakeStorageInTransitExtension(db *pop.Connection, assertions Assertions) models.StorageInTransitExtension {
+	sitID := assertions.StorageInTransitExtension.StorageInTransitID
+	if isZeroUUID(sitID) {
+		sit := MakeStorageInTransit(db, assertions)
+		sitID = sit.ID
+	}
+
+	requestedByUserID := assertions.StorageInTransitExtension.RequestedByUserID
+	if isZeroUUID(requestedByUserID) {
+		user := MakeUser(db, assertions)
+		requestedByUserID = user.ID
+	}
+
+	//filled in dummy data
+	extension := models.StorageInTransitExtension{
+		StorageInTransitID: sitID,
+		RequestedDays:      30,
+		Reason:             "The service member's new home won't be ready until next month",
+		Status:             models.StorageInTransitExtensionStatusREQUESTED,
+		RequestedByUserID:  requestedByUserID,
+	}
+
+	// Overwrite values with those from assertions
+	mergeModels(&extension, assertions.StorageInTransitExtension)
+
+	mustCreate(db, &extension)
+
+	return extension
+}
@@ -38,6 +38,7 @@ func MakeTspUser(db *pop.Connection, assertions Assertions) models.TspUser {
 	mergeModels(&tspUser, assertions.TspUser)
 
 	mustCreate(db, &tspUser)
+	GrantRoles(db, *tspUser.UserID, models.DefaultTspRoles...)
 
 	return tspUser
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/transcom/mymove/pkg/assets"
 	"github.com/transcom/mymove/pkg/gen/apimessages"
 	"github.com/transcom/mymove/pkg/paperwork"
+	"github.com/transcom/mymove/pkg/scanner"
 	"github.com/transcom/mymove/pkg/storage"
 	uploaderpkg "github.com/transcom/mymove/pkg/uploader"
 	"go.uber.org/zap"
@@ -1765,7 +1766,7 @@ func MakeHhgWithGBL(db *pop.Connection, tspUser models.TspUser, logger *zap.Logg
 
 	// Create PDF for GBL
 	gbl, _ := models.FetchGovBillOfLadingExtractor(db, hhgID)
-	formLayout := paperwork.Form1203Layout
+	formLayout, _ := paperwork.Form1203Layout()
 
 	// Read in bytes from Asset pkg
 	data, _ := assets.Asset(formLayout.TemplateImagePath)
@@ -1773,14 +1774,14 @@ func MakeHhgWithGBL(db *pop.Connection, tspUser models.TspUser, logger *zap.Logg
 	f.Write(data)
 	f.Seek(0, 0)
 
-	form, _ := paperwork.NewTemplateForm(f, formLayout.FieldsLayout)
+	form, _ := paperwork.NewFormFromLayout(f, formLayout)
 
 	// Populate form fields with GBL data
 	form.DrawData(gbl)
 	aFile, _ := storer.FileSystem().Create(gbl.GBLNumber1)
 	form.Output(aFile)
 
-	uploader := uploaderpkg.NewUploader(db, logger, storer)
+	uploader := uploaderpkg.NewUploader(db, logger, storer, scanner.NewNoopScanner())
 	upload, _, _ := uploader.CreateUpload(nil, *tspUser.UserID, aFile)
 	uploads := []models.Upload{*upload}
 
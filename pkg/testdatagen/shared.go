@@ -20,6 +20,7 @@ import (
 
 // Assertions defines assertions about what the data contains
 type Assertions struct {
+	APIClient                                models.APIClient
 	Address                                  models.Address
 	BackupContact                            models.BackupContact
 	BlackoutDate                             models.BlackoutDate
@@ -37,6 +38,8 @@ type Assertions struct {
 	Shipment                                 models.Shipment
 	ShipmentLineItem                         models.ShipmentLineItem
 	ShipmentOffer                            models.ShipmentOffer
+	StorageInTransit                         models.StorageInTransit
+	StorageInTransitExtension                models.StorageInTransitExtension
 	Tariff400ngItem                          models.Tariff400ngItem
 	Tariff400ngZip3                          models.Tariff400ngZip3
 	TrafficDistributionList                  models.TrafficDistributionList
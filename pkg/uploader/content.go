@@ -0,0 +1,211 @@
+package uploader
+
+import (
+	"bytes"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+
+	"github.com/transcom/mymove/pkg/storage"
+)
+
+// ErrUnsupportedContent represents an error caused by a file whose structure doesn't
+// match an allowed type, regardless of what its first bytes claim it to be
+var ErrUnsupportedContent = errors.New("File content is not a valid PDF or image")
+
+// How far from the end of a file we look for trailers. PDF writers commonly leave
+// a few bytes of whitespace after %%EOF, and some cameras pad JPEGs.
+const trailerWindow = 1024
+
+var pdfHeader = []byte("%PDF-")
+var pdfTrailer = []byte("%%EOF")
+var pdfStartXref = []byte("startxref")
+
+// PDF features that run code or carry other files along with the document. Nothing
+// members upload legitimately needs them, and they would survive merging into packets.
+var pdfForbiddenNames = [][]byte{
+	[]byte("/JavaScript"),
+	[]byte("/JS"),
+	[]byte("/Launch"),
+	[]byte("/EmbeddedFile"),
+	[]byte("/RichMedia"),
+}
+
+var jpegTrailer = []byte{0xFF, 0xD9}
+var pngTrailer = []byte{'I', 'E', 'N', 'D', 0xAE, 0x42, 0x60, 0x82}
+var gifTrailer = []byte{0x3B}
+var tiffHeaders = [][]byte{[]byte("II*\x00"), []byte("MM\x00*")}
+
+// ValidateContent checks that the file is structurally what its content type says it
+// is. It expects that the passed io object will be seeked to its beginning and will
+// seek back to the beginning after reading its content.
+func ValidateContent(data io.ReadSeeker, contentType string) error {
+	if _, err := data.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "could not seek to beginning of file")
+	}
+
+	var err error
+	switch contentType {
+	case "application/pdf":
+		err = validatePDF(data)
+	case "image/jpeg":
+		err = validateJPEG(data)
+	case "image/png":
+		err = validatePNG(data)
+	case "image/gif":
+		err = validateGIF(data)
+	case "image/tiff":
+		err = validateTIFF(data)
+	case "image/heic":
+		err = validateHEIC(data)
+	default:
+		err = errors.Wrapf(ErrUnsupportedContent, "content type %s is not allowed", contentType)
+	}
+
+	if _, seekErr := data.Seek(0, io.SeekStart); seekErr != nil { // seek back to beginning of file
+		return errors.Wrap(seekErr, "could not seek to beginning of file")
+	}
+	return err
+}
+
+func validatePDF(data io.Reader) error {
+	content, err := ioutil.ReadAll(data)
+	if err != nil {
+		return errors.Wrap(err, "could not read file")
+	}
+
+	if !bytes.HasPrefix(content, pdfHeader) {
+		return errors.Wrap(ErrUnsupportedContent, "missing PDF header")
+	}
+
+	tail := tailOf(content)
+	if !bytes.Contains(tail, pdfTrailer) || !bytes.Contains(tail, pdfStartXref) {
+		return errors.Wrap(ErrUnsupportedContent, "missing PDF trailer")
+	}
+
+	for _, name := range pdfForbiddenNames {
+		if containsPDFName(content, name) {
+			return errors.Wrapf(ErrUnsupportedContent, "PDF uses %s", name)
+		}
+	}
+	return nil
+}
+
+// containsPDFName looks for a PDF name token, making sure that e.g. /JS doesn't match /JSON
+func containsPDFName(content []byte, name []byte) bool {
+	for offset := 0; offset < len(content); {
+		i := bytes.Index(content[offset:], name)
+		if i < 0 {
+			return false
+		}
+		end := offset + i + len(name)
+		if end >= len(content) || isPDFDelimiter(content[end]) {
+			return true
+		}
+		offset = end
+	}
+	return false
+}
+
+func isPDFDelimiter(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '\f', 0, '/', '<', '>', '[', ']', '(', ')', '{', '}', '%':
+		return true
+	}
+	return false
+}
+
+func validateJPEG(data io.ReadSeeker) error {
+	if _, err := jpeg.DecodeConfig(data); err != nil {
+		return errors.Wrap(ErrUnsupportedContent, "invalid JPEG header")
+	}
+
+	tail, err := readTail(data)
+	if err != nil {
+		return err
+	}
+	if !bytes.HasSuffix(bytes.TrimRight(tail, "\x00"), jpegTrailer) {
+		return errors.Wrap(ErrUnsupportedContent, "missing JPEG end of image marker")
+	}
+	return nil
+}
+
+func validatePNG(data io.ReadSeeker) error {
+	if _, err := png.DecodeConfig(data); err != nil {
+		return errors.Wrap(ErrUnsupportedContent, "invalid PNG header")
+	}
+
+	tail, err := readTail(data)
+	if err != nil {
+		return err
+	}
+	if !bytes.HasSuffix(tail, pngTrailer) {
+		return errors.Wrap(ErrUnsupportedContent, "missing PNG end chunk")
+	}
+	return nil
+}
+
+func validateGIF(data io.ReadSeeker) error {
+	if _, err := gif.DecodeConfig(data); err != nil {
+		return errors.Wrap(ErrUnsupportedContent, "invalid GIF header")
+	}
+
+	tail, err := readTail(data)
+	if err != nil {
+		return err
+	}
+	if !bytes.HasSuffix(tail, gifTrailer) {
+		return errors.Wrap(ErrUnsupportedContent, "missing GIF trailer")
+	}
+	return nil
+}
+
+// TIFF and HEIC can't be decoded here, so we only check their headers. They're
+// converted before being stored, which fails for anything that isn't a real image.
+func validateTIFF(data io.Reader) error {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(data, header); err != nil {
+		return errors.Wrap(ErrUnsupportedContent, "missing TIFF header")
+	}
+	for _, tiffHeader := range tiffHeaders {
+		if bytes.Equal(header, tiffHeader) {
+			return nil
+		}
+	}
+	return errors.Wrap(ErrUnsupportedContent, "invalid TIFF header")
+}
+
+func validateHEIC(data io.Reader) error {
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(data, header); err != nil || !storage.IsHEIC(header) {
+		return errors.Wrap(ErrUnsupportedContent, "invalid HEIC header")
+	}
+	return nil
+}
+
+func readTail(data io.ReadSeeker) ([]byte, error) {
+	size, err := data.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not seek to end of file")
+	}
+
+	offset := size - trailerWindow
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err := data.Seek(offset, io.SeekStart); err != nil {
+		return nil, errors.Wrap(err, "could not seek to file trailer")
+	}
+	return ioutil.ReadAll(data)
+}
+
+func tailOf(content []byte) []byte {
+	if len(content) <= trailerWindow {
+		return content
+	}
+	return content[len(content)-trailerWindow:]
+}
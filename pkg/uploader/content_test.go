@@ -0,0 +1,63 @@
+package uploader
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestValidateContentPDF(t *testing.T) {
+	cases := map[string]bool{
+		"%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\nstartxref\n0\n%%EOF\n":         true,
+		"%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\n":                              false,
+		"<html>%PDF-1.4\nstartxref\n0\n%%EOF\n":                                        false,
+		"%PDF-1.4\n1 0 obj << /S /Launch /F (cmd.exe) >> endobj\nstartxref\n%%EOF\n":   false,
+		"%PDF-1.4\n1 0 obj << /JSON (is not javascript) >> endobj\nstartxref\n%%EOF\n": true,
+	}
+
+	for content, valid := range cases {
+		err := ValidateContent(strings.NewReader(content), "application/pdf")
+		if valid && err != nil {
+			t.Errorf("expected %q to be valid, got %v", content, err)
+		}
+		if !valid && errors.Cause(err) != ErrUnsupportedContent {
+			t.Errorf("expected %q to be rejected, got %v", content, err)
+		}
+	}
+}
+
+func TestValidateContentPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	valid := buf.Bytes()
+
+	if err := ValidateContent(bytes.NewReader(valid), "image/png"); err != nil {
+		t.Errorf("expected PNG to be valid, got %v", err)
+	}
+
+	truncated := valid[:len(valid)-12]
+	if err := ValidateContent(bytes.NewReader(truncated), "image/png"); errors.Cause(err) != ErrUnsupportedContent {
+		t.Errorf("expected truncated PNG to be rejected, got %v", err)
+	}
+
+	// A PNG claiming to be a JPEG is still rejected
+	if err := ValidateContent(bytes.NewReader(valid), "image/jpeg"); errors.Cause(err) != ErrUnsupportedContent {
+		t.Errorf("expected PNG to be rejected as a JPEG, got %v", err)
+	}
+}
+
+func TestValidateContentSeeksBack(t *testing.T) {
+	reader := strings.NewReader("%PDF-1.4\nstartxref\n0\n%%EOF\n")
+	if err := ValidateContent(reader, "application/pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if int64(reader.Len()) != reader.Size() {
+		t.Error("expected reader to be seeked back to the beginning")
+	}
+}
@@ -0,0 +1,106 @@
+package uploader
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// EXIF orientation values, see http://sylvana.net/jpegcrop/exif_orientation.html
+const (
+	orientationNormal     = 1
+	orientationFlipH      = 2
+	orientationRotate180  = 3
+	orientationFlipV      = 4
+	orientationTranspose  = 5
+	orientationRotate90   = 6
+	orientationTransverse = 7
+	orientationRotate270  = 8
+)
+
+const exifOrientationTag = 0x0112
+const exifTypeShort = 3
+
+var exifHeader = []byte("Exif\x00\x00")
+
+// exifOrientation returns the EXIF orientation of a JPEG, or orientationNormal if it
+// doesn't have one. Phones store photos as the sensor saw them and rely on this tag
+// to display them upright.
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return orientationNormal
+	}
+
+	offset := 2
+	for offset+4 <= len(data) {
+		if data[offset] != 0xFF {
+			return orientationNormal
+		}
+		marker := data[offset+1]
+		switch {
+		case marker == 0xFF: // fill byte
+			offset++
+			continue
+		case marker == 0xDA || marker == 0xD9: // start of scan or end of image, no metadata follows
+			return orientationNormal
+		case marker >= 0xD0 && marker <= 0xD7 || marker == 0x01: // markers without a length
+			offset += 2
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		if length < 2 || offset+2+length > len(data) {
+			return orientationNormal
+		}
+		segment := data[offset+4 : offset+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, exifHeader) {
+			return tiffOrientation(segment[len(exifHeader):])
+		}
+		offset += 2 + length
+	}
+	return orientationNormal
+}
+
+// tiffOrientation reads the orientation tag from the first IFD of TIFF formatted EXIF data
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return orientationNormal
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return orientationNormal
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return orientationNormal
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return orientationNormal
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return orientationNormal
+		}
+		if order.Uint16(tiff[entry:]) != exifOrientationTag {
+			continue
+		}
+		if order.Uint16(tiff[entry+2:]) != exifTypeShort {
+			return orientationNormal
+		}
+		// A single SHORT is stored at the start of the entry's 4 byte value field
+		orientation := int(order.Uint16(tiff[entry+8:]))
+		if orientation < orientationNormal || orientation > orientationRotate270 {
+			return orientationNormal
+		}
+		return orientation
+	}
+	return orientationNormal
+}
@@ -0,0 +1,333 @@
+package uploader
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MaxImageDimension is the longest side, in pixels, that we keep for an uploaded image.
+// It's a letter size page at 300 DPI, which is all the detail a printed packet can show.
+const MaxImageDimension = 3300
+
+// Images with more pixels than this are rejected rather than decoded into memory. Decoding
+// takes four bytes a pixel, so this keeps one upload to about 160MB, and still fits photos
+// from phone cameras.
+const maxImagePixels = 40 * 1000 * 1000
+
+const normalizedJPEGQuality = 85
+
+// Content types of images that are normalized before they are stored
+var normalizedContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/tiff": true,
+	"image/heic": true,
+}
+
+// Content types that Go can't decode, and which need an ImageConverter
+var convertedContentTypes = map[string]bool{
+	"image/tiff": true,
+	"image/heic": true,
+}
+
+// NeedsNormalization returns true if files of the given content type are images
+// that should be normalized before they are stored.
+func NeedsNormalization(contentType string) bool {
+	return normalizedContentTypes[contentType]
+}
+
+// ImageConverter converts images in formats we can't decode into JPEG
+type ImageConverter interface {
+	ConvertToJPEG(data io.Reader, contentType string) ([]byte, error)
+}
+
+// NormalizedImage is an upright, metadata free image that is small enough to print
+type NormalizedImage struct {
+	Content     []byte
+	ContentType string
+}
+
+// ImageNormalizer prepares uploaded images for storage: it applies EXIF rotation,
+// strips metadata, downscales to MaxDimension and re-encodes everything as JPEG or PNG,
+// which are the only image types that paperwork can place.
+type ImageNormalizer struct {
+	MaxDimension int
+	converter    ImageConverter
+}
+
+// NewImageNormalizer creates and returns a new ImageNormalizer. HEIC and TIFF images
+// are rejected unless a converter is supplied.
+func NewImageNormalizer(converter ImageConverter) *ImageNormalizer {
+	return &ImageNormalizer{
+		MaxDimension: MaxImageDimension,
+		converter:    converter,
+	}
+}
+
+// Normalize returns a normalized copy of the image. It expects that the passed io object
+// will be seeked to its beginning and will seek back to the beginning after reading its
+// content.
+func (n *ImageNormalizer) Normalize(data io.ReadSeeker, contentType string) (NormalizedImage, error) {
+	if _, err := data.Seek(0, io.SeekStart); err != nil {
+		return NormalizedImage{}, errors.Wrap(err, "could not seek to beginning of file")
+	}
+	content, err := ioutil.ReadAll(data)
+	if err != nil {
+		return NormalizedImage{}, errors.Wrap(err, "could not read file")
+	}
+	if _, err := data.Seek(0, io.SeekStart); err != nil { // seek back to beginning of file
+		return NormalizedImage{}, errors.Wrap(err, "could not seek to beginning of file")
+	}
+
+	if convertedContentTypes[contentType] {
+		if n.converter == nil {
+			return NormalizedImage{}, errors.Wrapf(ErrUnsupportedContent, "no converter is configured for %s", contentType)
+		}
+		content, err = n.converter.ConvertToJPEG(bytes.NewReader(content), contentType)
+		if err != nil {
+			return NormalizedImage{}, errors.Wrap(ErrUnsupportedContent, err.Error())
+		}
+		contentType = "image/jpeg"
+	}
+
+	var decode func(io.Reader) (image.Image, error)
+	var decodeConfig func(io.Reader) (image.Config, error)
+	orientation := orientationNormal
+	outputType := "image/png"
+	switch contentType {
+	case "image/jpeg":
+		decode, decodeConfig = jpeg.Decode, jpeg.DecodeConfig
+		orientation = exifOrientation(content)
+		outputType = "image/jpeg"
+	case "image/png":
+		decode, decodeConfig = png.Decode, png.DecodeConfig
+	case "image/gif":
+		// Only the first frame of an animation is kept
+		decode, decodeConfig = gif.Decode, gif.DecodeConfig
+	default:
+		return NormalizedImage{}, errors.Wrapf(ErrUnsupportedContent, "content type %s is not an image", contentType)
+	}
+
+	config, err := decodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return NormalizedImage{}, errors.Wrap(ErrUnsupportedContent, "invalid image header")
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return NormalizedImage{}, errors.Wrapf(ErrUnsupportedContent, "image is %dx%d pixels", config.Width, config.Height)
+	}
+	img, err := decode(bytes.NewReader(content))
+	if err != nil {
+		return NormalizedImage{}, errors.Wrap(ErrUnsupportedContent, "could not decode image")
+	}
+
+	normalized := orient(downscale(toRGBA(img), n.MaxDimension), orientation)
+
+	// Re-encoding only writes pixels, which drops EXIF and any other metadata
+	var buf bytes.Buffer
+	if outputType == "image/jpeg" {
+		err = jpeg.Encode(&buf, normalized, &jpeg.Options{Quality: normalizedJPEGQuality})
+	} else {
+		err = png.Encode(&buf, normalized)
+	}
+	if err != nil {
+		return NormalizedImage{}, errors.Wrap(err, "could not encode image")
+	}
+
+	return NormalizedImage{Content: buf.Bytes(), ContentType: outputType}, nil
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
+
+// downscale shrinks the image so that neither side is longer than maxDimension, averaging
+// each block of source pixels into one destination pixel. Smaller images are returned as-is.
+func downscale(src *image.RGBA, maxDimension int) *image.RGBA {
+	width, height := src.Rect.Dx(), src.Rect.Dy()
+	longest := width
+	if height > longest {
+		longest = height
+	}
+	if maxDimension <= 0 || longest <= maxDimension {
+		return src
+	}
+
+	dstWidth := width * maxDimension / longest
+	dstHeight := height * maxDimension / longest
+	if dstWidth < 1 {
+		dstWidth = 1
+	}
+	if dstHeight < 1 {
+		dstHeight = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0, y1 := y*height/dstHeight, (y+1)*height/dstHeight
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < dstWidth; x++ {
+			x0, x1 := x*width/dstWidth, (x+1)*width/dstWidth
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+
+			count := (x1 - x0) * (y1 - y0)
+			d := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[d+c] = uint8(sum[c] / count)
+			}
+		}
+	}
+	return dst
+}
+
+// orient rotates and flips the image so that it displays upright without its EXIF orientation
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation == orientationNormal {
+		return src
+	}
+
+	width, height := src.Rect.Dx(), src.Rect.Dy()
+	dstWidth, dstHeight := width, height
+	if orientation >= orientationTranspose {
+		dstWidth, dstHeight = height, width
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			var sx, sy int
+			switch orientation {
+			case orientationFlipH:
+				sx, sy = width-1-x, y
+			case orientationRotate180:
+				sx, sy = width-1-x, height-1-y
+			case orientationFlipV:
+				sx, sy = x, height-1-y
+			case orientationTranspose:
+				sx, sy = y, x
+			case orientationRotate90:
+				sx, sy = y, height-1-x
+			case orientationTransverse:
+				sx, sy = width-1-y, height-1-x
+			case orientationRotate270:
+				sx, sy = width-1-y, x
+			default:
+				sx, sy = x, y
+			}
+			s := src.PixOffset(sx, sy)
+			d := dst.PixOffset(x, y)
+			copy(dst.Pix[d:d+4], src.Pix[s:s+4])
+		}
+	}
+	return dst
+}
+
+// Input formats understood by ImageMagick's convert
+var imageMagickFormats = map[string]string{
+	"image/tiff": "tiff",
+	"image/heic": "heic",
+}
+
+// imageConversionTimeout is how long convert may run before it is killed. ImageMagick
+// stops itself after its own 30 second time limit, so this only catches a hung process.
+const imageConversionTimeout = time.Minute
+
+// imageMagickPolicy only lets convert read the formats we accept and write JPEG, and
+// rejects images larger than the normalizer would accept anyway
+const imageMagickPolicy = `<policymap>
+  <policy domain="coder" rights="none" pattern="*" />
+  <policy domain="coder" rights="read" pattern="{TIFF,HEIC}" />
+  <policy domain="coder" rights="write" pattern="{JPEG,JPG}" />
+  <policy domain="delegate" rights="none" pattern="*" />
+  <policy domain="path" rights="none" pattern="@*" />
+  <policy domain="resource" name="area" value="40MP" />
+  <policy domain="resource" name="width" value="16KP" />
+  <policy domain="resource" name="height" value="16KP" />
+</policymap>
+`
+
+// CommandImageConverter converts images by running ImageMagick's convert, which must be
+// built with libheif to read HEIC.
+type CommandImageConverter struct {
+	path string
+}
+
+// NewCommandImageConverter creates and returns a new CommandImageConverter that runs the
+// convert binary at the given path.
+func NewCommandImageConverter(path string) *CommandImageConverter {
+	return &CommandImageConverter{path: path}
+}
+
+// ConvertToJPEG converts the first page of the image to an upright JPEG. convert runs
+// under imageMagickPolicy, and is limited to the memory, disk and time an ordinary photo
+// needs so that a hostile file can't exhaust the server.
+func (c *CommandImageConverter) ConvertToJPEG(data io.Reader, contentType string) ([]byte, error) {
+	format, ok := imageMagickFormats[contentType]
+	if !ok {
+		return nil, errors.Errorf("can't convert %s", contentType)
+	}
+
+	policyDir, err := ioutil.TempDir("", "imagemagick")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create ImageMagick policy directory")
+	}
+	defer os.RemoveAll(policyDir)
+	err = ioutil.WriteFile(filepath.Join(policyDir, "policy.xml"), []byte(imageMagickPolicy), 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not write ImageMagick policy")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), imageConversionTimeout)
+	defer cancel()
+
+	// #nosec the binary is set by the operator and the arguments are constants
+	cmd := exec.CommandContext(ctx, c.path,
+		"-limit", "memory", "256MiB",
+		"-limit", "map", "512MiB",
+		"-limit", "disk", "1GiB",
+		"-limit", "time", "30",
+		format+":-[0]", "-auto-orient", "jpeg:-")
+	cmd.Env = append(os.Environ(), "MAGICK_CONFIGURE_PATH="+policyDir)
+	cmd.Stdin = data
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, errors.Wrapf(ctx.Err(), "converting %s took too long", contentType)
+		}
+		return nil, errors.Wrapf(err, "converting %s failed: %s", contentType, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
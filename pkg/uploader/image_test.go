@@ -0,0 +1,198 @@
+package uploader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// withOrientation inserts an EXIF segment with the given orientation after a JPEG's SOI marker
+func withOrientation(t *testing.T, content []byte, orientation uint16) []byte {
+	tiff := new(bytes.Buffer)
+	tiff.WriteString("MM")
+	for _, v := range []interface{}{uint16(42), uint32(8), uint16(1), uint16(exifOrientationTag), uint16(exifTypeShort), uint32(1), orientation, uint16(0), uint32(0)} {
+		if err := binary.Write(tiff, binary.BigEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	segment := append(append([]byte{}, exifHeader...), tiff.Bytes()...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+
+	result := append([]byte{}, content[:2]...)
+	result = append(result, app1...)
+	result = append(result, segment...)
+	return append(result, content[2:]...)
+}
+
+// sampleImage is wider than it is tall, with a red left half and a blue right half
+func sampleImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestExifOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, sampleImage(8, 4), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if o := exifOrientation(buf.Bytes()); o != orientationNormal {
+		t.Errorf("expected a JPEG without EXIF to be normal, got %d", o)
+	}
+	for _, orientation := range []uint16{orientationNormal, orientationRotate90, orientationRotate270} {
+		if o := exifOrientation(withOrientation(t, buf.Bytes(), orientation)); o != int(orientation) {
+			t.Errorf("expected orientation %d, got %d", orientation, o)
+		}
+	}
+}
+
+func TestNormalizeRotatesAndStripsJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, sampleImage(80, 40), &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	content := withOrientation(t, buf.Bytes(), orientationRotate90)
+
+	normalized, err := NewImageNormalizer(nil).Normalize(bytes.NewReader(content), "image/jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if normalized.ContentType != "image/jpeg" {
+		t.Errorf("expected a JPEG, got %s", normalized.ContentType)
+	}
+	if o := exifOrientation(normalized.Content); o != orientationNormal {
+		t.Errorf("expected EXIF to be stripped, got orientation %d", o)
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(normalized.Content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 40 || img.Bounds().Dy() != 80 {
+		t.Fatalf("expected a 40x80 image, got %v", img.Bounds())
+	}
+	// Rotating clockwise puts the red left half on top
+	if r, _, b, _ := img.At(20, 5).RGBA(); r < b {
+		t.Errorf("expected the top of the image to be red")
+	}
+}
+
+// pngHeader returns the start of a PNG of the given size, with no pixel data
+func pngHeader(width, height uint32) []byte {
+	// 8 bit RGBA, no interlacing
+	ihdr := []byte{'I', 'H', 'D', 'R', 0, 0, 0, 0, 0, 0, 0, 0, 8, 6, 0, 0, 0}
+	binary.BigEndian.PutUint32(ihdr[4:], width)
+	binary.BigEndian.PutUint32(ihdr[8:], height)
+
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(ihdr)-4))
+	checksum := make([]byte, 4)
+	binary.BigEndian.PutUint32(checksum, crc32.ChecksumIEEE(ihdr))
+
+	content := append([]byte("\x89PNG\r\n\x1a\n"), length...)
+	content = append(content, ihdr...)
+	return append(content, checksum...)
+}
+
+func TestNormalizePixelLimit(t *testing.T) {
+	normalizer := NewImageNormalizer(nil)
+
+	// 8000x5000 is exactly the limit, so it gets as far as decoding the missing pixels
+	_, err := normalizer.Normalize(bytes.NewReader(pngHeader(8000, 5000)), "image/png")
+	if err == nil || !strings.Contains(err.Error(), "could not decode image") {
+		t.Fatalf("expected an image at the limit to be decoded, got %v", err)
+	}
+
+	// One more row is too many
+	_, err = normalizer.Normalize(bytes.NewReader(pngHeader(8000, 5001)), "image/png")
+	if errors.Cause(err) != ErrUnsupportedContent || !strings.Contains(err.Error(), "image is 8000x5001 pixels") {
+		t.Fatalf("expected an image over the limit to be rejected, got %v", err)
+	}
+}
+
+func TestNormalizeDownscales(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, sampleImage(100, 50)); err != nil {
+		t.Fatal(err)
+	}
+
+	normalizer := NewImageNormalizer(nil)
+	normalizer.MaxDimension = 10
+	normalized, err := normalizer.Normalize(bytes.NewReader(buf.Bytes()), "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(normalized.Content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 10 || img.Bounds().Dy() != 5 {
+		t.Fatalf("expected a 10x5 image, got %v", img.Bounds())
+	}
+}
+
+func TestNormalizeConvertsGIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, sampleImage(8, 4), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	normalized, err := NewImageNormalizer(nil).Normalize(bytes.NewReader(buf.Bytes()), "image/gif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if normalized.ContentType != "image/png" {
+		t.Errorf("expected a PNG, got %s", normalized.ContentType)
+	}
+}
+
+type fakeImageConverter struct {
+	content []byte
+}
+
+func (c fakeImageConverter) ConvertToJPEG(data io.Reader, contentType string) ([]byte, error) {
+	return c.content, nil
+}
+
+func TestNormalizeHEIC(t *testing.T) {
+	heic := []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00mif1heic")
+
+	_, err := NewImageNormalizer(nil).Normalize(bytes.NewReader(heic), "image/heic")
+	if errors.Cause(err) != ErrUnsupportedContent {
+		t.Errorf("expected HEIC to be unsupported without a converter, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, sampleImage(8, 4), nil); err != nil {
+		t.Fatal(err)
+	}
+	normalized, err := NewImageNormalizer(fakeImageConverter{content: buf.Bytes()}).Normalize(bytes.NewReader(heic), "image/heic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if normalized.ContentType != "image/jpeg" {
+		t.Errorf("expected a JPEG, got %s", normalized.ContentType)
+	}
+}
@@ -1,7 +1,9 @@
 package uploader
 
 import (
+	"bytes"
 	"io"
+	"time"
 
 	"github.com/gobuffalo/pop"
 	"github.com/gobuffalo/validate"
@@ -11,32 +13,69 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/transcom/mymove/pkg/models"
+	"github.com/transcom/mymove/pkg/scanner"
 	"github.com/transcom/mymove/pkg/storage"
 )
 
 // ErrZeroLengthFile represents an error caused by a file with no content
 var ErrZeroLengthFile = errors.New("File has length of 0")
 
+// ErrNoScanner is returned when no malware scanner is configured, since unscanned files are never stored
+var ErrNoScanner = errors.New("No malware scanner is configured")
+
 // Uploader encapsulates a few common processes: creating Uploads for a Document,
 // generating pre-signed URLs for file access, and deleting Uploads.
 type Uploader struct {
-	db     *pop.Connection
-	logger *zap.Logger
-	Storer storage.FileStorer
+	db         *pop.Connection
+	logger     *zap.Logger
+	Storer     storage.FileStorer
+	scanner    scanner.Scanner
+	normalizer *ImageNormalizer
 }
 
 // NewUploader creates and returns a new uploader
-func NewUploader(db *pop.Connection, logger *zap.Logger, storer storage.FileStorer) *Uploader {
+func NewUploader(db *pop.Connection, logger *zap.Logger, storer storage.FileStorer, fileScanner scanner.Scanner) *Uploader {
 	return &Uploader{
-		db:     db,
-		logger: logger,
-		Storer: storer,
+		db:         db,
+		logger:     logger,
+		Storer:     storer,
+		scanner:    fileScanner,
+		normalizer: NewImageNormalizer(nil),
+	}
+}
+
+// SetImageConverter sets the converter used to normalize image formats that can't be
+// decoded directly, such as HEIC and TIFF
+func (u *Uploader) SetImageConverter(converter ImageConverter) {
+	u.normalizer.converter = converter
+}
+
+// WithTransaction returns a copy of the Uploader that saves Uploads in tx, so they are
+// committed or rolled back along with the rest of the caller's changes
+func (u *Uploader) WithTransaction(tx *pop.Connection) *Uploader {
+	txUploader := *u
+	txUploader.db = tx
+	return &txUploader
+}
+
+// transaction runs fn in a new transaction, or in the caller's if the Uploader was made
+// with WithTransaction, since pop commits the outer transaction when they are nested
+func (u *Uploader) transaction(fn func(db *pop.Connection) error) error {
+	if u.db.TX != nil {
+		return fn(u.db)
 	}
+	return u.db.Transaction(fn)
 }
 
 // CreateUpload creates a new Upload by performing validations, storing the specified
 // file using the supplied storer, and saving an Upload object to the database containing
 // the file's metadata.
+//
+// Files that fail a malware scan are recorded as quarantined but never stored, and
+// ErrUploadQuarantined is returned.
+//
+// Images are normalized before they are stored, and the Upload describes the normalized
+// image. The file as uploaded is stored separately under OriginalStorageKey.
 func (u *Uploader) CreateUpload(documentID *uuid.UUID, userID uuid.UUID, file afero.File) (*models.Upload, *validate.Errors, error) {
 	responseVErrors := validate.NewErrors()
 	var responseError error
@@ -56,12 +95,28 @@ func (u *Uploader) CreateUpload(documentID *uuid.UUID, userID uuid.UUID, file af
 		return nil, responseVErrors, err
 	}
 
+	if err := ValidateContent(file, contentType); err != nil {
+		u.logger.Info("Rejected upload with invalid content", zap.String("content_type", contentType), zap.Error(err))
+		return nil, responseVErrors, err
+	}
+
 	checksum, err := storage.ComputeChecksum(file)
 	if err != nil {
 		u.logger.Error("Could not compute checksum", zap.Error(err))
 		return nil, responseVErrors, err
 	}
 
+	// If we can't scan the file we can't store it
+	if u.scanner == nil {
+		u.logger.Error("Refusing to store upload without a malware scanner")
+		return nil, responseVErrors, ErrNoScanner
+	}
+	scanResult, err := u.scanner.Scan(file)
+	if err != nil {
+		u.logger.Error("Could not scan file", zap.Error(err))
+		return nil, responseVErrors, errors.Wrap(err, "could not scan file")
+	}
+
 	id := uuid.Must(uuid.NewV4())
 
 	newUpload := &models.Upload{
@@ -74,7 +129,59 @@ func (u *Uploader) CreateUpload(documentID *uuid.UUID, userID uuid.UUID, file af
 		Checksum:    checksum,
 	}
 
-	u.db.Transaction(func(db *pop.Connection) error {
+	scannedAt := time.Now()
+	if scanResult.Clean {
+		newUpload.MarkScanned(scannedAt)
+	} else {
+		newUpload.Quarantine(scannedAt, scanResult.Signature)
+	}
+
+	// content is what gets stored under the Upload's StorageKey
+	var content io.ReadSeeker = file
+	if !newUpload.IsQuarantined() {
+		sha256Checksum, err := storage.ComputeSHA256Checksum(file)
+		if err != nil {
+			u.logger.Error("Could not compute checksum", zap.Error(err))
+			return nil, responseVErrors, err
+		}
+
+		if NeedsNormalization(contentType) {
+			normalized, err := u.normalizer.Normalize(file, contentType)
+			if err != nil {
+				u.logger.Info("Could not normalize image", zap.String("content_type", contentType), zap.Error(err))
+				return nil, responseVErrors, err
+			}
+
+			normalizedContent := bytes.NewReader(normalized.Content)
+			normalizedChecksum, err := storage.ComputeChecksum(normalizedContent)
+			if err != nil {
+				u.logger.Error("Could not compute checksum", zap.Error(err))
+				return nil, responseVErrors, err
+			}
+
+			originalBytes := info.Size()
+			originalKey := storage.ContentKey(sha256Checksum)
+			newUpload.OriginalContentType = &contentType
+			newUpload.OriginalBytes = &originalBytes
+			newUpload.OriginalChecksum = &checksum
+			newUpload.OriginalStorageKey = &originalKey
+			newUpload.ContentType = normalized.ContentType
+			newUpload.Bytes = normalizedContent.Size()
+			newUpload.Checksum = normalizedChecksum
+			content = normalizedContent
+
+			sha256Checksum, err = storage.ComputeSHA256Checksum(normalizedContent)
+			if err != nil {
+				u.logger.Error("Could not compute checksum", zap.Error(err))
+				return nil, responseVErrors, err
+			}
+		}
+
+		newUpload.SHA256Checksum = &sha256Checksum
+		newUpload.StorageKey = storage.ContentKey(sha256Checksum)
+	}
+
+	u.transaction(func(db *pop.Connection) error {
 		transactionError := errors.New("Rollback The transaction")
 
 		verrs, err := db.ValidateAndCreate(newUpload)
@@ -85,8 +192,22 @@ func (u *Uploader) CreateUpload(documentID *uuid.UUID, userID uuid.UUID, file af
 			return transactionError
 		}
 
+		// Keep the record of a quarantined upload, but not its content
+		if newUpload.IsQuarantined() {
+			u.logger.Warn("quarantined an upload", zap.Any("new_upload_id", newUpload.ID), zap.String("signature", scanResult.Signature))
+			return nil
+		}
+
+		if newUpload.HasOriginal() {
+			if err := u.storeObject(db, *newUpload.OriginalStorageKey, file, *newUpload.OriginalChecksum); err != nil {
+				u.logger.Error("failed to store original object", zap.Error(err))
+				responseError = errors.Wrap(err, "failed to store original object")
+				return transactionError
+			}
+		}
+
 		// Push file to S3
-		if _, err := u.Storer.Store(newUpload.StorageKey, file, checksum); err != nil {
+		if err := u.storeObject(db, newUpload.StorageKey, content, newUpload.Checksum); err != nil {
 			u.logger.Error("failed to store object", zap.Error(err))
 			responseVErrors.Append(verrs)
 			responseError = errors.Wrap(err, "failed to store object")
@@ -98,11 +219,19 @@ func (u *Uploader) CreateUpload(documentID *uuid.UUID, userID uuid.UUID, file af
 
 	})
 
+	if responseError == nil && !responseVErrors.HasAny() && newUpload.IsQuarantined() {
+		responseError = models.ErrUploadQuarantined
+	}
+
 	return newUpload, responseVErrors, responseError
 }
 
 // PresignedURL returns a URL that can be used to access an Upload's file.
 func (u *Uploader) PresignedURL(upload *models.Upload) (string, error) {
+	if upload.IsQuarantined() {
+		return "", models.ErrUploadQuarantined
+	}
+
 	url, err := u.Storer.PresignedURL(upload.StorageKey, upload.ContentType)
 	if err != nil {
 		u.logger.Error("failed to get presigned url", zap.Error(err))
@@ -111,20 +240,82 @@ func (u *Uploader) PresignedURL(upload *models.Upload) (string, error) {
 	return url, nil
 }
 
-// DeleteUpload removes an Upload from the database and deletes its file from the
-// storer.
-func (u *Uploader) DeleteUpload(upload *models.Upload) error {
-	if err := u.Storer.Delete(upload.StorageKey); err != nil {
+// storeObject stores content under a content addressed key, unless another upload has
+// already stored identical content there.
+func (u *Uploader) storeObject(db *pop.Connection, key string, data io.ReadSeeker, checksum string) error {
+	references, err := models.AddStoredObjectReference(db, key)
+	if err != nil {
 		return err
 	}
+	if references > 1 {
+		u.logger.Info("reusing stored object", zap.String("key", key), zap.Int("references", references))
+		return nil
+	}
+
+	_, err = u.Storer.Store(key, data, checksum)
+	return err
+}
+
+// DeleteUpload removes an Upload from the database and deletes its files from the
+// storer, unless other uploads still refer to them.
+func (u *Uploader) DeleteUpload(upload *models.Upload) error {
+	return u.transaction(func(db *pop.Connection) error {
+		if err := models.DeleteUpload(db, upload); err != nil {
+			return err
+		}
 
-	return models.DeleteUpload(u.db, upload)
+		// Quarantined uploads never had their content stored
+		if upload.IsQuarantined() {
+			return nil
+		}
+
+		keys := []string{upload.StorageKey}
+		if upload.HasOriginal() {
+			keys = append(keys, *upload.OriginalStorageKey)
+		}
+		for _, key := range keys {
+			references, err := models.RemoveStoredObjectReference(db, key)
+			if err != nil {
+				return err
+			}
+			if references > 0 {
+				continue
+			}
+			if err := u.Storer.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-// Download fetches an Upload's file and stores it in a tempfile. The path to this
-// file is returned.
+// Download fetches an Upload's file. Reading it to the end fails with
+// storage.ErrChecksumMismatch if the stored file doesn't match the Upload's checksum.
 //
-// It is the caller's responsibility to delete the tempfile.
+// It is the caller's responsibility to close the returned reader.
 func (u *Uploader) Download(upload *models.Upload) (io.ReadCloser, error) {
-	return u.Storer.Fetch(upload.StorageKey)
+	if upload.IsQuarantined() {
+		return nil, models.ErrUploadQuarantined
+	}
+	return u.fetchVerified(upload.StorageKey, upload.Checksum)
+}
+
+// DownloadOriginal fetches the file as it was uploaded, before it was normalized. Uploads
+// that weren't normalized return their only file.
+func (u *Uploader) DownloadOriginal(upload *models.Upload) (io.ReadCloser, error) {
+	if !upload.HasOriginal() {
+		return u.Download(upload)
+	}
+	if upload.IsQuarantined() {
+		return nil, models.ErrUploadQuarantined
+	}
+	return u.fetchVerified(*upload.OriginalStorageKey, *upload.OriginalChecksum)
+}
+
+func (u *Uploader) fetchVerified(key string, checksum string) (io.ReadCloser, error) {
+	data, err := u.Storer.Fetch(key)
+	if err != nil {
+		return nil, err
+	}
+	return storage.VerifyMD5(data, checksum)
 }
@@ -1,17 +1,24 @@
 package uploader_test
 
 import (
+	"image"
+	"image/png"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
 	"path"
 	"testing"
 
 	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/validate"
+	"github.com/pkg/errors"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/zap"
 
+	"github.com/transcom/mymove/pkg/models"
+	scannerTest "github.com/transcom/mymove/pkg/scanner/test"
 	"github.com/transcom/mymove/pkg/storage"
 	storageTest "github.com/transcom/mymove/pkg/storage/test"
 	"github.com/transcom/mymove/pkg/testdatagen"
@@ -104,7 +111,7 @@ func TestUploaderSuite(t *testing.T) {
 func (suite *UploaderSuite) TestUploadFromLocalFile() {
 	document := testdatagen.MakeDefaultDocument(suite.db)
 
-	up := uploader.NewUploader(suite.db, suite.logger, suite.storer)
+	up := uploader.NewUploader(suite.db, suite.logger, suite.storer, scannerTest.NewFakeScanner())
 	file := suite.fixture("test.pdf")
 
 	upload, verrs, err := up.CreateUpload(&document.ID, document.ServiceMember.UserID, file)
@@ -114,10 +121,31 @@ func (suite *UploaderSuite) TestUploadFromLocalFile() {
 	suite.Equal(upload.Checksum, "nOE6HwzyE4VEDXn67ULeeA==")
 }
 
+func (suite *UploaderSuite) TestUploadRolledBackWithTransaction() {
+	document := testdatagen.MakeDefaultDocument(suite.db)
+
+	up := uploader.NewUploader(suite.db, suite.logger, suite.storer, scannerTest.NewFakeScanner())
+	file := suite.fixture("test.pdf")
+
+	var upload *models.Upload
+	suite.db.Transaction(func(tx *pop.Connection) error {
+		var verrs *validate.Errors
+		var err error
+		upload, verrs, err = up.WithTransaction(tx).CreateUpload(&document.ID, document.ServiceMember.UserID, file)
+		suite.Nil(err, "failed to create upload")
+		suite.False(verrs.HasAny(), "failed to validate upload", verrs)
+		return errors.New("Rollback The transaction")
+	})
+
+	count, err := suite.db.Where("id = ?", upload.ID).Count(&models.Upload{})
+	suite.Nil(err)
+	suite.Equal(0, count, "upload outlived the caller's transaction")
+}
+
 func (suite *UploaderSuite) TestUploadFromLocalFileZeroLength() {
 	document := testdatagen.MakeDefaultDocument(suite.db)
 
-	up := uploader.NewUploader(suite.db, suite.logger, suite.storer)
+	up := uploader.NewUploader(suite.db, suite.logger, suite.storer, scannerTest.NewFakeScanner())
 	file := suite.fixture("empty.pdf")
 
 	upload, verrs, err := up.CreateUpload(&document.ID, document.ServiceMember.UserID, file)
@@ -125,3 +153,122 @@ func (suite *UploaderSuite) TestUploadFromLocalFileZeroLength() {
 	suite.False(verrs.HasAny(), "failed to validate upload")
 	suite.Nil(upload, "returned an upload when erroring")
 }
+
+func (suite *UploaderSuite) TestUploadWithoutScanner() {
+	document := testdatagen.MakeDefaultDocument(suite.db)
+
+	up := uploader.NewUploader(suite.db, suite.logger, suite.storer, nil)
+	file := suite.fixture("test.pdf")
+
+	upload, _, err := up.CreateUpload(&document.ID, document.ServiceMember.UserID, file)
+	suite.Equal(uploader.ErrNoScanner, err)
+	suite.Nil(upload, "stored an upload that could not be scanned")
+}
+
+func (suite *UploaderSuite) TestUploadQuarantinesInfectedFile() {
+	document := testdatagen.MakeDefaultDocument(suite.db)
+
+	up := uploader.NewUploader(suite.db, suite.logger, suite.storer, scannerTest.NewInfectedFakeScanner("Eicar-Test-Signature"))
+	file := suite.fixture("test.pdf")
+
+	upload, verrs, err := up.CreateUpload(&document.ID, document.ServiceMember.UserID, file)
+	suite.Equal(models.ErrUploadQuarantined, err)
+	suite.False(verrs.HasAny(), "failed to validate upload", verrs)
+	suite.True(upload.IsQuarantined())
+	suite.Equal("Eicar-Test-Signature", *upload.QuarantineReason)
+
+	// The content was never stored, so it can't be served or merged
+	_, err = suite.storer.Fetch(upload.StorageKey)
+	suite.NotNil(err)
+	_, err = up.PresignedURL(upload)
+	suite.Equal(models.ErrUploadQuarantined, err)
+	_, err = up.Download(upload)
+	suite.Equal(models.ErrUploadQuarantined, err)
+}
+
+func (suite *UploaderSuite) TestUploadRejectsDisguisedFile() {
+	document := testdatagen.MakeDefaultDocument(suite.db)
+
+	up := uploader.NewUploader(suite.db, suite.logger, suite.storer, scannerTest.NewFakeScanner())
+	file, err := suite.fs.Create("disguised.pdf")
+	suite.Nil(err)
+	suite.closeFile(file)
+	_, err = file.WriteString("%PDF-1.4\n1 0 obj << /S /JavaScript /JS (app.alert(1)) >> endobj\nstartxref\n0\n%%EOF\n")
+	suite.Nil(err)
+
+	upload, _, err := up.CreateUpload(&document.ID, document.ServiceMember.UserID, file)
+	suite.Equal(uploader.ErrUnsupportedContent, errors.Cause(err))
+	suite.Nil(upload, "returned an upload when erroring")
+}
+
+func (suite *UploaderSuite) TestUploadNormalizesImage() {
+	document := testdatagen.MakeDefaultDocument(suite.db)
+
+	up := uploader.NewUploader(suite.db, suite.logger, suite.storer, scannerTest.NewFakeScanner())
+	file, err := suite.fs.Create("photo.png")
+	suite.Nil(err)
+	suite.closeFile(file)
+	suite.Nil(png.Encode(file, image.NewGray(image.Rect(0, 0, 40, 20))))
+
+	upload, verrs, err := up.CreateUpload(&document.ID, document.ServiceMember.UserID, file)
+	suite.Nil(err, "failed to create upload")
+	suite.False(verrs.HasAny(), "failed to validate upload", verrs)
+	suite.Equal("image/png", upload.ContentType)
+	suite.True(upload.HasOriginal())
+	suite.Equal("image/png", *upload.OriginalContentType)
+	suite.NotNil(upload.OriginalStorageKey)
+
+	// Both the normalized image and the original were stored
+	normalized, err := up.Download(upload)
+	suite.Nil(err)
+	normalizedContent, err := ioutil.ReadAll(normalized)
+	suite.Nil(err)
+	suite.Equal(upload.Bytes, int64(len(normalizedContent)))
+
+	original, err := up.DownloadOriginal(upload)
+	suite.Nil(err)
+	originalContent, err := ioutil.ReadAll(original)
+	suite.Nil(err)
+	suite.Equal(*upload.OriginalBytes, int64(len(originalContent)))
+}
+
+func (suite *UploaderSuite) TestUploadDeduplicatesContent() {
+	document := testdatagen.MakeDefaultDocument(suite.db)
+
+	up := uploader.NewUploader(suite.db, suite.logger, suite.storer, scannerTest.NewFakeScanner())
+	first, verrs, err := up.CreateUpload(&document.ID, document.ServiceMember.UserID, suite.fixture("test.pdf"))
+	suite.Nil(err, "failed to create upload")
+	suite.False(verrs.HasAny(), "failed to validate upload", verrs)
+	second, verrs, err := up.CreateUpload(&document.ID, document.ServiceMember.UserID, suite.fixture("test.pdf"))
+	suite.Nil(err, "failed to create upload")
+	suite.False(verrs.HasAny(), "failed to validate upload", verrs)
+
+	suite.NotEqual(first.ID, second.ID)
+	suite.Equal(first.StorageKey, second.StorageKey)
+	suite.Equal(storage.ContentKey(*first.SHA256Checksum), first.StorageKey)
+
+	// The object is only removed once nothing refers to it
+	suite.Nil(up.DeleteUpload(first))
+	_, err = suite.storer.Fetch(second.StorageKey)
+	suite.Nil(err)
+
+	suite.Nil(up.DeleteUpload(second))
+	_, err = suite.storer.Fetch(second.StorageKey)
+	suite.NotNil(err)
+}
+
+func (suite *UploaderSuite) TestDownloadDetectsCorruption() {
+	document := testdatagen.MakeDefaultDocument(suite.db)
+
+	up := uploader.NewUploader(suite.db, suite.logger, suite.storer, scannerTest.NewFakeScanner())
+	upload, verrs, err := up.CreateUpload(&document.ID, document.ServiceMember.UserID, suite.fixture("test.pdf"))
+	suite.Nil(err, "failed to create upload")
+	suite.False(verrs.HasAny(), "failed to validate upload", verrs)
+
+	suite.Nil(suite.storer.FileSystem().WriteFile(upload.StorageKey, []byte("%PDF-1.4 corrupted"), 0644))
+
+	data, err := up.Download(upload)
+	suite.Nil(err)
+	_, err = ioutil.ReadAll(data)
+	suite.Equal(storage.ErrChecksumMismatch, err)
+}
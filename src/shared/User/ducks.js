@@ -1,5 +1,4 @@
 import * as Cookies from 'js-cookie';
-import * as decode from 'jwt-decode';
 import * as helpers from 'shared/ReduxHelpers';
 import { GetLoggedInUser } from './api.js';
 import { normalize } from 'normalizr';
@@ -70,11 +69,19 @@ const loggedOutUser = {
   userId: null,
 };
 
+// The cookie is base64 encoded UTF-8, but atob decodes it to one character per byte
+function decodeUserInfo(cookie) {
+  const percentEncoded = Array.from(atob(cookie))
+    .map(c => '%' + ('00' + c.charCodeAt(0).toString(16)).slice(-2))
+    .join('');
+  return JSON.parse(decodeURIComponent(percentEncoded));
+}
+
 function getUserInfo() {
-  const cookie = Cookies.get('session_token');
+  // The session itself is kept on the server; this cookie only says who it belongs to
+  const cookie = Cookies.get('user_info');
   if (!cookie) return loggedOutUser;
-  const jwt = decode(cookie);
-  const { Email, UserID, FirstName } = jwt.SessionValue;
+  const { Email, UserID, FirstName } = decodeUserInfo(cookie);
   return {
     email: Email,
     userId: UserID,